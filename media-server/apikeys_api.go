@@ -1,12 +1,16 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"net"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"github.com/stevecastle/shrike/auth"
+	"github.com/stevecastle/shrike/renderer"
 )
 
 // requestAuthToken extracts the API credential from a request: the X-API-Key
@@ -22,19 +26,106 @@ func requestAuthToken(r *http.Request) string {
 }
 
 // verifyCredential authenticates either credential kind: lk_-prefixed API
-// keys against the api_keys table, anything else as a JWT.
-func verifyCredential(deps *Dependencies, token string) (*auth.Claims, error) {
+// keys against the api_keys table, anything else as a JWT. An API key must
+// also be unexpired and used from an address its allow-list permits (the
+// connection's address; forwarding headers are not trusted). Its scopes
+// ride along in the claims for the auth middleware (denyByScope) and
+// requestAccess to enforce.
+func verifyCredential(deps *Dependencies, r *http.Request, token string) (*auth.Claims, error) {
 	if strings.HasPrefix(token, auth.APIKeyPrefix) {
-		return deps.Auth.VerifyAPIKey(token)
+		claims, err := deps.Auth.VerifyAPIKey(token)
+		if err != nil {
+			return nil, err
+		}
+		if err := claims.CheckAddr(remoteIP(r)); err != nil {
+			return nil, err
+		}
+		return claims, nil
 	}
 	return deps.Auth.VerifyToken(token)
 }
 
+// remoteIP is the connection's peer address.
+func remoteIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
+
+// keyScopesKey carries an API key's scopes on the request context once the
+// auth middleware has admitted it.
+type keyScopesKey struct{}
+
+// withKeyScopes attaches claims' key scopes to r (a no-op for JWTs and
+// unscoped keys).
+func withKeyScopes(r *http.Request, claims *auth.Claims) *http.Request {
+	if len(claims.Scopes) == 0 {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), keyScopesKey{}, claims.Scopes))
+}
+
+// requestScopeAllows reports whether the request's credential may do need.
+// Anything but a scoped API key may.
+func requestScopeAllows(r *http.Request, need string) bool {
+	scopes, _ := r.Context().Value(keyScopesKey{}).([]string)
+	return auth.ScopeAllows(scopes, need)
+}
+
+// routeScope maps a route to the scope a scoped API key needs for it:
+// the SQL console and job routes have their own scopes; otherwise the
+// route's role decides (read, curation, or admin).
+func routeScope(r *http.Request, required renderer.AuthRole) string {
+	p := r.URL.Path
+	switch {
+	case p == "/api/db/query":
+		return auth.ScopeDBQuery
+	case p == "/create", p == "/jobs" || strings.HasPrefix(p, "/jobs/"), strings.HasPrefix(p, "/job/"), strings.HasPrefix(p, "/api/jobs/"):
+		return auth.ScopeJobsRun
+	case p == "/stream":
+		return streamRouteScope(r)
+	}
+	switch required {
+	case renderer.RolePublic, renderer.RolePublicRead:
+		return auth.ScopeMediaRead
+	case renderer.RoleCurator:
+		return auth.ScopeTagsWrite
+	}
+	return auth.ScopeAdmin
+}
+
+// denyByScope answers 403 and returns true when a scoped API key lacks the
+// scope for this route. /create only needs some jobs:run scope here;
+// createJobHandler narrows it to the requested task.
+func denyByScope(w http.ResponseWriter, r *http.Request, claims *auth.Claims, required renderer.AuthRole) bool {
+	if len(claims.Scopes) == 0 {
+		return false
+	}
+	need := routeScope(r, required)
+	ok := auth.ScopeAllows(claims.Scopes, need)
+	if !ok && r.URL.Path == "/create" {
+		// The task is in the body; createJobHandler checks jobs:run:<task>.
+		ok = auth.ScopeCoversAny(claims.Scopes, need)
+	}
+	if ok {
+		return false
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error":   "insufficient_scope",
+		"message": "this API key needs the " + need + " scope",
+	})
+	return true
+}
+
 // requestUsername resolves the authenticated user for a request that already
 // passed the auth middleware (header credential first, then cookie).
 func requestUsername(deps *Dependencies, r *http.Request) string {
 	if token := requestAuthToken(r); token != "" {
-		if claims, err := verifyCredential(deps, token); err == nil {
+		if claims, err := verifyCredential(deps, r, token); err == nil {
 			return claims.Username
 		}
 	}
@@ -47,7 +138,8 @@ func requestUsername(deps *Dependencies, r *http.Request) string {
 }
 
 // apiKeysHandler implements /auth/keys: GET lists keys, POST creates one
-// (plaintext returned exactly once), DELETE ?id= revokes. Registered behind
+// (plaintext returned exactly once; optional scopes, expires_at, and
+// allowed_cidrs narrow it), DELETE ?id= revokes. Registered behind
 // RoleAdmin, unlike /auth/users which must stay public for first-run setup.
 func apiKeysHandler(deps *Dependencies) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -66,8 +158,11 @@ func apiKeysHandler(deps *Dependencies) http.HandlerFunc {
 
 		case http.MethodPost:
 			var req struct {
-				Name     string `json:"name"`
-				Username string `json:"username"`
+				Name         string   `json:"name"`
+				Username     string   `json:"username"`
+				Scopes       []string `json:"scopes"`
+				ExpiresAt    int64    `json:"expires_at"`
+				AllowedCIDRs []string `json:"allowed_cidrs"`
 			}
 			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 				http.Error(w, "Invalid JSON", http.StatusBadRequest)
@@ -85,24 +180,37 @@ func apiKeysHandler(deps *Dependencies) http.HandlerFunc {
 				http.Error(w, "Username required", http.StatusBadRequest)
 				return
 			}
-			plaintext, key, err := deps.Auth.CreateAPIKey(username, req.Name)
+			plaintext, key, err := deps.Auth.CreateScopedAPIKey(username, req.Name, auth.APIKeyOptions{
+				Scopes:     req.Scopes,
+				ExpiresAt:  req.ExpiresAt,
+				AllowedIPs: req.AllowedCIDRs,
+			})
 			if err != nil {
 				status := http.StatusInternalServerError
-				if err == auth.ErrUserNotFound {
+				switch {
+				case errors.Is(err, auth.ErrUserNotFound), errors.Is(err, auth.ErrInvalidScope),
+					errors.Is(err, auth.ErrInvalidCIDR), errors.Is(err, auth.ErrAPIKeyExpiry),
+					errors.Is(err, auth.ErrAPIKeyName), errors.Is(err, auth.ErrAPIKeyOwner):
 					status = http.StatusBadRequest
 				}
 				http.Error(w, err.Error(), status)
 				return
 			}
+			auditLog(deps, r, "apikey.create", []string{"apikey:" + strconv.FormatInt(key.ID, 10)}, nil,
+				map[string]any{"name": key.Name, "username": key.Username, "scopes": key.Scopes,
+					"expires_at": key.ExpiresAt, "allowed_cidrs": key.AllowedIPs})
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusCreated)
 			json.NewEncoder(w).Encode(map[string]interface{}{
-				"status":   "created",
-				"key":      plaintext,
-				"id":       key.ID,
-				"name":     key.Name,
-				"username": key.Username,
-				"prefix":   key.Prefix,
+				"status":        "created",
+				"key":           plaintext,
+				"id":            key.ID,
+				"name":          key.Name,
+				"username":      key.Username,
+				"prefix":        key.Prefix,
+				"scopes":        key.Scopes,
+				"expires_at":    key.ExpiresAt,
+				"allowed_cidrs": key.AllowedIPs,
 			})
 
 		case http.MethodDelete:
@@ -115,6 +223,7 @@ func apiKeysHandler(deps *Dependencies) http.HandlerFunc {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
 			}
+			auditLog(deps, r, "apikey.delete", []string{"apikey:" + strconv.FormatInt(id, 10)}, nil, nil)
 			w.Header().Set("Content-Type", "application/json")
 			w.Write([]byte(`{"status":"deleted"}`))
 
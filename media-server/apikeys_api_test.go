@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stevecastle/shrike/auth"
 	"github.com/stevecastle/shrike/renderer"
@@ -24,7 +26,22 @@ func newAPIKeyTestDeps(t *testing.T) *Dependencies {
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			username TEXT UNIQUE NOT NULL,
 			password_hash TEXT NOT NULL,
-			created_at INTEGER
+			created_at INTEGER,
+			role TEXT NOT NULL DEFAULT 'admin',
+			roots TEXT NOT NULL DEFAULT '',
+			totp_secret TEXT NOT NULL DEFAULT '',
+			totp_enabled INTEGER NOT NULL DEFAULT 0,
+			totp_last_step INTEGER NOT NULL DEFAULT 0,
+			sso_subject TEXT NOT NULL DEFAULT '',
+			sso_created INTEGER NOT NULL DEFAULT 0,
+			library_profile INTEGER NOT NULL DEFAULT 0
+		)`,
+		`CREATE TABLE user_recovery_codes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			code_hash TEXT NOT NULL UNIQUE,
+			created_at INTEGER NOT NULL,
+			used_at INTEGER NOT NULL DEFAULT 0
 		)`,
 		`CREATE TABLE api_keys (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -33,7 +50,10 @@ func newAPIKeyTestDeps(t *testing.T) *Dependencies {
 			key_hash TEXT UNIQUE NOT NULL,
 			prefix TEXT NOT NULL,
 			created_at INTEGER,
-			last_used_at INTEGER
+			last_used_at INTEGER,
+			scopes TEXT NOT NULL DEFAULT '',
+			expires_at INTEGER NOT NULL DEFAULT 0,
+			allowed_cidrs TEXT NOT NULL DEFAULT ''
 		)`,
 	} {
 		if _, err := db.Exec(stmt); err != nil {
@@ -79,7 +99,7 @@ func TestAPIKeysHandler_CreateListRevoke(t *testing.T) {
 	}
 
 	// The minted key authenticates through the shared credential dispatch.
-	claims, err := verifyCredential(deps, created.Key)
+	claims, err := verifyCredential(deps, newReqNoAuth(), created.Key)
 	if err != nil || claims.Username != "steve" {
 		t.Fatalf("verifyCredential: claims = %+v, err = %v", claims, err)
 	}
@@ -104,7 +124,7 @@ func TestAPIKeysHandler_CreateListRevoke(t *testing.T) {
 	if rr.Code != http.StatusOK {
 		t.Fatalf("revoke status = %d; body = %s", rr.Code, rr.Body.String())
 	}
-	if _, err := verifyCredential(deps, created.Key); err == nil {
+	if _, err := verifyCredential(deps, newReqNoAuth(), created.Key); err == nil {
 		t.Error("revoked key still verifies")
 	}
 }
@@ -213,3 +233,77 @@ func TestRequestAuthToken(t *testing.T) {
 		t.Errorf("X-API-Key should win: got %q", got)
 	}
 }
+
+// TestAuthMiddleware_ScopedKey checks that a scoped key only reaches routes
+// its scopes cover, and that expiry and the address allow-list are enforced.
+func TestAuthMiddleware_ScopedKey(t *testing.T) {
+	deps := newAPIKeyTestDeps(t)
+	create := func(body string) string {
+		t.Helper()
+		rr := doAPIKeys(t, deps, http.MethodPost, "/auth/keys", body)
+		var created struct {
+			Key string `json:"key"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil || created.Key == "" {
+			t.Fatalf("create key: %v; status = %d; body = %s", err, rr.Code, rr.Body.String())
+		}
+		return created.Key
+	}
+	reader := create(`{"name":"r","username":"steve","scopes":["media:read"]}`)
+	dbKey := create(`{"name":"q","username":"steve","scopes":["db:query"]}`)
+	pinned := create(`{"name":"p","username":"steve","allowed_cidrs":["10.0.0.0/8"]}`)
+
+	send := func(key, path string, role renderer.AuthRole, remote string) int {
+		h := authMiddleware(deps, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}), role)
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("X-API-Key", key)
+		if remote != "" {
+			req.RemoteAddr = remote
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	cases := []struct {
+		name, key, path string
+		role            renderer.AuthRole
+		remote          string
+		want            int
+	}{
+		{"reader on read route", reader, "/media/api", renderer.RolePublicRead, "", http.StatusOK},
+		{"reader on curation route", reader, "/api/tags/rename", renderer.RoleCurator, "", http.StatusForbidden},
+		{"reader on admin route", reader, "/config", renderer.RoleAdmin, "", http.StatusForbidden},
+		{"reader on jobs", reader, "/jobs/list", renderer.RoleAdmin, "", http.StatusForbidden},
+		{"db key on console", dbKey, "/api/db/query", renderer.RoleAdmin, "", http.StatusOK},
+		{"db key on media", dbKey, "/media/api", renderer.RolePublicRead, "", http.StatusForbidden},
+		{"pinned key inside", pinned, "/config", renderer.RoleAdmin, "10.1.2.3:5555", http.StatusOK},
+		{"pinned key outside", pinned, "/config", renderer.RoleAdmin, "192.0.2.1:5555", http.StatusUnauthorized},
+	}
+	for _, c := range cases {
+		if got := send(c.key, c.path, c.role, c.remote); got != c.want {
+			t.Errorf("%s: status = %d, want %d", c.name, got, c.want)
+		}
+	}
+
+	expired := create(`{"name":"e","username":"steve","expires_at":` + strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10) + `}`)
+	if _, err := deps.DB.Exec("UPDATE api_keys SET expires_at = ? WHERE name = 'e'", time.Now().Add(-time.Minute).Unix()); err != nil {
+		t.Fatal(err)
+	}
+	if got := send(expired, "/config", renderer.RoleAdmin, ""); got != http.StatusUnauthorized {
+		t.Errorf("expired key: status = %d, want 401", got)
+	}
+
+	for name, body := range map[string]string{
+		"unknown scope":  `{"name":"x","username":"steve","scopes":["everything"]}`,
+		"bad address":    `{"name":"x","username":"steve","allowed_cidrs":["not-an-ip"]}`,
+		"expiry in past": `{"name":"x","username":"steve","expires_at":1}`,
+	} {
+		if rr := doAPIKeys(t, deps, http.MethodPost, "/auth/keys", body); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", name, rr.Code)
+		}
+	}
+}
@@ -60,6 +60,318 @@ type ByoFaceModel struct {
 	MatchThreshold float64 `json:"matchThreshold,omitempty"`
 }
 
+// ByoEmbedModel declares a bring-your-own visual-embedding model (CLIP ViT-L,
+// EVA-CLIP, ...) usable as the active EmbeddingModel. The image encoder runs
+// through the same embed subprocess as the built-ins, so every preprocessing
+// knob the subprocess accepts is exposed here. Vectors are stored keyed by ID
+// in media_embedding alongside the built-in models.
+type ByoEmbedModel struct {
+	ID   string `json:"id"`             // unique; vectors are stored keyed by this
+	Name string `json:"name,omitempty"` // display name (defaults to ID)
+	Dim  int    `json:"dim"`            // output embedding dimension
+	// ImageModelPath is the absolute path to the image-encoder ONNX file.
+	ImageModelPath string `json:"imageModelPath"`
+	// Tensor names; default "pixel_values"/"pooler_output" when empty.
+	InputName  string `json:"inputName,omitempty"`
+	OutputName string `json:"outputName,omitempty"`
+	// Square input edge; default 224 when 0. Width/Height override per axis.
+	Size   int `json:"size,omitempty"`
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+	// Mean/Std are per-channel RGB on the 0..1 scale (CLIP: 0.481,0.458,0.408
+	// / 0.269,0.261,0.276). Empty means SigLIP's 0.5/0.5.
+	Mean []float64 `json:"mean,omitempty"`
+	Std  []float64 `json:"std,omitempty"`
+	// CropPct < 1 enables a center crop of that fraction before resizing.
+	CropPct float64 `json:"cropPct,omitempty"`
+	// Pooling is "none" (output is already [1,dim]) or "cls" (take token 0 of
+	// a [1,N,dim] sequence). Default "none".
+	Pooling string `json:"pooling,omitempty"`
+
+	// Optional text encoder for multimodal models (text->image search). Both
+	// paths must be set together; the tokenizer is a SentencePiece model.
+	TextModelPath string `json:"textModelPath,omitempty"`
+	TokenizerPath string `json:"tokenizerPath,omitempty"`
+	TextInput     string `json:"textInput,omitempty"`  // default "input_ids"
+	TextOutput    string `json:"textOutput,omitempty"` // default "pooler_output"
+	SeqLen        int    `json:"seqLen,omitempty"`     // default 64
+}
+
+// ByoTaggerModel declares a bring-your-own auto-tagging model (e.g. another WD
+// tagger export) usable as the active AutotagModel. A timm-style ConfigPath
+// supplies the preprocessing when set; otherwise the explicit fields below are
+// forwarded to the onnxtag subprocess.
+type ByoTaggerModel struct {
+	ID   string `json:"id"`             // unique; selectable as autotagModel
+	Name string `json:"name,omitempty"` // display name (defaults to ID)
+	// ModelPath is the absolute path to the tagger ONNX file.
+	ModelPath string `json:"modelPath"`
+	// LabelsPath is the selected_tags.csv-style label file (row order matches
+	// the model outputs). ConfigPath is an optional timm config.json.
+	LabelsPath string `json:"labelsPath"`
+	ConfigPath string `json:"configPath,omitempty"`
+	// Tensor names; onnxtag's defaults ("input"/"output") when empty.
+	InputName  string `json:"inputName,omitempty"`
+	OutputName string `json:"outputName,omitempty"`
+	// Square input edge; 0 = onnxtag default (224). Ignored when ConfigPath
+	// declares an input size.
+	Size int `json:"size,omitempty"`
+	// Mean/Std are per-channel RGB on the 0..1 scale.
+	Mean []float64 `json:"mean,omitempty"`
+	Std  []float64 `json:"std,omitempty"`
+	// Layout "NCHW"/"NHWC", ColorOrder "RGB"/"BGR", PixelRange "0_1"/"0_255".
+	Layout     string `json:"layout,omitempty"`
+	ColorOrder string `json:"colorOrder,omitempty"`
+	PixelRange string `json:"pixelRange,omitempty"`
+	PadSquare  bool   `json:"padSquare,omitempty"`
+}
+
+// LLMTagCategory is one category of the llm-tag op's output schema. The
+// vision model fills each category with labels; a non-empty Vocabulary
+// restricts it to those labels (an enum in the JSON schema), an empty one
+// leaves it free-form. Labels are written as tags in the category Name.
+type LLMTagCategory struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"` // shown to the model
+	Vocabulary  []string `json:"vocabulary,omitempty"`
+	// MaxLabels caps the labels kept per item; 0 = 5.
+	MaxLabels int `json:"maxLabels,omitempty"`
+}
+
+// TextEmbedModel declares the local ONNX sentence encoder behind semantic
+// search over descriptions and transcripts (the `textembed` task and the
+// semantic: query predicate). It is separate from the visual EmbeddingModel:
+// a small multilingual encoder (multilingual-e5-small, MiniLM, ...) exported
+// with input_ids + attention_mask inputs and a token-level last_hidden_state
+// output that is mean-pooled over the mask. Chunk vectors are stored keyed by
+// ID, so swapping encoders is non-destructive like the visual models.
+type TextEmbedModel struct {
+	ID  string `json:"id"`  // chunk vectors are stored keyed by this
+	Dim int    `json:"dim"` // hidden size (384 for e5-small / MiniLM-L12)
+	// ModelPath is the absolute path to the encoder ONNX file; TokenizerPath
+	// is its SentencePiece model (sentencepiece.bpe.model for the XLM-R
+	// vocabulary the e5 family uses).
+	ModelPath     string `json:"modelPath"`
+	TokenizerPath string `json:"tokenizerPath"`
+	// Tensor names; default "input_ids"/"attention_mask"/"last_hidden_state".
+	InputName  string `json:"inputName,omitempty"`
+	MaskName   string `json:"maskName,omitempty"`
+	OutputName string `json:"outputName,omitempty"`
+	SeqLen     int    `json:"seqLen,omitempty"`  // default 256
+	Pooling    string `json:"pooling,omitempty"` // "mean" (default) or "cls"
+	// QueryPrefix / PassagePrefix are prepended to search text and indexed
+	// chunks respectively — e5 models expect "query: " and "passage: ".
+	QueryPrefix   string `json:"queryPrefix,omitempty"`
+	PassagePrefix string `json:"passagePrefix,omitempty"`
+}
+
+// Configured reports whether a text encoder has been declared at all.
+func (t TextEmbedModel) Configured() bool {
+	return strings.TrimSpace(t.ModelPath) != "" || strings.TrimSpace(t.ID) != ""
+}
+
+// SSOConfig enables sign-in without local passwords, alongside them. Either
+// mode provisions an account on first sign-in and sets its role from the
+// identity's groups via RoleGroups/DefaultRole on every sign-in.
+type SSOConfig struct {
+	// TrustedHeader names the header a reverse proxy puts the signed-in
+	// username in (e.g. "X-Forwarded-User"); "" disables the mode. It is
+	// believed only on connections from TrustedProxies (IPs or CIDRs).
+	// TrustedGroupsHeader optionally carries comma-separated groups.
+	TrustedHeader       string   `json:"trustedHeader"`
+	TrustedGroupsHeader string   `json:"trustedGroupsHeader"`
+	TrustedProxies      []string `json:"trustedProxies"`
+
+	// OIDC authorization-code flow (with PKCE) against OIDCIssuer; ""
+	// disables it. OIDCRedirectURL defaults to <this server>/auth/oidc/callback
+	// as the browser sees it — set it when a proxy rewrites the host.
+	OIDCIssuer        string   `json:"oidcIssuer"`
+	OIDCClientID      string   `json:"oidcClientId"`
+	OIDCClientSecret  string   `json:"oidcClientSecret"`
+	OIDCRedirectURL   string   `json:"oidcRedirectUrl"`
+	OIDCScopes        []string `json:"oidcScopes,omitempty"`        // default openid profile email
+	OIDCUsernameClaim string   `json:"oidcUsernameClaim,omitempty"` // default preferred_username
+	OIDCGroupsClaim   string   `json:"oidcGroupsClaim,omitempty"`   // default groups
+
+	// RoleGroups maps a group name to viewer/curator/admin; an identity
+	// gets the highest role among its groups. DefaultRole applies when no
+	// group matches; "" refuses such identities.
+	RoleGroups  map[string]string `json:"roleGroups"`
+	DefaultRole string            `json:"defaultRole"`
+}
+
+// OIDCEnabled reports whether the OIDC flow is configured.
+func (s SSOConfig) OIDCEnabled() bool {
+	return strings.TrimSpace(s.OIDCIssuer) != "" && strings.TrimSpace(s.OIDCClientID) != ""
+}
+
+// Validate reports the first problem that would keep the encoder from running.
+func (t TextEmbedModel) Validate() error {
+	switch {
+	case strings.TrimSpace(t.ID) == "":
+		return fmt.Errorf("id is required")
+	case strings.TrimSpace(t.ModelPath) == "":
+		return fmt.Errorf("modelPath is required")
+	case strings.TrimSpace(t.TokenizerPath) == "":
+		return fmt.Errorf("tokenizerPath is required")
+	case t.Dim <= 0:
+		return fmt.Errorf("dim must be positive")
+	case t.SeqLen < 0:
+		return fmt.Errorf("seqLen must not be negative")
+	}
+	switch strings.ToLower(strings.TrimSpace(t.Pooling)) {
+	case "", "mean", "cls":
+	default:
+		return fmt.Errorf("pooling must be \"mean\" or \"cls\", got %q", t.Pooling)
+	}
+	return nil
+}
+
+// validateRGB reports whether a mean/std list is either unset or a triple.
+func validateRGB(field string, v []float64) error {
+	if len(v) != 0 && len(v) != 3 {
+		return fmt.Errorf("%s must have 3 values, got %d", field, len(v))
+	}
+	return nil
+}
+
+// Validate reports the first problem that would keep the entry from running.
+func (b ByoFaceModel) Validate() error {
+	switch {
+	case strings.TrimSpace(b.ID) == "":
+		return fmt.Errorf("id is required")
+	case strings.TrimSpace(b.ModelPath) == "":
+		return fmt.Errorf("modelPath is required")
+	case b.Dim <= 0:
+		return fmt.Errorf("dim must be positive")
+	}
+	if err := validateRGB("mean", b.Mean); err != nil {
+		return err
+	}
+	return validateRGB("std", b.Std)
+}
+
+// Validate reports the first problem that would keep the entry from running.
+func (b ByoEmbedModel) Validate() error {
+	switch {
+	case strings.TrimSpace(b.ID) == "":
+		return fmt.Errorf("id is required")
+	case strings.TrimSpace(b.ImageModelPath) == "":
+		return fmt.Errorf("imageModelPath is required")
+	case b.Dim <= 0:
+		return fmt.Errorf("dim must be positive")
+	case b.Size < 0 || b.Width < 0 || b.Height < 0:
+		return fmt.Errorf("size must not be negative")
+	case b.CropPct < 0 || b.CropPct > 1:
+		return fmt.Errorf("cropPct must be in (0, 1]")
+	case (b.TextModelPath == "") != (b.TokenizerPath == ""):
+		return fmt.Errorf("textModelPath and tokenizerPath must be set together")
+	}
+	switch strings.ToLower(strings.TrimSpace(b.Pooling)) {
+	case "", "none", "cls":
+	default:
+		return fmt.Errorf("pooling must be \"none\" or \"cls\", got %q", b.Pooling)
+	}
+	if err := validateRGB("mean", b.Mean); err != nil {
+		return err
+	}
+	return validateRGB("std", b.Std)
+}
+
+// Validate reports the first problem that would keep the entry from running.
+func (b ByoTaggerModel) Validate() error {
+	switch {
+	case strings.TrimSpace(b.ID) == "":
+		return fmt.Errorf("id is required")
+	case strings.TrimSpace(b.ModelPath) == "":
+		return fmt.Errorf("modelPath is required")
+	case strings.TrimSpace(b.LabelsPath) == "":
+		return fmt.Errorf("labelsPath is required")
+	case b.Size < 0:
+		return fmt.Errorf("size must not be negative")
+	}
+	switch strings.ToUpper(strings.TrimSpace(b.Layout)) {
+	case "", "NCHW", "NHWC":
+	default:
+		return fmt.Errorf("layout must be NCHW or NHWC, got %q", b.Layout)
+	}
+	switch strings.ToUpper(strings.TrimSpace(b.ColorOrder)) {
+	case "", "RGB", "BGR":
+	default:
+		return fmt.Errorf("colorOrder must be RGB or BGR, got %q", b.ColorOrder)
+	}
+	switch strings.TrimSpace(b.PixelRange) {
+	case "", "0_1", "0_255":
+	default:
+		return fmt.Errorf("pixelRange must be 0_1 or 0_255, got %q", b.PixelRange)
+	}
+	if err := validateRGB("mean", b.Mean); err != nil {
+		return err
+	}
+	return validateRGB("std", b.Std)
+}
+
+// builtinModelIDs holds, per BYO list ("byoFaceModels", "byoEmbedModels",
+// "byoTaggerModels"), the IDs of the models that ship with the server. The
+// tasks package registers them; appconfig can't import it.
+var builtinModelIDs = map[string]map[string]bool{}
+
+// RegisterBuiltinModelIDs records the built-in model IDs a BYO list may not
+// reuse. Call during package initialization only — it is read without
+// synchronization.
+func RegisterBuiltinModelIDs(list string, ids []string) {
+	set := builtinModelIDs[list]
+	if set == nil {
+		set = map[string]bool{}
+		builtinModelIDs[list] = set
+	}
+	for _, id := range ids {
+		set[id] = true
+	}
+}
+
+// ValidateByoModels checks every bring-your-own entry (face, embed, tagger,
+// and the text encoder) and returns one message per invalid or duplicate
+// entry, including one that reuses a built-in model's ID. Invalid entries
+// are kept in the config (so a typo doesn't silently delete the user's
+// work) but the model registries skip them.
+func ValidateByoModels(c Config) []string {
+	var problems []string
+	check := func(kind string, i int, id string, err error, seen map[string]bool) {
+		id = strings.TrimSpace(id)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s[%d] %q: %v", kind, i, id, err))
+			return
+		}
+		if builtinModelIDs[kind][id] {
+			problems = append(problems, fmt.Sprintf("%s[%d] %q: id is taken by a built-in model", kind, i, id))
+			return
+		}
+		if seen[id] {
+			problems = append(problems, fmt.Sprintf("%s[%d] %q: duplicate id", kind, i, id))
+		}
+		seen[id] = true
+	}
+	seen := map[string]bool{}
+	for i, b := range c.ByoFaceModels {
+		check("byoFaceModels", i, b.ID, b.Validate(), seen)
+	}
+	seen = map[string]bool{}
+	for i, b := range c.ByoEmbedModels {
+		check("byoEmbedModels", i, b.ID, b.Validate(), seen)
+	}
+	seen = map[string]bool{}
+	for i, b := range c.ByoTaggerModels {
+		check("byoTaggerModels", i, b.ID, b.Validate(), seen)
+	}
+	if t := c.TextEmbedModel; t.Configured() {
+		if err := t.Validate(); err != nil {
+			problems = append(problems, fmt.Sprintf("textEmbedModel %q: %v", strings.TrimSpace(t.ID), err))
+		}
+	}
+	return problems
+}
+
 // DefaultEmbeddingModel is the visual-embedding model used when none is
 // configured. Must match an ID in the tasks package's embed-model registry.
 // Kept as a literal here (not imported from tasks) so appconfig stays a leaf
@@ -146,6 +458,17 @@ type Config struct {
 	LlamaCppModel   string `json:"llamacppModel"`
 	LlamaCppAPIKey  string `json:"llamacppApiKey"`
 
+	// OpenAI-compatible vision settings. Active when InferenceProvider ==
+	// "openai": any hosted or self-run /v1/chat/completions service (OpenAI
+	// itself, vLLM, OpenRouter, a gateway). The base URL excludes /v1.
+	OpenAIBaseURL string `json:"openaiBaseUrl"`
+	OpenAIModel   string `json:"openaiModel"`
+	OpenAIAPIKey  string `json:"openaiApiKey"`
+
+	// LLMTagCategories is the llm-tag op's per-category output schema.
+	// Empty uses the built-in setting/objects/mood/people schema.
+	LLMTagCategories []LLMTagCategory `json:"llmTagCategories,omitempty"`
+
 	// Per-provider concurrency caps. Drives the jobqueue host-bucket limit
 	// for the corresponding inference bucket — a single GPU local install
 	// typically wants 1 at a time, while RunPod serverless can absorb many
@@ -156,6 +479,7 @@ type Config struct {
 		RunPod   int `json:"runpod"`
 		LMStudio int `json:"lmstudio"`
 		LlamaCpp int `json:"llamacpp"`
+		OpenAI   int `json:"openai"`
 	} `json:"inferenceConcurrency"`
 
 	// AutoProcessOps is the comma-separated per-item op list the scheduled
@@ -180,6 +504,18 @@ type Config struct {
 	// not consume a slot. Values <= 0 fall back to the default.
 	LocalComputeConcurrency int `json:"localComputeConcurrency"`
 
+	// ResultCacheMaxMB caps the content-addressed inference result cache
+	// (descriptions, LLM tags, transcripts; see package resultcache) in
+	// MiB; least-recently-used results are evicted past it. 0 falls back
+	// to the default; a negative value turns the cache off.
+	ResultCacheMaxMB int `json:"resultCacheMaxMb"`
+
+	// JobLogMaxLines caps how many log lines are kept per job; the oldest
+	// are dropped past it. JobLogMaxLineBytes truncates longer lines.
+	// Values <= 0 fall back to the defaults.
+	JobLogMaxLines     int `json:"jobLogMaxLines"`
+	JobLogMaxLineBytes int `json:"jobLogMaxLineBytes"`
+
 	// ONNX tagger settings
 	OnnxTagger struct {
 		ModelPath            string  `json:"modelPath"`
@@ -263,6 +599,17 @@ type Config struct {
 	// file; entries here make it selectable as FaceModel.
 	ByoFaceModels []ByoFaceModel `json:"byoFaceModels,omitempty"`
 
+	// Bring-your-own embedding and tagging models (CLIP ViT-L, EVA-CLIP, a
+	// different WD tagger, ...). Entries here become selectable as
+	// EmbeddingModel / AutotagModel without patching the compiled-in
+	// registries. IDs may not shadow a built-in model.
+	ByoEmbedModels  []ByoEmbedModel  `json:"byoEmbedModels,omitempty"`
+	ByoTaggerModels []ByoTaggerModel `json:"byoTaggerModels,omitempty"`
+
+	// Sentence encoder for semantic description/transcript search. Unset
+	// (zero value) disables the textembed task and the semantic: predicate.
+	TextEmbedModel TextEmbedModel `json:"textEmbedModel"`
+
 	// Per-file processing timeout (seconds) for the local ONNX tasks (embed,
 	// autotag). A single file that exceeds this — e.g. a corrupt image stuck in
 	// decode or a bad video stuck in frame extraction — is skipped and the job
@@ -271,8 +618,8 @@ type Config struct {
 	OnnxFileTimeoutSeconds int `json:"onnxFileTimeoutSeconds"`
 
 	// Transcription settings. Provider names an implementation in the
-	// transcribe package's registry ("whisper-cli" today; HTTP or other
-	// engines can register later). Model is provider-specific ("" = provider
+	// transcribe package's registry: "whisper-cli" (local Faster-Whisper),
+	// "openai-http", or "whisper-server". Model is provider-specific ("" = provider
 	// default), Language is an ISO hint ("" = auto-detect), VADFilter trims
 	// non-speech before transcribing.
 	TranscriptionProvider  string `json:"transcriptionProvider"`
@@ -290,10 +637,35 @@ type Config struct {
 	TranscriptionHotwords      string `json:"transcriptionHotwords"`
 	TranscriptionVocalExtract  bool   `json:"transcriptionVocalExtract"`
 
+	// HTTP transcription services. The "openai-http" provider posts to
+	// <TranscriptionOpenAIBaseURL>/v1/audio/transcriptions (OpenAI, speaches,
+	// LocalAI, ...); "whisper-server" posts to a whisper.cpp `server` at
+	// <TranscriptionWhisperServerURL>/inference. TranscriptionHTTPConcurrency
+	// caps concurrent transcribe jobs against whichever one is active.
+	TranscriptionOpenAIBaseURL    string `json:"transcriptionOpenaiBaseUrl"`
+	TranscriptionOpenAIAPIKey     string `json:"transcriptionOpenaiApiKey"`
+	TranscriptionWhisperServerURL string `json:"transcriptionWhisperServerUrl"`
+	TranscriptionHTTPConcurrency  int    `json:"transcriptionHttpConcurrency"`
+
+	// Speaker diarization. When TranscriptionDiarize is on, transcribe jobs
+	// label cues "Speaker 1..N" after transcribing, using the ONNX
+	// speaker-embedding model at DiarizationModelPath (WeSpeaker or
+	// 3D-Speaker exports taking 80-bin fbank features). DiarizationThreshold
+	// is the cosine similarity at which two voices count as the same speaker.
+	TranscriptionDiarize bool    `json:"transcriptionDiarize"`
+	DiarizationModelPath string  `json:"diarizationModelPath"`
+	DiarizationThreshold float64 `json:"diarizationThreshold"`
+
 	// Optional path to a user-supplied faster-whisper executable. Overrides
 	// the binary installed via the Dependencies downloader.
 	FasterWhisperPath string `json:"fasterWhisperPath"`
 
+	// ModelMirror, when set, is a base URL that replaces the public hosts in
+	// the model manifest: https://host/path is fetched from
+	// <ModelMirror>/host/path, the layout `wget --mirror` produces. For
+	// networks without internet access; see also `lokictl deps import`.
+	ModelMirror string `json:"modelMirror"`
+
 	// Discord authentication token for media export
 	DiscordToken string `json:"discordToken"`
 
@@ -320,6 +692,14 @@ type Config struct {
 	// renderer.RolePublicRead, so toggling it needs no restart.
 	AllowPublicAccess bool `json:"allowPublicAccess"`
 
+	// AuditRetentionDays bounds the audit log (every delete, merge, move,
+	// tag/person edit, config save, and import): entries older than this
+	// are pruned daily. 0 keeps them forever.
+	AuditRetentionDays int `json:"auditRetentionDays"`
+
+	// SSO configures reverse-proxy header and OIDC sign-in.
+	SSO SSOConfig `json:"sso"`
+
 	// SwipeFeed tunes the /swipe "For You" algorithmic feed (mode=feed):
 	// lane mix (exploit/fresh/bridge/wildcard), taste clustering, cache
 	// TTLs, and which tag counts as a like. Zero/omitted fields fall back
@@ -417,18 +797,29 @@ func defaultConfig() Config {
 		DescribePrompt:         "Please describe this image, paying special attention to the people, the color of hair, clothing, items, text and captions, and actions being performed.",
 		LMStudioBaseURL:        "http://localhost:1234",
 		LlamaCppBaseURL:        "http://localhost:8080",
+		OpenAIBaseURL:          "https://api.openai.com",
+		// speaches' and whisper.cpp server's default listen addresses.
+		TranscriptionOpenAIBaseURL:    "http://localhost:8000",
+		TranscriptionWhisperServerURL: "http://127.0.0.1:8080",
+		TranscriptionHTTPConcurrency:  1, // a self-hosted server usually has one GPU
+		DiarizationThreshold:          0.5,
 		InferenceConcurrency: struct {
 			Ollama   int `json:"ollama"`
 			RunPod   int `json:"runpod"`
 			LMStudio int `json:"lmstudio"`
 			LlamaCpp int `json:"llamacpp"`
+			OpenAI   int `json:"openai"`
 		}{
 			Ollama:   1, // local single-GPU Ollama: one at a time
 			RunPod:   4, // serverless scales out per request
 			LMStudio: 1, // local single-GPU LM Studio: one at a time
 			LlamaCpp: 1, // local single-GPU llama.cpp: one at a time
+			OpenAI:   4, // hosted API: rate limits, not a GPU, are the cap
 		},
 		LocalComputeConcurrency: 1, // one heavy local model workload at a time
+		ResultCacheMaxMB:        512,
+		JobLogMaxLines:          100000,
+		JobLogMaxLineBytes:      16 * 1024,
 		OnnxTagger: struct {
 			ModelPath            string  `json:"modelPath"`
 			LabelsPath           string  `json:"labelsPath"`
@@ -741,15 +1132,42 @@ func Load() (Config, string, error) {
 	if c.InferenceConcurrency.LlamaCpp <= 0 {
 		c.InferenceConcurrency.LlamaCpp = def.InferenceConcurrency.LlamaCpp
 	}
+	if c.InferenceConcurrency.OpenAI <= 0 {
+		c.InferenceConcurrency.OpenAI = def.InferenceConcurrency.OpenAI
+	}
 	if c.LocalComputeConcurrency <= 0 {
 		c.LocalComputeConcurrency = def.LocalComputeConcurrency
 	}
+	if c.ResultCacheMaxMB == 0 {
+		c.ResultCacheMaxMB = def.ResultCacheMaxMB
+	}
+	if c.JobLogMaxLines <= 0 {
+		c.JobLogMaxLines = def.JobLogMaxLines
+	}
+	if c.JobLogMaxLineBytes <= 0 {
+		c.JobLogMaxLineBytes = def.JobLogMaxLineBytes
+	}
 	if c.LMStudioBaseURL == "" {
 		c.LMStudioBaseURL = def.LMStudioBaseURL
 	}
 	if c.LlamaCppBaseURL == "" {
 		c.LlamaCppBaseURL = def.LlamaCppBaseURL
 	}
+	if c.OpenAIBaseURL == "" {
+		c.OpenAIBaseURL = def.OpenAIBaseURL
+	}
+	if c.TranscriptionOpenAIBaseURL == "" {
+		c.TranscriptionOpenAIBaseURL = def.TranscriptionOpenAIBaseURL
+	}
+	if c.TranscriptionWhisperServerURL == "" {
+		c.TranscriptionWhisperServerURL = def.TranscriptionWhisperServerURL
+	}
+	if c.TranscriptionHTTPConcurrency <= 0 {
+		c.TranscriptionHTTPConcurrency = def.TranscriptionHTTPConcurrency
+	}
+	if c.DiarizationThreshold <= 0 || c.DiarizationThreshold >= 1 {
+		c.DiarizationThreshold = def.DiarizationThreshold
+	}
 	if c.JWTSecret == "" {
 		c.JWTSecret = uuid.New().String()
 		needsSave = true
@@ -797,6 +1215,12 @@ func Load() (Config, string, error) {
 		c.Roots[0].Path = c.DownloadPath
 	}
 
+	// Bring-your-own model entries are hand-edited JSON; surface mistakes at
+	// load instead of as a confusing "model not installed" mid-job.
+	for _, p := range ValidateByoModels(c) {
+		log.Printf("Warning: ignoring bring-your-own model %s", p)
+	}
+
 	Set(c)
 	return c, path, nil
 }
@@ -883,6 +1307,15 @@ func applyEnvOverrides(c *Config) {
 	if v := os.Getenv("LOWKEY_LLAMACPP_API_KEY"); v != "" {
 		c.LlamaCppAPIKey = v
 	}
+	if v := os.Getenv("LOWKEY_OPENAI_BASE_URL"); v != "" {
+		c.OpenAIBaseURL = v
+	}
+	if v := os.Getenv("LOWKEY_OPENAI_MODEL"); v != "" {
+		c.OpenAIModel = v
+	}
+	if v := os.Getenv("LOWKEY_OPENAI_API_KEY"); v != "" {
+		c.OpenAIAPIKey = v
+	}
 	if v := os.Getenv("LOWKEY_INFERENCE_LMSTUDIO_CONCURRENCY"); v != "" {
 		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && n > 0 {
 			c.InferenceConcurrency.LMStudio = n
@@ -897,6 +1330,13 @@ func applyEnvOverrides(c *Config) {
 			log.Printf("Warning: LOWKEY_INFERENCE_LLAMACPP_CONCURRENCY=%q is not a positive integer; ignored", v)
 		}
 	}
+	if v := os.Getenv("LOWKEY_INFERENCE_OPENAI_CONCURRENCY"); v != "" {
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && n > 0 {
+			c.InferenceConcurrency.OpenAI = n
+		} else {
+			log.Printf("Warning: LOWKEY_INFERENCE_OPENAI_CONCURRENCY=%q is not a positive integer; ignored", v)
+		}
+	}
 	if v := os.Getenv("LOWKEY_TRANSCRIPTION_PROVIDER"); v != "" {
 		c.TranscriptionProvider = v
 	}
@@ -932,6 +1372,42 @@ func applyEnvOverrides(c *Config) {
 			log.Printf("Warning: LOWKEY_TRANSCRIPTION_VOCAL_EXTRACT=%q is not a boolean; ignored", v)
 		}
 	}
+	if v := os.Getenv("LOWKEY_TRANSCRIPTION_OPENAI_BASE_URL"); v != "" {
+		c.TranscriptionOpenAIBaseURL = v
+	}
+	if v := os.Getenv("LOWKEY_TRANSCRIPTION_OPENAI_API_KEY"); v != "" {
+		c.TranscriptionOpenAIAPIKey = v
+	}
+	if v := os.Getenv("LOWKEY_TRANSCRIPTION_WHISPER_SERVER_URL"); v != "" {
+		c.TranscriptionWhisperServerURL = v
+	}
+	if v := os.Getenv("LOWKEY_TRANSCRIPTION_HTTP_CONCURRENCY"); v != "" {
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && n > 0 {
+			c.TranscriptionHTTPConcurrency = n
+		} else {
+			log.Printf("Warning: LOWKEY_TRANSCRIPTION_HTTP_CONCURRENCY=%q is not a positive integer; ignored", v)
+		}
+	}
+	if v := os.Getenv("LOWKEY_TRANSCRIPTION_DIARIZE"); v != "" {
+		switch strings.ToLower(v) {
+		case "true", "1", "yes", "on":
+			c.TranscriptionDiarize = true
+		case "false", "0", "no", "off":
+			c.TranscriptionDiarize = false
+		default:
+			log.Printf("Warning: LOWKEY_TRANSCRIPTION_DIARIZE=%q is not a boolean; ignored", v)
+		}
+	}
+	if v := os.Getenv("LOWKEY_DIARIZATION_MODEL_PATH"); v != "" {
+		c.DiarizationModelPath = v
+	}
+	if v := os.Getenv("LOWKEY_DIARIZATION_THRESHOLD"); v != "" {
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil && f > 0 && f < 1 {
+			c.DiarizationThreshold = f
+		} else {
+			log.Printf("Warning: LOWKEY_DIARIZATION_THRESHOLD=%q is not a number between 0 and 1; ignored", v)
+		}
+	}
 	if v := os.Getenv("LOWKEY_DEFAULT_START_PATH"); v != "" {
 		c.DefaultStartPath = v
 	}
@@ -945,6 +1421,37 @@ func applyEnvOverrides(c *Config) {
 			log.Printf("Warning: LOWKEY_ALLOW_PUBLIC_ACCESS=%q is not a boolean; ignored", v)
 		}
 	}
+	if v := os.Getenv("LOWKEY_AUDIT_RETENTION_DAYS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			c.AuditRetentionDays = n
+		} else {
+			log.Printf("Warning: LOWKEY_AUDIT_RETENTION_DAYS=%q is not a non-negative integer; ignored", v)
+		}
+	}
+	if v := os.Getenv("LOWKEY_TRUSTED_HEADER"); v != "" {
+		c.SSO.TrustedHeader = strings.TrimSpace(v)
+	}
+	if v := os.Getenv("LOWKEY_TRUSTED_GROUPS_HEADER"); v != "" {
+		c.SSO.TrustedGroupsHeader = strings.TrimSpace(v)
+	}
+	if v := os.Getenv("LOWKEY_TRUSTED_PROXIES"); v != "" {
+		c.SSO.TrustedProxies = strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+	}
+	if v := os.Getenv("LOWKEY_OIDC_ISSUER"); v != "" {
+		c.SSO.OIDCIssuer = strings.TrimSpace(v)
+	}
+	if v := os.Getenv("LOWKEY_OIDC_CLIENT_ID"); v != "" {
+		c.SSO.OIDCClientID = strings.TrimSpace(v)
+	}
+	if v := os.Getenv("LOWKEY_OIDC_CLIENT_SECRET"); v != "" {
+		c.SSO.OIDCClientSecret = v
+	}
+	if v := os.Getenv("LOWKEY_OIDC_REDIRECT_URL"); v != "" {
+		c.SSO.OIDCRedirectURL = strings.TrimSpace(v)
+	}
+	if v := os.Getenv("LOWKEY_SSO_DEFAULT_ROLE"); v != "" {
+		c.SSO.DefaultRole = strings.ToLower(strings.TrimSpace(v))
+	}
 	if v := os.Getenv("LOWKEY_JWT_SECRET"); v != "" {
 		c.JWTSecret = v
 	}
@@ -954,6 +1461,30 @@ func applyEnvOverrides(c *Config) {
 	if v := os.Getenv("LOWKEY_FASTER_WHISPER_PATH"); v != "" {
 		c.FasterWhisperPath = v
 	}
+	if v := os.Getenv("LOWKEY_MODEL_MIRROR"); v != "" {
+		c.ModelMirror = strings.TrimSpace(v)
+	}
+	if v := os.Getenv("LOWKEY_RESULT_CACHE_MAX_MB"); v != "" {
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && n != 0 {
+			c.ResultCacheMaxMB = n
+		} else {
+			log.Printf("Warning: LOWKEY_RESULT_CACHE_MAX_MB=%q is not a non-zero integer; ignored", v)
+		}
+	}
+	if v := os.Getenv("LOWKEY_JOB_LOG_MAX_LINES"); v != "" {
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && n > 0 {
+			c.JobLogMaxLines = n
+		} else {
+			log.Printf("Warning: LOWKEY_JOB_LOG_MAX_LINES=%q is not a positive integer; ignored", v)
+		}
+	}
+	if v := os.Getenv("LOWKEY_JOB_LOG_MAX_LINE_BYTES"); v != "" {
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && n > 0 {
+			c.JobLogMaxLineBytes = n
+		} else {
+			log.Printf("Warning: LOWKEY_JOB_LOG_MAX_LINE_BYTES=%q is not a positive integer; ignored", v)
+		}
+	}
 	if v := os.Getenv("LOWKEY_EMBEDDING_MODEL"); v != "" {
 		c.EmbeddingModel = strings.TrimSpace(v)
 	}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -60,6 +61,9 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.InferenceConcurrency.LlamaCpp != 1 {
 		t.Errorf("Default InferenceConcurrency.LlamaCpp = %d; want 1", cfg.InferenceConcurrency.LlamaCpp)
 	}
+	if cfg.InferenceConcurrency.OpenAI != 4 {
+		t.Errorf("Default InferenceConcurrency.OpenAI = %d; want 4", cfg.InferenceConcurrency.OpenAI)
+	}
 
 	// Defaults for the OpenAI-compatible local backends point at their
 	// upstream default ports.
@@ -69,6 +73,9 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.LlamaCppBaseURL != "http://localhost:8080" {
 		t.Errorf("Default LlamaCppBaseURL = %q; want %q", cfg.LlamaCppBaseURL, "http://localhost:8080")
 	}
+	if cfg.OpenAIBaseURL != "https://api.openai.com" {
+		t.Errorf("Default OpenAIBaseURL = %q; want %q", cfg.OpenAIBaseURL, "https://api.openai.com")
+	}
 }
 
 // TestDefaultDownloadPath verifies the download path generation
@@ -490,9 +497,17 @@ func TestApplyEnvOverrides(t *testing.T) {
 		"LOWKEY_LLAMACPP_API_KEY":               "env-llamacpp-key",
 		"LOWKEY_INFERENCE_LMSTUDIO_CONCURRENCY": "3",
 		"LOWKEY_INFERENCE_LLAMACPP_CONCURRENCY": "5",
+		"LOWKEY_OPENAI_BASE_URL":                "https://gateway.example",
+		"LOWKEY_OPENAI_MODEL":                   "gpt-4o-mini",
+		"LOWKEY_OPENAI_API_KEY":                 "env-openai-key",
+		"LOWKEY_INFERENCE_OPENAI_CONCURRENCY":   "6",
 		"LOWKEY_JWT_SECRET":                     "env-secret",
 		"LOWKEY_DISCORD_TOKEN":                  "env-discord",
 		"LOWKEY_FASTER_WHISPER_PATH":            "/env/whisper",
+		"LOWKEY_MODEL_MIRROR":                   " http://nas.lan/models ",
+		"LOWKEY_RESULT_CACHE_MAX_MB":            "-1",
+		"LOWKEY_JOB_LOG_MAX_LINES":              "2000",
+		"LOWKEY_JOB_LOG_MAX_LINE_BYTES":         "4096",
 	}
 	for k, v := range envs {
 		t.Setenv(k, v)
@@ -554,6 +569,12 @@ func TestApplyEnvOverrides(t *testing.T) {
 	if c.InferenceConcurrency.LlamaCpp != 5 {
 		t.Errorf("InferenceConcurrency.LlamaCpp = %d; want 5", c.InferenceConcurrency.LlamaCpp)
 	}
+	if c.OpenAIBaseURL != "https://gateway.example" || c.OpenAIModel != "gpt-4o-mini" || c.OpenAIAPIKey != "env-openai-key" {
+		t.Errorf("OpenAI = %q %q %q", c.OpenAIBaseURL, c.OpenAIModel, c.OpenAIAPIKey)
+	}
+	if c.InferenceConcurrency.OpenAI != 6 {
+		t.Errorf("InferenceConcurrency.OpenAI = %d; want 6", c.InferenceConcurrency.OpenAI)
+	}
 	if c.JWTSecret != "env-secret" {
 		t.Errorf("JWTSecret = %q; want %q", c.JWTSecret, "env-secret")
 	}
@@ -563,6 +584,15 @@ func TestApplyEnvOverrides(t *testing.T) {
 	if c.FasterWhisperPath != "/env/whisper" {
 		t.Errorf("FasterWhisperPath = %q; want %q", c.FasterWhisperPath, "/env/whisper")
 	}
+	if c.ModelMirror != "http://nas.lan/models" {
+		t.Errorf("ModelMirror = %q; want %q", c.ModelMirror, "http://nas.lan/models")
+	}
+	if c.ResultCacheMaxMB != -1 {
+		t.Errorf("ResultCacheMaxMB = %d; want -1", c.ResultCacheMaxMB)
+	}
+	if c.JobLogMaxLines != 2000 || c.JobLogMaxLineBytes != 4096 {
+		t.Errorf("JobLog caps = %d lines, %d bytes; want 2000, 4096", c.JobLogMaxLines, c.JobLogMaxLineBytes)
+	}
 }
 
 // TestApplyEnvOverridesInvalidPort verifies a malformed LOWKEY_PORT is ignored.
@@ -836,6 +866,13 @@ func TestTranscriptionEnvOverrides(t *testing.T) {
 	t.Setenv("LOWKEY_TRANSCRIPTION_INITIAL_PROMPT", "Names: Lowkey.")
 	t.Setenv("LOWKEY_TRANSCRIPTION_HOTWORDS", "SigLIP")
 	t.Setenv("LOWKEY_TRANSCRIPTION_VOCAL_EXTRACT", "true")
+	t.Setenv("LOWKEY_TRANSCRIPTION_OPENAI_BASE_URL", "http://speaches:8000")
+	t.Setenv("LOWKEY_TRANSCRIPTION_OPENAI_API_KEY", "env-stt-key")
+	t.Setenv("LOWKEY_TRANSCRIPTION_WHISPER_SERVER_URL", "http://whisper:8080")
+	t.Setenv("LOWKEY_TRANSCRIPTION_HTTP_CONCURRENCY", "3")
+	t.Setenv("LOWKEY_TRANSCRIPTION_DIARIZE", "on")
+	t.Setenv("LOWKEY_DIARIZATION_MODEL_PATH", "/models/voxceleb_resnet34.onnx")
+	t.Setenv("LOWKEY_DIARIZATION_THRESHOLD", "0.65")
 
 	c, _, err := Load()
 	if err != nil {
@@ -849,6 +886,13 @@ func TestTranscriptionEnvOverrides(t *testing.T) {
 		!c.TranscriptionVocalExtract {
 		t.Errorf("vocabulary env overrides not applied: %+v", c)
 	}
+	if c.TranscriptionOpenAIBaseURL != "http://speaches:8000" || c.TranscriptionOpenAIAPIKey != "env-stt-key" ||
+		c.TranscriptionWhisperServerURL != "http://whisper:8080" || c.TranscriptionHTTPConcurrency != 3 {
+		t.Errorf("HTTP provider env overrides not applied: %+v", c)
+	}
+	if !c.TranscriptionDiarize || c.DiarizationModelPath != "/models/voxceleb_resnet34.onnx" || c.DiarizationThreshold != 0.65 {
+		t.Errorf("diarization env overrides not applied: %+v", c)
+	}
 }
 
 // TestConfigConcurrency tests concurrent access to Get/Set
@@ -1305,3 +1349,113 @@ func TestAllowPublicAccessRoundTripAndEnvOverride(t *testing.T) {
 		t.Error("garbage env value should be ignored, keeping the saved true")
 	}
 }
+
+func TestValidateByoModels(t *testing.T) {
+	c := Config{
+		ByoFaceModels: []ByoFaceModel{
+			{ID: "arc", ModelPath: "/m/arc.onnx", Dim: 512},
+			{ID: "arc", ModelPath: "/m/arc2.onnx", Dim: 512}, // duplicate
+		},
+		ByoEmbedModels: []ByoEmbedModel{
+			{ID: "clip-l", ImageModelPath: "/m/clip.onnx", Dim: 768, Pooling: "cls"},
+			{ID: "no-dim", ImageModelPath: "/m/x.onnx"},
+			{ID: "half-text", ImageModelPath: "/m/x.onnx", Dim: 512, TextModelPath: "/m/t.onnx"},
+			{ID: "bad-pool", ImageModelPath: "/m/x.onnx", Dim: 512, Pooling: "max"},
+			{ID: "bad-mean", ImageModelPath: "/m/x.onnx", Dim: 512, Mean: []float64{0.5}},
+		},
+		ByoTaggerModels: []ByoTaggerModel{
+			{ID: "wd-vit", ModelPath: "/m/wd.onnx", LabelsPath: "/m/tags.csv", Layout: "nhwc"},
+			{ID: "no-labels", ModelPath: "/m/wd.onnx"},
+			{ID: "bad-range", ModelPath: "/m/wd.onnx", LabelsPath: "/m/tags.csv", PixelRange: "0_2"},
+		},
+	}
+	problems := ValidateByoModels(c)
+	want := []string{
+		`byoFaceModels[1] "arc": duplicate id`,
+		`byoEmbedModels[1] "no-dim"`,
+		`byoEmbedModels[2] "half-text"`,
+		`byoEmbedModels[3] "bad-pool"`,
+		`byoEmbedModels[4] "bad-mean"`,
+		`byoTaggerModels[1] "no-labels"`,
+		`byoTaggerModels[2] "bad-range"`,
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("problems = %q, want %d entries", problems, len(want))
+	}
+	for i, w := range want {
+		if !strings.HasPrefix(problems[i], w) {
+			t.Errorf("problem[%d] = %q, want prefix %q", i, problems[i], w)
+		}
+	}
+}
+
+func TestValidateByoModelsBuiltinClash(t *testing.T) {
+	old := builtinModelIDs
+	builtinModelIDs = map[string]map[string]bool{}
+	t.Cleanup(func() { builtinModelIDs = old })
+	RegisterBuiltinModelIDs("byoEmbedModels", []string{"siglip2-base-patch16-224"})
+	RegisterBuiltinModelIDs("byoTaggerModels", []string{"wd-eva02-large-tagger-v3"})
+
+	c := Config{
+		ByoEmbedModels: []ByoEmbedModel{
+			{ID: "siglip2-base-patch16-224", ImageModelPath: "/m/x.onnx", Dim: 768},
+			{ID: "clip-l", ImageModelPath: "/m/clip.onnx", Dim: 768},
+		},
+		ByoTaggerModels: []ByoTaggerModel{
+			{ID: "wd-eva02-large-tagger-v3", ModelPath: "/m/wd.onnx", LabelsPath: "/m/tags.csv"},
+		},
+	}
+	problems := ValidateByoModels(c)
+	want := []string{
+		`byoEmbedModels[0] "siglip2-base-patch16-224": id is taken by a built-in model`,
+		`byoTaggerModels[0] "wd-eva02-large-tagger-v3": id is taken by a built-in model`,
+	}
+	if strings.Join(problems, "\n") != strings.Join(want, "\n") {
+		t.Errorf("problems = %q, want %q", problems, want)
+	}
+}
+
+func TestValidateTextEmbedModel(t *testing.T) {
+	if p := ValidateByoModels(Config{}); len(p) != 0 {
+		t.Fatalf("unset text encoder reported problems: %q", p)
+	}
+	ok := TextEmbedModel{ID: "e5-small", Dim: 384, ModelPath: "/m/e5.onnx", TokenizerPath: "/m/spm.model"}
+	if p := ValidateByoModels(Config{TextEmbedModel: ok}); len(p) != 0 {
+		t.Fatalf("valid text encoder reported problems: %q", p)
+	}
+	cases := map[string]TextEmbedModel{
+		"tokenizerPath is required": {ID: "e5", Dim: 384, ModelPath: "/m/e5.onnx"},
+		"dim must be positive":      {ID: "e5", ModelPath: "/m/e5.onnx", TokenizerPath: "/m/spm.model"},
+		"pooling must be":           {ID: "e5", Dim: 384, ModelPath: "/m/e5.onnx", TokenizerPath: "/m/spm.model", Pooling: "max"},
+	}
+	for want, m := range cases {
+		p := ValidateByoModels(Config{TextEmbedModel: m})
+		if len(p) != 1 || !strings.HasPrefix(p[0], `textEmbedModel "e5": `+want) {
+			t.Errorf("problems = %q, want one starting %q", p, want)
+		}
+	}
+}
+
+func TestByoModelsRoundTrip(t *testing.T) {
+	in := Config{
+		ByoEmbedModels:  []ByoEmbedModel{{ID: "eva", ImageModelPath: "/m/eva.onnx", Dim: 1024, Mean: []float64{0.48, 0.46, 0.41}}},
+		ByoTaggerModels: []ByoTaggerModel{{ID: "wd-vit", ModelPath: "/m/wd.onnx", LabelsPath: "/m/tags.csv", PadSquare: true}},
+	}
+	data, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"byoEmbedModels"`) || !strings.Contains(string(data), `"byoTaggerModels"`) {
+		t.Fatalf("BYO keys missing from JSON: %s", data)
+	}
+	var out Config
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.ByoEmbedModels) != 1 || out.ByoEmbedModels[0].Mean[2] != 0.41 {
+		t.Errorf("embed entry not round-tripped: %+v", out.ByoEmbedModels)
+	}
+	if len(out.ByoTaggerModels) != 1 || !out.ByoTaggerModels[0].PadSquare {
+		t.Errorf("tagger entry not round-tripped: %+v", out.ByoTaggerModels)
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stevecastle/shrike/appconfig"
+	"github.com/stevecastle/shrike/media"
+	"github.com/stevecastle/shrike/renderer"
+)
+
+// -----------------------------------------------------------------------------
+// Audit log
+//
+// Every mutating handler (deletes, merges, moves, tag/category/person edits,
+// collections, saved searches, config saves, user and key management,
+// imports) records what it did with auditLog; job tasks that mutate the
+// library write their own entries with the job as actor. GET /api/audit
+// reads the log back with filters, and AuditRetentionDays bounds it.
+// -----------------------------------------------------------------------------
+
+// auditPruneInterval is how often the retention pruner runs.
+const auditPruneInterval = 24 * time.Hour
+
+// RegisterAuditRoutes wires the audit query endpoint onto mux. Admin-only:
+// the log names every user and key and what they touched.
+func RegisterAuditRoutes(mux *http.ServeMux, deps *Dependencies) {
+	mux.HandleFunc("/api/audit", renderer.ApplyMiddlewares(auditHandler(deps), renderer.RoleAdmin))
+}
+
+// requestActor resolves who is behind r: an API key (with its ID), a signed-in
+// user, or nobody. A nil request is the server itself.
+func requestActor(deps *Dependencies, r *http.Request) (actor, kind, id string) {
+	if r == nil {
+		return "", media.AuditActorSystem, ""
+	}
+	if deps.Auth != nil {
+		if token := requestAuthToken(r); token != "" {
+			if claims, err := verifyCredential(deps, r, token); err == nil {
+				if claims.KeyID != 0 {
+					return claims.Username, media.AuditActorAPIKey, strconv.FormatInt(claims.KeyID, 10)
+				}
+				return claims.Username, media.AuditActorUser, ""
+			}
+		}
+		if cookie, err := r.Cookie("auth_token"); err == nil {
+			if claims, err := deps.Auth.VerifyToken(cookie.Value); err == nil {
+				return claims.Username, media.AuditActorUser, ""
+			}
+		}
+	}
+	return "", media.AuditActorAnonymous, ""
+}
+
+// auditLog records one mutation made on behalf of r. before and after are
+// summarized as JSON (nil = not applicable). A failed write is logged and
+// never fails the mutation it describes, which has already happened.
+func auditLog(deps *Dependencies, r *http.Request, action string, targets []string, before, after any) {
+	if deps == nil || deps.DB == nil {
+		return
+	}
+	actor, kind, id := requestActor(deps, r)
+	if err := media.AppendAudit(deps.DB, media.AuditEntry{
+		Actor: actor, ActorKind: kind, ActorID: id, Action: action, Targets: targets,
+		Before: media.AuditSummary(before), After: media.AuditSummary(after),
+	}); err != nil {
+		log.Printf("[audit] %s: %v", action, err)
+	}
+}
+
+// auditMediaTags lists path's tags as "category/tag", the before-summary for
+// anything that erases them (a delete, a forget, a merge's sources).
+func auditMediaTags(deps *Dependencies, path string) []string {
+	rows, err := deps.DB.Query(
+		`SELECT DISTINCT category_label, tag_label FROM media_tag_by_category WHERE media_path = ? ORDER BY 1, 2`, path)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+	out := []string{}
+	for rows.Next() {
+		var category, tag string
+		if rows.Scan(&category, &tag) == nil {
+			out = append(out, category+"/"+tag)
+		}
+	}
+	return out
+}
+
+// personTarget, faceTarget, and shareTarget name non-path audit targets.
+func personTarget(id int64) string { return "person:" + strconv.FormatInt(id, 10) }
+func faceTarget(id int64) string   { return "face:" + strconv.FormatInt(id, 10) }
+func shareTarget(id int64) string  { return "share:" + strconv.FormatInt(id, 10) }
+
+func collectionTarget(id int64) string  { return "collection:" + strconv.FormatInt(id, 10) }
+func folderTarget(id int64) string      { return "folder:" + strconv.FormatInt(id, 10) }
+func savedSearchTarget(id int64) string { return "search:" + strconv.FormatInt(id, 10) }
+
+// auditCollection is a collection's editable fields for a summary.
+func auditCollection(c media.Collection) map[string]any {
+	return map[string]any{"name": c.Name, "description": c.Description, "folderId": c.FolderID}
+}
+
+// auditSavedSearch is a saved search's definition for a summary.
+func auditSavedSearch(s media.SavedSearch) map[string]any {
+	return map[string]any{"name": s.Name, "predicates": s.Predicates, "query": s.Query, "mode": s.Mode, "sort": s.Sort}
+}
+
+// auditPersonName is a person's current name for a before-summary.
+func auditPersonName(deps *Dependencies, id int64) string {
+	if p, found, err := media.GetPersonByID(deps.DB, id); err == nil && found {
+		return p.Name
+	}
+	return ""
+}
+
+// auditFacePerson is the person a face is assigned to (0 = none).
+func auditFacePerson(deps *Dependencies, id int64) int64 {
+	if f, found, err := media.GetFaceByID(deps.DB, id); err == nil && found {
+		return f.PersonID
+	}
+	return 0
+}
+
+// rowsAffected is res's row count, 0 for a failed statement (nil res).
+func rowsAffected(res sql.Result) int64 {
+	if res == nil {
+		return 0
+	}
+	n, _ := res.RowsAffected()
+	return n
+}
+
+// auditHandler implements GET /api/audit. Filters: actor, action (exact or a
+// dotted prefix like "tag"), target, since/until (unix seconds, RFC 3339, or
+// YYYY-MM-DD), before (an entry ID, for paging), and limit.
+func auditHandler(deps *Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "use GET", http.StatusMethodNotAllowed)
+			return
+		}
+		q := r.URL.Query()
+		f := media.AuditFilter{
+			Actor:  strings.TrimSpace(q.Get("actor")),
+			Action: strings.TrimSpace(q.Get("action")),
+			Target: q.Get("target"),
+		}
+		var err error
+		if f.Since, err = parseAuditTime(q.Get("since")); err != nil {
+			httpError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if f.Until, err = parseAuditTime(q.Get("until")); err != nil {
+			httpError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if v := q.Get("before"); v != "" {
+			if f.BeforeID, err = strconv.ParseInt(v, 10, 64); err != nil || f.BeforeID <= 0 {
+				httpError(w, "before must be an entry id", http.StatusBadRequest)
+				return
+			}
+		}
+		if v := q.Get("limit"); v != "" {
+			if f.Limit, err = strconv.Atoi(v); err != nil || f.Limit < 0 || f.Limit > 1000 {
+				httpError(w, "limit must be between 0 and 1000", http.StatusBadRequest)
+				return
+			}
+		}
+		entries, err := media.QueryAudit(deps.DB, f)
+		if err != nil {
+			httpError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, entries)
+	}
+}
+
+// parseAuditTime reads a since/until bound: unix seconds, RFC 3339, or a
+// YYYY-MM-DD date (local midnight). Empty is 0 (unbounded).
+func parseAuditTime(v string) (int64, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, nil
+	}
+	if n, err := strconv.ParseInt(v, 10, 64); err == nil && n >= 0 {
+		return n, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t.Unix(), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", v, time.Local); err == nil {
+		return t.Unix(), nil
+	}
+	return 0, fmt.Errorf("invalid time %q (use unix seconds, RFC 3339, or YYYY-MM-DD)", v)
+}
+
+var auditPrunerOnce sync.Once
+
+// startAuditPruner prunes entries past AuditRetentionDays at startup and then
+// daily. The setting is re-read each run, so changing it needs no restart.
+// Called once from each platform main.
+func startAuditPruner(deps *Dependencies) {
+	auditPrunerOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(auditPruneInterval)
+			defer ticker.Stop()
+			for {
+				pruneAudit(deps, time.Now())
+				<-ticker.C
+			}
+		}()
+	})
+}
+
+// pruneAudit applies the retention setting as of now.
+func pruneAudit(deps *Dependencies, now time.Time) {
+	days := appconfig.Get().AuditRetentionDays
+	if days <= 0 || deps.DB == nil {
+		return
+	}
+	n, err := media.PruneAudit(deps.DB, now.AddDate(0, 0, -days).Unix())
+	if err != nil {
+		log.Printf("[audit] %v", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("[audit] pruned %d entries older than %d days", n, days)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stevecastle/shrike/appconfig"
+	"github.com/stevecastle/shrike/auth"
+	"github.com/stevecastle/shrike/media"
+)
+
+func queryAudit(t *testing.T, deps *Dependencies, query string) []media.AuditEntry {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	auditHandler(deps)(rec, httptest.NewRequest(http.MethodGet, "/api/audit?"+query, nil))
+	var entries []media.AuditEntry
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &entries) != nil {
+		t.Fatalf("audit ?%s = %d %s", query, rec.Code, rec.Body)
+	}
+	return entries
+}
+
+func TestAuditRecordsActorAndSummary(t *testing.T) {
+	deps := newSavedSearchDB(t)
+	deps.Auth = auth.NewAuthService(deps.DB, "test-secret")
+	if err := deps.Auth.Register("steve", "pw"); err != nil {
+		t.Fatal(err)
+	}
+	key, info, err := deps.Auth.CreateAPIKey("steve", "script")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/tags/rename", strings.NewReader(`{"label":"cat","newLabel":"feline"}`))
+	req.Header.Set("X-API-Key", key)
+	lokiRenameTagHandler(deps)(httptest.NewRecorder(), req)
+
+	// No credential at all: recorded as anonymous, with the tags it erased.
+	req = httptest.NewRequest(http.MethodPost, "/api/media/delete", strings.NewReader(`{"path":"a.jpg"}`))
+	lokiMediaDeleteHandler(deps)(httptest.NewRecorder(), req)
+
+	got := queryAudit(t, deps, "action=tag")
+	if len(got) != 1 || got[0].Action != "tag.rename" || got[0].Actor != "steve" ||
+		got[0].ActorKind != media.AuditActorAPIKey || got[0].ActorID != strconv.FormatInt(info.ID, 10) ||
+		strings.Join(got[0].Targets, ",") != "tag:cat,tag:feline" {
+		t.Errorf("rename entry = %+v", got)
+	}
+	got = queryAudit(t, deps, "target=a.jpg")
+	if len(got) != 1 || got[0].Action != "media.delete" || got[0].ActorKind != media.AuditActorAnonymous ||
+		!strings.Contains(string(got[0].Before), "Subject/feline") {
+		t.Errorf("delete entry = %+v", got)
+	}
+
+	// Renaming a tag that doesn't exist changes nothing and records nothing.
+	lokiRenameTagHandler(deps)(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/tags/rename",
+		strings.NewReader(`{"label":"nope","newLabel":"still-nope"}`)))
+	if got := queryAudit(t, deps, ""); len(got) != 2 {
+		t.Errorf("entries = %d, want 2", len(got))
+	}
+
+	rec := httptest.NewRecorder()
+	auditHandler(deps)(rec, httptest.NewRequest(http.MethodGet, "/api/audit?since=yesterday", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("bad since: status = %d, want 400", rec.Code)
+	}
+}
+
+// TestAuditCoversCollectionsSearchesAndSwipeTags: collection, saved-search,
+// and /media/tag edits are recorded like every other library change.
+func TestAuditCoversCollectionsSearchesAndSwipeTags(t *testing.T) {
+	deps := newSavedSearchDB(t)
+	post := func(h http.HandlerFunc, target, body string) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h(rec, httptest.NewRequest(http.MethodPost, target, strings.NewReader(body)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s = %d %s", target, rec.Code, rec.Body)
+		}
+	}
+	post(collectionsHandler(deps), "/api/collections", `{"name":"Trip"}`)
+	post(collectionFoldersHandler(deps), "/api/collection-folders", `{"name":"Holidays"}`)
+	post(savedSearchesHandler(deps), "/api/saved-searches", `{"name":"Cats","query":"tag:cat"}`)
+	post(mediaTagHandler(deps), "/media/tag", `{"media_path":"a.jpg","tag_label":"cat","category_label":"Subject","action":"remove"}`)
+
+	for action, target := range map[string]string{
+		"collection.create": "collection:",
+		"folder.create":     "folder:",
+		"search.create":     "search:",
+		"tag.unassign":      "a.jpg",
+	} {
+		got := queryAudit(t, deps, "action="+action)
+		if len(got) != 1 || len(got[0].Targets) == 0 || !strings.HasPrefix(got[0].Targets[0], target) {
+			t.Errorf("%s entries = %+v", action, got)
+		}
+	}
+	if got := queryAudit(t, deps, "action=tag.unassign"); len(got) == 1 && !strings.Contains(string(got[0].Before), `"cat"`) {
+		t.Errorf("unassign before = %s, want the removed tag", got[0].Before)
+	}
+}
+
+func TestConfigChangesRedactsSecrets(t *testing.T) {
+	old := appconfig.Config{DiscordToken: "old-token", AuditRetentionDays: 0}
+	updated := old
+	updated.DiscordToken = "new-token"
+	updated.AuditRetentionDays = 30
+	before, after := configChanges(old, updated)
+	if len(after) != 2 {
+		t.Fatalf("changes = %v -> %v", before, after)
+	}
+	b, _ := json.Marshal(map[string]any{"before": before, "after": after})
+	if strings.Contains(string(b), "old-token") || strings.Contains(string(b), "new-token") {
+		t.Errorf("secret leaked into the audit summary: %s", b)
+	}
+	if string(after["auditRetentionDays"].(json.RawMessage)) != "30" {
+		t.Errorf("retention after = %s", after["auditRetentionDays"])
+	}
+}
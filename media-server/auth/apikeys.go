@@ -5,7 +5,9 @@ import (
 	"crypto/sha256"
 	"database/sql"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"net"
 	"strings"
 	"time"
 )
@@ -14,15 +16,38 @@ import (
 // VerifyAPIKey instead of JWT parsing.
 const APIKeyPrefix = "lk_"
 
-var ErrInvalidAPIKey = errors.New("invalid API key")
+var (
+	ErrInvalidAPIKey = errors.New("invalid API key")
+	ErrAPIKeyExpired = errors.New("API key has expired")
+	// ErrAPIKeyAddress is returned by Claims.CheckAddr when the caller's
+	// address is outside the key's allow-list.
+	ErrAPIKeyAddress = errors.New("API key is not allowed from this address")
+	// ErrAPIKeyExpiry and ErrAPIKeyName reject a key CreateScopedAPIKey
+	// can't mint, alongside ErrInvalidScope and ErrInvalidCIDR.
+	ErrAPIKeyExpiry = errors.New("expiry must be in the future")
+	ErrAPIKeyName   = errors.New("key name is required")
+	// ErrAPIKeyOwner refuses keys for the temporary bootstrap admin.
+	ErrAPIKeyOwner = errors.New("cannot create API keys for the temporary admin account")
+)
 
 type APIKey struct {
-	ID         int64  `json:"id"`
-	Username   string `json:"username"`
-	Name       string `json:"name"`
-	Prefix     string `json:"prefix"`
-	CreatedAt  int64  `json:"created_at"`
-	LastUsedAt int64  `json:"last_used_at"`
+	ID         int64    `json:"id"`
+	Username   string   `json:"username"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	CreatedAt  int64    `json:"created_at"`
+	LastUsedAt int64    `json:"last_used_at"`
+	Scopes     []string `json:"scopes"`        // empty = the owner's full access
+	ExpiresAt  int64    `json:"expires_at"`    // unix seconds; 0 = never
+	AllowedIPs []string `json:"allowed_cidrs"` // source CIDRs; empty = anywhere
+}
+
+// APIKeyOptions narrows a new key. The zero value mints an unscoped,
+// non-expiring key usable from anywhere.
+type APIKeyOptions struct {
+	Scopes     []string
+	ExpiresAt  int64
+	AllowedIPs []string
 }
 
 func hashAPIKey(key string) string {
@@ -33,16 +58,33 @@ func hashAPIKey(key string) string {
 // CreateAPIKey mints a key for username and returns the plaintext exactly
 // once; only its SHA-256 hash is stored.
 func (s *AuthService) CreateAPIKey(username, name string) (string, *APIKey, error) {
+	return s.CreateScopedAPIKey(username, name, APIKeyOptions{})
+}
+
+// CreateScopedAPIKey is CreateAPIKey with scopes, an expiry, and a source
+// address allow-list.
+func (s *AuthService) CreateScopedAPIKey(username, name string, opts APIKeyOptions) (string, *APIKey, error) {
 	if username == DefaultAdminUsername {
-		return "", nil, errors.New("cannot create API keys for the temporary admin account")
+		return "", nil, ErrAPIKeyOwner
 	}
 	name = strings.TrimSpace(name)
 	if name == "" {
-		return "", nil, errors.New("key name is required")
+		return "", nil, ErrAPIKeyName
+	}
+	scopes, err := NormalizeScopes(opts.Scopes)
+	if err != nil {
+		return "", nil, err
+	}
+	cidrs, _, err := ParseCIDRs(opts.AllowedIPs)
+	if err != nil {
+		return "", nil, err
+	}
+	if opts.ExpiresAt != 0 && opts.ExpiresAt <= time.Now().Unix() {
+		return "", nil, ErrAPIKeyExpiry
 	}
 
 	var userID int64
-	err := s.db.QueryRow("SELECT id FROM users WHERE username = ?", username).Scan(&userID)
+	err = s.db.QueryRow("SELECT id FROM users WHERE username = ?", username).Scan(&userID)
 	if err == sql.ErrNoRows {
 		return "", nil, ErrUserNotFound
 	} else if err != nil {
@@ -59,8 +101,9 @@ func (s *AuthService) CreateAPIKey(username, name string) (string, *APIKey, erro
 
 	now := time.Now().Unix()
 	res, err := s.db.Exec(
-		"INSERT INTO api_keys (user_id, name, key_hash, prefix, created_at, last_used_at) VALUES (?, ?, ?, ?, ?, 0)",
-		userID, name, hashAPIKey(key), prefix, now,
+		`INSERT INTO api_keys (user_id, name, key_hash, prefix, created_at, last_used_at, scopes, expires_at, allowed_cidrs)
+		 VALUES (?, ?, ?, ?, ?, 0, ?, ?, ?)`,
+		userID, name, hashAPIKey(key), prefix, now, encodeList(scopes), opts.ExpiresAt, encodeList(cidrs),
 	)
 	if err != nil {
 		return "", nil, err
@@ -69,41 +112,93 @@ func (s *AuthService) CreateAPIKey(username, name string) (string, *APIKey, erro
 	if err != nil {
 		return "", nil, err
 	}
-	return key, &APIKey{ID: id, Username: username, Name: name, Prefix: prefix, CreatedAt: now}, nil
+	return key, &APIKey{
+		ID: id, Username: username, Name: name, Prefix: prefix, CreatedAt: now,
+		Scopes: nonNil(scopes), ExpiresAt: opts.ExpiresAt, AllowedIPs: nonNil(cidrs),
+	}, nil
+}
+
+func encodeList(list []string) string {
+	if len(list) == 0 {
+		return ""
+	}
+	b, _ := json.Marshal(list)
+	return string(b)
+}
+
+func decodeList(s sql.NullString) []string {
+	list := []string{}
+	if s.Valid && s.String != "" {
+		json.Unmarshal([]byte(s.String), &list)
+	}
+	return list
+}
+
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
 }
 
 // VerifyAPIKey resolves a plaintext API key to its owning user, returning
-// Claims so callers can treat keys and JWTs uniformly.
+// Claims so callers can treat keys and JWTs uniformly. Expired keys fail;
+// the claims carry the key's scopes and address allow-list for the caller
+// to enforce (see Claims.CheckAddr and ScopeAllows).
 func (s *AuthService) VerifyAPIKey(key string) (*Claims, error) {
 	if !strings.HasPrefix(key, APIKeyPrefix) {
 		return nil, ErrInvalidAPIKey
 	}
 	var (
-		id       int64
-		username string
-		lastUsed int64
+		id        int64
+		username  string
+		lastUsed  int64
+		scopes    sql.NullString
+		expiresAt sql.NullInt64
+		cidrs     sql.NullString
 	)
 	err := s.db.QueryRow(`
-		SELECT k.id, u.username, k.last_used_at
+		SELECT k.id, u.username, k.last_used_at, k.scopes, k.expires_at, k.allowed_cidrs
 		FROM api_keys k JOIN users u ON u.id = k.user_id
-		WHERE k.key_hash = ?`, hashAPIKey(key)).Scan(&id, &username, &lastUsed)
+		WHERE k.key_hash = ?`, hashAPIKey(key)).Scan(&id, &username, &lastUsed, &scopes, &expiresAt, &cidrs)
 	if err == sql.ErrNoRows {
 		return nil, ErrInvalidAPIKey
 	} else if err != nil {
 		return nil, err
 	}
+	if expiresAt.Int64 != 0 && time.Now().Unix() >= expiresAt.Int64 {
+		return nil, ErrAPIKeyExpired
+	}
+	_, nets, err := ParseCIDRs(decodeList(cidrs))
+	if err != nil {
+		return nil, err
+	}
 
 	// Best-effort last-used stamp, throttled so hot paths (thumbnails, HLS)
 	// don't issue a write per request.
 	if now := time.Now().Unix(); now-lastUsed > 60 {
 		_, _ = s.db.Exec("UPDATE api_keys SET last_used_at = ? WHERE id = ?", now, id)
 	}
-	return &Claims{Username: username}, nil
+	return &Claims{Username: username, KeyID: id, Scopes: decodeList(scopes), allowedNets: nets}, nil
+}
+
+// CheckAddr enforces an API key's source-address allow-list against the
+// caller's IP. JWTs and keys without a list pass.
+func (c *Claims) CheckAddr(ip net.IP) error {
+	if len(c.allowedNets) == 0 {
+		return nil
+	}
+	for _, n := range c.allowedNets {
+		if ip != nil && n.Contains(ip) {
+			return nil
+		}
+	}
+	return ErrAPIKeyAddress
 }
 
 func (s *AuthService) ListAPIKeys() ([]APIKey, error) {
 	rows, err := s.db.Query(`
-		SELECT k.id, u.username, k.name, k.prefix, k.created_at, k.last_used_at
+		SELECT k.id, u.username, k.name, k.prefix, k.created_at, k.last_used_at, k.scopes, k.expires_at, k.allowed_cidrs
 		FROM api_keys k JOIN users u ON u.id = k.user_id
 		ORDER BY k.created_at DESC, k.id DESC`)
 	if err != nil {
@@ -114,9 +209,12 @@ func (s *AuthService) ListAPIKeys() ([]APIKey, error) {
 	var keys []APIKey
 	for rows.Next() {
 		var k APIKey
-		if err := rows.Scan(&k.ID, &k.Username, &k.Name, &k.Prefix, &k.CreatedAt, &k.LastUsedAt); err != nil {
+		var scopes, cidrs sql.NullString
+		var expiresAt sql.NullInt64
+		if err := rows.Scan(&k.ID, &k.Username, &k.Name, &k.Prefix, &k.CreatedAt, &k.LastUsedAt, &scopes, &expiresAt, &cidrs); err != nil {
 			return nil, err
 		}
+		k.Scopes, k.ExpiresAt, k.AllowedIPs = decodeList(scopes), expiresAt.Int64, decodeList(cidrs)
 		keys = append(keys, k)
 	}
 	return keys, rows.Err()
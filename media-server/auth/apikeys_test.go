@@ -2,8 +2,10 @@ package auth
 
 import (
 	"database/sql"
+	"net"
 	"strings"
 	"testing"
+	"time"
 
 	_ "modernc.org/sqlite"
 )
@@ -20,7 +22,22 @@ func newTestService(t *testing.T) *AuthService {
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			username TEXT UNIQUE NOT NULL,
 			password_hash TEXT NOT NULL,
-			created_at INTEGER
+			created_at INTEGER,
+			role TEXT NOT NULL DEFAULT 'admin',
+			roots TEXT NOT NULL DEFAULT '',
+			totp_secret TEXT NOT NULL DEFAULT '',
+			totp_enabled INTEGER NOT NULL DEFAULT 0,
+			totp_last_step INTEGER NOT NULL DEFAULT 0,
+			sso_subject TEXT NOT NULL DEFAULT '',
+			sso_created INTEGER NOT NULL DEFAULT 0,
+			library_profile INTEGER NOT NULL DEFAULT 0
+		)`,
+		`CREATE TABLE user_recovery_codes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			code_hash TEXT NOT NULL UNIQUE,
+			created_at INTEGER NOT NULL,
+			used_at INTEGER NOT NULL DEFAULT 0
 		)`,
 		`CREATE TABLE api_keys (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -29,7 +46,10 @@ func newTestService(t *testing.T) *AuthService {
 			key_hash TEXT UNIQUE NOT NULL,
 			prefix TEXT NOT NULL,
 			created_at INTEGER,
-			last_used_at INTEGER
+			last_used_at INTEGER,
+			scopes TEXT NOT NULL DEFAULT '',
+			expires_at INTEGER NOT NULL DEFAULT 0,
+			allowed_cidrs TEXT NOT NULL DEFAULT ''
 		)`,
 	} {
 		if _, err := db.Exec(stmt); err != nil {
@@ -154,3 +174,52 @@ func TestListAPIKeys(t *testing.T) {
 		}
 	}
 }
+
+func TestScopedAPIKey(t *testing.T) {
+	s := newTestService(t)
+
+	if _, _, err := s.CreateScopedAPIKey("steve", "bad", APIKeyOptions{Scopes: []string{"nope"}}); err == nil {
+		t.Error("unknown scope accepted")
+	}
+	if _, _, err := s.CreateScopedAPIKey("steve", "old", APIKeyOptions{ExpiresAt: time.Now().Add(-time.Hour).Unix()}); err == nil {
+		t.Error("past expiry accepted")
+	}
+
+	plaintext, key, err := s.CreateScopedAPIKey("steve", "ci", APIKeyOptions{
+		Scopes:     []string{"media:read"},
+		ExpiresAt:  time.Now().Add(time.Hour).Unix(),
+		AllowedIPs: []string{"10.0.0.0/8"},
+	})
+	if err != nil {
+		t.Fatalf("CreateScopedAPIKey: %v", err)
+	}
+	if len(key.Scopes) != 1 || key.AllowedIPs[0] != "10.0.0.0/8" {
+		t.Errorf("key = %+v", key)
+	}
+
+	claims, err := s.VerifyAPIKey(plaintext)
+	if err != nil {
+		t.Fatalf("VerifyAPIKey: %v", err)
+	}
+	if len(claims.Scopes) != 1 || claims.Scopes[0] != ScopeMediaRead || claims.KeyID != key.ID {
+		t.Errorf("claims = %+v", claims)
+	}
+	if err := claims.CheckAddr(net.ParseIP("10.2.3.4")); err != nil {
+		t.Errorf("CheckAddr(inside) = %v", err)
+	}
+	if err := claims.CheckAddr(net.ParseIP("192.168.0.1")); err != ErrAPIKeyAddress {
+		t.Errorf("CheckAddr(outside) = %v, want ErrAPIKeyAddress", err)
+	}
+
+	keys, err := s.ListAPIKeys()
+	if err != nil || len(keys) != 1 || keys[0].ExpiresAt != key.ExpiresAt || keys[0].Scopes[0] != ScopeMediaRead {
+		t.Errorf("ListAPIKeys = %+v, %v", keys, err)
+	}
+
+	if _, err := s.db.Exec("UPDATE api_keys SET expires_at = ? WHERE id = ?", time.Now().Add(-time.Minute).Unix(), key.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.VerifyAPIKey(plaintext); err != ErrAPIKeyExpired {
+		t.Errorf("expired key: err = %v, want ErrAPIKeyExpired", err)
+	}
+}
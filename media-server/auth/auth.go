@@ -3,6 +3,7 @@ package auth
 import (
 	"database/sql"
 	"errors"
+	"net"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -20,15 +21,31 @@ const DefaultAdminUsername = "admin"
 const DefaultAdminPassword = "admin"
 
 type User struct {
-	ID           int64  `json:"id"`
-	Username     string `json:"username"`
-	PasswordHash string `json:"-"`
-	CreatedAt    int64  `json:"created_at"`
+	ID           int64    `json:"id"`
+	Username     string   `json:"username"`
+	PasswordHash string   `json:"-"`
+	CreatedAt    int64    `json:"created_at"`
+	Role         Role     `json:"role"`
+	Roots        []string `json:"roots"`
+	TOTP         bool     `json:"totp"`
+	// SSOSubject is the single sign-on identity the account is linked to
+	// ("" = none); SSOCreated marks accounts SSO provisioned.
+	SSOSubject string `json:"ssoSubject"`
+	SSOCreated bool   `json:"ssoCreated"`
+	// LibraryProfile marks accounts that rate and like as the library-wide
+	// layer instead of their own overlay.
+	LibraryProfile bool `json:"libraryProfile"`
 }
 
 type Claims struct {
 	Username string `json:"username"`
 	jwt.RegisteredClaims
+
+	// Set only for API keys (never serialized into a JWT): the key's ID
+	// and scopes (empty = unscoped), and its source-address allow-list.
+	KeyID       int64    `json:"-"`
+	Scopes      []string `json:"-"`
+	allowedNets []*net.IPNet
 }
 
 type AuthService struct {
@@ -139,20 +156,25 @@ func (s *AuthService) Register(username, password string) error {
 }
 
 func (s *AuthService) Login(username, password string) (string, error) {
-	var user User
-	err := s.db.QueryRow("SELECT id, username, password_hash FROM users WHERE username = ?", username).
-		Scan(&user.ID, &user.Username, &user.PasswordHash)
-	if err == sql.ErrNoRows {
-		return "", ErrInvalidCreds
-	} else if err != nil {
+	return s.LoginWithCode(username, password, "")
+}
+
+// LoginWithCode is Login for users who may have a second factor: code is
+// their authenticator or recovery code, ignored when TOTP is off. With the
+// right password but no code it returns ErrTOTPRequired, so callers can
+// prompt for one.
+func (s *AuthService) LoginWithCode(username, password, code string) (string, error) {
+	if err := s.CheckPassword(username, password); err != nil {
 		return "", err
 	}
-
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		return "", ErrInvalidCreds
+	if err := s.CheckSecondFactor(username, code); err != nil {
+		return "", err
 	}
+	return s.issueToken(username)
+}
 
-	// Generate Token
+// issueToken signs a session JWT for username.
+func (s *AuthService) issueToken(username string) (string, error) {
 	expirationTime := time.Now().Add(24 * time.Hour * 365)
 	claims := &Claims{
 		Username: username,
@@ -170,6 +192,21 @@ func (s *AuthService) Login(username, password string) (string, error) {
 	return tokenString, nil
 }
 
+// CheckPassword verifies username's password without issuing a token.
+func (s *AuthService) CheckPassword(username, password string) error {
+	var hash string
+	err := s.db.QueryRow("SELECT password_hash FROM users WHERE username = ?", username).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return ErrInvalidCreds
+	} else if err != nil {
+		return err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+		return ErrInvalidCreds
+	}
+	return nil
+}
+
 func (s *AuthService) VerifyToken(tokenString string) (*Claims, error) {
 	claims := &Claims{}
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
@@ -188,7 +225,7 @@ func (s *AuthService) VerifyToken(tokenString string) (*Claims, error) {
 }
 
 func (s *AuthService) ListUsers() ([]User, error) {
-	rows, err := s.db.Query("SELECT id, username, created_at FROM users ORDER BY username")
+	rows, err := s.db.Query("SELECT id, username, created_at, role, roots, totp_enabled, sso_subject, sso_created, library_profile FROM users ORDER BY username")
 	if err != nil {
 		return nil, err
 	}
@@ -197,9 +234,21 @@ func (s *AuthService) ListUsers() ([]User, error) {
 	var users []User
 	for rows.Next() {
 		var u User
-		if err := rows.Scan(&u.ID, &u.Username, &u.CreatedAt); err != nil {
+		var role, roots sql.NullString
+		var totp, ssoCreated, library int
+		if err := rows.Scan(&u.ID, &u.Username, &u.CreatedAt, &role, &roots, &totp, &u.SSOSubject, &ssoCreated, &library); err != nil {
 			return nil, err
 		}
+		u.Role, _ = ParseRole(role.String)
+		u.TOTP = totp != 0
+		u.SSOCreated = ssoCreated != 0
+		u.LibraryProfile = library != 0
+		if u.Role != RoleAdmin {
+			u.Roots = decodeRoots(roots)
+		}
+		if u.Roots == nil {
+			u.Roots = []string{}
+		}
 		users = append(users, u)
 	}
 	return users, nil
@@ -214,6 +263,14 @@ func (s *AuthService) DeleteUser(username string) error {
 	if count <= 1 {
 		return errors.New("cannot delete the last user")
 	}
+	// ...nor the last admin, which would leave nobody able to manage users.
+	if access, err := s.UserAccess(username); err == nil && access.Role == RoleAdmin {
+		if n, err := s.adminCount(); err != nil {
+			return err
+		} else if n <= 1 {
+			return ErrLastAdmin
+		}
+	}
 
 	// Revoke the user's API keys so they can't outlive the account.
 	if _, err := s.db.Exec(
@@ -221,6 +278,9 @@ func (s *AuthService) DeleteUser(username string) error {
 	); err != nil {
 		return err
 	}
+	_, _ = s.db.Exec(
+		"DELETE FROM user_recovery_codes WHERE user_id IN (SELECT id FROM users WHERE username = ?)", username,
+	)
 
 	_, err := s.db.Exec("DELETE FROM users WHERE username = ?", username)
 	return err
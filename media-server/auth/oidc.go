@@ -0,0 +1,353 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// OIDC authorization-code flow with PKCE, client side. The provider is
+// found through its discovery document; ID tokens are checked against its
+// published keys (RSA or ECDSA), issuer, audience, expiry, and the nonce the
+// flow started with. No userinfo call: usernames and groups come from ID
+// token claims, which every mainstream IdP can be configured to include.
+//
+// Accounts are keyed on the token's subject (or, failing that, an email the
+// provider has verified), never on the username claim: preferred_username is
+// often user-editable, so it only names a newly created account.
+
+// OIDCConfig describes one identity provider.
+type OIDCConfig struct {
+	Issuer        string
+	ClientID      string
+	ClientSecret  string   // "" for public clients
+	Scopes        []string // default openid, profile, email
+	UsernameClaim string   // default preferred_username (then verified email, then sub)
+	GroupsClaim   string   // default groups
+}
+
+// OIDCIdentity is what a verified ID token says about the user.
+type OIDCIdentity struct {
+	Issuer        string
+	Subject       string
+	Username      string
+	Email         string
+	EmailVerified bool
+	Groups        []string
+}
+
+// Key is the stable identifier an account is linked to: the issuer and the
+// subject, or the issuer and a verified email when the token has no subject.
+// "" means the token identifies nobody reliably.
+func (id *OIDCIdentity) Key() string {
+	switch {
+	case id.Subject != "":
+		return "oidc:" + id.Issuer + "#" + id.Subject
+	case id.Email != "" && id.EmailVerified:
+		return "oidc:" + id.Issuer + "#email:" + strings.ToLower(id.Email)
+	}
+	return ""
+}
+
+type oidcMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// jwksRefreshMin stops a flood of tokens with unknown key IDs from turning
+// into a flood of JWKS fetches.
+const jwksRefreshMin = time.Minute
+
+// OIDCProvider talks to one identity provider, caching its discovery
+// document and signing keys.
+type OIDCProvider struct {
+	cfg    OIDCConfig
+	client *http.Client
+
+	mu     sync.Mutex
+	meta   *oidcMetadata
+	keys   map[string]any // kid → *rsa.PublicKey | *ecdsa.PublicKey
+	keysAt time.Time
+}
+
+// NewOIDCProvider fills in cfg's defaults; client nil means a 10-second
+// default client.
+func NewOIDCProvider(cfg OIDCConfig, client *http.Client) *OIDCProvider {
+	cfg.Issuer = strings.TrimRight(strings.TrimSpace(cfg.Issuer), "/")
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "profile", "email"}
+	}
+	if cfg.UsernameClaim == "" {
+		cfg.UsernameClaim = "preferred_username"
+	}
+	if cfg.GroupsClaim == "" {
+		cfg.GroupsClaim = "groups"
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &OIDCProvider{cfg: cfg, client: client}
+}
+
+func (p *OIDCProvider) getJSON(ctx context.Context, u string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+func (p *OIDCProvider) metadata(ctx context.Context) (*oidcMetadata, error) {
+	p.mu.Lock()
+	meta := p.meta
+	p.mu.Unlock()
+	if meta != nil {
+		return meta, nil
+	}
+	var m oidcMetadata
+	if err := p.getJSON(ctx, p.cfg.Issuer+"/.well-known/openid-configuration", &m); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if strings.TrimRight(m.Issuer, "/") != p.cfg.Issuer {
+		return nil, fmt.Errorf("oidc discovery: issuer %q does not match configured %q", m.Issuer, p.cfg.Issuer)
+	}
+	if m.AuthorizationEndpoint == "" || m.TokenEndpoint == "" || m.JWKSURI == "" {
+		return nil, errors.New("oidc discovery: document lacks authorization, token, or jwks endpoint")
+	}
+	p.mu.Lock()
+	p.meta = &m
+	p.mu.Unlock()
+	return &m, nil
+}
+
+// AuthURL is where to send the browser to sign in. challenge is the PKCE
+// S256 challenge; state and nonce are echoed back and checked by the caller
+// and Exchange respectively.
+func (p *OIDCProvider) AuthURL(ctx context.Context, redirectURL, state, nonce, challenge string) (string, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", p.cfg.ClientID)
+	q.Set("redirect_uri", redirectURL)
+	q.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", challenge)
+	q.Set("code_challenge_method", "S256")
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return meta.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange redeems an authorization code (with its PKCE verifier) and
+// returns the identity in the verified ID token.
+func (p *OIDCProvider) Exchange(ctx context.Context, redirectURL, code, verifier, nonce string) (*OIDCIdentity, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURL)
+	form.Set("client_id", p.cfg.ClientID)
+	form.Set("code_verifier", verifier)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc token exchange: %w", err)
+	}
+	defer resp.Body.Close()
+	var tok struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&tok); err != nil {
+		return nil, fmt.Errorf("oidc token exchange: %s", resp.Status)
+	}
+	if resp.StatusCode != http.StatusOK || tok.Error != "" {
+		return nil, fmt.Errorf("oidc token exchange: %s %s", tok.Error, tok.ErrorDescription)
+	}
+	if tok.IDToken == "" {
+		return nil, errors.New("oidc token exchange: no id_token in response")
+	}
+	return p.verifyIDToken(ctx, meta, tok.IDToken, nonce)
+}
+
+func (p *OIDCProvider) verifyIDToken(ctx context.Context, meta *oidcMetadata, raw, nonce string) (*OIDCIdentity, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.key(ctx, meta, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(meta.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("oidc id_token: %w", err)
+	}
+	if got, _ := claims["nonce"].(string); got == "" || got != nonce {
+		return nil, errors.New("oidc id_token: nonce mismatch")
+	}
+	id := &OIDCIdentity{Issuer: strings.TrimRight(meta.Issuer, "/")}
+	id.Subject, _ = claims["sub"].(string)
+	id.Email, _ = claims["email"].(string)
+	// Some providers send email_verified as a string.
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		id.EmailVerified = v
+	case string:
+		id.EmailVerified = strings.EqualFold(v, "true")
+	}
+	if id.Key() == "" {
+		return nil, errors.New("oidc id_token: no subject or verified email")
+	}
+	id.Username, _ = claims[p.cfg.UsernameClaim].(string)
+	if id.Username == "" && id.EmailVerified {
+		id.Username = id.Email
+	}
+	if id.Username == "" {
+		id.Username = id.Subject
+	}
+	if id.Username == "" {
+		return nil, errors.New("oidc id_token: no username claim")
+	}
+	switch g := claims[p.cfg.GroupsClaim].(type) {
+	case []any:
+		for _, v := range g {
+			if s, ok := v.(string); ok {
+				id.Groups = append(id.Groups, s)
+			}
+		}
+	case string:
+		id.Groups = strings.Fields(strings.ReplaceAll(g, ",", " "))
+	}
+	return id, nil
+}
+
+// key returns the signing key kid names, refetching the JWKS once when it's
+// unknown (the provider may have rotated).
+func (p *OIDCProvider) key(ctx context.Context, meta *oidcMetadata, kid string) (any, error) {
+	p.mu.Lock()
+	k, ok := p.lookupKeyLocked(kid)
+	stale := time.Since(p.keysAt) >= jwksRefreshMin
+	p.mu.Unlock()
+	if ok {
+		return k, nil
+	}
+	if !stale {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := p.getJSON(ctx, meta.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("oidc jwks: %w", err)
+	}
+	keys := map[string]any{}
+	for _, j := range set.Keys {
+		if j.Use != "" && j.Use != "sig" {
+			continue
+		}
+		switch j.Kty {
+		case "RSA":
+			n, err1 := base64.RawURLEncoding.DecodeString(j.N)
+			e, err2 := base64.RawURLEncoding.DecodeString(j.E)
+			if err1 != nil || err2 != nil || len(e) > 4 {
+				continue
+			}
+			keys[j.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		case "EC":
+			var curve elliptic.Curve
+			switch j.Crv {
+			case "P-256":
+				curve = elliptic.P256()
+			case "P-384":
+				curve = elliptic.P384()
+			case "P-521":
+				curve = elliptic.P521()
+			default:
+				continue
+			}
+			x, err1 := base64.RawURLEncoding.DecodeString(j.X)
+			y, err2 := base64.RawURLEncoding.DecodeString(j.Y)
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			keys[j.Kid] = &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		}
+	}
+	p.mu.Lock()
+	p.keys, p.keysAt = keys, time.Now()
+	k, ok = p.lookupKeyLocked(kid)
+	p.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return k, nil
+}
+
+// lookupKeyLocked finds kid; a token without a kid matches a provider that
+// publishes exactly one key.
+func (p *OIDCProvider) lookupKeyLocked(kid string) (any, bool) {
+	if k, ok := p.keys[kid]; ok {
+		return k, true
+	}
+	if kid == "" && len(p.keys) == 1 {
+		for _, k := range p.keys {
+			return k, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stevecastle/shrike/auth/oidctest"
+)
+
+// signIn runs the browser leg against the stub: follow AuthURL to the IdP,
+// which approves at once and redirects back with a code.
+func signIn(t *testing.T, p *OIDCProvider, redirect, nonce, challenge string) (code, state string) {
+	t.Helper()
+	u, err := p.AuthURL(context.Background(), redirect, "state-1", nonce, challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	back, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || !strings.HasPrefix(back.String(), redirect) {
+		t.Fatalf("authorize redirect = %q (%d)", resp.Header.Get("Location"), resp.StatusCode)
+	}
+	return back.Query().Get("code"), back.Query().Get("state")
+}
+
+func TestOIDCExchangeVerifiesIDToken(t *testing.T) {
+	idp := oidctest.New(t, "loki")
+	idp.ClientSecret = "s3cret"
+	idp.SetClaims(map[string]any{"preferred_username": "alice", "groups": []string{"staff", "media-admins"}})
+	p := NewOIDCProvider(OIDCConfig{Issuer: idp.Issuer() + "/", ClientID: "loki", ClientSecret: "s3cret"}, nil)
+
+	const verifier = "verifier-verifier-verifier-verifier-verifier-1"
+	const challenge = "EqEGs5pIMuljbpLTjRMiwvPGs2tOQNVxme9rjGjCFD0" // S256(verifier)
+	code, state := signIn(t, p, "http://loki.test/auth/oidc/callback", "nonce-1", challenge)
+	if state != "state-1" {
+		t.Errorf("state = %q", state)
+	}
+	// A wrong verifier is refused by the IdP (and the code is spent).
+	if _, err := p.Exchange(context.Background(), "http://loki.test/auth/oidc/callback", code, verifier+"x", "nonce-1"); err == nil {
+		t.Fatal("exchange with wrong verifier succeeded")
+	}
+
+	code, _ = signIn(t, p, "http://loki.test/auth/oidc/callback", "nonce-1", challenge)
+	id, err := p.Exchange(context.Background(), "http://loki.test/auth/oidc/callback", code, verifier, "nonce-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.Username != "alice" || id.Subject != "subject-1" || strings.Join(id.Groups, ",") != "staff,media-admins" {
+		t.Errorf("identity = %+v", id)
+	}
+
+	code, _ = signIn(t, p, "http://loki.test/auth/oidc/callback", "nonce-2", challenge)
+	if _, err := p.Exchange(context.Background(), "http://loki.test/auth/oidc/callback", code, verifier, "nonce-1"); err == nil ||
+		!strings.Contains(err.Error(), "nonce") {
+		t.Errorf("nonce mismatch: err = %v", err)
+	}
+
+	meta, _ := p.metadata(context.Background())
+	for name, claims := range map[string]map[string]any{
+		"other audience": {"aud": "someone-else", "sub": "x", "nonce": "n"},
+		"other issuer":   {"iss": "https://evil.example", "sub": "x", "nonce": "n"},
+		"expired":        {"exp": 1, "sub": "x", "nonce": "n"},
+	} {
+		if _, err := p.verifyIDToken(context.Background(), meta, idp.Sign(claims), "n"); err == nil {
+			t.Errorf("%s: token accepted", name)
+		}
+	}
+
+	// Without a subject only a verified email identifies the user.
+	if _, err := p.verifyIDToken(context.Background(), meta, idp.Sign(map[string]any{"email": "a@example.com", "nonce": "n"}), "n"); err == nil {
+		t.Error("token with neither subject nor verified email accepted")
+	}
+	id, err = p.verifyIDToken(context.Background(), meta,
+		idp.Sign(map[string]any{"email": "A@example.com", "email_verified": true, "nonce": "n"}), "n")
+	if err != nil {
+		t.Fatalf("verified-email token: %v", err)
+	}
+	if id.Username != "A@example.com" || id.Key() != "oidc:"+idp.Issuer()+"#email:a@example.com" {
+		t.Errorf("verified-email identity = %+v (key %q)", id, id.Key())
+	}
+}
+
+func TestMapRole(t *testing.T) {
+	mapping := map[string]string{"Media-Admins": "admin", "staff": "curator"}
+	for _, tc := range []struct {
+		groups   []string
+		fallback string
+		want     Role
+		wantErr  bool
+	}{
+		{[]string{"staff", "media-admins"}, "", RoleAdmin, false},
+		{[]string{"staff"}, "viewer", RoleCurator, false},
+		{[]string{"guests"}, "viewer", RoleViewer, false},
+		{nil, "", "", true},
+	} {
+		got, err := MapRole(tc.groups, mapping, tc.fallback)
+		if got != tc.want || (err != nil) != tc.wantErr {
+			t.Errorf("MapRole(%v, %q) = %q, %v", tc.groups, tc.fallback, got, err)
+		}
+	}
+}
+
+func TestSSOLoginProvisionsAndSyncsRole(t *testing.T) {
+	s := newTestService(t)
+	tok, account, created, err := s.SSOLogin("oidc:idp#a1", "alice", RoleCurator)
+	if err != nil || !created || account != "alice" {
+		t.Fatalf("first SSO login: account=%q created=%v err=%v", account, created, err)
+	}
+	if claims, err := s.VerifyToken(tok); err != nil || claims.Username != "alice" {
+		t.Errorf("token = %+v, %v", claims, err)
+	}
+	// No password works for an SSO-provisioned account.
+	if _, err := s.Login("alice", ""); err == nil {
+		t.Error("password login to an SSO account succeeded")
+	}
+	if _, _, created, err := s.SSOLogin("oidc:idp#a1", "alice", RoleViewer); err != nil || created {
+		t.Fatalf("second SSO login: created=%v err=%v", created, err)
+	}
+	if a, _ := s.UserAccess("alice"); a.Role != RoleViewer {
+		t.Errorf("role = %s, want synced to viewer", a.Role)
+	}
+	// The subject, not the username claim, picks the account.
+	if _, account, _, err := s.SSOLogin("oidc:idp#a1", "alice-renamed", RoleViewer); err != nil || account != "alice" {
+		t.Errorf("renamed claim signed in as %q (%v), want alice", account, err)
+	}
+	if _, _, _, err := s.SSOLogin("oidc:idp#someone-else", "alice", RoleViewer); !errors.Is(err, ErrSSOLinkedElsewhere) {
+		t.Errorf("other identity claiming alice: err = %v", err)
+	}
+	if _, _, _, err := s.SSOLogin("oidc:idp#x", DefaultAdminUsername, RoleAdmin); err == nil {
+		t.Error("SSO login as the reserved admin name succeeded")
+	}
+}
+
+func TestSSOLoginNeedsAdminToLinkPasswordAccounts(t *testing.T) {
+	s := newTestService(t) // steve, a password admin
+	if _, _, _, err := s.SSOLogin("oidc:idp#s1", "steve", RoleViewer); !errors.Is(err, ErrSSONotLinked) {
+		t.Fatalf("unlinked password account: err = %v", err)
+	}
+	if err := s.SetSSOSubject("steve", "oidc:idp#s1"); err != nil {
+		t.Fatal(err)
+	}
+	_, account, created, err := s.SSOLogin("oidc:idp#s1", "steve", RoleViewer)
+	if err != nil || created || account != "steve" {
+		t.Fatalf("linked login: account=%q created=%v err=%v", account, created, err)
+	}
+	// A linked password account keeps the role an admin gave it.
+	if a, _ := s.UserAccess("steve"); a.Role != RoleAdmin {
+		t.Errorf("linked account's role changed to %s", a.Role)
+	}
+	if err := s.SetSSOSubject("nobody", "oidc:idp#n"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("link unknown user: err = %v", err)
+	}
+}
+
+func TestSSOLoginRefusesToDemoteLastAdmin(t *testing.T) {
+	s := newTestService(t)
+	if _, _, _, err := s.SSOLogin("oidc:idp#b1", "boss", RoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+	// With steve gone, boss is the only admin left.
+	if err := s.DeleteUser("steve"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := s.SSOLogin("oidc:idp#b1", "boss", RoleViewer); !errors.Is(err, ErrLastAdmin) {
+		t.Errorf("demoting the last admin: err = %v", err)
+	}
+	if a, _ := s.UserAccess("boss"); a.Role != RoleAdmin {
+		t.Errorf("last admin demoted to %s", a.Role)
+	}
+}
+
+func TestTrustedHeaderIdentity(t *testing.T) {
+	_, nets, _ := ParseCIDRs([]string{"10.0.0.0/8"})
+	th := TrustedHeader{UserHeader: "X-Forwarded-User", GroupsHeader: "X-Forwarded-Groups", Proxies: nets}
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Forwarded-User", " alice ")
+	r.Header.Set("X-Forwarded-Groups", "staff, media-admins")
+	if u, g, ok := th.Identity(r, []byte{10, 1, 2, 3}); !ok || u != "alice" || strings.Join(g, "|") != "staff|media-admins" {
+		t.Errorf("from proxy: %q %v %v", u, g, ok)
+	}
+	if _, _, ok := th.Identity(r, []byte{192, 168, 1, 5}); ok {
+		t.Error("header believed from an untrusted address")
+	}
+}
@@ -0,0 +1,200 @@
+package auth
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Role is a user's access level. Roles are ordered: each one can do
+// everything the one below it can.
+type Role string
+
+const (
+	// RoleViewer browses and streams media but changes nothing shared.
+	RoleViewer Role = "viewer"
+	// RoleCurator also tags, rates, describes, and organizes media, but
+	// cannot delete or move files, run tasks, query the DB, or edit config.
+	RoleCurator Role = "curator"
+	// RoleAdmin is unrestricted. Accounts created before roles existed
+	// default to it.
+	RoleAdmin Role = "admin"
+)
+
+var (
+	ErrLastAdmin   = errors.New("cannot remove the last admin")
+	ErrInvalidRole = errors.New("role must be viewer, curator, or admin")
+)
+
+func (r Role) rank() int {
+	switch r {
+	case RoleViewer:
+		return 1
+	case RoleCurator:
+		return 2
+	case RoleAdmin:
+		return 3
+	}
+	return 0
+}
+
+// AtLeast reports whether r grants everything min does. Unknown roles grant
+// nothing.
+func (r Role) AtLeast(min Role) bool {
+	return r.rank() > 0 && r.rank() >= min.rank()
+}
+
+// ParseRole validates a role name; "" means RoleAdmin, matching the column
+// default.
+func ParseRole(s string) (Role, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return RoleAdmin, nil
+	}
+	if r := Role(s); r.rank() > 0 {
+		return r, nil
+	}
+	return "", fmt.Errorf("%w (got %q)", ErrInvalidRole, s)
+}
+
+// Access is what a user may reach: their role and, optionally, the storage
+// roots they are confined to. Empty Roots means every configured root.
+// Admins are never confined.
+type Access struct {
+	Role  Role     `json:"role"`
+	Roots []string `json:"roots"`
+}
+
+func decodeRoots(s sql.NullString) []string {
+	var roots []string
+	if s.Valid && s.String != "" {
+		json.Unmarshal([]byte(s.String), &roots)
+	}
+	return roots
+}
+
+func encodeRoots(roots []string) (string, error) {
+	var clean []string
+	for _, r := range roots {
+		if r = strings.TrimSpace(r); r != "" {
+			clean = append(clean, r)
+		}
+	}
+	if len(clean) == 0 {
+		return "", nil
+	}
+	b, err := json.Marshal(clean)
+	return string(b), err
+}
+
+// UserAccess looks up username's role and root allow-list.
+func (s *AuthService) UserAccess(username string) (Access, error) {
+	var role sql.NullString
+	var roots sql.NullString
+	err := s.db.QueryRow("SELECT role, roots FROM users WHERE username = ?", username).Scan(&role, &roots)
+	if err == sql.ErrNoRows {
+		return Access{}, ErrUserNotFound
+	} else if err != nil {
+		return Access{}, err
+	}
+	r, err := ParseRole(role.String)
+	if err != nil {
+		return Access{}, err
+	}
+	a := Access{Role: r}
+	if r != RoleAdmin {
+		a.Roots = decodeRoots(roots)
+	}
+	return a, nil
+}
+
+// RegisterWithAccess is Register with an explicit role and root allow-list.
+func (s *AuthService) RegisterWithAccess(username, password string, access Access) error {
+	role, err := ParseRole(string(access.Role))
+	if err != nil {
+		return err
+	}
+	roots, err := encodeRoots(access.Roots)
+	if err != nil {
+		return err
+	}
+	if err := s.Register(username, password); err != nil {
+		return err
+	}
+	_, err = s.db.Exec("UPDATE users SET role = ?, roots = ? WHERE username = ?", string(role), roots, username)
+	return err
+}
+
+// UpdateUserAccess changes a user's role and/or roots (nil leaves a field
+// alone). Demoting the last admin is refused so the server can't lock
+// itself out of its own settings.
+func (s *AuthService) UpdateUserAccess(username string, role *Role, roots []string) error {
+	current, err := s.UserAccess(username)
+	if err != nil {
+		return err
+	}
+	if role != nil {
+		parsed, err := ParseRole(string(*role))
+		if err != nil || strings.TrimSpace(string(*role)) == "" {
+			return fmt.Errorf("%w (got %q)", ErrInvalidRole, *role)
+		}
+		role = &parsed
+		if current.Role == RoleAdmin && *role != RoleAdmin {
+			if n, err := s.adminCount(); err != nil {
+				return err
+			} else if n <= 1 {
+				return ErrLastAdmin
+			}
+		}
+		if _, err := s.db.Exec("UPDATE users SET role = ? WHERE username = ?", string(*role), username); err != nil {
+			return err
+		}
+	}
+	if roots != nil {
+		enc, err := encodeRoots(roots)
+		if err != nil {
+			return err
+		}
+		if _, err := s.db.Exec("UPDATE users SET roots = ? WHERE username = ?", enc, username); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *AuthService) adminCount() (int, error) {
+	var n int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM users WHERE COALESCE(role, 'admin') IN ('admin', '')").Scan(&n)
+	return n, err
+}
+
+// LibraryProfile reports whether username rates, likes, and gets a feed
+// as the library-wide layer (the media columns the Electron viewer reads)
+// rather than from their own overlay. Nobody does until an admin picks it.
+func (s *AuthService) LibraryProfile(username string) (bool, error) {
+	var on int
+	err := s.db.QueryRow("SELECT library_profile FROM users WHERE username = ?", username).Scan(&on)
+	if err == sql.ErrNoRows {
+		return false, ErrUserNotFound
+	}
+	return on != 0, err
+}
+
+// SetLibraryProfile points username's likes and ratings at the
+// library-wide layer (on) or back at their own overlay.
+func (s *AuthService) SetLibraryProfile(username string, on bool) error {
+	v := 0
+	if on {
+		v = 1
+	}
+	res, err := s.db.Exec("UPDATE users SET library_profile = ? WHERE username = ?", v, username)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseRole(t *testing.T) {
+	for in, want := range map[string]Role{"": RoleAdmin, "Viewer": RoleViewer, " curator ": RoleCurator, "admin": RoleAdmin} {
+		if got, err := ParseRole(in); err != nil || got != want {
+			t.Errorf("ParseRole(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseRole("owner"); !errors.Is(err, ErrInvalidRole) {
+		t.Errorf("unknown role: err = %v, want ErrInvalidRole", err)
+	}
+	if !RoleAdmin.AtLeast(RoleCurator) || RoleViewer.AtLeast(RoleCurator) || Role("owner").AtLeast(RoleViewer) {
+		t.Error("AtLeast ordering is wrong")
+	}
+}
+
+func TestUserAccess(t *testing.T) {
+	s := newTestService(t)
+
+	// Accounts that predate roles are admins.
+	if a, err := s.UserAccess("steve"); err != nil || a.Role != RoleAdmin || len(a.Roots) != 0 {
+		t.Fatalf("legacy account = %+v, %v", a, err)
+	}
+	if err := s.RegisterWithAccess("kid", "pw", Access{Role: "VIEWER", Roots: []string{" /photos/family ", ""}}); err != nil {
+		t.Fatal(err)
+	}
+	a, err := s.UserAccess("kid")
+	if err != nil || a.Role != RoleViewer || len(a.Roots) != 1 || a.Roots[0] != "/photos/family" {
+		t.Fatalf("kid = %+v, %v", a, err)
+	}
+
+	curator := RoleCurator
+	if err := s.UpdateUserAccess("kid", &curator, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	if a, _ := s.UserAccess("kid"); a.Role != RoleCurator || len(a.Roots) != 0 {
+		t.Errorf("after update = %+v", a)
+	}
+	if _, err := s.UserAccess("ghost"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("unknown user err = %v", err)
+	}
+
+	owner := Role("owner")
+	if err := s.UpdateUserAccess("kid", &owner, nil); !errors.Is(err, ErrInvalidRole) {
+		t.Errorf("unknown role err = %v, want ErrInvalidRole", err)
+	}
+
+	// The only admin can be neither demoted nor deleted.
+	viewer := RoleViewer
+	if err := s.UpdateUserAccess("steve", &viewer, nil); !errors.Is(err, ErrLastAdmin) {
+		t.Errorf("demote last admin err = %v", err)
+	}
+	if err := s.DeleteUser("steve"); !errors.Is(err, ErrLastAdmin) {
+		t.Errorf("delete last admin err = %v", err)
+	}
+
+	users, err := s.ListUsers()
+	if err != nil || len(users) != 2 || users[0].Role != RoleCurator || users[1].Role != RoleAdmin {
+		t.Errorf("ListUsers = %+v, %v", users, err)
+	}
+}
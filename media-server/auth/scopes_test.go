@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestNormalizeScopes(t *testing.T) {
+	got, err := NormalizeScopes([]string{" Media:Read ", "jobs:run:ingest", "media:read", ""})
+	if err != nil || len(got) != 2 || got[0] != ScopeMediaRead || got[1] != "jobs:run:ingest" {
+		t.Fatalf("NormalizeScopes = %v, %v", got, err)
+	}
+	for _, bad := range []string{"media:write", "jobs:run:", "root"} {
+		if _, err := NormalizeScopes([]string{bad}); !errors.Is(err, ErrInvalidScope) {
+			t.Errorf("NormalizeScopes(%q) err = %v, want ErrInvalidScope", bad, err)
+		}
+	}
+}
+
+func TestScopeAllows(t *testing.T) {
+	cases := []struct {
+		granted []string
+		need    string
+		want    bool
+	}{
+		{nil, ScopeAdmin, true},
+		{[]string{ScopeMediaRead}, ScopeMediaRead, true},
+		{[]string{ScopeMediaRead}, ScopeTagsWrite, false},
+		{[]string{ScopeTagsWrite}, ScopeMediaRead, true},
+		{[]string{ScopeJobsRun}, "jobs:run:ingest", true},
+		{[]string{"jobs:run:ingest"}, "jobs:run:ingest", true},
+		{[]string{"jobs:run:ingest"}, "jobs:run:remove", false},
+		{[]string{"jobs:run:ingest"}, ScopeJobsRun, false},
+		{[]string{ScopeDBQuery}, ScopeMediaRead, false},
+		{[]string{ScopeAdmin}, ScopeDBQuery, true},
+	}
+	for _, c := range cases {
+		if got := ScopeAllows(c.granted, c.need); got != c.want {
+			t.Errorf("ScopeAllows(%v, %q) = %v, want %v", c.granted, c.need, got, c.want)
+		}
+	}
+	if !ScopeCoversAny([]string{"jobs:run:ingest"}, ScopeJobsRun) {
+		t.Error("ScopeCoversAny should accept a narrower jobs:run scope")
+	}
+	if ScopeCoversAny([]string{ScopeMediaRead}, ScopeJobsRun) {
+		t.Error("ScopeCoversAny(media:read, jobs:run) = true")
+	}
+}
+
+func TestScopeRole(t *testing.T) {
+	cases := []struct {
+		granted []string
+		want    Role
+	}{
+		{nil, RoleAdmin},
+		{[]string{ScopeAdmin}, RoleAdmin},
+		{[]string{ScopeTagsWrite}, RoleCurator},
+		{[]string{ScopeMediaRead, ScopeJobsRun}, RoleViewer},
+	}
+	for _, c := range cases {
+		if got := ScopeRole(c.granted); got != c.want {
+			t.Errorf("ScopeRole(%v) = %q, want %q", c.granted, got, c.want)
+		}
+	}
+}
+
+func TestParseCIDRs(t *testing.T) {
+	out, nets, err := ParseCIDRs([]string{"10.0.0.0/8", " 192.168.1.5 ", "::1"})
+	if err != nil || len(nets) != 3 {
+		t.Fatalf("ParseCIDRs = %v, %v", out, err)
+	}
+	if out[1] != "192.168.1.5/32" || out[2] != "::1/128" {
+		t.Errorf("normalized = %v", out)
+	}
+	if !nets[0].Contains(net.ParseIP("10.1.2.3")) {
+		t.Error("10.0.0.0/8 should contain 10.1.2.3")
+	}
+	if _, _, err := ParseCIDRs([]string{"not-an-ip"}); !errors.Is(err, ErrInvalidCIDR) {
+		t.Errorf("ParseCIDRs(garbage) err = %v, want ErrInvalidCIDR", err)
+	}
+}
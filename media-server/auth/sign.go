@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Sign returns an HMAC-SHA256 of value under the JWT secret, base64url
+// encoded. It backs tokens that must be checkable before any DB lookup
+// (share links) and that rotating the secret should invalidate.
+func (s *AuthService) Sign(value string) string {
+	mac := hmac.New(sha256.New, s.jwtSecret)
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySigned reports whether sig is Sign(value), in constant time.
+func (s *AuthService) VerifySigned(value, sig string) bool {
+	return hmac.Equal([]byte(s.Sign(value)), []byte(sig))
+}
+
+// HashSecret bcrypt-hashes a non-account secret such as a share password.
+func HashSecret(secret string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+// CheckSecret reports whether secret matches a HashSecret hash.
+func CheckSecret(hash, secret string) bool {
+	return hash != "" && bcrypt.CompareHashAndPassword([]byte(hash), []byte(secret)) == nil
+}
@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Single sign-on: identities asserted by something other than a password —
+// a trusted reverse proxy's header, or an OIDC identity provider. Either way
+// the result is an ordinary session JWT from SSOLogin, so cookies and the
+// auth middleware work unchanged.
+
+// ErrNoSSORole is returned when an SSO identity's groups map to no role and
+// there is no default role to fall back on.
+var ErrNoSSORole = errors.New("no role for this identity")
+
+// MapRole picks the highest role any of groups is mapped to (group names
+// compare case-insensitively); with no match it falls back to fallback, and
+// an empty fallback means the identity gets no access.
+func MapRole(groups []string, mapping map[string]string, fallback string) (Role, error) {
+	var best Role
+	for _, g := range groups {
+		for name, role := range mapping {
+			if !strings.EqualFold(strings.TrimSpace(g), strings.TrimSpace(name)) {
+				continue
+			}
+			r := Role(strings.ToLower(strings.TrimSpace(role)))
+			if r.rank() > best.rank() {
+				best = r
+			}
+		}
+	}
+	if best != "" {
+		return best, nil
+	}
+	if strings.TrimSpace(fallback) == "" {
+		return "", ErrNoSSORole
+	}
+	return ParseRole(fallback)
+}
+
+// TrustedHeader reads an identity a reverse proxy puts in request headers.
+// The headers are believed only on connections from Proxies — anyone else
+// could set them.
+type TrustedHeader struct {
+	UserHeader   string // e.g. X-Forwarded-User
+	GroupsHeader string // e.g. X-Forwarded-Groups (comma-separated); "" = none
+	Proxies      []*net.IPNet
+}
+
+// Identity returns the user and groups r carries, ok=false when r didn't
+// come from a trusted proxy or names nobody.
+func (t TrustedHeader) Identity(r *http.Request, peer net.IP) (username string, groups []string, ok bool) {
+	if t.UserHeader == "" || peer == nil {
+		return "", nil, false
+	}
+	trusted := false
+	for _, n := range t.Proxies {
+		if n.Contains(peer) {
+			trusted = true
+			break
+		}
+	}
+	if !trusted {
+		return "", nil, false
+	}
+	username = strings.TrimSpace(r.Header.Get(t.UserHeader))
+	if username == "" {
+		return "", nil, false
+	}
+	if t.GroupsHeader != "" {
+		for _, g := range strings.FieldsFunc(r.Header.Get(t.GroupsHeader), func(c rune) bool { return c == ',' || c == ';' }) {
+			if g = strings.TrimSpace(g); g != "" {
+				groups = append(groups, g)
+			}
+		}
+	}
+	return username, groups, true
+}
+
+var (
+	// ErrSSONotLinked refuses an identity whose username belongs to an
+	// account with a password: only an admin may link the two (see
+	// SetSSOSubject), or anyone who could pick that name at the provider
+	// would inherit the account.
+	ErrSSONotLinked = errors.New("an account with this name exists and is not linked to single sign-on; an admin must link it")
+	// ErrSSOLinkedElsewhere refuses an identity whose username belongs to
+	// an account already linked to a different identity.
+	ErrSSOLinkedElsewhere = errors.New("an account with this name is linked to a different identity")
+)
+
+// SSOLogin issues a session for the identity subject (a provider-qualified
+// stable ID, e.g. an OIDC issuer and sub) as vouched for by a proxy or
+// identity provider. The account linked to subject signs in whatever its
+// name; failing that, username names it. A new name creates the account —
+// with no usable password, so it can only sign in through SSO — linked to
+// subject. An existing account is taken over only when SSO created it and
+// it isn't linked yet (accounts from before identities were recorded);
+// one with a password needs an admin to link it first. Only accounts SSO
+// created have their role follow role on every login, and demoting the
+// last admin fails the login rather than leaving them an admin the
+// provider no longer vouches for. account is the name signed in; created
+// reports a new account.
+func (s *AuthService) SSOLogin(subject, username string, role Role) (token, account string, created bool, err error) {
+	if subject == "" {
+		return "", "", false, errors.New("identity has no subject")
+	}
+	if role.rank() == 0 {
+		return "", "", false, ErrNoSSORole
+	}
+	var linked, hash string
+	var ssoCreated int
+	err = s.db.QueryRow("SELECT username, password_hash, sso_created FROM users WHERE sso_subject = ?", subject).
+		Scan(&linked, &hash, &ssoCreated)
+	switch {
+	case err == nil:
+		account = linked
+	case err != sql.ErrNoRows:
+		return "", "", false, err
+	case username == DefaultAdminUsername:
+		return "", "", false, errors.New("cannot use reserved username 'admin'")
+	default:
+		account = username
+		var other string
+		err = s.db.QueryRow("SELECT password_hash, sso_created, sso_subject FROM users WHERE username = ?", username).
+			Scan(&hash, &ssoCreated, &other)
+		switch {
+		case err == sql.ErrNoRows:
+			// An empty hash never matches, so password login stays closed.
+			if _, err := s.db.Exec("INSERT INTO users (username, password_hash, created_at, role, sso_subject, sso_created) VALUES (?, '', ?, ?, ?, 1)",
+				username, time.Now().Unix(), string(role), subject); err != nil {
+				return "", "", false, err
+			}
+			// The first SSO admin retires the first-run admin/admin
+			// account, like a first Register does.
+			if role == RoleAdmin && s.HasDefaultAdminUser() {
+				_ = s.DeleteDefaultAdmin()
+			}
+			token, err = s.issueToken(username)
+			return token, username, true, err
+		case err != nil:
+			return "", "", false, err
+		case other != "":
+			return "", "", false, ErrSSOLinkedElsewhere
+		case ssoCreated == 0 || hash != "":
+			return "", "", false, ErrSSONotLinked
+		}
+		if _, err := s.db.Exec("UPDATE users SET sso_subject = ? WHERE username = ?", subject, username); err != nil {
+			return "", "", false, err
+		}
+	}
+	if ssoCreated != 0 {
+		current, err := s.UserAccess(account)
+		if err != nil {
+			return "", "", false, err
+		}
+		if current.Role != role {
+			if err := s.UpdateUserAccess(account, &role, nil); err != nil {
+				return "", "", false, err
+			}
+		}
+	}
+	token, err = s.issueToken(account)
+	return token, account, false, err
+}
+
+// SSOSubject is the identity username is linked to ("" = none).
+func (s *AuthService) SSOSubject(username string) (string, error) {
+	var subject string
+	err := s.db.QueryRow("SELECT sso_subject FROM users WHERE username = ?", username).Scan(&subject)
+	if err == sql.ErrNoRows {
+		return "", ErrUserNotFound
+	}
+	return subject, err
+}
+
+// SetSSOSubject links username to the SSO identity subject ("" unlinks),
+// so that identity signs in as it. This is how an admin hands an existing
+// password account over to single sign-on; the account keeps its password
+// and its role is left alone.
+func (s *AuthService) SetSSOSubject(username, subject string) error {
+	subject = strings.TrimSpace(subject)
+	if subject != "" {
+		var owner string
+		err := s.db.QueryRow("SELECT username FROM users WHERE sso_subject = ?", subject).Scan(&owner)
+		if err == nil && owner != username {
+			return fmt.Errorf("identity is already linked to %q", owner)
+		} else if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+	}
+	res, err := s.db.Exec("UPDATE users SET sso_subject = ? WHERE username = ?", subject, username)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
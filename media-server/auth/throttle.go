@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// Throttle limits guessing: each key (a client address, a username) gets
+// Free failed attempts, after which every further failure locks the key out
+// for Base, doubling per failure up to Max. A success clears the key, and a
+// key with no failure for Forget is dropped. State is in memory only, so a
+// restart forgives everyone — acceptable for a brute-force brake.
+//
+// At most MaxKeys keys are tracked; a failure from a new key beyond that
+// evicts the least recently failed key that is not locked out, so a flood of
+// distinct keys (spoofed addresses, made-up usernames) can neither grow the
+// map without bound nor push a live lockout out of it. While every tracked
+// key is locked out, new keys are refused until the first lockout ends.
+type Throttle struct {
+	Free    int
+	Base    time.Duration
+	Max     time.Duration
+	Forget  time.Duration
+	MaxKeys int
+
+	mu   sync.Mutex
+	keys map[string]*throttleEntry
+	now  func() time.Time
+}
+
+type throttleEntry struct {
+	failures    int
+	lockedUntil time.Time
+	last        time.Time
+}
+
+// DefaultThrottleKeys is the MaxKeys NewThrottle sets.
+const DefaultThrottleKeys = 10000
+
+// NewThrottle returns a Throttle with the given policy; Forget defaults to
+// the longer of an hour and Max, MaxKeys to DefaultThrottleKeys.
+func NewThrottle(free int, base, max time.Duration) *Throttle {
+	forget := time.Hour
+	if max > forget {
+		forget = max
+	}
+	return &Throttle{Free: free, Base: base, Max: max, Forget: forget,
+		MaxKeys: DefaultThrottleKeys, keys: map[string]*throttleEntry{}, now: time.Now}
+}
+
+// Wait reports how long key is still locked out (0 = it may try now).
+func (t *Throttle) Wait(key string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	e := t.keys[key]
+	if e == nil {
+		return t.saturatedLocked(now)
+	}
+	if d := e.lockedUntil.Sub(now); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// Fail records a failed attempt by key and returns the lockout it now
+// carries (0 while it still has free attempts).
+func (t *Throttle) Fail(key string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	t.sweepLocked(now)
+	e := t.keys[key]
+	if e == nil {
+		if t.MaxKeys > 0 && len(t.keys) >= t.MaxKeys && !t.evictLocked(now) {
+			return t.saturatedLocked(now)
+		}
+		e = &throttleEntry{}
+		t.keys[key] = e
+	}
+	e.failures++
+	e.last = now
+	over := e.failures - t.Free
+	if over <= 0 {
+		return 0
+	}
+	d := t.Base
+	for i := 1; i < over && d < t.Max; i++ {
+		d *= 2
+	}
+	if d > t.Max {
+		d = t.Max
+	}
+	e.lockedUntil = now.Add(d)
+	return d
+}
+
+// Failures is key's current failure count.
+func (t *Throttle) Failures(key string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if e := t.keys[key]; e != nil {
+		return e.failures
+	}
+	return 0
+}
+
+// Succeed clears key.
+func (t *Throttle) Succeed(key string) {
+	t.mu.Lock()
+	delete(t.keys, key)
+	t.mu.Unlock()
+}
+
+// Reset forgets every key.
+func (t *Throttle) Reset() {
+	t.mu.Lock()
+	t.keys = map[string]*throttleEntry{}
+	t.mu.Unlock()
+}
+
+func (t *Throttle) sweepLocked(now time.Time) {
+	for k, e := range t.keys {
+		if now.Sub(e.last) > t.Forget && !now.Before(e.lockedUntil) {
+			delete(t.keys, k)
+		}
+	}
+}
+
+// evictLocked drops the least recently failed key that is not locked out,
+// reporting false when every key still is.
+func (t *Throttle) evictLocked(now time.Time) bool {
+	var oldest string
+	var oldestAt time.Time
+	for k, e := range t.keys {
+		if now.Before(e.lockedUntil) {
+			continue
+		}
+		if oldest == "" || e.last.Before(oldestAt) {
+			oldest, oldestAt = k, e.last
+		}
+	}
+	if oldest == "" {
+		return false
+	}
+	delete(t.keys, oldest)
+	return true
+}
+
+// saturatedLocked is how long a key not yet tracked must wait: 0 unless
+// the map is full of live lockouts, then until the first of them ends.
+func (t *Throttle) saturatedLocked(now time.Time) time.Duration {
+	if t.MaxKeys <= 0 || len(t.keys) < t.MaxKeys {
+		return 0
+	}
+	var soonest time.Duration
+	for _, e := range t.keys {
+		d := e.lockedUntil.Sub(now)
+		if d <= 0 {
+			return 0
+		}
+		if soonest == 0 || d < soonest {
+			soonest = d
+		}
+	}
+	return soonest
+}
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestThrottleBacksOffExponentially(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	th := NewThrottle(2, time.Second, 5*time.Second)
+	th.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if d := th.Fail("ip:1.2.3.4"); d != 0 {
+			t.Fatalf("free attempt %d locked for %v", i+1, d)
+		}
+	}
+	for i, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if d := th.Fail("ip:1.2.3.4"); d != want {
+			t.Errorf("failure %d: lockout = %v, want %v", i+3, d, want)
+		}
+	}
+	if w := th.Wait("ip:1.2.3.4"); w != 5*time.Second {
+		t.Errorf("wait = %v", w)
+	}
+	if w := th.Wait("ip:5.6.7.8"); w != 0 {
+		t.Errorf("unrelated key waits %v", w)
+	}
+	now = now.Add(6 * time.Second)
+	if w := th.Wait("ip:1.2.3.4"); w != 0 {
+		t.Errorf("wait after lockout = %v", w)
+	}
+
+	th.Succeed("ip:1.2.3.4")
+	if n := th.Failures("ip:1.2.3.4"); n != 0 {
+		t.Errorf("failures after success = %d", n)
+	}
+
+	// Idle keys are forgotten on the next failure anywhere.
+	th.Fail("user:a")
+	now = now.Add(th.Forget + time.Minute)
+	th.Fail("user:b")
+	if n := th.Failures("user:a"); n != 0 {
+		t.Errorf("stale key kept %d failures", n)
+	}
+}
+
+func TestThrottleStaysBounded(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	th := NewThrottle(2, time.Second, time.Minute)
+	th.MaxKeys = 100
+	th.now = func() time.Time { return now }
+
+	th.Fail("user:steady")
+	for i := 0; i < 1000; i++ {
+		now = now.Add(time.Millisecond)
+		th.Fail(fmt.Sprintf("ip:10.0.%d.%d", i/256, i%256))
+		if i%10 == 0 {
+			now = now.Add(time.Millisecond)
+			th.Fail("user:steady")
+		}
+	}
+	th.mu.Lock()
+	n := len(th.keys)
+	th.mu.Unlock()
+	if n > th.MaxKeys {
+		t.Errorf("tracking %d keys, cap is %d", n, th.MaxKeys)
+	}
+	// Eviction takes the least recently failed, so a key that keeps
+	// failing keeps its count.
+	if got := th.Failures("user:steady"); got != 101 {
+		t.Errorf("steady key failures = %d, want 101", got)
+	}
+	if got := th.Failures("ip:10.0.0.0"); got != 0 {
+		t.Errorf("oldest key still tracked with %d failures", got)
+	}
+}
+
+// TestThrottleKeepsLiveLockouts: flooding the map with made-up keys never
+// evicts a key that is still locked out; once every slot holds a live
+// lockout, newcomers are refused instead.
+func TestThrottleKeepsLiveLockouts(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	th := NewThrottle(0, time.Minute, time.Hour)
+	th.MaxKeys = 10
+	th.now = func() time.Time { return now }
+
+	th.Fail("user:admin")
+	for i := 0; i < 1000; i++ {
+		now = now.Add(time.Millisecond)
+		th.Fail(fmt.Sprintf("user:made-up-%d", i))
+	}
+	if w := th.Wait("user:admin"); w <= 0 {
+		t.Fatal("flood evicted a live lockout")
+	}
+	th.mu.Lock()
+	n := len(th.keys)
+	th.mu.Unlock()
+	if n > th.MaxKeys {
+		t.Errorf("tracking %d keys, cap is %d", n, th.MaxKeys)
+	}
+	if w := th.Wait("user:newcomer"); w <= 0 {
+		t.Error("newcomer admitted while every slot is locked out")
+	}
+
+	// Once lockouts lapse their slots are reusable again.
+	now = now.Add(2 * time.Minute)
+	if w := th.Wait("user:newcomer"); w != 0 {
+		t.Errorf("newcomer waits %v after lockouts ended", w)
+	}
+	if d := th.Fail("user:newcomer"); d != time.Minute || th.Failures("user:newcomer") != 1 {
+		t.Errorf("newcomer failure: lockout = %v, failures = %d", d, th.Failures("user:newcomer"))
+	}
+}
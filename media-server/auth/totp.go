@@ -0,0 +1,287 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Optional TOTP second factor (RFC 6238) with single-use recovery codes.
+//
+// Parameters are what every authenticator app assumes by default: SHA-1,
+// 30-second steps, 6 digits. One step of clock skew is accepted either way,
+// and a step that has already logged in is refused so an observed code
+// can't be replayed. Enrollment is two-phase — BeginTOTPEnrollment stores a
+// pending secret, ConfirmTOTPEnrollment turns it on once the user proves
+// their app produces matching codes — so a half-finished setup never locks
+// anyone out. Recovery codes are random, so a plain SHA-256 hash suffices.
+
+const (
+	totpDigits = 6
+	totpPeriod = 30
+	totpSkew   = 1
+
+	// RecoveryCodeCount is how many recovery codes a user is issued.
+	RecoveryCodeCount = 10
+)
+
+var (
+	ErrTOTPRequired   = errors.New("authentication code required")
+	ErrInvalidCode    = errors.New("invalid authentication code")
+	ErrTOTPEnabled    = errors.New("two-factor authentication is already enabled")
+	ErrTOTPNotPending = errors.New("no two-factor enrollment in progress")
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TOTPStatus is a user's second-factor state.
+type TOTPStatus struct {
+	Enabled       bool `json:"enabled"`
+	Pending       bool `json:"pending"` // enrollment begun, not confirmed
+	RecoveryCodes int  `json:"recoveryCodes"`
+}
+
+// totpCode computes the code for one time step.
+func totpCode(secret []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	off := sum[len(sum)-1] & 0x0f
+	n := binary.BigEndian.Uint32(sum[off:off+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, n%1000000)
+}
+
+// TOTPCode is the code secret produces at t.
+func TOTPCode(secret string, t time.Time) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+	return totpCode(key, t.Unix()/totpPeriod), nil
+}
+
+// matchTOTP returns the step code matches at now (within the skew window).
+func matchTOTP(secret, code string, now time.Time) (int64, bool) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+	step := now.Unix() / totpPeriod
+	for d := int64(-totpSkew); d <= totpSkew; d++ {
+		if hmac.Equal([]byte(totpCode(key, step+d)), []byte(code)) {
+			return step + d, true
+		}
+	}
+	return 0, false
+}
+
+// TOTPURI is the otpauth:// URI authenticator apps import (usually as a QR
+// code).
+func TOTPURI(issuer, username, secret string) string {
+	label := url.PathEscape(issuer + ":" + username)
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", issuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(totpDigits))
+	q.Set("period", fmt.Sprint(totpPeriod))
+	return "otpauth://totp/" + label + "?" + q.Encode()
+}
+
+// normalizeRecoveryCode folds case, dashes, and spaces so "ABCDE-FGHIJ" and
+// "abcdefghij" are the same code.
+func normalizeRecoveryCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '-' || r == ' ':
+			return -1
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		}
+		return r
+	}, code)
+}
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(normalizeRecoveryCode(code)))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *AuthService) userID(username string) (int64, error) {
+	var id int64
+	err := s.db.QueryRow("SELECT id FROM users WHERE username = ?", username).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, ErrUserNotFound
+	}
+	return id, err
+}
+
+// TOTPState reports username's second-factor state.
+func (s *AuthService) TOTPState(username string) (TOTPStatus, error) {
+	var st TOTPStatus
+	var secret string
+	var enabled int
+	err := s.db.QueryRow("SELECT totp_secret, totp_enabled FROM users WHERE username = ?", username).Scan(&secret, &enabled)
+	if err == sql.ErrNoRows {
+		return st, ErrUserNotFound
+	} else if err != nil {
+		return st, err
+	}
+	st.Enabled = enabled != 0
+	st.Pending = !st.Enabled && secret != ""
+	if st.Enabled {
+		s.db.QueryRow(`SELECT COUNT(*) FROM user_recovery_codes
+			WHERE used_at = 0 AND user_id = (SELECT id FROM users WHERE username = ?)`, username).Scan(&st.RecoveryCodes)
+	}
+	return st, nil
+}
+
+// BeginTOTPEnrollment stores a fresh pending secret for username (replacing
+// any earlier unconfirmed one) and returns it with its otpauth URI.
+func (s *AuthService) BeginTOTPEnrollment(username, issuer string) (secret, uri string, err error) {
+	st, err := s.TOTPState(username)
+	if err != nil {
+		return "", "", err
+	}
+	if st.Enabled {
+		return "", "", ErrTOTPEnabled
+	}
+	raw := make([]byte, 20)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+	secret = totpEncoding.EncodeToString(raw)
+	if _, err := s.db.Exec("UPDATE users SET totp_secret = ?, totp_last_step = 0 WHERE username = ?", secret, username); err != nil {
+		return "", "", err
+	}
+	return secret, TOTPURI(issuer, username, secret), nil
+}
+
+// ConfirmTOTPEnrollment enables the pending secret once code matches it and
+// returns a fresh set of recovery codes (shown to the user exactly once).
+func (s *AuthService) ConfirmTOTPEnrollment(username, code string) ([]string, error) {
+	var secret string
+	var enabled int
+	err := s.db.QueryRow("SELECT totp_secret, totp_enabled FROM users WHERE username = ?", username).Scan(&secret, &enabled)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	if enabled != 0 {
+		return nil, ErrTOTPEnabled
+	}
+	if secret == "" {
+		return nil, ErrTOTPNotPending
+	}
+	step, ok := matchTOTP(secret, strings.TrimSpace(code), time.Now())
+	if !ok {
+		return nil, ErrInvalidCode
+	}
+	if _, err := s.db.Exec("UPDATE users SET totp_enabled = 1, totp_last_step = ? WHERE username = ?", step, username); err != nil {
+		return nil, err
+	}
+	return s.RegenerateRecoveryCodes(username)
+}
+
+// DisableTOTP turns the second factor off and discards the secret and any
+// recovery codes.
+func (s *AuthService) DisableTOTP(username string) error {
+	id, err := s.userID(username)
+	if err != nil {
+		return err
+	}
+	if _, err := s.db.Exec("UPDATE users SET totp_secret = '', totp_enabled = 0, totp_last_step = 0 WHERE id = ?", id); err != nil {
+		return err
+	}
+	_, err = s.db.Exec("DELETE FROM user_recovery_codes WHERE user_id = ?", id)
+	return err
+}
+
+// RegenerateRecoveryCodes replaces username's recovery codes with
+// RecoveryCodeCount new ones and returns them in plaintext (only their
+// hashes are stored).
+func (s *AuthService) RegenerateRecoveryCodes(username string) ([]string, error) {
+	id, err := s.userID(username)
+	if err != nil {
+		return nil, err
+	}
+	codes := make([]string, RecoveryCodeCount)
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("DELETE FROM user_recovery_codes WHERE user_id = ?", id); err != nil {
+		return nil, err
+	}
+	enc := base32.NewEncoding("abcdefghijkmnpqrstuvwxyz23456789").WithPadding(base32.NoPadding)
+	for i := range codes {
+		raw := make([]byte, 7)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, err
+		}
+		c := enc.EncodeToString(raw)[:10]
+		codes[i] = c[:5] + "-" + c[5:]
+		if _, err := tx.Exec("INSERT INTO user_recovery_codes (user_id, code_hash, created_at) VALUES (?, ?, ?)",
+			id, hashRecoveryCode(codes[i]), time.Now().Unix()); err != nil {
+			return nil, err
+		}
+	}
+	return codes, tx.Commit()
+}
+
+// CheckSecondFactor verifies code for a user with TOTP enabled: a current
+// authenticator code (each time step usable once) or an unused recovery
+// code, which is spent.
+func (s *AuthService) CheckSecondFactor(username, code string) error {
+	var id, lastStep int64
+	var secret string
+	var enabled int
+	err := s.db.QueryRow("SELECT id, totp_secret, totp_enabled, totp_last_step FROM users WHERE username = ?", username).
+		Scan(&id, &secret, &enabled, &lastStep)
+	if err == sql.ErrNoRows {
+		return ErrInvalidCreds
+	} else if err != nil {
+		return err
+	}
+	if enabled == 0 {
+		return nil
+	}
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return ErrTOTPRequired
+	}
+	if step, ok := matchTOTP(secret, code, time.Now()); ok {
+		// Conditional update: a concurrent login with the same code loses.
+		res, err := s.db.Exec("UPDATE users SET totp_last_step = ? WHERE id = ? AND totp_last_step < ?", step, id, step)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ErrInvalidCode
+		}
+		return nil
+	}
+	res, err := s.db.Exec("UPDATE user_recovery_codes SET used_at = ? WHERE user_id = ? AND code_hash = ? AND used_at = 0",
+		time.Now().Unix(), id, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrInvalidCode
+	}
+	return nil
+}
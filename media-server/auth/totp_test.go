@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 appendix B, SHA-1 column (truncated to six digits).
+func TestTOTPCodeVectors(t *testing.T) {
+	key := []byte("12345678901234567890")
+	for _, tc := range []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	} {
+		if got := totpCode(key, tc.unix/totpPeriod); got != tc.want {
+			t.Errorf("T=%d: code = %s, want %s", tc.unix, got, tc.want)
+		}
+	}
+}
+
+func currentCode(t *testing.T, secret string) string {
+	t.Helper()
+	code, err := TOTPCode(secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestTOTPEnrollmentAndLogin(t *testing.T) {
+	s := newTestService(t)
+
+	secret, uri, err := s.BeginTOTPEnrollment("steve", "Loki")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(uri, "otpauth://totp/Loki:steve?") || !strings.Contains(uri, "secret="+secret) {
+		t.Errorf("uri = %s", uri)
+	}
+	// Pending enrollment isn't enforced yet.
+	if _, err := s.Login("steve", "pw"); err != nil {
+		t.Fatalf("login while pending: %v", err)
+	}
+	if _, err := s.ConfirmTOTPEnrollment("steve", "12345"); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("confirm with wrong code: %v", err)
+	}
+	code := currentCode(t, secret)
+	recovery, err := s.ConfirmTOTPEnrollment("steve", code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recovery) != RecoveryCodeCount {
+		t.Fatalf("recovery codes = %d", len(recovery))
+	}
+
+	if _, err := s.Login("steve", "pw"); !errors.Is(err, ErrTOTPRequired) {
+		t.Errorf("login without code: %v", err)
+	}
+	if _, err := s.LoginWithCode("steve", "wrong", code); !errors.Is(err, ErrInvalidCreds) {
+		t.Errorf("wrong password with code: %v", err)
+	}
+	// The step used to confirm can't be replayed.
+	if _, err := s.LoginWithCode("steve", "pw", code); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("replayed code: %v", err)
+	}
+
+	// Recovery codes work once, whatever their case or dashes.
+	rc := strings.ToUpper(strings.ReplaceAll(recovery[0], "-", ""))
+	if _, err := s.LoginWithCode("steve", "pw", rc); err != nil {
+		t.Errorf("recovery code: %v", err)
+	}
+	if _, err := s.LoginWithCode("steve", "pw", recovery[0]); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("reused recovery code: %v", err)
+	}
+	if st, _ := s.TOTPState("steve"); !st.Enabled || st.RecoveryCodes != RecoveryCodeCount-1 {
+		t.Errorf("state = %+v", st)
+	}
+	if users, _ := s.ListUsers(); len(users) != 1 || !users[0].TOTP {
+		t.Errorf("users = %+v", users)
+	}
+
+	if err := s.DisableTOTP("steve"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Login("steve", "pw"); err != nil {
+		t.Errorf("login after disable: %v", err)
+	}
+	if st, _ := s.TOTPState("steve"); st.Enabled || st.Pending || st.RecoveryCodes != 0 {
+		t.Errorf("state after disable = %+v", st)
+	}
+}
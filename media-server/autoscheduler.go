@@ -325,7 +325,7 @@ func gapsRemain(stats *statsAPIResponse, ops []string) bool {
 			if stats.WithTranscript < stats.TotalTranscribable {
 				return true
 			}
-		case "autotag":
+		case "autotag", "llm-tag":
 			if stats.WithTags < stats.TotalMedia {
 				return true
 			}
@@ -585,7 +585,7 @@ func (s *autoScheduler) publishLocked(st schedulerStatus) {
 		return
 	}
 	s.lastPublished = key
-	stream.Broadcast(stream.Message{Type: "scheduler", Msg: string(payload)})
+	stream.Broadcast(stream.Message{Type: "scheduler", Msg: string(payload), Topics: []string{stream.TopicScheduler}})
 }
 
 // Status returns the latest published status (for the GET endpoint).
@@ -5,6 +5,8 @@ import (
 	"math"
 	"net/http"
 	"time"
+
+	"github.com/stevecastle/shrike/media"
 )
 
 // -----------------------------------------------------------------------------
@@ -16,12 +18,16 @@ import (
 // transaction so concurrent voters can't clobber each other with stale
 // ratings, and every vote lands in the append-only battle log. The elo
 // column on media is a derived cache of that log.
+//
+// Votes are per user (see ratingUser): an account's votes move only its own
+// ratings in user_media_stats and are logged with its username, while
+// accounts on the library profile move the library-wide columns on media
+// (log rows with a NULL username). Each ranking counts only its own history.
 // -----------------------------------------------------------------------------
 
-const defaultElo = 1500
-
 // battleKFactor returns the Elo K-factor for an item that has fought n
-// battles: provisional ratings move fast, established ones stabilise.
+// battles in one ranking (a user's, or the library-wide one): provisional
+// ratings move fast, established ones stabilise.
 // Mirrored in the Electron main process (src/main/media.ts recordBattle) —
 // keep the schedules identical or shared databases drift.
 func battleKFactor(n int64) float64 {
@@ -50,6 +56,10 @@ func mediaBattleHandler(deps *Dependencies) http.HandlerFunc {
 			httpError(w, "bad request: winnerPath and loserPath required", http.StatusBadRequest)
 			return
 		}
+		if !pathsAllowedForRequest(deps, r, req.WinnerPath, req.LoserPath) {
+			httpError(w, errOutsideRoots, http.StatusForbidden)
+			return
+		}
 		if req.WinnerPath == req.LoserPath {
 			httpError(w, "bad request: an item cannot battle itself", http.StatusBadRequest)
 			return
@@ -63,6 +73,15 @@ func mediaBattleHandler(deps *Dependencies) http.HandlerFunc {
 			return
 		}
 
+		user := ratingUser(deps, r)
+		if !ratesLibrary(deps, w, r, user, "rate") {
+			return
+		}
+		var username any // NULL in the log for the library-wide ranking
+		if user != "" {
+			username = user
+		}
+
 		tx, err := deps.DB.Begin()
 		if err != nil {
 			httpError(w, err.Error(), http.StatusInternalServerError)
@@ -71,9 +90,14 @@ func mediaBattleHandler(deps *Dependencies) http.HandlerFunc {
 		defer tx.Rollback()
 
 		readSide := func(path string) (elo float64, matches int64, err error) {
-			elo = defaultElo
+			elo = media.DefaultElo
 			var stored sql.NullFloat64
-			err = tx.QueryRow(`SELECT elo FROM media WHERE path = ?`, path).Scan(&stored)
+			if user == "" {
+				err = tx.QueryRow(`SELECT elo FROM media WHERE path = ?`, path).Scan(&stored)
+			} else {
+				err = tx.QueryRow(`SELECT elo FROM user_media_stats WHERE username = ? AND media_path = ?`,
+					user, path).Scan(&stored)
+			}
 			if err == sql.ErrNoRows {
 				err = nil // unseen path: rated on first battle, row created below
 			} else if err != nil {
@@ -83,8 +107,8 @@ func mediaBattleHandler(deps *Dependencies) http.HandlerFunc {
 				elo = stored.Float64
 			}
 			err = tx.QueryRow(
-				`SELECT COUNT(*) FROM battle WHERE winner_path = ? OR loser_path = ?`,
-				path, path).Scan(&matches)
+				`SELECT COUNT(*) FROM battle WHERE (winner_path = ? OR loser_path = ?) AND username IS ?`,
+				path, path, username).Scan(&matches)
 			return
 		}
 		winnerElo, winnerMatches, err := readSide(req.WinnerPath)
@@ -110,26 +134,40 @@ func mediaBattleHandler(deps *Dependencies) http.HandlerFunc {
 		if outcome == 1 {
 			winInc, lossInc = 1, 1
 		}
-		const upsert = `INSERT INTO media (path, elo, wins, losses, battles) VALUES (?, ?, ?, ?, ?)
-			ON CONFLICT(path) DO UPDATE SET
-				elo = excluded.elo,
-				wins = COALESCE(wins, 0) + ?,
-				losses = COALESCE(losses, 0) + ?,
-				battles = excluded.battles`
-		if _, err := tx.Exec(upsert, req.WinnerPath, newWinnerElo, winInc, 0, winnerMatches+1, winInc, 0); err != nil {
+		upsert := func(path string, elo float64, wins, losses, battles int64) error {
+			if user == "" {
+				_, err := tx.Exec(`INSERT INTO media (path, elo, wins, losses, battles) VALUES (?, ?, ?, ?, ?)
+					ON CONFLICT(path) DO UPDATE SET
+						elo = excluded.elo,
+						wins = COALESCE(wins, 0) + ?,
+						losses = COALESCE(losses, 0) + ?,
+						battles = excluded.battles`,
+					path, elo, wins, losses, battles, wins, losses)
+				return err
+			}
+			_, err := tx.Exec(`INSERT INTO user_media_stats (username, media_path, elo, wins, losses, battles) VALUES (?, ?, ?, ?, ?, ?)
+				ON CONFLICT(username, media_path) DO UPDATE SET
+					elo = excluded.elo,
+					wins = COALESCE(wins, 0) + ?,
+					losses = COALESCE(losses, 0) + ?,
+					battles = excluded.battles`,
+				user, path, elo, wins, losses, battles, wins, losses)
+			return err
+		}
+		if err := upsert(req.WinnerPath, newWinnerElo, winInc, 0, winnerMatches+1); err != nil {
 			httpError(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		if _, err := tx.Exec(upsert, req.LoserPath, newLoserElo, 0, lossInc, loserMatches+1, 0, lossInc); err != nil {
+		if err := upsert(req.LoserPath, newLoserElo, 0, lossInc, loserMatches+1); err != nil {
 			httpError(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 		if _, err := tx.Exec(`INSERT INTO battle
 			(winner_path, loser_path, outcome,
-			 winner_elo_before, loser_elo_before, winner_elo_after, loser_elo_after, created_at)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			 winner_elo_before, loser_elo_before, winner_elo_after, loser_elo_after, created_at, username)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 			req.WinnerPath, req.LoserPath, outcome,
-			winnerElo, loserElo, newWinnerElo, newLoserElo, time.Now().UnixMilli()); err != nil {
+			winnerElo, loserElo, newWinnerElo, newLoserElo, time.Now().UnixMilli(), username); err != nil {
 			httpError(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
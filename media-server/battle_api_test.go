@@ -1,10 +1,16 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
 	"math"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"github.com/stevecastle/shrike/auth"
+	"github.com/stevecastle/shrike/media"
 )
 
 type battleResp struct {
@@ -140,3 +146,154 @@ func TestBattleValidation(t *testing.T) {
 		t.Errorf("rejected requests must not log battles, got %d rows", n)
 	}
 }
+
+// newPerUserDeps is the saved-search library with auth on and kim, a
+// curator with their own ratings.
+func newPerUserDeps(t *testing.T) (*Dependencies, string) {
+	t.Helper()
+	deps := newSavedSearchDB(t)
+	deps.Auth = auth.NewAuthService(deps.DB, "test-secret")
+	if err := deps.Auth.RegisterWithAccess("kim", "pw", auth.Access{Role: auth.RoleCurator}); err != nil {
+		t.Fatal(err)
+	}
+	tok, err := deps.Auth.Login("kim", "pw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return deps, tok
+}
+
+func postAs(t *testing.T, h http.HandlerFunc, token, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestBattlePerUser(t *testing.T) {
+	deps, kim := newPerUserDeps(t)
+
+	rr := postAs(t, mediaBattleHandler(deps), kim, "/api/media/battle", `{"winnerPath":"a.jpg","loserPath":"b.jpg"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d %s", rr.Code, rr.Body)
+	}
+	var resp battleResp
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if math.Abs(resp.WinnerElo-1524) > 1e-9 || resp.WinnerMatches != 1 {
+		t.Errorf("kim's first battle: elo = %v, matches = %d", resp.WinnerElo, resp.WinnerMatches)
+	}
+
+	// Kim's rating lives in her overlay; the library-wide columns are untouched.
+	var global sql.NullFloat64
+	deps.DB.QueryRow(`SELECT elo FROM media WHERE path = 'a.jpg'`).Scan(&global)
+	if global.Valid {
+		t.Errorf("media.elo = %v, want NULL", global.Float64)
+	}
+	var elo float64
+	var wins int64
+	deps.DB.QueryRow(`SELECT elo, wins FROM user_media_stats WHERE username = 'kim' AND media_path = 'a.jpg'`).Scan(&elo, &wins)
+	if math.Abs(elo-1524) > 1e-9 || wins != 1 {
+		t.Errorf("kim's row: elo = %v, wins = %d", elo, wins)
+	}
+	var who string
+	deps.DB.QueryRow(`SELECT username FROM battle`).Scan(&who)
+	if who != "kim" {
+		t.Errorf("battle username = %q, want kim", who)
+	}
+
+	// Anonymous visitors can't battle the library-wide ranking...
+	rr = postAs(t, mediaBattleHandler(deps), "", "/api/media/battle", `{"winnerPath":"a.jpg","loserPath":"b.jpg"}`)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("anonymous battle: status = %d, want 401", rr.Code)
+	}
+	// ...but an account given the library profile starts it from scratch.
+	if err := deps.Auth.SetLibraryProfile("kim", true); err != nil {
+		t.Fatal(err)
+	}
+	rr = postAs(t, mediaBattleHandler(deps), kim, "/api/media/battle", `{"winnerPath":"a.jpg","loserPath":"b.jpg"}`)
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if rr.Code != http.StatusOK || resp.WinnerMatches != 1 {
+		t.Errorf("global battle: status = %d, matches = %d, want 200 / 1", rr.Code, resp.WinnerMatches)
+	}
+}
+
+// TestRatingProfiles: admins get their own overlay like everyone else,
+// viewers may rate into theirs, and the library-wide layer is only reached
+// through the library profile, which a viewer can't write.
+func TestRatingProfiles(t *testing.T) {
+	deps, _ := newPerUserDeps(t)
+	steve := roleToken(t, deps, "steve", auth.Access{Role: auth.RoleAdmin})
+	vera := roleToken(t, deps, "vera", auth.Access{Role: auth.RoleViewer})
+	battle := func(token string) int {
+		return postAs(t, mediaBattleHandler(deps), token, "/api/media/battle", `{"winnerPath":"a.jpg","loserPath":"b.jpg"}`).Code
+	}
+
+	if code := battle(steve); code != http.StatusOK {
+		t.Fatalf("admin battle: status = %d", code)
+	}
+	if code := battle(vera); code != http.StatusOK {
+		t.Fatalf("viewer battle: status = %d", code)
+	}
+	var global sql.NullFloat64
+	deps.DB.QueryRow(`SELECT elo FROM media WHERE path = 'a.jpg'`).Scan(&global)
+	if global.Valid {
+		t.Errorf("media.elo = %v after per-user battles, want NULL", global.Float64)
+	}
+	for _, who := range []string{"steve", "vera"} {
+		var n int
+		deps.DB.QueryRow(`SELECT COUNT(*) FROM user_media_stats WHERE username = ? AND media_path = 'a.jpg'`, who).Scan(&n)
+		if n != 1 {
+			t.Errorf("%s has %d rows of their own, want 1", who, n)
+		}
+	}
+
+	if err := deps.Auth.SetLibraryProfile("steve", true); err != nil {
+		t.Fatal(err)
+	}
+	if rr := postAs(t, mediaRatingHandler(deps), steve, "/api/media/rating", `{"path":"a.jpg","elo":1600}`); rr.Code != http.StatusOK {
+		t.Fatalf("library rating: status = %d %s", rr.Code, rr.Body)
+	}
+	deps.DB.QueryRow(`SELECT elo FROM media WHERE path = 'a.jpg'`).Scan(&global)
+	if !global.Valid || global.Float64 != 1600 {
+		t.Errorf("media.elo = %+v, want 1600", global)
+	}
+
+	if err := deps.Auth.SetLibraryProfile("vera", true); err != nil {
+		t.Fatal(err)
+	}
+	if code := battle(vera); code != http.StatusUnauthorized {
+		t.Errorf("viewer on the library profile: status = %d, want 401", code)
+	}
+}
+
+func TestMediaLikePerUser(t *testing.T) {
+	deps, kim := newPerUserDeps(t)
+	h := mediaLikeHandler(deps)
+
+	if rr := postAs(t, h, kim, "/api/media/like", `{"path":"a.jpg","liked":true}`); rr.Code != http.StatusOK {
+		t.Fatalf("like = %d %s", rr.Code, rr.Body)
+	}
+	if liked, _ := media.IsLiked(deps.DB, "kim", "a.jpg"); !liked {
+		t.Error("kim's like not stored")
+	}
+	if liked, _ := media.IsLiked(deps.DB, "", "a.jpg"); liked {
+		t.Error("kim's like leaked into the Favorites tag")
+	}
+	rr := postAs(t, h, kim, "/api/media/like", `{"path":"a.jpg"}`)
+	if !strings.Contains(rr.Body.String(), `"liked":true`) {
+		t.Errorf("read = %s", rr.Body)
+	}
+
+	// Anonymous clients can't write the library-wide layer.
+	if rr := postAs(t, h, "", "/api/media/like", `{"path":"a.jpg","liked":true}`); rr.Code != http.StatusUnauthorized {
+		t.Errorf("anonymous like = %d, want 401", rr.Code)
+	}
+	if rr := postAs(t, h, kim, "/api/media/like", `{"path":"nope.jpg","liked":true}`); rr.Code != http.StatusNotFound {
+		t.Errorf("unknown path = %d, want 404", rr.Code)
+	}
+}
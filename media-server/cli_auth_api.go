@@ -7,6 +7,7 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"net/http"
@@ -35,6 +36,8 @@ import (
 // -supplied URL); minting requires a POST from a same-origin page with a
 // valid session cookie (GET never mints); the PKCE S256 check stops anything
 // that saw the redirect from redeeming the code without the verifier.
+// Users with TOTP enabled must also enter a code to approve, and failed
+// codes and token exchanges count toward the login throttle.
 
 const cliAuthCodeTTL = 2 * time.Minute
 
@@ -182,6 +185,10 @@ var cliApprovePage = template.Must(template.New("cliapprove").Parse(`<!DOCTYPE h
   button { flex:1; padding:10px; border-radius:8px; border:1px solid #30343e; cursor:pointer;
            font-size:13px; font-weight:600; background:#262a33; color:#d7dae0; }
   button.approve { background:#00d4aa; border-color:#00d4aa; color:#00281f; }
+  label { display:block; margin-top:16px; color:#9aa0ab; font-size:12px; }
+  input[type=text] { width:100%; box-sizing:border-box; margin-top:6px; padding:9px 10px; border-radius:8px;
+          border:1px solid #30343e; background:#101216; color:#d7dae0; font-size:15px; letter-spacing:2px; }
+  .error { color:#ff6b6b; margin-top:12px; }
 </style>
 </head>
 <body>
@@ -197,15 +204,34 @@ var cliApprovePage = template.Must(template.New("cliapprove").Parse(`<!DOCTYPE h
       <input type="hidden" name="state" value="{{.State}}">
       <input type="hidden" name="code_challenge" value="{{.Challenge}}">
       <input type="hidden" name="name" value="{{.KeyName}}">
+      {{if .NeedCode}}
+      <label for="totp_code">Authentication code (or a recovery code)</label>
+      <input type="text" id="totp_code" name="totp_code" inputmode="numeric" autocomplete="one-time-code" autofocus>
+      {{end}}
+      {{if .Error}}<p class="error">{{.Error}}</p>{{end}}
       <div class="actions">
         <button type="submit" name="action" value="deny">Deny</button>
-        <button type="submit" name="action" value="approve" class="approve" autofocus>Approve</button>
+        <button type="submit" name="action" value="approve" class="approve"{{if not .NeedCode}} autofocus{{end}}>Approve</button>
       </div>
     </form>
   </div>
 </body>
 </html>`))
 
+// renderCLIApprovePage writes the approve page for username, asking for a
+// TOTP code when they have one enabled.
+func renderCLIApprovePage(w http.ResponseWriter, deps *Dependencies, status int, username string, p cliAuthParams, errMsg string) {
+	st, _ := deps.Auth.TOTPState(username)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = cliApprovePage.Execute(w, map[string]any{
+		"Username": username, "Port": p.port, "State": p.state,
+		"Challenge": p.challenge, "KeyName": p.keyName,
+		"NeedCode": st.Enabled, "Error": errMsg,
+	})
+}
+
 // cliAuthAuthorizeHandler implements /auth/cli/authorize: GET renders the
 // approve page (bouncing to /login first when there is no session), POST
 // mints the one-time code and redirects to the CLI's loopback callback.
@@ -227,12 +253,7 @@ func cliAuthAuthorizeHandler(deps *Dependencies) http.HandlerFunc {
 				http.Error(w, "Finish first-run setup (create a real account) before authorizing the CLI", http.StatusForbidden)
 				return
 			}
-			w.Header().Set("Content-Type", "text/html; charset=utf-8")
-			w.Header().Set("Cache-Control", "no-store")
-			_ = cliApprovePage.Execute(w, map[string]any{
-				"Username": username, "Port": p.port, "State": p.state,
-				"Challenge": p.challenge, "KeyName": p.keyName,
-			})
+			renderCLIApprovePage(w, deps, http.StatusOK, username, p, "")
 
 		case http.MethodPost:
 			if !sameOriginPost(r) {
@@ -262,6 +283,19 @@ func cliAuthAuthorizeHandler(deps *Dependencies) http.HandlerFunc {
 				http.Error(w, "Finish first-run setup before authorizing the CLI", http.StatusForbidden)
 				return
 			}
+			if wait := loginWait(r, username); wait > 0 {
+				writeLockedOut(w, wait)
+				return
+			}
+			switch err := deps.Auth.CheckSecondFactor(username, r.PostForm.Get("totp_code")); {
+			case errors.Is(err, auth.ErrTOTPRequired):
+				renderCLIApprovePage(w, deps, http.StatusUnauthorized, username, p, "Enter the code from your authenticator app.")
+				return
+			case err != nil:
+				loginFailed(deps, r, "cli_approve", username, "invalid code")
+				renderCLIApprovePage(w, deps, http.StatusUnauthorized, username, p, "Invalid authentication code.")
+				return
+			}
 			code, err := mintCLIAuthCode(username, p.challenge, p.keyName)
 			if err != nil {
 				http.Error(w, "Could not create authorization code", http.StatusInternalServerError)
@@ -288,6 +322,10 @@ func cliAuthTokenHandler(deps *Dependencies) http.HandlerFunc {
 			fail(w, http.StatusMethodNotAllowed, "Use POST")
 			return
 		}
+		if wait := loginWait(r, ""); wait > 0 {
+			writeLockedOut(w, wait)
+			return
+		}
 		var req struct {
 			Code         string `json:"code"`
 			CodeVerifier string `json:"code_verifier"`
@@ -302,12 +340,14 @@ func cliAuthTokenHandler(deps *Dependencies) http.HandlerFunc {
 		}
 		grant := redeemCLIAuthCode(req.Code)
 		if grant == nil {
+			loginFailed(deps, r, "cli_token", "", "invalid or expired code")
 			fail(w, http.StatusBadRequest, "Invalid or expired code")
 			return
 		}
 		sum := sha256.Sum256([]byte(req.CodeVerifier))
 		want := base64.RawURLEncoding.EncodeToString(sum[:])
 		if subtle.ConstantTimeCompare([]byte(want), []byte(grant.challenge)) != 1 {
+			loginFailed(deps, r, "cli_token", "", "code_verifier mismatch")
 			fail(w, http.StatusBadRequest, "code_verifier does not match")
 			return
 		}
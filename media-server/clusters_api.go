@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/stevecastle/shrike/media"
+)
+
+// -----------------------------------------------------------------------------
+// Library themes API (shared across all platform mains).
+//
+//   GET /api/clusters?covers=N — themes from the last cluster-library run,
+//                                largest first, each with N cover paths
+//                                (default 4, max 24). Browse a theme with
+//                                the cluster:<id> query predicate.
+// -----------------------------------------------------------------------------
+
+const (
+	clusterCoversDefault = 4
+	clusterCoversMax     = 24
+)
+
+func clustersHandler(deps *Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "use GET", http.StatusMethodNotAllowed)
+			return
+		}
+		covers := clusterCoversDefault
+		if v := r.URL.Query().Get("covers"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				httpError(w, "covers must be a non-negative integer", http.StatusBadRequest)
+				return
+			}
+			covers = min(n, clusterCoversMax)
+		}
+		clusters, err := media.GetLibraryClusters(deps.DB, covers)
+		if err != nil {
+			httpError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if clusters == nil {
+			clusters = []media.LibraryCluster{}
+		}
+		writeJSON(w, clusters)
+	}
+}
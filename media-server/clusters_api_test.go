@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stevecastle/shrike/media"
+)
+
+func TestClustersHandlerListsThemesWithCovers(t *testing.T) {
+	db := newFacesTestDB(t)
+	if _, err := media.ReplaceLibraryClusters(db, "m1", []media.NewLibraryCluster{
+		{Label: "beach", LabelSource: "tags", Members: []media.ClusterMember{{Path: "a.jpg", Score: 0.9}, {Path: "b.jpg", Score: 0.8}}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	h := clustersHandler(&Dependencies{DB: db})
+
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "/api/clusters?covers=1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	var got []media.LibraryCluster
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Label != "beach" || got[0].Size != 2 || len(got[0].Covers) != 1 || got[0].Covers[0] != "a.jpg" {
+		t.Fatalf("clusters = %+v", got)
+	}
+
+	rec = httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "/api/clusters?covers=-1", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("negative covers status = %d, want 400", rec.Code)
+	}
+}
@@ -29,6 +29,9 @@ func main() {
 		textStr, textModel, tokenizerPath string
 		textInput, textOutput             string
 		seqLen                            int
+		// sentence mode flags
+		sentenceMode bool
+		maskInput    string
 		// faces mode flags
 		facesMode                    bool
 		detectModel, detectKind      string
@@ -46,6 +49,10 @@ func main() {
 		face2Weight                  float64
 		faceMinScore                 float64
 		faceMinSize                  int
+		// speaker mode flags
+		speakerMode                 bool
+		speakerModel                string
+		speakerInput, speakerOutput string
 	)
 	flag.StringVar(&modelPath, "model", "", "Path to ONNX embedding model")
 	flag.StringVar(&imagePath, "image", "", "Path to input image")
@@ -75,6 +82,13 @@ func main() {
 	flag.StringVar(&textInput, "text-input", "input_ids", "Text encoder input tensor name")
 	flag.StringVar(&textOutput, "text-output", "pooler_output", "Text encoder output tensor name")
 	flag.IntVar(&seqLen, "seq-len", 64, "Sequence length for text tokenization")
+	// sentence mode: a standalone sentence encoder (e5 / MiniLM family, XLM-R
+	// SentencePiece vocabulary) for semantic transcript search. Reuses the
+	// --text-* flags; --pooling=cls takes token 0, anything else mean-pools
+	// last_hidden_state over the attention mask. Works one-shot (--text) and
+	// with --serve (one text line per request).
+	flag.BoolVar(&sentenceMode, "sentence", false, "Sentence mode: encode text with a standalone sentence encoder (--text-model, --tokenizer)")
+	flag.StringVar(&maskInput, "mask-input", "attention_mask", "Sentence encoder attention-mask tensor name")
 	// faces mode: detect faces (YuNet), align to the 112x112 five-landmark
 	// template, and embed each with a face-identity model (SFace by default;
 	// BYO ArcFace-family models via the --face-* preprocessing flags). Output
@@ -104,6 +118,13 @@ func main() {
 	flag.Float64Var(&face2Weight, "face2-weight", 1, "Secondary recognizer's cosine-fusion weight")
 	flag.Float64Var(&faceMinScore, "min-score", 0.7, "Minimum detection confidence (faces mode)")
 	flag.IntVar(&faceMinSize, "min-size", 40, "Minimum face bbox edge in original-image pixels (faces mode)")
+	// speaker mode: voice embeddings for transcript diarization. Serve-only;
+	// each request names a span of a raw 16 kHz mono s16le file, and the
+	// embedding length comes from the model, so --dim is not needed.
+	flag.BoolVar(&speakerMode, "speaker", false, "Speaker mode: embed voices from PCM spans for diarization (--speaker-model)")
+	flag.StringVar(&speakerModel, "speaker-model", "", "Path to the ONNX speaker-embedding model (speaker mode)")
+	flag.StringVar(&speakerInput, "speaker-input", "", "Speaker model input tensor name (default: the model's first input)")
+	flag.StringVar(&speakerOutput, "speaker-output", "", "Speaker model output tensor name (default: the model's first output)")
 	flag.Parse()
 
 	if showVersion {
@@ -111,6 +132,24 @@ func main() {
 		os.Exit(0)
 	}
 
+	// Speaker mode sizes its output from the model, so it runs before the
+	// --dim check.
+	if speakerMode {
+		err := runSpeaker(speakerFlags{
+			model:    speakerModel,
+			input:    speakerInput,
+			output:   speakerOutput,
+			ortLib:   ortLibPath,
+			provider: provider,
+			device:   device,
+			threads:  threads,
+		})
+		if err != nil {
+			log.Fatalf("speaker: %v", err)
+		}
+		return
+	}
+
 	if dim <= 0 {
 		fmt.Fprintln(os.Stderr, "Error: --dim is required")
 		flag.Usage()
@@ -157,6 +196,30 @@ func main() {
 		return
 	}
 
+	// Sentence mode: standalone text encoder, one-shot or serve.
+	if sentenceMode {
+		err := runSentence(sentenceFlags{
+			model:     textModel,
+			tokenizer: tokenizerPath,
+			input:     textInput,
+			mask:      maskInput,
+			output:    textOutput,
+			dim:       dim,
+			seqLen:    seqLen,
+			pooling:   pooling,
+			ortLib:    ortLibPath,
+			provider:  provider,
+			device:    device,
+			threads:   threads,
+			serve:     serve,
+			text:      textStr,
+		})
+		if err != nil {
+			log.Fatalf("sentence: %v", err)
+		}
+		return
+	}
+
 	// Text mode: --text is non-empty
 	if textStr != "" {
 		if textModel == "" || tokenizerPath == "" {
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/stevecastle/shrike/embedvec"
+	"github.com/stevecastle/shrike/onnxtag"
+)
+
+// sentenceFlags carries the sentence-mode CLI configuration from main.
+type sentenceFlags struct {
+	model     string // --text-model
+	tokenizer string
+	input     string // --text-input
+	mask      string // --mask-input
+	output    string // --text-output
+	dim       int
+	seqLen    int
+	pooling   string // "cls" takes token 0; anything else mean-pools
+	ortLib    string
+	provider  string
+	device    int
+	threads   int
+	serve     bool
+	text      string // one-shot input (--text)
+}
+
+// runSentence encodes text with a sentence encoder (semantic transcript
+// search). One-shot mode prints one base64 vector for --text; serve mode
+// emits READY, then reads one text line per request on stdin and writes one
+// base64 vector (or "ERR <msg>") per line, exactly like image --serve.
+func runSentence(f sentenceFlags) error {
+	if f.model == "" || f.tokenizer == "" {
+		return fmt.Errorf("--text-model and --tokenizer are required in sentence mode")
+	}
+	pooling := "mean"
+	if strings.EqualFold(strings.TrimSpace(f.pooling), "cls") {
+		pooling = "cls"
+	}
+	emb, err := onnxtag.NewSentenceEmbedder(onnxtag.SentenceEmbedderConfig{
+		ModelPath:     f.model,
+		TokenizerPath: f.tokenizer,
+		InputName:     f.input,
+		MaskName:      f.mask,
+		OutputName:    f.output,
+		Dim:           f.dim,
+		SeqLen:        f.seqLen,
+		Pooling:       pooling,
+		Provider:      onnxtag.EmbedProvider(strings.ToLower(strings.TrimSpace(f.provider))),
+		Threads:       f.threads,
+		Device:        f.device,
+		ORTLib:        f.ortLib,
+	})
+	if err != nil {
+		return err
+	}
+	defer emb.Close()
+
+	encode := func(text string) (string, error) {
+		vec, err := emb.Embed(text)
+		if err != nil {
+			return "", err
+		}
+		return base64.StdEncoding.EncodeToString(embedvec.Encode(embedvec.Normalize(vec))), nil
+	}
+
+	if !f.serve {
+		line, err := encode(f.text)
+		if err != nil {
+			return err
+		}
+		fmt.Println(line)
+		return nil
+	}
+
+	in := bufio.NewScanner(os.Stdin)
+	in.Buffer(make([]byte, 0, 64*1024), 4*1024*1024) // transcript chunks can be long
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	fmt.Fprintln(out, "READY")
+	if err := out.Flush(); err != nil {
+		return err
+	}
+	for in.Scan() {
+		text := strings.TrimSpace(in.Text())
+		if text == "" {
+			fmt.Fprintln(out, "ERR empty text")
+			out.Flush()
+			continue
+		}
+		line, err := encode(text)
+		if err != nil {
+			fmt.Fprintln(out, "ERR "+strings.ReplaceAll(err.Error(), "\n", " "))
+			out.Flush()
+			continue
+		}
+		fmt.Fprintln(out, line)
+		out.Flush()
+	}
+	return in.Err()
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/stevecastle/shrike/diarize"
+	"github.com/stevecastle/shrike/embedvec"
+	"github.com/stevecastle/shrike/onnxtag"
+)
+
+// speakerFlags carries the speaker-mode CLI configuration from main.
+type speakerFlags struct {
+	model    string // --speaker-model
+	input    string // --speaker-input ("" = the model's first input)
+	output   string // --speaker-output ("" = the model's first output)
+	ortLib   string
+	provider string
+	device   int
+	threads  int
+}
+
+// runSpeaker embeds voices for diarization. It is serve-only: after READY,
+// each stdin line is "<pcm path>\t<start ms>\t<end ms>" naming a span of a
+// raw 16 kHz mono s16le file, answered with one base64 speaker embedding
+// (or "ERR <msg>") per line. The parent extracts the audio once and sends
+// every cue against the same file.
+func runSpeaker(f speakerFlags) error {
+	if f.model == "" {
+		return fmt.Errorf("--speaker-model is required in speaker mode")
+	}
+	emb, err := onnxtag.NewSpeakerEmbedder(onnxtag.SpeakerEmbedderConfig{
+		ModelPath:  f.model,
+		InputName:  f.input,
+		OutputName: f.output,
+		Provider:   onnxtag.EmbedProvider(strings.ToLower(strings.TrimSpace(f.provider))),
+		Threads:    f.threads,
+		Device:     f.device,
+		ORTLib:     f.ortLib,
+	})
+	if err != nil {
+		return err
+	}
+	defer emb.Close()
+
+	in := bufio.NewScanner(os.Stdin)
+	in.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	fmt.Fprintln(out, "READY")
+	if err := out.Flush(); err != nil {
+		return err
+	}
+	for in.Scan() {
+		line, err := embedSpan(emb, in.Text())
+		if err != nil {
+			line = "ERR " + strings.ReplaceAll(err.Error(), "\n", " ")
+		}
+		fmt.Fprintln(out, line)
+		out.Flush()
+	}
+	return in.Err()
+}
+
+// embedSpan handles one "<path>\t<start ms>\t<end ms>" request.
+func embedSpan(emb *onnxtag.SpeakerEmbedder, req string) (string, error) {
+	parts := strings.Split(strings.TrimSpace(req), "\t")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("want \"path<TAB>start ms<TAB>end ms\", got %q", req)
+	}
+	startMs, err1 := strconv.ParseInt(parts[1], 10, 64)
+	endMs, err2 := strconv.ParseInt(parts[2], 10, 64)
+	if err1 != nil || err2 != nil || startMs < 0 || endMs <= startMs {
+		return "", fmt.Errorf("bad span %s-%s", parts[1], parts[2])
+	}
+	pcm, err := readPCMSpan(parts[0], startMs, endMs)
+	if err != nil {
+		return "", err
+	}
+	feats, frames := diarize.Fbank(pcm)
+	if frames == 0 {
+		return "", fmt.Errorf("span too short")
+	}
+	diarize.CMN(feats, frames)
+	vec, err := emb.Embed(feats, frames, diarize.MelBins)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(embedvec.Encode(embedvec.Normalize(vec))), nil
+}
+
+// readPCMSpan reads [startMs, endMs) of a raw 16 kHz mono s16le file,
+// clipped to the file's end.
+func readPCMSpan(path string, startMs, endMs int64) ([]int16, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	const bytesPerMs = diarize.SampleRate / 1000 * 2
+	buf := make([]byte, (endMs-startMs)*bytesPerMs)
+	n, err := f.ReadAt(buf, startMs*bytesPerMs)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	pcm := make([]int16, n/2)
+	for i := range pcm {
+		pcm[i] = int16(binary.LittleEndian.Uint16(buf[2*i:]))
+	}
+	return pcm, nil
+}
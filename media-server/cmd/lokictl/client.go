@@ -68,6 +68,8 @@ func hintForStatus(status int) string {
 		return "run: lokictl login (or lokictl login --password <password> on a headless machine)"
 	case http.StatusNotFound:
 		return "endpoint or resource not found — the server may be older than this CLI"
+	case http.StatusTooManyRequests:
+		return "too many failed attempts — wait a while before retrying"
 	}
 	return ""
 }
@@ -107,6 +107,31 @@ func TestLoginStoresConfig(t *testing.T) {
 	}
 }
 
+func TestLoginAsksForTOTPCode(t *testing.T) {
+	t.Setenv("LOKICTL_CONFIG_DIR", t.TempDir())
+	var sent map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&sent)
+		if sent["code"] == "" {
+			http.Error(w, `{"error":"totp_required"}`, http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"ok","token":"jwt-abc"}`))
+	}))
+	defer srv.Close()
+
+	var out, errOut strings.Builder
+	if code := run([]string{"--server", srv.URL, "login", "--username", "steve", "--password", "pw"}, &out, &errOut); code == 0 ||
+		!strings.Contains(errOut.String(), "--code") {
+		t.Errorf("without code: exit = %d; stderr = %s", code, errOut.String())
+	}
+	errOut.Reset()
+	if code := run([]string{"--server", srv.URL, "login", "--username", "steve", "--password", "pw", "--code", "123456"}, &out, &errOut); code != 0 ||
+		sent["code"] != "123456" {
+		t.Errorf("with code: exit = %d; sent = %v; stderr = %s", code, sent, errOut.String())
+	}
+}
+
 func TestUnknownCommandExits2(t *testing.T) {
 	var out, errOut strings.Builder
 	code := run([]string{"frobnicate"}, &out, &errOut)
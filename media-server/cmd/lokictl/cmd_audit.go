@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+func init() {
+	register(command{group: "audit",
+		args:    "[--actor U] [--action A] [--target T] [--since 7d|DATE] [--until DATE] [--before ID] [--limit N]",
+		summary: "Query the audit log of library mutations, newest first (GET /api/audit)", run: cmdAudit})
+}
+
+func cmdAudit(a *App, args []string) int {
+	fs := flag.NewFlagSet("audit", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	actor := fs.String("actor", "", "only this user or key owner")
+	action := fs.String("action", "", `exact action or dotted prefix ("tag" matches tag.rename, tag.delete, ...)`)
+	target := fs.String("target", "", "only entries touching this path or id (e.g. tag:cat, person:12)")
+	since := fs.String("since", "", "lookback (7d, 12h) or a start time (unix, RFC 3339, YYYY-MM-DD)")
+	until := fs.String("until", "", "end time, exclusive (unix, RFC 3339, YYYY-MM-DD)")
+	before := fs.Int64("before", 0, "page: entries older than this id")
+	limit := fs.Int("limit", 0, "max entries (default 100, at most 1000)")
+	if err := fs.Parse(args); err != nil {
+		return a.Usage(fs, err.Error())
+	}
+	if fs.NArg() > 0 {
+		return a.Usage(fs, "audit takes flags only")
+	}
+	q := url.Values{}
+	set := func(k, v string) {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	set("actor", *actor)
+	set("action", *action)
+	set("target", *target)
+	set("until", *until)
+	if *since != "" {
+		// A lifetime-style value is a lookback from now; anything else is
+		// passed through for the server to parse.
+		if d, err := parseLifetime(*since); err == nil {
+			set("since", strconv.FormatInt(time.Now().Add(-d).Unix(), 10))
+		} else {
+			set("since", *since)
+		}
+	}
+	if *before > 0 {
+		set("before", strconv.FormatInt(*before, 10))
+	}
+	if *limit > 0 {
+		set("limit", strconv.Itoa(*limit))
+	}
+	path := "/api/audit"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	var out []map[string]any
+	if err := a.Client.DoJSON("GET", path, nil, &out); err != nil {
+		return a.Fail(err)
+	}
+	if out == nil {
+		out = []map[string]any{}
+	}
+	return a.PrintJSON(out)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestAuditFilters(t *testing.T) {
+	var got *url.URL
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL
+		_, _ = w.Write([]byte(`[{"id":3,"action":"tag.delete"}]`))
+	}))
+	t.Cleanup(srv.Close)
+	a, out, _ := appForServer(srv.URL)
+	if code := cmdAudit(a, []string{"--action", "tag", "--target", "tag:cat", "--since", "7d", "--until", "2026-01-01", "--limit", "5"}); code != 0 {
+		t.Fatalf("exit = %d", code)
+	}
+	q := got.Query()
+	if got.Path != "/api/audit" || q.Get("action") != "tag" || q.Get("target") != "tag:cat" ||
+		q.Get("until") != "2026-01-01" || q.Get("limit") != "5" || q.Has("actor") {
+		t.Errorf("request = %s", got)
+	}
+	since, _ := strconv.ParseInt(q.Get("since"), 10, 64)
+	if want := time.Now().Add(-7 * 24 * time.Hour).Unix(); since < want-5 || since > want+5 {
+		t.Errorf("since = %d, want about %d", since, want)
+	}
+	if out.Len() == 0 {
+		t.Error("nothing printed")
+	}
+
+	if code := cmdAudit(a, []string{"stray"}); code != 2 {
+		t.Errorf("positional arg: exit = %d, want 2", code)
+	}
+}
@@ -15,12 +15,13 @@ import (
 	"os"
 	"os/exec"
 	"runtime"
+	"strings"
 	"time"
 )
 
 func init() {
 	register(command{
-		group: "login", args: "[--password P [--username U]] [--no-browser]",
+		group: "login", args: "[--password P [--username U] [--code C]] [--no-browser]",
 		summary: "Log in — opens the browser to authorize (default); --password logs in directly (headless)",
 		run:     cmdLogin,
 	})
@@ -31,19 +32,20 @@ func cmdLogin(a *App, args []string) int {
 	fs.SetOutput(io.Discard)
 	user := fs.String("username", "admin", "username (with --password)")
 	pass := fs.String("password", "", "password — skips the browser flow")
+	code := fs.String("code", "", "authenticator or recovery code, for accounts with two-factor auth (with --password)")
 	noBrowser := fs.Bool("no-browser", false, "print the authorization URL instead of opening a browser")
 	if err := fs.Parse(args); err != nil {
 		return a.Usage(fs, err.Error())
 	}
 	if *pass != "" {
-		return passwordLogin(a, *user, *pass)
+		return passwordLogin(a, *user, *pass, *code)
 	}
 	return browserLogin(a, *noBrowser)
 }
 
 /* ---- password login (headless / scripted) --------------------------- */
 
-func passwordLogin(a *App, user, pass string) int {
+func passwordLogin(a *App, user, pass, code string) int {
 	var resp struct {
 		Status        string `json:"status"`
 		Token         string `json:"token"`
@@ -52,7 +54,12 @@ func passwordLogin(a *App, user, pass string) int {
 	err := a.Client.DoJSON("POST", "/auth/login", map[string]string{
 		"username": user,
 		"password": pass,
+		"code":     code,
 	}, &resp)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && strings.Contains(apiErr.Body, "totp_required") {
+		return a.Fail(fmt.Errorf("%s has two-factor authentication on — rerun with --code <code from your authenticator app, or a recovery code>", user))
+	}
 	if err != nil {
 		return a.Fail(err)
 	}
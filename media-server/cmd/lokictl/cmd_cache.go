@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"net/url"
+)
+
+func init() {
+	register(command{group: "cache", name: "stats",
+		summary: "Inference result cache size, hit/miss counts, per-model usage (GET /api/result-cache)",
+		run:     cmdCacheStats})
+	register(command{group: "cache", name: "invalidate", args: "--model M [--provider P]",
+		summary: "Drop cached descriptions/tags/transcripts from one model (DELETE /api/result-cache)",
+		run:     cmdCacheInvalidate})
+	register(command{group: "cache", name: "clear", args: "--yes",
+		summary: "Drop every cached inference result (DELETE /api/result-cache?confirm=true)",
+		run:     cmdCacheClear})
+}
+
+func cmdCacheStats(a *App, args []string) int {
+	if len(args) > 0 {
+		return a.Usage(nil, "cache stats takes no arguments")
+	}
+	var out any
+	if err := a.Client.DoJSON("GET", "/api/result-cache", nil, &out); err != nil {
+		return a.Fail(err)
+	}
+	return a.PrintJSON(out)
+}
+
+func cmdCacheInvalidate(a *App, args []string) int {
+	fs := flag.NewFlagSet("cache invalidate", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	model := fs.String("model", "", "model whose results to drop (required)")
+	provider := fs.String("provider", "", "only under this provider (default: any)")
+	if err := fs.Parse(args); err != nil {
+		return a.Usage(fs, err.Error())
+	}
+	if *model == "" || fs.NArg() > 0 {
+		return a.Usage(fs, "usage: lokictl cache invalidate --model M [--provider P]")
+	}
+	q := url.Values{"model": {*model}}
+	if *provider != "" {
+		q.Set("provider", *provider)
+	}
+	var out any
+	if err := a.Client.DoJSON("DELETE", "/api/result-cache?"+q.Encode(), nil, &out); err != nil {
+		return a.Fail(err)
+	}
+	return a.PrintJSON(out)
+}
+
+func cmdCacheClear(a *App, args []string) int {
+	if !hasYesFlag(args) {
+		return a.Usage(nil, "this drops every cached inference result — re-run with --yes to confirm")
+	}
+	var out any
+	if err := a.Client.DoJSON("DELETE", "/api/result-cache?confirm=true", nil, &out); err != nil {
+		return a.Fail(err)
+	}
+	return a.PrintJSON(out)
+}
@@ -4,6 +4,9 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 )
@@ -42,6 +45,12 @@ func init() {
 	register(command{group: "deps", name: "download", args: "<model-id> [--wait] [--timeout D]",
 		summary: "Download a model (POST /api/deps/models/{id}/download); --wait polls until installed",
 		run:     cmdDepsDownload})
+	register(command{group: "deps", name: "import", args: "<dir|bundle.tar|bundle.7z> [--id ID]...",
+		summary: "Install models offline from a bundle on the server's disk, checksum-verified (POST /api/deps/models/import)",
+		run:     cmdDepsImport})
+	register(command{group: "deps", name: "export", args: "[model-id...] [--out FILE]",
+		summary: "Pack installed models into a .tar for an offline machine (GET /api/deps/models/export)",
+		run:     cmdDepsExport})
 	register(command{group: "deps", name: "verify", args: "<model-id>",
 		summary: "Verify a model's files (POST /api/deps/models/{id}/verify)",
 		run: func(a *App, args []string) int {
@@ -134,3 +143,91 @@ func cmdDepsDownload(a *App, args []string) int {
 		time.Sleep(2 * time.Second)
 	}
 }
+
+// depsImportResult mirrors deps/models.ImportResult.
+type depsImportResult struct {
+	Installed  []string            `json:"installed"`
+	Incomplete map[string][]string `json:"incomplete,omitempty"`
+	Failed     map[string]string   `json:"failed,omitempty"`
+}
+
+func cmdDepsImport(a *App, args []string) int {
+	fs := flag.NewFlagSet("deps import", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var ids stringList
+	fs.Var(&ids, "id", "import only this model (repeatable; default: every model the bundle holds)")
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return a.Usage(fs, "usage: lokictl deps import <dir|bundle.tar|bundle.7z> [--id ID]...")
+	}
+	src := args[0]
+	if err := fs.Parse(args[1:]); err != nil {
+		return a.Usage(fs, err.Error())
+	}
+	// The server reads the bundle itself, so send an absolute path; with the
+	// usual same-machine setup that is the path as typed here.
+	if abs, err := filepath.Abs(src); err == nil {
+		src = abs
+	}
+	body := map[string]any{"path": src}
+	if len(ids) > 0 {
+		body["ids"] = []string(ids)
+	}
+	// Imports copy and hash gigabytes; run on the untimed client.
+	c := *a.Client
+	c.HTTP = a.Client.Stream
+	var res depsImportResult
+	if err := c.DoJSON("POST", "/api/deps/models/import", body, &res); err != nil {
+		return a.Fail(err)
+	}
+	if code := a.PrintJSON(res); code != 0 {
+		return code
+	}
+	if len(res.Failed) > 0 || len(res.Incomplete) > 0 {
+		return 1
+	}
+	return 0
+}
+
+func cmdDepsExport(a *App, args []string) int {
+	fs := flag.NewFlagSet("deps export", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	out := fs.String("out", "lowkey-models.tar", "bundle file to write")
+	var ids []string
+	for len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		ids = append(ids, args[0])
+		args = args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return a.Usage(fs, err.Error())
+	}
+	if fs.NArg() > 0 {
+		return a.Usage(fs, "usage: lokictl deps export [model-id...] [--out FILE]")
+	}
+	path := "/api/deps/models/export"
+	if len(ids) > 0 {
+		path += "?ids=" + url.QueryEscape(strings.Join(ids, ","))
+	}
+	resp, err := a.Client.DoStream("GET", path)
+	if err != nil {
+		return a.Fail(err)
+	}
+	defer resp.Body.Close()
+
+	partial := *out + ".partial"
+	f, err := os.Create(partial)
+	if err != nil {
+		return a.Fail(err)
+	}
+	n, err := io.Copy(f, resp.Body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(partial, *out)
+	}
+	if err != nil {
+		_ = os.Remove(partial)
+		return a.Fail(err)
+	}
+	return a.PrintJSON(map[string]any{"path": *out, "bytes": n})
+}
@@ -2,9 +2,12 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"net/url"
 	"strings"
 	"time"
 )
@@ -93,6 +96,110 @@ func waitForJob(a *App, id string, timeout time.Duration) (*jobRow, int) {
 	}
 }
 
+// streamReconnectDelay is the pause before reconnecting a dropped /stream;
+// a variable so tests can shrink it.
+var streamReconnectDelay = 1 * time.Second
+
+// waitForJobStream is waitForJob driven by /stream?topics=job:<id> instead
+// of polling. A dropped connection reconnects with the last event id so the
+// server replays the updates sent meanwhile; the job is refetched only on
+// the first connect and when the server answers "resync" because it no
+// longer has them. Exit codes match waitForJob.
+func waitForJobStream(a *App, id string, timeout time.Duration) (*jobRow, int) {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	finish := func(job *jobRow) (*jobRow, int) {
+		if job.State == "completed" {
+			return job, 0
+		}
+		return job, 3
+	}
+
+	var job *jobRow
+	var lastID string
+	var failed error
+	// refetch reports whether the wait is over (finished job or API error).
+	refetch := func() bool {
+		j, err := fetchJob(a, id)
+		if err != nil {
+			failed = err
+			return true
+		}
+		job = j
+		return isTerminalState(j.State)
+	}
+	for attempt := 0; ; attempt++ {
+		path := "/stream?topics=" + url.QueryEscape("job:"+id)
+		if lastID != "" {
+			path += "&lastEventId=" + url.QueryEscape(lastID)
+		}
+		resp, err := a.Client.DoStream("GET", path)
+		var apiErr *APIError
+		if err != nil && (attempt == 0 || errors.As(err, &apiErr)) {
+			a.Fail(err)
+			return nil, 1
+		}
+		if err == nil {
+			// Subscribed first, then fetched: an update landing in between
+			// arrives on the stream rather than being lost.
+			done := lastID == "" && refetch()
+			if !done {
+				stop := make(chan struct{})
+				go func() {
+					select {
+					case <-ctx.Done():
+					case <-stop:
+					}
+					resp.Body.Close()
+				}()
+				_ = readSSE(ctx, resp.Body, func(ev sseEvent) bool {
+					if ev.ID != "" {
+						lastID = ev.ID
+					}
+					switch ev.Name {
+					case "resync":
+						done = refetch()
+					case "update", "delete":
+						var upd struct {
+							Job jobRow `json:"job"`
+						}
+						if json.Unmarshal([]byte(ev.Data), &upd) != nil || upd.Job.ID != id {
+							break
+						}
+						if ev.Name == "delete" {
+							failed = fmt.Errorf("job %q was removed", id)
+							done = true
+						} else {
+							job = &upd.Job
+							done = isTerminalState(job.State)
+						}
+					}
+					return !done
+				})
+				close(stop)
+			}
+			resp.Body.Close()
+			if failed != nil {
+				a.Fail(failed)
+				return nil, 1
+			}
+			if done {
+				return finish(job)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			fmt.Fprintf(a.ErrOut, `{"error":"timed out waiting for job %s (state: %s)"}`+"\n", id, job.State)
+			return job, 3
+		case <-time.After(streamReconnectDelay):
+		}
+	}
+}
+
 // buildJobInput assembles the /create input string. ParseCommand on the
 // server splits on spaces honoring double quotes, with no escape syntax —
 // so tokens containing spaces are quoted and embedded quotes are rejected.
@@ -132,10 +239,11 @@ func init() {
 		summary: "List jobs (GET /jobs/list)", run: cmdJobList})
 	register(command{group: "job", name: "get", args: "<id>",
 		summary: "Show one job", run: cmdJobGet})
-	register(command{group: "job", name: "wait", args: "<id> [--timeout D]",
-		summary: "Block until a job reaches a terminal state", run: cmdJobWait})
-	register(command{group: "job", name: "logs", args: "<id>",
-		summary: "Stream a running job's stdout via SSE (live jobs only; output is not stored)",
+	register(command{group: "job", name: "wait", args: "<id> [--timeout D] [--follow]",
+		summary: "Block until a job reaches a terminal state; --follow listens on /stream instead of polling",
+		run:     cmdJobWait})
+	register(command{group: "job", name: "logs", args: "<id> [--after SEQ] [--follow]",
+		summary: "Print a job's stored log (GET /job/{id}/logs); --follow streams new lines until the job finishes",
 		run:     cmdJobLogs})
 	register(command{group: "job", name: "cancel", args: "<id>",
 		summary: "Cancel a job (POST /job/{id}/cancel)", run: jobAction("cancel")})
@@ -259,23 +367,42 @@ func cmdJobRun(a *App, args []string) int {
 	return code
 }
 
-// followJobStdout attaches to /stream and relays stdout-<id> events to
-// stderr (stdout is reserved for the final JSON result).
+// followJobStdout relays a job's log to stderr (stdout is reserved for the
+// final JSON result).
 func followJobStdout(ctx context.Context, a *App, id string) {
-	resp, err := a.Client.DoStream("GET", "/stream")
+	if err := followJobLog(ctx, a, id, 0, a.ErrOut); err != nil && ctx.Err() == nil {
+		fmt.Fprintf(a.ErrOut, `{"warning":"could not attach to job log stream: %s"}`+"\n", err)
+	}
+}
+
+// jobLogLine is one line of GET /job/{id}/logs and the follow stream.
+type jobLogLine struct {
+	Seq   int64  `json:"seq"`
+	Level string `json:"level"`
+	Line  string `json:"line"`
+}
+
+// followJobLog prints the job's log lines after seq as
+// /job/{id}/logs/follow delivers them, returning once the job has finished.
+func followJobLog(ctx context.Context, a *App, id string, after int64, w io.Writer) error {
+	resp, err := a.Client.DoStream("GET", fmt.Sprintf("/job/%s/logs/follow?after=%d", id, after))
 	if err != nil {
-		fmt.Fprintf(a.ErrOut, `{"warning":"could not attach to job output stream: %s"}`+"\n", err)
-		return
+		return err
 	}
 	defer resp.Body.Close()
 	go func() {
 		<-ctx.Done()
 		resp.Body.Close()
 	}()
-	want := "stdout-" + id
-	_ = readSSE(ctx, resp.Body, func(ev sseEvent) bool {
-		if ev.Name == want && ev.Data != "" {
-			fmt.Fprintln(a.ErrOut, ev.Data)
+	return readSSE(ctx, resp.Body, func(ev sseEvent) bool {
+		switch ev.Name {
+		case "log":
+			var l jobLogLine
+			if json.Unmarshal([]byte(ev.Data), &l) == nil {
+				fmt.Fprintln(w, l.Line)
+			}
+		case "end":
+			return false
 		}
 		return true
 	})
@@ -322,14 +449,19 @@ func cmdJobWait(a *App, args []string) int {
 	fs := flag.NewFlagSet("job wait", flag.ContinueOnError)
 	fs.SetOutput(io.Discard)
 	timeout := fs.Duration("timeout", 0, "give up after this long (0 = wait forever)")
+	follow := fs.Bool("follow", false, "wait on the event stream, resuming after reconnects, instead of polling")
 	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
-		return a.Usage(fs, "usage: lokictl job wait <id> [--timeout D]")
+		return a.Usage(fs, "usage: lokictl job wait <id> [--timeout D] [--follow]")
 	}
 	id := args[0]
 	if err := fs.Parse(args[1:]); err != nil {
 		return a.Usage(fs, err.Error())
 	}
-	job, code := waitForJob(a, id, *timeout)
+	wait := waitForJob
+	if *follow {
+		wait = waitForJobStream
+	}
+	job, code := wait(a, id, *timeout)
 	if job != nil {
 		if pc := a.PrintJSON(job); code == 0 && pc != 0 {
 			return pc
@@ -339,30 +471,41 @@ func cmdJobWait(a *App, args []string) int {
 }
 
 func cmdJobLogs(a *App, args []string) int {
-	if len(args) != 1 {
-		return a.Usage(nil, "usage: lokictl job logs <id>")
+	fs := flag.NewFlagSet("job logs", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	after := fs.Int64("after", 0, "print only lines after this sequence number")
+	follow := fs.Bool("follow", false, "keep streaming new lines until the job finishes")
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return a.Usage(fs, "usage: lokictl job logs <id> [--after SEQ] [--follow]")
 	}
 	id := args[0]
-	// Surface a clear error if the job is already done (its stdout is gone).
-	if job, err := fetchJob(a, id); err == nil && isTerminalState(job.State) {
-		return a.Fail(fmt.Errorf("job %s is %s — stdout is only streamed live and is not stored", id, job.State))
+	if err := fs.Parse(args[1:]); err != nil {
+		return a.Usage(fs, err.Error())
 	}
-	resp, err := a.Client.DoStream("GET", "/stream")
-	if err != nil {
-		return a.Fail(err)
+	if *follow {
+		if err := followJobLog(context.Background(), a, id, *after, a.Out); err != nil {
+			return a.Fail(err)
+		}
+		return 0
 	}
-	defer resp.Body.Close()
-	want := "stdout-" + id
-	err = readSSE(context.Background(), resp.Body, func(ev sseEvent) bool {
-		if ev.Name == want && ev.Data != "" {
-			fmt.Fprintln(a.Out, ev.Data)
+	seq := *after
+	for {
+		var page struct {
+			Lines []jobLogLine `json:"lines"`
+			Last  int64        `json:"last"`
+		}
+		path := fmt.Sprintf("/job/%s/logs?after=%d", id, seq)
+		if err := a.Client.DoJSON("GET", path, nil, &page); err != nil {
+			return a.Fail(err)
+		}
+		for _, l := range page.Lines {
+			fmt.Fprintln(a.Out, l.Line)
+			seq = l.Seq
+		}
+		if len(page.Lines) == 0 || seq >= page.Last {
+			return 0
 		}
-		return true
-	})
-	if err != nil {
-		return a.Fail(err)
 	}
-	return 0
 }
 
 func jobAction(action string) func(a *App, args []string) int {
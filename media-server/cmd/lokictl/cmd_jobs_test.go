@@ -62,7 +62,7 @@ func TestSplitControlFlags(t *testing.T) {
 func TestReadSSE(t *testing.T) {
 	stream := "event: connected\ndata: hi\n\n" +
 		": keep-alive\n\n" +
-		"event: stdout-abc\ndata: line one\ndata: line two\n\n"
+		"id: 7\nevent: stdout-abc\ndata: line one\ndata: line two\n\n"
 	var got []sseEvent
 	err := readSSE(context.Background(), strings.NewReader(stream), func(ev sseEvent) bool {
 		got = append(got, ev)
@@ -77,7 +77,7 @@ func TestReadSSE(t *testing.T) {
 	if got[0].Name != "connected" || got[0].Data != "hi" {
 		t.Errorf("ev0 = %+v", got[0])
 	}
-	if got[1].Name != "stdout-abc" || got[1].Data != "line one\nline two" {
+	if got[1].ID != "7" || got[1].Name != "stdout-abc" || got[1].Data != "line one\nline two" {
 		t.Errorf("ev1 = %+v", got[1])
 	}
 }
@@ -199,3 +199,115 @@ func TestJobListStateFilter(t *testing.T) {
 		t.Errorf("stdout = %s", out.String())
 	}
 }
+
+// job logs pages through the stored log until it reaches the newest line.
+func TestJobLogsPages(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/job/j1/logs" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		switch r.URL.Query().Get("after") {
+		case "1":
+			_, _ = w.Write([]byte(`{"lines":[{"seq":2,"line":"two"},{"seq":3,"line":"three"}],"last":4}`))
+		case "3":
+			_, _ = w.Write([]byte(`{"lines":[{"seq":4,"line":"four"}],"last":4}`))
+		default:
+			t.Errorf("unexpected after=%s", r.URL.Query().Get("after"))
+		}
+	}))
+	defer srv.Close()
+	a, out, errOut := appForServer(srv.URL)
+	if code := cmdJobLogs(a, []string{"j1", "--after", "1"}); code != 0 {
+		t.Fatalf("exit = %d; stderr = %s", code, errOut.String())
+	}
+	if out.String() != "two\nthree\nfour\n" {
+		t.Errorf("stdout = %q", out.String())
+	}
+}
+
+func TestJobLogsFollow(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/job/j1/logs/follow" || r.URL.Query().Get("after") != "0" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("id: 1\nevent: log\ndata: {\"seq\":1,\"line\":\"one\"}\n\n" +
+			": keep-alive\n\n" +
+			"id: 2\nevent: log\ndata: {\"seq\":2,\"line\":\"two\"}\n\n" +
+			"event: end\ndata: \"completed\"\n\n"))
+	}))
+	defer srv.Close()
+	a, out, errOut := appForServer(srv.URL)
+	if code := cmdJobLogs(a, []string{"j1", "--follow"}); code != 0 {
+		t.Fatalf("exit = %d; stderr = %s", code, errOut.String())
+	}
+	if out.String() != "one\ntwo\n" {
+		t.Errorf("stdout = %q", out.String())
+	}
+}
+
+// The first connection drops after one update; the reconnect must resume
+// from its id, and the finished job arrives as a replayed update.
+func TestJobWaitFollowResumes(t *testing.T) {
+	old := streamReconnectDelay
+	streamReconnectDelay = time.Millisecond
+	defer func() { streamReconnectDelay = old }()
+
+	var conns int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/jobs/list":
+			_, _ = w.Write([]byte(`[{"id":"j1","state":"in_progress"}]`))
+		case "/stream":
+			if got := r.URL.Query().Get("topics"); got != "job:j1" {
+				t.Errorf("topics = %q", got)
+			}
+			w.Header().Set("Content-Type", "text/event-stream")
+			switch atomic.AddInt32(&conns, 1) {
+			case 1:
+				if r.URL.Query().Has("lastEventId") {
+					t.Errorf("first connect sent lastEventId")
+				}
+				_, _ = w.Write([]byte("id: 4\nevent: update\ndata: {\"job\":{\"id\":\"j1\",\"state\":\"in_progress\"}}\n\n"))
+			default:
+				if got := r.URL.Query().Get("lastEventId"); got != "4" {
+					t.Errorf("reconnect lastEventId = %q, want 4", got)
+				}
+				_, _ = w.Write([]byte("id: 5\nevent: update\ndata: {\"job\":{\"id\":\"other\",\"state\":\"error\"}}\n\n" +
+					"id: 6\nevent: update\ndata: {\"job\":{\"id\":\"j1\",\"state\":\"completed\"}}\n\n"))
+			}
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+	a, out, errOut := appForServer(srv.URL)
+	if code := cmdJobWait(a, []string{"j1", "--follow", "--timeout", "5s"}); code != 0 {
+		t.Fatalf("exit = %d; stderr = %s", code, errOut.String())
+	}
+	if !strings.Contains(out.String(), `"completed"`) || atomic.LoadInt32(&conns) != 2 {
+		t.Errorf("stdout = %s; connections = %d", out.String(), conns)
+	}
+}
+
+func TestJobWaitFollowResyncRefetches(t *testing.T) {
+	var lists int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/jobs/list":
+			state := "in_progress"
+			if atomic.AddInt32(&lists, 1) > 1 {
+				state = "cancelled"
+			}
+			_, _ = w.Write([]byte(`[{"id":"j1","state":"` + state + `"}]`))
+		case "/stream":
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("event: resync\ndata: {\"type\":\"resync\"}\n\n"))
+		}
+	}))
+	defer srv.Close()
+	a, _, errOut := appForServer(srv.URL)
+	if code := cmdJobWait(a, []string{"j1", "--follow"}); code != 3 {
+		t.Fatalf("exit = %d, want 3 for a cancelled job; stderr = %s", code, errOut.String())
+	}
+}
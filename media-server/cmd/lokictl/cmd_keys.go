@@ -5,17 +5,19 @@ import (
 	"fmt"
 	"io"
 	"strconv"
+	"strings"
+	"time"
 )
 
 func init() {
 	register(command{
-		group: "key", name: "create", args: "--name N [--username U] [--save]",
+		group: "key", name: "create", args: "--name N [--username U] [--scope S]... [--expires 30d] [--allow-ip CIDR]... [--save]",
 		summary: "Create an API key (POST /auth/keys); --save stores it as this CLI's token",
 		run:     cmdKeyCreate,
 	})
 	register(command{
 		group: "key", name: "list",
-		summary: "List API keys (GET /auth/keys)",
+		summary: "List API keys with their scopes and expiry (GET /auth/keys)",
 		run:     cmdKeyList,
 	})
 	register(command{
@@ -31,24 +33,42 @@ func cmdKeyCreate(a *App, args []string) int {
 	name := fs.String("name", "", "key name, e.g. \"lokictl\" (required)")
 	user := fs.String("username", "", "key owner (default: the authenticated user)")
 	save := fs.Bool("save", false, "store the new key as this CLI's token in the config file")
+	expires := fs.String("expires", "", "lifetime, e.g. 30d, 2w, 12h (default: never expires)")
+	var scopes, allowIPs stringList
+	fs.Var(&scopes, "scope", "limit the key to a scope: media:read, tags:write, jobs:run[:<task>], db:query, admin (repeatable)")
+	fs.Var(&allowIPs, "allow-ip", "only accept the key from this IP or CIDR (repeatable)")
 	if err := fs.Parse(args); err != nil {
 		return a.Usage(fs, err.Error())
 	}
 	if *name == "" {
 		return a.Usage(fs, "--name is required")
 	}
+	var expiresAt int64
+	if *expires != "" {
+		d, err := parseLifetime(*expires)
+		if err != nil {
+			return a.Usage(fs, err.Error())
+		}
+		expiresAt = time.Now().Add(d).Unix()
+	}
 
 	var resp struct {
-		Status   string `json:"status"`
-		Key      string `json:"key"`
-		ID       int64  `json:"id"`
-		Name     string `json:"name"`
-		Username string `json:"username"`
-		Prefix   string `json:"prefix"`
-	}
-	err := a.Client.DoJSON("POST", "/auth/keys", map[string]string{
-		"name":     *name,
-		"username": *user,
+		Status   string   `json:"status"`
+		Key      string   `json:"key"`
+		ID       int64    `json:"id"`
+		Name     string   `json:"name"`
+		Username string   `json:"username"`
+		Prefix   string   `json:"prefix"`
+		Scopes   []string `json:"scopes"`
+		Expires  int64    `json:"expires_at"`
+		CIDRs    []string `json:"allowed_cidrs"`
+	}
+	err := a.Client.DoJSON("POST", "/auth/keys", map[string]any{
+		"name":          *name,
+		"username":      *user,
+		"scopes":        []string(scopes),
+		"expires_at":    expiresAt,
+		"allowed_cidrs": []string(allowIPs),
 	}, &resp)
 	if err != nil {
 		return a.Fail(err)
@@ -58,12 +78,15 @@ func cmdKeyCreate(a *App, args []string) int {
 	}
 
 	out := map[string]any{
-		"status":   "ok",
-		"key":      resp.Key, // shown once; the server stores only a hash
-		"id":       resp.ID,
-		"name":     resp.Name,
-		"username": resp.Username,
-		"prefix":   resp.Prefix,
+		"status":        "ok",
+		"key":           resp.Key, // shown once; the server stores only a hash
+		"id":            resp.ID,
+		"name":          resp.Name,
+		"username":      resp.Username,
+		"prefix":        resp.Prefix,
+		"scopes":        resp.Scopes,
+		"expires_at":    resp.Expires,
+		"allowed_cidrs": resp.CIDRs,
 	}
 	if *save {
 		cfg := loadCLIConfig()
@@ -78,6 +101,36 @@ func cmdKeyCreate(a *App, args []string) int {
 	return a.PrintJSON(out)
 }
 
+// parseLifetime accepts Go durations plus whole days ("30d") and weeks
+// ("2w"), which is how key lifetimes are usually written.
+func parseLifetime(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	unit := time.Duration(0)
+	switch {
+	case strings.HasSuffix(s, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(s, "w"):
+		unit = 7 * 24 * time.Hour
+	}
+	var d time.Duration
+	if unit != 0 {
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid --expires %q (use e.g. 30d, 2w, 12h)", s)
+		}
+		d = time.Duration(n) * unit
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, fmt.Errorf("invalid --expires %q (use e.g. 30d, 2w, 12h)", s)
+		}
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("--expires must be positive (got %q)", s)
+	}
+	return d, nil
+}
+
 func cmdKeyList(a *App, args []string) int {
 	if len(args) > 0 {
 		return a.Usage(nil, "key list takes no arguments")
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestKeyCreateSendsScopesAndExpiry(t *testing.T) {
+	srv, reqs := newRecordingServer(t, http.StatusCreated, `{"status":"created","key":"lk_abc","id":3,"scopes":["media:read"]}`)
+	a, out, _ := appForServer(srv.URL)
+	args := []string{"--name", "ci", "--scope", "media:read", "--scope", "jobs:run:ingest", "--expires", "30d", "--allow-ip", "10.0.0.0/8"}
+	if code := cmdKeyCreate(a, args); code != 0 {
+		t.Fatalf("exit = %d", code)
+	}
+	got := (*reqs)[0]
+	if got.Method != "POST" || got.Path != "/auth/keys" {
+		t.Errorf("request = %+v", got)
+	}
+	var body struct {
+		Name         string   `json:"name"`
+		Scopes       []string `json:"scopes"`
+		ExpiresAt    int64    `json:"expires_at"`
+		AllowedCIDRs []string `json:"allowed_cidrs"`
+	}
+	if err := json.Unmarshal([]byte(got.Body), &body); err != nil {
+		t.Fatalf("body: %v", err)
+	}
+	if body.Name != "ci" || len(body.Scopes) != 2 || body.Scopes[1] != "jobs:run:ingest" || len(body.AllowedCIDRs) != 1 {
+		t.Errorf("body = %+v", body)
+	}
+	want := time.Now().Add(30 * 24 * time.Hour).Unix()
+	if body.ExpiresAt < want-60 || body.ExpiresAt > want+60 {
+		t.Errorf("expires_at = %d, want ~%d", body.ExpiresAt, want)
+	}
+	var printed map[string]any
+	if err := json.Unmarshal(out.Bytes(), &printed); err != nil || printed["key"] != "lk_abc" || printed["scopes"] == nil {
+		t.Errorf("stdout = %s", out.String())
+	}
+}
+
+func TestKeyCreateRejectsBadExpiry(t *testing.T) {
+	a, _, _ := appForServer("http://127.0.0.1:1")
+	if code := cmdKeyCreate(a, []string{"--name", "x", "--expires", "soon"}); code != 2 {
+		t.Errorf("exit = %d, want 2", code)
+	}
+}
+
+func TestParseLifetime(t *testing.T) {
+	cases := map[string]time.Duration{
+		"30d": 30 * 24 * time.Hour,
+		"2w":  14 * 24 * time.Hour,
+		"12h": 12 * time.Hour,
+		"90m": 90 * time.Minute,
+	}
+	for in, want := range cases {
+		if got, err := parseLifetime(in); err != nil || got != want {
+			t.Errorf("parseLifetime(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "d", "-1d", "0h", "xw"} {
+		if _, err := parseLifetime(bad); err == nil {
+			t.Errorf("parseLifetime(%q) succeeded", bad)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+)
+
+func init() {
+	register(command{group: "saved", name: "list",
+		summary: "List saved searches with their cached match counts (GET /api/saved-searches)", run: cmdSavedList})
+	register(command{group: "saved", name: "get", args: "<name|id>",
+		summary: "Show one saved search (GET /api/saved-searches/{id})", run: cmdSavedGet})
+	register(command{group: "saved", name: "create", args: "--name N (--query Q | --predicates FILE|-) [--mode AND|OR] [--sort S]",
+		summary: "Save a search (POST /api/saved-searches)", run: cmdSavedCreate})
+	register(command{group: "saved", name: "delete", args: "<name|id>",
+		summary: "Delete a saved search (DELETE /api/saved-searches/{id})", run: cmdSavedDelete})
+	register(command{group: "saved", name: "run", args: "<name> [--paths]",
+		summary: `Run a saved search (POST /api/media/query with saved:"name"); tasks take --saved <name>`, run: cmdSavedRun})
+}
+
+func savedSearchURL(key string) string {
+	return "/api/saved-searches/" + url.PathEscape(key)
+}
+
+func cmdSavedList(a *App, args []string) int {
+	if len(args) > 0 {
+		return a.Usage(nil, "saved list takes no arguments")
+	}
+	var out []map[string]any
+	if err := a.Client.DoJSON("GET", "/api/saved-searches", nil, &out); err != nil {
+		return a.Fail(err)
+	}
+	if out == nil {
+		out = []map[string]any{}
+	}
+	return a.PrintJSON(out)
+}
+
+func cmdSavedGet(a *App, args []string) int {
+	if len(args) != 1 {
+		return a.Usage(nil, "usage: lokictl saved get <name|id>")
+	}
+	var out any
+	if err := a.Client.DoJSON("GET", savedSearchURL(args[0]), nil, &out); err != nil {
+		return a.Fail(err)
+	}
+	return a.PrintJSON(out)
+}
+
+func cmdSavedCreate(a *App, args []string) int {
+	fs := flag.NewFlagSet("saved create", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	name := fs.String("name", "", "saved search name (required)")
+	query := fs.String("query", "", "task search-DSL query, e.g. 'tag:cat AND width>1000'")
+	predFile := fs.String("predicates", "", "predicate JSON array (file or - for stdin), as for media query")
+	mode := fs.String("mode", "AND", "combine predicates with AND or OR")
+	sort := fs.String("sort", "", "sort key the UI re-applies when the search is opened")
+	if err := fs.Parse(args); err != nil {
+		return a.Usage(fs, err.Error())
+	}
+	if *name == "" {
+		return a.Usage(fs, "--name is required")
+	}
+	if (*query == "") == (*predFile == "") {
+		return a.Usage(fs, "pass exactly one of --query or --predicates")
+	}
+	*mode = strings.ToUpper(*mode)
+	if *mode != "AND" && *mode != "OR" {
+		return a.Usage(fs, "--mode must be AND or OR")
+	}
+
+	body := map[string]any{"name": *name, "mode": *mode, "sort": *sort}
+	if *predFile != "" {
+		b, err := readBodyArg(nullSafeAt(*predFile), os.Stdin)
+		if err != nil {
+			return a.Fail(err)
+		}
+		var preds []predicate
+		if err := json.Unmarshal(b, &preds); err != nil {
+			return a.Fail(fmt.Errorf("--predicates must be a JSON array of {type,value,exclude,join}: %w", err))
+		}
+		body["predicates"] = preds
+	} else {
+		body["query"] = *query
+	}
+
+	var out any
+	if err := a.Client.DoJSON("POST", "/api/saved-searches", body, &out); err != nil {
+		return a.Fail(err)
+	}
+	return a.PrintJSON(out)
+}
+
+func cmdSavedDelete(a *App, args []string) int {
+	if len(args) != 1 {
+		return a.Usage(nil, "usage: lokictl saved delete <name|id>")
+	}
+	var out any
+	if err := a.Client.DoJSON("DELETE", savedSearchURL(args[0]), nil, &out); err != nil {
+		return a.Fail(err)
+	}
+	return a.PrintJSON(out)
+}
+
+func cmdSavedRun(a *App, args []string) int {
+	fs := flag.NewFlagSet("saved run", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	pathsOnly := fs.Bool("paths", false, "print only the matching paths")
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return a.Usage(fs, "usage: lokictl saved run <name> [--paths]")
+	}
+	name := args[0]
+	if err := fs.Parse(args[1:]); err != nil {
+		return a.Usage(fs, err.Error())
+	}
+
+	var items []map[string]any
+	preds := []predicate{{Type: "saved", Value: name}}
+	if err := a.Client.DoJSON("POST", "/api/media/query", map[string]any{"predicates": preds, "mode": "AND"}, &items); err != nil {
+		return a.Fail(err)
+	}
+	if items == nil {
+		items = []map[string]any{}
+	}
+	if *pathsOnly {
+		paths := make([]string, 0, len(items))
+		for _, it := range items {
+			if p, ok := it["path"].(string); ok {
+				paths = append(paths, p)
+			}
+		}
+		return a.PrintJSON(paths)
+	}
+	return a.PrintJSON(items)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSavedRunQueriesSavedPredicate(t *testing.T) {
+	srv, reqs := newRecordingServer(t, http.StatusOK, `[{"path":"/a.jpg","elo":1500},{"path":"/b.jpg"}]`)
+	a, out, _ := appForServer(srv.URL)
+	if code := cmdSavedRun(a, []string{"Best cats", "--paths"}); code != 0 {
+		t.Fatalf("exit = %d", code)
+	}
+	got := (*reqs)[0]
+	if got.Method != "POST" || got.Path != "/api/media/query" {
+		t.Errorf("request = %+v", got)
+	}
+	var body struct {
+		Predicates []predicate `json:"predicates"`
+	}
+	if err := json.Unmarshal([]byte(got.Body), &body); err != nil {
+		t.Fatalf("body: %v", err)
+	}
+	if len(body.Predicates) != 1 || body.Predicates[0] != (predicate{Type: "saved", Value: "Best cats"}) {
+		t.Errorf("predicates = %+v", body.Predicates)
+	}
+	var paths []string
+	if err := json.Unmarshal(out.Bytes(), &paths); err != nil || len(paths) != 2 || paths[1] != "/b.jpg" {
+		t.Errorf("stdout = %s", out.String())
+	}
+}
+
+func TestSavedCreateNeedsExactlyOneDefinition(t *testing.T) {
+	a, _, _ := appForServer("http://127.0.0.1:1")
+	if code := cmdSavedCreate(a, []string{"--name", "x"}); code != 2 {
+		t.Errorf("neither: exit = %d, want 2", code)
+	}
+	if code := cmdSavedCreate(a, []string{"--name", "x", "--query", "tag:a", "--predicates", "-"}); code != 2 {
+		t.Errorf("both: exit = %d, want 2", code)
+	}
+
+	srv, reqs := newRecordingServer(t, http.StatusOK, `{"id":1}`)
+	a, _, _ = appForServer(srv.URL)
+	if code := cmdSavedCreate(a, []string{"--name", "big", "--query", "width:>3000", "--mode", "or"}); code != 0 {
+		t.Fatalf("exit = %d", code)
+	}
+	got := (*reqs)[0]
+	if got.Path != "/api/saved-searches" || !strings.Contains(got.Body, `"query":"width:\u003e3000"`) || !strings.Contains(got.Body, `"mode":"OR"`) {
+		t.Errorf("request = %+v", got)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	register(command{group: "share", name: "create",
+		args:    "(--path P | --tag T | --query Q | --saved S | --collection C) [--title T] [--password PW] [--expires 7d] [--max-views N]",
+		summary: "Create a share link (POST /api/shares)", run: cmdShareCreate})
+	register(command{group: "share", name: "list",
+		summary: "List share links, revoked ones included (GET /api/shares)", run: cmdShareList})
+	register(command{group: "share", name: "revoke", args: "<id>",
+		summary: "Revoke a share link (DELETE /api/shares/{id})", run: cmdShareRevoke})
+	register(command{group: "share", name: "log", args: "<id> [--limit N]",
+		summary: "Show a share link's access log (GET /api/shares/{id}/log)", run: cmdShareLog})
+}
+
+func cmdShareCreate(a *App, args []string) int {
+	fs := flag.NewFlagSet("share create", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	kinds := map[string]*string{
+		"path":       fs.String("path", "", "share one library item"),
+		"tag":        fs.String("tag", "", "share everything carrying a tag"),
+		"query":      fs.String("query", "", "share a task search-DSL query's matches"),
+		"saved":      fs.String("saved", "", "share a saved search (name or id)"),
+		"collection": fs.String("collection", "", "share a collection (name or id)"),
+	}
+	title := fs.String("title", "", "heading on the gallery page")
+	password := fs.String("password", "", "require this password to open the link")
+	expires := fs.String("expires", "", "lifetime, e.g. 7d, 2w, 12h (default: never expires)")
+	maxViews := fs.Int("max-views", 0, "stop working after N views (0 = unlimited)")
+	if err := fs.Parse(args); err != nil {
+		return a.Usage(fs, err.Error())
+	}
+	kind, target := "", ""
+	for k, v := range kinds {
+		if *v == "" {
+			continue
+		}
+		if kind != "" {
+			return a.Usage(fs, "pass exactly one of --path, --tag, --query, --saved, or --collection")
+		}
+		kind, target = k, *v
+	}
+	if kind == "" {
+		return a.Usage(fs, "pass exactly one of --path, --tag, --query, --saved, or --collection")
+	}
+	if *maxViews < 0 {
+		return a.Usage(fs, "--max-views must be 0 or more")
+	}
+	var expiresAt int64
+	if *expires != "" {
+		d, err := parseLifetime(*expires)
+		if err != nil {
+			return a.Usage(fs, err.Error())
+		}
+		expiresAt = time.Now().Add(d).Unix()
+	}
+
+	var out map[string]any
+	err := a.Client.DoJSON("POST", "/api/shares", map[string]any{
+		"kind": kind, "target": target, "title": *title, "password": *password,
+		"expiresAt": expiresAt, "maxViews": *maxViews,
+	}, &out)
+	if err != nil {
+		return a.Fail(err)
+	}
+	if u, ok := out["url"].(string); ok {
+		out["url"] = strings.TrimRight(a.Client.Base, "/") + u
+	}
+	return a.PrintJSON(out)
+}
+
+func cmdShareList(a *App, args []string) int {
+	if len(args) > 0 {
+		return a.Usage(nil, "share list takes no arguments")
+	}
+	var out []map[string]any
+	if err := a.Client.DoJSON("GET", "/api/shares", nil, &out); err != nil {
+		return a.Fail(err)
+	}
+	if out == nil {
+		out = []map[string]any{}
+	}
+	return a.PrintJSON(out)
+}
+
+func isShareID(s string) bool {
+	_, err := strconv.ParseInt(s, 10, 64)
+	return err == nil
+}
+
+func cmdShareRevoke(a *App, args []string) int {
+	if len(args) != 1 || !isShareID(args[0]) {
+		return a.Usage(nil, "usage: lokictl share revoke <id>")
+	}
+	var out any
+	if err := a.Client.DoJSON("DELETE", "/api/shares/"+args[0], nil, &out); err != nil {
+		return a.Fail(err)
+	}
+	return a.PrintJSON(out)
+}
+
+func cmdShareLog(a *App, args []string) int {
+	if len(args) < 1 || !isShareID(args[0]) {
+		return a.Usage(nil, "usage: lokictl share log <id> [--limit N]")
+	}
+	fs := flag.NewFlagSet("share log", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	limit := fs.Int("limit", 0, "newest N entries (default: all kept)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return a.Usage(fs, err.Error())
+	}
+	path := "/api/shares/" + args[0] + "/log"
+	if *limit > 0 {
+		path += "?limit=" + strconv.Itoa(*limit)
+	}
+	var out []map[string]any
+	if err := a.Client.DoJSON("GET", path, nil, &out); err != nil {
+		return a.Fail(err)
+	}
+	if out == nil {
+		out = []map[string]any{}
+	}
+	return a.PrintJSON(out)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestShareCreate(t *testing.T) {
+	srv, reqs := newRecordingServer(t, http.StatusOK, `{"id":4,"token":"n.s","url":"/s/n.s"}`)
+	a, out, _ := appForServer(srv.URL)
+	if code := cmdShareCreate(a, []string{"--collection", "Holiday", "--password", "pw", "--max-views", "10"}); code != 0 {
+		t.Fatalf("exit = %d", code)
+	}
+	got := (*reqs)[0]
+	if got.Method != "POST" || got.Path != "/api/shares" {
+		t.Errorf("request = %+v", got)
+	}
+	var body map[string]any
+	if err := json.Unmarshal([]byte(got.Body), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body["kind"] != "collection" || body["target"] != "Holiday" || body["password"] != "pw" || body["maxViews"] != float64(10) {
+		t.Errorf("body = %v", body)
+	}
+	var printed map[string]any
+	if err := json.Unmarshal(out.Bytes(), &printed); err != nil || printed["url"] != srv.URL+"/s/n.s" {
+		t.Errorf("stdout = %s", out.String())
+	}
+}
+
+func TestShareCreateNeedsExactlyOneTarget(t *testing.T) {
+	a, _, _ := appForServer("http://127.0.0.1:1")
+	if code := cmdShareCreate(a, nil); code != 2 {
+		t.Errorf("none: exit = %d, want 2", code)
+	}
+	if code := cmdShareCreate(a, []string{"--tag", "cat", "--path", "/a.jpg"}); code != 2 {
+		t.Errorf("two: exit = %d, want 2", code)
+	}
+	if code := cmdShareRevoke(a, []string{"abc"}); code != 2 {
+		t.Errorf("revoke abc: exit = %d, want 2", code)
+	}
+}
+
+func TestShareLog(t *testing.T) {
+	srv, reqs := newRecordingServer(t, http.StatusOK, `[{"id":1,"outcome":"view"}]`)
+	a, _, _ := appForServer(srv.URL)
+	if code := cmdShareLog(a, []string{"4", "--limit", "20"}); code != 0 {
+		t.Fatalf("exit = %d", code)
+	}
+	if got := (*reqs)[0]; got.Method != "GET" || got.Path != "/api/shares/4/log" {
+		t.Errorf("request = %+v", got)
+	}
+}
@@ -15,6 +15,13 @@ import (
 // readDAG loads a workflow DAG from a file (or stdin with "-"), accepting a
 // bare JSON array or an object wrapping it as {"dag":[...]} / {"tasks":[...]}.
 func readDAG(pathOrDash string, stdin io.Reader) ([]map[string]any, error) {
+	dag, _, err := readWorkflowFile(pathOrDash, stdin)
+	return dag, err
+}
+
+// readWorkflowFile is readDAG that also returns the parameter declarations
+// of a wrapped {"dag":[...],"params":[...]} file (nil when absent).
+func readWorkflowFile(pathOrDash string, stdin io.Reader) ([]map[string]any, []map[string]any, error) {
 	var b []byte
 	var err error
 	if pathOrDash == "-" {
@@ -23,26 +30,40 @@ func readDAG(pathOrDash string, stdin io.Reader) ([]map[string]any, error) {
 		b, err = os.ReadFile(pathOrDash)
 	}
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	var arr []map[string]any
 	if json.Unmarshal(b, &arr) == nil {
-		return arr, nil
+		return arr, nil, nil
 	}
 	var wrapped struct {
-		DAG   []map[string]any `json:"dag"`
-		Tasks []map[string]any `json:"tasks"`
+		DAG    []map[string]any `json:"dag"`
+		Tasks  []map[string]any `json:"tasks"`
+		Params []map[string]any `json:"params"`
 	}
 	if err := json.Unmarshal(b, &wrapped); err != nil {
-		return nil, fmt.Errorf("DAG must be a JSON array of tasks (or {\"dag\":[...]}): %w", err)
+		return nil, nil, fmt.Errorf("DAG must be a JSON array of tasks (or {\"dag\":[...]}): %w", err)
 	}
 	if len(wrapped.DAG) > 0 {
-		return wrapped.DAG, nil
+		return wrapped.DAG, wrapped.Params, nil
 	}
 	if len(wrapped.Tasks) > 0 {
-		return wrapped.Tasks, nil
+		return wrapped.Tasks, wrapped.Params, nil
 	}
-	return nil, fmt.Errorf("no tasks found in DAG input")
+	return nil, nil, fmt.Errorf("no tasks found in DAG input")
+}
+
+// paramFlags collects repeated --param name=value flags.
+type paramFlags map[string]string
+
+func (p paramFlags) String() string { return "" }
+func (p paramFlags) Set(v string) error {
+	k, val, ok := strings.Cut(v, "=")
+	if !ok || k == "" {
+		return fmt.Errorf("--param wants name=value, got %q", v)
+	}
+	p[k] = val
+	return nil
 }
 
 // oldServerHint upgrades 404s on /workflows* with a version hint.
@@ -101,8 +122,9 @@ func init() {
 		summary: "Update a saved workflow (PUT /workflows/{id})", run: cmdWorkflowUpdate})
 	register(command{group: "workflow", name: "delete", args: "<id> --yes",
 		summary: "Delete a saved workflow (DELETE /workflows/{id})", run: cmdWorkflowDelete})
-	register(command{group: "workflow", name: "run", args: "<id> [--input S] [--wait] [--timeout D]",
-		summary: "Run a saved workflow (POST /workflows/{id}/run)", run: cmdWorkflowRun})
+	register(command{group: "workflow", name: "run", args: "<id> [--input S] [--param k=v]... [--wait] [--timeout D]",
+		summary: "Run a saved workflow (POST /workflows/{id}/run); --param sets its declared parameters",
+		run:     cmdWorkflowRun})
 	register(command{group: "workflow", name: "run-adhoc", args: "--dag FILE|- [--wait] [--timeout D]",
 		summary: "Run a one-off DAG without saving it (POST /workflow)", run: cmdWorkflowRunAdhoc})
 }
@@ -118,12 +140,16 @@ func cmdWorkflowCreate(a *App, args []string) int {
 	if *name == "" || *dagPath == "" {
 		return a.Usage(fs, "usage: lokictl workflow create --name N --dag FILE|-")
 	}
-	dag, err := readDAG(*dagPath, os.Stdin)
+	dag, params, err := readWorkflowFile(*dagPath, os.Stdin)
 	if err != nil {
 		return a.Fail(err)
 	}
+	body := map[string]any{"name": *name, "dag": dag}
+	if params != nil {
+		body["params"] = params
+	}
 	var out any
-	if err := a.Client.DoJSON("POST", "/workflows/create", map[string]any{"name": *name, "dag": dag}, &out); err != nil {
+	if err := a.Client.DoJSON("POST", "/workflows/create", body, &out); err != nil {
 		return a.Fail(oldServerHint(err))
 	}
 	return a.PrintJSON(out)
@@ -154,15 +180,22 @@ func cmdWorkflowUpdate(a *App, args []string) int {
 	if *name != "" {
 		current.Name = *name
 	}
+	// Params are sent only when the file declares them; the server keeps
+	// the current ones otherwise.
+	body := map[string]any{"name": current.Name}
 	if *dagPath != "" {
-		dag, err := readDAG(*dagPath, os.Stdin)
+		dag, params, err := readWorkflowFile(*dagPath, os.Stdin)
 		if err != nil {
 			return a.Fail(err)
 		}
 		current.DAG = dag
+		if params != nil {
+			body["params"] = params
+		}
 	}
+	body["dag"] = current.DAG
 	var out any
-	if err := a.Client.DoJSON("PUT", "/workflows/"+id, map[string]any{"name": current.Name, "dag": current.DAG}, &out); err != nil {
+	if err := a.Client.DoJSON("PUT", "/workflows/"+id, body, &out); err != nil {
 		return a.Fail(oldServerHint(err))
 	}
 	return a.PrintJSON(out)
@@ -191,19 +224,25 @@ func cmdWorkflowRun(a *App, args []string) int {
 	fs := flag.NewFlagSet("workflow run", flag.ContinueOnError)
 	fs.SetOutput(io.Discard)
 	input := fs.String("input", "", "input injected into the workflow's root tasks")
+	params := paramFlags{}
+	fs.Var(params, "param", "set a workflow parameter (repeatable): --param name=value")
 	wait := fs.Bool("wait", false, "wait for all spawned jobs to finish")
 	timeout := fs.Duration("timeout", 0, "per-job wait timeout (0 = forever)")
 	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
-		return a.Usage(fs, "usage: lokictl workflow run <id> [--input S] [--wait] [--timeout D]")
+		return a.Usage(fs, "usage: lokictl workflow run <id> [--input S] [--param k=v]... [--wait] [--timeout D]")
 	}
 	id := args[0]
 	if err := fs.Parse(args[1:]); err != nil {
 		return a.Usage(fs, err.Error())
 	}
+	body := map[string]any{"input": *input}
+	if len(params) > 0 {
+		body["params"] = params
+	}
 	var out struct {
 		IDs []string `json:"ids"`
 	}
-	if err := a.Client.DoJSON("POST", "/workflows/"+id+"/run", map[string]string{"input": *input}, &out); err != nil {
+	if err := a.Client.DoJSON("POST", "/workflows/"+id+"/run", body, &out); err != nil {
 		return a.Fail(oldServerHint(err))
 	}
 	if !*wait {
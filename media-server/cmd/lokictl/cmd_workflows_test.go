@@ -155,3 +155,37 @@ func TestWorkflowListOldServer404Hint(t *testing.T) {
 		t.Errorf("stderr = %s", errOut.String())
 	}
 }
+
+func TestWorkflowRunParams(t *testing.T) {
+	srv, reqs := newRecordingServer(t, http.StatusCreated, `{"ids":["j1"]}`)
+	a, _, _ := appForServer(srv.URL)
+	if code := cmdWorkflowRun(a, []string{"wf1", "--param", "dir=/data/in", "--param", "overwrite=true"}); code != 0 {
+		t.Fatalf("exit = %d", code)
+	}
+	var body struct {
+		Params map[string]string `json:"params"`
+	}
+	if err := json.Unmarshal([]byte((*reqs)[0].Body), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Params["dir"] != "/data/in" || body.Params["overwrite"] != "true" {
+		t.Errorf("params = %v", body.Params)
+	}
+
+	if code := cmdWorkflowRun(a, []string{"wf1", "--param", "novalue"}); code != 2 {
+		t.Errorf("--param without = : exit = %d, want 2", code)
+	}
+}
+
+func TestWorkflowCreateSendsParams(t *testing.T) {
+	srv, reqs := newRecordingServer(t, http.StatusCreated, `{"id":"wf1"}`)
+	dagFile := filepath.Join(t.TempDir(), "wf.json")
+	_ = os.WriteFile(dagFile, []byte(`{"params":[{"name":"dir","type":"path"}],"dag":[{"id":"a","command":"wait","input":"${dir}"}]}`), 0o600)
+	a, _, _ := appForServer(srv.URL)
+	if code := cmdWorkflowCreate(a, []string{"--name", "n", "--dag", dagFile}); code != 0 {
+		t.Fatalf("exit = %d", code)
+	}
+	if !strings.Contains((*reqs)[0].Body, `"params":[{"name":"dir"`) {
+		t.Errorf("body = %s", (*reqs)[0].Body)
+	}
+}
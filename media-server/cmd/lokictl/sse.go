@@ -8,6 +8,7 @@ import (
 )
 
 type sseEvent struct {
+	ID   string
 	Name string
 	Data string
 }
@@ -40,6 +41,8 @@ func readSSE(ctx context.Context, r io.Reader, fn func(sseEvent) bool) error {
 			if !flush() {
 				return nil
 			}
+		case strings.HasPrefix(line, "id:"):
+			ev.ID = strings.TrimSpace(strings.TrimPrefix(line, "id:"))
 		case strings.HasPrefix(line, "event:"):
 			ev.Name = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
 		case strings.HasPrefix(line, "data:"):
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/stevecastle/shrike/media"
+	"github.com/stevecastle/shrike/renderer"
+)
+
+// -----------------------------------------------------------------------------
+// Collections API (shared across all platform mains).
+//
+//   GET    /api/collections                 — every collection (count, cover)
+//   POST   /api/collections                 — create {name, description, folderId}
+//   GET    /api/collections/{id}            — one collection ({id} or name) with
+//                                              its items in order
+//   PUT    /api/collections/{id}            — partial edit {name, description,
+//                                              folderId (0 = top level),
+//                                              coverPath ("" = first item)}
+//   DELETE /api/collections/{id}            — the collection, not its media
+//   POST   /api/collections/{id}/items      — add {paths, at} (at < 0 appends)
+//   PUT    /api/collections/{id}/items      — reorder {paths}: listed first
+//   DELETE /api/collections/{id}/items      — remove {paths}
+//   PUT    /api/collections/{id}/caption    — {path, caption}
+//   GET    /api/collection-folders          — the folder list (build the tree
+//                                              from parentId)
+//   POST   /api/collection-folders          — create {name, parentId}
+//   PUT    /api/collection-folders/{id}     — rename / move {name, parentId
+//                                              (0 = top level)}
+//   DELETE /api/collection-folders/{id}     — contents move up a level
+//
+// Membership also answers the collection:"name" query predicate, and
+// GET /api/export?collection=<name|id> ships one collection as a .lokiexport.
+// -----------------------------------------------------------------------------
+
+// RegisterCollectionRoutes wires the collections API onto mux.
+func RegisterCollectionRoutes(mux *http.ServeMux, deps *Dependencies) {
+	// Reads are public-readable like the rest of browsing; writes need a
+	// curator or admin account.
+	publicRead := func(h http.HandlerFunc) http.HandlerFunc {
+		return renderer.ApplyMiddlewares(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				h(w, r)
+				return
+			}
+			requireCurator(deps, h)(w, r)
+		}, renderer.RolePublicRead)
+	}
+	mux.HandleFunc("/api/collections", publicRead(collectionsHandler(deps)))
+	mux.HandleFunc("/api/collections/{id}", publicRead(collectionHandler(deps)))
+	mux.HandleFunc("/api/collections/{id}/items", publicRead(collectionItemsHandler(deps)))
+	mux.HandleFunc("/api/collections/{id}/caption", publicRead(collectionCaptionHandler(deps)))
+	mux.HandleFunc("/api/collection-folders", publicRead(collectionFoldersHandler(deps)))
+	mux.HandleFunc("/api/collection-folders/{id}", publicRead(collectionFolderHandler(deps)))
+}
+
+// collectionDetail is one collection with its ordered entries.
+type collectionDetail struct {
+	media.Collection
+	Items []media.CollectionItem `json:"items"`
+}
+
+// lookupCollection resolves a path segment that is either a numeric ID or a
+// name.
+func lookupCollection(db *sql.DB, key string) (media.Collection, bool, error) {
+	if id, err := strconv.ParseInt(key, 10, 64); err == nil && id > 0 {
+		if c, ok, err := media.GetCollectionByID(db, id); err != nil || ok {
+			return c, ok, err
+		}
+	}
+	return media.GetCollectionByName(db, key)
+}
+
+// collectionFromPath resolves {id}, answering 404/500 itself when it can't.
+func collectionFromPath(w http.ResponseWriter, r *http.Request, db *sql.DB) (media.Collection, bool) {
+	c, found, err := lookupCollection(db, r.PathValue("id"))
+	if err != nil {
+		httpError(w, err.Error(), http.StatusInternalServerError)
+		return c, false
+	}
+	if !found {
+		httpError(w, "collection not found", http.StatusNotFound)
+		return c, false
+	}
+	return c, true
+}
+
+func collectionsHandler(deps *Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			list, err := media.ListCollections(deps.DB)
+			if err != nil {
+				httpError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if list == nil {
+				list = []media.Collection{}
+			}
+			writeJSON(w, list)
+		case http.MethodPost:
+			var req media.Collection
+			if err := readJSON(r, &req); err != nil {
+				httpError(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			if req.FolderID != nil && *req.FolderID <= 0 {
+				req.FolderID = nil
+			}
+			c, err := media.CreateCollection(deps.DB, req)
+			if err != nil {
+				httpError(w, err.Error(), userErrorStatus(err))
+				return
+			}
+			auditLog(deps, r, "collection.create", []string{collectionTarget(c.ID)}, nil, auditCollection(c))
+			writeJSON(w, c)
+		default:
+			httpError(w, "use GET or POST", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func collectionHandler(deps *Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, ok := collectionFromPath(w, r, deps.DB)
+		if !ok {
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			items, err := media.CollectionItems(deps.DB, c.ID)
+			if err != nil {
+				httpError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if items == nil {
+				items = []media.CollectionItem{}
+			}
+			writeJSON(w, collectionDetail{Collection: c, Items: items})
+		case http.MethodPut:
+			var req media.CollectionUpdate
+			if err := readJSON(r, &req); err != nil {
+				httpError(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			updated, found, err := media.UpdateCollection(deps.DB, c.ID, req)
+			if err != nil {
+				httpError(w, err.Error(), userErrorStatus(err))
+				return
+			}
+			if !found {
+				httpError(w, "collection not found", http.StatusNotFound)
+				return
+			}
+			auditLog(deps, r, "collection.update", []string{collectionTarget(c.ID)}, auditCollection(c), auditCollection(updated))
+			writeJSON(w, updated)
+		case http.MethodDelete:
+			if _, err := media.DeleteCollection(deps.DB, c.ID); err != nil {
+				httpError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			auditLog(deps, r, "collection.delete", []string{collectionTarget(c.ID)},
+				map[string]any{"name": c.Name, "items": c.Count}, nil)
+			writeJSON(w, map[string]any{"deleted": c.ID})
+		default:
+			httpError(w, "use GET, PUT, or DELETE", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func collectionItemsHandler(deps *Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, ok := collectionFromPath(w, r, deps.DB)
+		if !ok {
+			return
+		}
+		var req struct {
+			Paths []string `json:"paths"`
+			At    *int     `json:"at"`
+		}
+		if r.Method == http.MethodPost || r.Method == http.MethodPut || r.Method == http.MethodDelete {
+			if err := readJSON(r, &req); err != nil {
+				httpError(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			if len(req.Paths) == 0 {
+				httpError(w, "paths required", http.StatusBadRequest)
+				return
+			}
+		}
+		ctx := r.Context()
+		switch r.Method {
+		case http.MethodGet:
+			items, err := media.CollectionItems(deps.DB, c.ID)
+			if err != nil {
+				httpError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if items == nil {
+				items = []media.CollectionItem{}
+			}
+			writeJSON(w, items)
+		case http.MethodPost:
+			at := -1
+			if req.At != nil {
+				at = *req.At
+			}
+			n, err := media.AddCollectionItems(ctx, deps.DB, c.ID, req.Paths, at)
+			if err != nil {
+				httpError(w, err.Error(), userErrorStatus(err))
+				return
+			}
+			if n > 0 {
+				auditLog(deps, r, "collection.add", append([]string{collectionTarget(c.ID)}, req.Paths...), nil,
+					map[string]any{"added": n})
+			}
+			writeJSON(w, map[string]any{"added": n})
+		case http.MethodPut:
+			if err := media.ReorderCollection(ctx, deps.DB, c.ID, req.Paths); err != nil {
+				httpError(w, err.Error(), userErrorStatus(err))
+				return
+			}
+			auditLog(deps, r, "collection.reorder", []string{collectionTarget(c.ID)}, nil,
+				map[string]any{"items": len(req.Paths)})
+			writeJSON(w, map[string]any{"ok": true})
+		case http.MethodDelete:
+			n, err := media.RemoveCollectionItems(ctx, deps.DB, c.ID, req.Paths)
+			if err != nil {
+				httpError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if n > 0 {
+				auditLog(deps, r, "collection.remove", append([]string{collectionTarget(c.ID)}, req.Paths...),
+					map[string]any{"removed": n}, nil)
+			}
+			writeJSON(w, map[string]any{"removed": n})
+		default:
+			httpError(w, "use GET, POST, PUT, or DELETE", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func collectionCaptionHandler(deps *Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			httpError(w, "use PUT", http.StatusMethodNotAllowed)
+			return
+		}
+		c, ok := collectionFromPath(w, r, deps.DB)
+		if !ok {
+			return
+		}
+		var req struct {
+			Path    string `json:"path"`
+			Caption string `json:"caption"`
+		}
+		if err := readJSON(r, &req); err != nil || req.Path == "" {
+			httpError(w, "path required", http.StatusBadRequest)
+			return
+		}
+		found, err := media.SetCollectionCaption(deps.DB, c.ID, req.Path, req.Caption)
+		if err != nil {
+			httpError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			httpError(w, "path is not in this collection", http.StatusNotFound)
+			return
+		}
+		auditLog(deps, r, "collection.caption", []string{collectionTarget(c.ID), req.Path}, nil,
+			map[string]string{"caption": req.Caption})
+		writeJSON(w, map[string]any{"ok": true})
+	}
+}
+
+func collectionFoldersHandler(deps *Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			folders, err := media.ListCollectionFolders(deps.DB)
+			if err != nil {
+				httpError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if folders == nil {
+				folders = []media.CollectionFolder{}
+			}
+			writeJSON(w, folders)
+		case http.MethodPost:
+			var req media.CollectionFolder
+			if err := readJSON(r, &req); err != nil {
+				httpError(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			if req.ParentID != nil && *req.ParentID <= 0 {
+				req.ParentID = nil
+			}
+			f, err := media.CreateCollectionFolder(deps.DB, req.Name, req.ParentID)
+			if err != nil {
+				httpError(w, err.Error(), userErrorStatus(err))
+				return
+			}
+			auditLog(deps, r, "folder.create", []string{folderTarget(f.ID)}, nil,
+				map[string]any{"name": f.Name, "parentId": f.ParentID})
+			writeJSON(w, f)
+		default:
+			httpError(w, "use GET or POST", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func collectionFolderHandler(deps *Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := pathID(r)
+		if !ok {
+			httpError(w, "bad folder id", http.StatusBadRequest)
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			var req media.CollectionFolder
+			if err := readJSON(r, &req); err != nil {
+				httpError(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			found, err := media.UpdateCollectionFolder(deps.DB, id, req.Name, req.ParentID)
+			if err != nil {
+				httpError(w, err.Error(), userErrorStatus(err))
+				return
+			}
+			if !found {
+				httpError(w, "folder not found", http.StatusNotFound)
+				return
+			}
+			auditLog(deps, r, "folder.update", []string{folderTarget(id)}, nil,
+				map[string]any{"name": req.Name, "parentId": req.ParentID})
+			writeJSON(w, map[string]any{"ok": true})
+		case http.MethodDelete:
+			found, err := media.DeleteCollectionFolder(deps.DB, id)
+			if err != nil {
+				httpError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if !found {
+				httpError(w, "folder not found", http.StatusNotFound)
+				return
+			}
+			auditLog(deps, r, "folder.delete", []string{folderTarget(id)}, nil, nil)
+			writeJSON(w, map[string]any{"deleted": id})
+		default:
+			httpError(w, "use PUT or DELETE", http.StatusMethodNotAllowed)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stevecastle/shrike/media"
+	"github.com/stevecastle/shrike/storage"
+)
+
+func collectionReq(t *testing.T, h http.HandlerFunc, method, target, id, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if id != "" {
+		req.SetPathValue("id", id)
+	}
+	rec := httptest.NewRecorder()
+	h(rec, req)
+	return rec
+}
+
+func TestCollectionHandlersAndPredicate(t *testing.T) {
+	deps := newSavedSearchDB(t)
+
+	rec := collectionReq(t, collectionsHandler(deps), http.MethodPost, "/api/collections", "", `{"name":"Best of"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("create = %d %s", rec.Code, rec.Body)
+	}
+	rec = collectionReq(t, collectionsHandler(deps), http.MethodPost, "/api/collections", "", `{"name":"best OF"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("duplicate name = %d, want 400", rec.Code)
+	}
+
+	items := collectionItemsHandler(deps)
+	if rec := collectionReq(t, items, http.MethodPost, "/x", "best of", `{"paths":["c.png","a.jpg"]}`); rec.Code != http.StatusOK {
+		t.Fatalf("add = %d %s", rec.Code, rec.Body)
+	}
+	if rec := collectionReq(t, items, http.MethodPost, "/x", "best of", `{"paths":["nope.jpg"]}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("add non-library path = %d, want 400", rec.Code)
+	}
+	if rec := collectionReq(t, items, http.MethodPut, "/x", "best of", `{"paths":["a.jpg"]}`); rec.Code != http.StatusOK {
+		t.Fatalf("reorder = %d %s", rec.Code, rec.Body)
+	}
+	if rec := collectionReq(t, collectionCaptionHandler(deps), http.MethodPut, "/x", "best of", `{"path":"c.png","caption":"the dog"}`); rec.Code != http.StatusOK {
+		t.Fatalf("caption = %d %s", rec.Code, rec.Body)
+	}
+
+	rec = collectionReq(t, collectionHandler(deps), http.MethodGet, "/x", "Best Of", "")
+	var detail collectionDetail
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &detail) != nil {
+		t.Fatalf("get = %d %s", rec.Code, rec.Body)
+	}
+	if len(detail.Items) != 2 || detail.Items[0].Path != "a.jpg" || detail.Items[1].Caption != "the dog" || detail.CoverPath != "a.jpg" {
+		t.Errorf("detail = %+v", detail)
+	}
+
+	// collection:"name" combines with the other predicates.
+	if code, paths := runSavedQuery(t, deps, `{"predicates":[{"type":"collection","value":"BEST OF"}],"mode":"AND"}`); code != http.StatusOK ||
+		len(paths) != 2 || paths[0] != "a.jpg" || paths[1] != "c.png" {
+		t.Errorf("collection:best of = %d %v", code, paths)
+	}
+	if _, paths := runSavedQuery(t, deps, `{"predicates":[{"type":"tag","value":"cat"},{"type":"collection","value":"best of","exclude":true}],"mode":"AND"}`); len(paths) != 1 || paths[0] != "b.jpg" {
+		t.Errorf("cat -collection:best of = %v, want [b.jpg]", paths)
+	}
+
+	if rec := collectionReq(t, collectionHandler(deps), http.MethodDelete, "/x", "best of", ""); rec.Code != http.StatusOK {
+		t.Fatalf("delete = %d %s", rec.Code, rec.Body)
+	}
+	if _, found, _ := media.GetCollectionByName(deps.DB, "best of"); found {
+		t.Error("collection still present after delete")
+	}
+}
+
+// TestExportImportCollection: ?collection= exports exactly that collection's
+// items (tagged or not) and the import recreates it in order, with captions
+// and the chosen cover, keyed by the rebased paths.
+func TestExportImportCollection(t *testing.T) {
+	srcDir := t.TempDir()
+	srcDB := xferSchema(t)
+	var paths []string
+	for _, name := range []string{"one.jpg", "two.jpg", "left-out.jpg"} {
+		p := filepath.Join(srcDir, name)
+		os.WriteFile(p, []byte(name), 0o644)
+		srcDB.Exec(`INSERT INTO media(path) VALUES(?)`, p)
+		paths = append(paths, p)
+	}
+	ctx := t.Context()
+	c, err := media.CreateCollection(srcDB, media.Collection{Name: "Album"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := media.AddCollectionItems(ctx, srcDB, c.ID, []string{paths[1], paths[0]}, -1); err != nil {
+		t.Fatal(err)
+	}
+	media.SetCollectionCaption(srcDB, c.ID, paths[0], "first shot")
+	cover := paths[0]
+	media.UpdateCollection(srcDB, c.ID, media.CollectionUpdate{CoverPath: &cover})
+	srcDeps := &Dependencies{DB: srcDB, Storage: storage.NewRegistry([]storage.Backend{storage.NewLocalBackend(srcDir, "L")})}
+
+	rr := httptest.NewRecorder()
+	exportHandler(srcDeps)(rr, httptest.NewRequest(http.MethodGet, "/api/export?collection=album", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("export: %d %s", rr.Code, rr.Body.String())
+	}
+	rr404 := httptest.NewRecorder()
+	exportHandler(srcDeps)(rr404, httptest.NewRequest(http.MethodGet, "/api/export?collection=nope", nil))
+	if rr404.Code != http.StatusNotFound {
+		t.Errorf("unknown collection export = %d, want 404", rr404.Code)
+	}
+
+	dstDB := xferSchema(t)
+	mem := newMemBackend("s3://demo/", "Demo")
+	reg := storage.NewRegistry([]storage.Backend{mem})
+	reg.ReplaceWithDefault([]storage.Backend{mem}, 0)
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("file", "album.lokiexport")
+	fw.Write(rr.Body.Bytes())
+	mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/api/import", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rr2 := httptest.NewRecorder()
+	importHandler(&Dependencies{DB: dstDB, Storage: reg})(rr2, req)
+	if rr2.Code != http.StatusOK {
+		t.Fatalf("import: %d %s", rr2.Code, rr2.Body.String())
+	}
+	var res importResult
+	json.Unmarshal(rr2.Body.Bytes(), &res)
+	if res.Imported != 2 || res.Collections != 1 {
+		t.Errorf("import result = %+v, want 2 items and 1 collection", res)
+	}
+
+	got, found, err := media.GetCollectionByName(dstDB, "album")
+	if err != nil || !found || got.CoverPath != "s3://demo/one.jpg" || !got.CoverChosen {
+		t.Fatalf("imported collection = %+v %v %v", got, found, err)
+	}
+	items, _ := media.CollectionItems(dstDB, got.ID)
+	if len(items) != 2 || items[0].Path != "s3://demo/two.jpg" || items[1].Path != "s3://demo/one.jpg" || items[1].Caption != "first shot" {
+		t.Errorf("imported items = %+v", items)
+	}
+}
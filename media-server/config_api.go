@@ -48,10 +48,35 @@ func redactConfig(cfg appconfig.Config) appconfig.Config {
 	cfg.RunPodAPIKey = red(cfg.RunPodAPIKey)
 	cfg.LMStudioAPIKey = red(cfg.LMStudioAPIKey)
 	cfg.LlamaCppAPIKey = red(cfg.LlamaCppAPIKey)
+	cfg.OpenAIAPIKey = red(cfg.OpenAIAPIKey)
+	cfg.TranscriptionOpenAIAPIKey = red(cfg.TranscriptionOpenAIAPIKey)
+	cfg.SSO.OIDCClientSecret = red(cfg.SSO.OIDCClientSecret)
 	cfg.Roots = redactRoots(cfg.Roots)
 	return cfg
 }
 
+// configChanges reports the top-level config fields that differ between old
+// and updated, as before/after maps for the audit log. Secrets are compared
+// for real but reported redacted.
+func configChanges(old, updated appconfig.Config) (before, after map[string]any) {
+	fields := func(c appconfig.Config) map[string]json.RawMessage {
+		m := map[string]json.RawMessage{}
+		b, _ := json.Marshal(c)
+		_ = json.Unmarshal(b, &m)
+		return m
+	}
+	rawOld, rawNew := fields(old), fields(updated)
+	redOld, redNew := fields(redactConfig(old)), fields(redactConfig(updated))
+	before, after = map[string]any{}, map[string]any{}
+	for k, v := range rawNew {
+		if string(rawOld[k]) == string(v) {
+			continue
+		}
+		before[k], after[k] = redOld[k], redNew[k]
+	}
+	return before, after
+}
+
 // mergeIncomingRoots resolves redaction placeholders in a posted roots list by
 // recovering the real credentials from the currently stored roots. Incoming
 // roots are matched to stored ones by S3 identity (bucket+endpoint+prefix)
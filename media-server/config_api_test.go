@@ -17,6 +17,8 @@ func TestRedactConfig(t *testing.T) {
 	cfg.RunPodAPIKey = "rp"
 	cfg.LMStudioAPIKey = "lm"
 	cfg.LlamaCppAPIKey = "lc"
+	cfg.OpenAIAPIKey = "oa"
+	cfg.TranscriptionOpenAIAPIKey = "stt"
 	cfg.Roots = []appconfig.StorageRoot{
 		{Type: "s3", Label: "bucket", AccessKey: "AK", SecretKey: "SK"},
 		{Type: "local", Label: "disk", Path: "D:/media"},
@@ -25,13 +27,15 @@ func TestRedactConfig(t *testing.T) {
 	got := redactConfig(cfg)
 
 	for name, v := range map[string]string{
-		"JWTSecret":          got.JWTSecret,
-		"DiscordToken":       got.DiscordToken,
-		"RunPodAPIKey":       got.RunPodAPIKey,
-		"LMStudioAPIKey":     got.LMStudioAPIKey,
-		"LlamaCppAPIKey":     got.LlamaCppAPIKey,
-		"Roots[0].AccessKey": got.Roots[0].AccessKey,
-		"Roots[0].SecretKey": got.Roots[0].SecretKey,
+		"JWTSecret":                 got.JWTSecret,
+		"DiscordToken":              got.DiscordToken,
+		"RunPodAPIKey":              got.RunPodAPIKey,
+		"LMStudioAPIKey":            got.LMStudioAPIKey,
+		"LlamaCppAPIKey":            got.LlamaCppAPIKey,
+		"OpenAIAPIKey":              got.OpenAIAPIKey,
+		"TranscriptionOpenAIAPIKey": got.TranscriptionOpenAIAPIKey,
+		"Roots[0].AccessKey":        got.Roots[0].AccessKey,
+		"Roots[0].SecretKey":        got.Roots[0].SecretKey,
 	} {
 		if v != "<redacted>" {
 			t.Errorf("%s = %q, want <redacted>", name, v)
@@ -1,8 +1,9 @@
 package main
 
 // dataxfer.go — full-library export/import with cross-root path
-// normalization. Exports the TAGGED items (curated content) and everything
-// referencing them — tags, embeddings, faces, people — into a portable
+// normalization. Exports the TAGGED items (curated content) — or, with
+// ?collection=<name|id>, one collection's items — and everything referencing
+// them — tags, embeddings, faces, people, collections — into a portable
 // archive whose paths are storage-root-RELATIVE. Import rebases those
 // relative keys onto the destination's root (local <-> s3 seamlessly),
 // copies the file bytes, and merges by media item (skip items already
@@ -39,7 +40,10 @@ type dataxferManifest struct {
 	CreatedAt   time.Time `json:"created_at"`
 	SourceRoots []string  `json:"source_roots"`
 	MediaCount  int       `json:"media_count"`
-	Note        string    `json:"note"`
+	// Collection names the one collection a ?collection= export carries;
+	// empty for a full-library export.
+	Collection string `json:"collection,omitempty"`
+	Note       string `json:"note"`
 }
 
 // buildExportKeys assigns each selected media path a FLAT, collision-free
@@ -118,7 +122,8 @@ func openMediaSource(ctx context.Context, reg *storage.Registry, p string) (io.R
 	return os.Open(p)
 }
 
-// exportHandler streams a .lokiexport (tar.gz) of the tagged library.
+// exportHandler streams a .lokiexport (tar.gz) of the tagged library, or of
+// one collection with ?collection=<name|id>.
 func exportHandler(deps *Dependencies) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
@@ -127,15 +132,39 @@ func exportHandler(deps *Dependencies) http.HandlerFunc {
 		}
 		ctx := r.Context()
 
-		// Selected media = every path that has at least one tag.
-		selected, err := selectTaggedPaths(deps.DB)
-		if err != nil {
-			http.Error(w, "select tagged: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-		if len(selected) == 0 {
-			http.Error(w, "no tagged media to export", http.StatusBadRequest)
-			return
+		// Selected media = every path that has at least one tag, or the
+		// members of the requested collection (tagged or not).
+		var selected []string
+		var coll media.Collection
+		if key := strings.TrimSpace(r.URL.Query().Get("collection")); key != "" {
+			c, found, err := lookupCollection(deps.DB, key)
+			if err != nil {
+				http.Error(w, "lookup collection: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if !found {
+				http.Error(w, "collection not found", http.StatusNotFound)
+				return
+			}
+			coll = c
+			if selected, err = selectCollectionPaths(deps.DB, c.ID); err != nil {
+				http.Error(w, "select collection: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if len(selected) == 0 {
+				http.Error(w, "collection is empty", http.StatusBadRequest)
+				return
+			}
+		} else {
+			var err error
+			if selected, err = selectTaggedPaths(deps.DB); err != nil {
+				http.Error(w, "select tagged: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if len(selected) == 0 {
+				http.Error(w, "no tagged media to export", http.StatusBadRequest)
+				return
+			}
 		}
 
 		// Build the portable DB (relativized paths, thumbnails/previews nulled)
@@ -149,13 +178,16 @@ func exportHandler(deps *Dependencies) http.HandlerFunc {
 		tmpDB.Close()
 		defer os.Remove(tmpDBPath)
 
-		relByPath, err := buildExportDB(deps, tmpDBPath, selected)
+		relByPath, err := buildExportDB(deps, tmpDBPath, selected, coll.ID)
 		if err != nil {
 			http.Error(w, "build export db: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
 
 		filename := fmt.Sprintf("lowkey-export-%s.lokiexport", time.Now().Format("20060102-150405"))
+		if coll.ID != 0 {
+			filename = fmt.Sprintf("lowkey-collection-%s-%s.lokiexport", flatBaseName(coll.Name), time.Now().Format("20060102-150405"))
+		}
 		w.Header().Set("Content-Type", "application/gzip")
 		w.Header().Set("Content-Disposition", "attachment; filename=\""+filename+"\"")
 
@@ -174,7 +206,11 @@ func exportHandler(deps *Dependencies) http.HandlerFunc {
 			CreatedAt:   time.Now().UTC(),
 			SourceRoots: roots,
 			MediaCount:  len(selected),
-			Note:        "tagged media + referencing tags/embeddings/faces; flat root-agnostic keys; thumbnails regenerate on import",
+			Collection:  coll.Name,
+			Note:        "tagged media + referencing tags/embeddings/faces/collections; flat root-agnostic keys; thumbnails regenerate on import",
+		}
+		if coll.ID != 0 {
+			manifest.Note = "one collection's media + referencing tags/embeddings/faces; flat root-agnostic keys; thumbnails regenerate on import"
 		}
 		manifestJSON, _ := json.MarshalIndent(manifest, "", "  ")
 		if err := writeTarBytes(tw, "manifest.json", manifestJSON); err != nil {
@@ -223,10 +259,37 @@ func selectTaggedPaths(db *sql.DB) ([]string, error) {
 	return out, rows.Err()
 }
 
+// selectCollectionPaths returns one collection's member paths in order,
+// restricted to rows that still exist in the media table.
+func selectCollectionPaths(db *sql.DB, collectionID int64) ([]string, error) {
+	rows, err := db.Query(`
+		SELECT ci.media_path
+		FROM collection_item ci
+		JOIN media m ON m.path = ci.media_path
+		WHERE ci.collection_id = ?
+		ORDER BY ci.position, ci.media_path`, collectionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
 // buildExportDB creates a fresh SQLite at dbPath containing only the selected
 // media and their referencing rows, with every media path rewritten to a
-// flat, root-agnostic archive key. Returns the path->key map for file staging.
-func buildExportDB(deps *Dependencies, dbPath string, selected []string) (map[string]string, error) {
+// flat, root-agnostic archive key. Collections come along with just their
+// selected members — every such collection, or only onlyCollection when it
+// is non-zero. Folders are not exported; collections import at the top
+// level. Returns the path->key map for file staging.
+func buildExportDB(deps *Dependencies, dbPath string, selected []string, onlyCollection int64) (map[string]string, error) {
 	src := deps.DB
 
 	dst, err := sql.Open("sqlite", dbPath)
@@ -310,7 +373,7 @@ func buildExportDB(deps *Dependencies, dbPath string, selected []string) (map[st
 	// Faces: preserve original id so face_veto/face_cannot_link/person refs in
 	// the export stay valid; import remaps ids on the destination.
 	frows, err := src.Query(`
-		SELECT id, media_path, model, frame_ts, bbox_x, bbox_y, bbox_w, bbox_h, det_score, vector, person_id, assigned_by, created_at
+		SELECT id, media_path, model, frame_ts, bbox_x, bbox_y, bbox_w, bbox_h, det_score, vector, person_id, assigned_by, created_at, track_start, track_end
 		FROM face WHERE media_path IN (`+placeholders(len(selected))+`)`, toArgs(selected)...)
 	if err != nil {
 		return nil, err
@@ -325,14 +388,15 @@ func buildExportDB(deps *Dependencies, dbPath string, selected []string) (map[st
 		var personID sql.NullInt64
 		var assignedBy sql.NullString
 		var createdAt sql.NullInt64
-		if err := frows.Scan(&id, &mp, &model, &frameTs, &bx, &by, &bw, &bh, &det, &vec, &personID, &assignedBy, &createdAt); err != nil {
+		var trackStart, trackEnd sql.NullFloat64
+		if err := frows.Scan(&id, &mp, &model, &frameTs, &bx, &by, &bw, &bh, &det, &vec, &personID, &assignedBy, &createdAt, &trackStart, &trackEnd); err != nil {
 			frows.Close()
 			return nil, err
 		}
 		if _, err := tx.Exec(`INSERT OR IGNORE INTO face
-			(id, media_path, model, frame_ts, bbox_x, bbox_y, bbox_w, bbox_h, det_score, vector, person_id, assigned_by, created_at)
-			VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?)`,
-			id, relByPath[mp], model, frameTs, bx, by, bw, bh, det, vec, personID, assignedBy, createdAt); err != nil {
+			(id, media_path, model, frame_ts, bbox_x, bbox_y, bbox_w, bbox_h, det_score, vector, person_id, assigned_by, created_at, track_start, track_end)
+			VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)`,
+			id, relByPath[mp], model, frameTs, bx, by, bw, bh, det, vec, personID, assignedBy, createdAt, trackStart, trackEnd); err != nil {
 			frows.Close()
 			return nil, err
 		}
@@ -377,6 +441,48 @@ func buildExportDB(deps *Dependencies, dbPath string, selected []string) (map[st
 			`INSERT OR IGNORE INTO face_cannot_link(face_a, face_b) VALUES(?,?)`, faceIDs)
 	}
 
+	// Collections: entries for exported items (order and captions kept),
+	// then the collection rows they belong to. Original ids are preserved so
+	// collection_item refs stay valid; import matches collections by name.
+	collQuery := `SELECT media_path, collection_id, position, caption, added_at FROM collection_item WHERE media_path IN (`
+	if onlyCollection != 0 {
+		collQuery = fmt.Sprintf(`SELECT media_path, collection_id, position, caption, added_at FROM collection_item WHERE collection_id = %d AND media_path IN (`, onlyCollection)
+	}
+	if err := copyRelKeyed(src, tx, selected, relByPath, collQuery,
+		`INSERT OR IGNORE INTO collection_item(media_path, collection_id, position, caption, added_at) VALUES(?,?,?,?,?)`,
+		5); err != nil {
+		return nil, fmt.Errorf("collection items: %w", err)
+	}
+	crows, err := src.Query(`SELECT id, name, description, cover_path, created_at, updated_at FROM collection`)
+	if err != nil {
+		return nil, err
+	}
+	for crows.Next() {
+		var id int64
+		var name, desc string
+		var cover sql.NullString
+		var createdAt, updatedAt sql.NullInt64
+		if err := crows.Scan(&id, &name, &desc, &cover, &createdAt, &updatedAt); err != nil {
+			crows.Close()
+			return nil, err
+		}
+		var members int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM collection_item WHERE collection_id = ?`, id).Scan(&members); err != nil || members == 0 {
+			continue
+		}
+		// A cover outside the exported items falls back to the first item.
+		var relCover any
+		if cover.Valid && relByPath[cover.String] != "" {
+			relCover = relByPath[cover.String]
+		}
+		if _, err := tx.Exec(`INSERT INTO collection(id, name, description, cover_path, created_at, updated_at) VALUES(?,?,?,?,?,?)`,
+			id, name, desc, relCover, createdAt, updatedAt); err != nil {
+			crows.Close()
+			return nil, err
+		}
+	}
+	crows.Close()
+
 	return relByPath, tx.Commit()
 }
 
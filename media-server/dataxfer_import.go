@@ -6,6 +6,8 @@ package main
 // media item: an item already present in the destination is skipped whole;
 // a new item brings in its media row, tags, embeddings, faces, and file.
 // Face/person AUTOINCREMENT ids are remapped so curation refs stay valid.
+// Collections merge by name: entries are appended in archive order for every
+// archived item, new or already present, so re-importing completes an album.
 // Thumbnails are left null and regenerate on first view.
 
 import (
@@ -27,11 +29,13 @@ import (
 )
 
 type importResult struct {
-	Imported int      `json:"imported"`
-	Skipped  int      `json:"skipped"`
-	Files    int      `json:"files"`
-	DestRoot string   `json:"dest_root"`
-	Warnings []string `json:"warnings,omitempty"`
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"`
+	Files    int `json:"files"`
+	// Collections counts collections created or extended by the import.
+	Collections int      `json:"collections"`
+	DestRoot    string   `json:"dest_root"`
+	Warnings    []string `json:"warnings,omitempty"`
 }
 
 // importHandler receives a .lokiexport (multipart "file"), extracts it, and
@@ -100,6 +104,9 @@ func importHandler(deps *Dependencies) http.HandlerFunc {
 		if _, _, err := tasks.RebuildActiveFaceIndex(deps.DB, nil); err != nil {
 			res.Warnings = append(res.Warnings, "face index rebuild: "+err.Error())
 		}
+		auditLog(deps, r, "library.import", []string{destRoot}, nil, map[string]any{
+			"imported": res.Imported, "skipped": res.Skipped, "files": res.Files, "collections": res.Collections,
+		})
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(res)
@@ -288,8 +295,15 @@ func mergeImport(ctx context.Context, deps *Dependencies, workDir string, dest s
 
 	// --- faces (only for newly-imported media; remap person_id, capture id map) ---
 	faceMap := map[int64]int64{}
+	var pairedPaths []string // imported media with assigned faces
+	pairedSeen := map[string]bool{}
+	// Exports made before video face tracks have no track columns.
+	trackCols := "NULL, NULL"
+	if _, err := src.Exec(`SELECT track_start, track_end FROM face LIMIT 0`); err == nil {
+		trackCols = "track_start, track_end"
+	}
 	frows, err := src.Query(`
-		SELECT id, media_path, model, frame_ts, bbox_x, bbox_y, bbox_w, bbox_h, det_score, vector, person_id, assigned_by, created_at FROM face`)
+		SELECT id, media_path, model, frame_ts, bbox_x, bbox_y, bbox_w, bbox_h, det_score, vector, person_id, assigned_by, created_at, ` + trackCols + ` FROM face`)
 	if err == nil {
 		for frows.Next() {
 			var oldID int64
@@ -299,7 +313,8 @@ func mergeImport(ctx context.Context, deps *Dependencies, workDir string, dest s
 			var personID sql.NullInt64
 			var assignedBy sql.NullString
 			var createdAt sql.NullInt64
-			if err := frows.Scan(&oldID, &rel, &model, &frameTs, &bx, &by, &bw, &bh, &det, &vec, &personID, &assignedBy, &createdAt); err != nil {
+			var trackStart, trackEnd sql.NullFloat64
+			if err := frows.Scan(&oldID, &rel, &model, &frameTs, &bx, &by, &bw, &bh, &det, &vec, &personID, &assignedBy, &createdAt, &trackStart, &trackEnd); err != nil {
 				break
 			}
 			if !newPaths[rel] {
@@ -312,17 +327,24 @@ func mergeImport(ctx context.Context, deps *Dependencies, workDir string, dest s
 				}
 			}
 			r, err := dst.Exec(`INSERT INTO face
-				(media_path, model, frame_ts, bbox_x, bbox_y, bbox_w, bbox_h, det_score, vector, person_id, assigned_by, created_at)
-				VALUES (?,?,?,?,?,?,?,?,?,?,?,?)`,
-				absByRel[rel], model, frameTs, bx, by, bw, bh, det, vec, newPerson, assignedBy, createdAt)
+				(media_path, model, frame_ts, bbox_x, bbox_y, bbox_w, bbox_h, det_score, vector, person_id, assigned_by, created_at, track_start, track_end)
+				VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?)`,
+				absByRel[rel], model, frameTs, bx, by, bw, bh, det, vec, newPerson, assignedBy, createdAt, trackStart, trackEnd)
 			if err != nil {
 				continue
 			}
 			newID, _ := r.LastInsertId()
 			faceMap[oldID] = newID
+			if newPerson.Valid && !pairedSeen[rel] {
+				pairedSeen[rel] = true
+				pairedPaths = append(pairedPaths, absByRel[rel])
+			}
 		}
 		frows.Close()
 	}
+	if err := media.RefreshCooccurrence(dst, pairedPaths); err != nil {
+		res.Warnings = append(res.Warnings, "people co-occurrence: "+err.Error())
+	}
 
 	// --- person cover faces + curation assertions, remapped ---
 	crows, err := src.Query(`SELECT id, cover_face_id FROM person WHERE cover_face_id IS NOT NULL`)
@@ -351,9 +373,89 @@ func mergeImport(ctx context.Context, deps *Dependencies, workDir string, dest s
 		`SELECT face_a, face_b FROM face_cannot_link`,
 		`INSERT OR IGNORE INTO face_cannot_link(face_a, face_b) VALUES(?,?)`, false)
 
+	mergeCollections(ctx, src, dst, absByRel, res)
+
 	return res, nil
 }
 
+// mergeCollections upserts each archived collection by name and appends its
+// entries (archive order, captions) for items present on the destination.
+// An existing collection keeps its own order, captions, and cover; a new one
+// takes the archived cover when that item came along.
+func mergeCollections(ctx context.Context, src, dst *sql.DB, absByRel map[string]string, res *importResult) {
+	crows, err := src.Query(`SELECT id, name, description, COALESCE(cover_path, '') FROM collection`)
+	if err != nil {
+		return
+	}
+	type archived struct {
+		id                int64
+		name, desc, cover string
+	}
+	var colls []archived
+	for crows.Next() {
+		var c archived
+		if err := crows.Scan(&c.id, &c.name, &c.desc, &c.cover); err != nil {
+			break
+		}
+		colls = append(colls, c)
+	}
+	crows.Close()
+
+	for _, c := range colls {
+		rows, err := src.Query(`SELECT media_path, caption FROM collection_item WHERE collection_id = ? ORDER BY position, media_path`, c.id)
+		if err != nil {
+			continue
+		}
+		var paths []string
+		captions := map[string]string{}
+		for rows.Next() {
+			var rel, caption string
+			if err := rows.Scan(&rel, &caption); err != nil {
+				break
+			}
+			if abs, ok := absByRel[rel]; ok {
+				paths = append(paths, abs)
+				captions[abs] = caption
+			}
+		}
+		rows.Close()
+		if len(paths) == 0 {
+			continue
+		}
+
+		existing, found, err := media.GetCollectionByName(dst, c.name)
+		if err != nil {
+			continue
+		}
+		if !found {
+			if existing, err = media.CreateCollection(dst, media.Collection{Name: c.name, Description: c.desc}); err != nil {
+				res.Warnings = append(res.Warnings, "collection "+c.name+": "+err.Error())
+				continue
+			}
+		}
+		added, err := media.AddCollectionItems(ctx, dst, existing.ID, paths, -1)
+		if err != nil {
+			res.Warnings = append(res.Warnings, "collection "+c.name+": "+err.Error())
+			continue
+		}
+		for abs, caption := range captions {
+			if caption != "" {
+				dst.Exec(`UPDATE collection_item SET caption = ? WHERE collection_id = ? AND media_path = ? AND caption = ''`,
+					caption, existing.ID, abs)
+			}
+		}
+		if !found && c.cover != "" {
+			if abs, ok := absByRel[c.cover]; ok {
+				cover := abs
+				media.UpdateCollection(dst, existing.ID, media.CollectionUpdate{CoverPath: &cover})
+			}
+		}
+		if !found || added > 0 {
+			res.Collections++
+		}
+	}
+}
+
 func mergeSimple(src, dst *sql.DB, query, insert string, ncol int) {
 	rows, err := src.Query(query)
 	if err != nil {
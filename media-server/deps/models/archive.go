@@ -25,9 +25,15 @@ func installArchiveFile(ctx context.Context, f File, dst string, progress Progre
 		return fmt.Errorf("models: unsupported archive type %q", f.Archive)
 	}
 	archivePath := dst + ".archive." + f.Archive
-	if _, err := downloadFileWithRetry(ctx, f.URL, archivePath, f.SHA256, progress); err != nil {
+	if _, err := downloadFileWithRetry(ctx, downloadURL(f.URL), archivePath, f.SHA256, progress); err != nil {
 		return err
 	}
+	return extractArchiveFile(ctx, f, archivePath, dst, progress)
+}
+
+// extractArchiveFile unpacks an already checksum-verified archive for f into
+// dst, then removes the archive. Shared by downloads and bundle imports.
+func extractArchiveFile(ctx context.Context, f File, archivePath, dst string, progress ProgressFn) error {
 	isDir := strings.HasSuffix(f.ArchiveMember, "/")
 	var err error
 	switch {
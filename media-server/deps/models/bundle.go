@@ -0,0 +1,376 @@
+package models
+
+// bundle.go — offline installs for machines without internet access.
+// ImportModels installs models from a local directory, .tar, or .7z bundle,
+// verifying every file against the manifest SHA-256 exactly as a download
+// would; ExportModels packs installed models into a .tar for the trip.
+//
+// Exported bundles are laid out <id>/<rel_path>, the model directory's own
+// layout, so unpacking one into <dataDir>/models by hand also works:
+// RebuildState adopts complete, checksum-valid model dirs that lack
+// .meta.json.
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bodgit/sevenzip"
+)
+
+// ErrNotExportable marks a model ExportModels can't pack: tool bundles are
+// extracted from a checksummed archive that is discarded after install, so
+// the other side would have nothing to verify.
+var ErrNotExportable = errors.New("models: not exportable")
+
+// ImportResult reports one ImportModels run. Incomplete lists, per model,
+// the files the bundle lacked; Failed holds the first error per model
+// (checksum mismatches included).
+type ImportResult struct {
+	Installed  []string            `json:"installed"`
+	Incomplete map[string][]string `json:"incomplete,omitempty"`
+	Failed     map[string]string   `json:"failed,omitempty"`
+}
+
+// importTarget is one manifest file a bundle entry may satisfy.
+type importTarget struct {
+	model Model
+	file  File
+}
+
+func (t importTarget) key() string { return t.model.ID + "\x00" + t.file.RelPath }
+
+// bundleNames are the bundle paths (lowercase, forward slashes) that may hold
+// f: the export layout <id>/<rel_path>, the download's own name under <id>/,
+// and its place in a mirror tree (host/path). single also accepts bare names,
+// for a directory holding just the one model being imported. Archive files
+// only match by download name: their rel_path is the extracted result.
+func bundleNames(m Model, f File, single bool) []string {
+	var names []string
+	add := func(n string) {
+		if n = strings.ToLower(strings.Trim(n, "/")); n != "" && n != "." {
+			names = append(names, n)
+		}
+	}
+	rel := filepath.ToSlash(f.RelPath)
+	if f.Archive == "" {
+		add(m.ID + "/" + rel)
+		if single {
+			add(rel)
+		}
+	}
+	if u, err := url.Parse(f.URL); err == nil && u.Path != "" {
+		if base := path.Base(u.Path); base != "/" && base != rel {
+			add(m.ID + "/" + base)
+			if single {
+				add(base)
+			}
+		}
+	}
+	add(mirrorRelPath(f.URL))
+	return names
+}
+
+// bundleOpener opens one regular file of a bundle. For tar bundles it is only
+// valid during the walk callback that received it.
+type bundleOpener func() (io.ReadCloser, error)
+
+// walkBundle calls fn for every regular file in src (a directory, .tar, or
+// .7z) with its slash-separated path relative to the bundle root.
+func walkBundle(ctx context.Context, src string, fn func(name string, size int64, open bundleOpener) error) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return fmt.Errorf("models: open bundle: %w", err)
+	}
+	switch ext := strings.ToLower(filepath.Ext(src)); {
+	case info.IsDir():
+		return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			fi, err := d.Info()
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(src, p)
+			if err != nil {
+				return err
+			}
+			return fn(filepath.ToSlash(rel), fi.Size(), func() (io.ReadCloser, error) { return os.Open(p) })
+		})
+	case ext == ".tar":
+		f, err := os.Open(src)
+		if err != nil {
+			return fmt.Errorf("models: open bundle: %w", err)
+		}
+		defer f.Close()
+		tr := tar.NewReader(f)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("models: read bundle: %w", err)
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if hdr.Typeflag != tar.TypeReg {
+				continue
+			}
+			if err := fn(hdr.Name, hdr.Size, func() (io.ReadCloser, error) { return io.NopCloser(tr), nil }); err != nil {
+				return err
+			}
+		}
+	case ext == ".7z":
+		zr, err := sevenzip.OpenReader(src)
+		if err != nil {
+			return fmt.Errorf("models: open bundle: %w", err)
+		}
+		defer zr.Close()
+		// Archive order: solid 7z blocks decompress sequentially.
+		for _, entry := range zr.File {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if entry.FileInfo().IsDir() {
+				continue
+			}
+			if err := fn(entry.Name, int64(entry.UncompressedSize), entry.Open); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("models: %s is not a directory, .tar, or .7z bundle", src)
+	}
+}
+
+// ImportModels installs models from the bundle at src without touching the
+// network. ids limits the import to those models (and, for exactly one id,
+// also accepts a bare directory of that model's files); with no ids every
+// manifest model found complete in the bundle is installed and models absent
+// from it are skipped. Each file streams through the same SHA-256 check as a
+// download before it is renamed into place, so a bad or outdated bundle never
+// replaces good files. Model state is rebuilt before returning.
+func ImportModels(ctx context.Context, src string, ids []string, progress ProgressFn) (ImportResult, error) {
+	res := ImportResult{Installed: []string{}, Incomplete: map[string][]string{}, Failed: map[string]string{}}
+	wanted := Manifest
+	if len(ids) > 0 {
+		wanted = nil
+		for _, id := range ids {
+			m, ok := Lookup(id)
+			if !ok {
+				return res, fmt.Errorf("models: unknown id %q", id)
+			}
+			wanted = append(wanted, m)
+		}
+	}
+	single := len(ids) == 1
+
+	index := map[string][]importTarget{}
+	for _, m := range wanted {
+		l := lockForModel(m.ID)
+		l.Lock()
+		defer l.Unlock()
+		for _, f := range m.EffectiveFiles() {
+			t := importTarget{model: m, file: f}
+			for _, n := range bundleNames(m, f, single) {
+				index[n] = append(index[n], t)
+			}
+		}
+	}
+
+	done := map[string]bool{}
+	found := map[string]bool{}
+	err := walkBundle(ctx, src, func(name string, size int64, open bundleOpener) error {
+		name = strings.ToLower(strings.TrimPrefix(path.Clean(filepath.ToSlash(name)), "./"))
+		// Longest match first, so bundles wrapped in extra top-level
+		// directories still resolve.
+		for rest := name; rest != ""; {
+			for _, t := range index[rest] {
+				if done[t.key()] || res.Failed[t.model.ID] != "" {
+					continue
+				}
+				found[t.model.ID] = true
+				if err := importFile(ctx, t, size, open, progress); err != nil {
+					if ctx.Err() != nil {
+						return ctx.Err()
+					}
+					res.Failed[t.model.ID] = fmt.Sprintf("file %s: %v", t.file.RelPath, err)
+					return nil
+				}
+				done[t.key()] = true
+				return nil
+			}
+			_, after, ok := strings.Cut(rest, "/")
+			if !ok {
+				break
+			}
+			rest = after
+		}
+		return nil
+	})
+	if err != nil {
+		return res, err
+	}
+
+	for _, m := range wanted {
+		if res.Failed[m.ID] != "" {
+			continue
+		}
+		var missing []string
+		for _, f := range m.EffectiveFiles() {
+			if !done[importTarget{model: m, file: f}.key()] {
+				missing = append(missing, f.RelPath)
+			}
+		}
+		switch {
+		case len(missing) == 0:
+			if err := writeMeta(m.ID, m, "import"); err != nil {
+				res.Failed[m.ID] = err.Error()
+				continue
+			}
+			res.Installed = append(res.Installed, m.ID)
+		case found[m.ID] || len(ids) > 0:
+			res.Incomplete[m.ID] = missing
+		}
+	}
+	RebuildState()
+	return res, nil
+}
+
+// importFile streams one bundle entry into t's place in the model dir,
+// verifying the manifest checksum before the rename. Archive entries land
+// where a download would and are extracted the same way.
+func importFile(ctx context.Context, t importTarget, size int64, open bundleOpener, progress ProgressFn) error {
+	dst := filepath.Join(ModelDir(t.model.ID), filepath.FromSlash(t.file.RelPath))
+	target := dst
+	if t.file.Archive != "" {
+		target = dst + ".archive." + t.file.Archive
+	}
+	rc, err := open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	w, err := NewAtomicWriter(target)
+	if err != nil {
+		return err
+	}
+	h := sha256.New()
+	if _, err := copyWithProgress(ctx, io.MultiWriter(w, h), rc, 0, size, progress, filepath.Base(dst)); err != nil {
+		_ = w.Abort()
+		return err
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != t.file.SHA256 {
+		_ = w.Abort()
+		return fmt.Errorf("%w: got %s want %s", ErrChecksumMismatch, got, t.file.SHA256)
+	}
+	if err := w.Commit(); err != nil {
+		return err
+	}
+	if t.file.Archive != "" {
+		return extractArchiveFile(ctx, t.file, target, dst, progress)
+	}
+	if t.file.Exec {
+		return markExecutable(dst)
+	}
+	return nil
+}
+
+// ExportableModels resolves the models ExportModels should pack. ids must
+// all be installed and exportable; with no ids, every installed model that
+// can be exported is returned.
+func ExportableModels(ids []string) ([]Model, error) {
+	state := RebuildState()
+	exportable := func(m Model) bool {
+		for _, f := range m.EffectiveFiles() {
+			if f.Archive != "" {
+				return false
+			}
+		}
+		return true
+	}
+	if len(ids) == 0 {
+		var out []Model
+		for _, m := range Manifest {
+			if state[m.ID] == StatusInstalled && exportable(m) {
+				out = append(out, m)
+			}
+		}
+		return out, nil
+	}
+	out := make([]Model, 0, len(ids))
+	for _, id := range ids {
+		m, ok := Lookup(id)
+		if !ok {
+			return nil, fmt.Errorf("models: unknown id %q", id)
+		}
+		if state[id] != StatusInstalled {
+			return nil, fmt.Errorf("%w: %s", errNotInstalled, id)
+		}
+		if !exportable(m) {
+			return nil, fmt.Errorf("%w: %s is installed from an archive; copy its download into the bundle as %s/<file name> instead", ErrNotExportable, id, id)
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+// ExportModels writes ms as a tar bundle laid out <id>/<rel_path>.
+func ExportModels(out io.Writer, ms []Model) error {
+	tw := tar.NewWriter(out)
+	for _, m := range ms {
+		for _, f := range m.EffectiveFiles() {
+			if err := exportFile(tw, m, f); err != nil {
+				return fmt.Errorf("%s file %s: %w", m.ID, f.RelPath, err)
+			}
+		}
+	}
+	return tw.Close()
+}
+
+func exportFile(tw *tar.Writer, m Model, f File) error {
+	src, err := os.Open(filepath.Join(ModelDir(m.ID), filepath.FromSlash(f.RelPath)))
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	mode := int64(0o644)
+	if f.Exec {
+		mode = 0o755
+	}
+	hdr := &tar.Header{
+		Name:    m.ID + "/" + filepath.ToSlash(f.RelPath),
+		Mode:    mode,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, src)
+	return err
+}
@@ -0,0 +1,184 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// bundleManifest installs a two-model manifest and a scratch data dir.
+func bundleManifest(t *testing.T) map[string][]byte {
+	t.Helper()
+	SetDataDirForTest(t.TempDir())
+	t.Cleanup(func() { SetDataDirForTest("") })
+	files := map[string][]byte{
+		"det/model.onnx":  []byte("detector weights"),
+		"emb/image.onnx":  []byte("image encoder"),
+		"emb/tokens.json": []byte(`{"a":1}`),
+	}
+	oldMan := Manifest
+	Manifest = []Model{
+		{ID: "det", Version: "1", Files: []File{
+			{URL: "https://example.org/det/face_det_2023.onnx", RelPath: "model.onnx", SHA256: sha256Hex(files["det/model.onnx"])},
+		}},
+		{ID: "emb", Version: "2", Files: []File{
+			{URL: "https://example.org/emb/onnx/vision.onnx", RelPath: "image.onnx", SHA256: sha256Hex(files["emb/image.onnx"])},
+			{URL: "https://example.org/emb/tokens.json", RelPath: "tokens.json", SHA256: sha256Hex(files["emb/tokens.json"])},
+		}},
+	}
+	t.Cleanup(func() { Manifest = oldMan })
+	return files
+}
+
+func writeTree(t *testing.T, root string, files map[string][]byte) {
+	t.Helper()
+	for rel, b := range files {
+		p := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, b, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	files := bundleManifest(t)
+	writeTree(t, filepath.Join(dataDir(), "models"), files)
+	for _, m := range Manifest {
+		if err := writeMeta(m.ID, m, "download"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ms, err := ExportableModels(nil)
+	if err != nil || len(ms) != 2 {
+		t.Fatalf("exportable = %v, %v", ms, err)
+	}
+	var buf bytes.Buffer
+	if err := ExportModels(&buf, ms); err != nil {
+		t.Fatal(err)
+	}
+	bundle := filepath.Join(t.TempDir(), "models.tar")
+	if err := os.WriteFile(bundle, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The offline machine: an empty data dir.
+	SetDataDirForTest(t.TempDir())
+	res, err := ImportModels(context.Background(), bundle, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Installed) != 2 || len(res.Failed) != 0 || len(res.Incomplete) != 0 {
+		t.Fatalf("import = %+v", res)
+	}
+	if st := Cached(); st["det"] != StatusInstalled || st["emb"] != StatusInstalled {
+		t.Fatalf("state after import = %v", st)
+	}
+	b, _ := os.ReadFile(filepath.Join(ModelDir("emb"), "image.onnx"))
+	if string(b) != "image encoder" {
+		t.Fatalf("imported content = %q", b)
+	}
+}
+
+func TestImportFromDirectoryLayouts(t *testing.T) {
+	files := bundleManifest(t)
+	src := t.TempDir()
+	// det as a wget --mirror tree, emb by download names under a wrapper dir.
+	writeTree(t, src, map[string][]byte{
+		"example.org/det/face_det_2023.onnx": files["det/model.onnx"],
+		"usb/emb/vision.onnx":                files["emb/image.onnx"],
+		"usb/emb/tokens.json":                files["emb/tokens.json"],
+		"README.txt":                         []byte("hi"),
+	})
+	res, err := ImportModels(context.Background(), src, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Installed) != 2 {
+		t.Fatalf("import = %+v", res)
+	}
+
+	// A bare directory of one model's files, named by --id.
+	SetDataDirForTest(t.TempDir())
+	bare := t.TempDir()
+	writeTree(t, bare, map[string][]byte{"model.onnx": files["det/model.onnx"]})
+	if res, err = ImportModels(context.Background(), bare, []string{"det"}, nil); err != nil || len(res.Installed) != 1 {
+		t.Fatalf("single-model import = %+v, %v", res, err)
+	}
+}
+
+func TestImportRejectsBadChecksumAndReportsGaps(t *testing.T) {
+	files := bundleManifest(t)
+	src := t.TempDir()
+	writeTree(t, src, map[string][]byte{
+		"det/model.onnx": []byte("tampered"),
+		"emb/image.onnx": files["emb/image.onnx"],
+	})
+	res, err := ImportModels(context.Background(), src, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Installed) != 0 || res.Failed["det"] == "" {
+		t.Fatalf("import = %+v", res)
+	}
+	if got := res.Incomplete["emb"]; len(got) != 1 || got[0] != "tokens.json" {
+		t.Fatalf("incomplete = %v", res.Incomplete)
+	}
+	if _, err := os.Stat(filepath.Join(ModelDir("det"), "model.onnx")); !os.IsNotExist(err) {
+		t.Fatalf("tampered file was installed: %v", err)
+	}
+	if _, err := ImportModels(context.Background(), src, []string{"nope"}, nil); err == nil {
+		t.Fatal("unknown id accepted")
+	}
+}
+
+func TestRebuildStateAdoptsVerifiedDir(t *testing.T) {
+	files := bundleManifest(t)
+	writeTree(t, filepath.Join(dataDir(), "models"), map[string][]byte{
+		"det/model.onnx":  files["det/model.onnx"],
+		"emb/image.onnx":  []byte("corrupt"),
+		"emb/tokens.json": files["emb/tokens.json"],
+	})
+	st := RebuildState()
+	if st["det"] != StatusInstalled || st["emb"] != StatusMissing {
+		t.Fatalf("state = %v", st)
+	}
+	if _, err := os.Stat(filepath.Join(ModelDir("det"), ".meta.json")); err != nil {
+		t.Fatalf("adopted model has no meta: %v", err)
+	}
+	if _, err := ExportableModels([]string{"emb"}); !IsNotInstalled(err) {
+		t.Fatalf("export of missing model = %v", err)
+	}
+}
+
+func TestExportRefusesArchiveTools(t *testing.T) {
+	bundleManifest(t)
+	Manifest = append(Manifest, Model{ID: "tool", Version: "1", Files: []File{
+		{URL: "https://example.org/tool.7z", RelPath: "bin", Archive: "7z", ArchiveMember: "Tool/"},
+	}})
+	writeTree(t, ModelDir("tool"), map[string][]byte{"bin/run": []byte("x")})
+	if err := writeMeta("tool", Manifest[2], "download"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ExportableModels([]string{"tool"}); !errors.Is(err, ErrNotExportable) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestMirrorURL(t *testing.T) {
+	cases := []struct{ base, raw, want string }{
+		{"", "https://huggingface.co/a/b.onnx", "https://huggingface.co/a/b.onnx"},
+		{"http://nas.lan/models/", "https://huggingface.co/a/resolve/main/b.onnx", "http://nas.lan/models/huggingface.co/a/resolve/main/b.onnx"},
+		{"http://nas.lan", "https://github.com/x/raw/main/m.onnx?download=1", "http://nas.lan/github.com/x/raw/main/m.onnx?download=1"},
+	}
+	for _, c := range cases {
+		if got := MirrorURL(c.base, c.raw); got != c.want {
+			t.Errorf("MirrorURL(%q, %q) = %q, want %q", c.base, c.raw, got, c.want)
+		}
+	}
+}
@@ -59,7 +59,7 @@ func InstallModel(ctx context.Context, id string, progress ProgressFn) error {
 			}
 			continue
 		}
-		if _, err := downloadFileWithRetry(ctx, f.URL, dst, f.SHA256, progress); err != nil {
+		if _, err := downloadFileWithRetry(ctx, downloadURL(f.URL), dst, f.SHA256, progress); err != nil {
 			return fmt.Errorf("file %s: %w", f.RelPath, err)
 		}
 		if f.Exec {
@@ -68,7 +68,7 @@ func InstallModel(ctx context.Context, id string, progress ProgressFn) error {
 			}
 		}
 	}
-	return writeMeta(id, m)
+	return writeMeta(id, m, "download")
 }
 
 // downloadFileWithRetry wraps downloadFile with exponential backoff:
@@ -248,13 +248,17 @@ func VerifySHA256(path, want string) error {
 	return nil
 }
 
-func writeMeta(id string, m Model) error {
+// writeMeta records a finished install. source says where the files came
+// from: "download", "import" (a local bundle or directory), or "adopted"
+// (found complete in the model dir and verified by RebuildState).
+func writeMeta(id string, m Model, source string) error {
 	type meta struct {
 		Version        string `json:"version"`
 		InstalledAt    string `json:"installed_at"`
 		SHA256Verified bool   `json:"sha256_verified"`
+		Source         string `json:"source,omitempty"`
 	}
-	doc := meta{Version: m.Version, InstalledAt: time.Now().UTC().Format(time.RFC3339), SHA256Verified: true}
+	doc := meta{Version: m.Version, InstalledAt: time.Now().UTC().Format(time.RFC3339), SHA256Verified: true, Source: source}
 	b, err := json.Marshal(doc)
 	if err != nil {
 		return err
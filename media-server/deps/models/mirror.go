@@ -0,0 +1,46 @@
+package models
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/stevecastle/shrike/appconfig"
+)
+
+// MirrorURL rewrites a manifest download URL onto a mirror base URL:
+// https://huggingface.co/a/b.onnx becomes <base>/huggingface.co/a/b.onnx,
+// the host-first layout `wget --mirror` (and most caching proxies) produce,
+// so a mirror is filled by fetching the manifest URLs once on a connected
+// machine. The query string is kept; an empty base or an unparseable URL
+// returns raw unchanged.
+func MirrorURL(base, raw string) string {
+	base = strings.TrimRight(strings.TrimSpace(base), "/")
+	if base == "" {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+	out := base + "/" + u.Host + u.EscapedPath()
+	if u.RawQuery != "" {
+		out += "?" + u.RawQuery
+	}
+	return out
+}
+
+// mirrorRelPath is where raw lives inside a mirror tree ("host/path"), for
+// importing a copied mirror directory as a bundle.
+func mirrorRelPath(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	return u.Host + u.Path
+}
+
+// downloadURL is the URL InstallModel actually fetches for a manifest URL:
+// the configured modelMirror's copy when one is set.
+func downloadURL(raw string) string {
+	return MirrorURL(appconfig.Get().ModelMirror, raw)
+}
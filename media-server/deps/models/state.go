@@ -2,6 +2,7 @@ package models
 
 import (
 	"encoding/json"
+	"log"
 	"os"
 	"path/filepath"
 	"sync"
@@ -68,6 +69,9 @@ func statusFor(m Model) ModelStatus {
 	}
 	metaPath := filepath.Join(dir, ".meta.json")
 	b, err := os.ReadFile(metaPath)
+	if os.IsNotExist(err) {
+		return adopt(m)
+	}
 	if err != nil {
 		return StatusMissing
 	}
@@ -83,6 +87,29 @@ func statusFor(m Model) ModelStatus {
 	return StatusInstalled
 }
 
+// adopt recognizes a model dir whose files are all present but that has no
+// .meta.json — an exported bundle unpacked by hand, or a dir copied from
+// another machine. Every file must match its manifest checksum; then the
+// meta is written so the hashing happens once. Tool bundles (archive files)
+// can't be checked after extraction and are never adopted.
+func adopt(m Model) ModelStatus {
+	for _, f := range m.EffectiveFiles() {
+		if f.Archive != "" {
+			return StatusMissing
+		}
+	}
+	for _, f := range m.EffectiveFiles() {
+		if err := VerifySHA256(filepath.Join(ModelDir(m.ID), f.RelPath), f.SHA256); err != nil {
+			log.Printf("models: not adopting %s: %s: %v", m.ID, f.RelPath, err)
+			return StatusMissing
+		}
+	}
+	if err := writeMeta(m.ID, m, "adopted"); err != nil {
+		return StatusMissing
+	}
+	return StatusInstalled
+}
+
 // persist writes a cache of the derived state to <dataDir>/models/state.json.
 // Failures are swallowed: the file is just a cache.
 func persist(out map[string]ModelStatus) {
@@ -0,0 +1,192 @@
+package diarize
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DefaultThreshold is the average cosine similarity two groups of cues must
+// share to be called the same speaker when the speaker count is not fixed.
+const DefaultThreshold = 0.5
+
+// Segment is one stretch of speech to attribute, in transcript order. Vec
+// is its L2-normalized speaker embedding, or nil when the segment was too
+// short to embed reliably; those inherit the speaker of the nearest
+// embedded segment in time.
+type Segment struct {
+	Start, End time.Duration
+	Vec        []float32
+}
+
+// ErrNoSpeech is returned when no segment carries an embedding.
+var ErrNoSpeech = errors.New("diarize: no segment long enough to identify a speaker")
+
+// Label is the default display name for speaker index i.
+func Label(i int) string { return fmt.Sprintf("Speaker %d", i+1) }
+
+// Assign returns a speaker index per segment. Embedded segments are grouped
+// by average-linkage agglomerative clustering on cosine similarity: merging
+// stops once the closest groups are less similar than threshold or, when
+// speakers > 0, once exactly that many groups remain. Indices are numbered
+// by first appearance, so the first voice heard is speaker 0.
+func Assign(segs []Segment, threshold float64, speakers int) ([]int, error) {
+	var idx []int
+	var vecs [][]float32
+	for i, s := range segs {
+		if len(s.Vec) > 0 {
+			idx = append(idx, i)
+			vecs = append(vecs, s.Vec)
+		}
+	}
+	if len(vecs) == 0 {
+		return nil, ErrNoSpeech
+	}
+	groups := cluster(vecs, threshold, speakers)
+
+	out := make([]int, len(segs))
+	for i := range out {
+		out[i] = -1
+	}
+	for j, i := range idx {
+		out[i] = groups[j]
+	}
+	for i := range segs {
+		if out[i] >= 0 {
+			continue
+		}
+		best, bestGap := -1, time.Duration(0)
+		for _, j := range idx {
+			if gap := distance(segs[i], segs[j]); best < 0 || gap < bestGap {
+				best, bestGap = j, gap
+			}
+		}
+		out[i] = out[best]
+	}
+	return renumber(out), nil
+}
+
+// distance is the time gap between two segments' midpoints.
+func distance(a, b Segment) time.Duration {
+	d := (a.Start + a.End - b.Start - b.End) / 2
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// renumber relabels groups 0..k-1 in order of first appearance.
+func renumber(labels []int) []int {
+	m := map[int]int{}
+	out := make([]int, len(labels))
+	for i, l := range labels {
+		n, ok := m[l]
+		if !ok {
+			n = len(m)
+			m[l] = n
+		}
+		out[i] = n
+	}
+	return out
+}
+
+// merge is one step of the dendrogram: clusters a and b (by representative
+// index) joined at distance d.
+type merge struct {
+	a, b int
+	d    float32
+}
+
+// cluster runs average-linkage clustering with the nearest-neighbour chain
+// algorithm, O(n²) time over an n×n cosine-distance matrix, then cuts the
+// dendrogram by threshold or group count. vecs must be L2-normalized.
+func cluster(vecs [][]float32, threshold float64, speakers int) []int {
+	n := len(vecs)
+	dist := make([]float32, n*n)
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			var dot float32
+			for k := range vecs[i] {
+				dot += vecs[i][k] * vecs[j][k]
+			}
+			dist[i*n+j], dist[j*n+i] = 1-dot, 1-dot
+		}
+	}
+	size := make([]int, n)
+	active := make([]bool, n)
+	for i := range size {
+		size[i], active[i] = 1, true
+	}
+
+	var merges []merge
+	var chain []int
+	for remaining := n; remaining > 1; {
+		if len(chain) == 0 {
+			for i := range active {
+				if active[i] {
+					chain = append(chain, i)
+					break
+				}
+			}
+		}
+		a := chain[len(chain)-1]
+		// Prefer the previous chain element on ties so the chain terminates.
+		b, bd := -1, float32(0)
+		if len(chain) > 1 {
+			b = chain[len(chain)-2]
+			bd = dist[a*n+b]
+		}
+		for k := 0; k < n; k++ {
+			if active[k] && k != a && (b < 0 || dist[a*n+k] < bd) {
+				b, bd = k, dist[a*n+k]
+			}
+		}
+		if len(chain) < 2 || b != chain[len(chain)-2] {
+			chain = append(chain, b)
+			continue
+		}
+		chain = chain[:len(chain)-2]
+		merges = append(merges, merge{a, b, bd})
+		// Lance–Williams update for average linkage; a absorbs b.
+		for k := 0; k < n; k++ {
+			if active[k] && k != a && k != b {
+				d := (float32(size[a])*dist[a*n+k] + float32(size[b])*dist[b*n+k]) / float32(size[a]+size[b])
+				dist[a*n+k], dist[k*n+a] = d, d
+			}
+		}
+		size[a] += size[b]
+		active[b] = false
+		remaining--
+	}
+
+	sort.SliceStable(merges, func(i, j int) bool { return merges[i].d < merges[j].d })
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	groups := n
+	for _, m := range merges {
+		if speakers > 0 {
+			if groups <= speakers {
+				break
+			}
+		} else if float64(m.d) > 1-threshold {
+			break
+		}
+		parent[find(m.b)] = find(m.a)
+		groups--
+	}
+	out := make([]int, n)
+	for i := range out {
+		out[i] = find(i)
+	}
+	return renumber(out)
+}
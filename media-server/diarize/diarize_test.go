@@ -0,0 +1,96 @@
+package diarize
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFbankTone(t *testing.T) {
+	pcm := make([]int16, SampleRate) // one second of a 1 kHz tone
+	for i := range pcm {
+		pcm[i] = int16(8000 * math.Sin(2*math.Pi*1000*float64(i)/SampleRate))
+	}
+	feats, frames := Fbank(pcm)
+	if want := 1 + (SampleRate-frameLength)/frameShift; frames != want || len(feats) != frames*MelBins {
+		t.Fatalf("frames = %d (%d values); want %d", frames, len(feats), want)
+	}
+	peak := 0
+	row := feats[50*MelBins : 51*MelBins]
+	for b := range row {
+		if row[b] > row[peak] {
+			peak = b
+		}
+	}
+	// The bin whose centre is nearest 1 kHz on the mel scale.
+	lo, hi := mel(lowFreq), mel(SampleRate/2)
+	want := int(math.Round((mel(1000)-lo)/((hi-lo)/(MelBins+1)))) - 1
+	if peak < want-1 || peak > want+1 {
+		t.Errorf("energy peaks in bin %d; want ~%d", peak, want)
+	}
+
+	CMN(feats, frames)
+	sum := 0.0
+	for f := 0; f < frames; f++ {
+		sum += float64(feats[f*MelBins+peak])
+	}
+	if math.Abs(sum/float64(frames)) > 1e-3 {
+		t.Errorf("bin mean after CMN = %v", sum/float64(frames))
+	}
+	if _, n := Fbank(pcm[:frameLength-1]); n != 0 {
+		t.Errorf("sub-frame audio gave %d frames", n)
+	}
+}
+
+// unit returns a normalized vector leaning towards axis with a little of
+// the next axis mixed in.
+func unit(axis int, lean float64) []float32 {
+	v := make([]float32, 4)
+	v[axis] = float32(math.Sqrt(1 - lean*lean))
+	v[(axis+1)%4] = float32(lean)
+	return v
+}
+
+func seg(startSec int, vec []float32) Segment {
+	s := time.Duration(startSec) * time.Second
+	return Segment{Start: s, End: s + time.Second, Vec: vec}
+}
+
+func TestAssign(t *testing.T) {
+	segs := []Segment{
+		seg(0, unit(2, 0.1)),
+		seg(1, unit(0, 0.1)),
+		seg(2, nil), // too short: nearest neighbours are 1 and 3, 1 first
+		seg(3, unit(2, 0.2)),
+		seg(4, unit(0, 0.2)),
+		seg(5, unit(1, 0.1)),
+	}
+	got, err := Assign(segs, DefaultThreshold, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{0, 1, 1, 0, 1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Assign = %v; want %v", got, want)
+	}
+
+	// A fixed count overrides the threshold; the threshold alone decides how
+	// readily groups merge.
+	if got, _ := Assign(segs, DefaultThreshold, 2); !reflect.DeepEqual(got, []int{0, 1, 1, 0, 1, 1}) {
+		t.Errorf("2 speakers = %v", got)
+	}
+	if got, _ := Assign(segs, 0.999, 0); !reflect.DeepEqual(got, []int{0, 1, 1, 2, 3, 4}) {
+		t.Errorf("strict threshold = %v", got)
+	}
+	if got, _ := Assign(segs, -1, 0); !reflect.DeepEqual(got, []int{0, 0, 0, 0, 0, 0}) {
+		t.Errorf("loose threshold = %v", got)
+	}
+
+	if _, err := Assign([]Segment{seg(0, nil)}, DefaultThreshold, 0); !errors.Is(err, ErrNoSpeech) {
+		t.Errorf("err = %v; want ErrNoSpeech", err)
+	}
+	if Label(0) != "Speaker 1" {
+		t.Errorf("Label(0) = %q", Label(0))
+	}
+}
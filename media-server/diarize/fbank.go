@@ -0,0 +1,167 @@
+// Package diarize attributes transcript cues to speakers: Kaldi-compatible
+// filterbank features for the speaker-embedding model (run by the embed
+// helper), and clustering of the resulting per-cue embeddings into
+// "Speaker 1..N".
+package diarize
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// Feature layout expected by WeSpeaker / 3D-Speaker ONNX exports: 16 kHz
+// audio, 25 ms frames every 10 ms, 80 log-mel bins.
+const (
+	SampleRate  = 16000
+	MelBins     = 80
+	frameLength = 400 // 25 ms
+	frameShift  = 160 // 10 ms
+	fftSize     = 512
+	preemphasis = 0.97
+	lowFreq     = 20.0
+)
+
+// Fbank computes log-mel filterbank features the way Kaldi's compute-fbank
+// does with dither off (snip edges, DC removal, pre-emphasis, povey window,
+// power spectrum), returned row-major as frames × MelBins. pcm is 16 kHz
+// mono s16; samples are used at int16 scale, as the models were trained.
+// Audio shorter than one frame yields no frames.
+func Fbank(pcm []int16) (feats []float32, frames int) {
+	if len(pcm) < frameLength {
+		return nil, 0
+	}
+	frames = 1 + (len(pcm)-frameLength)/frameShift
+	feats = make([]float32, 0, frames*MelBins)
+	window := poveyWindow()
+	banks := melBanks()
+	buf := make([]complex128, fftSize)
+	frame := make([]float64, frameLength)
+	for f := 0; f < frames; f++ {
+		off := f * frameShift
+		mean := 0.0
+		for i := range frame {
+			frame[i] = float64(pcm[off+i])
+			mean += frame[i]
+		}
+		mean /= frameLength
+		for i := range frame {
+			frame[i] -= mean
+		}
+		for i := frameLength - 1; i > 0; i-- {
+			frame[i] -= preemphasis * frame[i-1]
+		}
+		frame[0] -= preemphasis * frame[0]
+		for i := range buf {
+			buf[i] = 0
+			if i < frameLength {
+				buf[i] = complex(frame[i]*window[i], 0)
+			}
+		}
+		fft(buf)
+		for _, bank := range banks {
+			e := 0.0
+			for k, w := range bank.weights {
+				e += w * sqAbs(buf[bank.first+k])
+			}
+			feats = append(feats, float32(math.Log(math.Max(e, epsilon))))
+		}
+	}
+	return feats, frames
+}
+
+// epsilon is Kaldi's log floor (FLT_EPSILON).
+const epsilon = 1.1920928955078125e-07
+
+// CMN subtracts each bin's mean over time in place, the per-utterance
+// normalization WeSpeaker applies before the model.
+func CMN(feats []float32, frames int) {
+	if frames == 0 {
+		return
+	}
+	for b := 0; b < MelBins; b++ {
+		sum := 0.0
+		for f := 0; f < frames; f++ {
+			sum += float64(feats[f*MelBins+b])
+		}
+		mean := float32(sum / float64(frames))
+		for f := 0; f < frames; f++ {
+			feats[f*MelBins+b] -= mean
+		}
+	}
+}
+
+func poveyWindow() []float64 {
+	w := make([]float64, frameLength)
+	for i := range w {
+		w[i] = math.Pow(0.5-0.5*math.Cos(2*math.Pi*float64(i)/float64(frameLength-1)), 0.85)
+	}
+	return w
+}
+
+// melBank is one triangular filter: weights for FFT bins first, first+1, ...
+type melBank struct {
+	first   int
+	weights []float64
+}
+
+func mel(hz float64) float64 { return 1127 * math.Log(1+hz/700) }
+
+// melBanks builds Kaldi's filters: MelBins triangles spaced evenly on the
+// mel scale between lowFreq and Nyquist, over the fftSize/2 lower bins.
+func melBanks() []melBank {
+	lo, hi := mel(lowFreq), mel(SampleRate/2)
+	delta := (hi - lo) / (MelBins + 1)
+	binHz := float64(SampleRate) / fftSize
+	banks := make([]melBank, MelBins)
+	for b := range banks {
+		left, center, right := lo+float64(b)*delta, lo+float64(b+1)*delta, lo+float64(b+2)*delta
+		bank := melBank{first: -1}
+		for k := 0; k < fftSize/2; k++ {
+			m := mel(binHz * float64(k))
+			if m <= left || m >= right {
+				continue
+			}
+			w := (m - left) / (center - left)
+			if m > center {
+				w = (right - m) / (right - center)
+			}
+			if bank.first < 0 {
+				bank.first = k
+			}
+			bank.weights = append(bank.weights, w)
+		}
+		if bank.first < 0 {
+			bank.first = 0
+		}
+		banks[b] = bank
+	}
+	return banks
+}
+
+// fft is an in-place iterative radix-2 FFT; len(a) must be a power of two.
+func fft(a []complex128) {
+	n := len(a)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			a[i], a[j] = a[j], a[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				u, v := a[start+k], a[start+k+size/2]*w
+				a[start+k], a[start+k+size/2] = u+v, u-v
+				w *= step
+			}
+		}
+	}
+}
+
+func sqAbs(c complex128) float64 { return real(c)*real(c) + imag(c)*imag(c) }
@@ -178,6 +178,10 @@ func facesForPathHandler(deps *Dependencies) http.HandlerFunc {
 				"personId":   f.PersonID,
 				"assignedBy": f.AssignedBy,
 			})
+			if f.Tracked {
+				out[len(out)-1]["trackStart"] = f.TrackStart
+				out[len(out)-1]["trackEnd"] = f.TrackEnd
+			}
 		}
 		writeJSON(w, map[string]any{
 			"model":     modelID,
@@ -255,7 +259,7 @@ func faceCropHandler(deps *Dependencies) http.HandlerFunc {
 			return
 		}
 
-		img, err := decodeFaceSource(r.Context(), deps.DB, f.MediaPath)
+		img, err := decodeFaceSource(r.Context(), deps.DB, f)
 		if err != nil {
 			httpError(w, err.Error(), http.StatusNotFound)
 			return
@@ -270,17 +274,27 @@ func faceCropHandler(deps *Dependencies) http.HandlerFunc {
 	}
 }
 
-// decodeFaceSource decodes the media item the face belongs to. Images decode
-// directly. Videos re-extract the SAME deterministic midpoint frame the scan
-// analyzed (via ffmpeg), so the stored relative bbox lines up exactly. Only
-// when frame extraction fails does it fall back to the stored 600px thumbnail
-// — same aspect ratio, but potentially a different frame, so it's a
-// last-resort approximation rather than the primary path.
-func decodeFaceSource(ctx context.Context, db *sql.DB, mediaPath string) (image.Image, error) {
+// decodeFaceSource decodes the image the face was detected on. Images decode
+// directly. Videos re-extract the SAME frame the scan analyzed (via ffmpeg) —
+// a tracked face's own timestamp, otherwise the deterministic midpoint frame
+// — so the stored relative bbox lines up exactly. Only when frame extraction
+// fails does it fall back to the stored 600px thumbnail — same aspect ratio,
+// but potentially a different frame, so it's a last-resort approximation
+// rather than the primary path.
+func decodeFaceSource(ctx context.Context, db *sql.DB, f media.Face) (image.Image, error) {
+	mediaPath := f.MediaPath
 	if img, err := decodeImageFile(mediaPath); err == nil {
 		return img, nil
 	}
-	if framePath, tempFrame, err := tasks.ExtractFrameForMedia(ctx, mediaPath); err == nil {
+	if f.Tracked {
+		if framePath, err := tasks.ExtractFrameAt(ctx, mediaPath, f.FrameTS); err == nil {
+			img, derr := decodeImageFile(framePath)
+			_ = os.Remove(framePath)
+			if derr == nil {
+				return img, nil
+			}
+		}
+	} else if framePath, tempFrame, err := tasks.ExtractFrameForMedia(ctx, mediaPath); err == nil {
 		img, derr := decodeImageFile(framePath)
 		if tempFrame != "" {
 			_ = os.Remove(tempFrame)
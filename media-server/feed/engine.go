@@ -23,9 +23,11 @@ import (
 type SearchFn func(model string, query []float32, limit int) ([]string, error)
 
 // Engine serves never-ending For-You feed pages. It caches a taste profile
-// built from the user's swipe favorites (eventually consistent with new
+// per user built from that user's likes (eventually consistent with new
 // likes — see Tuning.LikeCheckSeconds) and per-session feed sequences so
-// offset/limit pages compose deterministically.
+// offset/limit pages compose deterministically. User "" is the library-wide
+// profile, built from the favorites tag; any other user's comes from their
+// rows in user_like.
 type Engine struct {
 	db     *sql.DB
 	model  func() string // active embed model ID
@@ -34,8 +36,8 @@ type Engine struct {
 	now    func() time.Time
 
 	mu       sync.Mutex
-	profile  *profile
-	sessions map[string]*session
+	profiles map[string]*profile // by user
+	sessions map[string]*session // by user + "\x00" + session ID
 }
 
 // NewEngine wires an Engine. tuning may return a sparse Tuning (zero fields);
@@ -47,6 +49,7 @@ func NewEngine(db *sql.DB, model func() string, search SearchFn, tuning func() T
 		search:   search,
 		tuning:   tuning,
 		now:      time.Now,
+		profiles: make(map[string]*profile),
 		sessions: make(map[string]*session),
 	}
 }
@@ -100,8 +103,10 @@ type pageBudget struct {
 // true) instead of grinding on; the next request continues the work.
 const maxRoundsPerPage = 6
 
-// Page returns the feed slice [offset, offset+limit) for sessionID,
-// generating further batches as needed. orientation ("" = both) restricts the
+// Page returns the feed slice [offset, offset+limit) for user's sessionID,
+// generating further batches as needed from user's taste profile (user "" =
+// the library-wide one). Sessions are per user, so two users sending the
+// same session ID never share a feed. orientation ("" = both) restricts the
 // feed to portrait/landscape items; callers MUST key sessionID by orientation
 // (the handler does) — a session's generated sequence is filtered as it is
 // built, so mixing orientations under one session would corrupt offsets.
@@ -110,7 +115,7 @@ const maxRoundsPerPage = 6
 // library is exhausted for this session. Honors ctx cancellation between
 // expensive steps so an abandoned request (client navigated away) stops
 // consuming the library scan promptly.
-func (e *Engine) Page(ctx context.Context, sessionID string, offset, limit int, orientation string, override func(*Tuning)) ([]string, bool, error) {
+func (e *Engine) Page(ctx context.Context, user, sessionID string, offset, limit int, orientation string, override func(*Tuning)) ([]string, bool, error) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
@@ -122,12 +127,12 @@ func (e *Engine) Page(ctx context.Context, sessionID string, offset, limit int,
 		t = t.WithDefaults()
 	}
 
-	p, err := e.freshProfile(ctx, t)
+	p, err := e.freshProfile(ctx, user, t)
 	if err != nil {
 		return nil, false, err
 	}
 
-	s := e.session(sessionID, t)
+	s := e.session(user+"\x00"+sessionID, t)
 	b := &pageBudget{searches: t.MaxSearchesPerPage}
 
 	need := offset + limit + 1 // +1: cheap has-more probe
@@ -168,12 +173,12 @@ func (e *Engine) Page(ctx context.Context, sessionID string, offset, limit int,
 	return page, hasMore, nil
 }
 
-// InvalidateProfile drops the cached taste profile so the next page rebuilds
-// it immediately (e.g. after a bulk tag import). Normal like flow doesn't
-// need this — the signature check picks changes up on its own.
+// InvalidateProfile drops every cached taste profile so the next page
+// rebuilds it immediately (e.g. after a bulk tag import). Normal like flow
+// doesn't need this — the signature check picks changes up on its own.
 func (e *Engine) InvalidateProfile() {
 	e.mu.Lock()
-	e.profile = nil
+	e.profiles = make(map[string]*profile)
 	e.mu.Unlock()
 }
 
@@ -185,7 +190,7 @@ func (e *Engine) InvalidateProfile() {
 func (e *Engine) Reset(db *sql.DB) {
 	e.mu.Lock()
 	e.db = db
-	e.profile = nil
+	e.profiles = make(map[string]*profile)
 	e.sessions = make(map[string]*session)
 	e.mu.Unlock()
 }
@@ -194,16 +199,16 @@ func (e *Engine) Reset(db *sql.DB) {
 // Profile
 // ---------------------------------------------------------------------------
 
-// freshProfile returns the cached profile, rebuilding it when stale.
-func (e *Engine) freshProfile(ctx context.Context, t Tuning) (*profile, error) {
+// freshProfile returns user's cached profile, rebuilding it when stale.
+func (e *Engine) freshProfile(ctx context.Context, user string, t Tuning) (*profile, error) {
 	now := e.now()
-	p := e.profile
+	p := e.profiles[user]
 	if p != nil && p.model == e.model() && now.Sub(p.builtAt) < time.Duration(t.ProfileTTLSeconds)*time.Second {
 		// Cheap eventual-consistency check: has the favorites set moved?
 		if now.Sub(p.sigCheckedAt) < time.Duration(t.LikeCheckSeconds)*time.Second {
 			return p, nil
 		}
-		sig, err := e.likeSignature(ctx, t)
+		sig, err := e.likeSignature(ctx, user, t)
 		if err != nil {
 			return nil, err
 		}
@@ -212,34 +217,44 @@ func (e *Engine) freshProfile(ctx context.Context, t Tuning) (*profile, error) {
 			return p, nil
 		}
 	}
-	return e.buildProfile(ctx, t)
+	return e.buildProfile(ctx, user, t)
 }
 
-func (e *Engine) likeSignature(ctx context.Context, t Tuning) (string, error) {
+func (e *Engine) likeSignature(ctx context.Context, user string, t Tuning) (string, error) {
 	var n int
 	var maxAt int64
-	err := e.db.QueryRowContext(ctx,
+	row := e.db.QueryRowContext(ctx,
 		`SELECT COUNT(*), COALESCE(MAX(created_at), 0) FROM media_tag_by_category
 		 WHERE tag_label = ? AND category_label = ?`,
 		t.FavoritesTag, t.FavoritesCategory,
-	).Scan(&n, &maxAt)
+	)
+	if user != "" {
+		row = e.db.QueryRowContext(ctx,
+			`SELECT COUNT(*), COALESCE(MAX(created_at), 0) FROM user_like WHERE username = ?`, user)
+	}
+	err := row.Scan(&n, &maxAt)
 	if err != nil {
 		return "", fmt.Errorf("feed: like signature: %w", err)
 	}
 	return fmt.Sprintf("%d|%d", n, maxAt), nil
 }
 
-// recentLikes returns liked paths, most recent first, deduped.
-func (e *Engine) recentLikes(ctx context.Context, t Tuning) ([]string, error) {
-	rows, err := e.db.QueryContext(ctx,
-		`SELECT media_path, MAX(COALESCE(created_at, 0)) AS ts
+// recentLikes returns user's liked paths, most recent first, deduped.
+func (e *Engine) recentLikes(ctx context.Context, user string, t Tuning) ([]string, error) {
+	query, args := `SELECT media_path, MAX(COALESCE(created_at, 0)) AS ts
 		 FROM media_tag_by_category
 		 WHERE tag_label = ? AND category_label = ?
 		 GROUP BY media_path
 		 ORDER BY ts DESC
-		 LIMIT ?`,
-		t.FavoritesTag, t.FavoritesCategory, t.MaxLikes,
-	)
+		 LIMIT ?`, []any{t.FavoritesTag, t.FavoritesCategory, t.MaxLikes}
+	if user != "" {
+		query, args = `SELECT media_path, created_at AS ts
+		 FROM user_like
+		 WHERE username = ?
+		 ORDER BY ts DESC
+		 LIMIT ?`, []any{user, t.MaxLikes}
+	}
+	rows, err := e.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("feed: load likes: %w", err)
 	}
@@ -262,13 +277,13 @@ func (e *Engine) recentLikes(ctx context.Context, t Tuning) ([]string, error) {
 // MaxClusters, then it folds into the nearest regardless). Like weight
 // decays by half every RecencyHalfLife likes so the profile tracks current
 // taste. Neighbor pools are filled lazily by the lanes.
-func (e *Engine) buildProfile(ctx context.Context, t Tuning) (*profile, error) {
+func (e *Engine) buildProfile(ctx context.Context, user string, t Tuning) (*profile, error) {
 	now := e.now()
-	sig, err := e.likeSignature(ctx, t)
+	sig, err := e.likeSignature(ctx, user, t)
 	if err != nil {
 		return nil, err
 	}
-	likes, err := e.recentLikes(ctx, t)
+	likes, err := e.recentLikes(ctx, user, t)
 	if err != nil {
 		return nil, err
 	}
@@ -319,7 +334,15 @@ func (e *Engine) buildProfile(ctx context.Context, t Tuning) (*profile, error) {
 	// dominant tastes deterministically.
 	sort.SliceStable(p.clusters, func(i, j int) bool { return p.clusters[i].weight > p.clusters[j].weight })
 
-	e.profile = p
+	// Drop other users' expired profiles so accounts that stopped swiping
+	// don't pin their clusters and pools in memory.
+	ttl := time.Duration(t.ProfileTTLSeconds) * time.Second
+	for u, old := range e.profiles {
+		if now.Sub(old.builtAt) >= ttl {
+			delete(e.profiles, u)
+		}
+	}
+	e.profiles[user] = p
 	return p, nil
 }
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"path/filepath"
 	"sort"
 	"strings"
 	"testing"
@@ -25,7 +26,9 @@ type fakeLibrary struct {
 
 func newFakeLibrary(t *testing.T) *fakeLibrary {
 	t.Helper()
-	db, err := sql.Open("sqlite", ":memory:")
+	// A file, not :memory: — each pooled connection to :memory: would be
+	// its own empty database.
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "library.db"))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -128,7 +131,7 @@ func TestPageOrientationRestriction(t *testing.T) {
 	// what the lanes drew, not on what survived the filter).
 	seen := map[string]bool{}
 	for offset := 0; offset < 40; offset += 10 {
-		page, _, err := e.Page(context.Background(), "s-portrait", offset, 10, "portrait", nil)
+		page, _, err := e.Page(context.Background(), "", "s-portrait", offset, 10, "portrait", nil)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -158,7 +161,7 @@ func TestPageComposesAndNeverRepeats(t *testing.T) {
 	seen := map[string]bool{}
 	var all []string
 	for offset := 0; offset < 40; offset += 10 {
-		page, _, err := e.Page(context.Background(), "s1", offset, 10, "", nil)
+		page, _, err := e.Page(context.Background(), "", "s1", offset, 10, "", nil)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -179,11 +182,11 @@ func TestPageIsDeterministicPerSession(t *testing.T) {
 	l := newFakeLibrary(t)
 	seedTwoTastes(t, l)
 
-	page1, _, err := newTestEngine(l, Tuning{BatchSize: 12}).Page(context.Background(), "same-session", 0, 12, "", nil)
+	page1, _, err := newTestEngine(l, Tuning{BatchSize: 12}).Page(context.Background(), "", "same-session", 0, 12, "", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
-	page2, _, err := newTestEngine(l, Tuning{BatchSize: 12}).Page(context.Background(), "same-session", 0, 12, "", nil)
+	page2, _, err := newTestEngine(l, Tuning{BatchSize: 12}).Page(context.Background(), "", "same-session", 0, 12, "", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -202,7 +205,7 @@ func TestLikedItemsExcludedByDefault(t *testing.T) {
 	seedTwoTastes(t, l)
 	e := newTestEngine(l, Tuning{BatchSize: 30})
 
-	page, _, err := e.Page(context.Background(), "s", 0, 30, "", nil)
+	page, _, err := e.Page(context.Background(), "", "s", 0, 30, "", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -219,7 +222,7 @@ func TestFeedCoversBothTastesAndWildcards(t *testing.T) {
 	seedTwoTastes(t, l)
 	e := newTestEngine(l, Tuning{BatchSize: 40})
 
-	page, _, err := e.Page(context.Background(), "s", 0, 40, "", nil)
+	page, _, err := e.Page(context.Background(), "", "s", 0, 40, "", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -251,7 +254,7 @@ func TestOrphanEmbeddingsFilteredOut(t *testing.T) {
 		t.Fatal(err)
 	}
 	e := newTestEngine(l, Tuning{BatchSize: 40})
-	page, _, err := e.Page(context.Background(), "s", 0, 40, "", nil)
+	page, _, err := e.Page(context.Background(), "", "s", 0, 40, "", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -268,7 +271,7 @@ func TestColdStartFallsBackToWildcard(t *testing.T) {
 		l.addItem(t, fmt.Sprintf("/m/%d.jpg", i), nil) // no embeddings, no likes
 	}
 	e := newTestEngine(l, Tuning{BatchSize: 10})
-	page, hasMore, err := e.Page(context.Background(), "s", 0, 10, "", nil)
+	page, hasMore, err := e.Page(context.Background(), "", "s", 0, 10, "", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -286,14 +289,14 @@ func TestExhaustionEndsFeed(t *testing.T) {
 		l.addItem(t, fmt.Sprintf("/m/%d.jpg", i), nil)
 	}
 	e := newTestEngine(l, Tuning{BatchSize: 10})
-	page, _, err := e.Page(context.Background(), "s", 0, 10, "", nil)
+	page, _, err := e.Page(context.Background(), "", "s", 0, 10, "", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
 	if len(page) != 5 {
 		t.Fatalf("got %d items, want the whole 5-item library", len(page))
 	}
-	page2, hasMore, err := e.Page(context.Background(), "s", 5, 10, "", nil)
+	page2, hasMore, err := e.Page(context.Background(), "", "s", 5, 10, "", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -315,40 +318,78 @@ func TestNewLikeRebuildsProfileWithinCheckWindow(t *testing.T) {
 	clock := time.Unix(1000, 0)
 	e.now = func() time.Time { return clock }
 
-	if _, _, err := e.Page(context.Background(), "s", 0, 10, "", nil); err != nil {
+	if _, _, err := e.Page(context.Background(), "", "s", 0, 10, "", nil); err != nil {
 		t.Fatal(err)
 	}
-	firstSig := e.profile.signature
+	firstSig := e.profiles[""].signature
 
 	// A new like lands; within LikeCheckSeconds the cached profile is
 	// still served, after it the signature check forces a rebuild.
 	l.like(t, "/z/0.jpg", 200)
-	if _, _, err := e.Page(context.Background(), "s", 10, 10, "", nil); err != nil {
+	if _, _, err := e.Page(context.Background(), "", "s", 10, 10, "", nil); err != nil {
 		t.Fatal(err)
 	}
-	if e.profile.signature != firstSig {
+	if e.profiles[""].signature != firstSig {
 		t.Fatal("profile rebuilt inside the like-check window; expected cached")
 	}
 
 	clock = clock.Add(10 * time.Second) // past LikeCheckSeconds (default 5)
-	if _, _, err := e.Page(context.Background(), "s", 20, 10, "", nil); err != nil {
+	if _, _, err := e.Page(context.Background(), "", "s", 20, 10, "", nil); err != nil {
 		t.Fatal(err)
 	}
-	if e.profile.signature == firstSig {
+	if e.profiles[""].signature == firstSig {
 		t.Fatal("profile not rebuilt after favorites changed")
 	}
-	if !e.profile.liked["/z/0.jpg"] {
+	if !e.profiles[""].liked["/z/0.jpg"] {
 		t.Fatal("rebuilt profile missing the new like")
 	}
 }
 
+func TestProfilesArePerUser(t *testing.T) {
+	l := newFakeLibrary(t)
+	seedTwoTastes(t, l) // library-wide likes in /a and /b
+	if err := media.SetLiked(l.db, "alice", "/z/0.jpg", true); err != nil {
+		t.Fatal(err)
+	}
+	e := newTestEngine(l, Tuning{BatchSize: 10, WildcardWeight: 0.0001, ExploitWeight: 1})
+
+	if _, _, err := e.Page(context.Background(), "", "s", 0, 10, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	page, _, err := e.Page(context.Background(), "alice", "s", 0, 10, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	global, alice := e.profiles[""], e.profiles["alice"]
+	if global == nil || alice == nil || global == alice {
+		t.Fatalf("want separate profiles, got global=%v alice=%v", global != nil, alice != nil)
+	}
+	if !alice.liked["/z/0.jpg"] || alice.liked["/a/0.jpg"] {
+		t.Fatalf("alice's profile = %v, want only her own like", alice.liked)
+	}
+	if global.liked["/z/0.jpg"] {
+		t.Fatal("alice's like leaked into the library-wide profile")
+	}
+	// Her taste is the z region, so exploitation deals z items — and her
+	// session is her own even though the ID matches the global one.
+	z := 0
+	for _, p := range page {
+		if strings.HasPrefix(p, "/z/") {
+			z++
+		}
+	}
+	if z < len(page)/2 {
+		t.Fatalf("alice's feed %v is not dominated by her z-region taste", page)
+	}
+}
+
 func TestQueryOverrideChangesLaneMix(t *testing.T) {
 	l := newFakeLibrary(t)
 	seedTwoTastes(t, l)
 	e := newTestEngine(l, Tuning{BatchSize: 20})
 
 	// Force a pure-wildcard feed via the per-request override hook.
-	page, _, err := e.Page(context.Background(), "s", 0, 20, "", func(t *Tuning) {
+	page, _, err := e.Page(context.Background(), "", "s", 0, 20, "", func(t *Tuning) {
 		t.ExploitWeight, t.FreshWeight, t.BridgeWeight, t.WildcardWeight = 0.000001, 0.000001, 0.000001, 1
 	})
 	if err != nil {
@@ -371,7 +412,7 @@ func TestSearchBudgetBoundsPerPageCost(t *testing.T) {
 	tun := Tuning{BatchSize: 20, MaxSearchesPerPage: 2}
 	e := NewEngine(l.db, func() string { return testModel }, counting, func() Tuning { return tun })
 
-	page, _, err := e.Page(context.Background(), "s", 0, 20, "", nil)
+	page, _, err := e.Page(context.Background(), "", "s", 0, 20, "", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -383,7 +424,7 @@ func TestSearchBudgetBoundsPerPageCost(t *testing.T) {
 	}
 
 	// Later pages get their own budget, warming up more pools over time.
-	if _, _, err := e.Page(context.Background(), "s", 20, 20, "", nil); err != nil {
+	if _, _, err := e.Page(context.Background(), "", "s", 20, 20, "", nil); err != nil {
 		t.Fatal(err)
 	}
 	if searches > 4 {
@@ -398,7 +439,7 @@ func TestPageStopsOnCanceledContext(t *testing.T) {
 
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
-	if _, _, err := e.Page(ctx, "s", 0, 10, "", nil); err == nil {
+	if _, _, err := e.Page(ctx, "", "s", 0, 10, "", nil); err == nil {
 		t.Fatal("expected an error from a canceled context")
 	}
 }
@@ -448,7 +489,7 @@ func TestResetSwapsDatabaseAndDropsCaches(t *testing.T) {
 		func() Tuning { return Tuning{BatchSize: 10} },
 	)
 
-	page, _, err := e.Page(context.Background(), "s", 0, 10, "", nil)
+	page, _, err := e.Page(context.Background(), "", "s", 0, 10, "", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -460,7 +501,7 @@ func TestResetSwapsDatabaseAndDropsCaches(t *testing.T) {
 	e.Reset(l2.db)
 
 	e.mu.Lock()
-	db, profile, sessions := e.db, e.profile, len(e.sessions)
+	db, profile, sessions := e.db, e.profiles[""], len(e.sessions)
 	e.mu.Unlock()
 	if db != l2.db {
 		t.Fatal("Reset did not swap the engine's database handle")
@@ -471,7 +512,7 @@ func TestResetSwapsDatabaseAndDropsCaches(t *testing.T) {
 
 	// Same session ID, fresh sequence — and every path must come from the
 	// NEW library, even though this session was mid-feed on the old one.
-	page, _, err = e.Page(context.Background(), "s", 0, 10, "", nil)
+	page, _, err = e.Page(context.Background(), "", "s", 0, 10, "", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
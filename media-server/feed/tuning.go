@@ -7,6 +7,8 @@
 // overridden per-request via query params for quick experiments.
 package feed
 
+import "github.com/stevecastle/shrike/media"
+
 // Tuning holds every adjustable parameter of the For-You feed algorithm.
 // The zero value means "use the built-in default" for each field, so a
 // config file may set any subset (or omit the section entirely).
@@ -107,7 +109,8 @@ type Tuning struct {
 	// ---- Source of truth for likes --------------------------------------
 
 	// FavoritesTag / FavoritesCategory identify which tag counts as a
-	// "like". Defaults match the swipe UI's heart button.
+	// "like" in the library-wide profile. Defaults match the swipe UI's
+	// heart button. Other accounts' likes live in user_like.
 	FavoritesTag      string `json:"favoritesTag"`      // default "Favorites"
 	FavoritesCategory string `json:"favoritesCategory"` // default "Swipe"
 }
@@ -145,10 +148,10 @@ func (t Tuning) WithDefaults() Tuning {
 	defi(&t.SessionTTLMinutes, 120)
 	defi(&t.MaxSessions, 64)
 	if t.FavoritesTag == "" {
-		t.FavoritesTag = "Favorites"
+		t.FavoritesTag = media.FavoritesTag
 	}
 	if t.FavoritesCategory == "" {
-		t.FavoritesCategory = "Swipe"
+		t.FavoritesCategory = media.FavoritesCategory
 	}
 	return t
 }
@@ -49,11 +49,16 @@ func fsListHandler(deps *Dependencies) http.HandlerFunc {
 			return
 		}
 
-		// Empty path: return all configured roots
+		// Empty path: return the configured roots this requester may browse
 		if req.Path == "" {
-			allRoots := deps.Storage.AllRoots()
-			entries := make([]fsEntry, 0, len(allRoots))
-			for _, root := range allRoots {
+			access, authed := requestAccess(deps, r)
+			backends := deps.Storage.AllBackends()
+			entries := make([]fsEntry, 0, len(backends))
+			for _, b := range backends {
+				if authed && !rootPermitted(access, b) {
+					continue
+				}
+				root := b.Root()
 				entries = append(entries, fsEntry{
 					Name:  root.Name,
 					Path:  root.Path,
@@ -71,7 +76,7 @@ func fsListHandler(deps *Dependencies) http.HandlerFunc {
 
 		// Find the backend that owns this path
 		backend := deps.Storage.BackendFor(req.Path)
-		if backend == nil {
+		if backend == nil || !pathAllowedForRequest(deps, r, req.Path) {
 			httpError(w, "path is not within any configured storage root", http.StatusForbidden)
 			return
 		}
@@ -140,7 +145,7 @@ func fsScanHandler(deps *Dependencies) http.HandlerFunc {
 		}
 
 		backend := deps.Storage.BackendFor(req.Path)
-		if backend == nil {
+		if backend == nil || !pathAllowedForRequest(deps, r, req.Path) {
 			httpError(w, "path is not within any configured storage root", http.StatusForbidden)
 			return
 		}
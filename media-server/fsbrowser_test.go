@@ -196,7 +196,22 @@ func TestFsScanHandler_InsertsIntoDB(t *testing.T) {
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			username TEXT UNIQUE NOT NULL,
 			password_hash TEXT NOT NULL,
-			created_at INTEGER
+			created_at INTEGER,
+			role TEXT NOT NULL DEFAULT 'admin',
+			roots TEXT NOT NULL DEFAULT '',
+			totp_secret TEXT NOT NULL DEFAULT '',
+			totp_enabled INTEGER NOT NULL DEFAULT 0,
+			totp_last_step INTEGER NOT NULL DEFAULT 0,
+			sso_subject TEXT NOT NULL DEFAULT '',
+			sso_created INTEGER NOT NULL DEFAULT 0,
+			library_profile INTEGER NOT NULL DEFAULT 0
+		)`,
+		`CREATE TABLE user_recovery_codes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			code_hash TEXT NOT NULL UNIQUE,
+			created_at INTEGER NOT NULL,
+			used_at INTEGER NOT NULL DEFAULT 0
 		)`,
 		`CREATE TABLE api_keys (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -205,7 +220,10 @@ func TestFsScanHandler_InsertsIntoDB(t *testing.T) {
 			key_hash TEXT UNIQUE NOT NULL,
 			prefix TEXT NOT NULL,
 			created_at INTEGER,
-			last_used_at INTEGER
+			last_used_at INTEGER,
+			scopes TEXT NOT NULL DEFAULT '',
+			expires_at INTEGER NOT NULL DEFAULT 0,
+			allowed_cidrs TEXT NOT NULL DEFAULT ''
 		)`,
 	} {
 		if _, err := db.Exec(stmt); err != nil {
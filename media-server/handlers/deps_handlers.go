@@ -6,9 +6,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/stevecastle/shrike/deps/models"
@@ -105,6 +107,63 @@ func HandleModelVerify(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, result)
 }
 
+// HandleModelImport serves POST /api/deps/models/import: install models from
+// a directory, .tar, or .7z bundle on the server's filesystem, for machines
+// without internet access. Body {"path": "...", "ids": [...]}; no ids
+// imports every model the bundle holds completely.
+func HandleModelImport(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Path string   `json:"path"`
+		IDs  []string `json:"ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, fmt.Errorf("invalid JSON: %w", err))
+		return
+	}
+	if strings.TrimSpace(req.Path) == "" {
+		writeErr(w, http.StatusBadRequest, errors.New("path is required"))
+		return
+	}
+	res, err := models.ImportModels(r.Context(), strings.TrimSpace(req.Path), req.IDs, nil)
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// HandleModelExport serves GET /api/deps/models/export?ids=a,b: installed
+// models as a tar bundle for POST /api/deps/models/import on another
+// machine. No ids exports every installed model that can be exported.
+func HandleModelExport(w http.ResponseWriter, r *http.Request) {
+	var ids []string
+	for _, v := range r.URL.Query()["ids"] {
+		for _, id := range strings.Split(v, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				ids = append(ids, id)
+			}
+		}
+	}
+	ms, err := models.ExportableModels(ids)
+	switch {
+	case models.IsNotInstalled(err), errors.Is(err, models.ErrNotExportable):
+		writeErr(w, http.StatusConflict, err)
+		return
+	case err != nil:
+		writeErr(w, http.StatusNotFound, err)
+		return
+	case len(ms) == 0:
+		writeErr(w, http.StatusNotFound, errors.New("no installed models to export"))
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-tar")
+	w.Header().Set("Content-Disposition", `attachment; filename="lowkey-models.tar"`)
+	if err := models.ExportModels(w, ms); err != nil {
+		// Headers are gone; a truncated tar fails loudly on import.
+		log.Printf("deps: model export: %v", err)
+	}
+}
+
 // HandleModelProgressSSE serves GET /api/deps/models/progress.
 func HandleModelProgressSSE(w http.ResponseWriter, r *http.Request) {
 	flusher, ok := w.(http.Flusher)
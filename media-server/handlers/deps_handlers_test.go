@@ -6,6 +6,8 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"github.com/stevecastle/shrike/deps/models"
 )
 
 func TestGetStatus_ReturnsJSONArray(t *testing.T) {
@@ -33,3 +35,23 @@ func TestPostDownload_UnknownIDReturns404(t *testing.T) {
 		t.Errorf("body=%q want unknown", rr.Body)
 	}
 }
+
+func TestPostImport_RequiresPath(t *testing.T) {
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/deps/models/import", strings.NewReader(`{"ids":["yunet"]}`))
+	HandleModelImport(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("status=%d want 400", rr.Code)
+	}
+}
+
+func TestGetExport_NotInstalledConflicts(t *testing.T) {
+	models.SetDataDirForTest(t.TempDir())
+	t.Cleanup(func() { models.SetDataDirForTest("") })
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/deps/models/export?ids=yunet", nil)
+	HandleModelExport(rr, req)
+	if rr.Code != http.StatusConflict {
+		t.Errorf("status=%d want 409 body=%s", rr.Code, rr.Body)
+	}
+}
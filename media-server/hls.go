@@ -133,6 +133,10 @@ func hlsStatus(w http.ResponseWriter, r *http.Request) {
 	// Already cached — ready to play.
 	if _, err := os.Stat(masterPath); err == nil {
 		log.Printf("[hls] ready (cached): %s", filepath.Base(mediaPath))
+		// Caches from before subtitle tracks have no source record.
+		if _, ok := readHlsSource(cacheDir); !ok {
+			writeHlsSource(cacheDir, mediaPath)
+		}
 		json.NewEncoder(w).Encode(hlsStatusResponse{
 			Status: "ready",
 			URL:    fmt.Sprintf("/media/hls/%s/master.m3u8", hash),
@@ -252,6 +256,15 @@ func hlsSegmentHandler(d *Dependencies) http.HandlerFunc {
 		path := strings.TrimPrefix(r.URL.Path, "/media/hls/")
 		parts := strings.Split(path, "/")
 
+		if len(parts) == 3 && parts[1] == "subs" {
+			if !hexRe.MatchString(parts[0]) {
+				http.Error(w, "invalid path", http.StatusBadRequest)
+				return
+			}
+			serveHlsSubtitle(d, w, filepath.Join(hlsBasePath(), "hls", parts[0]), parts[2])
+			return
+		}
+
 		var filePath string
 		var contentType string
 
@@ -264,6 +277,9 @@ func hlsSegmentHandler(d *Dependencies) http.HandlerFunc {
 			}
 			filePath = filepath.Join(hlsBasePath(), "hls", hash, filename)
 			contentType = "application/vnd.apple.mpegurl"
+			if filename == "master.m3u8" && serveHlsMaster(d, w, filepath.Dir(filePath)) {
+				return
+			}
 
 		case 3:
 			hash, preset, filename := parts[0], parts[1], parts[2]
@@ -427,7 +443,11 @@ func generatePassthroughHLS(ctx context.Context, mediaPath, cacheDir string, dur
 		return fmt.Errorf("ffmpeg failed: %w\n%s", err, strings.Join(tail, "\n"))
 	}
 
-	// Write master playlist now that all segments are ready.
+	// Record the source for the subtitle routes, then write the master
+	// playlist now that all segments are ready.
+	if err := writeHlsSource(cacheDir, mediaPath); err != nil {
+		return fmt.Errorf("failed to record HLS source: %w", err)
+	}
 	masterContent := "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-STREAM-INF:BANDWIDTH=0,NAME=\"passthrough\"\npassthrough/stream.m3u8\n"
 	masterPath := filepath.Join(cacheDir, "master.m3u8")
 	if err := os.WriteFile(masterPath, []byte(masterContent), 0644); err != nil {
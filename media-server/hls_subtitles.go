@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/stevecastle/shrike/appconfig"
+	"github.com/stevecastle/shrike/media"
+	"github.com/stevecastle/shrike/subtitle"
+)
+
+// HLS subtitle renditions. The master playlist is served with one
+// EXT-X-MEDIA subtitle track per stored transcript — the original plus each
+// translation in media_transcript — so players offer them in their caption
+// menu. Tracks are read from the database on every request, so a
+// translation added after the stream was cached shows up without a
+// re-transcode. Each track is a single-segment WebVTT playlist:
+//
+//	/media/hls/<hash>/subs/<key>.m3u8 — the track's playlist
+//	/media/hls/<hash>/subs/<key>.vtt  — the transcript as WebVTT
+//
+// <key> is "original" or a normalized language tag.
+
+// hlsSourceFile, inside a cache directory, records the media path the
+// directory was generated from; the hash in the URL can't be reversed.
+const hlsSourceFile = "source"
+
+// hlsOriginalTrack is the key of the untranslated transcript's track.
+const hlsOriginalTrack = "original"
+
+var hlsSubtitleFileRe = regexp.MustCompile(`^([a-z0-9-]+)\.(m3u8|vtt)$`)
+
+type hlsSubtitleTrack struct {
+	Key  string // URL key: hlsOriginalTrack or a language tag
+	Lang string // LANGUAGE attribute; "" when unknown
+	Name string
+}
+
+// writeHlsSource records mediaPath in cacheDir for the subtitle routes.
+func writeHlsSource(cacheDir, mediaPath string) error {
+	return os.WriteFile(filepath.Join(cacheDir, hlsSourceFile), []byte(mediaPath), 0644)
+}
+
+// readHlsSource returns the media path cacheDir was generated from.
+func readHlsSource(cacheDir string) (string, bool) {
+	b, err := os.ReadFile(filepath.Join(cacheDir, hlsSourceFile))
+	if err != nil || len(b) == 0 {
+		return "", false
+	}
+	return string(b), true
+}
+
+// hlsSubtitleTracks lists the transcripts mediaPath can be captioned with.
+func hlsSubtitleTracks(db *sql.DB, mediaPath string) ([]hlsSubtitleTrack, error) {
+	var tracks []hlsSubtitleTrack
+	if _, ok, err := hlsTrackVTT(db, mediaPath, hlsOriginalTrack); err != nil {
+		return nil, err
+	} else if ok {
+		lang, known := media.NormalizeLang(appconfig.Get().TranscriptionLanguage)
+		if !known {
+			lang = ""
+		}
+		tracks = append(tracks, hlsSubtitleTrack{Key: hlsOriginalTrack, Lang: lang, Name: "Original"})
+	}
+	langs, err := media.TranscriptLanguages(db, mediaPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range langs {
+		tracks = append(tracks, hlsSubtitleTrack{Key: l, Lang: l, Name: media.LangName(l)})
+	}
+	return tracks, nil
+}
+
+// hlsTrackVTT returns track key's transcript as WebVTT; ok is false when
+// there is no such transcript or it has no timed cues.
+func hlsTrackVTT(db *sql.DB, mediaPath, key string) (string, bool, error) {
+	var text string
+	if key == hlsOriginalTrack {
+		var s sql.NullString
+		err := db.QueryRow("SELECT transcript FROM media WHERE path = ?", mediaPath).Scan(&s)
+		if err == sql.ErrNoRows {
+			return "", false, nil
+		}
+		if err != nil {
+			return "", false, err
+		}
+		text = s.String
+	} else {
+		t, ok, err := media.GetTranscriptTranslation(db, mediaPath, key)
+		if err != nil || !ok {
+			return "", false, err
+		}
+		text = t.Transcript
+	}
+	cues := subtitle.Parse(text)
+	if len(cues) == 0 {
+		return "", false, nil
+	}
+	return subtitle.FormatVTT(cues), true, nil
+}
+
+// hlsMasterWithSubtitles adds tracks to a master playlist as the "subs"
+// subtitle group and points every variant at it.
+func hlsMasterWithSubtitles(master string, tracks []hlsSubtitleTrack) string {
+	if len(tracks) == 0 {
+		return master
+	}
+	var renditions strings.Builder
+	for _, t := range tracks {
+		fmt.Fprintf(&renditions, `#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID="subs",NAME=%q,DEFAULT=NO,AUTOSELECT=YES,`, t.Name)
+		if t.Lang != "" {
+			fmt.Fprintf(&renditions, `LANGUAGE=%q,`, t.Lang)
+		}
+		fmt.Fprintf(&renditions, `URI="subs/%s.m3u8"`+"\n", t.Key)
+	}
+	var out strings.Builder
+	inserted := false
+	for _, line := range strings.SplitAfter(master, "\n") {
+		trimmed := strings.TrimRight(line, "\r\n")
+		if strings.HasPrefix(trimmed, "#EXT-X-STREAM-INF:") {
+			if !inserted {
+				out.WriteString(renditions.String())
+				inserted = true
+			}
+			line = trimmed + `,SUBTITLES="subs"` + "\n"
+		}
+		out.WriteString(line)
+	}
+	return out.String()
+}
+
+// hlsSubtitlePlaylist is a VOD playlist holding key's whole WebVTT file as
+// one segment spanning the stream's duration.
+func hlsSubtitlePlaylist(key string, duration float64) string {
+	return fmt.Sprintf("#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:%d\n#EXT-X-MEDIA-SEQUENCE:0\n#EXT-X-PLAYLIST-TYPE:VOD\n#EXTINF:%.3f,\n%s.vtt\n#EXT-X-ENDLIST\n",
+		int(math.Ceil(duration)), duration, key)
+}
+
+// hlsPlaylistDuration sums a media playlist's EXTINF durations.
+func hlsPlaylistDuration(path string) float64 {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+	var total float64
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		rest, ok := strings.CutPrefix(sc.Text(), "#EXTINF:")
+		if !ok {
+			continue
+		}
+		if i := strings.IndexByte(rest, ','); i >= 0 {
+			rest = rest[:i]
+		}
+		if d, err := strconv.ParseFloat(strings.TrimSpace(rest), 64); err == nil {
+			total += d
+		}
+	}
+	return total
+}
+
+// serveHlsMaster writes cacheDir's master playlist with its subtitle
+// renditions. It reports false, having written nothing, when the playlist
+// should be served from disk as is (no tracks, or no recorded source).
+func serveHlsMaster(d *Dependencies, w http.ResponseWriter, cacheDir string) bool {
+	if d == nil || d.DB == nil {
+		return false
+	}
+	mediaPath, ok := readHlsSource(cacheDir)
+	if !ok {
+		return false
+	}
+	master, err := os.ReadFile(filepath.Join(cacheDir, "master.m3u8"))
+	if err != nil {
+		return false
+	}
+	tracks, err := hlsSubtitleTracks(d.DB, mediaPath)
+	if err != nil || len(tracks) == 0 {
+		return false
+	}
+	w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+	// Tracks change as transcripts are translated; don't let a player keep
+	// a stale list.
+	w.Header().Set("Cache-Control", "no-cache")
+	io.WriteString(w, hlsMasterWithSubtitles(string(master), tracks))
+	return true
+}
+
+// serveHlsSubtitle serves /media/hls/<hash>/subs/<file>.
+func serveHlsSubtitle(d *Dependencies, w http.ResponseWriter, cacheDir, filename string) {
+	m := hlsSubtitleFileRe.FindStringSubmatch(filename)
+	if m == nil || !validHlsSubtitleKey(m[1]) {
+		http.Error(w, "invalid path", http.StatusBadRequest)
+		return
+	}
+	key, ext := m[1], m[2]
+	mediaPath, ok := readHlsSource(cacheDir)
+	if !ok || d == nil || d.DB == nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	vtt, ok, err := hlsTrackVTT(d.DB, mediaPath, key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+	if ext == "vtt" {
+		w.Header().Set("Content-Type", "text/vtt; charset=utf-8")
+		io.WriteString(w, vtt)
+		return
+	}
+	duration := hlsPlaylistDuration(filepath.Join(cacheDir, "passthrough", "stream.m3u8"))
+	w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+	io.WriteString(w, hlsSubtitlePlaylist(key, duration))
+}
+
+// validHlsSubtitleKey reports whether key names a servable track.
+func validHlsSubtitleKey(key string) bool {
+	if key == hlsOriginalTrack {
+		return true
+	}
+	lang, ok := media.NormalizeLang(key)
+	return ok && lang == key
+}
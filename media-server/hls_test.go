@@ -1,6 +1,11 @@
 package main
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -48,3 +53,80 @@ func TestValidateHlsPreset(t *testing.T) {
 		}
 	}
 }
+
+func TestHlsMasterWithSubtitles(t *testing.T) {
+	master := "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-STREAM-INF:BANDWIDTH=0,NAME=\"passthrough\"\npassthrough/stream.m3u8\n"
+	got := hlsMasterWithSubtitles(master, []hlsSubtitleTrack{
+		{Key: "original", Name: "Original"},
+		{Key: "es", Lang: "es", Name: "Spanish"},
+	})
+	want := "#EXTM3U\n#EXT-X-VERSION:3\n" +
+		`#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID="subs",NAME="Original",DEFAULT=NO,AUTOSELECT=YES,URI="subs/original.m3u8"` + "\n" +
+		`#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID="subs",NAME="Spanish",DEFAULT=NO,AUTOSELECT=YES,LANGUAGE="es",URI="subs/es.m3u8"` + "\n" +
+		`#EXT-X-STREAM-INF:BANDWIDTH=0,NAME="passthrough",SUBTITLES="subs"` + "\npassthrough/stream.m3u8\n"
+	if got != want {
+		t.Errorf("master =\n%s\nwant\n%s", got, want)
+	}
+	if hlsMasterWithSubtitles(master, nil) != master {
+		t.Error("master without tracks was rewritten")
+	}
+}
+
+func TestHlsSubtitleRoutes(t *testing.T) {
+	deps := newLibraryTestDeps(t)
+	cacheDir := t.TempDir()
+	os.MkdirAll(filepath.Join(cacheDir, "passthrough"), 0755)
+	os.WriteFile(filepath.Join(cacheDir, "master.m3u8"), []byte("#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=0\npassthrough/stream.m3u8\n"), 0644)
+	os.WriteFile(filepath.Join(cacheDir, "passthrough", "stream.m3u8"), []byte("#EXTM3U\n#EXTINF:6.000000,\nsegment_000.ts\n#EXTINF:4.500000,\nsegment_001.ts\n#EXT-X-ENDLIST\n"), 0644)
+
+	// No source record: the master is left to be served from disk.
+	if serveHlsMaster(deps, httptest.NewRecorder(), cacheDir) {
+		t.Fatal("rewrote a master without a source record")
+	}
+	if err := writeHlsSource(cacheDir, "a.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	if serveHlsMaster(deps, httptest.NewRecorder(), cacheDir) {
+		t.Fatal("rewrote a master with no transcripts")
+	}
+
+	deps.DB.Exec(`UPDATE media SET transcript = 'WEBVTT
+
+00:00:01.000 --> 00:00:02.000
+hello' WHERE path = 'a.jpg'`)
+	deps.DB.Exec(`INSERT INTO media_transcript (media_path, lang, transcript) VALUES ('a.jpg', 'es', 'WEBVTT
+
+00:00:01.000 --> 00:00:02.000
+hola')`)
+	rr := httptest.NewRecorder()
+	if !serveHlsMaster(deps, rr, cacheDir) {
+		t.Fatal("master not rewritten")
+	}
+	if body := rr.Body.String(); !strings.Contains(body, `URI="subs/original.m3u8"`) || !strings.Contains(body, `LANGUAGE="es",URI="subs/es.m3u8"`) {
+		t.Errorf("master =\n%s", body)
+	}
+
+	rr = httptest.NewRecorder()
+	serveHlsSubtitle(deps, rr, cacheDir, "es.m3u8")
+	if body := rr.Body.String(); !strings.Contains(body, "#EXTINF:10.500,\nes.vtt\n") || !strings.Contains(body, "#EXT-X-TARGETDURATION:11\n") {
+		t.Errorf("es playlist =\n%s", body)
+	}
+	rr = httptest.NewRecorder()
+	serveHlsSubtitle(deps, rr, cacheDir, "es.vtt")
+	if body := rr.Body.String(); rr.Header().Get("Content-Type") != "text/vtt; charset=utf-8" || !strings.Contains(body, "hola") {
+		t.Errorf("es vtt = %q (%s)", body, rr.Header().Get("Content-Type"))
+	}
+
+	for name, want := range map[string]int{
+		"fr.vtt":       http.StatusNotFound,
+		"../x.vtt":     http.StatusBadRequest,
+		"ES.vtt":       http.StatusBadRequest,
+		"original.txt": http.StatusBadRequest,
+	} {
+		rr = httptest.NewRecorder()
+		serveHlsSubtitle(deps, rr, cacheDir, name)
+		if rr.Code != want {
+			t.Errorf("%s: status = %d, want %d", name, rr.Code, want)
+		}
+	}
+}
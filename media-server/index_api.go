@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/stevecastle/shrike/appconfig"
 	"github.com/stevecastle/shrike/embedvec"
 	"github.com/stevecastle/shrike/tasks"
 )
@@ -12,7 +13,7 @@ import (
 // Embeddings-index management API (shared across all platform mains).
 //
 //   GET    /api/index/status      — installed index + per-model DB stats
-//   GET    /api/index/models      — embed model registry
+//   GET    /api/index/models      — embed + tagger model registries (incl. BYO)
 //   POST   /api/index/rebuild     — rebuild vector index for the active model
 //   GET    /api/index/missing     — media paths lacking an embedding
 //   GET    /api/embeddings        — stored embedding rows for one path
@@ -128,9 +129,26 @@ func indexModelsHandler(deps *Dependencies) http.HandlerFunc {
 				"multimodal":   m.Multimodal,
 				"active":       m.ID == activeID,
 				"indexed":      m.ID == indexedID,
+				"byo":          m.BYO,
 			})
 		}
-		writeJSON(w, map[string]any{"models": models})
+		activeTagger := tasks.ActiveTaggerModel().ID
+		taggers := []map[string]any{}
+		for _, m := range tasks.TaggerModelList() {
+			taggers = append(taggers, map[string]any{
+				"id":           m.ID,
+				"display_name": m.DisplayName,
+				"active":       m.ID == activeTagger,
+				"byo":          m.BYO,
+			})
+		}
+		// Invalid BYO entries are skipped by the registries; report why so
+		// the config UI / lokictl can show it instead of a silent omission.
+		problems := appconfig.ValidateByoModels(appconfig.Get())
+		if problems == nil {
+			problems = []string{}
+		}
+		writeJSON(w, map[string]any{"models": models, "taggers": taggers, "byo_problems": problems})
 	}
 }
 
@@ -0,0 +1,196 @@
+package main
+
+// job_logs_api.go — a job's stored log (jobqueue/joblog.go). Shared by all
+// three platform mains.
+//
+//	GET /job/{id}/logs?after=<seq>&limit=<n>   lines after seq, oldest first
+//	GET /job/{id}/logs?before=<seq>&limit=<n>  lines just before seq
+//	GET /job/{id}/logs?limit=<n>               the newest lines
+//	GET /job/{id}/logs/follow?after=<seq>      SSE: each new line as a "log"
+//	                                           event, then "end" once the job
+//	                                           has finished and been drained
+//
+// Unlike the "stdout-<id>" events on /stream, these work for finished jobs
+// and a reader that falls behind or reconnects picks up where it left off.
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/stevecastle/shrike/jobqueue"
+)
+
+// jobLogFollowPoll is how often the follow stream checks for new lines, and
+// jobLogKeepAlive how long it may stay silent before sending a comment so
+// proxies don't drop the connection. Vars for tests.
+var (
+	jobLogFollowPoll = 500 * time.Millisecond
+	jobLogKeepAlive  = 15 * time.Second
+)
+
+type jobLogsResponse struct {
+	ID    string             `json:"id"`
+	State jobqueue.JobState  `json:"state"`
+	Lines []jobqueue.LogLine `json:"lines"`
+	First int64              `json:"first"`
+	Last  int64              `json:"last"`
+}
+
+func jobFinished(s jobqueue.JobState) bool {
+	return s == jobqueue.StateCompleted || s == jobqueue.StateCancelled || s == jobqueue.StateError
+}
+
+// parseLogSeq reads a non-negative integer query parameter; absent is 0.
+func parseLogSeq(r *http.Request, name string) (int64, bool, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return 0, false, nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return 0, false, fmt.Errorf("invalid %s %q", name, v)
+	}
+	return n, true, nil
+}
+
+func jobLogsHandler(deps *Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Use GET", http.StatusMethodNotAllowed)
+			return
+		}
+		id := r.PathValue("id")
+		state, ok := deps.Queue.JobState(id)
+		if !ok {
+			http.Error(w, "job not found", http.StatusNotFound)
+			return
+		}
+		var lq jobqueue.LogQuery
+		after, hasAfter, err := parseLogSeq(r, "after")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		before, hasBefore, err := parseLogSeq(r, "before")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		limit, _, err := parseLogSeq(r, "limit")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		switch {
+		case hasAfter && hasBefore:
+			http.Error(w, "use after or before, not both", http.StatusBadRequest)
+			return
+		case hasAfter:
+			lq.After = after
+		case hasBefore:
+			lq.Before = before
+		default:
+			lq.Tail = true
+		}
+		lq.Limit = int(min(limit, int64(jobqueue.LogPageMax)))
+
+		page, err := deps.Queue.JobLogs(id, lq)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, jobLogsResponse{
+			ID:    id,
+			State: state,
+			Lines: page.Lines,
+			First: page.First,
+			Last:  page.Last,
+		})
+	}
+}
+
+// jobLogsFollowHandler streams a job's log from ?after= (or the
+// Last-Event-ID an EventSource reconnects with) until the job finishes.
+func jobLogsFollowHandler(deps *Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Use GET", http.StatusMethodNotAllowed)
+			return
+		}
+		id := r.PathValue("id")
+		if _, ok := deps.Queue.JobState(id); !ok {
+			http.Error(w, "job not found", http.StatusNotFound)
+			return
+		}
+		after, _, err := parseLogSeq(r, "after")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if n, err := strconv.ParseInt(r.Header.Get("Last-Event-ID"), 10, 64); err == nil && n > after {
+			after = n
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		ticker := time.NewTicker(jobLogFollowPoll)
+		defer ticker.Stop()
+		lastWrite := time.Now()
+		for r.Context().Err() == nil {
+			// Read the state before the lines: a job pushes its last line
+			// before it finishes, so a finished state seen here means the
+			// page below holds everything.
+			state, ok := deps.Queue.JobState(id)
+			if !ok {
+				fmt.Fprint(w, "event: end\ndata: \"removed\"\n\n")
+				flusher.Flush()
+				return
+			}
+			page, err := deps.Queue.JobLogs(id, jobqueue.LogQuery{After: after})
+			if err != nil {
+				return
+			}
+			for _, l := range page.Lines {
+				data, _ := json.Marshal(l)
+				fmt.Fprintf(w, "id: %d\nevent: log\ndata: %s\n\n", l.Seq, data)
+				after = l.Seq
+			}
+			if len(page.Lines) > 0 {
+				flusher.Flush()
+				lastWrite = time.Now()
+			}
+			drained := len(page.Lines) < jobqueue.LogPageMax
+			if drained && jobFinished(state) {
+				data, _ := json.Marshal(state)
+				fmt.Fprintf(w, "event: end\ndata: %s\n\n", data)
+				flusher.Flush()
+				return
+			}
+			if !drained {
+				continue
+			}
+			if time.Since(lastWrite) >= jobLogKeepAlive {
+				fmt.Fprint(w, ": keep-alive\n\n")
+				flusher.Flush()
+				lastWrite = time.Now()
+			}
+			select {
+			case <-r.Context().Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}
+}
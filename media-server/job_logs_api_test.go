@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stevecastle/shrike/jobqueue"
+	_ "modernc.org/sqlite"
+)
+
+func newJobLogsEnv(t *testing.T) (*jobqueue.Queue, *http.ServeMux) {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	q := jobqueue.NewQueueWithDB(db)
+	deps := &Dependencies{Queue: q}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/job/{id}/logs", jobLogsHandler(deps))
+	mux.HandleFunc("/job/{id}/logs/follow", jobLogsFollowHandler(deps))
+	return q, mux
+}
+
+func getJobLogs(t *testing.T, mux *http.ServeMux, path string) (int, jobLogsResponse) {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+	var resp jobLogsResponse
+	if rr.Code == http.StatusOK {
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("bad json: %v: %s", err, rr.Body.String())
+		}
+	}
+	return rr.Code, resp
+}
+
+func TestJobLogsEndpoint(t *testing.T) {
+	q, mux := newJobLogsEnv(t)
+	id, _ := q.AddJob("", "cmd", nil, "", nil)
+	for i := 1; i <= 6; i++ {
+		q.PushJobStdout(id, fmt.Sprintf("line %d", i))
+	}
+
+	lineSeqs := func(r jobLogsResponse) string {
+		var s []string
+		for _, l := range r.Lines {
+			s = append(s, fmt.Sprint(l.Seq))
+		}
+		return strings.Join(s, ",")
+	}
+	for path, want := range map[string]string{
+		"/job/" + id + "/logs?after=4":          "5,6",
+		"/job/" + id + "/logs?after=0&limit=2":  "1,2",
+		"/job/" + id + "/logs?limit=2":          "5,6",
+		"/job/" + id + "/logs?before=3&limit=5": "1,2",
+	} {
+		code, resp := getJobLogs(t, mux, path)
+		if code != http.StatusOK || lineSeqs(resp) != want {
+			t.Errorf("%s = %d %q; want %q", path, code, lineSeqs(resp), want)
+		}
+		if resp.First != 1 || resp.Last != 6 || resp.State != jobqueue.StatePending {
+			t.Errorf("%s: first=%d last=%d state=%v", path, resp.First, resp.Last, resp.State)
+		}
+	}
+
+	for path, want := range map[string]int{
+		"/job/nope/logs":                        http.StatusNotFound,
+		"/job/" + id + "/logs?after=x":          http.StatusBadRequest,
+		"/job/" + id + "/logs?after=1&before=3": http.StatusBadRequest,
+	} {
+		if code, _ := getJobLogs(t, mux, path); code != want {
+			t.Errorf("%s = %d; want %d", path, code, want)
+		}
+	}
+}
+
+// readFollow collects a follow stream's log lines until its end event.
+func readFollow(t *testing.T, body *bufio.Reader) ([]string, string) {
+	t.Helper()
+	var lines []string
+	var event string
+	for {
+		raw, err := body.ReadString('\n')
+		if err != nil {
+			t.Fatalf("stream ended without an end event (got %v): %v", lines, err)
+		}
+		raw = strings.TrimRight(raw, "\n")
+		switch {
+		case strings.HasPrefix(raw, "event: "):
+			event = strings.TrimPrefix(raw, "event: ")
+		case strings.HasPrefix(raw, "data: "):
+			data := strings.TrimPrefix(raw, "data: ")
+			if event == "end" {
+				return lines, data
+			}
+			var l jobqueue.LogLine
+			if err := json.Unmarshal([]byte(data), &l); err != nil {
+				t.Fatalf("bad log event %q: %v", data, err)
+			}
+			lines = append(lines, l.Line)
+		}
+	}
+}
+
+func TestJobLogsFollowFinishedJob(t *testing.T) {
+	q, mux := newJobLogsEnv(t)
+	id, _ := q.AddJob("", "cmd", nil, "", nil)
+	q.ClaimJob()
+	for _, l := range []string{"a", "b", "c"} {
+		q.PushJobStdout(id, l)
+	}
+	q.CompleteJob(id)
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/job/"+id+"/logs/follow?after=1", nil))
+	if ct := rr.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	lines, end := readFollow(t, bufio.NewReader(rr.Body))
+	if strings.Join(lines, ",") != "b,c" || end != `"completed"` {
+		t.Errorf("lines=%v end=%s", lines, end)
+	}
+}
+
+func TestJobLogsFollowLiveJob(t *testing.T) {
+	old := jobLogFollowPoll
+	jobLogFollowPoll = 10 * time.Millisecond
+	t.Cleanup(func() { jobLogFollowPoll = old })
+
+	q, mux := newJobLogsEnv(t)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	id, _ := q.AddJob("", "cmd", nil, "", nil)
+	q.ClaimJob()
+	q.PushJobStdout(id, "first")
+
+	resp, err := http.Get(srv.URL + "/job/" + id + "/logs/follow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		q.PushJobStdout(id, "second")
+		q.ErrorJob(id)
+	}()
+	lines, end := readFollow(t, bufio.NewReader(resp.Body))
+	if strings.Join(lines, ",") != "first,second" || end != `"error"` {
+		t.Errorf("lines=%v end=%s", lines, end)
+	}
+}
@@ -0,0 +1,458 @@
+package jobqueue
+
+// Job logs. Every PushJobStdout line is appended to the job_log table — one
+// row per line, inserted in batches — instead of re-serializing the whole
+// stdout array into the jobs row, so a long job's log costs linear writes
+// and bounded memory. Job.Stdout keeps only the newest lines; readers page
+// through JobLogs for the rest.
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Log levels. PushJobStdout infers one from the line's prefix.
+const (
+	LogLevelInfo  = "info"
+	LogLevelWarn  = "warn"
+	LogLevelError = "error"
+)
+
+const (
+	// StdoutTailLines is how many of a job's newest lines Job.Stdout keeps.
+	StdoutTailLines = 500
+	// DefaultLogMaxLines and DefaultLogMaxLineBytes are the per-job caps
+	// used until SetLogLimits is called: past MaxLines the oldest lines are
+	// dropped, and longer lines are truncated.
+	DefaultLogMaxLines     = 100000
+	DefaultLogMaxLineBytes = 16 * 1024
+	// LogPageMax caps one JobLogs page.
+	LogPageMax = 5000
+
+	logFlushInterval = 250 * time.Millisecond
+	logFlushBatch    = 512
+)
+
+// LogLine is one stored line of a job's log. Seq numbers a job's lines from
+// 1 and never repeats, so readers resume with "after seq".
+type LogLine struct {
+	Seq   int64  `json:"seq"`
+	TS    int64  `json:"ts"` // unix milliseconds
+	Level string `json:"level"`
+	Line  string `json:"line"`
+}
+
+// LogQuery selects a page of a job's log. Before > 0 reads the lines just
+// before that seq; Tail reads the newest lines; otherwise lines after After
+// (0 = from the start). Limit <= 0 means LogPageMax.
+type LogQuery struct {
+	After  int64
+	Before int64
+	Tail   bool
+	Limit  int
+}
+
+// LogPage is one page of a job's log, oldest line first. First and Last are
+// the oldest retained and newest seq of the whole log (0 when it is empty),
+// so a reader can tell whether earlier lines exist or were dropped.
+type LogPage struct {
+	Lines []LogLine `json:"lines"`
+	First int64     `json:"first"`
+	Last  int64     `json:"last"`
+}
+
+type pendingLog struct {
+	jobID string
+	line  LogLine
+}
+
+func (q *Queue) createJobLogTable() error {
+	_, err := q.Db.Exec(`CREATE TABLE IF NOT EXISTS job_log (
+		job_id TEXT NOT NULL,
+		seq INTEGER NOT NULL,
+		ts INTEGER NOT NULL,
+		level TEXT NOT NULL DEFAULT 'info',
+		line TEXT NOT NULL,
+		PRIMARY KEY (job_id, seq)
+	)`)
+	return err
+}
+
+// SetLogLimits sets the per-job log caps; values <= 0 fall back to the
+// defaults. Lines already stored are trimmed on the job's next flush.
+func (q *Queue) SetLogLimits(maxLines, maxLineBytes int) {
+	if maxLines <= 0 {
+		maxLines = DefaultLogMaxLines
+	}
+	if maxLineBytes <= 0 {
+		maxLineBytes = DefaultLogMaxLineBytes
+	}
+	q.logMu.Lock()
+	q.logMaxLines, q.logMaxLineBytes = maxLines, maxLineBytes
+	q.logMu.Unlock()
+}
+
+func (q *Queue) logLimits() (maxLines, maxLineBytes int) {
+	q.logMu.Lock()
+	defer q.logMu.Unlock()
+	maxLines, maxLineBytes = q.logMaxLines, q.logMaxLineBytes
+	if maxLines <= 0 {
+		maxLines = DefaultLogMaxLines
+	}
+	if maxLineBytes <= 0 {
+		maxLineBytes = DefaultLogMaxLineBytes
+	}
+	return maxLines, maxLineBytes
+}
+
+// inferLogLevel reads the level tasks have always written as a prefix
+// ("Error: ...", "Warning: ...").
+func inferLogLevel(line string) string {
+	head := strings.ToLower(strings.TrimSpace(line))
+	if i := strings.IndexByte(head, ']'); strings.HasPrefix(head, "[") && i > 0 {
+		head = strings.TrimSpace(head[i+1:]) // "[transcribe] Warning: ..."
+	}
+	switch {
+	case strings.HasPrefix(head, "error"), strings.HasPrefix(head, "failed"):
+		return LogLevelError
+	case strings.HasPrefix(head, "warning"), strings.HasPrefix(head, "warn:"):
+		return LogLevelWarn
+	}
+	return LogLevelInfo
+}
+
+// truncateLogLine cuts line to at most max bytes on a rune boundary.
+func truncateLogLine(line string, max int) string {
+	if len(line) <= max {
+		return line
+	}
+	const marker = " …[truncated]"
+	cut := max - len(marker)
+	if cut < 0 {
+		cut = 0
+	}
+	for cut > 0 && !utf8.RuneStart(line[cut]) {
+		cut--
+	}
+	return line[:cut] + marker
+}
+
+// appendLogLocked numbers line, adds it to the job's in-memory tail, and
+// returns the entry to store. q.mu must be held.
+func (q *Queue) appendLogLocked(job *Job, level, line string, maxLineBytes int) LogLine {
+	if q.logSeq == nil {
+		q.logSeq = make(map[string]int64)
+	}
+	q.logSeq[job.ID]++
+	entry := LogLine{
+		Seq:   q.logSeq[job.ID],
+		TS:    time.Now().UnixMilli(),
+		Level: level,
+		Line:  truncateLogLine(line, maxLineBytes),
+	}
+	job.Stdout = append(job.Stdout, entry.Line)
+	// Trim in chunks so a busy job isn't copying its tail on every line.
+	if n := len(job.Stdout); n >= 2*StdoutTailLines {
+		job.Stdout = append([]string(nil), job.Stdout[n-StdoutTailLines:]...)
+	}
+	return entry
+}
+
+// PushJobLog appends a line at the given level to the job's log.
+func (q *Queue) PushJobLog(id, level, line string) error {
+	_, maxLineBytes := q.logLimits()
+	q.mu.Lock()
+	job, exists := q.Jobs[id]
+	if !exists {
+		q.mu.Unlock()
+		return errors.New("job not found")
+	}
+	entry := q.appendLogLocked(job, level, line, maxLineBytes)
+	q.mu.Unlock()
+
+	q.enqueueLog(id, entry)
+	_ = serializeStdout(entry.Line, id)
+	return nil
+}
+
+// enqueueLog buffers entry for the next batched insert.
+func (q *Queue) enqueueLog(jobID string, entry LogLine) {
+	if q.Db == nil {
+		return
+	}
+	q.logMu.Lock()
+	q.logPending = append(q.logPending, pendingLog{jobID: jobID, line: entry})
+	full := len(q.logPending) >= logFlushBatch
+	arm := !full && !q.logFlushArmed
+	if arm {
+		q.logFlushArmed = true
+	}
+	q.logMu.Unlock()
+	switch {
+	case full:
+		q.FlushLogs()
+	case arm:
+		time.AfterFunc(logFlushInterval, q.FlushLogs)
+	}
+}
+
+// FlushLogs writes buffered log lines and trims jobs past the line cap.
+// Readers call it first so a page always includes every pushed line.
+func (q *Queue) FlushLogs() {
+	if q.Db == nil {
+		return
+	}
+	// Holding logFlushMu across the swap and the write keeps batches in
+	// push order.
+	q.logFlushMu.Lock()
+	defer q.logFlushMu.Unlock()
+	q.logMu.Lock()
+	batch := q.logPending
+	q.logPending = nil
+	q.logFlushArmed = false
+	q.logMu.Unlock()
+	if len(batch) == 0 {
+		return
+	}
+	if err := q.writeLogBatch(batch); err != nil {
+		log.Printf("Failed to write %d job log line(s): %v", len(batch), err)
+	}
+}
+
+func (q *Queue) writeLogBatch(batch []pendingLog) error {
+	maxLines, _ := q.logLimits()
+	tx, err := q.Db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO job_log (job_id, seq, ts, level, line) VALUES (?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	newest := map[string]int64{}
+	for _, p := range batch {
+		if _, err := stmt.Exec(p.jobID, p.line.Seq, p.line.TS, p.line.Level, p.line.Line); err != nil {
+			return err
+		}
+		if p.line.Seq > newest[p.jobID] {
+			newest[p.jobID] = p.line.Seq
+		}
+	}
+	for id, seq := range newest {
+		if cut := seq - int64(maxLines); cut > 0 {
+			if _, err := tx.Exec(`DELETE FROM job_log WHERE job_id = ? AND seq <= ?`, id, cut); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// deleteJobLog drops a removed job's log, including lines still buffered.
+func (q *Queue) deleteJobLog(jobID string) error {
+	q.FlushLogs()
+	_, err := q.Db.Exec(`DELETE FROM job_log WHERE job_id = ?`, jobID)
+	return err
+}
+
+// JobLogs returns a page of the job's log. Without a database the page comes
+// from the in-memory tail.
+func (q *Queue) JobLogs(id string, lq LogQuery) (LogPage, error) {
+	if lq.Limit <= 0 || lq.Limit > LogPageMax {
+		lq.Limit = LogPageMax
+	}
+	q.mu.Lock()
+	job, exists := q.Jobs[id]
+	if !exists {
+		q.mu.Unlock()
+		return LogPage{}, errors.New("job not found")
+	}
+	last := q.logSeq[id]
+	tail := append([]string(nil), job.Stdout...)
+	q.mu.Unlock()
+
+	if q.Db == nil {
+		return tailLogPage(tail, last, lq), nil
+	}
+	q.FlushLogs()
+	page := LogPage{Lines: []LogLine{}, Last: last}
+	var first sql.NullInt64
+	if err := q.Db.QueryRow(`SELECT MIN(seq) FROM job_log WHERE job_id = ?`, id).Scan(&first); err != nil {
+		return LogPage{}, err
+	}
+	page.First = first.Int64
+
+	var rows *sql.Rows
+	var err error
+	before := lq.Before
+	if lq.Tail && before <= 0 {
+		before = math.MaxInt64
+	}
+	if before > 0 {
+		rows, err = q.Db.Query(`SELECT seq, ts, level, line FROM job_log WHERE job_id = ? AND seq < ? ORDER BY seq DESC LIMIT ?`, id, before, lq.Limit)
+	} else {
+		rows, err = q.Db.Query(`SELECT seq, ts, level, line FROM job_log WHERE job_id = ? AND seq > ? ORDER BY seq LIMIT ?`, id, lq.After, lq.Limit)
+	}
+	if err != nil {
+		return LogPage{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var l LogLine
+		if err := rows.Scan(&l.Seq, &l.TS, &l.Level, &l.Line); err != nil {
+			return LogPage{}, err
+		}
+		page.Lines = append(page.Lines, l)
+	}
+	if before > 0 {
+		for i, j := 0, len(page.Lines)-1; i < j; i, j = i+1, j-1 {
+			page.Lines[i], page.Lines[j] = page.Lines[j], page.Lines[i]
+		}
+	}
+	return page, rows.Err()
+}
+
+// tailLogPage serves a LogQuery from an in-memory tail whose newest line is
+// seq last. Timestamps and levels aren't kept in memory.
+func tailLogPage(tail []string, last int64, lq LogQuery) LogPage {
+	page := LogPage{Lines: []LogLine{}, Last: last}
+	if len(tail) == 0 {
+		return page
+	}
+	page.First = last - int64(len(tail)) + 1
+	var lines []LogLine
+	for i, l := range tail {
+		lines = append(lines, LogLine{Seq: page.First + int64(i), Level: inferLogLevel(l), Line: l})
+	}
+	switch {
+	case lq.Before > 0 || lq.Tail:
+		end := len(lines)
+		if lq.Before > 0 {
+			end = 0
+			for end < len(lines) && lines[end].Seq < lq.Before {
+				end++
+			}
+		}
+		page.Lines = lines[max(0, end-lq.Limit):end]
+	default:
+		start := 0
+		for start < len(lines) && lines[start].Seq <= lq.After {
+			start++
+		}
+		page.Lines = lines[start:min(len(lines), start+lq.Limit)]
+	}
+	return page
+}
+
+// jobLogLines returns the text of a job's whole retained log — the chained
+// input of legacy tasks that print their output paths. q.mu must not be
+// held: with a database this flushes and queries SQLite.
+func (q *Queue) jobLogLines(id string) []string {
+	q.mu.Lock()
+	var tail []string
+	if job, ok := q.Jobs[id]; ok {
+		tail = append(tail, job.Stdout...)
+	}
+	q.mu.Unlock()
+	if q.Db == nil {
+		return tail
+	}
+	q.FlushLogs()
+	rows, err := q.Db.Query(`SELECT line FROM job_log WHERE job_id = ? ORDER BY seq`, id)
+	if err != nil {
+		log.Printf("Failed to read log of job %s: %v", id, err)
+		return tail
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var l string
+		if rows.Scan(&l) == nil {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// loadJobLogs restores each job's sequence counter and in-memory tail at
+// startup, first moving any stdout array still stored in the jobs row (from
+// before job_log existed) into the table.
+func (q *Queue) loadJobLogs() {
+	if q.logSeq == nil {
+		q.logSeq = make(map[string]int64)
+	}
+	rows, err := q.Db.Query(`SELECT job_id, MAX(seq) FROM job_log GROUP BY job_id`)
+	if err != nil {
+		log.Printf("Failed to read job log sequences: %v", err)
+		return
+	}
+	for rows.Next() {
+		var id string
+		var seq int64
+		if rows.Scan(&id, &seq) == nil {
+			q.logSeq[id] = seq
+		}
+	}
+	rows.Close()
+
+	for _, id := range q.JobOrder {
+		job := q.Jobs[id]
+		if q.logSeq[id] == 0 {
+			if len(job.Stdout) > 0 {
+				q.migrateLegacyStdout(job)
+			}
+			continue
+		}
+		job.Stdout = q.readTail(id, q.logSeq[id])
+	}
+}
+
+// migrateLegacyStdout stores a job's jobs-row stdout array as its log.
+func (q *Queue) migrateLegacyStdout(job *Job) {
+	maxLines, maxLineBytes := q.logLimits()
+	lines := job.Stdout
+	if len(lines) > maxLines {
+		lines = lines[len(lines)-maxLines:]
+	}
+	ts := job.CreatedAt.UnixMilli()
+	batch := make([]pendingLog, len(lines))
+	for i, l := range lines {
+		l = truncateLogLine(l, maxLineBytes)
+		batch[i] = pendingLog{jobID: job.ID, line: LogLine{Seq: int64(i + 1), TS: ts, Level: inferLogLevel(l), Line: l}}
+	}
+	if err := q.writeLogBatch(batch); err != nil {
+		log.Printf("Failed to migrate stdout of job %s: %v", job.ID, err)
+		return
+	}
+	q.logSeq[job.ID] = int64(len(lines))
+	if len(job.Stdout) > StdoutTailLines {
+		job.Stdout = append([]string(nil), job.Stdout[len(job.Stdout)-StdoutTailLines:]...)
+	}
+	// Clear the array from the jobs row now that job_log holds it.
+	if err := q.saveJobToDB(job); err != nil {
+		log.Printf("Failed to save migrated job %s: %v", job.ID, err)
+	}
+}
+
+func (q *Queue) readTail(id string, last int64) []string {
+	rows, err := q.Db.Query(`SELECT line FROM job_log WHERE job_id = ? AND seq > ? ORDER BY seq`, id, last-StdoutTailLines)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var l string
+		if rows.Scan(&l) == nil {
+			out = append(out, l)
+		}
+	}
+	return out
+}
@@ -0,0 +1,236 @@
+package jobqueue
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func newLogTestQueue(t *testing.T) (*Queue, *sql.DB) {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// One connection: every :memory: connection is its own database.
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return NewQueueWithDB(db), db
+}
+
+func seqs(lines []LogLine) []int64 {
+	out := make([]int64, len(lines))
+	for i, l := range lines {
+		out[i] = l.Seq
+	}
+	return out
+}
+
+func TestJobLogsPaging(t *testing.T) {
+	q, _ := newLogTestQueue(t)
+	id, _ := q.AddJob("", "cmd", nil, "", nil)
+	for i := 1; i <= 10; i++ {
+		q.PushJobStdout(id, fmt.Sprintf("line %d", i))
+	}
+
+	page, err := q.JobLogs(id, LogQuery{After: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(seqs(page.Lines)) != "[8 9 10]" || page.First != 1 || page.Last != 10 {
+		t.Errorf("after 7 = %v first=%d last=%d", seqs(page.Lines), page.First, page.Last)
+	}
+	if page.Lines[0].Line != "line 8" || page.Lines[0].TS == 0 || page.Lines[0].Level != LogLevelInfo {
+		t.Errorf("line = %+v", page.Lines[0])
+	}
+	if page, _ = q.JobLogs(id, LogQuery{Tail: true, Limit: 3}); fmt.Sprint(seqs(page.Lines)) != "[8 9 10]" {
+		t.Errorf("tail = %v", seqs(page.Lines))
+	}
+	if page, _ = q.JobLogs(id, LogQuery{Before: 8, Limit: 3}); fmt.Sprint(seqs(page.Lines)) != "[5 6 7]" {
+		t.Errorf("before 8 = %v", seqs(page.Lines))
+	}
+	if page, _ = q.JobLogs(id, LogQuery{Limit: 2}); fmt.Sprint(seqs(page.Lines)) != "[1 2]" {
+		t.Errorf("from start = %v", seqs(page.Lines))
+	}
+	if _, err := q.JobLogs("nope", LogQuery{}); err == nil {
+		t.Error("unknown job: no error")
+	}
+}
+
+func TestJobLogsCapsAndTail(t *testing.T) {
+	q, db := newLogTestQueue(t)
+	q.SetLogLimits(5, 32)
+	id, _ := q.AddJob("", "cmd", nil, "", nil)
+	for i := 1; i <= 2*StdoutTailLines+3; i++ {
+		q.PushJobStdout(id, fmt.Sprintf("line %d", i))
+	}
+	q.PushJobStdout(id, strings.Repeat("é", 40))
+
+	job := q.GetJob(id)
+	if len(job.Stdout) > 2*StdoutTailLines || job.Stdout[len(job.Stdout)-2] != fmt.Sprintf("line %d", 2*StdoutTailLines+3) {
+		t.Errorf("tail has %d lines, last %q", len(job.Stdout), job.Stdout[len(job.Stdout)-2])
+	}
+
+	page, _ := q.JobLogs(id, LogQuery{})
+	last := int64(2*StdoutTailLines + 4)
+	if len(page.Lines) != 5 || page.First != last-4 || page.Last != last {
+		t.Errorf("retained %v first=%d last=%d; want the newest 5", seqs(page.Lines), page.First, page.Last)
+	}
+	long := page.Lines[4].Line
+	if len(long) > 32 || !strings.HasSuffix(long, "[truncated]") || !strings.HasPrefix(long, "é") {
+		t.Errorf("long line = %q (%d bytes)", long, len(long))
+	}
+
+	// Removing the job removes its log.
+	q.RemoveJob(id)
+	var n int
+	db.QueryRow(`SELECT COUNT(*) FROM job_log`).Scan(&n)
+	if n != 0 {
+		t.Errorf("%d log rows left after remove", n)
+	}
+}
+
+func TestJobLogsSurviveRestart(t *testing.T) {
+	q, db := newLogTestQueue(t)
+	id, _ := q.AddJob("", "cmd", nil, "", nil)
+	q.PushJobStdout(id, "one")
+	q.PushJobStdout(id, "Warning: two")
+	q.FlushLogs()
+
+	q2 := NewQueueWithDB(db)
+	if got := q2.GetJob(id).Stdout; fmt.Sprint(got) != "[one Warning: two]" {
+		t.Errorf("restored tail = %v", got)
+	}
+	q2.PushJobStdout(id, "three")
+	page, _ := q2.JobLogs(id, LogQuery{})
+	if fmt.Sprint(seqs(page.Lines)) != "[1 2 3]" || page.Lines[1].Level != LogLevelWarn {
+		t.Errorf("after restart = %+v", page.Lines)
+	}
+	var stored string
+	db.QueryRow(`SELECT stdout FROM jobs WHERE id = ?`, id).Scan(&stored)
+	if stored != "[]" {
+		t.Errorf("jobs.stdout = %q; the log belongs in job_log", stored)
+	}
+}
+
+func TestJobLogsMigrateLegacyStdout(t *testing.T) {
+	q, db := newLogTestQueue(t)
+	id, _ := q.AddJob("", "cmd", nil, "", nil)
+	if _, err := db.Exec(`UPDATE jobs SET stdout = ? WHERE id = ?`, `["old 1","Error: old 2"]`, id); err != nil {
+		t.Fatal(err)
+	}
+
+	q2 := NewQueueWithDB(db)
+	page, _ := q2.JobLogs(id, LogQuery{})
+	if len(page.Lines) != 2 || page.Lines[0].Line != "old 1" || page.Lines[1].Level != LogLevelError {
+		t.Fatalf("migrated = %+v", page.Lines)
+	}
+	var stored string
+	db.QueryRow(`SELECT stdout FROM jobs WHERE id = ?`, id).Scan(&stored)
+	if stored != "[]" {
+		t.Errorf("jobs.stdout after migration = %q", stored)
+	}
+	// A second start must not migrate again.
+	q3 := NewQueueWithDB(db)
+	if page, _ = q3.JobLogs(id, LogQuery{}); len(page.Lines) != 2 {
+		t.Errorf("re-migrated: %v", seqs(page.Lines))
+	}
+}
+
+// Legacy tasks that print output paths still chain their whole log, not
+// just the in-memory tail.
+func TestClaimJobChainsFullLog(t *testing.T) {
+	q, _ := newLogTestQueue(t)
+	parent, _ := q.AddJob("", "legacy", nil, "", nil)
+	child, _ := q.AddJob("", "next", nil, "", []string{parent})
+	q.ClaimJob()
+	n := StdoutTailLines*2 + 10
+	for i := 0; i < n; i++ {
+		q.PushJobStdout(parent, fmt.Sprintf("/out/%d.jpg", i))
+	}
+	q.CompleteJob(parent)
+	job, _ := q.ClaimJob()
+	if job == nil || job.ID != child {
+		t.Fatalf("claimed %v", job)
+	}
+	if got := strings.Count(job.Input, "\n") + 1; got != n {
+		t.Errorf("child input has %d lines; want %d", got, n)
+	}
+}
+
+// JobState reads a job's state under the lock while a worker moves it on.
+func TestJobStateWhileWorking(t *testing.T) {
+	q := NewQueue()
+	id, _ := q.AddJob("", "cmd", nil, "", nil)
+	if _, ok := q.JobState("missing"); ok {
+		t.Error("missing job reported as present")
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		q.ClaimJob()
+		q.CompleteJob(id)
+	}()
+	for {
+		state, ok := q.JobState(id)
+		if !ok {
+			t.Fatal("job vanished")
+		}
+		if state == StateCompleted {
+			break
+		}
+	}
+	<-done
+}
+
+func TestJobLogsWithoutDB(t *testing.T) {
+	q := NewQueue()
+	id, _ := q.AddJob("", "cmd", nil, "", nil)
+	for i := 1; i <= 4; i++ {
+		q.PushJobStdout(id, fmt.Sprintf("line %d", i))
+	}
+	page, _ := q.JobLogs(id, LogQuery{After: 2})
+	if fmt.Sprint(seqs(page.Lines)) != "[3 4]" || page.First != 1 || page.Last != 4 {
+		t.Errorf("after 2 = %v first=%d last=%d", seqs(page.Lines), page.First, page.Last)
+	}
+	if page, _ = q.JobLogs(id, LogQuery{Tail: true, Limit: 1}); fmt.Sprint(seqs(page.Lines)) != "[4]" {
+		t.Errorf("tail = %v", seqs(page.Lines))
+	}
+}
+
+func TestJobLogBatchFlushesOnTimer(t *testing.T) {
+	q, db := newLogTestQueue(t)
+	id, _ := q.AddJob("", "cmd", nil, "", nil)
+	q.PushJobStdout(id, "x")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var n int
+		db.QueryRow(`SELECT COUNT(*) FROM job_log WHERE job_id = ?`, id).Scan(&n)
+		if n == 1 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("buffered line never flushed")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestInferLogLevel(t *testing.T) {
+	for line, want := range map[string]string{
+		"Error: boom":             LogLevelError,
+		"failed to open":          LogLevelError,
+		"Warning: slow":           LogLevelWarn,
+		"[transcribe] Warning: x": LogLevelWarn,
+		"processed 3 items":       LogLevelInfo,
+		"[diarize] cue at 1s: ok": LogLevelInfo,
+	} {
+		if got := inferLogLevel(line); got != want {
+			t.Errorf("inferLogLevel(%q) = %q, want %q", line, got, want)
+		}
+	}
+}
@@ -130,7 +130,9 @@ type Job struct {
 	// combined job running embed+faces ops holds those buckets too, plus the
 	// shared local-compute slot). A job is only claimed when EVERY bucket has
 	// capacity, and it counts against all of them while running.
-	Resources    []string           `json:"resources"`
+	Resources []string `json:"resources"`
+	// Stdout is the newest StdoutTailLines-or-so lines of the job's log;
+	// the whole log is in job_log (see JobLogs).
 	Stdout       []string           `json:"-"`
 	StdoutRaw    io.Reader          `json:"-"` // Raw stdout stream
 	StdIn        io.Reader          `json:"-"`
@@ -200,6 +202,16 @@ type Queue struct {
 	// its normalized item keys; pathJobs is the reverse. Lazily initialized.
 	jobItems map[string][]string
 	pathJobs map[string]map[string]struct{}
+
+	// Job logs (see joblog.go). logSeq is each job's newest line number,
+	// guarded by mu; the batch buffer and caps are guarded by logMu.
+	logSeq          map[string]int64
+	logMu           sync.Mutex
+	logFlushMu      sync.Mutex
+	logPending      []pendingLog
+	logFlushArmed   bool
+	logMaxLines     int
+	logMaxLineBytes int
 }
 
 // NewQueue initializes and returns a new Queue.
@@ -277,7 +289,7 @@ func (q *Queue) createJobsTable() error {
 	_, _ = q.Db.Exec("ALTER TABLE jobs ADD COLUMN resources TEXT")
 	_, _ = q.Db.Exec("ALTER TABLE jobs ADD COLUMN interrupt_count INTEGER")
 
-	return nil
+	return q.createJobLogTable()
 }
 
 // saveJobToDB saves a single job to the database
@@ -285,10 +297,12 @@ func (q *Queue) saveJobToDB(job *Job) error {
 	if q.Db == nil {
 		return nil // No database connection
 	}
+	// Every state change lands its log lines first, so a finished job's log
+	// is complete on disk before its state says so.
+	q.FlushLogs()
 
 	// Serialize arrays to JSON
 	argumentsJSON, _ := json.Marshal(job.Arguments)
-	stdoutJSON, _ := json.Marshal(job.Stdout)
 	dependenciesJSON, _ := json.Marshal(job.Dependencies)
 	outputFilesJSON, _ := json.Marshal(job.OutputFiles)
 	sourceFilesJSON, _ := json.Marshal(job.SourceFiles)
@@ -318,7 +332,7 @@ func (q *Queue) saveJobToDB(job *Job) error {
 		job.Input,
 		job.OriginalInput,
 		job.Host,
-		string(stdoutJSON),
+		"[]", // the log lives in job_log; the column only feeds migration
 		string(dependenciesJSON),
 		int(job.State),
 		job.CreatedAt,
@@ -361,6 +375,7 @@ func (q *Queue) loadJobsFromDB() error {
 
 	var resumedJobs []string
 	var interruptedIDs []string
+	var notResumed []string
 
 	for rows.Next() {
 		var job Job
@@ -437,10 +452,7 @@ func (q *Queue) loadJobsFromDB() error {
 			if job.InterruptCount >= maxJobResumes {
 				job.State = StateError
 				job.ErroredAt = time.Now()
-				job.Stdout = append(job.Stdout, fmt.Sprintf(
-					"Not auto-resumed: interrupted %d times without completing "+
-						"(possible out-of-memory). Restart it manually if intended.",
-					job.InterruptCount))
+				notResumed = append(notResumed, job.ID)
 			} else {
 				job.InterruptCount++
 				job.State = StatePending
@@ -461,6 +473,20 @@ func (q *Queue) loadJobsFromDB() error {
 		q.indexJobFromDefinitionLocked(&job)
 	}
 
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+	q.loadJobLogs()
+	_, maxLineBytes := q.logLimits()
+	for _, id := range notResumed {
+		j := q.Jobs[id]
+		q.enqueueLog(id, q.appendLogLocked(j, LogLevelError, fmt.Sprintf(
+			"Not auto-resumed: interrupted %d times without completing "+
+				"(possible out-of-memory). Restart it manually if intended.",
+			j.InterruptCount), maxLineBytes))
+	}
+
 	// Persist the interrupt decisions now that the SELECT cursor is closed
 	// (writing mid-iteration deadlocks on SQLITE_BUSY). Doing it before the
 	// resumed jobs are signaled means the incremented count / failed state
@@ -485,7 +511,7 @@ func (q *Queue) loadJobsFromDB() error {
 		}
 	}
 
-	return rows.Err()
+	return nil
 }
 
 // removeJobFromDB removes a job from the database
@@ -494,6 +520,9 @@ func (q *Queue) removeJobFromDB(jobID string) error {
 		return nil // No database connection
 	}
 
+	if err := q.deleteJobLog(jobID); err != nil {
+		return err
+	}
 	_, err := q.Db.Exec("DELETE FROM jobs WHERE id = ?", jobID)
 	return err
 }
@@ -657,6 +686,57 @@ func (q *Queue) CopyJob(id string) (string, error) {
 // in FIFO order. If successful, it returns the job and marks it as InProgress.
 // If no suitable job is found, it returns nil and no error.
 func (q *Queue) ClaimJob() (*Job, error) {
+	job, logDeps := q.claimPendingJob()
+	if job == nil {
+		return nil, nil
+	}
+	// Legacy dependencies with no OutputFiles chain their whole log, which
+	// is read from SQLite outside q.mu so claims, enqueues, and status
+	// calls never wait on it.
+	depLogs := make(map[string][]string, len(logDeps))
+	for _, id := range logDeps {
+		depLogs[id] = q.jobLogLines(id)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// Construct effective input from OriginalInput and parent outputs
+	var inputBuilder strings.Builder
+	inputBuilder.WriteString(job.OriginalInput)
+	for _, depID := range job.Dependencies {
+		if depJob, ok := q.Jobs[depID]; ok {
+			// Prefer OutputFiles; fall back to the log for legacy tasks
+			source := depJob.OutputFiles
+			if len(source) == 0 {
+				source = depLogs[depID]
+			}
+			for _, line := range source {
+				if inputBuilder.Len() > 0 {
+					inputBuilder.WriteString("\n")
+				}
+				inputBuilder.WriteString(line)
+			}
+		}
+	}
+	job.Input = inputBuilder.String()
+
+	// Save to database
+	if err := q.saveJobToDB(job); err != nil {
+		log.Printf("Failed to save job state to database: %v", err)
+	}
+
+	err := serializeListUpdate("update", job)
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// claimPendingJob marks the first claimable pending job InProgress and
+// returns it with the IDs of its dependencies whose output must come from
+// their logs.
+func (q *Queue) claimPendingJob() (*Job, []string) {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
@@ -674,36 +754,13 @@ func (q *Queue) ClaimJob() (*Job, error) {
 			job.ClaimedAt = time.Now()
 			q.incRunningLocked(job)
 
-			// Construct effective input from OriginalInput and parent outputs
-			var inputBuilder strings.Builder
-			inputBuilder.WriteString(job.OriginalInput)
+			var logDeps []string
 			for _, depID := range job.Dependencies {
-				if depJob, ok := q.Jobs[depID]; ok {
-					// Prefer OutputFiles; fall back to Stdout for legacy tasks
-					source := depJob.OutputFiles
-					if len(source) == 0 {
-						source = depJob.Stdout
-					}
-					for _, line := range source {
-						if inputBuilder.Len() > 0 {
-							inputBuilder.WriteString("\n")
-						}
-						inputBuilder.WriteString(line)
-					}
+				if depJob, ok := q.Jobs[depID]; ok && len(depJob.OutputFiles) == 0 {
+					logDeps = append(logDeps, depID)
 				}
 			}
-			job.Input = inputBuilder.String()
-
-			// Save to database
-			if err := q.saveJobToDB(job); err != nil {
-				log.Printf("Failed to save job state to database: %v", err)
-			}
-
-			err := serializeListUpdate("update", job)
-			if err != nil {
-				return nil, err
-			}
-			return job, nil
+			return job, logDeps
 		}
 	}
 
@@ -995,32 +1052,14 @@ func (q *Queue) SetJobProgress(id string, done, total int) error {
 	if err != nil {
 		return err
 	}
-	stream.Broadcast(stream.Message{Type: "progress", Msg: string(payload)})
+	stream.Broadcast(stream.Message{Type: "progress", Msg: string(payload), Topics: []string{stream.TopicJobs, stream.JobTopic(id)}})
 	return nil
 }
 
-// UpdateJobStdout updates the job's stdout with the given string.
+// PushJobStdout appends a line to the job's log, at the level its prefix
+// suggests ("Error: ...", "Warning: ..."), and streams it to listeners.
 func (q *Queue) PushJobStdout(id string, stdout string) error {
-	q.mu.Lock()
-	defer q.mu.Unlock()
-
-	job, exists := q.Jobs[id]
-	if !exists {
-		return errors.New("job not found")
-	}
-
-	job.Stdout = append(job.Stdout, stdout)
-
-	// Save to database
-	if err := q.saveJobToDB(job); err != nil {
-		log.Printf("Failed to save job stdout to database: %v", err)
-	}
-
-	err := serializeStdout(stdout, id)
-	if err != nil {
-		return nil
-	}
-	return nil
+	return q.PushJobLog(id, inferLogLevel(stdout), stdout)
 }
 
 // RegisterOutputFile appends a file path to the job's OutputFiles list.
@@ -1141,6 +1180,19 @@ func (q *Queue) GetJob(id string) *Job {
 	return job
 }
 
+// JobState returns the job's current state, read under the queue lock, and
+// whether the job exists. Callers polling a job use it rather than reading
+// State off GetJob's pointer, which workers update concurrently.
+func (q *Queue) JobState(id string) (JobState, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, exists := q.Jobs[id]
+	if !exists {
+		return 0, false
+	}
+	return job.State, true
+}
+
 // GetWorkflowOutputFiles returns all OutputFiles paths for jobs matching the given workflowID.
 func (q *Queue) GetWorkflowOutputFiles(workflowID string) []string {
 	q.mu.Lock()
@@ -1223,6 +1275,7 @@ func (q *Queue) RemoveJob(id string) error {
 	delete(q.pauseRequests, id)
 	q.dropJobItemsLocked(id)
 	delete(q.Jobs, id)
+	delete(q.logSeq, id)
 	for i, jobId := range q.JobOrder {
 		if jobId == id {
 			q.JobOrder = append(q.JobOrder[:i], q.JobOrder[i+1:]...)
@@ -1267,6 +1320,7 @@ func (q *Queue) ClearFinishedJobs() (int, error) {
 	for _, jobID := range jobsToRemove {
 		q.dropJobItemsLocked(jobID)
 		delete(q.Jobs, jobID)
+		delete(q.logSeq, jobID)
 
 		// Remove from job order
 		for i, id := range q.JobOrder {
@@ -1321,7 +1375,7 @@ func serializeListUpdate(updateType string, job *Job) error {
 		return fmt.Errorf("error marshalling event: %v", err)
 	}
 
-	stream.Broadcast(stream.Message{Type: updateType, Msg: string(j)})
+	stream.Broadcast(stream.Message{Type: updateType, Msg: string(j), Topics: []string{stream.TopicJobs, stream.JobTopic(job.ID)}})
 	return nil
 }
 
@@ -1335,8 +1389,9 @@ func serializeStdout(line string, id string) error {
 	if err != nil {
 		return fmt.Errorf("error marshalling event: %v", err)
 	}
-	//Type should be in the format `stdout-<job-id>`
-	stream.Broadcast(stream.Message{Type: "stdout-" + id, Msg: string(j)})
+	// Type should be in the format `stdout-<job-id>`. Lines aren't kept for
+	// replay; /job/{id}/logs serves them.
+	stream.Broadcast(stream.Message{Type: "stdout-" + id, Msg: string(j), Topics: []string{stream.JobTopic(id)}, NoReplay: true})
 	return nil
 }
 
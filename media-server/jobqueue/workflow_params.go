@@ -0,0 +1,213 @@
+package jobqueue
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Workflow parameters let one saved DAG cover every variation of a
+// pipeline. A workflow declares typed parameters; its tasks reference them
+// as ${name} inside Arguments and Input, and each run supplies values (or
+// takes the defaults). Bool parameters expand to "true"/"false", so a flag
+// is written --overwrite=${overwrite}.
+
+// Parameter types. path and query behave like string; they tell editors
+// what kind of value to offer.
+const (
+	ParamString = "string"
+	ParamNumber = "number"
+	ParamBool   = "bool"
+	ParamEnum   = "enum"
+	ParamPath   = "path"
+	ParamQuery  = "query"
+)
+
+// WorkflowParam declares one input of a saved workflow.
+type WorkflowParam struct {
+	Name        string   `json:"name"`
+	Label       string   `json:"label,omitempty"`
+	Type        string   `json:"type"`
+	Choices     []string `json:"choices,omitempty"`
+	Default     any      `json:"default,omitempty"`
+	Required    bool     `json:"required,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
+
+// ErrInvalidParams wraps every bad parameter declaration or run-time
+// value, so API handlers can answer 400 rather than 500.
+var ErrInvalidParams = errors.New("invalid workflow parameters")
+
+var (
+	paramNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+	paramRefRe  = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_-]*)\}`)
+)
+
+// argumentsValidator checks a task's expanded arguments against its option
+// schema. The tasks package registers it; nil skips the check.
+var argumentsValidator func(command string, args []string) error
+
+// SetArgumentsValidator registers the run-time argument check. Call during
+// package initialization only — it is read without synchronization.
+func SetArgumentsValidator(fn func(command string, args []string) error) {
+	argumentsValidator = fn
+}
+
+func paramErr(format string, a ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidParams, fmt.Sprintf(format, a...))
+}
+
+// paramString renders a JSON-decoded value (string, number, bool) as the
+// text substituted into arguments.
+func paramString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// checkParamValue validates v for p and returns its normalized form.
+func checkParamValue(p WorkflowParam, v string) (string, error) {
+	switch p.Type {
+	case ParamNumber:
+		if _, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err != nil {
+			return "", paramErr("%s must be a number, got %q", p.Name, v)
+		}
+		return strings.TrimSpace(v), nil
+	case ParamBool:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "true", "1", "yes":
+			return "true", nil
+		case "false", "0", "no":
+			return "false", nil
+		}
+		return "", paramErr("%s must be true or false, got %q", p.Name, v)
+	case ParamEnum:
+		if !slices.Contains(p.Choices, v) {
+			return "", paramErr("%s must be one of %s, got %q", p.Name, strings.Join(p.Choices, ", "), v)
+		}
+	case ParamPath:
+		if strings.ContainsAny(v, "\x00\r\n") {
+			return "", paramErr("%s is not a valid path", p.Name)
+		}
+	}
+	return v, nil
+}
+
+// paramRefs returns the parameter names referenced in s.
+func paramRefs(s string) []string {
+	var names []string
+	for _, m := range paramRefRe.FindAllStringSubmatch(s, -1) {
+		names = append(names, m[1])
+	}
+	return names
+}
+
+// validateParams checks the declarations and that the DAG references only
+// declared parameters.
+func validateParams(params []WorkflowParam, dag []WorkflowTask) error {
+	declared := make(map[string]bool, len(params))
+	for _, p := range params {
+		if !paramNameRe.MatchString(p.Name) {
+			return paramErr("bad parameter name %q (letters, digits, _ and -)", p.Name)
+		}
+		if declared[p.Name] {
+			return paramErr("duplicate parameter %s", p.Name)
+		}
+		declared[p.Name] = true
+		switch p.Type {
+		case ParamString, ParamNumber, ParamBool, ParamPath, ParamQuery:
+		case ParamEnum:
+			if len(p.Choices) == 0 {
+				return paramErr("enum parameter %s needs choices", p.Name)
+			}
+		default:
+			return paramErr("parameter %s has unknown type %q", p.Name, p.Type)
+		}
+		if p.Default != nil {
+			if _, err := checkParamValue(p, paramString(p.Default)); err != nil {
+				return fmt.Errorf("default: %w", err)
+			}
+		}
+	}
+
+	for _, task := range dag {
+		for _, s := range append([]string{task.Input}, task.Arguments...) {
+			for _, name := range paramRefs(s) {
+				if !declared[name] {
+					return paramErr("task %s references undeclared parameter ${%s}", task.ID, name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ResolveParams merges run-time values over the declared defaults and
+// validates them. Parameters with neither fall back to their type's zero
+// value ("", "0", "false"), or fail when required.
+func ResolveParams(params []WorkflowParam, values map[string]any) (map[string]string, error) {
+	byName := make(map[string]WorkflowParam, len(params))
+	for _, p := range params {
+		byName[p.Name] = p
+	}
+	for name := range values {
+		if _, ok := byName[name]; !ok {
+			return nil, paramErr("unknown parameter %s", name)
+		}
+	}
+
+	resolved := make(map[string]string, len(params))
+	for _, p := range params {
+		v, ok := values[p.Name]
+		if !ok || v == nil {
+			v = p.Default
+		}
+		if v == nil {
+			if p.Required {
+				return nil, paramErr("missing required parameter %s", p.Name)
+			}
+			switch p.Type {
+			case ParamNumber:
+				resolved[p.Name] = "0"
+			case ParamBool:
+				resolved[p.Name] = "false"
+			default:
+				resolved[p.Name] = ""
+			}
+			continue
+		}
+		s, err := checkParamValue(p, paramString(v))
+		if err != nil {
+			return nil, err
+		}
+		resolved[p.Name] = s
+	}
+	return resolved, nil
+}
+
+// expandParams replaces each ${name} in s with its value. References to
+// unknown names (possible only in workflows saved before parameters
+// existed) are left as written.
+func expandParams(s string, values map[string]string) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+	return paramRefRe.ReplaceAllStringFunc(s, func(ref string) string {
+		if v, ok := values[ref[2:len(ref)-1]]; ok {
+			return v
+		}
+		return ref
+	})
+}
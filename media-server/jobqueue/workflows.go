@@ -10,9 +10,10 @@ import (
 
 // SavedWorkflow represents a persisted workflow template with its full DAG.
 type SavedWorkflow struct {
-	ID   string         `json:"id"`
-	Name string         `json:"name"`
-	DAG  []WorkflowTask `json:"dag"`
+	ID     string          `json:"id"`
+	Name   string          `json:"name"`
+	DAG    []WorkflowTask  `json:"dag"`
+	Params []WorkflowParam `json:"params"`
 }
 
 // SavedWorkflowSummary is a lightweight representation for listing workflows.
@@ -27,10 +28,15 @@ func (q *Queue) createWorkflowsTable() error {
 	CREATE TABLE IF NOT EXISTS workflows (
 		id TEXT PRIMARY KEY,
 		name TEXT NOT NULL UNIQUE,
-		dag TEXT NOT NULL
+		dag TEXT NOT NULL,
+		params TEXT
 	)`
-	_, err := q.Db.Exec(query)
-	return err
+	if _, err := q.Db.Exec(query); err != nil {
+		return err
+	}
+	// Migration for tables created before workflow parameters.
+	_, _ = q.Db.Exec("ALTER TABLE workflows ADD COLUMN params TEXT")
+	return nil
 }
 
 // ListWorkflows returns all saved workflows ordered by name.
@@ -52,11 +58,13 @@ func (q *Queue) ListWorkflows() ([]SavedWorkflowSummary, error) {
 	return workflows, rows.Err()
 }
 
-// GetWorkflow retrieves a saved workflow by ID, including its full DAG.
+// GetWorkflow retrieves a saved workflow by ID, including its full DAG and
+// parameters.
 func (q *Queue) GetWorkflow(id string) (*SavedWorkflow, error) {
 	var w SavedWorkflow
 	var dagJSON string
-	err := q.Db.QueryRow("SELECT id, name, dag FROM workflows WHERE id = ?", id).Scan(&w.ID, &w.Name, &dagJSON)
+	var paramsJSON sql.NullString
+	err := q.Db.QueryRow("SELECT id, name, dag, params FROM workflows WHERE id = ?", id).Scan(&w.ID, &w.Name, &dagJSON, &paramsJSON)
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("workflow not found: %s", id)
 	}
@@ -66,41 +74,44 @@ func (q *Queue) GetWorkflow(id string) (*SavedWorkflow, error) {
 	if err := json.Unmarshal([]byte(dagJSON), &w.DAG); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal DAG: %w", err)
 	}
+	if paramsJSON.String != "" {
+		if err := json.Unmarshal([]byte(paramsJSON.String), &w.Params); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal params: %w", err)
+		}
+	}
+	if w.Params == nil {
+		w.Params = []WorkflowParam{}
+	}
 	return &w, nil
 }
 
 // CreateWorkflow validates and persists a new workflow template.
-func (q *Queue) CreateWorkflow(name string, dag []WorkflowTask) (*SavedWorkflow, error) {
-	if err := validateDAG(dag); err != nil {
+func (q *Queue) CreateWorkflow(name string, dag []WorkflowTask, params []WorkflowParam) (*SavedWorkflow, error) {
+	dagJSON, paramsJSON, err := marshalWorkflow(dag, params)
+	if err != nil {
 		return nil, err
 	}
 
 	id := uuid.NewString()
-	dagJSON, err := json.Marshal(dag)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal DAG: %w", err)
-	}
-
-	_, err = q.Db.Exec("INSERT INTO workflows (id, name, dag) VALUES (?, ?, ?)", id, name, string(dagJSON))
+	_, err = q.Db.Exec("INSERT INTO workflows (id, name, dag, params) VALUES (?, ?, ?, ?)", id, name, dagJSON, paramsJSON)
 	if err != nil {
 		return nil, err
 	}
 
-	return &SavedWorkflow{ID: id, Name: name, DAG: dag}, nil
-}
-
-// UpdateWorkflow updates an existing workflow's name and DAG.
-func (q *Queue) UpdateWorkflow(id string, name string, dag []WorkflowTask) error {
-	if err := validateDAG(dag); err != nil {
-		return err
+	if params == nil {
+		params = []WorkflowParam{}
 	}
+	return &SavedWorkflow{ID: id, Name: name, DAG: dag, Params: params}, nil
+}
 
-	dagJSON, err := json.Marshal(dag)
+// UpdateWorkflow updates an existing workflow's name, DAG and parameters.
+func (q *Queue) UpdateWorkflow(id string, name string, dag []WorkflowTask, params []WorkflowParam) error {
+	dagJSON, paramsJSON, err := marshalWorkflow(dag, params)
 	if err != nil {
-		return fmt.Errorf("failed to marshal DAG: %w", err)
+		return err
 	}
 
-	result, err := q.Db.Exec("UPDATE workflows SET name = ?, dag = ? WHERE id = ?", name, string(dagJSON), id)
+	result, err := q.Db.Exec("UPDATE workflows SET name = ?, dag = ?, params = ? WHERE id = ?", name, dagJSON, paramsJSON, id)
 	if err != nil {
 		return err
 	}
@@ -130,6 +141,29 @@ func (q *Queue) DeleteWorkflow(id string) error {
 	return nil
 }
 
+// marshalWorkflow validates a DAG and its parameters and encodes both for
+// the workflows table.
+func marshalWorkflow(dag []WorkflowTask, params []WorkflowParam) (string, string, error) {
+	if err := validateDAG(dag); err != nil {
+		return "", "", err
+	}
+	if err := validateParams(params, dag); err != nil {
+		return "", "", err
+	}
+	dagJSON, err := json.Marshal(dag)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to marshal DAG: %w", err)
+	}
+	if params == nil {
+		params = []WorkflowParam{}
+	}
+	paramsJSON, err := json.Marshal(params)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to marshal params: %w", err)
+	}
+	return string(dagJSON), string(paramsJSON), nil
+}
+
 // validateDAG checks that a DAG is well-formed.
 func validateDAG(dag []WorkflowTask) error {
 	if len(dag) == 0 {
@@ -162,13 +196,21 @@ func validateDAG(dag []WorkflowTask) error {
 }
 
 // RunWorkflow loads a saved workflow, generates fresh UUIDs for all nodes,
-// remaps dependency references, injects input into root nodes, and submits
-// the workflow as live jobs via AddWorkflow.
-func (q *Queue) RunWorkflow(id string, input string) ([]string, error) {
+// remaps dependency references, expands ${param} references with values
+// (falling back to the declared defaults), injects input into root nodes,
+// and submits the workflow as live jobs via AddWorkflow. Bad values, and
+// parameterized arguments that don't fit a task's options, fail with
+// ErrInvalidParams before any job is created; a task whose arguments
+// reference no parameter runs them as saved, as it did before parameters.
+func (q *Queue) RunWorkflow(id string, input string, values map[string]any) ([]string, error) {
 	saved, err := q.GetWorkflow(id)
 	if err != nil {
 		return nil, err
 	}
+	resolved, err := ResolveParams(saved.Params, values)
+	if err != nil {
+		return nil, err
+	}
 
 	// Generate fresh UUIDs and build a mapping from template ID to live ID.
 	idMap := make(map[string]string, len(saved.DAG))
@@ -184,7 +226,19 @@ func (q *Queue) RunWorkflow(id string, input string) ([]string, error) {
 			liveDeps[j] = idMap[dep]
 		}
 
-		liveInput := task.Input
+		var liveArgs []string
+		parameterized := false
+		for _, arg := range task.Arguments {
+			liveArgs = append(liveArgs, expandParams(arg, resolved))
+			parameterized = parameterized || len(paramRefs(arg)) > 0
+		}
+		if parameterized && argumentsValidator != nil {
+			if err := argumentsValidator(task.Command, liveArgs); err != nil {
+				return nil, fmt.Errorf("%w: task %s (%s): %v", ErrInvalidParams, task.ID, task.Command, err)
+			}
+		}
+
+		liveInput := expandParams(task.Input, resolved)
 		// Inject runtime input into root nodes (those with no dependencies).
 		if len(task.Dependencies) == 0 && input != "" {
 			if liveInput != "" {
@@ -197,7 +251,7 @@ func (q *Queue) RunWorkflow(id string, input string) ([]string, error) {
 		tasks[i] = WorkflowTask{
 			ID:           idMap[task.ID],
 			Command:      task.Command,
-			Arguments:    task.Arguments,
+			Arguments:    liveArgs,
 			Input:        liveInput,
 			Dependencies: liveDeps,
 		}
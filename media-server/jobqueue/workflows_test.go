@@ -2,6 +2,9 @@ package jobqueue
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 
 	_ "modernc.org/sqlite"
@@ -25,7 +28,7 @@ func TestCreateAndGetWorkflow(t *testing.T) {
 		{ID: "b", Command: "cmd2", Dependencies: []string{"a"}},
 	}
 
-	saved, err := q.CreateWorkflow("my-workflow", dag)
+	saved, err := q.CreateWorkflow("my-workflow", dag, nil)
 	if err != nil {
 		t.Fatalf("CreateWorkflow: %v", err)
 	}
@@ -52,11 +55,11 @@ func TestListWorkflows(t *testing.T) {
 	q := newTestQueue(t)
 
 	dag := []WorkflowTask{{ID: "x", Command: "run"}}
-	_, err := q.CreateWorkflow("zebra", dag)
+	_, err := q.CreateWorkflow("zebra", dag, nil)
 	if err != nil {
 		t.Fatalf("CreateWorkflow: %v", err)
 	}
-	_, err = q.CreateWorkflow("alpha", dag)
+	_, err = q.CreateWorkflow("alpha", dag, nil)
 	if err != nil {
 		t.Fatalf("CreateWorkflow: %v", err)
 	}
@@ -77,13 +80,13 @@ func TestUpdateWorkflow(t *testing.T) {
 	q := newTestQueue(t)
 
 	dag := []WorkflowTask{{ID: "a", Command: "old"}}
-	saved, err := q.CreateWorkflow("original", dag)
+	saved, err := q.CreateWorkflow("original", dag, nil)
 	if err != nil {
 		t.Fatalf("CreateWorkflow: %v", err)
 	}
 
 	newDAG := []WorkflowTask{{ID: "b", Command: "new"}}
-	err = q.UpdateWorkflow(saved.ID, "updated", newDAG)
+	err = q.UpdateWorkflow(saved.ID, "updated", newDAG, nil)
 	if err != nil {
 		t.Fatalf("UpdateWorkflow: %v", err)
 	}
@@ -104,7 +107,7 @@ func TestDeleteWorkflow(t *testing.T) {
 	q := newTestQueue(t)
 
 	dag := []WorkflowTask{{ID: "a", Command: "cmd"}}
-	saved, err := q.CreateWorkflow("to-delete", dag)
+	saved, err := q.CreateWorkflow("to-delete", dag, nil)
 	if err != nil {
 		t.Fatalf("CreateWorkflow: %v", err)
 	}
@@ -133,12 +136,12 @@ func TestCreateWorkflowDuplicateName(t *testing.T) {
 	q := newTestQueue(t)
 
 	dag := []WorkflowTask{{ID: "a", Command: "cmd"}}
-	_, err := q.CreateWorkflow("dup", dag)
+	_, err := q.CreateWorkflow("dup", dag, nil)
 	if err != nil {
 		t.Fatalf("first CreateWorkflow: %v", err)
 	}
 
-	_, err = q.CreateWorkflow("dup", dag)
+	_, err = q.CreateWorkflow("dup", dag, nil)
 	if err == nil {
 		t.Fatal("expected error creating workflow with duplicate name, got nil")
 	}
@@ -208,12 +211,12 @@ func TestRunWorkflowSetsWorkflowID(t *testing.T) {
 		{ID: "child", Command: "process", Dependencies: []string{"root"}},
 	}
 
-	saved, err := q.CreateWorkflow("wf-test", dag)
+	saved, err := q.CreateWorkflow("wf-test", dag, nil)
 	if err != nil {
 		t.Fatalf("CreateWorkflow: %v", err)
 	}
 
-	liveIDs, err := q.RunWorkflow(saved.ID, "")
+	liveIDs, err := q.RunWorkflow(saved.ID, "", nil)
 	if err != nil {
 		t.Fatalf("RunWorkflow: %v", err)
 	}
@@ -237,12 +240,12 @@ func TestRunWorkflow(t *testing.T) {
 		{ID: "child", Command: "process", Dependencies: []string{"root"}},
 	}
 
-	saved, err := q.CreateWorkflow("run-test", dag)
+	saved, err := q.CreateWorkflow("run-test", dag, nil)
 	if err != nil {
 		t.Fatalf("CreateWorkflow: %v", err)
 	}
 
-	liveIDs, err := q.RunWorkflow(saved.ID, "runtime-input")
+	liveIDs, err := q.RunWorkflow(saved.ID, "runtime-input", nil)
 	if err != nil {
 		t.Fatalf("RunWorkflow: %v", err)
 	}
@@ -274,3 +277,119 @@ func TestRunWorkflow(t *testing.T) {
 		t.Errorf("expected child dependency on %s, got %v", liveIDs[0], childJob.Dependencies)
 	}
 }
+
+func TestWorkflowParamsValidation(t *testing.T) {
+	q := newTestQueue(t)
+	dag := []WorkflowTask{{ID: "a", Command: "cmd", Arguments: []string{"--target", "${dir}"}}}
+
+	tests := []struct {
+		name   string
+		params []WorkflowParam
+	}{
+		{"undeclared reference", nil},
+		{"bad name", []WorkflowParam{{Name: "dir", Type: ParamPath}, {Name: "9x", Type: ParamString}}},
+		{"duplicate", []WorkflowParam{{Name: "dir", Type: ParamPath}, {Name: "dir", Type: ParamString}}},
+		{"unknown type", []WorkflowParam{{Name: "dir", Type: "file"}}},
+		{"enum without choices", []WorkflowParam{{Name: "dir", Type: ParamEnum}}},
+		{"bad default", []WorkflowParam{{Name: "dir", Type: ParamNumber, Default: "lots"}}},
+	}
+	for _, tt := range tests {
+		_, err := q.CreateWorkflow(tt.name, dag, tt.params)
+		if !errors.Is(err, ErrInvalidParams) {
+			t.Errorf("%s: err = %v, want ErrInvalidParams", tt.name, err)
+		}
+	}
+
+	saved, err := q.CreateWorkflow("ok", dag, []WorkflowParam{{Name: "dir", Type: ParamPath, Default: "/media"}})
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+	got, err := q.GetWorkflow(saved.ID)
+	if err != nil {
+		t.Fatalf("GetWorkflow: %v", err)
+	}
+	if len(got.Params) != 1 || got.Params[0].Default != "/media" {
+		t.Errorf("params not persisted: %+v", got.Params)
+	}
+}
+
+func TestRunWorkflowExpandsParams(t *testing.T) {
+	q := newTestQueue(t)
+	params := []WorkflowParam{
+		{Name: "dir", Type: ParamPath, Required: true},
+		{Name: "limit", Type: ParamNumber, Default: 10.0},
+		{Name: "overwrite", Type: ParamBool},
+		{Name: "model", Type: ParamEnum, Choices: []string{"small", "large"}, Default: "small"},
+	}
+	dag := []WorkflowTask{{
+		ID:        "a",
+		Command:   "cmd",
+		Arguments: []string{"--target", "${dir}", "--limit", "${limit}", "--overwrite=${overwrite}", "--model=${model}"},
+		Input:     "${dir}/in",
+	}}
+	saved, err := q.CreateWorkflow("params", dag, params)
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+
+	ids, err := q.RunWorkflow(saved.ID, "", map[string]any{"dir": "/data", "overwrite": "yes", "model": "large"})
+	if err != nil {
+		t.Fatalf("RunWorkflow: %v", err)
+	}
+	job := q.GetJob(ids[0])
+	want := "--target /data --limit 10 --overwrite=true --model=large"
+	if got := strings.Join(job.Arguments, " "); got != want {
+		t.Errorf("arguments = %q, want %q", got, want)
+	}
+	if job.Input != "/data/in" {
+		t.Errorf("input = %q", job.Input)
+	}
+
+	for name, values := range map[string]map[string]any{
+		"missing required": {},
+		"bad number":       {"dir": "/d", "limit": "ten"},
+		"bad choice":       {"dir": "/d", "model": "huge"},
+		"unknown":          {"dir": "/d", "colour": "red"},
+	} {
+		if _, err := q.RunWorkflow(saved.ID, "", values); !errors.Is(err, ErrInvalidParams) {
+			t.Errorf("%s: err = %v, want ErrInvalidParams", name, err)
+		}
+	}
+}
+
+func TestRunWorkflowChecksArguments(t *testing.T) {
+	old := argumentsValidator
+	t.Cleanup(func() { argumentsValidator = old })
+	SetArgumentsValidator(func(command string, args []string) error {
+		if command == "cmd" && len(args) == 2 && args[1] == "-1" {
+			return fmt.Errorf("--count must not be negative")
+		}
+		return nil
+	})
+
+	q := newTestQueue(t)
+	dag := []WorkflowTask{{ID: "a", Command: "cmd", Arguments: []string{"--count", "${n}"}}}
+	saved, err := q.CreateWorkflow("checked", dag, []WorkflowParam{{Name: "n", Type: ParamNumber, Default: 1.0}})
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+	if _, err := q.RunWorkflow(saved.ID, "", map[string]any{"n": -1.0}); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("err = %v, want ErrInvalidParams", err)
+	}
+	if len(q.GetJobs()) != 0 {
+		t.Error("a rejected run must not create jobs")
+	}
+	if _, err := q.RunWorkflow(saved.ID, "", nil); err != nil {
+		t.Errorf("default run: %v", err)
+	}
+
+	// Workflows saved before parameters existed keep running their
+	// free-form arguments unchecked.
+	legacy, err := q.CreateWorkflow("legacy", []WorkflowTask{{ID: "a", Command: "cmd", Arguments: []string{"--count", "-1"}}}, nil)
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+	if _, err := q.RunWorkflow(legacy.ID, "", nil); err != nil {
+		t.Errorf("legacy run: %v", err)
+	}
+}
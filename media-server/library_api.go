@@ -4,30 +4,56 @@ import (
 	"database/sql"
 	"net/http"
 	"strings"
+
+	"github.com/stevecastle/shrike/media"
+	"github.com/stevecastle/shrike/renderer"
+	"github.com/stevecastle/shrike/subtitle"
 )
 
 // -----------------------------------------------------------------------------
 // Library data API (shared across all platform mains).
 //
+//   GET  /api/media/transcript — read a transcript (?path=, ?lang= for a translation)
 //   POST /api/media/transcript — set or clear a media item's transcript
+//   POST /api/media/speakers   — list/rename a transcript's speakers
 //   POST /api/media/rating     — read/set elo, views, wins, losses
+//   POST /api/media/like       — read/set whether the requester likes an item
 //   GET  /api/tags/list        — all tags with usage counts (?category= filter)
 // -----------------------------------------------------------------------------
 
+// mediaTranscriptHandler reads and writes transcripts. GET ?path= returns the
+// original transcript plus the languages it has been translated into; adding
+// &lang= returns that translation instead (404 when there is none). POST
+// {"path","transcript"} sets the original; with "lang" it sets that
+// translation, and an empty transcript deletes it.
 func mediaTranscriptHandler(deps *Dependencies) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			httpError(w, "use POST", http.StatusMethodNotAllowed)
+		switch r.Method {
+		case http.MethodGet:
+			getMediaTranscript(deps, w, r)
+			return
+		case http.MethodPost:
+		default:
+			httpError(w, "use GET or POST", http.StatusMethodNotAllowed)
 			return
 		}
 		var req struct {
 			Path       string `json:"path"`
+			Lang       string `json:"lang"`
 			Transcript string `json:"transcript"`
 		}
 		if err := readJSON(r, &req); err != nil || req.Path == "" {
 			httpError(w, "bad request: path required", http.StatusBadRequest)
 			return
 		}
+		if !pathsAllowedForRequest(deps, r, req.Path) {
+			httpError(w, errOutsideRoots, http.StatusForbidden)
+			return
+		}
+		if req.Lang != "" {
+			setMediaTranscriptTranslation(deps, w, req.Path, req.Lang, req.Transcript)
+			return
+		}
 		res, err := deps.DB.Exec("UPDATE media SET transcript = ? WHERE path = ?", req.Transcript, req.Path)
 		if err != nil {
 			httpError(w, err.Error(), http.StatusInternalServerError)
@@ -41,8 +67,171 @@ func mediaTranscriptHandler(deps *Dependencies) http.HandlerFunc {
 	}
 }
 
-// mediaRatingHandler reads and writes the rating columns. Only fields present
-// in the request are updated; a body with just {"path": ...} reads.
+func getMediaTranscript(deps *Dependencies, w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Query().Get("path")
+	if path == "" {
+		httpError(w, "bad request: path required", http.StatusBadRequest)
+		return
+	}
+	var original sql.NullString
+	err := deps.DB.QueryRow("SELECT transcript FROM media WHERE path = ?", path).Scan(&original)
+	if err == sql.ErrNoRows {
+		httpError(w, "media not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	langs, err := media.TranscriptLanguages(deps.DB, path)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if langs == nil {
+		langs = []string{}
+	}
+	out := map[string]any{"path": path, "lang": "", "transcript": original.String, "languages": langs}
+	if raw := r.URL.Query().Get("lang"); raw != "" {
+		lang, ok := media.NormalizeLang(raw)
+		if !ok {
+			httpError(w, "bad request: invalid lang", http.StatusBadRequest)
+			return
+		}
+		t, found, err := media.GetTranscriptTranslation(deps.DB, path, lang)
+		if err != nil {
+			httpError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			httpError(w, "no "+lang+" transcript", http.StatusNotFound)
+			return
+		}
+		out["lang"], out["transcript"], out["source"], out["model"] = lang, t.Transcript, t.Source, t.Model
+	}
+	writeJSON(w, out)
+}
+
+func setMediaTranscriptTranslation(deps *Dependencies, w http.ResponseWriter, path, raw, transcript string) {
+	lang, ok := media.NormalizeLang(raw)
+	if !ok {
+		httpError(w, "bad request: invalid lang", http.StatusBadRequest)
+		return
+	}
+	var exists int
+	if err := deps.DB.QueryRow("SELECT 1 FROM media WHERE path = ?", path).Scan(&exists); err == sql.ErrNoRows {
+		httpError(w, "media not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var err error
+	if strings.TrimSpace(transcript) == "" {
+		_, err = media.DeleteTranscriptTranslation(deps.DB, path, lang)
+	} else {
+		err = media.SetTranscriptTranslation(deps.DB, path, media.TranscriptTranslation{
+			Lang: lang, Transcript: transcript, Source: media.TranslationSourceManual})
+	}
+	if err != nil {
+		httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]string{"status": "ok"})
+}
+
+// mediaSpeakersHandler lists and renames the speakers in an item's
+// diarized transcript (the WebVTT voice spans, see the subtitle package).
+// {"path": ...} reads; adding "from" and "to" renames every cue spoken by
+// from, which merges the two when to is already a speaker.
+func mediaSpeakersHandler(deps *Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			httpError(w, "use POST", http.StatusMethodNotAllowed)
+			return
+		}
+		var req struct {
+			Path string `json:"path"`
+			From string `json:"from"`
+			To   string `json:"to"`
+		}
+		if err := readJSON(r, &req); err != nil || req.Path == "" {
+			httpError(w, "bad request: path required", http.StatusBadRequest)
+			return
+		}
+		if !pathsAllowedForRequest(deps, r, req.Path) {
+			httpError(w, errOutsideRoots, http.StatusForbidden)
+			return
+		}
+		var transcript sql.NullString
+		err := deps.DB.QueryRow("SELECT transcript FROM media WHERE path = ?", req.Path).Scan(&transcript)
+		if err == sql.ErrNoRows {
+			httpError(w, "media not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			httpError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		cues := subtitle.Parse(transcript.String)
+		renamed := 0
+		if req.From != "" || req.To != "" {
+			to := strings.TrimSpace(req.To)
+			if !subtitle.ValidSpeakerName(to) {
+				httpError(w, "bad request: to must be a non-empty name without <, > or &", http.StatusBadRequest)
+				return
+			}
+			if renamed = subtitle.RenameSpeaker(cues, req.From, to); renamed == 0 {
+				httpError(w, "no cues spoken by "+req.From, http.StatusNotFound)
+				return
+			}
+			if _, err := deps.DB.Exec("UPDATE media SET transcript = ? WHERE path = ?", subtitle.FormatVTT(cues), req.Path); err != nil {
+				httpError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		speakers := subtitle.Speakers(cues)
+		if speakers == nil {
+			speakers = []string{}
+		}
+		writeJSON(w, map[string]any{"speakers": speakers, "renamed": renamed})
+	}
+}
+
+// ratingUser is whose likes and ratings r reads and writes (see
+// media/ratings.go): the signed-in account, or "" for the library-wide layer
+// the Electron viewer reads. Every account, admins included, gets its own
+// overlay unless an admin picked the library profile for it; anonymous
+// requests and share links use the library-wide layer.
+func ratingUser(deps *Dependencies, r *http.Request) string {
+	if deps.Auth == nil || requestShare(r) != nil {
+		return ""
+	}
+	username := requestUsername(deps, r)
+	if username == "" {
+		return ""
+	}
+	if library, err := deps.Auth.LibraryProfile(username); err == nil && library {
+		return ""
+	}
+	return username
+}
+
+// ratesLibrary refuses a write to the library-wide layer from a requester
+// below curator: an anonymous visitor, a share link, or a viewer given the
+// library profile. Everyone else only touches their own overlay.
+func ratesLibrary(deps *Dependencies, w http.ResponseWriter, r *http.Request, user, verb string) bool {
+	if user == "" && deps.Auth != nil && !requestPermits(deps, r, renderer.RoleCurator) {
+		httpError(w, "Sign in to "+verb+" items", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// mediaRatingHandler reads and writes the rating columns — the requester's
+// own (user_media_stats) or the library-wide ones on media, per ratingUser.
+// Only fields present in the request are updated; a body with just
+// {"path": ...} reads.
 func mediaRatingHandler(deps *Dependencies) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -60,6 +249,21 @@ func mediaRatingHandler(deps *Dependencies) http.HandlerFunc {
 			httpError(w, "bad request: path required", http.StatusBadRequest)
 			return
 		}
+		if !pathsAllowedForRequest(deps, r, req.Path) {
+			httpError(w, errOutsideRoots, http.StatusForbidden)
+			return
+		}
+
+		user := ratingUser(deps, r)
+		if req.Elo != nil || req.Views != nil || req.Wins != nil || req.Losses != nil {
+			if !ratesLibrary(deps, w, r, user, "rate") {
+				return
+			}
+		}
+		if user != "" {
+			writeUserRating(w, deps, user, req.Path, req.Elo, req.Views, req.Wins, req.Losses)
+			return
+		}
 
 		var sets []string
 		var args []any
@@ -117,6 +321,94 @@ func mediaRatingHandler(deps *Dependencies) http.HandlerFunc {
 	}
 }
 
+// writeUserRating is mediaRatingHandler for a user's own ratings. Rows are
+// created on first write; an item the user never rated reads as nulls.
+func writeUserRating(w http.ResponseWriter, deps *Dependencies, user, path string, elo *float64, views, wins, losses *int64) {
+	if elo != nil || views != nil || wins != nil || losses != nil {
+		if !mediaRowExists(deps, path) {
+			httpError(w, "media not found", http.StatusNotFound)
+			return
+		}
+		if _, err := deps.DB.Exec(`INSERT INTO user_media_stats (username, media_path, elo, views, wins, losses)
+			VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT(username, media_path) DO UPDATE SET
+				elo = COALESCE(?, elo), views = COALESCE(?, views),
+				wins = COALESCE(?, wins), losses = COALESCE(?, losses)`,
+			user, path, elo, views, wins, losses, elo, views, wins, losses); err != nil {
+			httpError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	var e sql.NullFloat64
+	var v, wn, l sql.NullInt64
+	err := deps.DB.QueryRow(
+		"SELECT elo, views, wins, losses FROM user_media_stats WHERE username = ? AND media_path = ?", user, path).
+		Scan(&e, &v, &wn, &l)
+	if err == sql.ErrNoRows {
+		if !mediaRowExists(deps, path) {
+			httpError(w, "media not found", http.StatusNotFound)
+			return
+		}
+	} else if err != nil {
+		httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	nullable := func(v any, valid bool) any {
+		if !valid {
+			return nil
+		}
+		return v
+	}
+	writeJSON(w, map[string]any{
+		"path":   path,
+		"elo":    nullable(e.Float64, e.Valid),
+		"views":  nullable(v.Int64, v.Valid),
+		"wins":   nullable(wn.Int64, wn.Valid),
+		"losses": nullable(l.Int64, l.Valid),
+	})
+}
+
+// mediaLikeHandler reads and sets whether the requester likes an item:
+// {"path"} reads, {"path", "liked"} sets. Likes are personal for every
+// account below admin, so viewers may like too; the library-wide Favorites
+// tag still takes a curator's rights, which anonymous visitors never have.
+func mediaLikeHandler(deps *Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			httpError(w, "use POST", http.StatusMethodNotAllowed)
+			return
+		}
+		var req struct {
+			Path  string `json:"path"`
+			Liked *bool  `json:"liked"`
+		}
+		if err := readJSON(r, &req); err != nil || req.Path == "" {
+			httpError(w, "bad request: path required", http.StatusBadRequest)
+			return
+		}
+		user := ratingUser(deps, r)
+		if req.Liked != nil {
+			if !ratesLibrary(deps, w, r, user, "like") {
+				return
+			}
+			if !mediaRowExists(deps, req.Path) {
+				httpError(w, "media not found", http.StatusNotFound)
+				return
+			}
+			if err := media.SetLiked(deps.DB, user, req.Path, *req.Liked); err != nil {
+				httpError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		liked, err := media.IsLiked(deps.DB, user, req.Path)
+		if err != nil {
+			httpError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]any{"path": req.Path, "liked": liked})
+	}
+}
+
 // tagsListHandler returns every tag with its category, weight, and usage count
 // (distinct media). ?category= filters to one category.
 func tagsListHandler(deps *Dependencies) http.HandlerFunc {
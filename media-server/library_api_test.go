@@ -3,6 +3,7 @@ package main
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -32,7 +33,11 @@ func newLibraryTestDeps(t *testing.T) *Dependencies {
 			outcome REAL NOT NULL DEFAULT 1,
 			winner_elo_before REAL, loser_elo_before REAL,
 			winner_elo_after REAL, loser_elo_after REAL,
-			created_at INTEGER)`,
+			created_at INTEGER, username TEXT)`,
+		`CREATE TABLE media_transcript (
+			media_path TEXT NOT NULL, lang TEXT NOT NULL, transcript TEXT NOT NULL,
+			source TEXT NOT NULL DEFAULT '', model TEXT NOT NULL DEFAULT '',
+			created_at INTEGER, PRIMARY KEY (media_path, lang))`,
 	} {
 		if _, err := db.Exec(stmt); err != nil {
 			t.Fatalf("create table: %v", err)
@@ -82,6 +87,101 @@ func TestMediaTranscript(t *testing.T) {
 	}
 }
 
+func TestMediaTranscriptTranslations(t *testing.T) {
+	deps := newLibraryTestDeps(t)
+	h := mediaTranscriptHandler(deps)
+	get := func(target string) (*httptest.ResponseRecorder, map[string]any) {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, target, nil))
+		var out map[string]any
+		json.Unmarshal(rr.Body.Bytes(), &out)
+		return rr, out
+	}
+
+	postLibraryJSON(t, h, "/api/media/transcript", `{"path":"a.jpg","transcript":"hello"}`)
+	if rr := postLibraryJSON(t, h, "/api/media/transcript", `{"path":"a.jpg","lang":"pt_BR","transcript":"olá"}`); rr.Code != http.StatusOK {
+		t.Fatalf("set translation: status = %d; body = %s", rr.Code, rr.Body.String())
+	}
+
+	_, out := get("/api/media/transcript?path=a.jpg")
+	if out["transcript"] != "hello" || out["lang"] != "" || fmt.Sprint(out["languages"]) != "[pt-br]" {
+		t.Errorf("original = %v", out)
+	}
+	_, out = get("/api/media/transcript?path=a.jpg&lang=pt-BR")
+	if out["transcript"] != "olá" || out["lang"] != "pt-br" || out["source"] != "manual" {
+		t.Errorf("translation = %v", out)
+	}
+	for target, want := range map[string]int{
+		"/api/media/transcript?path=a.jpg&lang=es":  http.StatusNotFound,
+		"/api/media/transcript?path=a.jpg&lang=x/y": http.StatusBadRequest,
+		"/api/media/transcript?path=nope.jpg":       http.StatusNotFound,
+		"/api/media/transcript":                     http.StatusBadRequest,
+	} {
+		if rr, _ := get(target); rr.Code != want {
+			t.Errorf("GET %s: status = %d, want %d", target, rr.Code, want)
+		}
+	}
+	if rr := postLibraryJSON(t, h, "/api/media/transcript", `{"path":"nope.jpg","lang":"es","transcript":"x"}`); rr.Code != http.StatusNotFound {
+		t.Errorf("translation for unknown media: status = %d, want 404", rr.Code)
+	}
+
+	// An empty transcript deletes the translation.
+	postLibraryJSON(t, h, "/api/media/transcript", `{"path":"a.jpg","lang":"pt-br","transcript":""}`)
+	if rr, _ := get("/api/media/transcript?path=a.jpg&lang=pt-br"); rr.Code != http.StatusNotFound {
+		t.Errorf("deleted translation: status = %d, want 404", rr.Code)
+	}
+}
+
+func TestMediaSpeakers(t *testing.T) {
+	deps := newLibraryTestDeps(t)
+	h := mediaSpeakersHandler(deps)
+	vtt := "WEBVTT\n\n00:00:00.000 --> 00:00:01.000\n<v Speaker 1>Hi.\n\n" +
+		"00:00:01.000 --> 00:00:02.000\n<v Speaker 2>Hello.\n\n" +
+		"00:00:02.000 --> 00:00:03.000\n<v Speaker 1>Bye.\n"
+	if _, err := deps.DB.Exec(`UPDATE media SET transcript = ? WHERE path = 'a.jpg'`, vtt); err != nil {
+		t.Fatal(err)
+	}
+	decode := func(rr *httptest.ResponseRecorder) (speakers []string, renamed int) {
+		t.Helper()
+		var out struct {
+			Speakers []string `json:"speakers"`
+			Renamed  int      `json:"renamed"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &out); err != nil {
+			t.Fatalf("decode %q: %v", rr.Body.String(), err)
+		}
+		return out.Speakers, out.Renamed
+	}
+
+	if got, _ := decode(postLibraryJSON(t, h, "/api/media/speakers", `{"path":"a.jpg"}`)); strings.Join(got, ",") != "Speaker 1,Speaker 2" {
+		t.Errorf("speakers = %v", got)
+	}
+	rr := postLibraryJSON(t, h, "/api/media/speakers", `{"path":"a.jpg","from":"speaker 1","to":"Ann"}`)
+	if got, n := decode(rr); n != 2 || strings.Join(got, ",") != "Ann,Speaker 2" {
+		t.Errorf("after rename: speakers = %v, renamed = %d", got, n)
+	}
+	var stored string
+	deps.DB.QueryRow(`SELECT transcript FROM media WHERE path = 'a.jpg'`).Scan(&stored)
+	if !strings.Contains(stored, "<v Ann>Bye.") || strings.Contains(stored, "Speaker 1") {
+		t.Errorf("stored transcript not rewritten:\n%s", stored)
+	}
+
+	for body, want := range map[string]int{
+		`{"path":"a.jpg","from":"Ann","to":"<b>"}`:    http.StatusBadRequest,
+		`{"path":"a.jpg","from":"Nobody","to":"Bob"}`: http.StatusNotFound,
+		`{"path":"nope.jpg"}`:                         http.StatusNotFound,
+		`{"from":"Ann","to":"Bob"}`:                   http.StatusBadRequest,
+	} {
+		if rr := postLibraryJSON(t, h, "/api/media/speakers", body); rr.Code != want {
+			t.Errorf("%s: status = %d, want %d", body, rr.Code, want)
+		}
+	}
+	if got, _ := decode(postLibraryJSON(t, h, "/api/media/speakers", `{"path":"b.jpg"}`)); got == nil || len(got) != 0 {
+		t.Errorf("no transcript: speakers = %#v, want []", got)
+	}
+}
+
 func TestMediaRating(t *testing.T) {
 	deps := newLibraryTestDeps(t)
 	h := mediaRatingHandler(deps)
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/stevecastle/shrike/auth"
+)
+
+// -----------------------------------------------------------------------------
+// Login throttling
+//
+// /auth/login, the CLI approve page, and the CLI token exchange share two
+// throttles: one per client address (generous, so a household behind one
+// NAT isn't punished for a typo) and one per username (tight, so a password
+// can't be guessed from many addresses). Past the free attempts each failure
+// doubles the lockout up to an hour. Failures are logged and written to the
+// audit log as auth.login_failed.
+// -----------------------------------------------------------------------------
+
+var (
+	loginIPThrottle   = auth.NewThrottle(20, 30*time.Second, time.Hour)
+	loginUserThrottle = auth.NewThrottle(5, 30*time.Second, time.Hour)
+)
+
+func loginIPKey(r *http.Request) string {
+	if ip := remoteIP(r); ip != nil {
+		return ip.String()
+	}
+	return r.RemoteAddr
+}
+
+// guessWait is how long r's client must wait before guessing at key again:
+// the longer of byIP's lockout for its address and byKey's for key ("" =
+// none yet, e.g. a token exchange).
+func guessWait(byIP, byKey *auth.Throttle, r *http.Request, key string) time.Duration {
+	wait := byIP.Wait(loginIPKey(r))
+	if key != "" {
+		if d := byKey.Wait(key); d > wait {
+			wait = d
+		}
+	}
+	return wait
+}
+
+// guessFailed records a failed guess at key against both throttles and
+// returns r's address and the lockout it now carries.
+func guessFailed(byIP, byKey *auth.Throttle, r *http.Request, key string) (ip string, lockout time.Duration) {
+	ip = loginIPKey(r)
+	lockout = byIP.Fail(ip)
+	if key != "" {
+		if d := byKey.Fail(key); d > lockout {
+			lockout = d
+		}
+	}
+	return ip, lockout
+}
+
+// loginWait is how long r's client must wait before trying username again
+// (username may be empty when there is none yet, e.g. a token exchange).
+func loginWait(r *http.Request, username string) time.Duration {
+	return guessWait(loginIPThrottle, loginUserThrottle, r, username)
+}
+
+// writeLockedOut answers a throttled attempt with 429 and Retry-After.
+func writeLockedOut(w http.ResponseWriter, wait time.Duration) {
+	secs := int((wait + time.Second - 1) / time.Second)
+	w.Header().Set("Retry-After", strconv.Itoa(secs))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusTooManyRequests)
+	fmt.Fprintf(w, `{"error":"too_many_attempts","retry_after":%d}`, secs)
+}
+
+// loginFailed records a failed attempt against r's address and username,
+// logs it, and audits it. via names the endpoint ("login", "cli_approve",
+// "cli_token"); reason is why it failed.
+func loginFailed(deps *Dependencies, r *http.Request, via, username, reason string) {
+	ip, lockout := guessFailed(loginIPThrottle, loginUserThrottle, r, username)
+	var targets []string
+	if username != "" {
+		targets = []string{"user:" + username}
+	}
+	after := map[string]any{"remote": ip, "via": via, "reason": reason}
+	if lockout > 0 {
+		after["lockout_seconds"] = int(lockout / time.Second)
+		log.Printf("[auth] failed %s for %q from %s (%s); locked out for %s", via, username, ip, reason, lockout)
+	} else {
+		log.Printf("[auth] failed %s for %q from %s (%s)", via, username, ip, reason)
+	}
+	auditLog(deps, r, "auth.login_failed", targets, nil, after)
+}
+
+// loginSucceeded clears username's failures. The address keeps its count:
+// one good account mustn't buy unlimited guesses at the others.
+func loginSucceeded(username string) {
+	loginUserThrottle.Succeed(username)
+}
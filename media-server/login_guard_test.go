@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stevecastle/shrike/auth"
+)
+
+// resetLoginThrottles clears the sign-in and share password throttles
+// before and after the test.
+func resetLoginThrottles(t *testing.T) {
+	reset := func() {
+		for _, th := range []*auth.Throttle{loginIPThrottle, loginUserThrottle, shareIPThrottle, sharePasswordThrottle} {
+			th.Reset()
+		}
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+func postLogin(deps *Dependencies, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	loginAPIHandler(deps)(rec, httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(body)))
+	return rec
+}
+
+func TestLoginLocksOutAfterRepeatedFailures(t *testing.T) {
+	deps := newSavedSearchDB(t)
+	deps.Auth = auth.NewAuthService(deps.DB, "test-secret")
+	if err := deps.Auth.Register("steve", "pw"); err != nil {
+		t.Fatal(err)
+	}
+	resetLoginThrottles(t)
+
+	for i := 0; i < 6; i++ {
+		if rec := postLogin(deps, `{"username":"steve","password":"guess"}`); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: status = %d", i+1, rec.Code)
+		}
+	}
+	// Now locked out, even with the right password.
+	rec := postLogin(deps, `{"username":"steve","password":"pw"}`)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("locked login = %d %s (Retry-After %q)", rec.Code, rec.Body, rec.Header().Get("Retry-After"))
+	}
+	// Another username from the same address isn't locked yet.
+	if rec := postLogin(deps, `{"username":"other","password":"x"}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("other user = %d", rec.Code)
+	}
+
+	failed := queryAudit(t, deps, "action=auth.login_failed&target=user:steve")
+	if len(failed) != 6 || !strings.Contains(string(failed[0].After), `"lockout_seconds":30`) {
+		t.Errorf("failed-login entries = %d, newest %s", len(failed), failed[0].After)
+	}
+}
+
+func TestLoginAndCLIApproveRequireTOTP(t *testing.T) {
+	deps := newSavedSearchDB(t)
+	deps.Auth = auth.NewAuthService(deps.DB, "test-secret")
+	if err := deps.Auth.Register("steve", "pw"); err != nil {
+		t.Fatal(err)
+	}
+	resetLoginThrottles(t)
+	tok := loginToken(t, deps)
+
+	call := func(h http.HandlerFunc, method, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/auth/totp", strings.NewReader(body))
+		req.AddCookie(&http.Cookie{Name: "auth_token", Value: tok})
+		rec := httptest.NewRecorder()
+		h(rec, req)
+		return rec
+	}
+	if rec := call(totpEnrollHandler(deps), http.MethodPost, `{"password":"nope"}`); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("enroll with wrong password = %d", rec.Code)
+	}
+	rec := call(totpEnrollHandler(deps), http.MethodPost, `{"password":"pw"}`)
+	var enroll struct{ Secret, URI string }
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &enroll) != nil || enroll.Secret == "" {
+		t.Fatalf("enroll = %d %s", rec.Code, rec.Body)
+	}
+	code, _ := auth.TOTPCode(enroll.Secret, time.Now())
+	rec = call(totpConfirmHandler(deps), http.MethodPost, `{"code":"`+code+`"}`)
+	var confirmed struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &confirmed) != nil || len(confirmed.RecoveryCodes) == 0 {
+		t.Fatalf("confirm = %d %s", rec.Code, rec.Body)
+	}
+
+	if rec := postLogin(deps, `{"username":"steve","password":"pw"}`); rec.Code != http.StatusUnauthorized ||
+		!strings.Contains(rec.Body.String(), "totp_required") {
+		t.Errorf("login without code = %d %s", rec.Code, rec.Body)
+	}
+
+	form := authorizeQuery() + "&action=approve"
+	rr := doAuthorize(t, deps, http.MethodPost, "/auth/cli/authorize", form, tok, nil)
+	if rr.Code != http.StatusUnauthorized || !strings.Contains(rr.Body.String(), `name="totp_code"`) {
+		t.Fatalf("approve without code = %d", rr.Code)
+	}
+	// The confirming step is spent; the next one is still inside the window.
+	next, _ := auth.TOTPCode(enroll.Secret, time.Now().Add(30*time.Second))
+	if rr := doAuthorize(t, deps, http.MethodPost, "/auth/cli/authorize", form+"&totp_code="+next, tok, nil); rr.Code != http.StatusSeeOther {
+		t.Fatalf("approve with code = %d %s", rr.Code, rr.Body)
+	}
+	if rr := doAuthorize(t, deps, http.MethodPost, "/auth/cli/authorize", form+"&totp_code="+next, tok, nil); rr.Code != http.StatusUnauthorized {
+		t.Errorf("replayed approve code = %d, want 401", rr.Code)
+	}
+
+	body, _ := json.Marshal(map[string]string{"username": "steve", "password": "pw", "code": confirmed.RecoveryCodes[0]})
+	if rec := postLogin(deps, string(body)); rec.Code != http.StatusOK {
+		t.Errorf("login with recovery code = %d %s", rec.Code, rec.Body)
+	}
+}
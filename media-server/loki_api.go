@@ -467,10 +467,14 @@ func sortItemsByScore(items []map[string]any, scoreByPath map[string]float32) {
 
 // isVisualPredicate reports whether p is resolved via the embedding backend —
 // the same guard the resolution loop and platform.ts use: a visual type with a
-// non-empty value.
+// non-empty value. "semantic" is text-chunk search rather than visual, but it
+// resolves the same way (a scored path set from a vector index).
 func isVisualPredicate(p Predicate) bool {
-	return (p.Type == "similar" || p.Type == "visual" || p.Type == "clip" || p.Type == "face") &&
-		p.Value != ""
+	switch p.Type {
+	case "similar", "visual", "clip", "face", "semantic":
+		return p.Value != ""
+	}
+	return false
 }
 
 // allAndPredicates reports whether every connector in the left-to-right
@@ -507,17 +511,17 @@ func allAndPredicates(preds []Predicate, mode string) bool {
 // restricted to. A broad filter can return a large set; that is the price of
 // filter-first semantics, and the subsequent vector scan only scores this set,
 // so narrow filters make the search cheaper, not costlier.
-func resolveFilterPaths(deps *Dependencies, preds []Predicate, mode string) (tasks.PathSet, error) {
+func resolveFilterPaths(db *sql.DB, preds []Predicate, mode string) (tasks.PathSet, error) {
 	sqlPreds := make([]Predicate, 0, len(preds))
 	for _, p := range preds {
 		switch p.Type {
-		case "similar", "visual", "clip", "face":
+		case "similar", "visual", "clip", "face", "semantic":
 			continue
 		}
 		sqlPreds = append(sqlPreds, p)
 	}
 	querySQL, params := BuildMediaQuery(sqlPreds, mode)
-	rows, err := deps.DB.Query(querySQL, params...)
+	rows, err := db.Query(querySQL, params...)
 	if err != nil {
 		return nil, err
 	}
@@ -538,151 +542,193 @@ func resolveFilterPaths(deps *Dependencies, preds []Predicate, mode string) (tas
 	return allow, rows.Err()
 }
 
-func lokiMediaQueryHandler(deps *Dependencies) http.HandlerFunc {
-	type queryRequest struct {
-		Predicates []Predicate `json:"predicates"`
-		Mode       string      `json:"mode"`
+// Candidate cap for visual predicates: we pull the top-N most similar paths
+// from the ANN/brute-force search, then compose them with the other SQL
+// predicates. 1000 balances recall vs. the SQL IN-list size (well under
+// SQLite's 32766 var cap). For all-AND queries the similarity scan is
+// restricted to the set matching the OTHER predicates first, so
+// `visual:x AND tag:y` returns the top-N most similar items WITHIN tag:y —
+// not tag:y intersected with the global top-N (which silently dropped
+// matches ranked beyond the cap).
+const visualCandidateLimit = 1000
+
+// resolveQueryPredicates prepares preds (in place) for BuildMediaQuery, which
+// is pure and can neither read saved searches nor call a model: saved:"name"
+// predicates are expanded into subqueries (visiting is the chain of saved
+// searches being expanded, for cycle detection), and visual predicates
+// (similar/visual/clip/face/semantic) are resolved into path sets, recording
+// each hit's best score in scoreByPath and each semantic hit's best chunk in
+// semanticByPath. Reports whether any visual predicate was present.
+func resolveQueryPredicates(ctx context.Context, db *sql.DB, preds []Predicate, mode string, visiting []string,
+	scoreByPath map[string]float32, semanticByPath map[string]tasks.SemanticHit) (bool, error) {
+	if err := expandSavedPredicates(ctx, db, preds, visiting); err != nil {
+		return false, err
+	}
+
+	// Filter-first: when every predicate is AND-composed and the query
+	// mixes visual and SQL predicates, resolve the SQL side into a path
+	// set the similarity scans are restricted to. OR/mixed chains keep
+	// resolve-then-compose — there the visual predicate stands alone in
+	// the boolean expression rather than narrowing the others.
+	hasVisualPred := false
+	hasSQLPred := false
+	for _, p := range preds {
+		if isVisualPredicate(p) {
+			hasVisualPred = true
+		} else if p.Value != "" {
+			hasSQLPred = true
+		}
 	}
-	// Candidate cap for visual predicates: we pull the top-N most similar paths
-	// from the ANN/brute-force search, then compose them with the other SQL
-	// predicates. 1000 balances recall vs. the SQL IN-list size (well under
-	// SQLite's 32766 var cap). For all-AND queries the similarity scan is
-	// restricted to the set matching the OTHER predicates first, so
-	// `visual:x AND tag:y` returns the top-N most similar items WITHIN tag:y —
-	// not tag:y intersected with the global top-N (which silently dropped
-	// matches ranked beyond the cap).
-	const visualCandidateLimit = 1000
-
-	return func(w http.ResponseWriter, r *http.Request) {
-		var req queryRequest
-		if err := readJSON(r, &req); err != nil {
-			httpError(w, "bad request", http.StatusBadRequest)
-			return
+	var allow tasks.PathSet
+	if hasVisualPred && hasSQLPred && allAndPredicates(preds, mode) {
+		var err error
+		allow, err = resolveFilterPaths(db, preds, mode)
+		if err != nil {
+			return false, err
 		}
+	}
 
-		// Filter-first: when every predicate is AND-composed and the query
-		// mixes visual and SQL predicates, resolve the SQL side into a path
-		// set the similarity scans are restricted to. OR/mixed chains keep
-		// resolve-then-compose — there the visual predicate stands alone in
-		// the boolean expression rather than narrowing the others.
-		hasVisualPred := false
-		hasSQLPred := false
-		for _, p := range req.Predicates {
-			if isVisualPredicate(p) {
-				hasVisualPred = true
-			} else if p.Value != "" {
-				hasSQLPred = true
+	hasVisual := false
+	for i := range preds {
+		pt := preds[i].Type
+		val := preds[i].Value
+		if isVisualPredicate(preds[i]) {
+			hasVisual = true
+			if allow != nil && len(allow) == 0 {
+				// The SQL filter matched nothing: the intersection is empty
+				// no matter what the scan would return, so skip the model
+				// call entirely (Resolved stays nil → clause (1=0)).
+				continue
 			}
-		}
-		var allow tasks.PathSet
-		if hasVisualPred && hasSQLPred && allAndPredicates(req.Predicates, req.Mode) {
+			var hits []tasks.SimilarHit
 			var err error
-			allow, err = resolveFilterPaths(deps, req.Predicates, req.Mode)
-			if err != nil {
-				httpError(w, err.Error(), http.StatusInternalServerError)
-				return
-			}
-		}
-
-		// Resolve visual predicates (similar/visual/clip) into path sets before
-		// BuildMediaQuery, which is pure and cannot call the model.
-		scoreByPath := map[string]float32{}
-		hasVisual := false
-		for i := range req.Predicates {
-			pt := req.Predicates[i].Type
-			val := req.Predicates[i].Value
-			if isVisualPredicate(req.Predicates[i]) {
-				hasVisual = true
-				if allow != nil && len(allow) == 0 {
-					// The SQL filter matched nothing: the intersection is empty
-					// no matter what the scan would return, so skip the model
-					// call entirely (Resolved stays nil → clause (1=0)).
-					continue
+			// An image predicate carrying text becomes a blended query: one
+			// combined vector ((1-w)*image + w*text) cosine-scanned once,
+			// rather than two independently-resolved path sets.
+			blendText := strings.TrimSpace(preds[i].Text)
+			hasNodes := len(preds[i].Nodes) > 0
+			switch pt {
+			case "similar":
+				if hasNodes {
+					var terms []tasks.QueryTerm
+					terms, err = compositeTerms(tasks.QueryTerm{Kind: "path", Value: val, Weight: 1}, preds[i])
+					if err == nil {
+						hits, err = searchComposite(ctx, db, preds[i], terms, visualCandidateLimit, allow)
+					}
+				} else if blendText != "" {
+					hits, err = tasks.SearchByPathAndText(ctx, db, val, blendText, blendTextWeight(preds[i].TextWeight), visualCandidateLimit, allow)
+				} else {
+					hits, err = tasks.SimilarByPathOrEmbed(ctx, db, tasks.ActiveEmbedModel().ID, val, visualCandidateLimit, allow)
 				}
-				var hits []tasks.SimilarHit
-				var err error
-				// An image predicate carrying text becomes a blended query: one
-				// combined vector ((1-w)*image + w*text) cosine-scanned once,
-				// rather than two independently-resolved path sets.
-				blendText := strings.TrimSpace(req.Predicates[i].Text)
-				hasNodes := len(req.Predicates[i].Nodes) > 0
-				switch pt {
-				case "similar":
+			case "clip":
+				// A captured screen region: the value is a PNG data URL.
+				var image []byte
+				if image, err = decodeImageDataURL(val); err == nil {
 					if hasNodes {
 						var terms []tasks.QueryTerm
-						terms, err = compositeTerms(tasks.QueryTerm{Kind: "path", Value: val, Weight: 1}, req.Predicates[i])
+						terms, err = compositeTerms(tasks.QueryTerm{Kind: "image", Image: image, Weight: 1}, preds[i])
 						if err == nil {
-							hits, err = searchComposite(r.Context(), deps.DB, req.Predicates[i], terms, visualCandidateLimit, allow)
+							hits, err = searchComposite(ctx, db, preds[i], terms, visualCandidateLimit, allow)
 						}
 					} else if blendText != "" {
-						hits, err = tasks.SearchByPathAndText(r.Context(), deps.DB, val, blendText, blendTextWeight(req.Predicates[i].TextWeight), visualCandidateLimit, allow)
+						hits, err = tasks.SearchByImageAndText(ctx, db, image, blendText, blendTextWeight(preds[i].TextWeight), visualCandidateLimit, allow)
 					} else {
-						hits, err = tasks.SimilarByPathOrEmbed(r.Context(), deps.DB, tasks.ActiveEmbedModel().ID, val, visualCandidateLimit, allow)
+						hits, err = tasks.SearchByImage(ctx, db, image, visualCandidateLimit, allow)
 					}
-				case "clip":
-					// A captured screen region: the value is a PNG data URL.
+				}
+			case "face":
+				// Face identity: the value is a library path ("find this
+				// person") or a captured-region PNG data URL. Matches by
+				// face embedding, collapsed to one hit per media item.
+				var faceHits []tasks.FaceHit
+				if strings.HasPrefix(val, "data:") {
 					var image []byte
 					if image, err = decodeImageDataURL(val); err == nil {
-						if hasNodes {
-							var terms []tasks.QueryTerm
-							terms, err = compositeTerms(tasks.QueryTerm{Kind: "image", Image: image, Weight: 1}, req.Predicates[i])
-							if err == nil {
-								hits, err = searchComposite(r.Context(), deps.DB, req.Predicates[i], terms, visualCandidateLimit, allow)
-							}
-						} else if blendText != "" {
-							hits, err = tasks.SearchByImageAndText(r.Context(), deps.DB, image, blendText, blendTextWeight(req.Predicates[i].TextWeight), visualCandidateLimit, allow)
-						} else {
-							hits, err = tasks.SearchByImage(r.Context(), deps.DB, image, visualCandidateLimit, allow)
-						}
-					}
-				case "face":
-					// Face identity: the value is a library path ("find this
-					// person") or a captured-region PNG data URL. Matches by
-					// face embedding, collapsed to one hit per media item.
-					var faceHits []tasks.FaceHit
-					if strings.HasPrefix(val, "data:") {
-						var image []byte
-						if image, err = decodeImageDataURL(val); err == nil {
-							faceHits, err = tasks.SearchFacesByImage(r.Context(), deps.DB, image, visualCandidateLimit, allow)
-						}
-					} else {
-						faceHits, err = tasks.SearchFacesByMediaPath(r.Context(), deps.DB, val, visualCandidateLimit, allow)
-					}
-					hits = tasks.FaceHitsToMediaHits(faceHits)
-				default: // "visual": free-text → image search, composable like similar/clip
-					if hasNodes {
-						var terms []tasks.QueryTerm
-						terms, err = compositeTerms(tasks.QueryTerm{Kind: "text", Value: val, Weight: 1}, req.Predicates[i])
-						if err == nil {
-							hits, err = searchComposite(r.Context(), deps.DB, req.Predicates[i], terms, visualCandidateLimit, allow)
-						}
-					} else {
-						hits, err = tasks.SearchByText(r.Context(), deps.DB, val, visualCandidateLimit, allow)
+						faceHits, err = tasks.SearchFacesByImage(ctx, db, image, visualCandidateLimit, allow)
 					}
+				} else {
+					faceHits, err = tasks.SearchFacesByMediaPath(ctx, db, val, visualCandidateLimit, allow)
 				}
-				if err != nil {
-					// A clip/face value can be a multi-hundred-KB data URL — log its size, not the payload.
-					logVal := val
-					if pt == "clip" || strings.HasPrefix(val, "data:") {
-						logVal = fmt.Sprintf("<%s %d bytes>", pt, len(val))
+				hits = tasks.FaceHitsToMediaHits(faceHits)
+			case "semantic":
+				// Meaning-based text search over description and
+				// transcript chunks (local sentence encoder).
+				var semHits []tasks.SemanticHit
+				semHits, err = tasks.SearchSemantic(ctx, db, val, visualCandidateLimit, allow)
+				for _, h := range semHits {
+					hits = append(hits, tasks.SimilarHit{Path: h.Path, Score: h.Score})
+					if prev, ok := semanticByPath[h.Path]; !ok || h.Score > prev.Score {
+						semanticByPath[h.Path] = h
 					}
-					log.Printf("query: %s predicate %q failed (model=%q): %v", pt, logVal, tasks.ActiveEmbedModel().ID, err)
-					httpError(w, err.Error(), http.StatusInternalServerError)
-					return
 				}
-				paths := make([]string, 0, len(hits))
-				for _, h := range hits {
-					paths = append(paths, h.Path)
-					// Merge scores with MAX so multi-predicate composites keep the best.
-					if s, ok := scoreByPath[h.Path]; !ok || h.Score > s {
-						scoreByPath[h.Path] = h.Score
+			default: // "visual": free-text → image search, composable like similar/clip
+				if hasNodes {
+					var terms []tasks.QueryTerm
+					terms, err = compositeTerms(tasks.QueryTerm{Kind: "text", Value: val, Weight: 1}, preds[i])
+					if err == nil {
+						hits, err = searchComposite(ctx, db, preds[i], terms, visualCandidateLimit, allow)
 					}
+				} else {
+					hits, err = tasks.SearchByText(ctx, db, val, visualCandidateLimit, allow)
+				}
+			}
+			if err != nil {
+				// A clip/face value can be a multi-hundred-KB data URL — log its size, not the payload.
+				logVal := val
+				if pt == "clip" || strings.HasPrefix(val, "data:") {
+					logVal = fmt.Sprintf("<%s %d bytes>", pt, len(val))
+				}
+				log.Printf("query: %s predicate %q failed (model=%q): %v", pt, logVal, tasks.ActiveEmbedModel().ID, err)
+				return false, err
+			}
+			paths := make([]string, 0, len(hits))
+			for _, h := range hits {
+				paths = append(paths, h.Path)
+				// Merge scores with MAX so multi-predicate composites keep the best.
+				if s, ok := scoreByPath[h.Path]; !ok || h.Score > s {
+					scoreByPath[h.Path] = h.Score
 				}
-				req.Predicates[i].Resolved = paths
 			}
+			preds[i].Resolved = paths
+		}
+	}
+	return hasVisual, nil
+}
+
+func lokiMediaQueryHandler(deps *Dependencies) http.HandlerFunc {
+	type queryRequest struct {
+		Predicates []Predicate `json:"predicates"`
+		Mode       string      `json:"mode"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req queryRequest
+		if err := readJSON(r, &req); err != nil {
+			httpError(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		scoreByPath := map[string]float32{}
+		// Semantic predicates also report WHERE each item matched: the best
+		// chunk's text and, for transcripts, its start offset.
+		semanticByPath := map[string]tasks.SemanticHit{}
+		hasVisual, err := resolveQueryPredicates(r.Context(), deps.DB, req.Predicates, req.Mode, nil, scoreByPath, semanticByPath)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, errSavedSearch) {
+				status = http.StatusBadRequest
+			}
+			httpError(w, err.Error(), status)
+			return
 		}
 
 		querySQL, params := BuildMediaQuery(req.Predicates, req.Mode)
+		// Every branch selects a path column and none orders, so the
+		// confinement can wrap the query whole.
+		if scope, args := rootScopeSQL(deps, r, "path"); scope != "" {
+			querySQL = "SELECT * FROM (" + querySQL + ") WHERE " + scope
+			params = append(params, args...)
+		}
 		rows, err := deps.DB.Query(querySQL, params...)
 		if err != nil {
 			httpError(w, err.Error(), http.StatusInternalServerError)
@@ -729,6 +775,18 @@ func lokiMediaQueryHandler(deps *Dependencies) http.HandlerFunc {
 			if timeStamp.Valid {
 				item["timeStamp"] = timeStamp.Float64
 			}
+			if h, ok := semanticByPath[path]; ok {
+				item["semanticText"] = h.Text
+				if h.Source == media.TextSourceTranscript {
+					off := float64(h.StartMs) / 1000
+					item["semanticOffset"] = off
+					// The viewer already seeks to timeStamp (tag hits), so a
+					// transcript match opens at the matching moment too.
+					if !timeStamp.Valid {
+						item["timeStamp"] = off
+					}
+				}
+			}
 			items = append(items, item)
 		}
 
@@ -832,12 +890,20 @@ func lokiUpdateDescriptionHandler(deps *Dependencies) http.HandlerFunc {
 			httpError(w, "bad request", http.StatusBadRequest)
 			return
 		}
+		if !pathsAllowedForRequest(deps, r, req.Path) {
+			httpError(w, errOutsideRoots, http.StatusForbidden)
+			return
+		}
 
+		var before sql.NullString
+		deps.DB.QueryRow("SELECT description FROM media WHERE path = ?", req.Path).Scan(&before)
 		_, err := deps.DB.Exec("UPDATE media SET description = ? WHERE path = ?", req.Description, req.Path)
 		if err != nil {
 			httpError(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		auditLog(deps, r, "media.describe", []string{req.Path},
+			map[string]string{"description": before.String}, map[string]string{"description": req.Description})
 		writeJSON(w, map[string]string{})
 	}
 }
@@ -1128,11 +1194,13 @@ func lokiMediaDeleteHandler(deps *Dependencies) http.HandlerFunc {
 			httpError(w, "bad request", http.StatusBadRequest)
 			return
 		}
+		tags := auditMediaTags(deps, req.Path)
 		res, err := eraseMediaReferences(r.Context(), deps, req.Path)
 		if err != nil {
 			httpError(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		auditLog(deps, r, "media.delete", []string{req.Path}, map[string]any{"tags": tags}, res)
 		writeJSON(w, res)
 	}
 }
@@ -1236,10 +1304,11 @@ func lokiMediaMoveHandler(deps *Dependencies) http.HandlerFunc {
 		if !res.DryRun && res.Items > 0 {
 			// Derived in-memory state is keyed by path: the vector index needs
 			// re-keying or similarity search keeps returning the old path, and
-			// the face index's path→keys map needs the same.
+			// the face and text-chunk indexes' path→keys maps need the same.
 			for _, p := range res.Paths {
 				tasks.IndexRenamePath(deps.DB, p.From, p.To)
 				tasks.FaceIndexRenamePath(p.From, p.To)
+				tasks.TextIndexRenamePath(deps.DB, p.From, p.To)
 			}
 			if res.Truncated {
 				// Too many rows to re-key one by one — the next rebuild is the
@@ -1250,6 +1319,9 @@ func lokiMediaMoveHandler(deps *Dependencies) http.HandlerFunc {
 			if res.Rows["face.media_path"] > 0 {
 				broadcastPeopleChanged()
 			}
+			auditLog(deps, r, "media.move", []string{req.From, req.To},
+				map[string]any{"path": req.From, "prefix": req.Prefix},
+				map[string]any{"path": req.To, "items": res.Items, "rows": res.Rows})
 		}
 		writeJSON(w, res)
 	}
@@ -1297,11 +1369,18 @@ func lokiMediaMergeMetadataHandler(deps *Dependencies) http.HandlerFunc {
 			return
 		}
 
+		before := map[string][]string{}
+		for _, src := range sources {
+			before[src] = auditMediaTags(deps, src)
+		}
 		res, err := media.MergeInto(r.Context(), deps.DB, target, sources)
 		if err != nil {
 			httpError(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		auditLog(deps, r, "media.merge", append([]string{target}, sources...), map[string]any{"tags": before}, res)
+		// The target may have inherited the sources' transcript chunks.
+		tasks.TextIndexReloadPath(deps.DB, target)
 		if res.FacesRemoved > 0 {
 			// The sources' faces were in someone's group — open People views
 			// are now showing stale counts.
@@ -1318,11 +1397,13 @@ func lokiMediaForgetHandler(deps *Dependencies) http.HandlerFunc {
 			httpError(w, "bad request", http.StatusBadRequest)
 			return
 		}
+		tags := auditMediaTags(deps, req.Path)
 		res, err := eraseMediaReferences(r.Context(), deps, req.Path)
 		if err != nil {
 			httpError(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		auditLog(deps, r, "media.forget", []string{req.Path}, map[string]any{"tags": tags}, res)
 		writeJSON(w, res)
 	}
 }
@@ -1682,6 +1763,7 @@ func lokiCreateTagHandler(deps *Dependencies) http.HandlerFunc {
 			httpError(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		auditLog(deps, r, "tag.create", []string{"tag:" + req.Label}, nil, map[string]string{"category": req.CategoryLabel})
 		writeJSON(w, map[string]string{"label": req.Label})
 	}
 }
@@ -1698,9 +1780,13 @@ func lokiRenameTagHandler(deps *Dependencies) http.HandlerFunc {
 			httpError(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		tx.Exec("UPDATE tag SET label = ? WHERE label = ?", req.NewLabel, req.Label)
-		tx.Exec("UPDATE media_tag_by_category SET tag_label = ? WHERE tag_label = ?", req.NewLabel, req.Label)
-		tx.Commit()
+		res, _ := tx.Exec("UPDATE tag SET label = ? WHERE label = ?", req.NewLabel, req.Label)
+		assigned, _ := tx.Exec("UPDATE media_tag_by_category SET tag_label = ? WHERE tag_label = ?", req.NewLabel, req.Label)
+		if err := tx.Commit(); err == nil && rowsAffected(res)+rowsAffected(assigned) > 0 {
+			auditLog(deps, r, "tag.rename", []string{"tag:" + req.Label, "tag:" + req.NewLabel},
+				map[string]string{"label": req.Label},
+				map[string]any{"label": req.NewLabel, "assignments": rowsAffected(assigned)})
+		}
 		writeJSON(w, map[string]string{})
 	}
 }
@@ -1712,7 +1798,12 @@ func lokiMoveTagHandler(deps *Dependencies) http.HandlerFunc {
 			httpError(w, "bad request", http.StatusBadRequest)
 			return
 		}
-		deps.DB.Exec("UPDATE tag SET category_label = ? WHERE label = ?", req.CategoryLabel, req.Label)
+		var before string
+		deps.DB.QueryRow("SELECT category_label FROM tag WHERE label = ?", req.Label).Scan(&before)
+		if res, err := deps.DB.Exec("UPDATE tag SET category_label = ? WHERE label = ?", req.CategoryLabel, req.Label); err == nil && rowsAffected(res) > 0 {
+			auditLog(deps, r, "tag.move", []string{"tag:" + req.Label},
+				map[string]string{"category": before}, map[string]string{"category": req.CategoryLabel})
+		}
 		writeJSON(w, map[string]string{})
 	}
 }
@@ -1729,9 +1820,14 @@ func lokiDeleteTagHandler(deps *Dependencies) http.HandlerFunc {
 			httpError(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		tx.Exec("DELETE FROM media_tag_by_category WHERE tag_label = ?", req.Label)
-		tx.Exec("DELETE FROM tag WHERE label = ?", req.Label)
-		tx.Commit()
+		var category string
+		tx.QueryRow("SELECT category_label FROM tag WHERE label = ?", req.Label).Scan(&category)
+		assigned, _ := tx.Exec("DELETE FROM media_tag_by_category WHERE tag_label = ?", req.Label)
+		res, _ := tx.Exec("DELETE FROM tag WHERE label = ?", req.Label)
+		if err := tx.Commit(); err == nil && rowsAffected(res)+rowsAffected(assigned) > 0 {
+			auditLog(deps, r, "tag.delete", []string{"tag:" + req.Label},
+				map[string]any{"category": category, "assignments": rowsAffected(assigned)}, nil)
+		}
 		// Cascade may have removed paths from the swipe pool.
 		media.InvalidateRandomSampleCache()
 		writeJSON(w, map[string]string{})
@@ -1777,6 +1873,10 @@ func lokiUpdateTimestampHandler(deps *Dependencies) http.HandlerFunc {
 			httpError(w, "bad request", http.StatusBadRequest)
 			return
 		}
+		if !pathsAllowedForRequest(deps, r, req.MediaPath) {
+			httpError(w, errOutsideRoots, http.StatusForbidden)
+			return
+		}
 		deps.DB.Exec(`UPDATE media_tag_by_category SET time_stamp = ?
 			WHERE media_path = ? AND tag_label = ? AND time_stamp = ?`,
 			req.NewTimestamp, req.MediaPath, req.TagLabel, req.OldTimestamp)
@@ -1791,6 +1891,10 @@ func lokiRemoveTimestampHandler(deps *Dependencies) http.HandlerFunc {
 			httpError(w, "bad request", http.StatusBadRequest)
 			return
 		}
+		if !pathsAllowedForRequest(deps, r, req.MediaPath) {
+			httpError(w, errOutsideRoots, http.StatusForbidden)
+			return
+		}
 		// Check if there's already a tag without timestamp
 		var count int
 		deps.DB.QueryRow(`SELECT COUNT(*) FROM media_tag_by_category
@@ -1882,6 +1986,7 @@ func lokiCreateCategoryHandler(deps *Dependencies) http.HandlerFunc {
 			httpError(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		auditLog(deps, r, "category.create", []string{"category:" + req.Label}, nil, nil)
 		writeJSON(w, map[string]string{"label": req.Label})
 	}
 }
@@ -1898,10 +2003,13 @@ func lokiRenameCategoryHandler(deps *Dependencies) http.HandlerFunc {
 			httpError(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		tx.Exec("UPDATE category SET label = ? WHERE label = ?", req.NewLabel, req.Label)
+		res, _ := tx.Exec("UPDATE category SET label = ? WHERE label = ?", req.NewLabel, req.Label)
 		tx.Exec("UPDATE tag SET category_label = ? WHERE category_label = ?", req.NewLabel, req.Label)
 		tx.Exec("UPDATE media_tag_by_category SET category_label = ? WHERE category_label = ?", req.NewLabel, req.Label)
-		tx.Commit()
+		if err := tx.Commit(); err == nil && rowsAffected(res) > 0 {
+			auditLog(deps, r, "category.rename", []string{"category:" + req.Label, "category:" + req.NewLabel},
+				map[string]string{"label": req.Label}, map[string]string{"label": req.NewLabel})
+		}
 		writeJSON(w, map[string]string{})
 	}
 }
@@ -1943,10 +2051,13 @@ func lokiDeleteCategoryHandler(deps *Dependencies) http.HandlerFunc {
 			httpError(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		tx.Exec("DELETE FROM media_tag_by_category WHERE category_label = ?", req.Label)
-		tx.Exec("DELETE FROM tag WHERE category_label = ?", req.Label)
-		tx.Exec("DELETE FROM category WHERE label = ?", req.Label)
-		tx.Commit()
+		assigned, _ := tx.Exec("DELETE FROM media_tag_by_category WHERE category_label = ?", req.Label)
+		tags, _ := tx.Exec("DELETE FROM tag WHERE category_label = ?", req.Label)
+		res, _ := tx.Exec("DELETE FROM category WHERE label = ?", req.Label)
+		if err := tx.Commit(); err == nil && rowsAffected(res) > 0 {
+			auditLog(deps, r, "category.delete", []string{"category:" + req.Label},
+				map[string]int64{"tags": rowsAffected(tags), "assignments": rowsAffected(assigned)}, nil)
+		}
 		// Cascade may have removed paths from the swipe pool.
 		media.InvalidateRandomSampleCache()
 		writeJSON(w, map[string]string{})
@@ -2029,6 +2140,10 @@ func lokiCreateAssignmentHandler(deps *Dependencies) http.HandlerFunc {
 			httpError(w, "missing required fields", http.StatusBadRequest)
 			return
 		}
+		if !pathsAllowedForRequest(deps, r, paths...) {
+			httpError(w, errOutsideRoots, http.StatusForbidden)
+			return
+		}
 		tx, err := deps.DB.Begin()
 		if err != nil {
 			httpError(w, err.Error(), http.StatusInternalServerError)
@@ -2045,7 +2160,10 @@ func lokiCreateAssignmentHandler(deps *Dependencies) http.HandlerFunc {
 				p, req.TagLabel, req.CategoryLabel, count, req.TimeStamp)
 			count++
 		}
-		tx.Commit()
+		if err := tx.Commit(); err == nil {
+			auditLog(deps, r, "tag.assign", paths, nil,
+				map[string]any{"tag": req.TagLabel, "category": req.CategoryLabel, "timeStamp": req.TimeStamp})
+		}
 		// New assignments may add previously-untagged paths to the swipe pool.
 		media.InvalidateRandomSampleCache()
 
@@ -2082,18 +2200,29 @@ func lokiDeleteAssignmentHandler(deps *Dependencies) http.HandlerFunc {
 		if len(paths) == 0 {
 			paths = []string{req.MediaPath}
 		}
+		if !pathsAllowedForRequest(deps, r, paths...) {
+			httpError(w, errOutsideRoots, http.StatusForbidden)
+			return
+		}
+		var removed int64
 		for _, p := range paths {
+			var res sql.Result
 			if timeStamp != 0 {
 				// Delete specific timestamped assignment
-				deps.DB.Exec(`DELETE FROM media_tag_by_category
+				res, _ = deps.DB.Exec(`DELETE FROM media_tag_by_category
 					WHERE media_path = ? AND tag_label = ? AND time_stamp = ?`,
 					p, tagLabel, timeStamp)
 			} else {
 				// Delete all assignments for this tag
-				deps.DB.Exec(`DELETE FROM media_tag_by_category
+				res, _ = deps.DB.Exec(`DELETE FROM media_tag_by_category
 					WHERE media_path = ? AND tag_label = ?`,
 					p, tagLabel)
 			}
+			removed += rowsAffected(res)
+		}
+		if removed > 0 {
+			auditLog(deps, r, "tag.unassign", paths,
+				map[string]any{"tag": tagLabel, "timeStamp": timeStamp, "assignments": removed}, nil)
 		}
 		// Person tags mirror face assignments: once an item no longer carries
 		// the person's tag at any timestamp, removing it means "this person is
@@ -2129,6 +2258,10 @@ func lokiUpdateAssignmentWeightHandler(deps *Dependencies) http.HandlerFunc {
 			httpError(w, "bad request", http.StatusBadRequest)
 			return
 		}
+		if !pathsAllowedForRequest(deps, r, req.MediaPath) {
+			httpError(w, errOutsideRoots, http.StatusForbidden)
+			return
+		}
 		if req.MediaTimeStamp != 0 {
 			deps.DB.Exec(`UPDATE media_tag_by_category SET weight = ?
 				WHERE media_path = ? AND tag_label = ? AND time_stamp = ?`,
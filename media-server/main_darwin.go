@@ -8,6 +8,7 @@ import (
 	"database/sql"
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
@@ -36,6 +37,7 @@ import (
 	"github.com/stevecastle/shrike/mediaext"
 	"github.com/stevecastle/shrike/platform"
 	"github.com/stevecastle/shrike/renderer"
+	"github.com/stevecastle/shrike/resultcache"
 	"github.com/stevecastle/shrike/runners"
 	"github.com/stevecastle/shrike/storage"
 	"github.com/stevecastle/shrike/stream"
@@ -148,6 +150,7 @@ func switchDatabase(newDBPath string) error {
 	// Prepare a new queue backed by the new DB
 	newQueue := jobqueue.NewQueueWithDB(newDB)
 	tasks.ApplyHostLimits(newQueue, currentConfig)
+	newQueue.SetLogLimits(currentConfig.JobLogMaxLines, currentConfig.JobLogMaxLineBytes)
 
 	// Shut down old runners first if they exist
 	if currentRunners != nil {
@@ -418,6 +421,11 @@ func createJobHandler(deps *Dependencies) http.HandlerFunc {
 
 		args = appendFieldArgs(args, req.Fields)
 
+		if !requestScopeAllows(r, auth.ScopeJobsRun+":"+cmd) {
+			http.Error(w, "this API key may not run "+cmd, http.StatusForbidden)
+			return
+		}
+
 		id, err := deps.Queue.AddWorkflow(jobqueue.Workflow{
 			Tasks: []jobqueue.WorkflowTask{
 				{
@@ -592,7 +600,7 @@ func mediaHandler(deps *Dependencies) http.HandlerFunc {
 		// Get search query from URL parameter
 		searchQuery := r.URL.Query().Get("q")
 
-		items, totalCount, hasMore, err := media.GetItems(deps.DB, 0, initialLimit, searchQuery)
+		items, totalCount, hasMore, err := media.GetItemsForUser(deps.DB, ratingUser(deps, r), 0, initialLimit, searchQuery)
 		if err != nil {
 			log.Printf("Error fetching media items: %v", err)
 			http.Error(w, "Error fetching media items", http.StatusInternalServerError)
@@ -684,7 +692,7 @@ func mediaAPIHandler(deps *Dependencies) http.HandlerFunc {
 			}
 		}
 
-		items, totalCount, hasMore, err := media.GetItems(deps.DB, offset, limit, searchQuery)
+		items, totalCount, hasMore, err := media.GetItemsForUser(deps.DB, ratingUser(deps, r), offset, limit, searchQuery)
 		if err != nil {
 			log.Printf("Error fetching media items: %v", err)
 			http.Error(w, "Error fetching media items", http.StatusInternalServerError)
@@ -829,6 +837,11 @@ func mediaTagHandler(deps *Dependencies) http.HandlerFunc {
 			return
 		}
 
+		if !pathsAllowedForRequest(deps, r, req.MediaPath) {
+			http.Error(w, errOutsideRoots, http.StatusForbidden)
+			return
+		}
+
 		var err error
 		if req.Action == "add" {
 			err = media.AddTag(deps.DB, req.MediaPath, req.TagLabel, req.CategoryLabel)
@@ -841,6 +854,13 @@ func mediaTagHandler(deps *Dependencies) http.HandlerFunc {
 			http.Error(w, "Failed to update tag", http.StatusInternalServerError)
 			return
 		}
+		if req.Action == "add" {
+			auditLog(deps, r, "tag.assign", []string{req.MediaPath}, nil,
+				map[string]any{"tag": req.TagLabel, "category": req.CategoryLabel})
+		} else {
+			auditLog(deps, r, "tag.unassign", []string{req.MediaPath},
+				map[string]any{"tag": req.TagLabel, "category": req.CategoryLabel}, nil)
+		}
 
 		w.Header().Set("Content-Type", "application/json")
 		_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
@@ -896,12 +916,9 @@ func swipeHandler(deps *Dependencies) http.HandlerFunc {
 
 		searchQuery := r.URL.Query().Get("q")
 
-		// Anonymous visitors reach this page only while Allow Public
-		// Access is on; render it view-only for them (tagging hidden).
-		canWrite := true
-		if appconfig.Get().AllowPublicAccess {
-			canWrite = isAdminRequest(deps, r)
-		}
+		// Anonymous visitors (while Allow Public Access is on) and viewer
+		// accounts get it view-only (tagging hidden).
+		canWrite := requestPermits(deps, r, renderer.RoleCurator)
 
 		data := swipeTemplateData{
 			SearchQuery: searchQuery,
@@ -963,7 +980,7 @@ func swipeAPIHandler(deps *Dependencies) http.HandlerFunc {
 		}
 		orientation := media.NormalizeOrientation(r.URL.Query().Get("orientation"))
 
-		items, hasMore, err := media.GetRandomItems(deps.DB, offset, limit, searchQuery, seed, orientation)
+		items, hasMore, err := media.GetRandomItemsForUser(deps.DB, ratingUser(deps, r), offset, limit, searchQuery, seed, orientation)
 		if err != nil {
 			log.Printf("Error fetching random media items: %v", err)
 			http.Error(w, "Error fetching media items", http.StatusInternalServerError)
@@ -1264,13 +1281,20 @@ type updateConfigRequest struct {
 	LlamaCppBaseURL      string `json:"llamacppBaseUrl"`
 	LlamaCppModel        string `json:"llamacppModel"`
 	LlamaCppAPIKey       string `json:"llamacppApiKey"`
+	OpenAIBaseURL        string `json:"openaiBaseUrl"`
+	OpenAIModel          string `json:"openaiModel"`
+	OpenAIAPIKey         string `json:"openaiApiKey"`
 	InferenceConcurrency struct {
 		Ollama   int `json:"ollama"`
 		RunPod   int `json:"runpod"`
 		LMStudio int `json:"lmstudio"`
 		LlamaCpp int `json:"llamacpp"`
+		OpenAI   int `json:"openai"`
 	} `json:"inferenceConcurrency"`
 	LocalComputeConcurrency   int     `json:"localComputeConcurrency"`
+	ResultCacheMaxMB          int     `json:"resultCacheMaxMb"`
+	JobLogMaxLines            int     `json:"jobLogMaxLines"`
+	JobLogMaxLineBytes        int     `json:"jobLogMaxLineBytes"`
 	OnnxModelPath             string  `json:"onnxModelPath"`
 	OnnxLabelsPath            string  `json:"onnxLabelsPath"`
 	OnnxConfigPath            string  `json:"onnxConfigPath"`
@@ -1296,21 +1320,35 @@ type updateConfigRequest struct {
 	FaceThreadsPerWorker      int     `json:"faceThreadsPerWorker"`
 	// nil = field absent from the POST (leave stored entries alone);
 	// an explicit empty array clears the list.
-	ByoFaceModels          []appconfig.ByoFaceModel `json:"byoFaceModels"`
-	TranscriptionProvider  string                   `json:"transcriptionProvider"`
-	TranscriptionModel     string                   `json:"transcriptionModel"`
-	TranscriptionLanguage  *string                  `json:"transcriptionLanguage"`
-	TranscriptionVADFilter *bool                    `json:"transcriptionVadFilter"`
+	ByoFaceModels          []appconfig.ByoFaceModel   `json:"byoFaceModels"`
+	ByoEmbedModels         []appconfig.ByoEmbedModel  `json:"byoEmbedModels"`
+	ByoTaggerModels        []appconfig.ByoTaggerModel `json:"byoTaggerModels"`
+	TextEmbedModel         *appconfig.TextEmbedModel  `json:"textEmbedModel"`   // semantic: search encoder; nil = absent
+	LLMTagCategories       []appconfig.LLMTagCategory `json:"llmTagCategories"` // llm-tag op schema; nil = absent
+	TranscriptionProvider  string                     `json:"transcriptionProvider"`
+	TranscriptionModel     string                     `json:"transcriptionModel"`
+	TranscriptionLanguage  *string                    `json:"transcriptionLanguage"`
+	TranscriptionVADFilter *bool                      `json:"transcriptionVadFilter"`
 	// Pointers so clearing the prompt/hotwords or unchecking vocal
 	// extraction persists, while partial POSTs leave them alone.
-	TranscriptionInitialPrompt *string                 `json:"transcriptionInitialPrompt"`
-	TranscriptionHotwords      *string                 `json:"transcriptionHotwords"`
-	TranscriptionVocalExtract  *bool                   `json:"transcriptionVocalExtract"`
-	AllowPublicAccess          *bool                   `json:"allowPublicAccess"`
-	DefaultStartPath           *string                 `json:"defaultStartPath"`
-	FasterWhisperPath          string                  `json:"fasterWhisperPath"`
-	DiscordToken               string                  `json:"discordToken"`
-	Roots                      []appconfig.StorageRoot `json:"roots"`
+	TranscriptionInitialPrompt    *string                 `json:"transcriptionInitialPrompt"`
+	TranscriptionHotwords         *string                 `json:"transcriptionHotwords"`
+	TranscriptionVocalExtract     *bool                   `json:"transcriptionVocalExtract"`
+	TranscriptionOpenAIBaseURL    string                  `json:"transcriptionOpenaiBaseUrl"`
+	TranscriptionOpenAIAPIKey     string                  `json:"transcriptionOpenaiApiKey"`
+	TranscriptionWhisperServerURL string                  `json:"transcriptionWhisperServerUrl"`
+	TranscriptionHTTPConcurrency  int                     `json:"transcriptionHttpConcurrency"`
+	TranscriptionDiarize          *bool                   `json:"transcriptionDiarize"`
+	DiarizationModelPath          *string                 `json:"diarizationModelPath"` // "" clears
+	DiarizationThreshold          float64                 `json:"diarizationThreshold"`
+	AllowPublicAccess             *bool                   `json:"allowPublicAccess"`
+	AuditRetentionDays            *int                    `json:"auditRetentionDays"`
+	SSO                           *appconfig.SSOConfig    `json:"sso"`
+	DefaultStartPath              *string                 `json:"defaultStartPath"`
+	ModelMirror                   *string                 `json:"modelMirror"`
+	FasterWhisperPath             string                  `json:"fasterWhisperPath"`
+	DiscordToken                  string                  `json:"discordToken"`
+	Roots                         []appconfig.StorageRoot `json:"roots"`
 }
 
 // -----------------------------------------------------------------------------
@@ -1540,6 +1578,15 @@ func configHandler(deps *Dependencies) http.HandlerFunc {
 			if v := keepStoredIfRedacted(req.LlamaCppAPIKey, newCfg.LlamaCppAPIKey); v != "" {
 				newCfg.LlamaCppAPIKey = v
 			}
+			if v := strings.TrimSpace(req.OpenAIBaseURL); v != "" {
+				newCfg.OpenAIBaseURL = v
+			}
+			if v := strings.TrimSpace(req.OpenAIModel); v != "" {
+				newCfg.OpenAIModel = v
+			}
+			if v := keepStoredIfRedacted(req.OpenAIAPIKey, newCfg.OpenAIAPIKey); v != "" {
+				newCfg.OpenAIAPIKey = v
+			}
 			if req.InferenceConcurrency.Ollama > 0 {
 				newCfg.InferenceConcurrency.Ollama = req.InferenceConcurrency.Ollama
 			}
@@ -1552,9 +1599,22 @@ func configHandler(deps *Dependencies) http.HandlerFunc {
 			if req.InferenceConcurrency.LlamaCpp > 0 {
 				newCfg.InferenceConcurrency.LlamaCpp = req.InferenceConcurrency.LlamaCpp
 			}
+			if req.InferenceConcurrency.OpenAI > 0 {
+				newCfg.InferenceConcurrency.OpenAI = req.InferenceConcurrency.OpenAI
+			}
 			if req.LocalComputeConcurrency > 0 {
 				newCfg.LocalComputeConcurrency = req.LocalComputeConcurrency
 			}
+			// Negative is meaningful (cache off), so only 0 means "absent".
+			if req.ResultCacheMaxMB != 0 {
+				newCfg.ResultCacheMaxMB = req.ResultCacheMaxMB
+			}
+			if req.JobLogMaxLines > 0 {
+				newCfg.JobLogMaxLines = req.JobLogMaxLines
+			}
+			if req.JobLogMaxLineBytes > 0 {
+				newCfg.JobLogMaxLineBytes = req.JobLogMaxLineBytes
+			}
 			newCfg.OnnxTagger.ModelPath = strings.TrimSpace(req.OnnxModelPath)
 			newCfg.OnnxTagger.LabelsPath = strings.TrimSpace(req.OnnxLabelsPath)
 			newCfg.OnnxTagger.ConfigPath = strings.TrimSpace(req.OnnxConfigPath)
@@ -1622,6 +1682,32 @@ func configHandler(deps *Dependencies) http.HandlerFunc {
 			if req.ByoFaceModels != nil {
 				newCfg.ByoFaceModels = req.ByoFaceModels
 			}
+			if req.ByoEmbedModels != nil {
+				newCfg.ByoEmbedModels = req.ByoEmbedModels
+			}
+			if req.ByoTaggerModels != nil {
+				newCfg.ByoTaggerModels = req.ByoTaggerModels
+			}
+			if req.LLMTagCategories != nil {
+				newCfg.LLMTagCategories = req.LLMTagCategories
+			}
+			if req.TextEmbedModel != nil {
+				newCfg.TextEmbedModel = *req.TextEmbedModel
+			}
+			// Reject malformed BYO entries up front (only the lists in this
+			// POST, so a stale stored entry can't block unrelated saves).
+			check := appconfig.Config{
+				ByoFaceModels:   req.ByoFaceModels,
+				ByoEmbedModels:  req.ByoEmbedModels,
+				ByoTaggerModels: req.ByoTaggerModels,
+			}
+			if req.TextEmbedModel != nil {
+				check.TextEmbedModel = *req.TextEmbedModel
+			}
+			if problems := appconfig.ValidateByoModels(check); len(problems) > 0 {
+				http.Error(w, "invalid bring-your-own model: "+strings.Join(problems, "; "), http.StatusBadRequest)
+				return
+			}
 			// Transcription: provider/model use the protective non-empty
 			// pattern; language and VAD are pointers so an explicit empty
 			// language ("auto-detect") or unchecked VAD box persists, while
@@ -1647,12 +1733,55 @@ func configHandler(deps *Dependencies) http.HandlerFunc {
 			if req.TranscriptionVocalExtract != nil {
 				newCfg.TranscriptionVocalExtract = *req.TranscriptionVocalExtract
 			}
+			if v := strings.TrimSpace(req.TranscriptionOpenAIBaseURL); v != "" {
+				newCfg.TranscriptionOpenAIBaseURL = v
+			}
+			if v := keepStoredIfRedacted(req.TranscriptionOpenAIAPIKey, newCfg.TranscriptionOpenAIAPIKey); v != "" {
+				newCfg.TranscriptionOpenAIAPIKey = v
+			}
+			if v := strings.TrimSpace(req.TranscriptionWhisperServerURL); v != "" {
+				newCfg.TranscriptionWhisperServerURL = v
+			}
+			if req.TranscriptionHTTPConcurrency > 0 {
+				newCfg.TranscriptionHTTPConcurrency = req.TranscriptionHTTPConcurrency
+			}
+			if req.TranscriptionDiarize != nil {
+				newCfg.TranscriptionDiarize = *req.TranscriptionDiarize
+			}
+			if req.DiarizationModelPath != nil {
+				newCfg.DiarizationModelPath = strings.TrimSpace(*req.DiarizationModelPath)
+			}
+			if req.DiarizationThreshold != 0 {
+				if req.DiarizationThreshold < 0 || req.DiarizationThreshold >= 1 {
+					http.Error(w, "diarizationThreshold must be between 0 and 1", http.StatusBadRequest)
+					return
+				}
+				newCfg.DiarizationThreshold = req.DiarizationThreshold
+			}
 			if req.AllowPublicAccess != nil {
 				newCfg.AllowPublicAccess = *req.AllowPublicAccess
 			}
+			if req.AuditRetentionDays != nil {
+				if *req.AuditRetentionDays < 0 {
+					http.Error(w, "auditRetentionDays must be 0 (keep forever) or more", http.StatusBadRequest)
+					return
+				}
+				newCfg.AuditRetentionDays = *req.AuditRetentionDays
+			}
+			if req.SSO != nil {
+				sso, err := mergeSSOConfig(*req.SSO, currentConfig.SSO)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				newCfg.SSO = sso
+			}
 			if req.DefaultStartPath != nil {
 				newCfg.DefaultStartPath = strings.TrimSpace(*req.DefaultStartPath)
 			}
+			if req.ModelMirror != nil {
+				newCfg.ModelMirror = strings.TrimSpace(*req.ModelMirror)
+			}
 			if strings.TrimSpace(req.FasterWhisperPath) != "" {
 				newCfg.FasterWhisperPath = strings.TrimSpace(req.FasterWhisperPath)
 			}
@@ -1670,6 +1799,9 @@ func configHandler(deps *Dependencies) http.HandlerFunc {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
 			}
+			if before, after := configChanges(currentConfig, newCfg); len(after) > 0 {
+				auditLog(deps, r, "config.update", []string{"config"}, before, after)
+			}
 
 			dbChanged := req.DBPath != oldDBPath
 			if dbChanged {
@@ -1752,6 +1884,7 @@ func configHandler(deps *Dependencies) http.HandlerFunc {
 			// immediately. Cheap idempotent operation â€” only future ClaimJob
 			// calls consult the new value; in-flight jobs are untouched.
 			tasks.ApplyHostLimits(deps.Queue, newCfg)
+			deps.Queue.SetLogLimits(newCfg.JobLogMaxLines, newCfg.JobLogMaxLineBytes)
 
 			// Determine if any config field actually changed
 			changed := !reflect.DeepEqual(oldCfg, newCfg)
@@ -2072,6 +2205,10 @@ func ParseCommand(input string) []string {
 
 func authMiddleware(deps *Dependencies, next http.Handler, requiredRole renderer.AuthRole) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A trusted reverse proxy's user header becomes a session cookie
+		// before anything below looks for credentials.
+		r = trustedHeaderSession(deps, w, r)
+
 		if requiredRole == renderer.RolePublic {
 			next.ServeHTTP(w, r)
 			return
@@ -2085,10 +2222,19 @@ func authMiddleware(deps *Dependencies, next http.Handler, requiredRole renderer
 			return
 		}
 
+		// A share link opens its own items on the media routes to anyone
+		// holding it (see shareRequest).
+		if requiredRole == renderer.RolePublicRead {
+			if sr, ok := shareRequest(deps, r); ok {
+				next.ServeHTTP(w, sr)
+				return
+			}
+		}
+
 		// Check header credentials first (Authorization Bearer JWT or lk_
 		// API key, or the X-API-Key header)
 		if tokenString := requestAuthToken(r); tokenString != "" {
-			if claims, err := verifyCredential(deps, tokenString); err == nil {
+			if claims, err := verifyCredential(deps, r, tokenString); err == nil {
 				// Check if user setup is required (logged in as default admin)
 				if claims.Username == auth.DefaultAdminUsername {
 					setupRequired, _ := deps.Auth.IsSetupRequired()
@@ -2103,7 +2249,10 @@ func authMiddleware(deps *Dependencies, next http.Handler, requiredRole renderer
 						return
 					}
 				}
-				next.ServeHTTP(w, r)
+				if denyByRole(deps, w, r, claims.Username, requiredRole) || denyByScope(w, r, claims, requiredRole) {
+					return
+				}
+				next.ServeHTTP(w, withKeyScopes(r, claims))
 				return
 			}
 		}
@@ -2145,8 +2294,11 @@ func authMiddleware(deps *Dependencies, next http.Handler, requiredRole renderer
 			}
 		}
 
-		// For now, assume all authenticated users have Admin role
-		// In the future, we would check the role from the token/claims
+		// The account's role must reach the route's: viewers get read
+		// routes, curators add curation, admins everything (renderer.Permits).
+		if denyByRole(deps, w, r, claims.Username, requiredRole) {
+			return
+		}
 		next.ServeHTTP(w, r)
 	})
 }
@@ -2202,17 +2354,33 @@ func loginAPIHandler(deps *Dependencies) http.HandlerFunc {
 		var creds struct {
 			Username string `json:"username"`
 			Password string `json:"password"`
+			Code     string `json:"code"` // authenticator or recovery code, when TOTP is on
 		}
 		if err := json.NewDecoder(r.Body).Decode(&creds); err != nil {
 			http.Error(w, "Invalid JSON", http.StatusBadRequest)
 			return
 		}
+		if wait := loginWait(r, creds.Username); wait > 0 {
+			writeLockedOut(w, wait)
+			return
+		}
 
-		token, err := deps.Auth.Login(creds.Username, creds.Password)
-		if err != nil {
+		token, err := deps.Auth.LoginWithCode(creds.Username, creds.Password, creds.Code)
+		switch {
+		case errors.Is(err, auth.ErrTOTPRequired):
+			// Right password; the client should prompt for a code and retry.
+			http.Error(w, `{"error":"totp_required"}`, http.StatusUnauthorized)
+			return
+		case errors.Is(err, auth.ErrInvalidCode):
+			loginFailed(deps, r, "login", creds.Username, "invalid code")
+			http.Error(w, `{"error":"Invalid authentication code"}`, http.StatusUnauthorized)
+			return
+		case err != nil:
+			loginFailed(deps, r, "login", creds.Username, "invalid credentials")
 			http.Error(w, `{"error":"Invalid credentials"}`, http.StatusUnauthorized)
 			return
 		}
+		loginSucceeded(creds.Username)
 
 		http.SetCookie(w, &http.Cookie{
 			Name:     "auth_token",
@@ -2286,11 +2454,12 @@ func authStatusHandler(deps *Dependencies) http.HandlerFunc {
 		// Check header credentials first (Authorization Bearer JWT or lk_
 		// API key, or the X-API-Key header)
 		if tokenString := requestAuthToken(r); tokenString != "" {
-			if claims, err := verifyCredential(deps, tokenString); err == nil {
+			if claims, err := verifyCredential(deps, r, tokenString); err == nil {
 				w.Header().Set("Content-Type", "application/json")
 				json.NewEncoder(w).Encode(map[string]interface{}{
 					"loggedIn":         true,
 					"username":         claims.Username,
+					"role":             accountRole(deps, claims.Username),
 					"publicAccess":     appconfig.Get().AllowPublicAccess,
 					"defaultStartPath": appconfig.Get().DefaultStartPath,
 				})
@@ -2322,24 +2491,21 @@ func authStatusHandler(deps *Dependencies) http.HandlerFunc {
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"loggedIn":         true,
 			"username":         claims.Username,
+			"role":             accountRole(deps, claims.Username),
 			"publicAccess":     appconfig.Get().AllowPublicAccess,
 			"defaultStartPath": appconfig.Get().DefaultStartPath,
 		})
 	}
 }
 
-// streamHandler wraps the stream handler with CORS headers (public endpoint)
-func streamHandler() http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		// Set CORS headers for SSE
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Headers", "Cache-Control")
-		stream.StreamHandler(w, r)
-	}
-}
-
 func userManagementHandler(deps *Dependencies) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		// Listing, editing, and deleting accounts is admin-only; creation
+		// has its own first-run exception below.
+		if r.Method != http.MethodPost && !isAdminRequest(deps, r) {
+			http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+			return
+		}
 		switch r.Method {
 		case http.MethodGet:
 			users, err := deps.Auth.ListUsers()
@@ -2353,16 +2519,17 @@ func userManagementHandler(deps *Dependencies) http.HandlerFunc {
 		case http.MethodPost:
 			// First-account creation is open (the setup wizard and Electron
 			// onboarding run before any credential exists); once a real user
-			// exists, only an authenticated caller may create more.
-			if setupRequired, _ := deps.Auth.IsSetupRequired(); !setupRequired {
-				if !setupAuthed(deps, r) {
-					http.Error(w, `{"error":"unauthorized"}`, http.StatusForbidden)
-					return
-				}
+			// exists, only an admin may create more.
+			setupRequired, _ := deps.Auth.IsSetupRequired()
+			if !setupRequired && !isAdminRequest(deps, r) {
+				http.Error(w, `{"error":"unauthorized"}`, http.StatusForbidden)
+				return
 			}
 			var req struct {
-				Username string `json:"username"`
-				Password string `json:"password"`
+				Username string   `json:"username"`
+				Password string   `json:"password"`
+				Role     string   `json:"role"`
+				Roots    []string `json:"roots"`
 			}
 			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 				http.Error(w, "Invalid JSON", http.StatusBadRequest)
@@ -2372,13 +2539,89 @@ func userManagementHandler(deps *Dependencies) http.HandlerFunc {
 				http.Error(w, "Username and password required", http.StatusBadRequest)
 				return
 			}
-			if err := deps.Auth.Register(req.Username, req.Password); err != nil {
+			role, err := auth.ParseRole(req.Role)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			// The first real account replaces the bootstrap admin, so it
+			// must be an unconfined admin itself.
+			if setupRequired {
+				role, req.Roots = auth.RoleAdmin, nil
+			}
+			if err := deps.Auth.RegisterWithAccess(req.Username, req.Password, auth.Access{Role: role, Roots: req.Roots}); err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
 			}
+			auditLog(deps, r, "user.create", []string{"user:" + req.Username}, nil,
+				map[string]any{"role": role, "roots": req.Roots})
 			w.WriteHeader(http.StatusCreated)
 			w.Write([]byte(`{"status":"created"}`))
 
+		case http.MethodPut:
+			// Change an account's role, root allow-list, linked SSO identity,
+			// and/or rating profile; omitted fields are left alone, roots []
+			// lifts the confinement, and ssoSubject "" unlinks.
+			var req struct {
+				Username       string   `json:"username"`
+				Role           *string  `json:"role"`
+				Roots          []string `json:"roots"`
+				SSOSubject     *string  `json:"ssoSubject"`
+				LibraryProfile *bool    `json:"libraryProfile"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "Invalid JSON", http.StatusBadRequest)
+				return
+			}
+			if req.Username == "" {
+				http.Error(w, "Username required", http.StatusBadRequest)
+				return
+			}
+			var role *auth.Role
+			if req.Role != nil {
+				rl := auth.Role(*req.Role)
+				role = &rl
+			}
+			before, _ := deps.Auth.UserAccess(req.Username)
+			if err := deps.Auth.UpdateUserAccess(req.Username, role, req.Roots); err != nil {
+				status := http.StatusInternalServerError
+				switch {
+				case errors.Is(err, auth.ErrUserNotFound):
+					status = http.StatusNotFound
+				case errors.Is(err, auth.ErrLastAdmin), errors.Is(err, auth.ErrInvalidRole):
+					status = http.StatusBadRequest
+				}
+				http.Error(w, err.Error(), status)
+				return
+			}
+			if req.SSOSubject != nil {
+				if err := deps.Auth.SetSSOSubject(req.Username, *req.SSOSubject); err != nil {
+					status := http.StatusBadRequest
+					if errors.Is(err, auth.ErrUserNotFound) {
+						status = http.StatusNotFound
+					}
+					http.Error(w, err.Error(), status)
+					return
+				}
+				auditLog(deps, r, "user.sso_link", []string{"user:" + req.Username}, nil,
+					map[string]any{"ssoSubject": strings.TrimSpace(*req.SSOSubject)})
+			}
+			if req.LibraryProfile != nil {
+				if err := deps.Auth.SetLibraryProfile(req.Username, *req.LibraryProfile); err != nil {
+					status := http.StatusInternalServerError
+					if errors.Is(err, auth.ErrUserNotFound) {
+						status = http.StatusNotFound
+					}
+					http.Error(w, err.Error(), status)
+					return
+				}
+				auditLog(deps, r, "user.library_profile", []string{"user:" + req.Username}, nil,
+					map[string]any{"libraryProfile": *req.LibraryProfile})
+			}
+			after, _ := deps.Auth.UserAccess(req.Username)
+			auditLog(deps, r, "user.update", []string{"user:" + req.Username}, before, after)
+			w.Write([]byte(`{"status":"updated"}`))
+
 		case http.MethodDelete:
 			username := r.URL.Query().Get("username")
 			if username == "" {
@@ -2389,6 +2632,10 @@ func userManagementHandler(deps *Dependencies) http.HandlerFunc {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
 			}
+			if err := media.DeleteUserRatings(deps.DB, username); err != nil {
+				log.Printf("Error deleting ratings for %s: %v", username, err)
+			}
+			auditLog(deps, r, "user.delete", []string{"user:" + username}, nil, nil)
 			w.Write([]byte(`{"status":"deleted"}`))
 
 		default:
@@ -2421,6 +2668,7 @@ func main() {
 	queue := jobqueue.NewQueueWithDB(db)
 	log.Printf("Job queue initialized. Current jobs: %d", len(queue.GetJobs()))
 	tasks.ApplyHostLimits(queue, currentConfig)
+	queue.SetLogLimits(currentConfig.JobLogMaxLines, currentConfig.JobLogMaxLineBytes)
 	currentRunners = runners.New(queue)
 
 	// â€“â€“â€“ auth service â€“â€“â€“
@@ -2446,6 +2694,13 @@ func main() {
 	// Background idle scheduler (mode/config-gated; dormant when off). Reads
 	// deps.Queue on every tick, so it follows database switches transparently.
 	startAutoScheduler(deps)
+	startAuditPruner(deps)
+
+	// Content-addressed inference result cache (best-effort, non-fatal):
+	// without it every describe/transcribe simply runs inference.
+	if err := resultcache.Open(platform.GetDataDir()); err != nil {
+		log.Printf("Warning: inference result cache disabled: %v", err)
+	}
 
 	// â€“â€“â€“ embedding vector index (best-effort, non-fatal) â€“â€“â€“
 	log.Printf("Building embedding search indexâ€¦")
@@ -2470,6 +2725,8 @@ func main() {
 	mux.HandleFunc("/job/{id}/cancel", renderer.ApplyMiddlewares(cancelHandler(deps), renderer.RoleAdmin))
 	mux.HandleFunc("/job/{id}/pause", renderer.ApplyMiddlewares(pauseJobHandler(deps), renderer.RoleAdmin))
 	mux.HandleFunc("/job/{id}/resume", renderer.ApplyMiddlewares(resumeJobHandler(deps), renderer.RoleAdmin))
+	mux.HandleFunc("/job/{id}/logs", renderer.ApplyMiddlewares(jobLogsHandler(deps), renderer.RoleAdmin))
+	mux.HandleFunc("/job/{id}/logs/follow", renderer.ApplyMiddlewares(jobLogsFollowHandler(deps), renderer.RoleAdmin))
 	mux.HandleFunc("/api/scheduler", renderer.ApplyMiddlewares(schedulerStatusHandler(deps), renderer.RoleAdmin))
 	mux.HandleFunc("/api/scheduler/mode", renderer.ApplyMiddlewares(schedulerModeHandler(deps), renderer.RoleAdmin))
 	mux.HandleFunc("/api/scheduler/run", renderer.ApplyMiddlewares(schedulerRunHandler(deps), renderer.RoleAdmin))
@@ -2477,7 +2734,7 @@ func main() {
 	mux.HandleFunc("/job/{id}/remove", renderer.ApplyMiddlewares(removeHandler(deps), renderer.RoleAdmin))
 	mux.HandleFunc("/jobs/clear", renderer.ApplyMiddlewares(clearNonRunningJobsHandler(deps), renderer.RoleAdmin))
 	mux.HandleFunc("/api/jobs/for-path", renderer.ApplyMiddlewares(jobsForPathHandler(deps), renderer.RoleAdmin))
-	mux.HandleFunc("/stream", streamHandler())
+	mux.HandleFunc("/stream", streamRoute(deps))
 	mux.HandleFunc("/health", healthHandler(deps))
 	mux.HandleFunc("/create", renderer.ApplyMiddlewares(createJobHandler(deps), renderer.RoleAdmin))
 	mux.HandleFunc("/media", renderer.ApplyMiddlewares(mediaHandler(deps), renderer.RoleAdmin))
@@ -2487,7 +2744,7 @@ func main() {
 	mux.HandleFunc("/media/hls", renderer.ApplyMiddlewares(hlsHandler(deps), renderer.RolePublicRead))
 	mux.HandleFunc("/media/hls/", renderer.ApplyMiddlewares(hlsSegmentHandler(deps), renderer.RolePublicRead))
 	mux.HandleFunc("/media/suggest", renderer.ApplyMiddlewares(mediaSuggestHandler(deps), renderer.RolePublicRead))
-	mux.HandleFunc("/media/tag", renderer.ApplyMiddlewares(mediaTagHandler(deps), renderer.RoleAdmin))
+	mux.HandleFunc("/media/tag", renderer.ApplyMiddlewares(mediaTagHandler(deps), renderer.RoleCurator))
 	mux.HandleFunc("/media/has-tag", renderer.ApplyMiddlewares(mediaHasTagHandler(deps), renderer.RolePublicRead))
 	mux.HandleFunc("/swipe", renderer.ApplyMiddlewares(swipeHandler(deps), renderer.RolePublicRead))
 	mux.HandleFunc("/swipe/api", renderer.ApplyMiddlewares(swipeAPIHandler(deps), renderer.RolePublicRead))
@@ -2528,9 +2785,21 @@ func main() {
 	mux.HandleFunc("/api/embeddings", renderer.ApplyMiddlewares(embeddingsHandler(deps), renderer.RoleAdmin))
 	mux.HandleFunc("/api/embeddings/prune", renderer.ApplyMiddlewares(embeddingsPruneHandler(deps), renderer.RoleAdmin))
 	mux.HandleFunc("/api/embeddings/all", renderer.ApplyMiddlewares(embeddingsWipeHandler(deps), renderer.RoleAdmin))
-	mux.HandleFunc("/api/media/transcript", renderer.ApplyMiddlewares(mediaTranscriptHandler(deps), renderer.RoleAdmin))
-	mux.HandleFunc("/api/media/rating", renderer.ApplyMiddlewares(mediaRatingHandler(deps), renderer.RoleAdmin))
-	mux.HandleFunc("/api/media/battle", renderer.ApplyMiddlewares(mediaBattleHandler(deps), renderer.RoleAdmin))
+	mux.HandleFunc("/api/result-cache", renderer.ApplyMiddlewares(resultCacheHandler(), renderer.RoleAdmin))
+	// Theme browsing is read-only, so it stays available in public view mode
+	// like the People list.
+	mux.HandleFunc("/api/clusters", renderer.ApplyMiddlewares(clustersHandler(deps), renderer.RolePublicRead))
+	RegisterSavedSearchRoutes(mux, deps)
+	RegisterCollectionRoutes(mux, deps)
+	RegisterShareRoutes(mux, deps)
+	RegisterAuditRoutes(mux, deps)
+	RegisterTOTPRoutes(mux, deps)
+	RegisterSSORoutes(mux, deps)
+	mux.HandleFunc("/api/media/transcript", renderer.ApplyMiddlewares(mediaTranscriptHandler(deps), renderer.RoleCurator))
+	mux.HandleFunc("/api/media/speakers", renderer.ApplyMiddlewares(mediaSpeakersHandler(deps), renderer.RoleCurator))
+	mux.HandleFunc("/api/media/rating", renderer.ApplyMiddlewares(mediaRatingHandler(deps), renderer.RolePublicRead))
+	mux.HandleFunc("/api/media/like", renderer.ApplyMiddlewares(mediaLikeHandler(deps), renderer.RolePublicRead))
+	mux.HandleFunc("/api/media/battle", renderer.ApplyMiddlewares(mediaBattleHandler(deps), renderer.RolePublicRead))
 	mux.HandleFunc("/api/tags/list", renderer.ApplyMiddlewares(tagsListHandler(deps), renderer.RolePublicRead))
 
 	// Auth routes
@@ -2556,7 +2825,7 @@ func main() {
 	mux.HandleFunc("/api/media/query", renderer.ApplyMiddlewares(lokiMediaQueryHandler(deps), renderer.RolePublicRead))
 	mux.HandleFunc("/api/media/metadata", renderer.ApplyMiddlewares(lokiMediaMetadataHandler(deps), renderer.RolePublicRead))
 	mux.HandleFunc("/api/media/tags", renderer.ApplyMiddlewares(lokiMediaTagsHandler(deps), renderer.RolePublicRead))
-	mux.HandleFunc("/api/media/description", renderer.ApplyMiddlewares(lokiUpdateDescriptionHandler(deps), renderer.RoleAdmin))
+	mux.HandleFunc("/api/media/description", renderer.ApplyMiddlewares(lokiUpdateDescriptionHandler(deps), renderer.RoleCurator))
 	mux.HandleFunc("/api/media/preview", renderer.ApplyMiddlewares(lokiMediaPreviewHandler(deps), renderer.RolePublicRead))
 	mux.HandleFunc("/api/media/delete", renderer.ApplyMiddlewares(lokiMediaDeleteHandler(deps), renderer.RoleAdmin))
 	mux.HandleFunc("/api/media/merge-metadata", renderer.ApplyMiddlewares(lokiMediaMergeMetadataHandler(deps), renderer.RoleAdmin))
@@ -2580,11 +2849,11 @@ func main() {
 		case http.MethodDelete:
 			lokiDeleteTagHandler(deps)(w, r)
 		}
-	}, renderer.RoleAdmin))
-	mux.HandleFunc("/api/tags/rename", renderer.ApplyMiddlewares(lokiRenameTagHandler(deps), renderer.RoleAdmin))
-	mux.HandleFunc("/api/tags/move", renderer.ApplyMiddlewares(lokiMoveTagHandler(deps), renderer.RoleAdmin))
-	mux.HandleFunc("/api/tags/order", renderer.ApplyMiddlewares(lokiOrderTagsHandler(deps), renderer.RoleAdmin))
-	mux.HandleFunc("/api/tags/weight", renderer.ApplyMiddlewares(lokiUpdateTagWeightHandler(deps), renderer.RoleAdmin))
+	}, renderer.RoleCurator))
+	mux.HandleFunc("/api/tags/rename", renderer.ApplyMiddlewares(lokiRenameTagHandler(deps), renderer.RoleCurator))
+	mux.HandleFunc("/api/tags/move", renderer.ApplyMiddlewares(lokiMoveTagHandler(deps), renderer.RoleCurator))
+	mux.HandleFunc("/api/tags/order", renderer.ApplyMiddlewares(lokiOrderTagsHandler(deps), renderer.RoleCurator))
+	mux.HandleFunc("/api/tags/weight", renderer.ApplyMiddlewares(lokiUpdateTagWeightHandler(deps), renderer.RoleCurator))
 	mux.HandleFunc("/api/tags/timestamp", renderer.ApplyMiddlewares(func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodPut:
@@ -2592,7 +2861,7 @@ func main() {
 		case http.MethodDelete:
 			lokiRemoveTimestampHandler(deps)(w, r)
 		}
-	}, renderer.RoleAdmin))
+	}, renderer.RoleCurator))
 	mux.HandleFunc("/api/tags/preview", renderer.ApplyMiddlewares(lokiTagPreviewHandler(deps), renderer.RolePublicRead))
 	mux.HandleFunc("/api/tags/count", renderer.ApplyMiddlewares(lokiTagCountHandler(deps), renderer.RolePublicRead))
 
@@ -2603,9 +2872,9 @@ func main() {
 		case http.MethodDelete:
 			lokiDeleteCategoryHandler(deps)(w, r)
 		}
-	}, renderer.RoleAdmin))
-	mux.HandleFunc("/api/categories/rename", renderer.ApplyMiddlewares(lokiRenameCategoryHandler(deps), renderer.RoleAdmin))
-	mux.HandleFunc("/api/categories/tag-view-mode", renderer.ApplyMiddlewares(lokiUpdateCategoryTagViewModeHandler(deps), renderer.RoleAdmin))
+	}, renderer.RoleCurator))
+	mux.HandleFunc("/api/categories/rename", renderer.ApplyMiddlewares(lokiRenameCategoryHandler(deps), renderer.RoleCurator))
+	mux.HandleFunc("/api/categories/tag-view-mode", renderer.ApplyMiddlewares(lokiUpdateCategoryTagViewModeHandler(deps), renderer.RoleCurator))
 
 	mux.HandleFunc("/api/assignments", renderer.ApplyMiddlewares(func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
@@ -2614,8 +2883,8 @@ func main() {
 		case http.MethodDelete:
 			lokiDeleteAssignmentHandler(deps)(w, r)
 		}
-	}, renderer.RoleAdmin))
-	mux.HandleFunc("/api/assignments/weight", renderer.ApplyMiddlewares(lokiUpdateAssignmentWeightHandler(deps), renderer.RoleAdmin))
+	}, renderer.RoleCurator))
+	mux.HandleFunc("/api/assignments/weight", renderer.ApplyMiddlewares(lokiUpdateAssignmentWeightHandler(deps), renderer.RoleCurator))
 
 	mux.HandleFunc("/api/thumbnails", renderer.ApplyMiddlewares(lokiThumbnailsHandler(deps), renderer.RolePublicRead))
 	mux.HandleFunc("/api/thumbnails/regenerate", renderer.ApplyMiddlewares(lokiRegenerateThumbnailHandler(deps), renderer.RoleAdmin))
@@ -0,0 +1,181 @@
+package media
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// The audit log records who changed what: one append-only row per mutating
+// operation (delete, merge, move, tag/person edits, config saves, imports).
+// The main package and job tasks resolve the actor and write entries through
+// AppendAudit; nothing but retention pruning removes them.
+
+// Audit actor kinds.
+const (
+	AuditActorUser      = "user"
+	AuditActorAPIKey    = "api_key"
+	AuditActorJob       = "job"
+	AuditActorAnonymous = "anonymous"
+	AuditActorSystem    = "system"
+)
+
+// auditTargetKeep caps the targets stored per entry; TargetCount keeps the
+// real number so a bulk delete of 50,000 files stays one bounded row.
+const auditTargetKeep = 200
+
+// auditSummaryMax caps each before/after summary in bytes.
+const auditSummaryMax = 4096
+
+// AuditEntry is one audit log row. Before and After are JSON summaries of
+// the affected state (null when not applicable).
+type AuditEntry struct {
+	ID          int64           `json:"id"`
+	At          int64           `json:"at"` // unix seconds
+	Actor       string          `json:"actor"`
+	ActorKind   string          `json:"actorKind"`
+	ActorID     string          `json:"actorId,omitempty"` // API key ID or job ID
+	Action      string          `json:"action"`
+	Targets     []string        `json:"targets"`
+	TargetCount int             `json:"targetCount"`
+	Before      json.RawMessage `json:"before,omitempty"`
+	After       json.RawMessage `json:"after,omitempty"`
+}
+
+// AuditSummary marshals v for an entry's Before/After, returning nil for a
+// nil v and a truncation marker when the JSON exceeds auditSummaryMax.
+func AuditSummary(v any) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		b, _ = json.Marshal(map[string]string{"error": err.Error()})
+	}
+	if len(b) > auditSummaryMax {
+		b, _ = json.Marshal(map[string]any{"truncated": true, "bytes": len(b)})
+	}
+	return b
+}
+
+// AppendAudit writes e, stamping At when unset. Action is required.
+func AppendAudit(db *sql.DB, e AuditEntry) error {
+	e.Action = strings.TrimSpace(e.Action)
+	if e.Action == "" {
+		return fmt.Errorf("audit action required")
+	}
+	if e.At == 0 {
+		e.At = time.Now().Unix()
+	}
+	if e.ActorKind == "" {
+		e.ActorKind = AuditActorSystem
+	}
+	count := len(e.Targets)
+	if e.TargetCount > count {
+		count = e.TargetCount
+	}
+	targets := e.Targets
+	if len(targets) > auditTargetKeep {
+		targets = targets[:auditTargetKeep]
+	}
+	_, err := db.Exec(
+		`INSERT INTO audit_log (at, actor, actor_kind, actor_id, action, targets, n_targets, before, after)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.At, e.Actor, e.ActorKind, e.ActorID, e.Action,
+		strings.Join(targets, "\n"), count, string(e.Before), string(e.After),
+	)
+	if err != nil {
+		return fmt.Errorf("append audit entry: %w", err)
+	}
+	return nil
+}
+
+// AuditFilter narrows QueryAudit. Empty fields match everything.
+type AuditFilter struct {
+	Actor    string // exact actor name
+	Action   string // exact action, or a prefix: "tag" matches "tag.rename"
+	Target   string // one of the entry's targets, exactly
+	Since    int64  // unix seconds, inclusive
+	Until    int64  // unix seconds, exclusive
+	BeforeID int64  // paging: only entries older than this ID
+	Limit    int    // <= 0 means 100
+}
+
+// QueryAudit returns matching entries, newest first.
+func QueryAudit(db *sql.DB, f AuditFilter) ([]AuditEntry, error) {
+	var where []string
+	var args []any
+	if f.Actor != "" {
+		where = append(where, `actor = ?`)
+		args = append(args, f.Actor)
+	}
+	if a := strings.TrimSuffix(strings.TrimSpace(f.Action), "."); a != "" {
+		where = append(where, `(action = ? OR substr(action, 1, ?) = ?)`)
+		args = append(args, a, len(a)+1, a+".")
+	}
+	if f.Target != "" {
+		where = append(where, `instr(char(10) || targets || char(10), char(10) || ? || char(10)) > 0`)
+		args = append(args, f.Target)
+	}
+	if f.Since > 0 {
+		where = append(where, `at >= ?`)
+		args = append(args, f.Since)
+	}
+	if f.Until > 0 {
+		where = append(where, `at < ?`)
+		args = append(args, f.Until)
+	}
+	if f.BeforeID > 0 {
+		where = append(where, `id < ?`)
+		args = append(args, f.BeforeID)
+	}
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	q := `SELECT id, at, actor, actor_kind, actor_id, action, targets, n_targets, before, after FROM audit_log`
+	if len(where) > 0 {
+		q += ` WHERE ` + strings.Join(where, ` AND `)
+	}
+	q += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []AuditEntry{}
+	for rows.Next() {
+		var e AuditEntry
+		var targets, before, after string
+		if err := rows.Scan(&e.ID, &e.At, &e.Actor, &e.ActorKind, &e.ActorID, &e.Action,
+			&targets, &e.TargetCount, &before, &after); err != nil {
+			return nil, err
+		}
+		e.Targets = []string{}
+		if targets != "" {
+			e.Targets = strings.Split(targets, "\n")
+		}
+		if before != "" {
+			e.Before = json.RawMessage(before)
+		}
+		if after != "" {
+			e.After = json.RawMessage(after)
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// PruneAudit deletes entries older than cutoff (unix seconds) and returns
+// how many went.
+func PruneAudit(db *sql.DB, cutoff int64) (int64, error) {
+	res, err := db.Exec(`DELETE FROM audit_log WHERE at < ?`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("prune audit log: %w", err)
+	}
+	return res.RowsAffected()
+}
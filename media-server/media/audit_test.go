@@ -0,0 +1,85 @@
+package media
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuditQueryFilters(t *testing.T) {
+	db := newPeopleDB(t)
+	now := time.Now().Unix()
+	for _, e := range []AuditEntry{
+		{At: now - 3*86400, Actor: "steve", ActorKind: AuditActorUser, Action: "tag.rename", Targets: []string{"tag:cat", "tag:kitty"}},
+		{At: now - 60, Actor: "bot", ActorKind: AuditActorAPIKey, ActorID: "7", Action: "tag.delete", Targets: []string{"tag:dog"}},
+		{At: now, Actor: "steve", ActorKind: AuditActorUser, Action: "media.delete", Targets: []string{"/a/x.jpg"},
+			Before: AuditSummary(map[string]any{"tags": []string{"General/cat"}})},
+	} {
+		if err := AppendAudit(db, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := AppendAudit(db, AuditEntry{Action: " "}); err == nil {
+		t.Error("an empty action should fail")
+	}
+
+	count := func(f AuditFilter) int {
+		t.Helper()
+		got, err := QueryAudit(db, f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(got)
+	}
+	if n := count(AuditFilter{Action: "tag"}); n != 2 {
+		t.Errorf("action prefix tag = %d, want 2", n)
+	}
+	if n := count(AuditFilter{Action: "ta"}); n != 0 {
+		t.Errorf("a partial segment should not match: got %d", n)
+	}
+	if n := count(AuditFilter{Actor: "steve", Since: now - 86400}); n != 1 {
+		t.Errorf("steve since yesterday = %d, want 1", n)
+	}
+	// Targets match whole entries, not substrings.
+	if n := count(AuditFilter{Target: "tag:kitty"}); n != 1 {
+		t.Errorf("target tag:kitty = %d, want 1", n)
+	}
+	if n := count(AuditFilter{Target: "tag:kit"}); n != 0 {
+		t.Errorf("target substring matched %d entries", n)
+	}
+
+	all, _ := QueryAudit(db, AuditFilter{})
+	if len(all) != 3 || all[0].Action != "media.delete" || !strings.Contains(string(all[0].Before), "General/cat") {
+		t.Fatalf("newest first with summaries: %+v", all)
+	}
+	if page, _ := QueryAudit(db, AuditFilter{BeforeID: all[0].ID, Limit: 1}); len(page) != 1 || page[0].ID != all[1].ID {
+		t.Errorf("paging = %+v", page)
+	}
+
+	if n, err := PruneAudit(db, now-86400); err != nil || n != 1 {
+		t.Errorf("prune = %d %v, want 1", n, err)
+	}
+}
+
+func TestAuditBoundsLargeEntries(t *testing.T) {
+	db := newPeopleDB(t)
+	targets := make([]string, auditTargetKeep+50)
+	for i := range targets {
+		targets[i] = fmt.Sprintf("/lib/%d.jpg", i)
+	}
+	if err := AppendAudit(db, AuditEntry{Action: "media.remove", Targets: targets,
+		After: AuditSummary(strings.Repeat("x", auditSummaryMax))}); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := QueryAudit(db, AuditFilter{})
+	if len(got) != 1 || len(got[0].Targets) != auditTargetKeep || got[0].TargetCount != len(targets) {
+		t.Fatalf("targets kept %d of %d", len(got[0].Targets), got[0].TargetCount)
+	}
+	if !strings.Contains(string(got[0].After), `"truncated":true`) {
+		t.Errorf("oversized summary = %s", got[0].After)
+	}
+	if got[0].ActorKind != AuditActorSystem {
+		t.Errorf("actor kind default = %q", got[0].ActorKind)
+	}
+}
@@ -0,0 +1,207 @@
+package media
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/stevecastle/shrike/embedvec"
+)
+
+// Library themes: the stored output of the cluster-library task. One
+// clustering is live at a time — a re-run replaces every cluster (IDs are not
+// stable across runs), so a theme is something to browse, not to bookmark.
+
+// ClusterMember is one item assigned to a theme, with its cosine to the
+// theme's centroid.
+type ClusterMember struct {
+	Path  string
+	Score float32
+}
+
+// NewLibraryCluster is a theme as the clustering pass produces it.
+type NewLibraryCluster struct {
+	Label       string
+	LabelSource string // "prompt" | "tags" | "" (unlabeled)
+	Centroid    []float32
+	Members     []ClusterMember
+}
+
+// LibraryCluster is a stored theme as the browse API lists it. Size and
+// Covers are computed from the current membership.
+type LibraryCluster struct {
+	ID          int64    `json:"id"`
+	Model       string   `json:"model"`
+	Label       string   `json:"label"`
+	LabelSource string   `json:"labelSource,omitempty"`
+	Size        int      `json:"size"`
+	Covers      []string `json:"covers"`
+	CreatedAt   int64    `json:"createdAt,omitempty"`
+}
+
+// ReplaceLibraryClusters swaps the stored themes for clusters (computed under
+// model) in one transaction and returns the new cluster IDs, parallel to
+// clusters.
+func ReplaceLibraryClusters(db *sql.DB, model string, clusters []NewLibraryCluster) ([]int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM media_cluster_member`); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`DELETE FROM media_cluster`); err != nil {
+		return nil, err
+	}
+	member, err := tx.Prepare(`INSERT OR IGNORE INTO media_cluster_member (cluster_id, media_path, score) VALUES (?, ?, ?)`)
+	if err != nil {
+		return nil, err
+	}
+	defer member.Close()
+	now := time.Now().Unix()
+	ids := make([]int64, 0, len(clusters))
+	for _, c := range clusters {
+		var centroid []byte
+		if len(c.Centroid) > 0 {
+			centroid = embedvec.Encode(c.Centroid)
+		}
+		res, err := tx.Exec(
+			`INSERT INTO media_cluster (model, label, label_source, centroid, created_at) VALUES (?, ?, ?, ?, ?)`,
+			model, c.Label, c.LabelSource, centroid, now,
+		)
+		if err != nil {
+			return nil, err
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range c.Members {
+			if _, err := member.Exec(id, m.Path, m.Score); err != nil {
+				return nil, err
+			}
+		}
+		ids = append(ids, id)
+	}
+	return ids, tx.Commit()
+}
+
+// SetLibraryClusterLabel updates one theme's label.
+func SetLibraryClusterLabel(db *sql.DB, id int64, label, source string) error {
+	_, err := db.Exec(`UPDATE media_cluster SET label = ?, label_source = ? WHERE id = ?`, label, source, id)
+	return err
+}
+
+// GetLibraryClusters lists the stored themes, largest first, each with up to
+// covers of its most typical members (highest centroid cosine). Themes whose
+// members have all been deleted are omitted.
+func GetLibraryClusters(db *sql.DB, covers int) ([]LibraryCluster, error) {
+	rows, err := db.Query(`
+		SELECT c.id, c.model, c.label, c.label_source, COALESCE(c.created_at, 0), COUNT(m.media_path)
+		FROM media_cluster c
+		JOIN media_cluster_member m ON m.cluster_id = c.id
+		GROUP BY c.id
+		ORDER BY COUNT(m.media_path) DESC, c.id ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	var out []LibraryCluster
+	for rows.Next() {
+		var c LibraryCluster
+		if err := rows.Scan(&c.ID, &c.Model, &c.Label, &c.LabelSource, &c.CreatedAt, &c.Size); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, err
+	}
+	rows.Close()
+
+	if covers <= 0 {
+		return out, nil
+	}
+	stmt, err := db.Prepare(`SELECT media_path FROM media_cluster_member WHERE cluster_id = ? ORDER BY score DESC, media_path ASC LIMIT ?`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+	for i := range out {
+		crows, err := stmt.Query(out[i].ID, covers)
+		if err != nil {
+			return nil, err
+		}
+		out[i].Covers = []string{}
+		for crows.Next() {
+			var p string
+			if err := crows.Scan(&p); err != nil {
+				crows.Close()
+				return nil, err
+			}
+			out[i].Covers = append(out[i].Covers, p)
+		}
+		crows.Close()
+	}
+	return out, nil
+}
+
+// ClusterTagCounts returns, per stored theme, how many of its members carry
+// each tag (distinct media, so a tag placed at several video timestamps
+// counts once).
+func ClusterTagCounts(db *sql.DB) (map[int64]map[string]int, error) {
+	rows, err := db.Query(`
+		SELECT m.cluster_id, t.tag_label, COUNT(DISTINCT t.media_path)
+		FROM media_cluster_member m
+		JOIN media_tag_by_category t ON t.media_path = m.media_path
+		GROUP BY m.cluster_id, t.tag_label
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[int64]map[string]int{}
+	for rows.Next() {
+		var id int64
+		var tag string
+		var n int
+		if err := rows.Scan(&id, &tag, &n); err != nil {
+			return nil, err
+		}
+		if out[id] == nil {
+			out[id] = map[string]int{}
+		}
+		out[id][tag] = n
+	}
+	return out, rows.Err()
+}
+
+// LibraryTagCounts returns how many media items carry each tag, plus the
+// number of tagged items overall — the background a theme's tags are scored
+// against.
+func LibraryTagCounts(db *sql.DB) (map[string]int, int, error) {
+	rows, err := db.Query(`SELECT tag_label, COUNT(DISTINCT media_path) FROM media_tag_by_category GROUP BY tag_label`)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	out := map[string]int{}
+	for rows.Next() {
+		var tag string
+		var n int
+		if err := rows.Scan(&tag, &n); err != nil {
+			return nil, 0, err
+		}
+		out[tag] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	var total int
+	if err := db.QueryRow(`SELECT COUNT(DISTINCT media_path) FROM media_tag_by_category`).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	return out, total, nil
+}
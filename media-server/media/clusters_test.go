@@ -0,0 +1,55 @@
+package media
+
+import "testing"
+
+func TestReplaceAndListLibraryClusters(t *testing.T) {
+	db := newEmbedDB(t)
+	defer db.Close()
+
+	if _, err := ReplaceLibraryClusters(db, "m1", []NewLibraryCluster{
+		{Label: "old", Members: []ClusterMember{{Path: "z.jpg", Score: 1}}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	ids, err := ReplaceLibraryClusters(db, "m1", []NewLibraryCluster{
+		{Centroid: []float32{1, 0}, Members: []ClusterMember{{"a.jpg", 0.5}, {"b.jpg", 0.9}, {"c.jpg", 0.7}}},
+		{Label: "pets", LabelSource: "prompt", Members: []ClusterMember{{"d.jpg", 0.8}}},
+	})
+	if err != nil || len(ids) != 2 {
+		t.Fatalf("replace: ids=%v err=%v", ids, err)
+	}
+	if _, err := db.Exec(
+		`INSERT INTO media_tag_by_category (media_path, tag_label, category_label, time_stamp) VALUES
+		 ('a.jpg', 'beach', 'Subject', 0), ('b.jpg', 'beach', 'Subject', 0), ('b.jpg', 'beach', 'Subject', 12), ('d.jpg', 'cat', 'Subject', 0)`,
+	); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetLibraryClusterLabel(db, ids[0], "beach", "tags"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := GetLibraryClusters(db, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The previous run's themes are gone; largest first; covers are the
+	// most typical members.
+	if len(got) != 2 || got[0].ID != ids[0] || got[0].Size != 3 || got[0].Label != "beach" {
+		t.Fatalf("clusters = %+v", got)
+	}
+	if len(got[0].Covers) != 2 || got[0].Covers[0] != "b.jpg" || got[0].Covers[1] != "c.jpg" {
+		t.Errorf("covers = %v, want [b.jpg c.jpg]", got[0].Covers)
+	}
+
+	counts, err := ClusterTagCounts(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts[ids[0]]["beach"] != 2 || counts[ids[1]]["cat"] != 1 {
+		t.Errorf("tag counts = %v", counts)
+	}
+	lib, total, err := LibraryTagCounts(db)
+	if err != nil || lib["beach"] != 2 || total != 3 {
+		t.Errorf("library tag counts = %v total=%d err=%v", lib, total, err)
+	}
+}
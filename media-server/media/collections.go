@@ -0,0 +1,549 @@
+package media
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Collections are hand-curated albums: a named list of media in an explicit
+// order, each entry with an optional caption. Unlike tags, membership carries
+// a position, and the same item can appear in any number of collections.
+// Collections live in folders, which nest; a nil FolderID / ParentID is the
+// top level.
+//
+// Positions are kept dense (0..n-1) — every edit renumbers the collection in
+// one transaction — so the order is exactly the ORDER BY position the API
+// returns, and a MergeInto that hands the target a source's slot never needs
+// to make room.
+
+// Collection is one album as the API reports it. CoverPath is the chosen
+// cover, or the first item when none was chosen (CoverChosen tells which).
+type Collection struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	FolderID    *int64 `json:"folderId"`
+	CoverPath   string `json:"coverPath,omitempty"`
+	CoverChosen bool   `json:"coverChosen"`
+	Count       int    `json:"count"`
+	CreatedAt   int64  `json:"createdAt"`
+	UpdatedAt   int64  `json:"updatedAt"`
+}
+
+// CollectionItem is one entry of a collection, in order.
+type CollectionItem struct {
+	Path     string `json:"path"`
+	Position int    `json:"position"`
+	Caption  string `json:"caption"`
+	AddedAt  int64  `json:"addedAt"`
+}
+
+// CollectionFolder groups collections (and other folders).
+type CollectionFolder struct {
+	ID        int64  `json:"id"`
+	Name      string `json:"name"`
+	ParentID  *int64 `json:"parentId"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
+const collectionColumns = `c.id, c.name, c.description, c.folder_id,
+	COALESCE(c.cover_path, (SELECT ci.media_path FROM collection_item ci
+		WHERE ci.collection_id = c.id ORDER BY ci.position, ci.media_path LIMIT 1), ''),
+	c.cover_path IS NOT NULL,
+	(SELECT COUNT(*) FROM collection_item ci WHERE ci.collection_id = c.id),
+	COALESCE(c.created_at, 0), COALESCE(c.updated_at, 0)`
+
+func scanCollection(row interface{ Scan(...any) error }) (Collection, error) {
+	var c Collection
+	var folder sql.NullInt64
+	if err := row.Scan(&c.ID, &c.Name, &c.Description, &folder, &c.CoverPath, &c.CoverChosen,
+		&c.Count, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		return Collection{}, err
+	}
+	if folder.Valid {
+		c.FolderID = &folder.Int64
+	}
+	return c, nil
+}
+
+// ListCollections returns every collection ordered by name.
+func ListCollections(db *sql.DB) ([]Collection, error) {
+	rows, err := db.Query(`SELECT ` + collectionColumns + ` FROM collection c ORDER BY c.name COLLATE NOCASE`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Collection
+	for rows.Next() {
+		c, err := scanCollection(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// GetCollectionByID returns one collection by ID.
+func GetCollectionByID(db *sql.DB, id int64) (Collection, bool, error) {
+	return getCollection(db, `c.id = ?`, id)
+}
+
+// GetCollectionByName returns one collection by name (case-insensitive).
+func GetCollectionByName(db *sql.DB, name string) (Collection, bool, error) {
+	return getCollection(db, `c.name = ?`, strings.TrimSpace(name))
+}
+
+func getCollection(db *sql.DB, where string, arg any) (Collection, bool, error) {
+	c, err := scanCollection(db.QueryRow(`SELECT `+collectionColumns+` FROM collection c WHERE `+where, arg))
+	if err == sql.ErrNoRows {
+		return Collection{}, false, nil
+	}
+	if err != nil {
+		return Collection{}, false, err
+	}
+	return c, true, nil
+}
+
+// checkFolder verifies that a folder reference (nil = top level) exists.
+func checkFolder(db *sql.DB, id *int64) error {
+	if id == nil {
+		return nil
+	}
+	var one int
+	err := db.QueryRow(`SELECT 1 FROM collection_folder WHERE id = ?`, *id).Scan(&one)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("folder must be an existing collection folder (no id %d)", *id)
+	}
+	return err
+}
+
+// CreateCollection stores an empty collection named c.Name in c.FolderID.
+// Names are unique case-insensitively — they are what collection:"name"
+// queries match.
+func CreateCollection(db *sql.DB, c Collection) (Collection, error) {
+	c.Name = strings.TrimSpace(c.Name)
+	if c.Name == "" {
+		return Collection{}, fmt.Errorf("collection name required")
+	}
+	if _, exists, err := GetCollectionByName(db, c.Name); err != nil {
+		return Collection{}, err
+	} else if exists {
+		return Collection{}, fmt.Errorf("collection %q already exists", c.Name)
+	}
+	if err := checkFolder(db, c.FolderID); err != nil {
+		return Collection{}, err
+	}
+	now := time.Now().Unix()
+	res, err := db.Exec(
+		`INSERT INTO collection (name, description, folder_id, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`,
+		c.Name, strings.TrimSpace(c.Description), c.FolderID, now, now,
+	)
+	if err != nil {
+		return Collection{}, fmt.Errorf("create collection %q: %w", c.Name, err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return Collection{}, err
+	}
+	created, _, err := GetCollectionByID(db, id)
+	return created, err
+}
+
+// CollectionUpdate is a partial edit: nil fields are left alone. An empty
+// CoverPath clears the chosen cover (back to the first item); a FolderID of
+// 0 moves the collection to the top level.
+type CollectionUpdate struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	FolderID    *int64  `json:"folderId"`
+	CoverPath   *string `json:"coverPath"`
+}
+
+// UpdateCollection applies u to collection id and returns the result. ok is
+// false when no such collection exists. A chosen cover must be a member.
+func UpdateCollection(db *sql.DB, id int64, u CollectionUpdate) (Collection, bool, error) {
+	c, found, err := GetCollectionByID(db, id)
+	if err != nil || !found {
+		return Collection{}, found, err
+	}
+	sets := []string{"updated_at = ?"}
+	args := []any{time.Now().Unix()}
+	if u.Name != nil {
+		name := strings.TrimSpace(*u.Name)
+		if name == "" {
+			return Collection{}, false, fmt.Errorf("collection name required")
+		}
+		if other, exists, err := GetCollectionByName(db, name); err != nil {
+			return Collection{}, false, err
+		} else if exists && other.ID != id {
+			return Collection{}, false, fmt.Errorf("collection %q already exists", name)
+		}
+		sets, args = append(sets, "name = ?"), append(args, name)
+	}
+	if u.Description != nil {
+		sets, args = append(sets, "description = ?"), append(args, strings.TrimSpace(*u.Description))
+	}
+	if u.FolderID != nil {
+		var folder *int64
+		if *u.FolderID > 0 {
+			folder = u.FolderID
+		}
+		if err := checkFolder(db, folder); err != nil {
+			return Collection{}, false, err
+		}
+		sets, args = append(sets, "folder_id = ?"), append(args, folder)
+	}
+	if u.CoverPath != nil {
+		var cover any
+		if p := strings.TrimSpace(*u.CoverPath); p != "" {
+			var one int
+			err := db.QueryRow(`SELECT 1 FROM collection_item WHERE collection_id = ? AND media_path = ?`, id, p).Scan(&one)
+			if err == sql.ErrNoRows {
+				return Collection{}, false, fmt.Errorf("cover must be an item of collection %q", c.Name)
+			}
+			if err != nil {
+				return Collection{}, false, err
+			}
+			cover = p
+		}
+		sets, args = append(sets, "cover_path = ?"), append(args, cover)
+	}
+	if _, err := db.Exec(`UPDATE collection SET `+strings.Join(sets, ", ")+` WHERE id = ?`, append(args, id)...); err != nil {
+		return Collection{}, false, fmt.Errorf("update collection %q: %w", c.Name, err)
+	}
+	return GetCollectionByID(db, id)
+}
+
+// DeleteCollection removes a collection and its entries. The media items
+// themselves are untouched.
+func DeleteCollection(db *sql.DB, id int64) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM collection_item WHERE collection_id = ?`, id); err != nil {
+		return false, err
+	}
+	res, err := tx.Exec(`DELETE FROM collection WHERE id = ?`, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, tx.Commit()
+}
+
+// CollectionItems returns a collection's entries in order.
+func CollectionItems(db *sql.DB, id int64) ([]CollectionItem, error) {
+	rows, err := db.Query(
+		`SELECT media_path, position, caption, COALESCE(added_at, 0) FROM collection_item
+		 WHERE collection_id = ? ORDER BY position, media_path`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []CollectionItem
+	for rows.Next() {
+		var it CollectionItem
+		if err := rows.Scan(&it.Path, &it.Position, &it.Caption, &it.AddedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, it)
+	}
+	return out, rows.Err()
+}
+
+// collectionOrder reads a collection's member paths in order, inside tx.
+func collectionOrder(ctx context.Context, tx *sql.Tx, id int64) ([]string, error) {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT media_path FROM collection_item WHERE collection_id = ? ORDER BY position, media_path`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// renumberCollection writes positions 0..n-1 for order and bumps the
+// collection's updated_at.
+func renumberCollection(ctx context.Context, tx *sql.Tx, id int64, order []string) error {
+	stmt, err := tx.PrepareContext(ctx, `UPDATE collection_item SET position = ? WHERE collection_id = ? AND media_path = ?`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for i, p := range order {
+		if _, err := stmt.ExecContext(ctx, i, id, p); err != nil {
+			return err
+		}
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE collection SET updated_at = ? WHERE id = ?`, time.Now().Unix(), id)
+	return err
+}
+
+// AddCollectionItems inserts paths into collection id before index at (at <
+// 0 or past the end appends), keeping their relative order. Paths already in
+// the collection stay where they are and are not counted. Every path must be
+// a library item. Returns how many entries were added.
+func AddCollectionItems(ctx context.Context, db *sql.DB, id int64, paths []string, at int) (int, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	order, err := collectionOrder(ctx, tx, id)
+	if err != nil {
+		return 0, err
+	}
+	member := make(map[string]bool, len(order))
+	for _, p := range order {
+		member[p] = true
+	}
+	now := time.Now().Unix()
+	var added []string
+	for _, p := range paths {
+		p = strings.TrimSpace(p)
+		if p == "" || member[p] {
+			continue
+		}
+		var one int
+		err := tx.QueryRowContext(ctx, `SELECT 1 FROM media WHERE path = ?`, p).Scan(&one)
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("%q must be a library item to join a collection", p)
+		}
+		if err != nil {
+			return 0, err
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO collection_item (collection_id, media_path, position, caption, added_at) VALUES (?, ?, 0, '', ?)`,
+			id, p, now); err != nil {
+			return 0, err
+		}
+		member[p] = true
+		added = append(added, p)
+	}
+	if len(added) == 0 {
+		return 0, nil
+	}
+	if at < 0 || at > len(order) {
+		at = len(order)
+	}
+	next := make([]string, 0, len(order)+len(added))
+	next = append(next, order[:at]...)
+	next = append(next, added...)
+	next = append(next, order[at:]...)
+	if err := renumberCollection(ctx, tx, id, next); err != nil {
+		return 0, err
+	}
+	return len(added), tx.Commit()
+}
+
+// RemoveCollectionItems drops paths from collection id (clearing the chosen
+// cover if it was one of them) and closes the gaps. Returns how many entries
+// were removed.
+func RemoveCollectionItems(ctx context.Context, db *sql.DB, id int64, paths []string) (int, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	var removed int64
+	for _, p := range paths {
+		res, err := tx.ExecContext(ctx, `DELETE FROM collection_item WHERE collection_id = ? AND media_path = ?`, id, p)
+		if err != nil {
+			return 0, err
+		}
+		n, _ := res.RowsAffected()
+		removed += n
+		if _, err := tx.ExecContext(ctx, `UPDATE collection SET cover_path = NULL WHERE id = ? AND cover_path = ?`, id, p); err != nil {
+			return 0, err
+		}
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+	order, err := collectionOrder(ctx, tx, id)
+	if err != nil {
+		return 0, err
+	}
+	if err := renumberCollection(ctx, tx, id, order); err != nil {
+		return 0, err
+	}
+	return int(removed), tx.Commit()
+}
+
+// ReorderCollection puts paths first, in the given order; members not listed
+// keep their relative order after them. Passing the full member list sets the
+// whole order. Every listed path must already be a member.
+func ReorderCollection(ctx context.Context, db *sql.DB, id int64, paths []string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	order, err := collectionOrder(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	member := make(map[string]bool, len(order))
+	for _, p := range order {
+		member[p] = true
+	}
+	listed := make(map[string]bool, len(paths))
+	next := make([]string, 0, len(order))
+	for _, p := range paths {
+		if !member[p] {
+			return fmt.Errorf("%q must be an item of the collection to reorder it", p)
+		}
+		if !listed[p] {
+			listed[p] = true
+			next = append(next, p)
+		}
+	}
+	for _, p := range order {
+		if !listed[p] {
+			next = append(next, p)
+		}
+	}
+	if err := renumberCollection(ctx, tx, id, next); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetCollectionCaption sets one entry's caption. ok is false when the path is
+// not in the collection.
+func SetCollectionCaption(db *sql.DB, id int64, path, caption string) (bool, error) {
+	res, err := db.Exec(`UPDATE collection_item SET caption = ? WHERE collection_id = ? AND media_path = ?`,
+		strings.TrimSpace(caption), id, path)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ListCollectionFolders returns every folder ordered by name; callers build
+// the tree from ParentID.
+func ListCollectionFolders(db *sql.DB) ([]CollectionFolder, error) {
+	rows, err := db.Query(`SELECT id, name, parent_id, COALESCE(created_at, 0) FROM collection_folder ORDER BY name COLLATE NOCASE`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []CollectionFolder
+	for rows.Next() {
+		var f CollectionFolder
+		var parent sql.NullInt64
+		if err := rows.Scan(&f.ID, &f.Name, &parent, &f.CreatedAt); err != nil {
+			return nil, err
+		}
+		if parent.Valid {
+			f.ParentID = &parent.Int64
+		}
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
+
+// CreateCollectionFolder adds a folder under parent (nil = top level).
+func CreateCollectionFolder(db *sql.DB, name string, parent *int64) (CollectionFolder, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return CollectionFolder{}, fmt.Errorf("folder name required")
+	}
+	if err := checkFolder(db, parent); err != nil {
+		return CollectionFolder{}, err
+	}
+	now := time.Now().Unix()
+	res, err := db.Exec(`INSERT INTO collection_folder (name, parent_id, created_at) VALUES (?, ?, ?)`, name, parent, now)
+	if err != nil {
+		return CollectionFolder{}, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return CollectionFolder{}, err
+	}
+	return CollectionFolder{ID: id, Name: name, ParentID: parent, CreatedAt: now}, nil
+}
+
+// UpdateCollectionFolder renames a folder (name != "") and/or moves it under
+// parent (non-nil; 0 = top level). A folder cannot move into itself or one of
+// its own descendants. ok is false when no such folder exists.
+func UpdateCollectionFolder(db *sql.DB, id int64, name string, parent *int64) (bool, error) {
+	var one int
+	if err := db.QueryRow(`SELECT 1 FROM collection_folder WHERE id = ?`, id).Scan(&one); err == sql.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if name = strings.TrimSpace(name); name != "" {
+		if _, err := db.Exec(`UPDATE collection_folder SET name = ? WHERE id = ?`, name, id); err != nil {
+			return false, err
+		}
+	}
+	if parent == nil {
+		return true, nil
+	}
+	var newParent *int64
+	if *parent > 0 {
+		newParent = parent
+		if err := checkFolder(db, newParent); err != nil {
+			return false, err
+		}
+		// Walk up from the new parent; meeting id means a cycle.
+		for cur := *newParent; ; {
+			if cur == id {
+				return false, fmt.Errorf("folder must be moved outside its own subtree")
+			}
+			var up sql.NullInt64
+			if err := db.QueryRow(`SELECT parent_id FROM collection_folder WHERE id = ?`, cur).Scan(&up); err != nil || !up.Valid {
+				break
+			}
+			cur = up.Int64
+		}
+	}
+	_, err := db.Exec(`UPDATE collection_folder SET parent_id = ? WHERE id = ?`, newParent, id)
+	return err == nil, err
+}
+
+// DeleteCollectionFolder removes a folder; its subfolders and collections
+// move up to the folder's own parent rather than being deleted.
+func DeleteCollectionFolder(db *sql.DB, id int64) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	var parent sql.NullInt64
+	err = tx.QueryRow(`SELECT parent_id FROM collection_folder WHERE id = ?`, id).Scan(&parent)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(`UPDATE collection_folder SET parent_id = ? WHERE parent_id = ?`, parent, id); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(`UPDATE collection SET folder_id = ? WHERE folder_id = ?`, parent, id); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(`DELETE FROM collection_folder WHERE id = ?`, id); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
@@ -0,0 +1,177 @@
+package media
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func itemPaths(t *testing.T, db *sql.DB, id int64) []string {
+	t.Helper()
+	items, err := CollectionItems(db, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []string
+	for i, it := range items {
+		if it.Position != i {
+			t.Fatalf("positions not dense: %+v", items)
+		}
+		out = append(out, it.Path)
+	}
+	return out
+}
+
+func TestCollectionOrdering(t *testing.T) {
+	db := newPeopleDB(t)
+	ctx := context.Background()
+	for _, p := range []string{"a.jpg", "b.jpg", "c.jpg", "d.jpg"} {
+		if _, err := db.Exec(`INSERT OR IGNORE INTO media (path) VALUES (?)`, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c, err := CreateCollection(db, Collection{Name: " Trip "})
+	if err != nil || c.Name != "Trip" || c.Count != 0 {
+		t.Fatalf("create = %+v %v", c, err)
+	}
+	if _, err := CreateCollection(db, Collection{Name: "trip"}); err == nil {
+		t.Error("names should be unique case-insensitively")
+	}
+
+	if n, err := AddCollectionItems(ctx, db, c.ID, []string{"a.jpg", "b.jpg"}, -1); err != nil || n != 2 {
+		t.Fatalf("append = %d %v", n, err)
+	}
+	// Insert before index 1; a.jpg is already a member and is not moved.
+	if n, err := AddCollectionItems(ctx, db, c.ID, []string{"c.jpg", "a.jpg", "d.jpg"}, 1); err != nil || n != 2 {
+		t.Fatalf("insert = %d %v", n, err)
+	}
+	if got := itemPaths(t, db, c.ID); !reflect.DeepEqual(got, []string{"a.jpg", "c.jpg", "d.jpg", "b.jpg"}) {
+		t.Errorf("order after insert = %v", got)
+	}
+	if _, err := AddCollectionItems(ctx, db, c.ID, []string{"nope.jpg"}, -1); err == nil {
+		t.Error("adding a non-library path should fail")
+	}
+
+	if err := ReorderCollection(ctx, db, c.ID, []string{"b.jpg", "d.jpg"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := itemPaths(t, db, c.ID); !reflect.DeepEqual(got, []string{"b.jpg", "d.jpg", "a.jpg", "c.jpg"}) {
+		t.Errorf("order after reorder = %v", got)
+	}
+	if err := ReorderCollection(ctx, db, c.ID, []string{"zzz.jpg"}); err == nil {
+		t.Error("reordering a non-member should fail")
+	}
+
+	// Cover falls back to the first item until one is chosen; a chosen
+	// cover must be a member and is cleared when it leaves.
+	got, _, _ := GetCollectionByID(db, c.ID)
+	if got.CoverPath != "b.jpg" || got.CoverChosen || got.Count != 4 {
+		t.Errorf("default cover = %+v", got)
+	}
+	cover := "a.jpg"
+	if got, _, err = UpdateCollection(db, c.ID, CollectionUpdate{CoverPath: &cover}); err != nil || got.CoverPath != "a.jpg" || !got.CoverChosen {
+		t.Fatalf("set cover = %+v %v", got, err)
+	}
+	outside := "other.jpg"
+	if _, _, err := UpdateCollection(db, c.ID, CollectionUpdate{CoverPath: &outside}); err == nil {
+		t.Error("a cover outside the collection should fail")
+	}
+	if ok, err := SetCollectionCaption(db, c.ID, "d.jpg", " sunset "); err != nil || !ok {
+		t.Fatalf("caption = %v %v", ok, err)
+	}
+	if n, err := RemoveCollectionItems(ctx, db, c.ID, []string{"a.jpg", "missing.jpg"}); err != nil || n != 1 {
+		t.Fatalf("remove = %d %v", n, err)
+	}
+	got, _, _ = GetCollectionByID(db, c.ID)
+	if got.CoverChosen || got.CoverPath != "b.jpg" {
+		t.Errorf("cover after removing it = %+v", got)
+	}
+	items, _ := CollectionItems(db, c.ID)
+	if len(items) != 3 || items[1].Path != "d.jpg" || items[1].Caption != "sunset" {
+		t.Errorf("items = %+v", items)
+	}
+
+	if ok, err := DeleteCollection(db, c.ID); err != nil || !ok {
+		t.Fatalf("delete = %v %v", ok, err)
+	}
+	var left int
+	db.QueryRow(`SELECT COUNT(*) FROM collection_item`).Scan(&left)
+	if left != 0 {
+		t.Errorf("entries left after delete = %d", left)
+	}
+}
+
+func TestCollectionFolders(t *testing.T) {
+	db := newPeopleDB(t)
+	top, err := CreateCollectionFolder(db, "Travel", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub, err := CreateCollectionFolder(db, "2024", &top.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := CreateCollection(db, Collection{Name: "Japan", FolderID: &sub.ID})
+	if err != nil || c.FolderID == nil || *c.FolderID != sub.ID {
+		t.Fatalf("create in folder = %+v %v", c, err)
+	}
+	missing := int64(999)
+	if _, err := CreateCollection(db, Collection{Name: "Lost", FolderID: &missing}); err == nil {
+		t.Error("an unknown folder should fail")
+	}
+	if _, err := UpdateCollectionFolder(db, top.ID, "", &sub.ID); err == nil {
+		t.Error("moving a folder under its own child should fail")
+	}
+
+	// Deleting the subfolder lifts its collection up to the parent.
+	if ok, err := DeleteCollectionFolder(db, sub.ID); err != nil || !ok {
+		t.Fatalf("delete folder = %v %v", ok, err)
+	}
+	c, _, _ = GetCollectionByID(db, c.ID)
+	if c.FolderID == nil || *c.FolderID != top.ID {
+		t.Errorf("collection folder after delete = %v, want %d", c.FolderID, top.ID)
+	}
+	folders, err := ListCollectionFolders(db)
+	if err != nil || len(folders) != 1 || folders[0].ParentID != nil {
+		t.Errorf("folders = %+v %v", folders, err)
+	}
+}
+
+func TestMergeIntoTakesOverCollectionSlots(t *testing.T) {
+	db := newPeopleDB(t)
+	ctx := context.Background()
+	for _, p := range []string{"keep.jpg", "s3://bucket/dup.jpg", "x.jpg"} {
+		if _, err := db.Exec(`INSERT OR IGNORE INTO media (path) VALUES (?)`, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	both, _ := CreateCollection(db, Collection{Name: "both"})
+	only, _ := CreateCollection(db, Collection{Name: "only dup"})
+	if _, err := AddCollectionItems(ctx, db, both.ID, []string{"keep.jpg", "s3://bucket/dup.jpg"}, -1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AddCollectionItems(ctx, db, only.ID, []string{"x.jpg", "s3://bucket/dup.jpg"}, -1); err != nil {
+		t.Fatal(err)
+	}
+	SetCollectionCaption(db, only.ID, "s3://bucket/dup.jpg", "the good one")
+	cover := "s3://bucket/dup.jpg"
+	UpdateCollection(db, only.ID, CollectionUpdate{CoverPath: &cover})
+
+	// An s3 source is never deleted, which leaves the rows inspectable.
+	res, err := MergeInto(ctx, db, "keep.jpg", []string{"s3://bucket/dup.jpg"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Collections != 1 {
+		t.Errorf("collections gained = %d, want 1", res.Collections)
+	}
+	items, _ := CollectionItems(db, only.ID)
+	if len(items) < 2 || items[1].Path != "keep.jpg" || items[1].Caption != "the good one" {
+		t.Errorf("target did not take the source's slot: %+v", items)
+	}
+	got, _, _ := GetCollectionByID(db, only.ID)
+	if got.CoverPath != "keep.jpg" || !got.CoverChosen {
+		t.Errorf("cover = %+v, want keep.jpg", got)
+	}
+}
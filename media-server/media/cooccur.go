@@ -0,0 +1,314 @@
+package media
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// Co-occurrence: who appears together. person_cooccur holds one row per
+// (pair of people, library media item) — person_a < person_b, both with a
+// face assigned on that item. Counts and example media fall out of GROUP BY
+// over an indexed table instead of a self-join over every assigned face, and
+// the rows stay exact because every path that changes an assignment
+// recomputes the pairs of the media it touched, inside its own transaction.
+
+// cooccurPairsSelect produces the pair rows for the media paths bound to the
+// IN list it is completed with. Only library media counts: faces on
+// reference photos and query images have nothing to open.
+const cooccurPairsSelect = `
+	SELECT DISTINCT a.person_id, b.person_id, a.media_path
+	FROM face a
+	JOIN face b ON b.media_path = a.media_path AND b.person_id > a.person_id
+	JOIN media m ON m.path = a.media_path
+	WHERE a.person_id > 0`
+
+// cooccurRefreshChunk bounds the IN list of one refresh statement (SQLite's
+// default variable limit is far higher; this keeps statements small).
+const cooccurRefreshChunk = 400
+
+// refreshCooccurrenceTx recomputes the pair rows of paths from their current
+// face assignments.
+func refreshCooccurrenceTx(tx *sql.Tx, paths []string) error {
+	for start := 0; start < len(paths); start += cooccurRefreshChunk {
+		end := min(start+cooccurRefreshChunk, len(paths))
+		batch := paths[start:end]
+		in := strings.TrimSuffix(strings.Repeat("?,", len(batch)), ",")
+		args := make([]any, len(batch))
+		for i, p := range batch {
+			args[i] = p
+		}
+		if _, err := tx.Exec(`DELETE FROM person_cooccur WHERE media_path IN (`+in+`)`, args...); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(
+			`INSERT OR IGNORE INTO person_cooccur (person_a, person_b, media_path)`+
+				cooccurPairsSelect+` AND a.media_path IN (`+in+`)`, args...,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RefreshCooccurrence recomputes the pair rows of paths — for callers that
+// write face rows directly (archive import) rather than through AssignFace.
+func RefreshCooccurrence(db *sql.DB, paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := refreshCooccurrenceTx(tx, paths); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// personMediaPathsTx lists the distinct media paths personID has faces on.
+func personMediaPathsTx(tx *sql.Tx, personID int64) ([]string, error) {
+	return queryStringsTx(tx, `SELECT DISTINCT media_path FROM face WHERE person_id = ?`, personID)
+}
+
+// queryStringsTx collects a single-column string query.
+func queryStringsTx(tx *sql.Tx, query string, args ...any) ([]string, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// backfillCooccurrence fills person_cooccur from existing assignments when
+// it is empty (first start after the table was added).
+func backfillCooccurrence(db *sql.DB) error {
+	var one int
+	err := db.QueryRow(`SELECT 1 FROM person_cooccur LIMIT 1`).Scan(&one)
+	if err != sql.ErrNoRows {
+		return err
+	}
+	_, err = db.Exec(`INSERT OR IGNORE INTO person_cooccur (person_a, person_b, media_path)` + cooccurPairsSelect)
+	return err
+}
+
+// unnamedPersonPattern matches the auto-cluster names NextUnknownName hands
+// out — groups nobody has named yet.
+const unnamedPersonPattern = "Unknown #%"
+
+// personNameArgs are a display name's two stored forms (see PersonClusterSuffix).
+func personNameArgs(name string) []any {
+	name = strings.TrimSpace(name)
+	return []any{name, name + PersonClusterSuffix}
+}
+
+// CooccurWithSQL is the with:"name" condition on the media path expression
+// pathExpr: the person appears on the item alongside at least one other
+// NAMED person (unnamed auto-clusters don't count).
+func CooccurWithSQL(pathExpr, name string) (string, []any) {
+	return `EXISTS (SELECT 1 FROM person_cooccur pc
+		JOIN person p1 ON p1.id IN (pc.person_a, pc.person_b)
+		JOIN person p2 ON p2.id IN (pc.person_a, pc.person_b) AND p2.id <> p1.id
+		WHERE pc.media_path = ` + pathExpr + ` AND p1.name IN (?, ?) AND p2.name NOT LIKE '` + unnamedPersonPattern + `')`,
+		personNameArgs(name)
+}
+
+// SplitTogetherNames splits a together:"Alice","Bob" value (the quotes are
+// gone by the time it gets here) into its names.
+func SplitTogetherNames(value string) []string {
+	var out []string
+	for _, n := range strings.Split(value, ",") {
+		if n = strings.TrimSpace(strings.Trim(strings.TrimSpace(n), `"`)); n != "" {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// CooccurTogetherSQL is the together:"Alice","Bob" condition on pathExpr:
+// every named person appears on the item. Fewer than two names is not a
+// relationship and matches nothing.
+func CooccurTogetherSQL(pathExpr string, names []string) (string, []any) {
+	if len(names) < 2 {
+		return "1=0", nil
+	}
+	var conds []string
+	var args []any
+	for _, other := range names[1:] {
+		conds = append(conds, `EXISTS (SELECT 1 FROM person_cooccur pc
+		JOIN person p1 ON p1.id IN (pc.person_a, pc.person_b)
+		JOIN person p2 ON p2.id IN (pc.person_a, pc.person_b) AND p2.id <> p1.id
+		WHERE pc.media_path = `+pathExpr+` AND p1.name IN (?, ?) AND p2.name IN (?, ?))`)
+		args = append(args, personNameArgs(names[0])...)
+		args = append(args, personNameArgs(other)...)
+	}
+	return "(" + strings.Join(conds, " AND ") + ")", args
+}
+
+// CoPerson is someone who appears alongside a person: how many media items
+// they share and a few of those items.
+type CoPerson struct {
+	PersonID int64    `json:"personId"`
+	Name     string   `json:"name"`
+	Count    int      `json:"count"`
+	Examples []string `json:"examples"`
+}
+
+// PersonCooccurrence returns the people most often seen with id, by shared
+// media count (ties by name), with up to examples media paths each — the
+// most recently added first.
+func PersonCooccurrence(db *sql.DB, id int64, limit, examples int) ([]CoPerson, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	rows, err := db.Query(`
+		SELECT p.id, COALESCE(p.name, ''), COUNT(*)
+		FROM (
+			SELECT person_b AS other FROM person_cooccur WHERE person_a = ?
+			UNION ALL
+			SELECT person_a AS other FROM person_cooccur WHERE person_b = ?
+		) c
+		JOIN person p ON p.id = c.other
+		GROUP BY p.id
+		ORDER BY COUNT(*) DESC, p.name ASC
+		LIMIT ?`, id, id, limit)
+	if err != nil {
+		return nil, err
+	}
+	var out []CoPerson
+	for rows.Next() {
+		var c CoPerson
+		if err := rows.Scan(&c.PersonID, &c.Name, &c.Count); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		c.Examples = []string{}
+		out = append(out, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if examples <= 0 {
+		return out, nil
+	}
+	for i := range out {
+		a, b := id, out[i].PersonID
+		if a > b {
+			a, b = b, a
+		}
+		ex, err := db.Query(
+			`SELECT pc.media_path FROM person_cooccur pc
+			 LEFT JOIN media m ON m.path = pc.media_path
+			 WHERE pc.person_a = ? AND pc.person_b = ?
+			 ORDER BY m.rowid DESC LIMIT ?`, a, b, examples)
+		if err != nil {
+			return nil, err
+		}
+		for ex.Next() {
+			var p string
+			if err := ex.Scan(&p); err != nil {
+				ex.Close()
+				return nil, err
+			}
+			out[i].Examples = append(out[i].Examples, p)
+		}
+		ex.Close()
+		if err := ex.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// CooccurrenceNode is one person in the co-occurrence graph.
+type CooccurrenceNode struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	MediaCount int    `json:"mediaCount"`
+}
+
+// CooccurrenceEdge links two people who share Weight media items.
+type CooccurrenceEdge struct {
+	Source int64 `json:"source"`
+	Target int64 `json:"target"`
+	Weight int   `json:"weight"`
+}
+
+// CooccurrenceGraph is the whole who-appears-with-whom network.
+type CooccurrenceGraph struct {
+	Nodes []CooccurrenceNode `json:"nodes"`
+	Edges []CooccurrenceEdge `json:"edges"`
+}
+
+// GetCooccurrenceGraph returns every pair sharing at least minWeight media
+// items, and the people on those edges. named drops unnamed auto-clusters.
+func GetCooccurrenceGraph(db *sql.DB, minWeight int, named bool) (CooccurrenceGraph, error) {
+	if minWeight < 1 {
+		minWeight = 1
+	}
+	g := CooccurrenceGraph{Nodes: []CooccurrenceNode{}, Edges: []CooccurrenceEdge{}}
+	nameFilter := ""
+	if named {
+		nameFilter = ` AND pa.name NOT LIKE '` + unnamedPersonPattern + `' AND pb.name NOT LIKE '` + unnamedPersonPattern + `'`
+	}
+	rows, err := db.Query(`
+		SELECT pc.person_a, pc.person_b, COUNT(*)
+		FROM person_cooccur pc
+		JOIN person pa ON pa.id = pc.person_a
+		JOIN person pb ON pb.id = pc.person_b
+		WHERE 1=1`+nameFilter+`
+		GROUP BY pc.person_a, pc.person_b
+		HAVING COUNT(*) >= ?
+		ORDER BY pc.person_a, pc.person_b`, minWeight)
+	if err != nil {
+		return g, err
+	}
+	onEdge := map[int64]bool{}
+	for rows.Next() {
+		var e CooccurrenceEdge
+		if err := rows.Scan(&e.Source, &e.Target, &e.Weight); err != nil {
+			rows.Close()
+			return g, err
+		}
+		g.Edges = append(g.Edges, e)
+		onEdge[e.Source], onEdge[e.Target] = true, true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return g, err
+	}
+	if len(onEdge) == 0 {
+		return g, nil
+	}
+	people, err := db.Query(`
+		SELECT p.id, COALESCE(p.name, ''), COUNT(DISTINCT f.media_path)
+		FROM person p
+		LEFT JOIN face f ON f.person_id = p.id AND f.media_path IN (SELECT path FROM media)
+		GROUP BY p.id
+		ORDER BY p.id`)
+	if err != nil {
+		return g, err
+	}
+	defer people.Close()
+	for people.Next() {
+		var n CooccurrenceNode
+		if err := people.Scan(&n.ID, &n.Name, &n.MediaCount); err != nil {
+			return g, err
+		}
+		if onEdge[n.ID] {
+			g.Nodes = append(g.Nodes, n)
+		}
+	}
+	return g, people.Err()
+}
@@ -0,0 +1,171 @@
+package media
+
+import (
+	"database/sql"
+	"testing"
+)
+
+// pairCount is how many media items a and b share in person_cooccur.
+func pairCount(t *testing.T, db *sql.DB, a, b int64) int {
+	t.Helper()
+	if a > b {
+		a, b = b, a
+	}
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM person_cooccur WHERE person_a = ? AND person_b = ?`, a, b).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+// twoFaces stores two faces on path and returns their ids.
+func twoFaces(t *testing.T, db *sql.DB, path string) []int64 {
+	t.Helper()
+	ids, err := ReplaceFaces(db, path, "m1", []NewFace{{Score: 0.9, Vec: []float32{1}}, {Score: 0.8, Vec: []float32{1}}}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ids
+}
+
+func TestCooccurrenceFollowsAssignments(t *testing.T) {
+	db := newPeopleDB(t)
+	alice, _ := CreatePerson(db, "Alice")
+	bob, _ := CreatePerson(db, "Bob")
+	unknown, _ := CreatePerson(db, "Unknown #1")
+	a := twoFaces(t, db, "a.jpg")
+	b := twoFaces(t, db, "b.jpg")
+
+	_ = AssignFace(db, a[0], alice, "user")
+	if n := pairCount(t, db, alice, bob); n != 0 {
+		t.Fatalf("pairs before Bob = %d", n)
+	}
+	_ = AssignFace(db, a[1], bob, "user")
+	if _, err := AssignFacesAuto(db, []FaceAssignment{{FaceID: b[0], PersonID: alice}, {FaceID: b[1], PersonID: unknown}}); err != nil {
+		t.Fatal(err)
+	}
+	if n := pairCount(t, db, alice, bob); n != 1 {
+		t.Fatalf("Alice+Bob = %d, want 1", n)
+	}
+	if n := pairCount(t, db, alice, unknown); n != 1 {
+		t.Fatalf("Alice+Unknown = %d, want 1", n)
+	}
+
+	// Naming the unknown as Bob: their pair folds into Alice+Bob.
+	if err := MergePersons(db, unknown, bob); err != nil {
+		t.Fatal(err)
+	}
+	if n := pairCount(t, db, alice, bob); n != 2 {
+		t.Fatalf("Alice+Bob after merge = %d, want 2", n)
+	}
+	if n := pairCount(t, db, alice, unknown); n != 0 {
+		t.Fatalf("merged-away pair remains: %d", n)
+	}
+
+	if err := UnassignFace(db, a[1]); err != nil {
+		t.Fatal(err)
+	}
+	if n := pairCount(t, db, alice, bob); n != 1 {
+		t.Fatalf("Alice+Bob after unassign = %d, want 1", n)
+	}
+	// A rescan drops the item's assignments, and its pairs with them.
+	if _, err := ReplaceFaces(db, "b.jpg", "m1", nil, 2); err != nil {
+		t.Fatal(err)
+	}
+	if n := pairCount(t, db, alice, bob); n != 0 {
+		t.Fatalf("Alice+Bob after rescan = %d, want 0", n)
+	}
+
+	_ = AssignFace(db, a[1], bob, "user")
+	if err := DeletePerson(db, bob); err != nil {
+		t.Fatal(err)
+	}
+	var left int
+	db.QueryRow(`SELECT COUNT(*) FROM person_cooccur`).Scan(&left)
+	if left != 0 {
+		t.Fatalf("rows after deleting Bob = %d", left)
+	}
+}
+
+func TestPersonCooccurrenceAndGraph(t *testing.T) {
+	db := newPeopleDB(t)
+	if _, err := db.Exec(`INSERT INTO media (path) VALUES ('c.jpg')`); err != nil {
+		t.Fatal(err)
+	}
+	alice, _ := CreatePerson(db, "Alice")
+	bob, _ := CreatePerson(db, "Bob")
+	unknown, _ := CreatePerson(db, "Unknown #1")
+	for _, p := range []string{"a.jpg", "b.jpg"} {
+		ids := twoFaces(t, db, p)
+		_ = AssignFace(db, ids[0], alice, "user")
+		_ = AssignFace(db, ids[1], bob, "user")
+	}
+	ids := twoFaces(t, db, "c.jpg")
+	_ = AssignFace(db, ids[0], alice, "user")
+	_ = AssignFace(db, ids[1], unknown, "auto")
+
+	co, err := PersonCooccurrence(db, alice, 10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(co) != 2 || co[0].PersonID != bob || co[0].Count != 2 || co[1].PersonID != unknown || co[1].Count != 1 {
+		t.Fatalf("cooccurrence = %+v", co)
+	}
+	if len(co[0].Examples) != 1 || co[0].Examples[0] != "b.jpg" {
+		t.Fatalf("examples = %v, want newest item b.jpg", co[0].Examples)
+	}
+
+	g, err := GetCooccurrenceGraph(db, 1, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Edges) != 1 || g.Edges[0].Weight != 2 || len(g.Nodes) != 2 {
+		t.Fatalf("named graph = %+v", g)
+	}
+	if g.Nodes[0].Name != "Alice" || g.Nodes[0].MediaCount != 3 {
+		t.Fatalf("Alice node = %+v", g.Nodes[0])
+	}
+	if g, _ = GetCooccurrenceGraph(db, 1, false); len(g.Edges) != 2 || len(g.Nodes) != 3 {
+		t.Fatalf("full graph = %+v", g)
+	}
+	if g, _ = GetCooccurrenceGraph(db, 3, false); len(g.Edges) != 0 || len(g.Nodes) != 0 {
+		t.Fatalf("min-weight graph = %+v", g)
+	}
+
+	// with: needs another NAMED person; together: needs all of them.
+	for q, want := range map[string]int{
+		`with:Alice`:                2,
+		`with:Bob`:                  2,
+		`together:Alice,Bob`:        2,
+		`together:"Alice","Bob"`:    2,
+		`together:Bob,"Unknown #1"`: 0,
+		`together:Alice`:            0,
+	} {
+		items, _, _, err := GetItems(db, 0, 10, q)
+		if err != nil {
+			t.Fatalf("%s: %v", q, err)
+		}
+		if len(items) != want {
+			t.Errorf("%s = %d items, want %d", q, len(items), want)
+		}
+	}
+}
+
+func TestBackfillCooccurrence(t *testing.T) {
+	db := newPeopleDB(t)
+	alice, _ := CreatePerson(db, "Alice")
+	bob, _ := CreatePerson(db, "Bob")
+	ids := twoFaces(t, db, "a.jpg")
+	_ = AssignFace(db, ids[0], alice, "user")
+	_ = AssignFace(db, ids[1], bob, "user")
+	// A library from before the table existed: assignments, no pairs.
+	if _, err := db.Exec(`DELETE FROM person_cooccur`); err != nil {
+		t.Fatal(err)
+	}
+	if err := InitializeSchema(db); err != nil {
+		t.Fatal(err)
+	}
+	if n := pairCount(t, db, alice, bob); n != 1 {
+		t.Fatalf("backfilled pairs = %d, want 1", n)
+	}
+}
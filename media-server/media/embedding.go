@@ -134,3 +134,59 @@ func LoadAllEmbeddings(db *sql.DB, model string) ([]StoredEmbedding, error) {
 	}
 	return out, rows.Err()
 }
+
+// CountEmbeddings returns how many items have an embedding under model.
+func CountEmbeddings(db *sql.DB, model string) (int, error) {
+	var n int
+	err := db.QueryRow(`SELECT COUNT(*) FROM media_embedding WHERE model = ?`, model).Scan(&n)
+	return n, err
+}
+
+// ForEachEmbeddingBatch streams model's embeddings in rowid order, batch rows
+// at a time, so a library-wide pass holds one batch of vectors in memory
+// rather than all of them. Keyset pagination keeps every page an index seek.
+// A non-nil error from fn stops the scan and is returned.
+func ForEachEmbeddingBatch(db *sql.DB, model string, batch int, fn func([]StoredEmbedding) error) error {
+	if batch <= 0 {
+		batch = 1024
+	}
+	var after int64
+	for {
+		rows, err := db.Query(
+			`SELECT rowid, media_path, vector FROM media_embedding WHERE model = ? AND rowid > ? ORDER BY rowid LIMIT ?`,
+			model, after, batch,
+		)
+		if err != nil {
+			return err
+		}
+		page := make([]StoredEmbedding, 0, batch)
+		for rows.Next() {
+			var path string
+			var blob []byte
+			if err := rows.Scan(&after, &path, &blob); err != nil {
+				rows.Close()
+				return err
+			}
+			v, err := embedvec.Decode(blob)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			page = append(page, StoredEmbedding{Path: path, Vec: v})
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return err
+		}
+		rows.Close()
+		if len(page) == 0 {
+			return nil
+		}
+		if err := fn(page); err != nil {
+			return err
+		}
+		if len(page) < batch {
+			return nil
+		}
+	}
+}
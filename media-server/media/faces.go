@@ -22,16 +22,29 @@ type Face struct {
 	PersonID   int64
 	AssignedBy string
 	CreatedAt  int64
+	// Tracked marks a face from a multi-frame video scan: one row per track,
+	// FrameTS is the exact timestamp of its best detection, and TrackStart..
+	// TrackEnd is the span the face was followed over. Untracked video faces
+	// come from the single midpoint frame, whatever FrameTS says.
+	Tracked              bool
+	TrackStart, TrackEnd float64
 }
 
 // NewFace is one detected face to persist (ID assigned by the DB).
 type NewFace struct {
-	FrameTS    float64
-	X, Y, W, H float64
-	Score      float64
-	Vec        []float32
+	FrameTS              float64
+	X, Y, W, H           float64
+	Score                float64
+	Vec                  []float32
+	Tracked              bool
+	TrackStart, TrackEnd float64
 }
 
+// faceColumns is the SELECT list scanFaceRows reads.
+const faceColumns = `id, media_path, model, frame_ts, bbox_x, bbox_y, bbox_w, bbox_h,
+		        det_score, vector, COALESCE(person_id, 0), COALESCE(assigned_by, ''), COALESCE(created_at, 0),
+		        track_start, track_end`
+
 // ReplaceFaces atomically replaces all stored faces for path with faces
 // under model and records the scan in face_scan. The new scan is
 // authoritative for the WHOLE item, not just its own recognizer: rows and
@@ -73,6 +86,10 @@ func ReplaceFaces(db *sql.DB, path, model string, faces []NewFace, scannedAt int
 	if _, err := tx.Exec(`DELETE FROM face WHERE media_path=?`, path); err != nil {
 		return nil, fmt.Errorf("clear stale faces: %w", err)
 	}
+	// The fresh rows arrive unassigned, so the item shares no pairs anymore.
+	if _, err := tx.Exec(`DELETE FROM person_cooccur WHERE media_path=?`, path); err != nil {
+		return nil, fmt.Errorf("clear stale co-occurrence: %w", err)
+	}
 	// Other models' scan markers are superseded along with their rows; ours is
 	// upserted below.
 	if _, err := tx.Exec(`DELETE FROM face_scan WHERE media_path=? AND model<>?`, path, model); err != nil {
@@ -80,10 +97,14 @@ func ReplaceFaces(db *sql.DB, path, model string, faces []NewFace, scannedAt int
 	}
 	ids := make([]int64, 0, len(faces))
 	for _, f := range faces {
+		var start, end any
+		if f.Tracked {
+			start, end = f.TrackStart, f.TrackEnd
+		}
 		res, err := tx.Exec(
-			`INSERT INTO face (media_path, model, frame_ts, bbox_x, bbox_y, bbox_w, bbox_h, det_score, vector, created_at)
-			 VALUES (?,?,?,?,?,?,?,?,?,?)`,
-			path, model, f.FrameTS, f.X, f.Y, f.W, f.H, f.Score, embedvec.Encode(f.Vec), scannedAt,
+			`INSERT INTO face (media_path, model, frame_ts, bbox_x, bbox_y, bbox_w, bbox_h, det_score, vector, created_at, track_start, track_end)
+			 VALUES (?,?,?,?,?,?,?,?,?,?,?,?)`,
+			path, model, f.FrameTS, f.X, f.Y, f.W, f.H, f.Score, embedvec.Encode(f.Vec), scannedAt, start, end,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("insert face: %w", err)
@@ -140,11 +161,19 @@ func CleanupSupersededFaces(db *sql.DB) (int, error) {
 	if err := clearConstraintsForFacesTx(tx, supersededFaceIDs); err != nil {
 		return 0, fmt.Errorf("clear superseded face constraints: %w", err)
 	}
+	paired, err := queryStringsTx(tx,
+		`SELECT DISTINCT media_path FROM face WHERE person_id > 0 AND id IN (`+supersededFaceIDs+`)`)
+	if err != nil {
+		return 0, fmt.Errorf("list superseded assignments: %w", err)
+	}
 	res, err := tx.Exec(`DELETE FROM face WHERE id IN (` + supersededFaceIDs + `)`)
 	if err != nil {
 		return 0, fmt.Errorf("delete superseded faces: %w", err)
 	}
 	removed, _ := res.RowsAffected()
+	if err := refreshCooccurrenceTx(tx, paired); err != nil {
+		return 0, fmt.Errorf("refresh superseded co-occurrence: %w", err)
+	}
 	if _, err := tx.Exec(
 		`DELETE FROM face_scan
 		 WHERE COALESCE(scanned_at, 0) < (
@@ -275,8 +304,7 @@ func FaceScanInfo(db *sql.DB, path, model string) (bool, int64, error) {
 // descending.
 func GetFaces(db *sql.DB, path, model string) ([]Face, error) {
 	rows, err := db.Query(
-		`SELECT id, media_path, model, frame_ts, bbox_x, bbox_y, bbox_w, bbox_h,
-		        det_score, vector, COALESCE(person_id, 0), COALESCE(assigned_by, ''), COALESCE(created_at, 0)
+		`SELECT `+faceColumns+`
 		 FROM face WHERE media_path=? AND model=? ORDER BY det_score DESC`,
 		path, model,
 	)
@@ -291,8 +319,7 @@ func GetFaces(db *sql.DB, path, model string) ([]Face, error) {
 // the face index builder and clustering.
 func LoadAllFaces(db *sql.DB, model string) ([]Face, error) {
 	rows, err := db.Query(
-		`SELECT id, media_path, model, frame_ts, bbox_x, bbox_y, bbox_w, bbox_h,
-		        det_score, vector, COALESCE(person_id, 0), COALESCE(assigned_by, ''), COALESCE(created_at, 0)
+		`SELECT `+faceColumns+`
 		 FROM face WHERE model=?`,
 		model,
 	)
@@ -306,8 +333,7 @@ func LoadAllFaces(db *sql.DB, model string) ([]Face, error) {
 // GetFaceByID returns one face row (vector decoded).
 func GetFaceByID(db *sql.DB, id int64) (Face, bool, error) {
 	rows, err := db.Query(
-		`SELECT id, media_path, model, frame_ts, bbox_x, bbox_y, bbox_w, bbox_h,
-		        det_score, vector, COALESCE(person_id, 0), COALESCE(assigned_by, ''), COALESCE(created_at, 0)
+		`SELECT `+faceColumns+`
 		 FROM face WHERE id=?`,
 		id,
 	)
@@ -351,6 +377,9 @@ func DeleteFacesForMedia(db *sql.DB, path string) error {
 	if _, err := db.Exec(`DELETE FROM face WHERE media_path=?`, path); err != nil {
 		return err
 	}
+	if _, err := db.Exec(`DELETE FROM person_cooccur WHERE media_path=?`, path); err != nil {
+		return err
+	}
 	_, err := db.Exec(`DELETE FROM face_scan WHERE media_path=?`, path)
 	return err
 }
@@ -367,13 +396,18 @@ func scanFaceRows(rows *sql.Rows) ([]Face, error) {
 	for rows.Next() {
 		var f Face
 		var blob []byte
+		var start, end sql.NullFloat64
 		if err := rows.Scan(
 			&f.ID, &f.MediaPath, &f.Model, &f.FrameTS,
 			&f.X, &f.Y, &f.W, &f.H,
 			&f.Score, &blob, &f.PersonID, &f.AssignedBy, &f.CreatedAt,
+			&start, &end,
 		); err != nil {
 			return nil, err
 		}
+		if start.Valid && end.Valid {
+			f.Tracked, f.TrackStart, f.TrackEnd = true, start.Float64, end.Float64
+		}
 		vec, err := embedvec.Decode(blob)
 		if err != nil {
 			return nil, err
@@ -2,6 +2,7 @@ package media
 
 import (
 	"database/sql"
+	"strings"
 	"testing"
 )
 
@@ -208,6 +209,47 @@ func TestGetPathsByQuery_TranscriptTargeting(t *testing.T) {
 	}
 }
 
+// TestGetPathsByQuery_Translation covers translation:<lang>[:text] — the
+// translate task's per-language transcripts, searched one language at a time.
+func TestGetPathsByQuery_Translation(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE media_transcript (media_path TEXT, lang TEXT, transcript TEXT, source TEXT, model TEXT, created_at INTEGER)`); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{"/lib/a.mp4", "/lib/b.mp4", "/lib/c.mp4"} {
+		if _, err := db.Exec("INSERT INTO media (path) VALUES (?)", p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, r := range [][3]string{
+		{"/lib/a.mp4", "es", "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nHola amigos"},
+		{"/lib/a.mp4", "fr", "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nBonjour"},
+		{"/lib/b.mp4", "pt-br", "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nOla"},
+	} {
+		if _, err := db.Exec("INSERT INTO media_transcript (media_path, lang, transcript) VALUES (?, ?, ?)", r[0], r[1], r[2]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for q, want := range map[string]string{
+		`translation:es`:                               "/lib/a.mp4",
+		`translation:pt_BR`:                            "/lib/b.mp4",
+		`translation:es:"*hola*"`:                      "/lib/a.mp4",
+		`translation:fr:"*hola*"`:                      "",
+		`translation:es AND path:*b.mp4`:               "",
+		`translation:klingon`:                          "",
+		`NOT translation:fr AND NOT translation:pt-br`: "/lib/c.mp4",
+	} {
+		paths, err := GetPathsByQuery(db, q)
+		if err != nil {
+			t.Fatalf("%s: %v", q, err)
+		}
+		if got := strings.Join(paths, ","); got != want {
+			t.Errorf("%s matched %q, want %q", q, got, want)
+		}
+	}
+}
+
 // TestGetPathsByQuery_FacesUngrouped pins the faces:ungrouped predicate the
 // People panel's Ungrouped card emits: only media whose detected faces are
 // ALL unassigned match — one grouped face disqualifies the item (it already
@@ -223,11 +265,12 @@ func TestGetPathsByQuery_FacesUngrouped(t *testing.T) {
 		}
 	}
 	// setupTestDB's manual schema has the face table but not the constraint
-	// tables ReplaceFaces reconciles — create those minimally.
+	// and co-occurrence tables ReplaceFaces reconciles — create those minimally.
 	for _, stmt := range []string{
 		`CREATE TABLE IF NOT EXISTS face_veto (face_id INTEGER, person_id INTEGER)`,
 		`CREATE TABLE IF NOT EXISTS face_cannot_link (face_a INTEGER, face_b INTEGER)`,
 		`CREATE TABLE IF NOT EXISTS face_group_ban_member (ban_id INTEGER, face_id INTEGER)`,
+		`CREATE TABLE IF NOT EXISTS person_cooccur (person_a INTEGER, person_b INTEGER, media_path TEXT)`,
 	} {
 		if _, err := db.Exec(stmt); err != nil {
 			t.Fatal(err)
@@ -336,6 +336,12 @@ func swipeOrientationSQL(orientation, colPrefix string) string {
 }
 
 func GetRandomItems(db *sql.DB, offset, limit int, searchQuery string, seed int64, orientation string) ([]MediaItem, bool, error) {
+	return GetRandomItemsForUser(db, "", offset, limit, searchQuery, seed, orientation)
+}
+
+// GetRandomItemsForUser is GetRandomItems with my: predicates bound to
+// user's likes and ratings (see NewUserParser).
+func GetRandomItemsForUser(db *sql.DB, user string, offset, limit int, searchQuery string, seed int64, orientation string) ([]MediaItem, bool, error) {
 	orientation = NormalizeOrientation(orientation)
 
 	// Fast path: no search filter (the dominant swipe case). Use the
@@ -364,7 +370,7 @@ func GetRandomItems(db *sql.DB, offset, limit int, searchQuery string, seed int6
 
 	// Parse search query if provided
 	if strings.TrimSpace(searchQuery) != "" {
-		parser := NewParser(searchQuery)
+		parser := NewUserParser(searchQuery, user)
 		var err error
 		rootNode, err = parser.Parse()
 		if err != nil {
@@ -862,6 +868,12 @@ func getRandomItemsWithExistenceFilter(db *sql.DB, baseQuery, whereClause string
 
 // GetItems fetches media items from the database with pagination and search
 func GetItems(db *sql.DB, offset, limit int, searchQuery string) ([]MediaItem, int, bool, error) {
+	return GetItemsForUser(db, "", offset, limit, searchQuery)
+}
+
+// GetItemsForUser is GetItems with my: predicates bound to user's likes and
+// ratings (see NewUserParser).
+func GetItemsForUser(db *sql.DB, user string, offset, limit int, searchQuery string) ([]MediaItem, int, bool, error) {
 	baseQuery := `SELECT DISTINCT m.path, m.description, m.size, m.hash, m.width, m.height FROM media m`
 	orderBy := ` ORDER BY m.path`
 
@@ -871,7 +883,7 @@ func GetItems(db *sql.DB, offset, limit int, searchQuery string) ([]MediaItem, i
 
 	// Parse search query if provided
 	if strings.TrimSpace(searchQuery) != "" {
-		parser := NewParser(searchQuery)
+		parser := NewUserParser(searchQuery, user)
 		var err error
 		rootNode, err = parser.Parse()
 		if err != nil {
@@ -1304,11 +1316,13 @@ func RemoveItemsFromDBStream(ctx context.Context, db *sql.DB, paths []string, on
 		}
 		in := strings.Join(placeholders, ",")
 
-		// Sidecar rows first: tags, embeddings (visual-similarity), then face
-		// rows + scan markers (face-identity). Person covers pointing at the
-		// doomed faces are cleared before the faces go so they don't dangle
-		// (GetPeople falls back to the person's best face). The removal hook
-		// evicts both indexes once the batch commits.
+		// Sidecar rows first: tags, embeddings (visual-similarity), semantic
+		// text chunks, theme and collection memberships (collection covers
+		// are cleared), then face rows + scan markers (face-identity). Person
+		// covers pointing at the doomed faces are cleared before the faces go
+		// so they don't dangle (GetPeople falls back to the person's best
+		// face). The removal hook evicts the live indexes once the batch
+		// commits.
 		type batchStmt struct {
 			label string
 			sql   string
@@ -1318,6 +1332,10 @@ func RemoveItemsFromDBStream(ctx context.Context, db *sql.DB, paths []string, on
 		stmts := []batchStmt{
 			{"media tags", `DELETE FROM media_tag_by_category WHERE media_path IN (%s)`, &batchTagsRemoved},
 			{"embeddings", `DELETE FROM media_embedding WHERE media_path IN (%s)`, nil},
+			{"text chunks", `DELETE FROM media_text_chunk WHERE media_path IN (%s)`, nil},
+			{"cluster members", `DELETE FROM media_cluster_member WHERE media_path IN (%s)`, nil},
+			{"collection entries", `DELETE FROM collection_item WHERE media_path IN (%s)`, nil},
+			{"collection covers", `UPDATE collection SET cover_path = NULL WHERE cover_path IN (%s)`, nil},
 			{"person covers", `UPDATE person SET cover_face_id = NULL WHERE cover_face_id IN (SELECT id FROM face WHERE media_path IN (%s))`, nil},
 			{"face rows", `DELETE FROM face WHERE media_path IN (%s)`, nil},
 			{"face scan markers", `DELETE FROM face_scan WHERE media_path IN (%s)`, nil},
@@ -1337,6 +1355,12 @@ func RemoveItemsFromDBStream(ctx context.Context, db *sql.DB, paths []string, on
 				*s.count, _ = res.RowsAffected()
 			}
 		}
+		// Per-user likes and ratings, people co-occurrence, and translated
+		// transcripts exist only in server-created databases; a viewer-only
+		// library has none to clear.
+		for _, table := range []string{"user_like", "user_media_stats", "person_cooccur", "media_transcript"} {
+			_, _ = tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE media_path IN (%s)`, table, in), args...)
+		}
 		totalTagsRemoved += batchTagsRemoved
 		totalMediaRemoved += batchMediaRemoved
 
@@ -2301,6 +2325,57 @@ func InitializeSchema(db *sql.DB) error {
 	); err != nil {
 		log.Printf("warning: failed to create idx_battle_loser: %v", err)
 	}
+	// username marks a vote cast against a user's own ratings (see
+	// user_media_stats); NULL is the library-wide ranking on media.
+	_, _ = db.Exec(`ALTER TABLE battle ADD COLUMN username TEXT`)
+	if _, err := db.Exec(
+		`CREATE INDEX IF NOT EXISTS idx_battle_username ON battle(username)`,
+	); err != nil {
+		log.Printf("warning: failed to create idx_battle_username: %v", err)
+	}
+
+	// Per-user overlays of the rating columns and the favorites tag. The
+	// elo/views/wins/losses/battles columns on media and the Swipe/Favorites
+	// tag stay the library-wide view (anonymous clients and accounts on the
+	// library profile); every other account rates and likes into these
+	// tables instead, so one user's taste never bends another's feed or
+	// ranking.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS user_like (
+			username   TEXT NOT NULL,
+			media_path TEXT NOT NULL,
+			created_at INTEGER NOT NULL,
+			PRIMARY KEY (username, media_path)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create user_like table: %w", err)
+	}
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS user_media_stats (
+			username   TEXT NOT NULL,
+			media_path TEXT NOT NULL,
+			elo        REAL,
+			views      INTEGER,
+			wins       INTEGER,
+			losses     INTEGER,
+			battles    INTEGER,
+			PRIMARY KEY (username, media_path)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create user_media_stats table: %w", err)
+	}
+	if _, err := db.Exec(
+		`CREATE INDEX IF NOT EXISTS idx_user_like_path ON user_like(media_path)`,
+	); err != nil {
+		log.Printf("warning: failed to create idx_user_like_path: %v", err)
+	}
+	if _, err := db.Exec(
+		`CREATE INDEX IF NOT EXISTS idx_user_media_stats_path ON user_media_stats(media_path)`,
+	); err != nil {
+		log.Printf("warning: failed to create idx_user_media_stats_path: %v", err)
+	}
 
 	// Create users table
 	_, err = db.Exec(`
@@ -2314,6 +2389,48 @@ func InitializeSchema(db *sql.DB) error {
 	if err != nil {
 		return fmt.Errorf("failed to create users table: %w", err)
 	}
+	// Per-user access: role is viewer/curator/admin (accounts that predate
+	// roles stay admins); roots is a JSON list of storage roots a non-admin
+	// is confined to ('' = all of them).
+	_, _ = db.Exec(`ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'admin'`)
+	_, _ = db.Exec(`ALTER TABLE users ADD COLUMN roots TEXT NOT NULL DEFAULT ''`)
+	// Optional TOTP second factor: totp_secret is set while enrolling and
+	// only enforced once totp_enabled; totp_last_step refuses code replay.
+	_, _ = db.Exec(`ALTER TABLE users ADD COLUMN totp_secret TEXT NOT NULL DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE users ADD COLUMN totp_enabled INTEGER NOT NULL DEFAULT 0`)
+	_, _ = db.Exec(`ALTER TABLE users ADD COLUMN totp_last_step INTEGER NOT NULL DEFAULT 0`)
+	// Single sign-on: sso_subject is the identity an account is linked to
+	// (provider-qualified, '' = none); sso_created marks accounts SSO
+	// provisioned, the only ones whose role follows the identity's groups.
+	// Accounts without a password predate the column and were all made by
+	// SSO, so they are marked as the column is added.
+	_, _ = db.Exec(`ALTER TABLE users ADD COLUMN sso_subject TEXT NOT NULL DEFAULT ''`)
+	if _, err := db.Exec(`ALTER TABLE users ADD COLUMN sso_created INTEGER NOT NULL DEFAULT 0`); err == nil {
+		_, _ = db.Exec(`UPDATE users SET sso_created = 1 WHERE password_hash = ''`)
+	}
+	// library_profile opts an account into rating as the library-wide
+	// layer (media's own columns) instead of its per-user overlay.
+	_, _ = db.Exec(`ALTER TABLE users ADD COLUMN library_profile INTEGER NOT NULL DEFAULT 0`)
+	if _, err := db.Exec(
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_users_sso_subject ON users(sso_subject) WHERE sso_subject != ''`,
+	); err != nil {
+		log.Printf("warning: failed to create idx_users_sso_subject: %v", err)
+	}
+
+	// Create user_recovery_codes table (single-use second-factor fallbacks;
+	// only their SHA-256 hash is stored, used_at = 0 while unspent)
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS user_recovery_codes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			code_hash TEXT NOT NULL UNIQUE,
+			created_at INTEGER NOT NULL,
+			used_at INTEGER NOT NULL DEFAULT 0
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create user_recovery_codes table: %w", err)
+	}
 
 	// Create api_keys table (long-lived credentials for lokictl / automation;
 	// plaintext keys are never stored, only their SHA-256 hash)
@@ -2331,6 +2448,11 @@ func InitializeSchema(db *sql.DB) error {
 	if err != nil {
 		return fmt.Errorf("failed to create api_keys table: %w", err)
 	}
+	// Optional narrowing: JSON scope list ('' = the owner's full access),
+	// unix expiry (0 = never), and JSON source-CIDR allow-list ('' = any).
+	_, _ = db.Exec(`ALTER TABLE api_keys ADD COLUMN scopes TEXT NOT NULL DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE api_keys ADD COLUMN expires_at INTEGER NOT NULL DEFAULT 0`)
+	_, _ = db.Exec(`ALTER TABLE api_keys ADD COLUMN allowed_cidrs TEXT NOT NULL DEFAULT ''`)
 
 	// Index on tag_label for the typed-query hot paths. The composite PK on
 	// (media_path, tag_label, ...) only helps queries that lead with
@@ -2373,6 +2495,228 @@ func InitializeSchema(db *sql.DB) error {
 		log.Printf("warning: failed to create idx_media_embedding_model: %v", err)
 	}
 
+	// Semantic text chunks: descriptions and transcripts cut into windows and
+	// embedded with the configured sentence encoder (appconfig
+	// TextEmbedModel). Model-keyed like media_embedding; start_ms/end_ms
+	// place transcript windows on the media timeline so a semantic hit can
+	// seek to the matching line.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS media_text_chunk (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			media_path TEXT NOT NULL,
+			model      TEXT NOT NULL,
+			seq        INTEGER NOT NULL,
+			source     TEXT NOT NULL,
+			start_ms   INTEGER NOT NULL DEFAULT 0,
+			end_ms     INTEGER NOT NULL DEFAULT 0,
+			text       TEXT NOT NULL,
+			vector     BLOB NOT NULL,
+			created_at INTEGER
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create media_text_chunk table: %w", err)
+	}
+	if _, err := db.Exec(
+		`CREATE INDEX IF NOT EXISTS idx_media_text_chunk_path ON media_text_chunk(media_path, model)`,
+	); err != nil {
+		log.Printf("warning: failed to create idx_media_text_chunk_path: %v", err)
+	}
+
+	// Translated transcripts (translate task): one WebVTT per language,
+	// alongside the original in media.transcript. source records how it was
+	// made ("llm" or "whisper") and model which model made it.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS media_transcript (
+			media_path TEXT NOT NULL,
+			lang       TEXT NOT NULL,
+			transcript TEXT NOT NULL,
+			source     TEXT NOT NULL DEFAULT '',
+			model      TEXT NOT NULL DEFAULT '',
+			created_at INTEGER,
+			PRIMARY KEY (media_path, lang)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create media_transcript table: %w", err)
+	}
+
+	// Library themes (cluster-library task): k-means clusters over one
+	// model's embeddings, replaced wholesale on every run. Membership is the
+	// only path-keyed table — sizes and covers are computed from it at read
+	// time, so deleting or moving an item never leaves a stale count. score
+	// is the member's cosine to its centroid (covers = most typical members).
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS media_cluster (
+			id           INTEGER PRIMARY KEY AUTOINCREMENT,
+			model        TEXT NOT NULL,
+			label        TEXT NOT NULL DEFAULT '',
+			label_source TEXT NOT NULL DEFAULT '',
+			centroid     BLOB,
+			created_at   INTEGER
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create media_cluster table: %w", err)
+	}
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS media_cluster_member (
+			cluster_id INTEGER NOT NULL,
+			media_path TEXT NOT NULL,
+			score      REAL NOT NULL DEFAULT 0,
+			PRIMARY KEY (cluster_id, media_path)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create media_cluster_member table: %w", err)
+	}
+	if _, err := db.Exec(
+		`CREATE INDEX IF NOT EXISTS idx_media_cluster_member_path ON media_cluster_member(media_path)`,
+	); err != nil {
+		log.Printf("warning: failed to create idx_media_cluster_member_path: %v", err)
+	}
+
+	// Saved searches: a named query stored server-side so the UI, tasks
+	// (--saved) and lokictl can re-run it. Exactly one of predicates (the
+	// browse UI's JSON Predicate list) or query (the task search DSL) is set.
+	// Not path-keyed — membership is re-evaluated on every run.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS saved_search (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			name       TEXT NOT NULL UNIQUE COLLATE NOCASE,
+			predicates TEXT NOT NULL DEFAULT '',
+			query      TEXT NOT NULL DEFAULT '',
+			mode       TEXT NOT NULL DEFAULT 'AND',
+			sort       TEXT NOT NULL DEFAULT '',
+			created_at INTEGER,
+			updated_at INTEGER
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create saved_search table: %w", err)
+	}
+
+	// Collections: named, hand-ordered lists of media (albums). An item may
+	// sit in any number of collections, once each, at an explicit position
+	// with an optional caption. Collections nest into folders (folders nest
+	// too; parent_id NULL = top level). collection_item.media_path and
+	// collection.cover_path are path-keyed — MovePath/MergeInto rewrite them
+	// and item removal drops the entries / clears the cover.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS collection_folder (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			name       TEXT NOT NULL,
+			parent_id  INTEGER,
+			created_at INTEGER
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create collection_folder table: %w", err)
+	}
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS collection (
+			id          INTEGER PRIMARY KEY AUTOINCREMENT,
+			name        TEXT NOT NULL UNIQUE COLLATE NOCASE,
+			description TEXT NOT NULL DEFAULT '',
+			folder_id   INTEGER,
+			cover_path  TEXT,
+			created_at  INTEGER,
+			updated_at  INTEGER
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create collection table: %w", err)
+	}
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS collection_item (
+			collection_id INTEGER NOT NULL,
+			media_path    TEXT NOT NULL,
+			position      INTEGER NOT NULL DEFAULT 0,
+			caption       TEXT NOT NULL DEFAULT '',
+			added_at      INTEGER,
+			PRIMARY KEY (collection_id, media_path)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create collection_item table: %w", err)
+	}
+	if _, err := db.Exec(
+		`CREATE INDEX IF NOT EXISTS idx_collection_item_path ON collection_item(media_path)`,
+	); err != nil {
+		log.Printf("warning: failed to create idx_collection_item_path: %v", err)
+	}
+
+	// Share links: anonymous, revocable access to one path, a tag, a query,
+	// a saved search, or a collection, with optional expiry, password
+	// (bcrypt), and view limit. A path share's path lives in media_path so
+	// MovePath/MergeInto rewrite it; other kinds use target. share_access is
+	// each share's bounded access log.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS share (
+			id            INTEGER PRIMARY KEY AUTOINCREMENT,
+			token         TEXT NOT NULL UNIQUE,
+			kind          TEXT NOT NULL,
+			target        TEXT NOT NULL DEFAULT '',
+			media_path    TEXT,
+			title         TEXT NOT NULL DEFAULT '',
+			password_hash TEXT NOT NULL DEFAULT '',
+			expires_at    INTEGER NOT NULL DEFAULT 0,
+			max_views     INTEGER NOT NULL DEFAULT 0,
+			views         INTEGER NOT NULL DEFAULT 0,
+			created_by    TEXT NOT NULL DEFAULT '',
+			created_at    INTEGER,
+			revoked_at    INTEGER NOT NULL DEFAULT 0
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create share table: %w", err)
+	}
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS share_access (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			share_id   INTEGER NOT NULL,
+			at         INTEGER NOT NULL,
+			remote     TEXT NOT NULL DEFAULT '',
+			route      TEXT NOT NULL DEFAULT '',
+			media_path TEXT NOT NULL DEFAULT '',
+			outcome    TEXT NOT NULL DEFAULT ''
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create share_access table: %w", err)
+	}
+	if _, err := db.Exec(
+		`CREATE INDEX IF NOT EXISTS idx_share_access_share ON share_access(share_id, id)`,
+	); err != nil {
+		log.Printf("warning: failed to create idx_share_access_share: %v", err)
+	}
+
+	// Audit log: one append-only row per mutating operation. Targets are
+	// newline-joined (paths can't contain newlines); before/after are short
+	// JSON summaries. Only retention pruning (PruneAudit) ever deletes.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS audit_log (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			at         INTEGER NOT NULL,
+			actor      TEXT NOT NULL DEFAULT '',
+			actor_kind TEXT NOT NULL DEFAULT '',
+			actor_id   TEXT NOT NULL DEFAULT '',
+			action     TEXT NOT NULL,
+			targets    TEXT NOT NULL DEFAULT '',
+			n_targets  INTEGER NOT NULL DEFAULT 0,
+			before     TEXT NOT NULL DEFAULT '',
+			after      TEXT NOT NULL DEFAULT ''
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create audit_log table: %w", err)
+	}
+	if _, err := db.Exec(
+		`CREATE INDEX IF NOT EXISTS idx_audit_log_at ON audit_log(at)`,
+	); err != nil {
+		log.Printf("warning: failed to create idx_audit_log_at: %v", err)
+	}
+
 	// Face identity tables (face detection/recognition feature). Decided up
 	// front because they're hard to reverse:
 	//   - bbox coordinates are RELATIVE ([0,1] of the image dimensions) so
@@ -2496,6 +2840,25 @@ func InitializeSchema(db *sql.DB) error {
 	if err != nil {
 		return fmt.Errorf("failed to create face_group_ban_member table: %w", err)
 	}
+	// Video face tracks: a multi-frame scan stores one row per track — the
+	// best detection, at frame_ts — and the span the face was followed over.
+	// NULL on images and single-frame (midpoint) scans, whose frame_ts of 0
+	// means "the midpoint frame", not the first one.
+	_, _ = db.Exec(`ALTER TABLE face ADD COLUMN track_start REAL`)
+	_, _ = db.Exec(`ALTER TABLE face ADD COLUMN track_end REAL`)
+	// Who appears together: one row per (person pair, media item), kept in
+	// step with assignments (see cooccur.go).
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS person_cooccur (
+			person_a   INTEGER NOT NULL,
+			person_b   INTEGER NOT NULL,
+			media_path TEXT NOT NULL,
+			PRIMARY KEY (person_a, person_b, media_path)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create person_cooccur table: %w", err)
+	}
 	for _, stmt := range []string{
 		`CREATE INDEX IF NOT EXISTS idx_face_media_path ON face(media_path)`,
 		`CREATE INDEX IF NOT EXISTS idx_face_model ON face(model)`,
@@ -2503,6 +2866,8 @@ func InitializeSchema(db *sql.DB) error {
 		`CREATE INDEX IF NOT EXISTS idx_face_veto_person ON face_veto(person_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_face_cannot_link_b ON face_cannot_link(face_b)`,
 		`CREATE INDEX IF NOT EXISTS idx_face_group_ban_member_face ON face_group_ban_member(face_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_person_cooccur_b ON person_cooccur(person_b)`,
+		`CREATE INDEX IF NOT EXISTS idx_person_cooccur_media ON person_cooccur(media_path)`,
 	} {
 		if _, err := db.Exec(stmt); err != nil {
 			log.Printf("warning: failed to create face index (will retry on next start): %v", err)
@@ -2519,6 +2884,11 @@ func InitializeSchema(db *sql.DB) error {
 	} else if n > 0 {
 		log.Printf("faces: removed %d stale face row(s) superseded by newer scans under another model", n)
 	}
+	// Libraries that had people before co-occurrence was tracked get their
+	// pairs once; afterwards every assignment change maintains them.
+	if err := backfillCooccurrence(db); err != nil {
+		log.Printf("warning: co-occurrence backfill failed (will retry on next start): %v", err)
+	}
 
 	log.Println("Database schema initialized successfully")
 	return nil
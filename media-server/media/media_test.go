@@ -354,6 +354,59 @@ func setupTestDB(t *testing.T) *sql.DB {
 		t.Fatalf("Failed to create media_embedding table: %v", err)
 	}
 
+	// Create media_text_chunk table (required by RemoveItemsFromDB).
+	if _, err := db.Exec(`
+		CREATE TABLE media_text_chunk (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			media_path TEXT NOT NULL,
+			model      TEXT NOT NULL,
+			seq        INTEGER NOT NULL,
+			source     TEXT NOT NULL,
+			start_ms   INTEGER NOT NULL DEFAULT 0,
+			end_ms     INTEGER NOT NULL DEFAULT 0,
+			text       TEXT NOT NULL,
+			vector     BLOB NOT NULL,
+			created_at INTEGER
+		)
+	`); err != nil {
+		t.Fatalf("Failed to create media_text_chunk table: %v", err)
+	}
+
+	// Create media_cluster_member table (required by RemoveItemsFromDB).
+	if _, err := db.Exec(`
+		CREATE TABLE media_cluster_member (
+			cluster_id INTEGER NOT NULL,
+			media_path TEXT NOT NULL,
+			score      REAL NOT NULL DEFAULT 0,
+			PRIMARY KEY (cluster_id, media_path)
+		)
+	`); err != nil {
+		t.Fatalf("Failed to create media_cluster_member table: %v", err)
+	}
+
+	// Create collection + collection_item tables (required by RemoveItemsFromDB).
+	if _, err := db.Exec(`
+		CREATE TABLE collection (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			name       TEXT NOT NULL UNIQUE COLLATE NOCASE,
+			cover_path TEXT
+		)
+	`); err != nil {
+		t.Fatalf("Failed to create collection table: %v", err)
+	}
+	if _, err := db.Exec(`
+		CREATE TABLE collection_item (
+			collection_id INTEGER NOT NULL,
+			media_path    TEXT NOT NULL,
+			position      INTEGER NOT NULL DEFAULT 0,
+			caption       TEXT NOT NULL DEFAULT '',
+			added_at      INTEGER,
+			PRIMARY KEY (collection_id, media_path)
+		)
+	`); err != nil {
+		t.Fatalf("Failed to create collection_item table: %v", err)
+	}
+
 	// Create face + face_scan tables (required by RemoveItemsFromDB).
 	if _, err := db.Exec(`
 		CREATE TABLE face (
@@ -369,7 +422,9 @@ func setupTestDB(t *testing.T) *sql.DB {
 			vector      BLOB NOT NULL,
 			person_id   INTEGER,
 			assigned_by TEXT,
-			created_at  INTEGER
+			created_at  INTEGER,
+			track_start REAL,
+			track_end   REAL
 		)
 	`); err != nil {
 		t.Fatalf("Failed to create face table: %v", err)
@@ -646,12 +701,33 @@ func TestRemoveItemsFromDBWithEnforcedForeignKeys(t *testing.T) {
 			vector BLOB NOT NULL, created_at INTEGER,
 			PRIMARY KEY (media_path, model),
 			FOREIGN KEY (media_path) REFERENCES media(path))`,
+		`CREATE TABLE media_text_chunk (
+			id INTEGER PRIMARY KEY AUTOINCREMENT, media_path TEXT NOT NULL,
+			model TEXT NOT NULL, seq INTEGER NOT NULL, source TEXT NOT NULL,
+			start_ms INTEGER NOT NULL DEFAULT 0, end_ms INTEGER NOT NULL DEFAULT 0,
+			text TEXT NOT NULL, vector BLOB NOT NULL, created_at INTEGER,
+			FOREIGN KEY (media_path) REFERENCES media(path))`,
+		`CREATE TABLE media_cluster_member (
+			cluster_id INTEGER NOT NULL, media_path TEXT NOT NULL,
+			score REAL NOT NULL DEFAULT 0,
+			PRIMARY KEY (cluster_id, media_path),
+			FOREIGN KEY (media_path) REFERENCES media(path))`,
+		`CREATE TABLE collection (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE COLLATE NOCASE, cover_path TEXT)`,
+		`CREATE TABLE collection_item (
+			collection_id INTEGER NOT NULL, media_path TEXT NOT NULL,
+			position INTEGER NOT NULL DEFAULT 0, caption TEXT NOT NULL DEFAULT '',
+			added_at INTEGER,
+			PRIMARY KEY (collection_id, media_path),
+			FOREIGN KEY (media_path) REFERENCES media(path))`,
 		`CREATE TABLE face (
 			id INTEGER PRIMARY KEY AUTOINCREMENT, media_path TEXT NOT NULL,
 			model TEXT NOT NULL, frame_ts REAL NOT NULL DEFAULT 0,
 			bbox_x REAL NOT NULL, bbox_y REAL NOT NULL, bbox_w REAL NOT NULL,
 			bbox_h REAL NOT NULL, det_score REAL NOT NULL, vector BLOB NOT NULL,
 			person_id INTEGER, assigned_by TEXT, created_at INTEGER,
+			track_start REAL, track_end REAL,
 			FOREIGN KEY (media_path) REFERENCES media(path))`,
 		`CREATE TABLE face_scan (
 			media_path TEXT NOT NULL, model TEXT NOT NULL,
@@ -13,24 +13,29 @@ import (
 //
 // MergeInto is the one implementation behind every "merge these items" surface:
 // the /api/media/merge-metadata endpoint (the viewer's context-palette Merge)
-// and the dedupe task. Metadata merge is additive: tag rows and per-model
-// embedding rows the target lacks are copied in (the target's own rows always
-// win), an empty transcript is filled from the first source that has one, and
-// that source's .vtt sidecar is moved next to the target. The sources are then
-// DELETED — local file removed (plus leftover sidecar) and every database
-// reference erased (tags, media row, embeddings, faces and their curation
-// assertions, scan markers, battle-log rows). s3:// sources and files that
-// fail to delete keep their rows and are reported in Failed so nothing
-// silently orphans.
+// and the dedupe task. Metadata merge is additive: tag rows, per-model
+// embedding rows, per-model semantic text chunks, per-language translated
+// transcripts, per-user likes and ratings, and collection memberships the
+// target lacks are copied in (the target's own rows always win), collection
+// covers are repointed at the target, an empty transcript is filled from the
+// first source that has one, and that source's .vtt sidecar is moved next to
+// the target. The sources are then DELETED — local file removed (plus
+// leftover sidecar) and every database reference erased (tags, media row,
+// embeddings, text chunks, translations, likes and ratings, faces and their
+// curation assertions, scan markers, battle-log rows). s3:// sources and
+// files that fail to delete keep their rows and are reported in Failed so
+// nothing silently orphans.
 
 // MergeResult reports what a merge changed. Field names mirror the historical
 // /api/media/merge-metadata response shape.
 type MergeResult struct {
 	Target  string   `json:"target"`
 	Sources []string `json:"sources"`
-	// Tags / Embeddings are the number of rows the target GAINED.
-	Tags       int64 `json:"tags"`
-	Embeddings int64 `json:"embeddings"`
+	// Tags / Embeddings / Collections are the number of rows the target
+	// GAINED (Collections: memberships taken over from the sources).
+	Tags        int64 `json:"tags"`
+	Embeddings  int64 `json:"embeddings"`
+	Collections int64 `json:"collections"`
 	// Transcript is true when the target's transcript column was filled from a
 	// source or a source's .vtt sidecar was moved next to the target.
 	Transcript     bool   `json:"transcript"`
@@ -131,6 +136,96 @@ func MergeInto(ctx context.Context, db *sql.DB, target string, sources []string)
 	}
 	res.Embeddings = embAfter - embBefore
 
+	for _, src := range srcs {
+		// Semantic text chunks are copied per model, whole: windows from two
+		// different transcripts must not interleave, so a model the target
+		// already has chunks for keeps the target's.
+		if _, err := tx.Exec(
+			`INSERT INTO media_text_chunk
+			   (media_path, model, seq, source, start_ms, end_ms, text, vector, created_at)
+			 SELECT ?1, s.model, s.seq, s.source, s.start_ms, s.end_ms, s.text, s.vector, s.created_at
+			 FROM media_text_chunk s
+			 WHERE s.media_path = ?2
+			   AND NOT EXISTS (
+			     SELECT 1 FROM media_text_chunk t WHERE t.media_path = ?1 AND t.model = s.model
+			   )`,
+			target, src,
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, src := range srcs {
+		// Translations are per language: the target's own win, earlier
+		// sources win over later ones for languages it lacks.
+		if _, err := tx.Exec(
+			`INSERT OR IGNORE INTO media_transcript
+			   (media_path, lang, transcript, source, model, created_at)
+			 SELECT ?, lang, transcript, source, model, created_at
+			 FROM media_transcript WHERE media_path = ?`,
+			target, src,
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, src := range srcs {
+		// Per-user likes and ratings follow the item, so a household member
+		// doesn't lose their favorite to a dedupe. Each user's own rows on the
+		// target win, then earlier sources over later ones.
+		if _, err := tx.Exec(
+			`INSERT OR IGNORE INTO user_like (username, media_path, created_at)
+			 SELECT username, ?, created_at FROM user_like WHERE media_path = ?`,
+			target, src,
+		); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(
+			`INSERT OR IGNORE INTO user_media_stats
+			   (username, media_path, elo, views, wins, losses, battles)
+			 SELECT username, ?, elo, views, wins, losses, battles
+			 FROM user_media_stats WHERE media_path = ?`,
+			target, src,
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	collBefore, err := countRows(`SELECT COUNT(*) FROM collection_item WHERE media_path = ?`)
+	if err != nil {
+		return nil, err
+	}
+	for _, src := range srcs {
+		// The target takes the source's slot (position and caption) in every
+		// collection it isn't already in; where it is, its own entry wins.
+		// Covers follow too, before eraseReferences would clear them.
+		if _, err := tx.Exec(
+			`INSERT OR IGNORE INTO collection_item
+			   (collection_id, media_path, position, caption, added_at)
+			 SELECT collection_id, ?, position, caption, added_at
+			 FROM collection_item WHERE media_path = ?`,
+			target, src,
+		); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(
+			`UPDATE collection SET cover_path = ? WHERE cover_path = ?`, target, src,
+		); err != nil {
+			return nil, err
+		}
+		// A link shared for the source now shows the target.
+		if _, err := tx.Exec(
+			`UPDATE share SET media_path = ? WHERE media_path = ?`, target, src,
+		); err != nil {
+			return nil, err
+		}
+	}
+	collAfter, err := countRows(`SELECT COUNT(*) FROM collection_item WHERE media_path = ?`)
+	if err != nil {
+		return nil, err
+	}
+	res.Collections = collAfter - collBefore
+
 	// Transcript: fill only when the target has none — never overwrite.
 	var targetTranscript sql.NullString
 	if err := tx.QueryRow(
@@ -38,10 +38,19 @@ var movablePathColumns = []pathColumn{
 	{Table: "media", Column: "path", quoted: `"path"`},
 	{Table: "media_tag_by_category", Column: "media_path", quoted: "media_path"},
 	{Table: "media_embedding", Column: "media_path", quoted: "media_path"},
+	{Table: "media_text_chunk", Column: "media_path", quoted: "media_path"},
+	{Table: "media_transcript", Column: "media_path", quoted: "media_path"},
+	{Table: "media_cluster_member", Column: "media_path", quoted: "media_path"},
+	{Table: "collection_item", Column: "media_path", quoted: "media_path"},
+	{Table: "collection", Column: "cover_path", quoted: "cover_path"},
+	{Table: "share", Column: "media_path", quoted: "media_path"},
 	{Table: "face", Column: "media_path", quoted: "media_path"},
 	{Table: "face_scan", Column: "media_path", quoted: "media_path"},
+	{Table: "person_cooccur", Column: "media_path", quoted: "media_path"},
 	{Table: "battle", Column: "winner_path", quoted: "winner_path"},
 	{Table: "battle", Column: "loser_path", quoted: "loser_path"},
+	{Table: "user_like", Column: "media_path", quoted: "media_path"},
+	{Table: "user_media_stats", Column: "media_path", quoted: "media_path"},
 }
 
 // MoveOptions tunes a MovePath call.
@@ -123,13 +132,13 @@ func matchClause(col, from string, prefix bool) (string, []any) {
 	}
 	n := utf8.RuneCountInString(from) + 1 // +1 for the separator character
 	return fmt.Sprintf(
-			"(%s = ? OR substr(%s, 1, ?) = ? OR substr(%s, 1, ?) = ?)",
-			col, col, col,
-		), []any{
-			from,
-			n, from + "/",
-			n, from + `\`,
-		}
+		"(%s = ? OR substr(%s, 1, ?) = ? OR substr(%s, 1, ?) = ?)",
+		col, col, col,
+	), []any{
+		from,
+		n, from + "/",
+		n, from + `\`,
+	}
 }
 
 // rewriteExpr builds the SET expression producing the new path, and its args.
@@ -19,6 +19,11 @@ func seedMovable(t *testing.T, db *sql.DB, path string) {
 		{`INSERT INTO media_tag_by_category (media_path, tag_label, category_label, weight, time_stamp)
 		  VALUES (?, 'sunset', 'Subject', 1, 0)`, []any{path}},
 		{`INSERT INTO media_embedding (media_path, model, dim, vector) VALUES (?, 'siglip2', 2, x'0000')`, []any{path}},
+		{`INSERT INTO media_text_chunk (media_path, model, seq, source, text, vector) VALUES (?, 'e5', 0, 'transcript', 'hello', x'0000')`, []any{path}},
+		{`INSERT INTO media_transcript (media_path, lang, transcript) VALUES (?, 'es', 'WEBVTT')`, []any{path}},
+		{`INSERT INTO media_cluster_member (cluster_id, media_path, score) VALUES (1, ?, 0.9)`, []any{path}},
+		{`INSERT INTO collection_item (collection_id, media_path, position) VALUES (1, ?, 0)`, []any{path}},
+		{`INSERT INTO collection (name, cover_path) VALUES ('Album ' || ?1, ?1)`, []any{path}},
 		{`INSERT INTO face_scan (media_path, model, face_count) VALUES (?, 'sface', 1)`, []any{path}},
 		{`INSERT INTO face (media_path, model, bbox_x, bbox_y, bbox_w, bbox_h, det_score, vector)
 		  VALUES (?, 'sface', 0.1, 0.1, 0.2, 0.2, 0.9, x'0000')`, []any{path}},
@@ -40,6 +45,11 @@ func countAt(t *testing.T, db *sql.DB, path string) map[string]int {
 		"media":                 `SELECT COUNT(*) FROM media WHERE "path" = ?`,
 		"media_tag_by_category": `SELECT COUNT(*) FROM media_tag_by_category WHERE media_path = ?`,
 		"media_embedding":       `SELECT COUNT(*) FROM media_embedding WHERE media_path = ?`,
+		"media_text_chunk":      `SELECT COUNT(*) FROM media_text_chunk WHERE media_path = ?`,
+		"media_transcript":      `SELECT COUNT(*) FROM media_transcript WHERE media_path = ?`,
+		"media_cluster_member":  `SELECT COUNT(*) FROM media_cluster_member WHERE media_path = ?`,
+		"collection_item":       `SELECT COUNT(*) FROM collection_item WHERE media_path = ?`,
+		"collection":            `SELECT COUNT(*) FROM collection WHERE cover_path = ?`,
 		"face":                  `SELECT COUNT(*) FROM face WHERE media_path = ?`,
 		"face_scan":             `SELECT COUNT(*) FROM face_scan WHERE media_path = ?`,
 		"battle":                `SELECT COUNT(*) FROM battle WHERE winner_path = ? OR loser_path = ?`,
@@ -76,6 +86,11 @@ func TestMovePathCarriesEveryReference(t *testing.T) {
 		"media.path":                       1,
 		"media_tag_by_category.media_path": 1,
 		"media_embedding.media_path":       1,
+		"media_text_chunk.media_path":      1,
+		"media_transcript.media_path":      1,
+		"media_cluster_member.media_path":  1,
+		"collection_item.media_path":       1,
+		"collection.cover_path":            1,
 		"face.media_path":                  1,
 		"face_scan.media_path":             1,
 		"battle.winner_path":               1,
@@ -86,8 +101,8 @@ func TestMovePathCarriesEveryReference(t *testing.T) {
 			t.Errorf("rows[%q] = %d, want %d (all: %v)", key, res.Rows[key], want, res.Rows)
 		}
 	}
-	if res.Total != 7 {
-		t.Errorf("total = %d, want 7", res.Total)
+	if res.Total != 12 {
+		t.Errorf("total = %d, want 12", res.Total)
 	}
 	if len(res.Paths) != 1 || res.Paths[0].From != from || res.Paths[0].To != to {
 		t.Errorf("paths = %+v", res.Paths)
@@ -243,8 +258,8 @@ func TestMovePathDryRunChangesNothing(t *testing.T) {
 		t.Fatal(err)
 	}
 	// The counts are the real ones — the work happened and was rolled back.
-	if res.Items != 1 || res.Total != 7 {
-		t.Errorf("dry run reported items=%d total=%d, want 1 and 7", res.Items, res.Total)
+	if res.Items != 1 || res.Total != 12 {
+		t.Errorf("dry run reported items=%d total=%d, want 1 and 12", res.Items, res.Total)
 	}
 	if !res.DryRun {
 		t.Error("result does not report itself as a dry run")
@@ -224,8 +224,7 @@ func GetPersonByName(db *sql.DB, name string) (Person, bool, error) {
 // first. Used to pick cover crops.
 func PersonFacesByQuality(db *sql.DB, personID int64) ([]Face, error) {
 	rows, err := db.Query(
-		`SELECT id, media_path, model, frame_ts, bbox_x, bbox_y, bbox_w, bbox_h,
-		        det_score, vector, COALESCE(person_id, 0), COALESCE(assigned_by, ''), COALESCE(created_at, 0)
+		`SELECT `+faceColumns+`
 		 FROM face WHERE person_id = ?
 		 ORDER BY det_score * bbox_w * bbox_h DESC, id ASC`,
 		personID,
@@ -275,6 +274,88 @@ func PersonMediaPaths(db *sql.DB, id int64) ([]string, error) {
 	return out, rows.Err()
 }
 
+// FaceSpan is one stretch of a video a person was tracked over: the track's
+// first and last sampled seconds and the timestamp of its best detection
+// (where the viewer seeks and the crop is cut).
+type FaceSpan struct {
+	FaceID int64   `json:"faceId"`
+	Start  float64 `json:"start"`
+	End    float64 `json:"end"`
+	Best   float64 `json:"best"`
+}
+
+// PersonMediaSpans returns, per media path, the video spans a person's
+// tracked faces cover, in time order. Images and single-frame scans have no
+// spans and are absent from the map.
+func PersonMediaSpans(db *sql.DB, id int64) (map[string][]FaceSpan, error) {
+	rows, err := db.Query(
+		`SELECT media_path, id, track_start, track_end, frame_ts FROM face
+		 WHERE person_id = ? AND track_start IS NOT NULL AND track_end IS NOT NULL
+		 ORDER BY media_path, track_start, id`, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string][]FaceSpan{}
+	for rows.Next() {
+		var path string
+		var sp FaceSpan
+		if err := rows.Scan(&path, &sp.FaceID, &sp.Start, &sp.End, &sp.Best); err != nil {
+			return nil, err
+		}
+		out[path] = append(out[path], sp)
+	}
+	return out, rows.Err()
+}
+
+// NamedFace is a person-assigned face with the person's stored name.
+type NamedFace struct {
+	Face
+	Name string
+}
+
+// AssignedFacesForMedia returns path's person-assigned faces under every
+// model, best detection first, each with its person's stored name.
+func AssignedFacesForMedia(db *sql.DB, path string) ([]NamedFace, error) {
+	rows, err := db.Query(
+		`SELECT `+faceColumns+`
+		 FROM face WHERE media_path = ? AND person_id > 0 ORDER BY det_score DESC`, path,
+	)
+	if err != nil {
+		return nil, err
+	}
+	faces, err := scanFaceRows(rows)
+	rows.Close()
+	if err != nil || len(faces) == 0 {
+		return nil, err
+	}
+	names := map[int64]string{}
+	nrows, err := db.Query(
+		`SELECT id, name FROM person WHERE id IN (SELECT person_id FROM face WHERE media_path = ?)`, path,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer nrows.Close()
+	for nrows.Next() {
+		var id int64
+		var name string
+		if err := nrows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		names[id] = name
+	}
+	if err := nrows.Err(); err != nil {
+		return nil, err
+	}
+	out := make([]NamedFace, 0, len(faces))
+	for _, f := range faces {
+		out = append(out, NamedFace{Face: f, Name: names[f.PersonID]})
+	}
+	return out, nil
+}
+
 // retagPerson rewrites the person's taxonomy rows from oldName to newName
 // inside a transaction: tag row and media_tag_by_category rows. Collisions
 // with already-existing target rows are resolved by dropping the old row.
@@ -369,9 +450,16 @@ func MergePersons(db *sql.DB, fromID, intoID int64) error {
 		return err
 	}
 	defer tx.Rollback()
+	fromPaths, err := personMediaPathsTx(tx, fromID)
+	if err != nil {
+		return err
+	}
 	if _, err := tx.Exec(`UPDATE face SET person_id = ? WHERE person_id = ?`, intoID, fromID); err != nil {
 		return fmt.Errorf("merge faces: %w", err)
 	}
+	if err := refreshCooccurrenceTx(tx, fromPaths); err != nil {
+		return fmt.Errorf("merge co-occurrence: %w", err)
+	}
 	// Vetoes against the dissolving person carry over to the merge target
 	// (rejected-from-A means rejected-from-the-merged-A+B)…
 	if _, err := tx.Exec(
@@ -434,6 +522,9 @@ func DeletePerson(db *sql.DB, id int64) error {
 	if _, err := tx.Exec(`UPDATE face SET person_id = NULL, assigned_by = NULL WHERE person_id = ?`, id); err != nil {
 		return err
 	}
+	if _, err := tx.Exec(`DELETE FROM person_cooccur WHERE person_a = ? OR person_b = ?`, id, id); err != nil {
+		return err
+	}
 	// The person id is gone for good (AUTOINCREMENT), so vetoes against it are
 	// dead weight. Cannot-links stay — they encode face-level truth that must
 	// outlive the group (the whole point of recording them).
@@ -500,6 +591,9 @@ func DeletePersonAndFaces(db *sql.DB, id int64) ([]int64, error) {
 	if _, err := tx.Exec(`DELETE FROM face WHERE person_id = ?`, id); err != nil {
 		return nil, err
 	}
+	if _, err := tx.Exec(`DELETE FROM person_cooccur WHERE person_a = ? OR person_b = ?`, id, id); err != nil {
+		return nil, err
+	}
 	if _, err := tx.Exec(
 		`DELETE FROM media_tag_by_category WHERE tag_label = ? AND category_label = ?`, p.Name, PeopleCategory,
 	); err != nil {
@@ -610,6 +704,9 @@ func AssignFace(db *sql.DB, faceID, personID int64, assignedBy string) error {
 		); err != nil {
 			return err
 		}
+		if err := refreshCooccurrenceTx(tx, []string{f.MediaPath}); err != nil {
+			return err
+		}
 	}
 	if _, err := tx.Exec(
 		`UPDATE person SET cover_face_id = ? WHERE id = ? AND (cover_face_id IS NULL OR cover_face_id = 0)`,
@@ -649,6 +746,9 @@ func UnassignFace(db *sql.DB, faceID int64) error {
 	if err := removeBridgeRowIfLastFace(tx, f, f.PersonID); err != nil {
 		return err
 	}
+	if err := refreshCooccurrenceTx(tx, []string{f.MediaPath}); err != nil {
+		return err
+	}
 	// Clear the cover if it pointed at this face.
 	if _, err := tx.Exec(`UPDATE person SET cover_face_id = NULL WHERE id = ? AND cover_face_id = ?`, f.PersonID, faceID); err != nil {
 		return err
@@ -731,6 +831,8 @@ func AssignFacesAuto(db *sql.DB, assignments []FaceAssignment) ([]FaceAssignment
 	now := time.Now().Unix()
 	tagged := map[int64]bool{} // persons whose tag row is already ensured
 	applied := make([]FaceAssignment, 0, len(assignments))
+	var touched []string // library media whose pairs need recomputing
+	seenPath := map[string]bool{}
 	for _, a := range assignments {
 		if missing[a.PersonID] {
 			continue
@@ -783,12 +885,19 @@ func AssignFacesAuto(db *sql.DB, assignments []FaceAssignment) ([]FaceAssignment
 			if _, err := bridge.Exec(mediaPath, names[a.PersonID], PeopleCategory, frameTS, now); err != nil {
 				return nil, err
 			}
+			if !seenPath[mediaPath] {
+				seenPath[mediaPath] = true
+				touched = append(touched, mediaPath)
+			}
 		}
 		if _, err := cover.Exec(a.FaceID, a.PersonID); err != nil {
 			return nil, err
 		}
 		applied = append(applied, a)
 	}
+	if err := refreshCooccurrenceTx(tx, touched); err != nil {
+		return nil, err
+	}
 	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
@@ -824,6 +933,8 @@ func UnassignFacesBulk(db *sql.DB, faceIDs []int64) (int, error) {
 		return 0, err
 	}
 	n := 0
+	var touched []string
+	seenPath := map[string]bool{}
 	for _, id := range faceIDs {
 		var mediaPath string
 		var frameTS float64
@@ -850,8 +961,15 @@ func UnassignFacesBulk(db *sql.DB, faceIDs []int64) (int, error) {
 		if _, err := clearCover.Exec(personID, id); err != nil {
 			return 0, err
 		}
+		if !seenPath[mediaPath] {
+			seenPath[mediaPath] = true
+			touched = append(touched, mediaPath)
+		}
 		n++
 	}
+	if err := refreshCooccurrenceTx(tx, touched); err != nil {
+		return 0, err
+	}
 	if err := tx.Commit(); err != nil {
 		return 0, err
 	}
@@ -901,6 +1019,7 @@ func DeleteAllFaceData(db *sql.DB) error {
 		`DELETE FROM face_cannot_link`,
 		`DELETE FROM face_group_ban`,
 		`DELETE FROM face_group_ban_member`,
+		`DELETE FROM person_cooccur`,
 		`DELETE FROM person`,
 		`DELETE FROM media_tag_by_category WHERE category_label = '` + PeopleCategory + `'`,
 		`DELETE FROM tag WHERE category_label = '` + PeopleCategory + `'`,
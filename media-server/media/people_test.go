@@ -479,3 +479,63 @@ func TestDeleteAllFaceData(t *testing.T) {
 		t.Fatalf("foreign tag harmed: (%q, %v)", cat, ok)
 	}
 }
+
+func TestPersonMediaSpansFromTrackedFaces(t *testing.T) {
+	db := newPeopleDB(t)
+	if _, err := db.Exec(`INSERT INTO media (path) VALUES ('v.mp4')`); err != nil {
+		t.Fatal(err)
+	}
+	pid, _ := CreatePerson(db, "Alice")
+	ids, err := ReplaceFaces(db, "v.mp4", "m1", []NewFace{
+		{Score: 0.9, Vec: []float32{1}, FrameTS: 14, Tracked: true, TrackStart: 10, TrackEnd: 20},
+		{Score: 0.8, Vec: []float32{1}, FrameTS: 62, Tracked: true, TrackStart: 60, TrackEnd: 64},
+	}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imgIDs, _ := ReplaceFaces(db, "a.jpg", "m1", []NewFace{{Score: 0.9, Vec: []float32{1}}}, 1)
+	for _, id := range append(ids, imgIDs...) {
+		_ = AssignFace(db, id, pid, "user")
+	}
+
+	f, ok, err := GetFaceByID(db, ids[0])
+	if err != nil || !ok {
+		t.Fatalf("GetFaceByID: %v %v", ok, err)
+	}
+	if !f.Tracked || f.TrackStart != 10 || f.TrackEnd != 20 || f.FrameTS != 14 {
+		t.Fatalf("tracked face round-trip = %+v", f)
+	}
+	if g, _, _ := GetFaceByID(db, imgIDs[0]); g.Tracked {
+		t.Fatalf("image face reads as tracked: %+v", g)
+	}
+
+	spans, err := PersonMediaSpans(db, pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := spans["a.jpg"]; ok {
+		t.Fatalf("image has spans: %+v", spans)
+	}
+	want := []FaceSpan{{FaceID: ids[0], Start: 10, End: 20, Best: 14}, {FaceID: ids[1], Start: 60, End: 64, Best: 62}}
+	if got := spans["v.mp4"]; len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("spans = %+v, want %+v", got, want)
+	}
+
+	// Each track bridges to its own timestamped People row.
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM media_tag_by_category
+		WHERE media_path = 'v.mp4' AND category_label = ? AND time_stamp IN (14, 62)`, PeopleCategory).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatalf("timestamped People rows = %d, want 2", n)
+	}
+
+	items, _, _, err := GetItems(db, 0, 10, "person:Alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("person:Alice = %d items, want a.jpg and v.mp4", len(items))
+	}
+}
@@ -0,0 +1,73 @@
+package media
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Likes and ratings come in two layers. The library-wide layer is the
+// Swipe/Favorites tag plus the elo/views/wins/losses/battles columns on
+// media — what anonymous clients, the Electron viewer (which shares the
+// database), and accounts an admin gave the library profile read and write.
+// Every other account, admins included, gets its own overlay: user_like and
+// user_media_stats, keyed by username, so household members build separate
+// taste profiles and rankings.
+
+// FavoritesTag and FavoritesCategory are the tag the library-wide layer
+// treats as "liked" (feed.Tuning's defaults).
+const (
+	FavoritesTag      = "Favorites"
+	FavoritesCategory = "Swipe"
+)
+
+// DefaultElo is the rating of an item that has never fought a battle.
+const DefaultElo = 1500
+
+// SetLiked likes or unlikes path for user ("" = the library-wide Favorites
+// tag).
+func SetLiked(db *sql.DB, user, path string, liked bool) error {
+	if user == "" {
+		if liked {
+			return AddTag(db, path, FavoritesTag, FavoritesCategory)
+		}
+		return RemoveTag(db, path, FavoritesTag, FavoritesCategory)
+	}
+	if !liked {
+		_, err := db.Exec(`DELETE FROM user_like WHERE username = ? AND media_path = ?`, user, path)
+		return err
+	}
+	_, err := db.Exec(`INSERT INTO user_like (username, media_path, created_at) VALUES (?, ?, ?)
+		ON CONFLICT(username, media_path) DO NOTHING`, user, path, time.Now().Unix())
+	return err
+}
+
+// IsLiked reports whether user ("" = library-wide) likes path.
+func IsLiked(db *sql.DB, user, path string) (bool, error) {
+	if user == "" {
+		return HasTag(db, path, FavoritesTag, FavoritesCategory)
+	}
+	var one int
+	err := db.QueryRow(`SELECT 1 FROM user_like WHERE username = ? AND media_path = ?`, user, path).Scan(&one)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// DeleteUserRatings drops user's likes, ratings, and battle history — the
+// account is gone, and a later account reusing the name starts fresh.
+func DeleteUserRatings(db *sql.DB, user string) error {
+	if user == "" {
+		return nil
+	}
+	for _, q := range []string{
+		`DELETE FROM user_like WHERE username = ?`,
+		`DELETE FROM user_media_stats WHERE username = ?`,
+		`DELETE FROM battle WHERE username = ?`,
+	} {
+		if _, err := db.Exec(q, user); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package media
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func queryPaths(t *testing.T, items []MediaItem, err error) []string {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []string
+	for _, it := range items {
+		out = append(out, it.Path)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestMyPredicatesBindToUser(t *testing.T) {
+	db := newPeopleDB(t) // a.jpg, b.jpg
+	if _, err := db.Exec(`INSERT INTO media (path, elo) VALUES ('c.jpg', 1700)`); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetLiked(db, "", "a.jpg", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetLiked(db, "alice", "b.jpg", true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO user_media_stats (username, media_path, elo, views) VALUES ('alice', 'a.jpg', 1600, 3)`); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		user, query string
+		want        []string
+	}{
+		{"", "my:liked", []string{"a.jpg"}},
+		{"alice", "my:liked", []string{"b.jpg"}},
+		{"bob", "my:liked", nil},
+		{"alice", "my:liked:false", []string{"a.jpg", "c.jpg"}},
+		{"", "my:rating>1500", []string{"c.jpg"}},
+		{"alice", "my:rating>1500", []string{"a.jpg"}},
+		{"alice", "my:rating:>=1500", []string{"a.jpg", "b.jpg", "c.jpg"}},
+		{"alice", "my:views>0 OR my:liked", []string{"a.jpg", "b.jpg"}},
+		{"alice", "NOT my:liked my:rating<1600", []string{"c.jpg"}},
+	}
+	for _, c := range cases {
+		items, _, _, err := GetItemsForUser(db, c.user, 0, 10, c.query)
+		if got := queryPaths(t, items, err); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s as %q = %v, want %v", c.query, c.user, got, c.want)
+		}
+	}
+
+	// Without a user the predicates read the library-wide layer.
+	items, _, _, err := GetItems(db, 0, 10, "my:liked")
+	if got := queryPaths(t, items, err); !reflect.DeepEqual(got, []string{"a.jpg"}) {
+		t.Errorf("GetItems my:liked = %v", got)
+	}
+}
+
+func TestSetLikedLayers(t *testing.T) {
+	db := newPeopleDB(t)
+	for _, user := range []string{"", "alice"} {
+		if err := SetLiked(db, user, "a.jpg", true); err != nil {
+			t.Fatal(err)
+		}
+		if err := SetLiked(db, user, "a.jpg", true); err != nil {
+			t.Fatalf("liking twice: %v", err)
+		}
+	}
+	if liked, _ := IsLiked(db, "bob", "a.jpg"); liked {
+		t.Error("bob sees alice's like")
+	}
+	if err := SetLiked(db, "alice", "a.jpg", false); err != nil {
+		t.Fatal(err)
+	}
+	if liked, _ := IsLiked(db, "alice", "a.jpg"); liked {
+		t.Error("unlike did not stick")
+	}
+	if liked, _ := IsLiked(db, "", "a.jpg"); !liked {
+		t.Error("alice's unlike removed the library-wide Favorites tag")
+	}
+
+	if err := SetLiked(db, "alice", "b.jpg", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeleteUserRatings(db, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if liked, _ := IsLiked(db, "alice", "b.jpg"); liked {
+		t.Error("DeleteUserRatings kept a like")
+	}
+}
+
+func TestMergeIntoCarriesUserRatings(t *testing.T) {
+	db := newPeopleDB(t) // a.jpg, b.jpg
+	dup := "s3://bucket/dup.jpg"
+	if _, err := db.Exec(`INSERT INTO media (path) VALUES (?)`, dup); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetLiked(db, "alice", dup, true); err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range []struct {
+		user, path string
+		elo        float64
+	}{{"alice", dup, 1650}, {"bob", dup, 1400}, {"bob", "a.jpg", 1550}} {
+		if _, err := db.Exec(`INSERT INTO user_media_stats (username, media_path, elo, views) VALUES (?, ?, ?, 1)`, row.user, row.path, row.elo); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// An s3 source is never deleted, which leaves its rows in place; only
+	// the target's matter here.
+	if _, err := MergeInto(context.Background(), db, "a.jpg", []string{dup}); err != nil {
+		t.Fatal(err)
+	}
+	if liked, err := IsLiked(db, "alice", "a.jpg"); err != nil || !liked {
+		t.Errorf("alice's like did not follow the merge: %v %v", liked, err)
+	}
+	elo := func(user string) float64 {
+		var v float64
+		if err := db.QueryRow(`SELECT elo FROM user_media_stats WHERE username = ? AND media_path = 'a.jpg'`, user).Scan(&v); err != nil {
+			t.Fatalf("%s stats on target: %v", user, err)
+		}
+		return v
+	}
+	if got := elo("alice"); got != 1650 {
+		t.Errorf("alice elo = %v, want the source's 1650", got)
+	}
+	if got := elo("bob"); got != 1550 {
+		t.Errorf("bob elo = %v, want the target's own 1550", got)
+	}
+}
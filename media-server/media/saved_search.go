@@ -0,0 +1,174 @@
+package media
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SavedSearch is a named, server-side query. Predicates holds the browse UI's
+// Predicate list verbatim (the main package owns that type and its SQL
+// builder); Query holds a task search-DSL string instead. Exactly one is set.
+// Sort is the client's sort key, stored for the UI to re-apply — the query
+// engine itself emits no ORDER BY.
+type SavedSearch struct {
+	ID         int64           `json:"id"`
+	Name       string          `json:"name"`
+	Predicates json.RawMessage `json:"predicates,omitempty"`
+	Query      string          `json:"query,omitempty"`
+	Mode       string          `json:"mode"`
+	Sort       string          `json:"sort,omitempty"`
+	CreatedAt  int64           `json:"createdAt"`
+	UpdatedAt  int64           `json:"updatedAt"`
+}
+
+// normalizeSavedSearch trims and validates s in place.
+func normalizeSavedSearch(s *SavedSearch) error {
+	s.Name = strings.TrimSpace(s.Name)
+	if s.Name == "" {
+		return fmt.Errorf("saved search name required")
+	}
+	s.Query = strings.TrimSpace(s.Query)
+	preds := strings.TrimSpace(string(s.Predicates))
+	if preds == "null" || preds == "[]" {
+		preds = ""
+	}
+	switch {
+	case preds == "" && s.Query == "":
+		return fmt.Errorf("saved search %q: predicates or a query required", s.Name)
+	case preds != "" && s.Query != "":
+		return fmt.Errorf("saved search %q must be predicates or a query, not both", s.Name)
+	}
+	if preds != "" {
+		var list []json.RawMessage
+		if err := json.Unmarshal([]byte(preds), &list); err != nil {
+			return fmt.Errorf("saved search %q: predicates must be a JSON array: %w", s.Name, err)
+		}
+	}
+	s.Predicates = json.RawMessage(preds)
+	s.Mode = strings.ToUpper(strings.TrimSpace(s.Mode))
+	if s.Mode != "OR" {
+		s.Mode = "AND"
+	}
+	s.Sort = strings.TrimSpace(s.Sort)
+	return nil
+}
+
+const savedSearchColumns = `id, name, predicates, query, mode, sort, COALESCE(created_at, 0), COALESCE(updated_at, 0)`
+
+func scanSavedSearch(row interface{ Scan(...any) error }) (SavedSearch, error) {
+	var s SavedSearch
+	var preds string
+	if err := row.Scan(&s.ID, &s.Name, &preds, &s.Query, &s.Mode, &s.Sort, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		return SavedSearch{}, err
+	}
+	if preds != "" {
+		s.Predicates = json.RawMessage(preds)
+	}
+	return s, nil
+}
+
+// ListSavedSearches returns every saved search ordered by name.
+func ListSavedSearches(db *sql.DB) ([]SavedSearch, error) {
+	rows, err := db.Query(`SELECT ` + savedSearchColumns + ` FROM saved_search ORDER BY name COLLATE NOCASE`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []SavedSearch
+	for rows.Next() {
+		s, err := scanSavedSearch(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// GetSavedSearchByName returns one saved search by name (case-insensitive).
+func GetSavedSearchByName(db *sql.DB, name string) (SavedSearch, bool, error) {
+	s, err := scanSavedSearch(db.QueryRow(`SELECT `+savedSearchColumns+` FROM saved_search WHERE name = ?`, strings.TrimSpace(name)))
+	if err == sql.ErrNoRows {
+		return SavedSearch{}, false, nil
+	}
+	if err != nil {
+		return SavedSearch{}, false, err
+	}
+	return s, true, nil
+}
+
+// GetSavedSearchByID returns one saved search by ID.
+func GetSavedSearchByID(db *sql.DB, id int64) (SavedSearch, bool, error) {
+	s, err := scanSavedSearch(db.QueryRow(`SELECT `+savedSearchColumns+` FROM saved_search WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return SavedSearch{}, false, nil
+	}
+	if err != nil {
+		return SavedSearch{}, false, err
+	}
+	return s, true, nil
+}
+
+// CreateSavedSearch stores s and returns it with its ID and timestamps set.
+// Names are unique case-insensitively.
+func CreateSavedSearch(db *sql.DB, s SavedSearch) (SavedSearch, error) {
+	if err := normalizeSavedSearch(&s); err != nil {
+		return SavedSearch{}, err
+	}
+	if _, exists, err := GetSavedSearchByName(db, s.Name); err != nil {
+		return SavedSearch{}, err
+	} else if exists {
+		return SavedSearch{}, fmt.Errorf("saved search %q already exists", s.Name)
+	}
+	now := time.Now().Unix()
+	res, err := db.Exec(
+		`INSERT INTO saved_search (name, predicates, query, mode, sort, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		s.Name, string(s.Predicates), s.Query, s.Mode, s.Sort, now, now,
+	)
+	if err != nil {
+		return SavedSearch{}, fmt.Errorf("create saved search %q: %w", s.Name, err)
+	}
+	if s.ID, err = res.LastInsertId(); err != nil {
+		return SavedSearch{}, err
+	}
+	s.CreatedAt, s.UpdatedAt = now, now
+	return s, nil
+}
+
+// UpdateSavedSearch replaces the stored definition of s.ID (name included)
+// and returns the updated row. ok is false when no such search exists.
+func UpdateSavedSearch(db *sql.DB, s SavedSearch) (SavedSearch, bool, error) {
+	if err := normalizeSavedSearch(&s); err != nil {
+		return SavedSearch{}, false, err
+	}
+	if other, exists, err := GetSavedSearchByName(db, s.Name); err != nil {
+		return SavedSearch{}, false, err
+	} else if exists && other.ID != s.ID {
+		return SavedSearch{}, false, fmt.Errorf("saved search %q already exists", s.Name)
+	}
+	res, err := db.Exec(
+		`UPDATE saved_search SET name = ?, predicates = ?, query = ?, mode = ?, sort = ?, updated_at = ? WHERE id = ?`,
+		s.Name, string(s.Predicates), s.Query, s.Mode, s.Sort, time.Now().Unix(), s.ID,
+	)
+	if err != nil {
+		return SavedSearch{}, false, fmt.Errorf("update saved search %q: %w", s.Name, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return SavedSearch{}, false, nil
+	}
+	return GetSavedSearchByID(db, s.ID)
+}
+
+// DeleteSavedSearch removes one saved search. Other searches that nest it
+// via saved:"name" simply stop matching through it.
+func DeleteSavedSearch(db *sql.DB, id int64) (bool, error) {
+	res, err := db.Exec(`DELETE FROM saved_search WHERE id = ?`, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
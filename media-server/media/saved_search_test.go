@@ -0,0 +1,60 @@
+package media
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSavedSearchCRUD(t *testing.T) {
+	db := newEmbedDB(t)
+
+	s, err := CreateSavedSearch(db, SavedSearch{Name: " Cats ", Predicates: json.RawMessage(`[{"type":"tag","value":"cat"}]`), Mode: "or"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ID == 0 || s.Name != "Cats" || s.Mode != "OR" || s.CreatedAt == 0 {
+		t.Errorf("created = %+v", s)
+	}
+	if _, err := CreateSavedSearch(db, SavedSearch{Name: "cats", Query: "tag:cat"}); err == nil {
+		t.Error("names should be unique case-insensitively")
+	}
+	for _, bad := range []SavedSearch{
+		{Name: "", Query: "tag:a"},
+		{Name: "empty", Predicates: json.RawMessage(`[]`)},
+		{Name: "both", Query: "tag:a", Predicates: json.RawMessage(`[{"type":"tag","value":"a"}]`)},
+		{Name: "not a list", Predicates: json.RawMessage(`{"type":"tag"}`)},
+	} {
+		if _, err := CreateSavedSearch(db, bad); err == nil {
+			t.Errorf("CreateSavedSearch(%+v) should fail", bad)
+		}
+	}
+
+	got, found, err := GetSavedSearchByName(db, "CATS")
+	if err != nil || !found || got.ID != s.ID || string(got.Predicates) != `[{"type":"tag","value":"cat"}]` {
+		t.Fatalf("by name = %+v %v %v", got, found, err)
+	}
+
+	// Switching a search from predicates to a DSL query clears the list.
+	updated, ok, err := UpdateSavedSearch(db, SavedSearch{ID: s.ID, Name: "Cats", Query: "tag:cat", Sort: "elo"})
+	if err != nil || !ok || updated.Query != "tag:cat" || updated.Predicates != nil || updated.Mode != "AND" || updated.Sort != "elo" {
+		t.Fatalf("updated = %+v %v %v", updated, ok, err)
+	}
+	other, err := CreateSavedSearch(db, SavedSearch{Name: "Dogs", Query: "tag:dog"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := UpdateSavedSearch(db, SavedSearch{ID: other.ID, Name: "cats", Query: "tag:dog"}); err == nil {
+		t.Error("rename onto an existing name should fail")
+	}
+
+	list, err := ListSavedSearches(db)
+	if err != nil || len(list) != 2 || list[0].Name != "Cats" || list[1].Name != "Dogs" {
+		t.Fatalf("list = %+v %v", list, err)
+	}
+	if ok, err := DeleteSavedSearch(db, s.ID); err != nil || !ok {
+		t.Fatalf("delete = %v %v", ok, err)
+	}
+	if _, found, _ := GetSavedSearchByID(db, s.ID); found {
+		t.Error("deleted search still found")
+	}
+}
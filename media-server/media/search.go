@@ -123,12 +123,20 @@ type Node interface {
 // Parser parses tokens into an AST
 type Parser struct {
 	lexer *Lexer
+	user  string // whose ratings my: predicates read; "" = library-wide
 }
 
 func NewParser(input string) *Parser {
 	return &Parser{lexer: NewLexer(input)}
 }
 
+// NewUserParser is NewParser with my: predicates (my:liked, my:rating>1500)
+// bound to user's likes and ratings. user "" binds them to the library-wide
+// Favorites tag and media rating columns, which is what NewParser does.
+func NewUserParser(input, user string) *Parser {
+	return &Parser{lexer: NewLexer(input), user: user}
+}
+
 func (p *Parser) Parse() (Node, error) {
 	node, err := p.parseExpression()
 	if err != nil {
@@ -238,6 +246,82 @@ func (p *Parser) parsePredicate() (Node, error) {
 		return nil, fmt.Errorf("expected identifier, got %v", keyToken)
 	}
 
+	operator, valToken, err := p.parseOperatorValue()
+	if err != nil {
+		return nil, err
+	}
+
+	// my:liked, my:rating>1500 — the requesting user's own likes and
+	// ratings. The field rides in the value slot, so a comparison may follow.
+	if strings.EqualFold(keyToken.Value, "my") && operator == "=" {
+		field := strings.ToLower(valToken.Value)
+		switch p.lexer.peek().Type {
+		case TokenColon, TokenGT, TokenLT, TokenGTE, TokenLTE:
+			if operator, valToken, err = p.parseOperatorValue(); err != nil {
+				return nil, err
+			}
+		default:
+			operator, valToken = "=", Token{Type: TokenIdentifier, Value: "true"}
+		}
+		return &ConditionNode{Column: "my:" + field, Operator: operator, Value: valToken.Value, User: p.user}, nil
+	}
+
+	// translation:es, translation:es:"*hola*" — a translated transcript
+	// (translate task) in that language, optionally matching the text the
+	// way transcript: matches the original. The language rides in the value
+	// slot like my:'s field.
+	if strings.EqualFold(keyToken.Value, "translation") && operator == "=" {
+		lang, ok := NormalizeLang(valToken.Value)
+		if !ok {
+			lang = ""
+		}
+		value := ""
+		if p.lexer.peek().Type == TokenColon {
+			if operator, valToken, err = p.parseOperatorValue(); err != nil {
+				return nil, err
+			}
+			value = valToken.Value
+			if operator == "=" && strings.ContainsAny(value, "*%") {
+				operator = "LIKE"
+				value = strings.ReplaceAll(value, "*", "%")
+			}
+		}
+		return &ConditionNode{Column: "translation:" + lang, Operator: operator, Value: value}, nil
+	}
+
+	if valToken.Type != TokenIdentifier && valToken.Type != TokenString {
+		// Allow identifiers, strings, numbers
+		// Note: TokenInt/Float are not explicitly in our enum but scanner returns them
+		// Our scan() maps everything else to TokenIdentifier so this check is simplified
+	}
+
+	// together:"Alice","Bob" — the names follow as a comma-separated list,
+	// carried in one value the way the chip form writes it.
+	value := valToken.Value
+	if strings.EqualFold(keyToken.Value, "together") {
+		for next := p.lexer.peek(); next.Type == TokenIdentifier && next.Value == ","; next = p.lexer.peek() {
+			p.lexer.scan()
+			value += "," + p.lexer.scan().Value
+		}
+		return &ConditionNode{Column: "together", Operator: operator, Value: value}, nil
+	}
+
+	// Handle wildcards in value for standard equality
+	if operator == "=" && (strings.Contains(value, "*") || strings.Contains(value, "%")) {
+		operator = "LIKE"
+		value = strings.ReplaceAll(value, "*", "%")
+	}
+
+	return &ConditionNode{
+		Column:   strings.ToLower(keyToken.Value),
+		Operator: operator,
+		Value:    value,
+	}, nil
+}
+
+// parseOperatorValue consumes the comparison after a key and the value token
+// that follows it.
+func (p *Parser) parseOperatorValue() (string, Token, error) {
 	// Check for operator
 	opToken := p.lexer.peek()
 	var operator string
@@ -262,7 +346,7 @@ func (p *Parser) parsePredicate() (Node, error) {
 		// Default to text search on description/path/tags if no operator?
 		// But existing logic is strict on key:value.
 		// Let's assume standard key:value syntax.
-		return nil, fmt.Errorf("expected ':', '>', '<', '>=', '<=' after key, got %v", opToken.Value)
+		return "", Token{}, fmt.Errorf("expected ':', '>', '<', '>=', '<=' after key, got %v", opToken.Value)
 	}
 
 	// Value
@@ -286,25 +370,7 @@ func (p *Parser) parsePredicate() (Node, error) {
 			valToken = p.lexer.scan()
 		}
 	}
-
-	if valToken.Type != TokenIdentifier && valToken.Type != TokenString {
-		// Allow identifiers, strings, numbers
-		// Note: TokenInt/Float are not explicitly in our enum but scanner returns them
-		// Our scan() maps everything else to TokenIdentifier so this check is simplified
-	}
-
-	// Handle wildcards in value for standard equality
-	value := valToken.Value
-	if operator == "=" && (strings.Contains(value, "*") || strings.Contains(value, "%")) {
-		operator = "LIKE"
-		value = strings.ReplaceAll(value, "*", "%")
-	}
-
-	return &ConditionNode{
-		Column:   strings.ToLower(keyToken.Value),
-		Operator: operator,
-		Value:    value,
-	}, nil
+	return operator, valToken, nil
 }
 
 // TokenInt isn't defined in enum, reusing TokenIdentifier for values as scanner simplifies it
@@ -316,6 +382,7 @@ type ConditionNode struct {
 	Column   string
 	Operator string
 	Value    string
+	User     string // my: predicates only — whose likes/ratings; "" = library-wide
 }
 
 func (n *ConditionNode) ToSQL() (string, []interface{}) {
@@ -336,6 +403,13 @@ func (n *ConditionNode) ToSQL() (string, []interface{}) {
 			return "m.transcript IS NULL", nil
 		}
 		return "m.transcript " + op + " ?", []interface{}{val}
+	case "speaker":
+		// speaker:"Speaker 2" / speaker:Ann* — a cue in the diarized
+		// transcript voiced by that speaker (a WebVTT <v Name> span).
+		if op != "=" && op != "LIKE" {
+			return "1=0", nil
+		}
+		return "m.transcript LIKE ?", []interface{}{"%<v " + val + ">%"}
 	case "filetype":
 		// filetype:video / filetype:audio / filetype:image — classify by path
 		// extension so batch jobs (e.g. transcription) can target only the media
@@ -440,10 +514,75 @@ func (n *ConditionNode) ToSQL() (string, []interface{}) {
 		return fmt.Sprintf("EXISTS (SELECT 1 FROM media_tag_by_category mtbc WHERE mtbc.media_path = m.path AND mtbc.tag_label %s ?)", op), []interface{}{val}
 	case "category":
 		return fmt.Sprintf("EXISTS (SELECT 1 FROM media_tag_by_category mtbc WHERE mtbc.media_path = m.path AND mtbc.category_label %s ?)", op), []interface{}{val}
+	case "person":
+		// person:Alice — a People tag under the person's name or their
+		// auto-cluster label (see PersonClusterSuffix).
+		if op == "LIKE" {
+			return "EXISTS (SELECT 1 FROM media_tag_by_category mtbc WHERE mtbc.media_path = m.path AND mtbc.category_label = ? AND mtbc.tag_label LIKE ?)", []interface{}{PeopleCategory, val}
+		}
+		if op != "=" {
+			return "1=0", nil
+		}
+		return "EXISTS (SELECT 1 FROM media_tag_by_category mtbc WHERE mtbc.media_path = m.path AND mtbc.category_label = ? AND mtbc.tag_label IN (?, ?))", []interface{}{PeopleCategory, val, val + PersonClusterSuffix}
+	case "with", "together":
+		// with:Alice — Alice alongside another named person;
+		// together:"Alice","Bob" — all of them, from the co-occurrence pairs.
+		if op != "=" {
+			return "1=0", nil
+		}
+		if column == "with" {
+			return CooccurWithSQL("m.path", val)
+		}
+		return CooccurTogetherSQL("m.path", SplitTogetherNames(val))
 	case "exists":
 		// Handled in Go, always true in SQL to fetch candidate
 		return "1=1", nil
+	case "translation:":
+		return "1=0", nil // malformed language
+	case "my:liked":
+		liked := "EXISTS (SELECT 1 FROM media_tag_by_category mtbc WHERE mtbc.media_path = m.path AND mtbc.tag_label = ? AND mtbc.category_label = ?)"
+		args := []interface{}{FavoritesTag, FavoritesCategory}
+		if n.User != "" {
+			liked = "EXISTS (SELECT 1 FROM user_like ul WHERE ul.username = ? AND ul.media_path = m.path)"
+			args = []interface{}{n.User}
+		}
+		if b, err := strconv.ParseBool(val); err == nil && !b {
+			return "NOT " + liked, args
+		}
+		return liked, args
+	case "my:rating", "my:elo", "my:views", "my:wins", "my:losses", "my:battles":
+		if op == "LIKE" {
+			return "1=0", nil
+		}
+		field := strings.TrimPrefix(column, "my:")
+		if field == "rating" {
+			field = "elo"
+		}
+		// Unrated items compare as a fresh rating: 1500 Elo, zero counts.
+		unrated := "0"
+		if field == "elo" {
+			unrated = strconv.Itoa(DefaultElo)
+		}
+		num, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return "1=0", nil
+		}
+		if n.User == "" {
+			return "COALESCE(m." + field + ", " + unrated + ") " + op + " ?", []interface{}{num}
+		}
+		return "COALESCE((SELECT us." + field + " FROM user_media_stats us WHERE us.username = ? AND us.media_path = m.path), " +
+			unrated + ") " + op + " ?", []interface{}{n.User, num}
 	default:
+		if lang, ok := strings.CutPrefix(column, "translation:"); ok {
+			sub := "SELECT 1 FROM media_transcript mt WHERE mt.media_path = m.path AND mt.lang = ?"
+			if val == "" {
+				return "EXISTS (" + sub + ")", []interface{}{lang}
+			}
+			if op != "=" && op != "LIKE" {
+				return "1=0", nil
+			}
+			return "EXISTS (" + sub + " AND mt.transcript " + op + " ?)", []interface{}{lang, val}
+		}
 		// Ignore unknown columns or fail?
 		return "1=1", nil // Ignore safely
 	}
@@ -514,6 +653,17 @@ func (n *ConditionNode) Evaluate(item MediaItem) bool {
 			}
 		}
 		return false
+	case "person":
+		for _, t := range item.Tags {
+			if t.Category != PeopleCategory {
+				continue
+			}
+			if compareString(t.Label, n.Operator, n.Value) ||
+				(n.Operator == "=" && strings.EqualFold(t.Label, n.Value+PersonClusterSuffix)) {
+				return true
+			}
+		}
+		return false
 	case "tags":
 		if strings.ToLower(n.Value) == "none" {
 			return len(item.Tags) == 0
@@ -534,14 +684,14 @@ func (n *ConditionNode) Evaluate(item MediaItem) bool {
 		// Note: The SQL does strictly one level.
 		// Go's filepath.Dir gives the parent.
 		return strings.EqualFold(dir, target)
-	case "transcript":
+	case "transcript", "speaker":
 		// MediaItem doesn't carry the transcript column (GetItems never selects
 		// it), so in-memory evaluation can't check it. The SQL side already
 		// filtered; treat as satisfied rather than dropping every row on the
 		// exists-condition slow path.
 		return true
-	case "faces":
-		// Face rows live in their own table; the SQL side already filtered.
+	case "faces", "with", "together":
+		// Face rows live in their own tables; the SQL side already filtered.
 		return true
 	case "filetype":
 		ext := strings.ToLower(filepath.Ext(item.Path))
@@ -0,0 +1,222 @@
+package media
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Shares are anonymous, revocable links to part of the library: one media
+// path, everything carrying a tag, a search-DSL query, a saved search, or a
+// collection. The main package mints the token, decides membership, and
+// serves the media; this file only stores shares and their access log.
+//
+// A path share keeps its path in media_path (so MovePath/MergeInto follow
+// the file); every other kind keeps its target in target.
+
+// Share kinds.
+const (
+	ShareKindPath       = "path"
+	ShareKindTag        = "tag"
+	ShareKindQuery      = "query"
+	ShareKindSaved      = "saved"
+	ShareKindCollection = "collection"
+)
+
+var shareKinds = map[string]bool{
+	ShareKindPath: true, ShareKindTag: true, ShareKindQuery: true,
+	ShareKindSaved: true, ShareKindCollection: true,
+}
+
+// shareAccessKeep bounds each share's access log; older entries are trimmed
+// as new ones arrive.
+const shareAccessKeep = 1000
+
+// Share is one share link as the API reports it. PasswordHash never leaves
+// the server; HasPassword says whether there is one.
+type Share struct {
+	ID           int64  `json:"id"`
+	Token        string `json:"token"`
+	Kind         string `json:"kind"`
+	Target       string `json:"target"`
+	Title        string `json:"title"`
+	PasswordHash string `json:"-"`
+	HasPassword  bool   `json:"hasPassword"`
+	ExpiresAt    int64  `json:"expiresAt"` // unix seconds; 0 = never
+	MaxViews     int    `json:"maxViews"`  // 0 = unlimited
+	Views        int    `json:"views"`
+	CreatedBy    string `json:"createdBy"`
+	CreatedAt    int64  `json:"createdAt"`
+	RevokedAt    int64  `json:"revokedAt"` // 0 = live
+}
+
+// ShareAccess is one entry of a share's access log.
+type ShareAccess struct {
+	ID      int64  `json:"id"`
+	ShareID int64  `json:"shareId"`
+	At      int64  `json:"at"`
+	Remote  string `json:"remote"`
+	Route   string `json:"route"`
+	Path    string `json:"path,omitempty"`
+	Outcome string `json:"outcome"`
+}
+
+const shareColumns = `id, token, kind,
+	CASE WHEN kind = 'path' THEN COALESCE(media_path, '') ELSE target END,
+	title, password_hash, expires_at, max_views, views, created_by,
+	COALESCE(created_at, 0), revoked_at`
+
+func scanShare(row interface{ Scan(...any) error }) (Share, error) {
+	var s Share
+	if err := row.Scan(&s.ID, &s.Token, &s.Kind, &s.Target, &s.Title, &s.PasswordHash,
+		&s.ExpiresAt, &s.MaxViews, &s.Views, &s.CreatedBy, &s.CreatedAt, &s.RevokedAt); err != nil {
+		return Share{}, err
+	}
+	s.HasPassword = s.PasswordHash != ""
+	return s, nil
+}
+
+// CreateShare stores s (Token, Kind, and Target required; the caller hashes
+// any password) and returns it with its ID and creation time set.
+func CreateShare(db *sql.DB, s Share) (Share, error) {
+	s.Kind = strings.ToLower(strings.TrimSpace(s.Kind))
+	s.Target = strings.TrimSpace(s.Target)
+	s.Title = strings.TrimSpace(s.Title)
+	switch {
+	case s.Token == "":
+		return Share{}, fmt.Errorf("share token required")
+	case !shareKinds[s.Kind]:
+		return Share{}, fmt.Errorf("share kind must be path, tag, query, saved, or collection (got %q)", s.Kind)
+	case s.Target == "":
+		return Share{}, fmt.Errorf("share target required")
+	case s.MaxViews < 0:
+		return Share{}, fmt.Errorf("share maxViews must be 0 (unlimited) or more")
+	}
+	now := time.Now().Unix()
+	if s.ExpiresAt != 0 && s.ExpiresAt <= now {
+		return Share{}, fmt.Errorf("share expiry must be in the future")
+	}
+	target, mediaPath := s.Target, sql.NullString{}
+	if s.Kind == ShareKindPath {
+		target, mediaPath = "", sql.NullString{String: s.Target, Valid: true}
+	}
+	res, err := db.Exec(
+		`INSERT INTO share (token, kind, target, media_path, title, password_hash, expires_at, max_views, created_by, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		s.Token, s.Kind, target, mediaPath, s.Title, s.PasswordHash, s.ExpiresAt, s.MaxViews, s.CreatedBy, now,
+	)
+	if err != nil {
+		return Share{}, fmt.Errorf("create share: %w", err)
+	}
+	if s.ID, err = res.LastInsertId(); err != nil {
+		return Share{}, err
+	}
+	s.CreatedAt, s.Views, s.RevokedAt = now, 0, 0
+	s.HasPassword = s.PasswordHash != ""
+	return s, nil
+}
+
+// ListShares returns every share, newest first, revoked ones included.
+func ListShares(db *sql.DB) ([]Share, error) {
+	rows, err := db.Query(`SELECT ` + shareColumns + ` FROM share ORDER BY id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Share
+	for rows.Next() {
+		s, err := scanShare(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// GetShareByID returns one share by ID.
+func GetShareByID(db *sql.DB, id int64) (Share, bool, error) {
+	return getShare(db, `id = ?`, id)
+}
+
+// GetShareByToken returns the share a link token names.
+func GetShareByToken(db *sql.DB, token string) (Share, bool, error) {
+	return getShare(db, `token = ?`, token)
+}
+
+func getShare(db *sql.DB, where string, arg any) (Share, bool, error) {
+	s, err := scanShare(db.QueryRow(`SELECT `+shareColumns+` FROM share WHERE `+where, arg))
+	if err == sql.ErrNoRows {
+		return Share{}, false, nil
+	}
+	if err != nil {
+		return Share{}, false, err
+	}
+	return s, true, nil
+}
+
+// RevokeShare stops a share from redeeming. The row and its access log are
+// kept. ok is false when no such share exists.
+func RevokeShare(db *sql.DB, id int64) (bool, error) {
+	if _, found, err := GetShareByID(db, id); err != nil || !found {
+		return false, err
+	}
+	_, err := db.Exec(`UPDATE share SET revoked_at = ? WHERE id = ? AND revoked_at = 0`, time.Now().Unix(), id)
+	return err == nil, err
+}
+
+// CountShareView spends one of the share's views. ok is false once the
+// view limit is reached; the count never passes it.
+func CountShareView(db *sql.DB, id int64) (bool, error) {
+	res, err := db.Exec(`UPDATE share SET views = views + 1 WHERE id = ? AND (max_views = 0 OR views < max_views)`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// LogShareAccess appends an entry to a share's access log, trimming the
+// log to its newest shareAccessKeep entries.
+func LogShareAccess(db *sql.DB, a ShareAccess) error {
+	if a.At == 0 {
+		a.At = time.Now().Unix()
+	}
+	if _, err := db.Exec(
+		`INSERT INTO share_access (share_id, at, remote, route, media_path, outcome) VALUES (?, ?, ?, ?, ?, ?)`,
+		a.ShareID, a.At, a.Remote, a.Route, a.Path, a.Outcome,
+	); err != nil {
+		return fmt.Errorf("log share access: %w", err)
+	}
+	_, err := db.Exec(
+		`DELETE FROM share_access WHERE share_id = ? AND id <= (
+			SELECT id FROM share_access WHERE share_id = ? ORDER BY id DESC LIMIT 1 OFFSET ?)`,
+		a.ShareID, a.ShareID, shareAccessKeep,
+	)
+	return err
+}
+
+// ShareAccessLog returns a share's access log, newest first. limit <= 0
+// returns everything kept.
+func ShareAccessLog(db *sql.DB, shareID int64, limit int) ([]ShareAccess, error) {
+	if limit <= 0 {
+		limit = shareAccessKeep
+	}
+	rows, err := db.Query(
+		`SELECT id, share_id, at, remote, route, media_path, outcome
+		 FROM share_access WHERE share_id = ? ORDER BY id DESC LIMIT ?`, shareID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []ShareAccess
+	for rows.Next() {
+		var a ShareAccess
+		if err := rows.Scan(&a.ID, &a.ShareID, &a.At, &a.Remote, &a.Route, &a.Path, &a.Outcome); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
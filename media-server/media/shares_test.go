@@ -0,0 +1,86 @@
+package media
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestShareLifecycle(t *testing.T) {
+	db := newPeopleDB(t)
+
+	if _, err := CreateShare(db, Share{Token: "t0", Kind: "album", Target: "x"}); err == nil {
+		t.Error("unknown kind accepted")
+	}
+	if _, err := CreateShare(db, Share{Token: "t0", Kind: ShareKindTag, Target: "cat", ExpiresAt: time.Now().Add(-time.Hour).Unix()}); err == nil {
+		t.Error("past expiry accepted")
+	}
+
+	s, err := CreateShare(db, Share{Token: "t1", Kind: ShareKindPath, Target: "/a.jpg", MaxViews: 2, PasswordHash: "h"})
+	if err != nil {
+		t.Fatalf("CreateShare: %v", err)
+	}
+	got, found, err := GetShareByToken(db, "t1")
+	if err != nil || !found || got.ID != s.ID || got.Target != "/a.jpg" || !got.HasPassword || got.MaxViews != 2 {
+		t.Fatalf("GetShareByToken = %+v, %v, %v", got, found, err)
+	}
+
+	// Views stop at the limit.
+	for i, want := range []bool{true, true, false} {
+		if ok, err := CountShareView(db, s.ID); err != nil || ok != want {
+			t.Errorf("view %d: ok = %v, err = %v; want %v", i+1, ok, err, want)
+		}
+	}
+	if got, _, _ := GetShareByID(db, s.ID); got.Views != 2 {
+		t.Errorf("views = %d, want 2", got.Views)
+	}
+
+	// A path share follows its file.
+	if _, err := db.Exec(`INSERT INTO media (path) VALUES ('/a.jpg')`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := MovePath(context.Background(), db, "/a.jpg", "/moved/a.jpg", MoveOptions{}); err != nil {
+		t.Fatalf("MovePath: %v", err)
+	}
+	if got, _, _ := GetShareByID(db, s.ID); got.Target != "/moved/a.jpg" {
+		t.Errorf("after move target = %q", got.Target)
+	}
+
+	if ok, err := RevokeShare(db, s.ID); err != nil || !ok {
+		t.Fatalf("RevokeShare = %v, %v", ok, err)
+	}
+	if got, _, _ := GetShareByID(db, s.ID); got.RevokedAt == 0 {
+		t.Error("share not revoked")
+	}
+	if ok, _ := RevokeShare(db, 999); ok {
+		t.Error("revoking a missing share reported ok")
+	}
+
+	list, err := ListShares(db)
+	if err != nil || len(list) != 1 {
+		t.Errorf("ListShares = %v, %v", list, err)
+	}
+}
+
+func TestShareAccessLogIsBounded(t *testing.T) {
+	db := newPeopleDB(t)
+	s, err := CreateShare(db, Share{Token: "t", Kind: ShareKindTag, Target: "cat"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < shareAccessKeep+5; i++ {
+		if err := LogShareAccess(db, ShareAccess{ShareID: s.ID, Route: "/media/file", Outcome: "view"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := ShareAccessLog(db, s.ID, 0)
+	if err != nil || len(entries) != shareAccessKeep {
+		t.Fatalf("log has %d entries, err = %v; want %d", len(entries), err, shareAccessKeep)
+	}
+	if entries[0].ID < entries[1].ID {
+		t.Error("log is not newest first")
+	}
+	if few, _ := ShareAccessLog(db, s.ID, 3); len(few) != 3 {
+		t.Errorf("limit 3 returned %d", len(few))
+	}
+}
@@ -0,0 +1,115 @@
+package media
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/stevecastle/shrike/embedvec"
+)
+
+// Text chunk sources: which media column a chunk was cut from.
+const (
+	TextSourceTranscript  = "transcript"
+	TextSourceDescription = "description"
+)
+
+// TextChunk is one embedded window of a media item's description or
+// transcript. StartMs/EndMs locate transcript chunks in the media timeline
+// (both 0 for descriptions).
+type TextChunk struct {
+	Seq     int
+	Source  string
+	StartMs int64
+	EndMs   int64
+	Text    string
+	Vec     []float32
+}
+
+// StoredTextChunk is a TextChunk read back with its row ID and media path.
+type StoredTextChunk struct {
+	ID   int64
+	Path string
+	TextChunk
+}
+
+// ReplaceTextChunks swaps path's chunks under model for chunks in one
+// transaction and returns the new row IDs (parallel to chunks). A re-run of
+// the textembed op after the transcript changed therefore never leaves stale
+// windows behind.
+func ReplaceTextChunks(db *sql.DB, path, model string, chunks []TextChunk) ([]int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM media_text_chunk WHERE media_path = ? AND model = ?`, path, model); err != nil {
+		return nil, err
+	}
+	stmt, err := tx.Prepare(`INSERT INTO media_text_chunk
+		(media_path, model, seq, source, start_ms, end_ms, text, vector, created_at)
+		VALUES (?,?,?,?,?,?,?,?,?)`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+	now := time.Now().Unix()
+	ids := make([]int64, 0, len(chunks))
+	for _, c := range chunks {
+		res, err := stmt.Exec(path, model, c.Seq, c.Source, c.StartMs, c.EndMs, c.Text, embedvec.Encode(c.Vec), now)
+		if err != nil {
+			return nil, err
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, tx.Commit()
+}
+
+// HasTextChunks reports whether path already has chunks under model.
+func HasTextChunks(db *sql.DB, path, model string) (bool, error) {
+	var one int
+	err := db.QueryRow(
+		`SELECT 1 FROM media_text_chunk WHERE media_path = ? AND model = ? LIMIT 1`,
+		path, model,
+	).Scan(&one)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// LoadTextChunks returns every chunk stored under model, or only path's when
+// path is non-empty. Used to build (and re-key) the semantic search index.
+func LoadTextChunks(db *sql.DB, model, path string) ([]StoredTextChunk, error) {
+	q := `SELECT id, media_path, seq, source, start_ms, end_ms, text, vector
+		FROM media_text_chunk WHERE model = ?`
+	args := []any{model}
+	if path != "" {
+		q += ` AND media_path = ?`
+		args = append(args, path)
+	}
+	rows, err := db.Query(q+` ORDER BY media_path, seq`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []StoredTextChunk
+	for rows.Next() {
+		var c StoredTextChunk
+		var blob []byte
+		if err := rows.Scan(&c.ID, &c.Path, &c.Seq, &c.Source, &c.StartMs, &c.EndMs, &c.Text, &blob); err != nil {
+			return nil, err
+		}
+		if c.Vec, err = embedvec.Decode(blob); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
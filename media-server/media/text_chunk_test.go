@@ -0,0 +1,45 @@
+package media
+
+import "testing"
+
+func TestReplaceAndLoadTextChunks(t *testing.T) {
+	db := newEmbedDB(t)
+	defer db.Close()
+
+	first := []TextChunk{
+		{Seq: 0, Source: TextSourceDescription, Text: "a beach", Vec: []float32{1, 0}},
+		{Seq: 1, Source: TextSourceTranscript, StartMs: 1500, EndMs: 4000, Text: "waves", Vec: []float32{0, 1}},
+	}
+	ids, err := ReplaceTextChunks(db, "a.mp4", "m1", first)
+	if err != nil || len(ids) != 2 {
+		t.Fatalf("replace: ids=%v err=%v", ids, err)
+	}
+	if _, err := ReplaceTextChunks(db, "b.mp4", "m1", first[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := HasTextChunks(db, "a.mp4", "m1"); !ok {
+		t.Error("a.mp4 should have chunks under m1")
+	}
+	if ok, _ := HasTextChunks(db, "a.mp4", "m2"); ok {
+		t.Error("chunks are per model")
+	}
+
+	got, err := LoadTextChunks(db, "m1", "a.mp4")
+	if err != nil || len(got) != 2 {
+		t.Fatalf("load: %v %v", got, err)
+	}
+	if c := got[1]; c.ID != ids[1] || c.StartMs != 1500 || c.EndMs != 4000 || c.Text != "waves" || c.Vec[1] != 1 {
+		t.Errorf("roundtrip mismatch: %+v", c)
+	}
+	if all, _ := LoadTextChunks(db, "m1", ""); len(all) != 3 {
+		t.Errorf("load all = %d rows, want 3", len(all))
+	}
+
+	// A re-run replaces the old windows rather than appending to them.
+	if _, err := ReplaceTextChunks(db, "a.mp4", "m1", first[1:]); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := LoadTextChunks(db, "m1", "a.mp4"); len(got) != 1 || got[0].Text != "waves" {
+		t.Errorf("after replace: %+v", got)
+	}
+}
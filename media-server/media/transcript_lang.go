@@ -0,0 +1,130 @@
+package media
+
+import (
+	"database/sql"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Translation sources: how a media_transcript row was produced.
+const (
+	TranslationSourceLLM     = "llm"
+	TranslationSourceWhisper = "whisper"
+	TranslationSourceManual  = "manual"
+)
+
+// langRe accepts BCP 47-style tags as the translate task writes them: a
+// primary language ("es", "fil") plus optional subtags ("pt-br", "zh-hant").
+var langRe = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+
+// NormalizeLang lowercases a language tag (underscores become hyphens) and
+// reports whether it is well-formed. Every media_transcript key goes through
+// it, so "pt_BR" and "pt-br" name the same track.
+func NormalizeLang(s string) (string, bool) {
+	s = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(s)), "_", "-")
+	return s, langRe.MatchString(s)
+}
+
+// langNames are display names for common tags, used in translation prompts
+// and subtitle track menus.
+var langNames = map[string]string{
+	"ar": "Arabic", "de": "German", "en": "English", "es": "Spanish",
+	"fr": "French", "hi": "Hindi", "it": "Italian", "ja": "Japanese",
+	"ko": "Korean", "nl": "Dutch", "pl": "Polish", "pt": "Portuguese",
+	"pt-br": "Brazilian Portuguese", "ru": "Russian", "sv": "Swedish",
+	"tr": "Turkish", "uk": "Ukrainian", "zh": "Chinese",
+	"zh-hans": "Simplified Chinese", "zh-hant": "Traditional Chinese",
+}
+
+// LangName returns a readable name for a normalized tag, or the tag itself
+// when it isn't a common one.
+func LangName(lang string) string {
+	if n, ok := langNames[lang]; ok {
+		return n
+	}
+	return lang
+}
+
+// TranscriptTranslation is one language's transcript for a media item.
+type TranscriptTranslation struct {
+	Lang       string `json:"lang"`
+	Transcript string `json:"transcript"`
+	Source     string `json:"source"`
+	Model      string `json:"model"`
+	CreatedAt  int64  `json:"createdAt"`
+}
+
+// SetTranscriptTranslation stores (or replaces) path's transcript in t.Lang.
+func SetTranscriptTranslation(db *sql.DB, path string, t TranscriptTranslation) error {
+	_, err := db.Exec(`INSERT INTO media_transcript (media_path, lang, transcript, source, model, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(media_path, lang) DO UPDATE SET
+			transcript = excluded.transcript,
+			source     = excluded.source,
+			model      = excluded.model,
+			created_at = excluded.created_at`,
+		path, t.Lang, t.Transcript, t.Source, t.Model, time.Now().Unix())
+	return err
+}
+
+// GetTranscriptTranslation returns path's transcript in lang; ok is false
+// when there is none.
+func GetTranscriptTranslation(db *sql.DB, path, lang string) (TranscriptTranslation, bool, error) {
+	t := TranscriptTranslation{Lang: lang}
+	var created sql.NullInt64
+	err := db.QueryRow(`SELECT transcript, source, model, created_at FROM media_transcript
+		WHERE media_path = ? AND lang = ?`, path, lang).Scan(&t.Transcript, &t.Source, &t.Model, &created)
+	if err == sql.ErrNoRows {
+		return TranscriptTranslation{}, false, nil
+	}
+	if err != nil {
+		return TranscriptTranslation{}, false, err
+	}
+	t.CreatedAt = created.Int64
+	return t, true, nil
+}
+
+// TranscriptLanguages lists the languages path has translations in, sorted.
+func TranscriptLanguages(db *sql.DB, path string) ([]string, error) {
+	rows, err := db.Query(`SELECT lang FROM media_transcript WHERE media_path = ? ORDER BY lang`, path)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var lang string
+		if err := rows.Scan(&lang); err != nil {
+			return nil, err
+		}
+		out = append(out, lang)
+	}
+	return out, rows.Err()
+}
+
+// DeleteTranscriptTranslation removes path's transcript in lang and reports
+// whether there was one.
+func DeleteTranscriptTranslation(db *sql.DB, path, lang string) (bool, error) {
+	res, err := db.Exec(`DELETE FROM media_transcript WHERE media_path = ? AND lang = ?`, path, lang)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// TranslationSQL is the translation:"es" / translation:"es:text" condition
+// on the media path expression pathExpr: the item has a transcript in that
+// language, containing text when given ('*' is a wildcard).
+func TranslationSQL(pathExpr, value string) (string, []any) {
+	rawLang, text, hasText := strings.Cut(value, ":")
+	lang, _ := NormalizeLang(rawLang)
+	cond := `EXISTS (SELECT 1 FROM media_transcript mt WHERE mt.media_path = ` + pathExpr + ` AND mt.lang = ?`
+	args := []any{lang}
+	if text = strings.TrimSpace(text); hasText && text != "" {
+		cond += ` AND mt.transcript LIKE ?`
+		args = append(args, "%"+strings.ReplaceAll(text, "*", "%")+"%")
+	}
+	return cond + ")", args
+}
@@ -0,0 +1,52 @@
+package media
+
+import "testing"
+
+func TestNormalizeLang(t *testing.T) {
+	for in, want := range map[string]string{"es": "es", " pt_BR ": "pt-br", "zh-Hant": "zh-hant", "FIL": "fil"} {
+		if got, ok := NormalizeLang(in); !ok || got != want {
+			t.Errorf("NormalizeLang(%q) = %q, %v; want %q", in, got, ok, want)
+		}
+	}
+	for _, in := range []string{"", "e", "spanish", "es/../x", "es-"} {
+		if _, ok := NormalizeLang(in); ok {
+			t.Errorf("NormalizeLang(%q) accepted", in)
+		}
+	}
+}
+
+func TestTranscriptTranslations(t *testing.T) {
+	db := newEmbedDB(t)
+	defer db.Close()
+
+	if _, ok, err := GetTranscriptTranslation(db, "a.mp4", "es"); ok || err != nil {
+		t.Fatalf("get before set: ok=%v err=%v", ok, err)
+	}
+	for _, lang := range []string{"fr", "es"} {
+		tr := TranscriptTranslation{Lang: lang, Transcript: "WEBVTT\n\n" + lang, Source: TranslationSourceLLM, Model: "m1"}
+		if err := SetTranscriptTranslation(db, "a.mp4", tr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A re-run replaces the language's row.
+	if err := SetTranscriptTranslation(db, "a.mp4", TranscriptTranslation{Lang: "es", Transcript: "WEBVTT\n\nhola", Source: TranslationSourceWhisper}); err != nil {
+		t.Fatal(err)
+	}
+	got, ok, err := GetTranscriptTranslation(db, "a.mp4", "es")
+	if err != nil || !ok || got.Transcript != "WEBVTT\n\nhola" || got.Source != TranslationSourceWhisper || got.Model != "" || got.CreatedAt == 0 {
+		t.Errorf("get es = %+v ok=%v err=%v", got, ok, err)
+	}
+	if langs, _ := TranscriptLanguages(db, "a.mp4"); len(langs) != 2 || langs[0] != "es" || langs[1] != "fr" {
+		t.Errorf("languages = %v, want [es fr]", langs)
+	}
+
+	if gone, err := DeleteTranscriptTranslation(db, "a.mp4", "fr"); !gone || err != nil {
+		t.Errorf("delete fr: %v %v", gone, err)
+	}
+	if gone, _ := DeleteTranscriptTranslation(db, "a.mp4", "fr"); gone {
+		t.Error("second delete reported a row")
+	}
+	if langs, _ := TranscriptLanguages(db, "a.mp4"); len(langs) != 1 {
+		t.Errorf("languages after delete = %v", langs)
+	}
+}
@@ -1,7 +1,12 @@
 // media-server/media_query.go
 package main
 
-import "strings"
+import (
+	"strconv"
+	"strings"
+
+	"github.com/stevecastle/shrike/media"
+)
 
 // BlendNode is one extra component of a composite similarity predicate: an
 // additional library image ("image" = media path), captured region ("clip" =
@@ -16,7 +21,7 @@ type BlendNode struct {
 
 // Predicate mirrors src/renderer/query/types.ts Predicate.
 type Predicate struct {
-	Type    string `json:"type"` // tag|category|path|description|hash|similar|visual|clip
+	Type    string `json:"type"` // tag|category|path|description|hash|similar|visual|clip|face|semantic|cluster|saved|person|speaker|translation
 	Value   string `json:"value"`
 	Exclude bool   `json:"exclude"`
 	Join    string `json:"join"` // "AND" | "OR" | "" (empty falls back to mode)
@@ -34,7 +39,11 @@ type Predicate struct {
 	// "shared" = must-match-all (tasks.SearchBySharedConcept), which zeroes in
 	// on what the positive nodes have in common.
 	BlendMode string   `json:"blendMode"`
-	Resolved  []string `json:"-"` // visual predicates (similar/visual/clip): paths resolved by the handler before BuildMediaQuery
+	Resolved  []string `json:"-"` // visual predicates (similar/visual/clip/face/semantic): paths resolved by the handler before BuildMediaQuery
+	// saved predicates: the stored search's own query, expanded by the
+	// handler (expandSavedPredicates) into a subquery yielding a path column.
+	SavedSQL    string `json:"-"`
+	SavedParams []any  `json:"-"`
 }
 
 // Columns returned for the library list. media.description is intentionally
@@ -71,6 +80,49 @@ func clauseFor(p Predicate, params *[]any) string {
 			return "(NOT EXISTS (SELECT 1 FROM media_tag_by_category mtc WHERE mtc.media_path = media.path AND mtc.category_label = ?))"
 		}
 		return "(EXISTS (SELECT 1 FROM media_tag_by_category mtc WHERE mtc.media_path = media.path AND mtc.category_label = ?))"
+	case "person":
+		// person:"name" — media a person's faces were assigned in, through
+		// their People tag rows (plain or _cluster-suffixed name). Mirror of
+		// query-sql.ts.
+		*params = append(*params, personTagParams(p.Value)...)
+		if p.Exclude {
+			return "(NOT EXISTS (SELECT 1 FROM media_tag_by_category mtc WHERE mtc.media_path = media.path AND mtc.category_label = ? AND mtc.tag_label IN (?, ?)))"
+		}
+		return "(EXISTS (SELECT 1 FROM media_tag_by_category mtc WHERE mtc.media_path = media.path AND mtc.category_label = ? AND mtc.tag_label IN (?, ?)))"
+	case "with", "together":
+		// with:"Alice" — Alice and at least one other named person;
+		// together:"Alice","Bob" — all of them. Read from the person_cooccur
+		// pairs. Mirror of query-sql.ts.
+		var cond string
+		var args []any
+		if p.Type == "with" {
+			cond, args = media.CooccurWithSQL("media.path", p.Value)
+		} else {
+			cond, args = media.CooccurTogetherSQL("media.path", media.SplitTogetherNames(p.Value))
+		}
+		*params = append(*params, args...)
+		if p.Exclude {
+			return "(NOT " + cond + ")"
+		}
+		return "(" + cond + ")"
+	case "speaker":
+		// speaker:"Ann" — a cue in the diarized transcript voiced by Ann
+		// (<v Ann>); '*' is a wildcard. An exclude keeps untranscribed items.
+		// Mirror of query-sql.ts.
+		*params = append(*params, "%<v "+strings.ReplaceAll(p.Value, "*", "%")+">%")
+		if p.Exclude {
+			return "(COALESCE(media.transcript, '') NOT LIKE ?)"
+		}
+		return "(media.transcript LIKE ?)"
+	case "translation":
+		// translation:"es" — a Spanish transcript exists; "es:hola" — and it
+		// contains hola. Mirror of query-sql.ts.
+		cond, args := media.TranslationSQL("media.path", p.Value)
+		*params = append(*params, args...)
+		if p.Exclude {
+			return "(NOT " + cond + ")"
+		}
+		return "(" + cond + ")"
 	case "path":
 		*params = append(*params, like)
 		if p.Exclude {
@@ -114,6 +166,44 @@ func clauseFor(p Predicate, params *[]any) string {
 			return "(NOT " + oriented + ")"
 		}
 		return oriented
+	case "cluster":
+		// cluster:<id> — members of one library theme (cluster-library
+		// task). A non-numeric id matches nothing. Mirror of query-sql.ts.
+		id, err := strconv.ParseInt(strings.TrimSpace(p.Value), 10, 64)
+		if err != nil {
+			if p.Exclude {
+				return "(1=1)"
+			}
+			return "(1=0)"
+		}
+		*params = append(*params, id)
+		if p.Exclude {
+			return "(NOT EXISTS (SELECT 1 FROM media_cluster_member mcm WHERE mcm.media_path = media.path AND mcm.cluster_id = ?))"
+		}
+		return "(EXISTS (SELECT 1 FROM media_cluster_member mcm WHERE mcm.media_path = media.path AND mcm.cluster_id = ?))"
+	case "collection":
+		// collection:"name" — members of a hand-ordered collection (names
+		// match case-insensitively). Mirror of query-sql.ts.
+		*params = append(*params, strings.TrimSpace(p.Value))
+		if p.Exclude {
+			return "(NOT EXISTS (SELECT 1 FROM collection_item ci JOIN collection c ON c.id = ci.collection_id WHERE ci.media_path = media.path AND c.name = ?))"
+		}
+		return "(EXISTS (SELECT 1 FROM collection_item ci JOIN collection c ON c.id = ci.collection_id WHERE ci.media_path = media.path AND c.name = ?))"
+	case "saved":
+		// saved:"name" — members of a stored search. The handler expanded it
+		// into SavedSQL; an unknown name leaves that empty and matches
+		// nothing. query-sql.ts cannot expand it and defers to the server.
+		if p.SavedSQL == "" {
+			if p.Exclude {
+				return "(1=1)"
+			}
+			return "(1=0)"
+		}
+		*params = append(*params, p.SavedParams...)
+		if p.Exclude {
+			return "(media.path NOT IN (SELECT path FROM (" + p.SavedSQL + ")))"
+		}
+		return "(media.path IN (SELECT path FROM (" + p.SavedSQL + ")))"
 	case "faces":
 		// faces:ungrouped — media whose detected faces are ALL still
 		// unassigned (the People panel's Ungrouped card). One grouped face
@@ -132,7 +222,7 @@ func clauseFor(p Predicate, params *[]any) string {
 			return "(1=1)"
 		}
 		return "(1=0)"
-	case "similar", "visual", "clip", "face":
+	case "similar", "visual", "clip", "face", "semantic":
 		// Resolved is the path set produced by the handler (similarity search).
 		// Empty set: an include matches nothing; an exclude removes nothing.
 		if len(p.Resolved) == 0 {
@@ -158,6 +248,30 @@ func clauseFor(p Predicate, params *[]any) string {
 func isIncludeTag(p Predicate) bool { return p.Type == "tag" && !p.Exclude }
 func isIncludeCat(p Predicate) bool { return p.Type == "category" && !p.Exclude }
 
+// drivesTagRows reports whether p's tag rows can drive a query, surfacing
+// their weight and timestamp: an include tag, or an include person (one row
+// per video face track, time_stamp = the track's best frame).
+func drivesTagRows(p Predicate) bool {
+	return isIncludeTag(p) || (p.Type == "person" && !p.Exclude)
+}
+
+// personTagParams are the People category and a person's two possible tag
+// labels (see media.PersonClusterSuffix).
+func personTagParams(name string) []any {
+	name = strings.TrimSpace(name)
+	return []any{media.PeopleCategory, name, name + media.PersonClusterSuffix}
+}
+
+// tagRowsOn is the mtcw condition selecting a driving predicate's tag rows.
+func tagRowsOn(p Predicate, params *[]any) string {
+	if p.Type == "person" {
+		*params = append(*params, personTagParams(p.Value)...)
+		return "mtcw.category_label = ? AND mtcw.tag_label IN (?, ?)"
+	}
+	*params = append(*params, p.Value)
+	return "mtcw.tag_label = ?"
+}
+
 // andJoin joins clauses for the conjunct "rest" of an intersection fast path.
 func andJoin(preds []Predicate, params *[]any) string {
 	clauses := []string{}
@@ -170,23 +284,22 @@ func andJoin(preds []Predicate, params *[]any) string {
 // mediaScan is the always-correct path: scan media and combine clauses
 // LEFT-TO-RIGHT with the given connectors (connectors[i] joins valid[i+1]),
 // parenthesized left-associatively to match how the chips read. Surfaces tag
-// columns via a LEFT JOIN on the first include-tag (if any).
+// columns via a LEFT JOIN on the first include tag or person (if any).
 func mediaScan(valid []Predicate, connectors []string) (string, []any) {
-	primaryTag := ""
-	for _, p := range valid {
-		if isIncludeTag(p) {
-			primaryTag = p.Value
+	primary := -1
+	for i, p := range valid {
+		if drivesTagRows(p) {
+			primary = i
 			break
 		}
 	}
 	params := []any{}
 	var selectClause string
-	if primaryTag != "" {
+	if primary >= 0 {
 		selectClause = "SELECT " + baseColumns +
 			", mtcw.weight AS weight, mtcw.tag_label AS tag_label, mtcw.time_stamp AS time_stamp, mtcw.created_at AS created_at" +
 			", media.battles AS battles" +
-			" FROM media LEFT JOIN media_tag_by_category mtcw ON mtcw.media_path = media.path AND mtcw.tag_label = ?"
-		params = append(params, primaryTag)
+			" FROM media LEFT JOIN media_tag_by_category mtcw ON mtcw.media_path = media.path AND " + tagRowsOn(valid[primary], &params)
 	} else {
 		selectClause = "SELECT " + baseColumns + ", " + nullTagCols + " FROM media"
 	}
@@ -282,17 +395,18 @@ func BuildMediaQuery(predicates []Predicate, mode string) (string, []any) {
 	}
 
 	// ---- Intersection (single predicate or all-AND): drive from an indexed
-	//      tag/category with the rest as AND conjuncts ----
+	//      tag/person/category with the rest as AND conjuncts ----
 	if allAnd {
 		driveIdx := -1
 		for i := range valid {
-			if isIncludeTag(valid[i]) {
+			if drivesTagRows(valid[i]) {
 				driveIdx = i
 				break
 			}
 		}
 		if driveIdx >= 0 {
-			params := []any{valid[driveIdx].Value}
+			params := []any{}
+			on := tagRowsOn(valid[driveIdx], &params)
 			rest := []Predicate{}
 			for i := range valid {
 				if i != driveIdx {
@@ -306,7 +420,7 @@ func BuildMediaQuery(predicates []Predicate, mode string) (string, []any) {
 			}
 			sql := "SELECT " + drivenColumns +
 				" FROM media_tag_by_category mtcw LEFT JOIN media ON media.path = mtcw.media_path" +
-				" WHERE mtcw.tag_label = ?" + extra
+				" WHERE " + on + extra
 			return sql, params
 		}
 		catIdx := -1
@@ -129,6 +129,31 @@ func TestBuildMediaQueryOrientation(t *testing.T) {
 	}
 }
 
+func TestBuildMediaQuerySpeaker(t *testing.T) {
+	// speaker: matches the transcript's WebVTT voice span; '*' is a wildcard.
+	sql, params := BuildMediaQuery([]Predicate{{Type: "speaker", Value: "Speaker *"}}, "AND")
+	if !strings.Contains(sql, "(media.transcript LIKE ?)") || len(params) != 1 || params[0] != "%<v Speaker %>%" {
+		t.Fatalf("speaker include: %q %v", sql, params)
+	}
+	// Excluding a speaker keeps items with no transcript at all.
+	sql, _ = BuildMediaQuery([]Predicate{{Type: "speaker", Value: "Ann", Exclude: true}}, "AND")
+	if !strings.Contains(sql, "(COALESCE(media.transcript, '') NOT LIKE ?)") {
+		t.Fatalf("speaker exclude: %q", sql)
+	}
+}
+
+func TestBuildMediaQueryTranslation(t *testing.T) {
+	sql, params := BuildMediaQuery([]Predicate{{Type: "translation", Value: "pt_BR:ol*"}}, "AND")
+	if !strings.Contains(sql, "(EXISTS (SELECT 1 FROM media_transcript mt WHERE mt.media_path = media.path AND mt.lang = ? AND mt.transcript LIKE ?))") ||
+		len(params) != 2 || params[0] != "pt-br" || params[1] != "%ol%%" {
+		t.Fatalf("translation include: %q %v", sql, params)
+	}
+	sql, params = BuildMediaQuery([]Predicate{{Type: "translation", Value: "es", Exclude: true}}, "AND")
+	if !strings.Contains(sql, "(NOT EXISTS (SELECT 1 FROM media_transcript mt WHERE mt.media_path = media.path AND mt.lang = ?))") || len(params) != 1 {
+		t.Fatalf("translation exclude: %q %v", sql, params)
+	}
+}
+
 func TestBuildMediaQueryOrSetUsesInLookup(t *testing.T) {
 	// OR-set of include-tags drives from an indexed tag_label IN, not a scan.
 	sql, params := BuildMediaQuery([]Predicate{
@@ -345,3 +370,115 @@ func TestSortItemsByScore(t *testing.T) {
 		}
 	}
 }
+
+func TestBuildMediaQuerySemanticUsesResolvedPaths(t *testing.T) {
+	// semantic: resolves like the visual types — the handler's path set
+	// becomes an IN list, and an unresolved include matches nothing.
+	sql, params := BuildMediaQuery([]Predicate{{Type: "semantic", Value: "a dog barking", Resolved: []string{"a.mp4", "b.mp4"}}}, "AND")
+	if !strings.Contains(sql, "media.path IN (?, ?)") || len(params) != 2 {
+		t.Fatalf("expected resolved IN list: %q %v", sql, params)
+	}
+	sql, _ = BuildMediaQuery([]Predicate{{Type: "semantic", Value: "a dog barking"}}, "AND")
+	if !strings.Contains(sql, "1=0") {
+		t.Fatalf("unresolved semantic predicate must match nothing: %q", sql)
+	}
+}
+
+func TestBuildMediaQueryCluster(t *testing.T) {
+	sql, params := BuildMediaQuery([]Predicate{{Type: "cluster", Value: "12"}}, "AND")
+	if !strings.Contains(sql, "(EXISTS (SELECT 1 FROM media_cluster_member mcm WHERE mcm.media_path = media.path AND mcm.cluster_id = ?))") {
+		t.Fatalf("expected membership EXISTS: %q", sql)
+	}
+	if len(params) != 1 || params[0] != int64(12) {
+		t.Fatalf("params = %v, want [12]", params)
+	}
+	sql, _ = BuildMediaQuery([]Predicate{{Type: "cluster", Value: "12", Exclude: true}}, "AND")
+	if !strings.Contains(sql, "NOT EXISTS (SELECT 1 FROM media_cluster_member") {
+		t.Fatalf("expected negated membership clause: %q", sql)
+	}
+	sql, _ = BuildMediaQuery([]Predicate{{Type: "cluster", Value: "beach"}}, "AND")
+	if !strings.Contains(sql, "1=0") {
+		t.Fatalf("non-numeric cluster id must match nothing: %q", sql)
+	}
+}
+
+func TestBuildMediaQueryCollection(t *testing.T) {
+	sql, params := BuildMediaQuery([]Predicate{{Type: "collection", Value: " Trip 2024 "}}, "AND")
+	if !strings.Contains(sql, "(EXISTS (SELECT 1 FROM collection_item ci JOIN collection c ON c.id = ci.collection_id WHERE ci.media_path = media.path AND c.name = ?))") {
+		t.Fatalf("expected membership EXISTS: %q", sql)
+	}
+	if len(params) != 1 || params[0] != "Trip 2024" {
+		t.Fatalf("params = %v, want [Trip 2024]", params)
+	}
+	sql, _ = BuildMediaQuery([]Predicate{{Type: "collection", Value: "x", Exclude: true}}, "AND")
+	if !strings.Contains(sql, "NOT EXISTS (SELECT 1 FROM collection_item") {
+		t.Fatalf("expected negated membership clause: %q", sql)
+	}
+}
+
+func TestBuildMediaQueryPerson(t *testing.T) {
+	// A single person drives from the People tag rows, so every tracked
+	// appearance in a video comes back as its own timestamped hit.
+	sql, params := BuildMediaQuery([]Predicate{{Type: "person", Value: " Ada "}}, "AND")
+	if !strings.Contains(sql, "WHERE mtcw.category_label = ? AND mtcw.tag_label IN (?, ?)") {
+		t.Fatalf("expected People tag-row drive: %q", sql)
+	}
+	want := []any{"People", "Ada", "Ada_cluster"}
+	if len(params) != len(want) {
+		t.Fatalf("params = %v, want %v", params, want)
+	}
+	for i := range want {
+		if params[i] != want[i] {
+			t.Fatalf("params = %v, want %v", params, want)
+		}
+	}
+
+	// Mixed connectors scan media and LEFT JOIN the person's rows.
+	sql, _ = BuildMediaQuery([]Predicate{
+		{Type: "person", Value: "Ada"},
+		{Type: "path", Value: "x", Join: "OR"},
+		{Type: "hash", Value: "y", Join: "AND"},
+	}, "AND")
+	if !strings.Contains(sql, "LEFT JOIN media_tag_by_category mtcw ON mtcw.media_path = media.path AND mtcw.category_label = ?") {
+		t.Fatalf("expected person LEFT JOIN: %q", sql)
+	}
+
+	sql, _ = BuildMediaQuery([]Predicate{{Type: "person", Value: "Ada", Exclude: true}}, "AND")
+	if !strings.Contains(sql, "NOT EXISTS (SELECT 1 FROM media_tag_by_category") {
+		t.Fatalf("expected negated person clause: %q", sql)
+	}
+}
+
+func TestBuildMediaQueryWithTogether(t *testing.T) {
+	sql, params := BuildMediaQuery([]Predicate{{Type: "with", Value: "Ada"}}, "AND")
+	if !strings.Contains(sql, "FROM person_cooccur pc") || !strings.Contains(sql, "pc.media_path = media.path") {
+		t.Fatalf("expected co-occurrence clause on media.path: %q", sql)
+	}
+	if !strings.Contains(sql, "p2.name NOT LIKE 'Unknown #%'") {
+		t.Fatalf("with: must require a NAMED companion: %q", sql)
+	}
+	if len(params) != 2 || params[0] != "Ada" || params[1] != "Ada_cluster" {
+		t.Fatalf("with params = %v", params)
+	}
+
+	// The chip tokenizer strips the quotes: together:"Ada","Bo","Cy" arrives
+	// as Ada,Bo,Cy — one pair check per companion.
+	sql, params = BuildMediaQuery([]Predicate{{Type: "together", Value: "Ada, Bo,Cy"}}, "AND")
+	if n := strings.Count(sql, "FROM person_cooccur pc"); n != 2 {
+		t.Fatalf("together pair checks = %d, want 2: %q", n, sql)
+	}
+	want := []any{"Ada", "Ada_cluster", "Bo", "Bo_cluster", "Ada", "Ada_cluster", "Cy", "Cy_cluster"}
+	if len(params) != len(want) {
+		t.Fatalf("together params = %v, want %v", params, want)
+	}
+	for i := range want {
+		if params[i] != want[i] {
+			t.Fatalf("together params = %v, want %v", params, want)
+		}
+	}
+
+	sql, _ = BuildMediaQuery([]Predicate{{Type: "with", Value: "Ada", Exclude: true}}, "AND")
+	if !strings.Contains(sql, "(NOT EXISTS (SELECT 1 FROM person_cooccur") {
+		t.Fatalf("expected negated with clause: %q", sql)
+	}
+}
@@ -75,3 +75,62 @@ func (c *Classifier) Classify(imagePath string) ([]string, error) { return nil,
 
 // Close is a no-op in non-cgo builds.
 func (c *Classifier) Close() error { return nil }
+
+// SentenceEmbedderConfig mirrors the cgo type (sentence.go).
+type SentenceEmbedderConfig struct {
+	ModelPath     string
+	TokenizerPath string
+	InputName     string
+	MaskName      string
+	OutputName    string
+	Dim           int
+	SeqLen        int
+	Pooling       string
+	Provider      EmbedProvider
+	Threads       int
+	Device        int
+	ORTLib        string
+}
+
+// SentenceEmbedder is unavailable without cgo. The real implementation lives
+// in sentence.go (//go:build cgo).
+type SentenceEmbedder struct{}
+
+// NewSentenceEmbedder returns ErrCGORequired in non-cgo builds.
+func NewSentenceEmbedder(cfg SentenceEmbedderConfig) (*SentenceEmbedder, error) {
+	return nil, ErrCGORequired
+}
+
+// Embed returns ErrCGORequired in non-cgo builds.
+func (e *SentenceEmbedder) Embed(text string) ([]float32, error) { return nil, ErrCGORequired }
+
+// Close is a no-op in non-cgo builds.
+func (e *SentenceEmbedder) Close() error { return nil }
+
+// SpeakerEmbedderConfig mirrors the cgo type (speaker.go).
+type SpeakerEmbedderConfig struct {
+	ModelPath  string
+	InputName  string
+	OutputName string
+	Provider   EmbedProvider
+	Threads    int
+	Device     int
+	ORTLib     string
+}
+
+// SpeakerEmbedder is unavailable without cgo. The real implementation lives
+// in speaker.go (//go:build cgo).
+type SpeakerEmbedder struct{}
+
+// NewSpeakerEmbedder returns ErrCGORequired in non-cgo builds.
+func NewSpeakerEmbedder(cfg SpeakerEmbedderConfig) (*SpeakerEmbedder, error) {
+	return nil, ErrCGORequired
+}
+
+// Embed returns ErrCGORequired in non-cgo builds.
+func (e *SpeakerEmbedder) Embed(feats []float32, frames, bins int) ([]float32, error) {
+	return nil, ErrCGORequired
+}
+
+// Close is a no-op in non-cgo builds.
+func (e *SpeakerEmbedder) Close() error { return nil }
@@ -0,0 +1,135 @@
+//go:build cgo
+
+package onnxtag
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/eliben/go-sentencepiece"
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+// SentenceEmbedderConfig configures a persistent SentenceEmbedder.
+type SentenceEmbedderConfig struct {
+	ModelPath     string
+	TokenizerPath string // SentencePiece model (XLM-R vocabulary)
+	InputName     string // token ids, e.g. "input_ids"
+	MaskName      string // attention mask, e.g. "attention_mask"
+	OutputName    string // e.g. "last_hidden_state"
+	Dim           int
+	SeqLen        int
+	Pooling       string // "" / "mean" (masked mean over tokens) or "cls" (token 0)
+	Provider      EmbedProvider
+	Threads       int
+	Device        int
+	ORTLib        string
+}
+
+// SentenceEmbedder holds a text-encoder session and its tokenizer, loaded once
+// and reused across many sentences (the semantic-search counterpart of
+// Embedder). Not safe for concurrent use; Close() must be called.
+type SentenceEmbedder struct {
+	session *ort.DynamicAdvancedSession
+	proc    *sentencepiece.Processor
+	cfg     SentenceEmbedderConfig
+	envInit bool
+}
+
+// NewSentenceEmbedder loads the tokenizer and model.
+func NewSentenceEmbedder(cfg SentenceEmbedderConfig) (*SentenceEmbedder, error) {
+	if cfg.Dim <= 0 || cfg.SeqLen < 2 {
+		return nil, errors.New("onnxtag: Dim must be > 0 and SeqLen >= 2")
+	}
+	if cfg.InputName == "" || cfg.MaskName == "" || cfg.OutputName == "" {
+		return nil, errors.New("onnxtag: input, mask and output names must be provided")
+	}
+	proc, err := sentencepiece.NewProcessorFromPath(cfg.TokenizerPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.ORTLib != "" {
+		ort.SetSharedLibraryPath(cfg.ORTLib)
+	} else if p := os.Getenv("ONNXRUNTIME_SHARED_LIBRARY_PATH"); p != "" {
+		ort.SetSharedLibraryPath(p)
+	}
+	if err := ort.InitializeEnvironment(); err != nil {
+		return nil, err
+	}
+	so, err := newSessionOptionsFor(cfg.Provider, cfg.Threads, cfg.Device)
+	if err != nil {
+		ort.DestroyEnvironment()
+		return nil, err
+	}
+	defer so.Destroy()
+
+	session, err := ort.NewDynamicAdvancedSession(
+		cfg.ModelPath,
+		[]string{cfg.InputName, cfg.MaskName},
+		[]string{cfg.OutputName},
+		so,
+	)
+	if err != nil {
+		ort.DestroyEnvironment()
+		return nil, err
+	}
+	return &SentenceEmbedder{session: session, proc: proc, cfg: cfg, envInit: true}, nil
+}
+
+// Embed encodes one sentence and returns the raw (un-normalized) pooled
+// vector. The caller L2-normalizes.
+func (e *SentenceEmbedder) Embed(text string) ([]float32, error) {
+	ids, mask := BuildSentenceInputIDs(e.proc, text, e.cfg.SeqLen)
+	shape := ort.NewShape(1, int64(len(ids)))
+	idTensor, err := ort.NewTensor(shape, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer idTensor.Destroy()
+	maskTensor, err := ort.NewTensor(shape, mask)
+	if err != nil {
+		return nil, err
+	}
+	defer maskTensor.Destroy()
+
+	outputs := []ort.Value{nil}
+	if err := e.session.Run([]ort.Value{idTensor, maskTensor}, outputs); err != nil {
+		return nil, err
+	}
+	if outputs[0] == nil {
+		return nil, errors.New("onnxtag: model produced no output")
+	}
+	defer outputs[0].Destroy()
+	tensor, ok := outputs[0].(*ort.Tensor[float32])
+	if !ok {
+		return nil, fmt.Errorf("onnxtag: unexpected output type %T (want float32 tensor)", outputs[0])
+	}
+	outShape := tensor.GetShape()
+	data := tensor.GetData()
+	if len(outShape) < 1 || int(outShape[len(outShape)-1]) != e.cfg.Dim {
+		return nil, fmt.Errorf("onnxtag: output shape %v does not end in dim %d", outShape, e.cfg.Dim)
+	}
+	// A [1,dim] output is already pooled; a [1,seq,dim] one is pooled here.
+	if len(outShape) == 2 || e.cfg.Pooling == "cls" {
+		vec := make([]float32, e.cfg.Dim)
+		copy(vec, data[:e.cfg.Dim])
+		return vec, nil
+	}
+	return meanPool(data, mask, e.cfg.Dim), nil
+}
+
+// Close releases the session and the process-global ONNX environment.
+func (e *SentenceEmbedder) Close() error {
+	var err error
+	if e.session != nil {
+		err = e.session.Destroy()
+		e.session = nil
+	}
+	if e.envInit {
+		ort.DestroyEnvironment()
+		e.envInit = false
+	}
+	return err
+}
@@ -0,0 +1,120 @@
+//go:build cgo
+
+package onnxtag
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+// SpeakerEmbedderConfig configures a persistent SpeakerEmbedder. Input and
+// output names are discovered from the model when left empty.
+type SpeakerEmbedderConfig struct {
+	ModelPath  string
+	InputName  string // filterbank features [1, frames, bins], e.g. "feats"
+	OutputName string // speaker embedding [1, dim], e.g. "embs"
+	Provider   EmbedProvider
+	Threads    int
+	Device     int
+	ORTLib     string
+}
+
+// SpeakerEmbedder holds a speaker-verification model session (WeSpeaker,
+// 3D-Speaker and similar exports that map log-mel features to one voice
+// embedding). Not safe for concurrent use; Close() must be called.
+type SpeakerEmbedder struct {
+	session *ort.DynamicAdvancedSession
+	envInit bool
+}
+
+// NewSpeakerEmbedder loads the model.
+func NewSpeakerEmbedder(cfg SpeakerEmbedderConfig) (*SpeakerEmbedder, error) {
+	if cfg.ModelPath == "" {
+		return nil, errors.New("onnxtag: speaker model path must be provided")
+	}
+	if cfg.ORTLib != "" {
+		ort.SetSharedLibraryPath(cfg.ORTLib)
+	} else if p := os.Getenv("ONNXRUNTIME_SHARED_LIBRARY_PATH"); p != "" {
+		ort.SetSharedLibraryPath(p)
+	}
+	if err := ort.InitializeEnvironment(); err != nil {
+		return nil, err
+	}
+	if cfg.InputName == "" || cfg.OutputName == "" {
+		inputs, outputs, err := ort.GetInputOutputInfo(cfg.ModelPath)
+		if err != nil {
+			ort.DestroyEnvironment()
+			return nil, err
+		}
+		if len(inputs) == 0 || len(outputs) == 0 {
+			ort.DestroyEnvironment()
+			return nil, errors.New("onnxtag: speaker model has no inputs or outputs")
+		}
+		if cfg.InputName == "" {
+			cfg.InputName = inputs[0].Name
+		}
+		if cfg.OutputName == "" {
+			cfg.OutputName = outputs[0].Name
+		}
+	}
+	so, err := newSessionOptionsFor(cfg.Provider, cfg.Threads, cfg.Device)
+	if err != nil {
+		ort.DestroyEnvironment()
+		return nil, err
+	}
+	defer so.Destroy()
+
+	session, err := ort.NewDynamicAdvancedSession(cfg.ModelPath, []string{cfg.InputName}, []string{cfg.OutputName}, so)
+	if err != nil {
+		ort.DestroyEnvironment()
+		return nil, err
+	}
+	return &SpeakerEmbedder{session: session, envInit: true}, nil
+}
+
+// Embed runs the model on frames×bins features (row-major) and returns the
+// raw (un-normalized) embedding. The caller L2-normalizes.
+func (e *SpeakerEmbedder) Embed(feats []float32, frames, bins int) ([]float32, error) {
+	if frames <= 0 || len(feats) != frames*bins {
+		return nil, fmt.Errorf("onnxtag: %d feature values do not fill %d frames of %d bins", len(feats), frames, bins)
+	}
+	in, err := ort.NewTensor(ort.NewShape(1, int64(frames), int64(bins)), feats)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Destroy()
+
+	outputs := []ort.Value{nil}
+	if err := e.session.Run([]ort.Value{in}, outputs); err != nil {
+		return nil, err
+	}
+	if outputs[0] == nil {
+		return nil, errors.New("onnxtag: model produced no output")
+	}
+	defer outputs[0].Destroy()
+	tensor, ok := outputs[0].(*ort.Tensor[float32])
+	if !ok {
+		return nil, fmt.Errorf("onnxtag: unexpected output type %T (want float32 tensor)", outputs[0])
+	}
+	data := tensor.GetData()
+	vec := make([]float32, len(data))
+	copy(vec, data)
+	return vec, nil
+}
+
+// Close releases the session and the process-global ONNX environment.
+func (e *SpeakerEmbedder) Close() error {
+	var err error
+	if e.session != nil {
+		err = e.session.Destroy()
+		e.session = nil
+	}
+	if e.envInit {
+		ort.DestroyEnvironment()
+		e.envInit = false
+	}
+	return err
+}
@@ -25,3 +25,80 @@ func BuildTextInputIDs(proc *sentencepiece.Processor, text string, seqLen int) [
 	}
 	return ids
 }
+
+// XLM-R special token ids. The e5 / multilingual MiniLM family reuse the
+// fairseq vocabulary layout: four specials first, then every SentencePiece id
+// shifted up by one (SentencePiece's own <unk> maps onto the fairseq one).
+const (
+	xlmrBOS    = 0
+	xlmrPad    = 1
+	xlmrEOS    = 2
+	xlmrUnk    = 3
+	xlmrOffset = 1
+)
+
+// BuildSentenceInputIDs tokenizes text for an XLM-R-vocabulary sentence
+// encoder: SentencePiece encode (case preserved) → <s> ids </s> → pad to
+// seqLen. Returns the int64 input ids and the matching attention mask (1 for
+// real tokens, 0 for padding), both of length seqLen.
+func BuildSentenceInputIDs(proc *sentencepiece.Processor, text string, seqLen int) (ids, mask []int64) {
+	toks := proc.Encode(text)
+	pieces := make([]int, len(toks))
+	for i, t := range toks {
+		pieces[i] = t.ID
+	}
+	return xlmrInputIDs(pieces, seqLen)
+}
+
+// xlmrInputIDs maps raw SentencePiece ids into the fairseq vocabulary and
+// frames them with <s>/</s>, truncating so </s> always fits.
+func xlmrInputIDs(pieces []int, seqLen int) (ids, mask []int64) {
+	ids = make([]int64, 0, seqLen)
+	mask = make([]int64, 0, seqLen)
+	if seqLen < 2 {
+		return ids, mask
+	}
+	ids = append(ids, xlmrBOS)
+	for _, p := range pieces {
+		if len(ids) == seqLen-1 {
+			break
+		}
+		if p == 0 {
+			ids = append(ids, xlmrUnk)
+		} else {
+			ids = append(ids, int64(p+xlmrOffset))
+		}
+	}
+	ids = append(ids, xlmrEOS)
+	for range ids {
+		mask = append(mask, 1)
+	}
+	for len(ids) < seqLen {
+		ids = append(ids, xlmrPad)
+		mask = append(mask, 0)
+	}
+	return ids, mask
+}
+
+// meanPool averages the token rows of a [seqLen, dim] hidden-state matrix
+// whose mask entry is 1 — sentence-transformers' mean pooling.
+func meanPool(hidden []float32, mask []int64, dim int) []float32 {
+	out := make([]float32, dim)
+	var n float32
+	for t, m := range mask {
+		if m == 0 || (t+1)*dim > len(hidden) {
+			continue
+		}
+		row := hidden[t*dim : (t+1)*dim]
+		for i, v := range row {
+			out[i] += v
+		}
+		n++
+	}
+	if n > 0 {
+		for i := range out {
+			out[i] /= n
+		}
+	}
+	return out
+}
@@ -53,3 +53,39 @@ func TestBuildTextInputIDsEOSOnMaxLength(t *testing.T) {
 		t.Errorf("ids[seqLen-1]=%d want EOS=%d (full=%v)", ids[seqLen-1], info.EndOfSentenceID, ids)
 	}
 }
+
+func TestXLMRInputIDs(t *testing.T) {
+	// SentencePiece ids 5 and 0 (<unk>) become fairseq 6 and 3, framed by
+	// <s>=0 ... </s>=2, then padded with 1.
+	ids, mask := xlmrInputIDs([]int{5, 0}, 6)
+	wantIDs := []int64{0, 6, 3, 2, 1, 1}
+	wantMask := []int64{1, 1, 1, 1, 0, 0}
+	for i := range wantIDs {
+		if ids[i] != wantIDs[i] || mask[i] != wantMask[i] {
+			t.Fatalf("ids=%v mask=%v, want %v / %v", ids, mask, wantIDs, wantMask)
+		}
+	}
+
+	// Overflow truncates the pieces so </s> still occupies the last slot.
+	ids, mask = xlmrInputIDs([]int{7, 8, 9, 10}, 4)
+	if len(ids) != 4 || ids[3] != xlmrEOS || ids[2] != 9 {
+		t.Errorf("truncated ids=%v", ids)
+	}
+	for _, m := range mask {
+		if m != 1 {
+			t.Errorf("truncated mask=%v, want all 1", mask)
+		}
+	}
+}
+
+func TestMeanPoolIgnoresPadding(t *testing.T) {
+	hidden := []float32{
+		1, 2,
+		3, 4,
+		100, 100, // padding row
+	}
+	got := meanPool(hidden, []int64{1, 1, 0}, 2)
+	if got[0] != 2 || got[1] != 3 {
+		t.Errorf("meanPool = %v, want [2 3]", got)
+	}
+}
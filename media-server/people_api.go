@@ -25,7 +25,7 @@ func broadcastPeopleChanged() {
 	if err != nil {
 		return
 	}
-	stream.Broadcast(stream.Message{Type: "people-updated", Msg: string(payload)})
+	stream.Broadcast(stream.Message{Type: "people-updated", Msg: string(payload), Topics: []string{stream.TopicPeople}})
 }
 
 // RegisterPeopleRoutes wires the person-management API onto mux (called from
@@ -36,8 +36,10 @@ func broadcastPeopleChanged() {
 //	POST   /api/people/{id}/rename   — {name}; cascades to taxonomy rows
 //	POST   /api/people/{id}/merge    — {intoId}; moves faces + taxonomy rows
 //	DELETE /api/people/{id}          — unassigns faces, removes taxonomy rows
-//	GET    /api/people/{id}/media    — the person's media, renderer item shape
+//	GET    /api/people/{id}/media    — the person's media, renderer item shape (+ video timeRanges)
 //	GET    /api/people/{id}/faces    — the person's faces with typicality, for review
+//	GET    /api/people/{id}/cooccurrence — who the person appears with (counts + examples)
+//	GET    /api/people/graph         — co-occurrence network, ?format=json|graphml
 //	POST   /api/people/{id}/lock     — promote every auto face to a user assignment
 //	POST   /api/people/lock-all      — same, for every NAMED person at once
 //	POST   /api/people/{id}/curate   — {keepFaceIds}: lock the keeps, reject the rest
@@ -48,30 +50,33 @@ func broadcastPeopleChanged() {
 //	DELETE /api/faces/all?confirm=true — privacy wipe of ALL face data
 func RegisterPeopleRoutes(mux *http.ServeMux, deps *Dependencies) {
 	// GET (list) is public-readable so people browsing works in view-only
-	// mode; POST (create) stays admin-only while public access is on.
+	// mode; POST (create) is curation. Naming, assigning, and covers are
+	// curator routes; deletes, lock-all, the wipe, and tuning stay admin.
 	mux.HandleFunc("/api/people", renderer.ApplyMiddlewares(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodGet {
 			peopleHandler(deps)(w, r)
 			return
 		}
-		requireAuthWhenPublic(deps, peopleHandler(deps))(w, r)
+		requireCurator(deps, peopleHandler(deps))(w, r)
 	}, renderer.RolePublicRead))
-	mux.HandleFunc("/api/people/{id}/rename", renderer.ApplyMiddlewares(personRenameHandler(deps), renderer.RoleAdmin))
-	mux.HandleFunc("/api/people/{id}/merge", renderer.ApplyMiddlewares(personMergeHandler(deps), renderer.RoleAdmin))
+	mux.HandleFunc("/api/people/{id}/rename", renderer.ApplyMiddlewares(personRenameHandler(deps), renderer.RoleCurator))
+	mux.HandleFunc("/api/people/{id}/merge", renderer.ApplyMiddlewares(personMergeHandler(deps), renderer.RoleCurator))
 	mux.HandleFunc("/api/people/{id}", renderer.ApplyMiddlewares(personDeleteHandler(deps), renderer.RoleAdmin))
 	mux.HandleFunc("/api/people/{id}/media", renderer.ApplyMiddlewares(personMediaHandler(deps), renderer.RolePublicRead))
 	mux.HandleFunc("/api/people/{id}/faces", renderer.ApplyMiddlewares(personFacesHandler(deps), renderer.RolePublicRead))
 	// Literal path — Go's mux prefers it over the "/api/people/{id}"
 	// wildcard, so "lock-all" never lands in the delete handler.
 	mux.HandleFunc("/api/people/lock-all", renderer.ApplyMiddlewares(peopleLockAllHandler(deps), renderer.RoleAdmin))
-	mux.HandleFunc("/api/people/{id}/lock", renderer.ApplyMiddlewares(personLockHandler(deps), renderer.RoleAdmin))
-	mux.HandleFunc("/api/people/{id}/curate", renderer.ApplyMiddlewares(personCurateHandler(deps), renderer.RoleAdmin))
-	mux.HandleFunc("/api/people/{id}/cover", renderer.ApplyMiddlewares(personCoverHandler(deps), renderer.RoleAdmin))
-	mux.HandleFunc("/api/media/assign-person", renderer.ApplyMiddlewares(mediaAssignPersonHandler(deps), renderer.RoleAdmin))
-	mux.HandleFunc("/api/media/reject-person", renderer.ApplyMiddlewares(mediaRejectPersonHandler(deps), renderer.RoleAdmin))
-	mux.HandleFunc("/api/faces/{id}/assign", renderer.ApplyMiddlewares(faceAssignHandler(deps), renderer.RoleAdmin))
-	mux.HandleFunc("/api/faces/{id}/unassign", renderer.ApplyMiddlewares(faceUnassignHandler(deps), renderer.RoleAdmin))
-	mux.HandleFunc("/api/faces/{id}/reject", renderer.ApplyMiddlewares(faceRejectHandler(deps), renderer.RoleAdmin))
+	mux.HandleFunc("/api/people/graph", renderer.ApplyMiddlewares(peopleGraphHandler(deps), renderer.RolePublicRead))
+	mux.HandleFunc("/api/people/{id}/cooccurrence", renderer.ApplyMiddlewares(personCooccurrenceHandler(deps), renderer.RolePublicRead))
+	mux.HandleFunc("/api/people/{id}/lock", renderer.ApplyMiddlewares(personLockHandler(deps), renderer.RoleCurator))
+	mux.HandleFunc("/api/people/{id}/curate", renderer.ApplyMiddlewares(personCurateHandler(deps), renderer.RoleCurator))
+	mux.HandleFunc("/api/people/{id}/cover", renderer.ApplyMiddlewares(personCoverHandler(deps), renderer.RoleCurator))
+	mux.HandleFunc("/api/media/assign-person", renderer.ApplyMiddlewares(mediaAssignPersonHandler(deps), renderer.RoleCurator))
+	mux.HandleFunc("/api/media/reject-person", renderer.ApplyMiddlewares(mediaRejectPersonHandler(deps), renderer.RoleCurator))
+	mux.HandleFunc("/api/faces/{id}/assign", renderer.ApplyMiddlewares(faceAssignHandler(deps), renderer.RoleCurator))
+	mux.HandleFunc("/api/faces/{id}/unassign", renderer.ApplyMiddlewares(faceUnassignHandler(deps), renderer.RoleCurator))
+	mux.HandleFunc("/api/faces/{id}/reject", renderer.ApplyMiddlewares(faceRejectHandler(deps), renderer.RoleCurator))
 	mux.HandleFunc("/api/faces/all", renderer.ApplyMiddlewares(facesWipeHandler(deps), renderer.RoleAdmin))
 	mux.HandleFunc("/api/faces/stats", renderer.ApplyMiddlewares(facesStatsHandler(deps), renderer.RolePublicRead))
 	// GET-only read; the count powers the "Not grouped yet" browse card,
@@ -127,6 +132,7 @@ func facesTuningHandler(deps *Dependencies) http.HandlerFunc {
 				httpError(w, "minQuality must be between 0 and 1", http.StatusBadRequest)
 				return
 			}
+			before := current()
 			cfg := appconfig.Get()
 			cfg.FaceClusterThresholdOffset = req.ThresholdOffset
 			cfg.FaceClusterMinCluster = req.MinCluster
@@ -135,6 +141,7 @@ func facesTuningHandler(deps *Dependencies) http.HandlerFunc {
 				httpError(w, err.Error(), http.StatusInternalServerError)
 				return
 			}
+			auditLog(deps, r, "config.faces", []string{"config"}, before, current())
 			writeJSON(w, current())
 		default:
 			httpError(w, "use GET or POST", http.StatusMethodNotAllowed)
@@ -286,6 +293,7 @@ func peopleHandler(deps *Dependencies) http.HandlerFunc {
 				httpError(w, err.Error(), userErrorStatus(err))
 				return
 			}
+			auditLog(deps, r, "person.create", []string{personTarget(id)}, nil, map[string]string{"name": strings.TrimSpace(req.Name)})
 			broadcastPeopleChanged()
 			writeJSON(w, map[string]any{"id": id, "name": strings.TrimSpace(req.Name)})
 		default:
@@ -312,6 +320,7 @@ func personRenameHandler(deps *Dependencies) http.HandlerFunc {
 			httpError(w, "bad request", http.StatusBadRequest)
 			return
 		}
+		before := auditPersonName(deps, id)
 		if err := media.RenamePerson(deps.DB, id, req.Name); err != nil {
 			httpError(w, err.Error(), userErrorStatus(err))
 			return
@@ -323,6 +332,8 @@ func personRenameHandler(deps *Dependencies) http.HandlerFunc {
 		if p, found, err := media.GetPersonByID(deps.DB, id); err == nil && found {
 			name = p.Name
 		}
+		auditLog(deps, r, "person.rename", []string{personTarget(id)},
+			map[string]string{"name": before}, map[string]string{"name": name})
 		broadcastPeopleChanged()
 		writeJSON(w, map[string]any{"id": id, "name": name})
 	}
@@ -346,10 +357,13 @@ func personMergeHandler(deps *Dependencies) http.HandlerFunc {
 			httpError(w, "intoId required", http.StatusBadRequest)
 			return
 		}
+		from, into := auditPersonName(deps, id), auditPersonName(deps, req.IntoID)
 		if err := media.MergePersons(deps.DB, id, req.IntoID); err != nil {
 			httpError(w, err.Error(), userErrorStatus(err))
 			return
 		}
+		auditLog(deps, r, "person.merge", []string{personTarget(id), personTarget(req.IntoID)},
+			map[string]string{"from": from, "into": into}, map[string]int64{"into": req.IntoID})
 		broadcastPeopleChanged()
 		writeJSON(w, map[string]any{"merged": id, "into": req.IntoID})
 	}
@@ -373,12 +387,15 @@ func personDeleteHandler(deps *Dependencies) http.HandlerFunc {
 			httpError(w, "invalid person id", http.StatusBadRequest)
 			return
 		}
+		name := auditPersonName(deps, id)
 		if r.URL.Query().Get("deleteFaces") == "true" {
 			faceIDs, err := media.DeletePersonAndFaces(deps.DB, id)
 			if err != nil {
 				httpError(w, err.Error(), userErrorStatus(err))
 				return
 			}
+			auditLog(deps, r, "person.delete", []string{personTarget(id)},
+				map[string]any{"name": name, "facesDeleted": len(faceIDs)}, nil)
 			tasks.FaceIndexDeleteFaceIDs(faceIDs)
 			broadcastPeopleChanged()
 			writeJSON(w, map[string]any{"deleted": id, "facesDeleted": len(faceIDs)})
@@ -404,6 +421,8 @@ func personDeleteHandler(deps *Dependencies) http.HandlerFunc {
 			httpError(w, err.Error(), userErrorStatus(err))
 			return
 		}
+		auditLog(deps, r, "person.delete", []string{personTarget(id)},
+			map[string]any{"name": name, "banned": banned}, nil)
 		broadcastPeopleChanged()
 		writeJSON(w, map[string]any{"deleted": id, "banned": banned})
 	}
@@ -442,6 +461,18 @@ func personMediaHandler(deps *Dependencies) http.HandlerFunc {
 			httpError(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		// Videos scanned frame-by-frame list where the person appears.
+		spans, err := media.PersonMediaSpans(deps.DB, id)
+		if err != nil {
+			httpError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for _, item := range items {
+			if ss := spans[item["path"].(string)]; len(ss) > 0 {
+				item["timeRanges"] = ss
+				item["timeStamp"] = ss[0].Best
+			}
+		}
 		writeJSON(w, items)
 	}
 }
@@ -449,7 +480,7 @@ func personMediaHandler(deps *Dependencies) http.HandlerFunc {
 // personCoverHandler regenerates a person's cover crop: it walks the person's
 // faces best-first (detection confidence × bbox area) and picks the first one
 // whose source actually renders — decoding images directly and re-extracting
-// the scan's midpoint frame for videos — so a person with usable faces always
+// the scanned frame for videos — so a person with usable faces always
 // ends up with a visible preview. POST /api/people/{id}/cover.
 func personCoverHandler(deps *Dependencies) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -486,13 +517,14 @@ func personCoverHandler(deps *Dependencies) http.HandlerFunc {
 			if i >= maxCandidates {
 				break
 			}
-			if _, err := decodeFaceSource(r.Context(), deps.DB, f.MediaPath); err != nil {
+			if _, err := decodeFaceSource(r.Context(), deps.DB, f); err != nil {
 				continue
 			}
 			if err := media.SetPersonCover(deps.DB, id, f.ID); err != nil {
 				httpError(w, err.Error(), http.StatusInternalServerError)
 				return
 			}
+			auditLog(deps, r, "person.cover", []string{personTarget(id)}, nil, map[string]int64{"coverFaceId": f.ID})
 			broadcastPeopleChanged()
 			writeJSON(w, map[string]any{"personId": id, "coverFaceId": f.ID})
 			return
@@ -593,6 +625,8 @@ func mediaAssignPersonHandler(deps *Dependencies) http.HandlerFunc {
 				return
 			}
 		}
+		auditLog(deps, r, "person.assign", []string{req.Path, personTarget(req.PersonID)}, nil,
+			map[string]any{"faceId": best.ID, "created": req.NewPerson, "setCover": req.SetCover})
 		broadcastPeopleChanged()
 		writeJSON(w, map[string]any{
 			"faceId":   best.ID,
@@ -647,6 +681,8 @@ func mediaRejectPersonHandler(deps *Dependencies) http.HandlerFunc {
 			return
 		}
 		if len(ids) > 0 {
+			auditLog(deps, r, "person.reject", []string{req.Path, personTarget(personID)}, nil,
+				map[string]any{"rejectedFaceIds": ids})
 			broadcastPeopleChanged()
 		}
 		writeJSON(w, map[string]any{"personId": personID, "rejectedFaceIds": ids})
@@ -709,6 +745,7 @@ func faceAssignHandler(deps *Dependencies) http.HandlerFunc {
 			}
 			personName = name
 		}
+		before := auditFacePerson(deps, faceID)
 		if err := media.AssignFace(deps.DB, faceID, personID, "user"); err != nil {
 			httpError(w, err.Error(), userErrorStatus(err))
 			return
@@ -720,6 +757,8 @@ func faceAssignHandler(deps *Dependencies) http.HandlerFunc {
 				return
 			}
 		}
+		auditLog(deps, r, "face.assign", []string{faceTarget(faceID), personTarget(personID)},
+			map[string]int64{"personId": before}, map[string]any{"personId": personID, "created": created})
 		broadcastPeopleChanged()
 		writeJSON(w, map[string]any{"faceId": faceID, "personId": personID, "created": created, "name": personName})
 	}
@@ -771,6 +810,8 @@ func faceRejectHandler(deps *Dependencies) http.HandlerFunc {
 			httpError(w, err.Error(), userErrorStatus(err))
 			return
 		}
+		auditLog(deps, r, "face.reject", []string{faceTarget(faceID), personTarget(personID)},
+			map[string]int64{"personId": personID}, map[string]int{"cannotLinks": links})
 		broadcastPeopleChanged()
 		writeJSON(w, map[string]any{
 			"faceId":      faceID,
@@ -800,6 +841,7 @@ func personLockHandler(deps *Dependencies) http.HandlerFunc {
 			httpError(w, err.Error(), userErrorStatus(err))
 			return
 		}
+		auditLog(deps, r, "person.lock", []string{personTarget(id)}, nil, map[string]any{"locked": n})
 		broadcastPeopleChanged()
 		writeJSON(w, map[string]any{"personId": id, "locked": n})
 	}
@@ -822,6 +864,7 @@ func peopleLockAllHandler(deps *Dependencies) http.HandlerFunc {
 			return
 		}
 		if faces > 0 {
+			auditLog(deps, r, "person.lock-all", nil, nil, map[string]any{"people": people, "locked": faces})
 			broadcastPeopleChanged()
 		}
 		writeJSON(w, map[string]any{"people": people, "locked": faces})
@@ -857,6 +900,7 @@ func personCurateHandler(deps *Dependencies) http.HandlerFunc {
 			httpError(w, err.Error(), userErrorStatus(err))
 			return
 		}
+		auditLog(deps, r, "person.curate", []string{personTarget(id)}, nil, map[string]any{"kept": kept, "rejected": rejected})
 		broadcastPeopleChanged()
 		writeJSON(w, map[string]any{"personId": id, "kept": kept, "rejected": rejected})
 	}
@@ -1003,10 +1047,12 @@ func faceUnassignHandler(deps *Dependencies) http.HandlerFunc {
 			httpError(w, "invalid face id", http.StatusBadRequest)
 			return
 		}
+		before := auditFacePerson(deps, faceID)
 		if err := media.UnassignFace(deps.DB, faceID); err != nil {
 			httpError(w, err.Error(), userErrorStatus(err))
 			return
 		}
+		auditLog(deps, r, "face.unassign", []string{faceTarget(faceID)}, map[string]int64{"personId": before}, nil)
 		broadcastPeopleChanged()
 		writeJSON(w, map[string]any{"faceId": faceID, "unassigned": true})
 	}
@@ -1026,10 +1072,14 @@ func facesWipeHandler(deps *Dependencies) http.HandlerFunc {
 			httpError(w, "add ?confirm=true to delete all face data", http.StatusBadRequest)
 			return
 		}
+		var faces, people int64
+		deps.DB.QueryRow(`SELECT COUNT(*) FROM face`).Scan(&faces)
+		deps.DB.QueryRow(`SELECT COUNT(*) FROM person`).Scan(&people)
 		if err := media.DeleteAllFaceData(deps.DB); err != nil {
 			httpError(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		auditLog(deps, r, "face.wipe", nil, map[string]int64{"faces": faces, "people": people}, nil)
 		tasks.SetFaceIndexForModel(nil, "", nil)
 		broadcastPeopleChanged()
 		writeJSON(w, map[string]any{"deleted": true})
@@ -195,6 +195,46 @@ func TestPersonMediaEndpoint(t *testing.T) {
 	}
 }
 
+func TestPersonMediaEndpointTimeRanges(t *testing.T) {
+	mux, deps := muxWithPeopleRoutes(t)
+	pid, _ := media.CreatePerson(deps.DB, "Alice")
+	if _, err := deps.DB.Exec(`INSERT INTO media (path) VALUES ('v.mp4')`); err != nil {
+		t.Fatal(err)
+	}
+	ids, _ := media.ReplaceFaces(deps.DB, "v.mp4", "m1", []media.NewFace{
+		{Score: 0.9, Vec: []float32{1}, FrameTS: 14, Tracked: true, TrackStart: 10, TrackEnd: 20},
+		{Score: 0.8, Vec: []float32{1}, FrameTS: 62, Tracked: true, TrackStart: 60, TrackEnd: 64},
+	}, 1)
+	for _, id := range ids {
+		_ = media.AssignFace(deps.DB, id, pid, "user")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/people/"+jsonNum(pid)+"/media", nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("media: %d %s", rec.Code, rec.Body.String())
+	}
+	var items []struct {
+		Path       string           `json:"path"`
+		TimeStamp  float64          `json:"timeStamp"`
+		TimeRanges []media.FaceSpan `json:"timeRanges"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &items); err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Path != "v.mp4" {
+		t.Fatalf("items = %+v", items)
+	}
+	got := items[0]
+	if len(got.TimeRanges) != 2 || got.TimeRanges[0].Start != 10 || got.TimeRanges[1].End != 64 {
+		t.Fatalf("timeRanges = %+v", got.TimeRanges)
+	}
+	if got.TimeStamp != 14 {
+		t.Fatalf("timeStamp = %v, want the first track's best frame (14)", got.TimeStamp)
+	}
+}
+
 func TestPersonCoverRegenerateEndpoint(t *testing.T) {
 	mux, deps := muxWithPeopleRoutes(t)
 	pid, _ := media.CreatePerson(deps.DB, "Alice")
@@ -938,3 +978,78 @@ func TestRejectPersonFacesOnTagRemoval(t *testing.T) {
 		t.Fatal("tag removal recorded no veto")
 	}
 }
+
+func TestPersonCooccurrenceAndGraphEndpoints(t *testing.T) {
+	mux, deps := muxWithPeopleRoutes(t)
+	alice, _ := media.CreatePerson(deps.DB, "Alice")
+	bob, _ := media.CreatePerson(deps.DB, "Bob & Co")
+	for _, p := range []string{"a.jpg", "b.jpg"} {
+		if _, err := deps.DB.Exec(`INSERT INTO media (path) VALUES (?)`, p); err != nil {
+			t.Fatal(err)
+		}
+		ids, _ := media.ReplaceFaces(deps.DB, p, "m1", []media.NewFace{
+			{Score: 0.9, Vec: []float32{1}}, {Score: 0.8, Vec: []float32{1}},
+		}, 1)
+		_ = media.AssignFace(deps.DB, ids[0], alice, "user")
+		_ = media.AssignFace(deps.DB, ids[1], bob, "user")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/people/"+jsonNum(alice)+"/cooccurrence?examples=1", nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("cooccurrence: %d %s", rec.Code, rec.Body.String())
+	}
+	var co struct {
+		People []media.CoPerson `json:"people"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &co); err != nil {
+		t.Fatal(err)
+	}
+	if len(co.People) != 1 || co.People[0].PersonID != bob || co.People[0].Count != 2 || len(co.People[0].Examples) != 1 {
+		t.Fatalf("cooccurrence = %+v", co.People)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/people/999/cooccurrence", nil)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown person: %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/people/graph", nil)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	var g media.CooccurrenceGraph
+	if err := json.Unmarshal(rec.Body.Bytes(), &g); err != nil {
+		t.Fatalf("graph json: %v (%s)", err, rec.Body.String())
+	}
+	if len(g.Nodes) != 2 || len(g.Edges) != 1 || g.Edges[0].Weight != 2 {
+		t.Fatalf("graph = %+v", g)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/people/graph?format=graphml", nil)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Header().Get("Content-Type"), "graphml") {
+		t.Fatalf("graphml: %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	for _, want := range []string{
+		`<graph id="people" edgedefault="undirected">`,
+		`<data key="name">Bob &amp; Co</data>`,
+		`<edge source="p` + jsonNum(alice) + `" target="p` + jsonNum(bob) + `">`,
+		`<data key="weight">2</data>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("graphml missing %q:\n%s", want, body)
+		}
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/people/graph?format=dot", nil)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("bad format: %d", rec.Code)
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/stevecastle/shrike/media"
+)
+
+// Relationship views over the people co-occurrence table (see
+// media/cooccur.go): who a person is most often seen with, and the whole
+// network as JSON or GraphML for Gephi, Cytoscape, yEd, and friends.
+
+// personCooccurrenceHandler lists the people most often seen with a person:
+// shared media count and a few example media paths each.
+// GET /api/people/{id}/cooccurrence?limit=20&examples=4.
+func personCooccurrenceHandler(deps *Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "use GET", http.StatusMethodNotAllowed)
+			return
+		}
+		id, ok := pathID(r)
+		if !ok {
+			httpError(w, "invalid person id", http.StatusBadRequest)
+			return
+		}
+		limit, ok := queryIntParam(w, r, "limit", 20, 200)
+		if !ok {
+			return
+		}
+		examples, ok := queryIntParam(w, r, "examples", 4, 50)
+		if !ok {
+			return
+		}
+		if _, found, err := media.GetPersonByID(deps.DB, id); err != nil {
+			httpError(w, err.Error(), http.StatusInternalServerError)
+			return
+		} else if !found {
+			httpError(w, "no such person", http.StatusNotFound)
+			return
+		}
+		people, err := media.PersonCooccurrence(deps.DB, id, limit, examples)
+		if err != nil {
+			httpError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if people == nil {
+			people = []media.CoPerson{}
+		}
+		writeJSON(w, map[string]any{"personId": id, "people": people})
+	}
+}
+
+// peopleGraphHandler exports the co-occurrence network: people as nodes
+// (with their media counts), shared media counts as weighted edges.
+// GET /api/people/graph?format=json|graphml&min=1&named=true. named (the
+// default) leaves unnamed auto-clusters out; min drops edges seen fewer times.
+func peopleGraphHandler(deps *Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "use GET", http.StatusMethodNotAllowed)
+			return
+		}
+		q := r.URL.Query()
+		format := q.Get("format")
+		if format == "" {
+			format = "json"
+		}
+		if format != "json" && format != "graphml" {
+			httpError(w, "format must be json or graphml", http.StatusBadRequest)
+			return
+		}
+		minWeight, ok := queryIntParam(w, r, "min", 1, 0)
+		if !ok {
+			return
+		}
+		named := true
+		if v := q.Get("named"); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				httpError(w, "invalid named", http.StatusBadRequest)
+				return
+			}
+			named = b
+		}
+		g, err := media.GetCooccurrenceGraph(deps.DB, minWeight, named)
+		if err != nil {
+			httpError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if format == "json" {
+			writeJSON(w, g)
+			return
+		}
+		w.Header().Set("Content-Type", "application/graphml+xml; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="people.graphml"`)
+		_ = writeGraphML(w, g)
+	}
+}
+
+// queryIntParam reads a positive integer query parameter, falling back to def
+// when absent and clamping to max (0 = no cap). An invalid value answers 400
+// and reports false.
+func queryIntParam(w http.ResponseWriter, r *http.Request, name string, def, max int) (int, bool) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		httpError(w, "invalid "+name, http.StatusBadRequest)
+		return 0, false
+	}
+	if max > 0 && n > max {
+		n = max
+	}
+	return n, true
+}
+
+// GraphML document shapes: one undirected graph, node names and media counts
+// and edge weights declared as typed keys so graph tools pick them up.
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLDoc struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   struct {
+		ID          string        `xml:"id,attr"`
+		EdgeDefault string        `xml:"edgedefault,attr"`
+		Nodes       []graphMLNode `xml:"node"`
+		Edges       []graphMLEdge `xml:"edge"`
+	} `xml:"graph"`
+}
+
+// writeGraphML encodes g as a GraphML document.
+func writeGraphML(out io.Writer, g media.CooccurrenceGraph) error {
+	doc := graphMLDoc{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "name", For: "node", AttrName: "name", AttrType: "string"},
+			{ID: "media", For: "node", AttrName: "mediaCount", AttrType: "int"},
+			{ID: "weight", For: "edge", AttrName: "weight", AttrType: "int"},
+		},
+	}
+	doc.Graph.ID = "people"
+	doc.Graph.EdgeDefault = "undirected"
+	nodeID := func(id int64) string { return fmt.Sprintf("p%d", id) }
+	for _, n := range g.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{
+			ID: nodeID(n.ID),
+			Data: []graphMLData{
+				{Key: "name", Value: n.Name},
+				{Key: "media", Value: strconv.Itoa(n.MediaCount)},
+			},
+		})
+	}
+	for _, e := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+			Source: nodeID(e.Source),
+			Target: nodeID(e.Target),
+			Data:   []graphMLData{{Key: "weight", Value: strconv.Itoa(e.Weight)}},
+		})
+	}
+	if _, err := io.WriteString(out, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(out)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
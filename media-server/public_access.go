@@ -5,33 +5,131 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/stevecastle/shrike/appconfig"
 	"github.com/stevecastle/shrike/auth"
+	"github.com/stevecastle/shrike/renderer"
+	"github.com/stevecastle/shrike/storage"
 )
 
-// isAdminRequest reports whether r carries valid admin credentials — a
-// header credential (Authorization Bearer JWT / lk_ API key / X-API-Key,
-// see requestAuthToken and verifyCredential) or the auth_token cookie.
-// Mirrors authMiddleware's checks, including the setup-required
-// default-admin lockout, but never writes a response.
-func isAdminRequest(deps *Dependencies, r *http.Request) bool {
+// requestAccess resolves the account behind r — a header credential
+// (Authorization Bearer JWT / lk_ API key / X-API-Key, see requestAuthToken
+// and verifyCredential) or the auth_token cookie — and looks up its role and
+// root allow-list. ok is false for anonymous requests, bad credentials, a
+// deleted account, and the setup-locked default admin. Mirrors
+// authMiddleware's checks but never writes a response.
+func requestAccess(deps *Dependencies, r *http.Request) (access auth.Access, ok bool) {
+	var claims *auth.Claims
 	if tok := requestAuthToken(r); tok != "" {
-		if claims, err := verifyCredential(deps, tok); err == nil {
-			return !isSetupLockedAdmin(deps, claims)
+		claims, _ = verifyCredential(deps, r, tok)
+	}
+	if claims == nil {
+		cookie, err := r.Cookie("auth_token")
+		if err != nil {
+			return access, false
+		}
+		if claims, err = deps.Auth.VerifyToken(cookie.Value); err != nil {
+			return access, false
 		}
 	}
-	cookie, err := r.Cookie("auth_token")
-	if err != nil {
-		return false
+	if isSetupLockedAdmin(deps, claims) {
+		return access, false
 	}
-	claims, err := deps.Auth.VerifyToken(cookie.Value)
+	access, err := deps.Auth.UserAccess(claims.Username)
 	if err != nil {
+		return access, false
+	}
+	// A scoped API key acts with at most the role its scopes imply.
+	if capped := auth.ScopeRole(claims.Scopes); !capped.AtLeast(access.Role) {
+		access.Role = capped
+	}
+	return access, true
+}
+
+// requestPermits reports whether r is authenticated with a role that
+// reaches routes registered with required (see renderer.Permits).
+func requestPermits(deps *Dependencies, r *http.Request, required renderer.AuthRole) bool {
+	access, ok := requestAccess(deps, r)
+	return ok && renderer.Permits(access.Role, required)
+}
+
+// isAdminRequest reports whether r carries valid credentials for an
+// admin account.
+func isAdminRequest(deps *Dependencies, r *http.Request) bool {
+	return requestPermits(deps, r, renderer.RoleAdmin)
+}
+
+// denyByRole answers 403 and returns true when username's role doesn't
+// reach required. authMiddleware calls it once credentials check out.
+func denyByRole(deps *Dependencies, w http.ResponseWriter, r *http.Request, username string, required renderer.AuthRole) bool {
+	access, err := deps.Auth.UserAccess(username)
+	if err == nil && renderer.Permits(access.Role, required) {
 		return false
 	}
-	return !isSetupLockedAdmin(deps, claims)
+	if r.Header.Get("Accept") == "application/json" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"error":"forbidden","message":"Your account's role does not allow this"}`))
+	} else {
+		http.Error(w, "Forbidden: your account's role does not allow this", http.StatusForbidden)
+	}
+	return true
+}
+
+// rootPermitted reports whether access may reach paths owned by b: admins
+// and unconfined users reach every root, others only the roots they are
+// listed for (matched by root path or name).
+func rootPermitted(access auth.Access, b storage.Backend) bool {
+	if access.Role == auth.RoleAdmin || len(access.Roots) == 0 {
+		return true
+	}
+	root := b.Root()
+	for _, allowed := range access.Roots {
+		if allowed == root.Path || allowed == root.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// rootScopeSQL is the SQL counterpart of rootPermitted for listings: a
+// condition confining column to the storage roots r's account may reach,
+// with its parameters. It is "" for admins, unconfined accounts, and
+// anonymous visitors, who see every row today. Matching is by path prefix,
+// segment-aligned as the backends' Contains is.
+func rootScopeSQL(deps *Dependencies, r *http.Request, column string) (string, []any) {
+	access, authed := requestAccess(deps, r)
+	if !authed || access.Role == auth.RoleAdmin || len(access.Roots) == 0 {
+		return "", nil
+	}
+	var conds []string
+	var args []any
+	if deps.Storage != nil {
+		for _, b := range deps.Storage.AllBackends() {
+			if !rootPermitted(access, b) {
+				continue
+			}
+			expr, sep, base := column, string(filepath.Separator), b.Root().Path
+			if b.Root().Type == "s3" {
+				sep = "/"
+			} else if runtime.GOOS == "windows" {
+				expr, base = "lower("+column+")", strings.ToLower(base)
+			}
+			base = strings.TrimSuffix(base, sep)
+			// substr counts characters, not bytes.
+			conds = append(conds, fmt.Sprintf("(%[1]s = ? OR substr(%[1]s, 1, ?) = ?)", expr))
+			args = append(args, base, utf8.RuneCountInString(base+sep), base+sep)
+		}
+	}
+	if len(conds) == 0 {
+		return "0", nil
+	}
+	return "(" + strings.Join(conds, " OR ") + ")", args
 }
 
 // isSetupLockedAdmin matches authMiddleware's gate: the bootstrap default
@@ -48,14 +146,47 @@ func isSetupLockedAdmin(deps *Dependencies, claims *auth.Claims) bool {
 // pathAllowedForRequest scopes media-path access for non-admin requesters:
 // s3:// paths must live inside a configured storage root, and LOCAL paths
 // outside every root are admin-only (an anonymous public-access visitor
-// must never read arbitrary files off the server's filesystem). Admins keep
-// today's unrestricted behavior — the Electron viewer serves arbitrary
-// local files through its own server.
+// must never read arbitrary files off the server's filesystem). Accounts
+// with a root allow-list are further confined to those roots, and a share
+// link reaches exactly its own items. Admins keep today's unrestricted
+// behavior — the Electron viewer serves arbitrary local files through its
+// own server.
 func pathAllowedForRequest(deps *Dependencies, r *http.Request, path string) bool {
-	if deps.Storage != nil && deps.Storage.BackendFor(path) != nil {
+	if requestShare(r) != nil {
+		return true // authMiddleware already checked path is one of the share's items
+	}
+	access, authed := requestAccess(deps, r)
+	if authed && access.Role == auth.RoleAdmin {
 		return true
 	}
-	return isAdminRequest(deps, r)
+	if deps.Storage == nil {
+		return false
+	}
+	b := deps.Storage.BackendFor(path)
+	if b == nil {
+		return false
+	}
+	return !authed || rootPermitted(access, b)
+}
+
+// errOutsideRoots answers a write to a path the requester may not reach.
+const errOutsideRoots = "path is outside your storage roots"
+
+// pathsAllowedForRequest is pathAllowedForRequest for every path a curator
+// write touches; one path out of reach refuses the whole write. Like
+// rootScopeSQL it only binds accounts with a root allow-list: curator
+// writes have always covered every library row, and curator routes never
+// reach a handler without an account.
+func pathsAllowedForRequest(deps *Dependencies, r *http.Request, paths ...string) bool {
+	if access, authed := requestAccess(deps, r); !authed || access.Role == auth.RoleAdmin || len(access.Roots) == 0 {
+		return true
+	}
+	for _, p := range paths {
+		if !pathAllowedForRequest(deps, r, p) {
+			return false
+		}
+	}
+	return true
 }
 
 // mediaRowExists reports whether path is a row in the media table — i.e.
@@ -75,16 +206,26 @@ func mediaRowExists(deps *Dependencies, path string) bool {
 // may only touch: paths inside a configured storage root (incl. s3://), or
 // a curated library row — and NEVER an http(s):// URL, which would make the
 // server fetch it (SSRF) or hand it to a subprocess that speaks network
-// protocols (ffprobe/ffmpeg).
+// protocols (ffprobe/ffmpeg). Accounts with a root allow-list only reach
+// paths inside those roots; a share link reaches its own items.
 func mediaReadAllowed(deps *Dependencies, r *http.Request, path string) bool {
-	if isAdminRequest(deps, r) {
+	access, authed := requestAccess(deps, r)
+	if authed && access.Role == auth.RoleAdmin {
 		return true
 	}
 	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
 		return false
 	}
-	if deps.Storage != nil && deps.Storage.BackendFor(path) != nil {
-		return true
+	if requestShare(r) != nil {
+		return true // a share link's item (checked by authMiddleware)
+	}
+	if deps.Storage != nil {
+		if b := deps.Storage.BackendFor(path); b != nil {
+			return !authed || rootPermitted(access, b)
+		}
+	}
+	if authed && len(access.Roots) > 0 {
+		return false
 	}
 	return mediaRowExists(deps, path)
 }
@@ -134,6 +275,12 @@ func isPublicIP(ip net.IP) bool {
 	return true
 }
 
+func writeForbiddenJSON(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	w.Write([]byte(`{"error":"forbidden"}`))
+}
+
 func writeUnauthorizedJSON(w http.ResponseWriter) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusUnauthorized)
@@ -158,3 +305,37 @@ func requireAuthWhenPublic(deps *Dependencies, h http.HandlerFunc) http.HandlerF
 		h(w, r)
 	}
 }
+
+// requireCurator gates the write branches of mixed-method RolePublicRead
+// routes whose writes are curation (saved searches, collections, people):
+// anonymous callers get 401 and viewer accounts 403, whatever the public
+// access flag says. Like requireAuthWhenPublic it answers JSON only, and
+// like ApplyMiddlewares it passes through until main wires
+// renderer.AuthMiddleware.
+func requireCurator(deps *Dependencies, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if renderer.AuthMiddleware == nil {
+			h(w, r)
+			return
+		}
+		access, ok := requestAccess(deps, r)
+		if !ok {
+			writeUnauthorizedJSON(w)
+			return
+		}
+		if !renderer.Permits(access.Role, renderer.RoleCurator) {
+			writeForbiddenJSON(w)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// accountRole is username's role for status responses ("" if unknown).
+func accountRole(deps *Dependencies, username string) auth.Role {
+	access, err := deps.Auth.UserAccess(username)
+	if err != nil {
+		return ""
+	}
+	return access.Role
+}
@@ -1,13 +1,19 @@
 package main
 
 import (
+	"encoding/json"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/stevecastle/shrike/appconfig"
+	"github.com/stevecastle/shrike/auth"
 	"github.com/stevecastle/shrike/renderer"
+	"github.com/stevecastle/shrike/storage"
 )
 
 // setPublicAccess flips the in-memory config flag for the duration of a test.
@@ -211,6 +217,216 @@ func TestMediaReadAllowed(t *testing.T) {
 	}
 }
 
+// roleToken registers username with access and returns a bearer token.
+func roleToken(t *testing.T, deps *Dependencies, username string, access auth.Access) string {
+	t.Helper()
+	if err := deps.Auth.RegisterWithAccess(username, "pw", access); err != nil {
+		t.Fatalf("register %s: %v", username, err)
+	}
+	tok, err := deps.Auth.Login(username, "pw")
+	if err != nil {
+		t.Fatalf("login %s: %v", username, err)
+	}
+	return tok
+}
+
+func TestAuthMiddlewareRoleMatrix(t *testing.T) {
+	deps := newAPIKeyTestDeps(t)
+	setPublicAccess(t, false)
+	tokens := map[auth.Role]string{
+		auth.RoleViewer:  roleToken(t, deps, "vera", auth.Access{Role: auth.RoleViewer}),
+		auth.RoleCurator: roleToken(t, deps, "cora", auth.Access{Role: auth.RoleCurator}),
+		auth.RoleAdmin:   loginToken(t, deps),
+	}
+	want := map[auth.Role]map[renderer.AuthRole]int{
+		auth.RoleViewer:  {renderer.RolePublicRead: 200, renderer.RoleCurator: 403, renderer.RoleAdmin: 403},
+		auth.RoleCurator: {renderer.RolePublicRead: 200, renderer.RoleCurator: 200, renderer.RoleAdmin: 403},
+		auth.RoleAdmin:   {renderer.RolePublicRead: 200, renderer.RoleCurator: 200, renderer.RoleAdmin: 200},
+	}
+	for role, byRoute := range want {
+		for route, code := range byRoute {
+			req := httptest.NewRequest(http.MethodPost, "/x", nil)
+			req.Header.Set("Accept", "application/json")
+			req.Header.Set("Authorization", "Bearer "+tokens[role])
+			rr := httptest.NewRecorder()
+			authMiddleware(deps, ok200(), route).ServeHTTP(rr, req)
+			if rr.Code != code {
+				t.Errorf("%s on route role %d: got %d, want %d", role, route, rr.Code, code)
+			}
+		}
+	}
+}
+
+func TestRequireCurator(t *testing.T) {
+	deps := newAPIKeyTestDeps(t)
+	setPublicAccess(t, false)
+	old := renderer.AuthMiddleware
+	renderer.AuthMiddleware = func(h http.Handler, role renderer.AuthRole) http.Handler { return authMiddleware(deps, h, role) }
+	t.Cleanup(func() { renderer.AuthMiddleware = old })
+	h := requireCurator(deps, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	viewer := roleToken(t, deps, "vera", auth.Access{Role: auth.RoleViewer})
+	curator := roleToken(t, deps, "cora", auth.Access{Role: auth.RoleCurator})
+
+	for _, tc := range []struct {
+		name, token string
+		want        int
+	}{
+		{"anonymous", "", http.StatusUnauthorized},
+		{"viewer", viewer, http.StatusForbidden},
+		{"curator", curator, http.StatusOK},
+		{"admin", loginToken(t, deps), http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/collections", nil)
+		if tc.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		if rr.Code != tc.want {
+			t.Errorf("%s: got %d, want %d", tc.name, rr.Code, tc.want)
+		}
+	}
+}
+
+// TestRootScoping: an account confined to one storage root can't reach
+// paths (or curated rows) outside it; anonymous public visitors and
+// unconfined accounts keep the every-root behavior.
+func TestRootScoping(t *testing.T) {
+	deps := newAPIKeyTestDeps(t)
+	deps.DB.Exec(`CREATE TABLE IF NOT EXISTS media (path TEXT PRIMARY KEY)`)
+	deps.DB.Exec(`INSERT INTO media (path) VALUES ('/curated/local/item.jpg')`)
+	family, work := t.TempDir(), t.TempDir()
+	deps.Storage = storage.NewRegistry([]storage.Backend{
+		storage.NewLocalBackend(family, "Family"),
+		storage.NewLocalBackend(work, "Work"),
+	})
+	inFamily, inWork := family+"/a.jpg", work+"/b.jpg"
+
+	as := func(token string) *http.Request {
+		req := newReqNoAuth()
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		return req
+	}
+	scoped := as(roleToken(t, deps, "kid", auth.Access{Role: auth.RoleViewer, Roots: []string{"Family"}}))
+	open := as(roleToken(t, deps, "pal", auth.Access{Role: auth.RoleViewer}))
+	anon := as("")
+
+	if !pathAllowedForRequest(deps, scoped, inFamily) || !mediaReadAllowed(deps, scoped, inFamily) {
+		t.Error("scoped user should reach their own root")
+	}
+	if pathAllowedForRequest(deps, scoped, inWork) || mediaReadAllowed(deps, scoped, inWork) {
+		t.Error("scoped user must not reach another root")
+	}
+	if mediaReadAllowed(deps, scoped, "/curated/local/item.jpg") {
+		t.Error("scoped user must not reach a library row outside their roots")
+	}
+	for name, req := range map[string]*http.Request{"unconfined": open, "anonymous": anon} {
+		if !pathAllowedForRequest(deps, req, inWork) || !mediaReadAllowed(deps, req, "/curated/local/item.jpg") {
+			t.Errorf("%s requester should keep every-root access", name)
+		}
+	}
+
+	// The root listing only shows the scoped user's roots.
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/fs/list", strings.NewReader(`{"path":""}`))
+	req.Header = scoped.Header
+	fsListHandler(deps)(rr, req)
+	var list fsListResponse
+	json.Unmarshal(rr.Body.Bytes(), &list)
+	if len(list.Entries) != 1 || list.Entries[0].Name != "Family" {
+		t.Errorf("scoped root listing = %+v", list.Entries)
+	}
+}
+
+// newConfinedLibrary is the saved-search library with auth on, plus one
+// item in each of two storage roots ("Family" and "Work") and one under a
+// sibling directory that merely shares Family's prefix.
+func newConfinedLibrary(t *testing.T) (deps *Dependencies, inFamily, inWork, lookalike string) {
+	t.Helper()
+	deps = newSavedSearchDB(t)
+	deps.Auth = auth.NewAuthService(deps.DB, "test-secret")
+	family, work := t.TempDir(), t.TempDir()
+	deps.Storage = storage.NewRegistry([]storage.Backend{
+		storage.NewLocalBackend(family, "Family"),
+		storage.NewLocalBackend(work, "Work"),
+	})
+	inFamily, inWork, lookalike = filepath.Join(family, "a.jpg"), filepath.Join(work, "b.jpg"), family+"-old"+string(filepath.Separator)+"c.jpg"
+	for _, p := range []string{inFamily, inWork, lookalike} {
+		if _, err := deps.DB.Exec(`INSERT INTO media (path) VALUES (?)`, p); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := deps.DB.Exec(
+			`INSERT INTO media_tag_by_category (media_path, tag_label, category_label, weight, time_stamp) VALUES (?, 'pet', 'Subject', 1, 0)`, p,
+		); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return deps, inFamily, inWork, lookalike
+}
+
+// TestMediaQueryRootScoping: a viewer confined to one root only gets that
+// root's rows back from /api/media/query; an unconfined viewer gets all.
+func TestMediaQueryRootScoping(t *testing.T) {
+	deps, inFamily, inWork, lookalike := newConfinedLibrary(t)
+	query := func(token string) []string {
+		t.Helper()
+		rr := postAs(t, lokiMediaQueryHandler(deps), token, "/api/media/query", `{"predicates":[{"type":"tag","value":"pet"}]}`)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status = %d %s", rr.Code, rr.Body)
+		}
+		var items []map[string]any
+		json.Unmarshal(rr.Body.Bytes(), &items)
+		var paths []string
+		for _, it := range items {
+			p, _ := it["path"].(string)
+			paths = append(paths, p)
+		}
+		sort.Strings(paths)
+		return paths
+	}
+
+	kid := roleToken(t, deps, "kid", auth.Access{Role: auth.RoleViewer, Roots: []string{"Family"}})
+	if got := query(kid); len(got) != 1 || got[0] != inFamily {
+		t.Errorf("confined viewer got %v, want only %s", got, inFamily)
+	}
+	pal := roleToken(t, deps, "pal", auth.Access{Role: auth.RoleViewer})
+	if got := query(pal); len(got) != 3 {
+		t.Errorf("unconfined viewer got %v, want %s, %s and %s", got, inFamily, inWork, lookalike)
+	}
+}
+
+// TestMediaTagRootScoping: a curator confined to one root can tag items in
+// it but gets a 403, and no tag, anywhere else.
+func TestMediaTagRootScoping(t *testing.T) {
+	deps, inFamily, inWork, lookalike := newConfinedLibrary(t)
+	cora := roleToken(t, deps, "cora", auth.Access{Role: auth.RoleCurator, Roots: []string{"Family"}})
+	tag := func(path string) int {
+		t.Helper()
+		body, _ := json.Marshal(map[string]string{
+			"media_path": path, "tag_label": "bird", "category_label": "Subject", "action": "add",
+		})
+		return postAs(t, mediaTagHandler(deps), cora, "/media/tag", string(body)).Code
+	}
+
+	if code := tag(inFamily); code != http.StatusOK {
+		t.Errorf("tag inside root: status = %d, want 200", code)
+	}
+	for _, p := range []string{inWork, lookalike} {
+		if code := tag(p); code != http.StatusForbidden {
+			t.Errorf("tag %s: status = %d, want 403", p, code)
+		}
+	}
+	var n int
+	deps.DB.QueryRow(`SELECT COUNT(*) FROM media_tag_by_category WHERE tag_label = 'bird'`).Scan(&n)
+	if n != 1 {
+		t.Errorf("bird tags = %d, want 1 (only the item inside the root)", n)
+	}
+}
+
 func netParse(s string) net.IP { return net.ParseIP(s) }
 
 func newReqNoAuth() *http.Request {
@@ -10,6 +10,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/stevecastle/shrike/auth"
 )
 
 var (
@@ -119,10 +121,31 @@ const (
 	// is evaluated per request; with the flag off these routes behave
 	// exactly like RoleAdmin.
 	RolePublicRead
+	// RoleCurator marks curation routes (tagging, rating, descriptions,
+	// people/face assignment) open to curator and admin accounts but not
+	// viewers.
+	RoleCurator
 )
 
+// Permits is the permission matrix: whether an authenticated user holding
+// userRole may reach a route registered with required. Viewers get the
+// read routes, curators add curation, and only admins reach RoleAdmin.
+func Permits(userRole auth.Role, required AuthRole) bool {
+	switch required {
+	case RolePublic:
+		return true
+	case RolePublicRead:
+		return userRole.AtLeast(auth.RoleViewer)
+	case RoleCurator:
+		return userRole.AtLeast(auth.RoleCurator)
+	default:
+		return userRole.AtLeast(auth.RoleAdmin)
+	}
+}
+
 // AuthMiddleware is a function that takes a handler and a required role, returning a protected handler.
-// This is set from main.go to avoid circular dependencies.
+// This is set from main.go to avoid circular dependencies. Implementations
+// check the caller's account role against required with Permits.
 var AuthMiddleware func(http.Handler, AuthRole) http.Handler
 
 func ApplyMiddlewares(handler http.HandlerFunc, role AuthRole) http.HandlerFunc {
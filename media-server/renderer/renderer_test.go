@@ -6,6 +6,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/stevecastle/shrike/auth"
 )
 
 // TestFormatTime tests the formatTime template function
@@ -432,3 +434,28 @@ func TestLoggerWithDifferentMethods(t *testing.T) {
 		})
 	}
 }
+
+// TestPermits checks the role permission matrix.
+func TestPermits(t *testing.T) {
+	tests := []struct {
+		role     auth.Role
+		required AuthRole
+		want     bool
+	}{
+		{auth.RoleViewer, RolePublic, true},
+		{auth.RoleViewer, RolePublicRead, true},
+		{auth.RoleViewer, RoleCurator, false},
+		{auth.RoleViewer, RoleAdmin, false},
+		{auth.RoleCurator, RolePublicRead, true},
+		{auth.RoleCurator, RoleCurator, true},
+		{auth.RoleCurator, RoleAdmin, false},
+		{auth.RoleAdmin, RoleCurator, true},
+		{auth.RoleAdmin, RoleAdmin, true},
+		{auth.Role("owner"), RolePublicRead, false},
+	}
+	for _, tt := range tests {
+		if got := Permits(tt.role, tt.required); got != tt.want {
+			t.Errorf("Permits(%q, %d) = %v; want %v", tt.role, tt.required, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,368 @@
+// Package resultcache memoizes inference results — vision-LLM descriptions
+// and tags, transcripts — by content instead of by media path. A result is
+// keyed by (kind, content hash, provider, model, params hash), so
+// re-running a task with --overwrite, describing a duplicate, or re-ingesting
+// a moved file answers from the cache instead of paying for inference again.
+//
+// The cache lives in its own SQLite file next to the other server data
+// (platform.GetDataDir()/result-cache.db), shared by every library database.
+// It is off until Open is called — the server opens it at startup; tests and
+// tools that never do see every lookup miss and every store dropped. Cache
+// failures are logged and never fail the caller: a broken cache only costs
+// the inference it would have saved.
+package resultcache
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/stevecastle/shrike/appconfig"
+
+	_ "modernc.org/sqlite"
+)
+
+// Result kinds. Kind is part of the key so one file's description and
+// transcript never collide.
+const (
+	KindDescribe   = "describe"
+	KindLLMTag     = "llm-tag"
+	KindTranscribe = "transcribe"
+)
+
+// Key identifies one cached result.
+type Key struct {
+	Kind     string
+	Content  string // ContentHash of the input file
+	Provider string
+	Model    string
+	Params   string // ParamsHash of the prompt and other result-shaping options
+}
+
+// ModelStats summarizes the entries stored for one kind/provider/model.
+type ModelStats struct {
+	Kind     string `json:"kind"`
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	Entries  int64  `json:"entries"`
+	Bytes    int64  `json:"bytes"`
+	Hits     int64  `json:"hits"` // lifetime hits on these entries
+}
+
+// Stats is the cache's state. Hits, Misses and Evictions count since the
+// server started; the rest is read from the store.
+type Stats struct {
+	Enabled   bool         `json:"enabled"`
+	Path      string       `json:"path,omitempty"`
+	Entries   int64        `json:"entries"`
+	Bytes     int64        `json:"bytes"`
+	MaxBytes  int64        `json:"max_bytes"`
+	Hits      int64        `json:"hits"`
+	Misses    int64        `json:"misses"`
+	Evictions int64        `json:"evictions"`
+	Models    []ModelStats `json:"models"`
+}
+
+var (
+	mu    sync.Mutex
+	db    *sql.DB
+	path  string
+	total int64 // stored result bytes, kept in step with the table
+
+	hits, misses, evictions atomic.Int64
+)
+
+// Open opens (creating if needed) the cache database in dir and enables the
+// cache. Calling it again switches to the new location.
+func Open(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("resultcache: %w", err)
+	}
+	p := filepath.Join(dir, "result-cache.db")
+	d, err := sql.Open("sqlite", fmt.Sprintf("file:%s?_pragma=busy_timeout=5000", p))
+	if err != nil {
+		return fmt.Errorf("resultcache: %w", err)
+	}
+	// One writer at a time; the cache is never the bottleneck.
+	d.SetMaxOpenConns(1)
+	if _, err := d.Exec(`
+		CREATE TABLE IF NOT EXISTS result_cache (
+			kind TEXT NOT NULL,
+			content_hash TEXT NOT NULL,
+			provider TEXT NOT NULL,
+			model TEXT NOT NULL,
+			params_hash TEXT NOT NULL,
+			result TEXT NOT NULL,
+			bytes INTEGER NOT NULL,
+			hits INTEGER NOT NULL DEFAULT 0,
+			created_at INTEGER NOT NULL,
+			last_used_at INTEGER NOT NULL,
+			PRIMARY KEY (kind, content_hash, provider, model, params_hash)
+		);
+		CREATE INDEX IF NOT EXISTS idx_result_cache_last_used ON result_cache(last_used_at);
+		CREATE INDEX IF NOT EXISTS idx_result_cache_model ON result_cache(provider, model);
+	`); err != nil {
+		d.Close()
+		return fmt.Errorf("resultcache: create schema: %w", err)
+	}
+	var size int64
+	if err := d.QueryRow(`SELECT COALESCE(SUM(bytes), 0) FROM result_cache`).Scan(&size); err != nil {
+		d.Close()
+		return fmt.Errorf("resultcache: %w", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if db != nil {
+		db.Close()
+	}
+	db, path, total = d, p, size
+	return nil
+}
+
+// Close disables the cache and closes its database.
+func Close() {
+	mu.Lock()
+	defer mu.Unlock()
+	if db != nil {
+		db.Close()
+	}
+	db, path, total = nil, "", 0
+}
+
+// defaultMaxMB applies when the config leaves the cap unset (a config that
+// never went through appconfig.Load).
+const defaultMaxMB = 512
+
+// maxBytes is the configured size cap; negative means the cache is off.
+func maxBytes() int64 {
+	mb := appconfig.Get().ResultCacheMaxMB
+	switch {
+	case mb < 0:
+		return -1
+	case mb == 0:
+		mb = defaultMaxMB
+	}
+	return int64(mb) * 1024 * 1024
+}
+
+// handle returns the open database when the cache is enabled. Callers hold mu.
+func handle() *sql.DB {
+	if db == nil || maxBytes() < 0 {
+		return nil
+	}
+	return db
+}
+
+// Enabled reports whether lookups can hit, so callers can skip hashing the
+// input when the cache is off.
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return handle() != nil
+}
+
+// ContentHash identifies a file by content: SHA-256 over every byte, so a
+// moved or duplicated file hashes the same and files that differ anywhere
+// never share a result. Unlike media.hash (a 3 MiB prefix, good enough to
+// spot duplicates) it reads the whole file, which is cheap next to the
+// model run it saves.
+func ContentHash(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ParamsHash folds the options that shape a result (prompt, schema,
+// language, ...) into a short key component. Order matters.
+func ParamsHash(parts ...string) string {
+	h := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(h[:12])
+}
+
+// Get returns the cached result for k.
+func Get(k Key) (string, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	d := handle()
+	if d == nil {
+		return "", false
+	}
+	var result string
+	err := d.QueryRow(`SELECT result FROM result_cache
+		WHERE kind = ? AND content_hash = ? AND provider = ? AND model = ? AND params_hash = ?`,
+		k.Kind, k.Content, k.Provider, k.Model, k.Params).Scan(&result)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("resultcache: get: %v", err)
+		}
+		misses.Add(1)
+		return "", false
+	}
+	if _, err := d.Exec(`UPDATE result_cache SET hits = hits + 1, last_used_at = ?
+		WHERE kind = ? AND content_hash = ? AND provider = ? AND model = ? AND params_hash = ?`,
+		time.Now().Unix(), k.Kind, k.Content, k.Provider, k.Model, k.Params); err != nil {
+		log.Printf("resultcache: touch: %v", err)
+	}
+	hits.Add(1)
+	return result, true
+}
+
+// Put stores result under k, then evicts least-recently-used entries while
+// the cache is over its size cap. A result larger than the whole cap is not
+// stored.
+func Put(k Key, result string) {
+	if k.Content == "" || result == "" {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	d := handle()
+	if d == nil {
+		return
+	}
+	limit := maxBytes()
+	size := int64(len(result))
+	if size > limit {
+		return
+	}
+	var prev int64
+	_ = d.QueryRow(`SELECT bytes FROM result_cache
+		WHERE kind = ? AND content_hash = ? AND provider = ? AND model = ? AND params_hash = ?`,
+		k.Kind, k.Content, k.Provider, k.Model, k.Params).Scan(&prev)
+	now := time.Now().Unix()
+	if _, err := d.Exec(`INSERT OR REPLACE INTO result_cache
+		(kind, content_hash, provider, model, params_hash, result, bytes, hits, created_at, last_used_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, 0, ?, ?)`,
+		k.Kind, k.Content, k.Provider, k.Model, k.Params, result, size, now, now); err != nil {
+		log.Printf("resultcache: put: %v", err)
+		return
+	}
+	total += size - prev
+	if total > limit {
+		evictLocked(d, limit)
+	}
+}
+
+// evictLocked drops least-recently-used entries until the cache is under 90%
+// of limit, so a full cache doesn't evict on every store.
+func evictLocked(d *sql.DB, limit int64) {
+	target := limit / 10 * 9
+	for total > target {
+		rows, err := d.Query(`SELECT rowid, bytes FROM result_cache ORDER BY last_used_at, created_at LIMIT 256`)
+		if err != nil {
+			log.Printf("resultcache: evict: %v", err)
+			return
+		}
+		var ids []int64
+		freed := int64(0)
+		for rows.Next() && total-freed > target {
+			var id, n int64
+			if err := rows.Scan(&id, &n); err != nil {
+				break
+			}
+			ids = append(ids, id)
+			freed += n
+		}
+		rows.Close()
+		if len(ids) == 0 {
+			return
+		}
+		for _, id := range ids {
+			if _, err := d.Exec(`DELETE FROM result_cache WHERE rowid = ?`, id); err != nil {
+				log.Printf("resultcache: evict: %v", err)
+				return
+			}
+		}
+		total -= freed
+		evictions.Add(int64(len(ids)))
+	}
+}
+
+// InvalidateModel deletes every entry produced by model — under provider
+// when one is given, under any provider otherwise — and returns how many
+// were removed. Use it after swapping a model's weights under the same name.
+func InvalidateModel(provider, model string) (int64, error) {
+	if strings.TrimSpace(model) == "" {
+		return 0, fmt.Errorf("resultcache: model is required")
+	}
+	where, args := `model = ?`, []any{model}
+	if provider != "" {
+		where, args = where+` AND provider = ?`, append(args, provider)
+	}
+	return deleteWhere(where, args...)
+}
+
+// Clear deletes every entry.
+func Clear() (int64, error) {
+	return deleteWhere(`1 = 1`)
+}
+
+func deleteWhere(where string, args ...any) (int64, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if db == nil {
+		return 0, nil
+	}
+	var freed int64
+	if err := db.QueryRow(`SELECT COALESCE(SUM(bytes), 0) FROM result_cache WHERE `+where, args...).Scan(&freed); err != nil {
+		return 0, fmt.Errorf("resultcache: %w", err)
+	}
+	res, err := db.Exec(`DELETE FROM result_cache WHERE `+where, args...)
+	if err != nil {
+		return 0, fmt.Errorf("resultcache: %w", err)
+	}
+	total -= freed
+	n, _ := res.RowsAffected()
+	return n, nil
+}
+
+// GetStats reports the cache's size, hit/miss counters, and per-model usage.
+func GetStats() (Stats, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	s := Stats{
+		Path:      path,
+		MaxBytes:  maxBytes(),
+		Hits:      hits.Load(),
+		Misses:    misses.Load(),
+		Evictions: evictions.Load(),
+		Models:    []ModelStats{},
+	}
+	s.Enabled = handle() != nil
+	if db == nil {
+		return s, nil
+	}
+	rows, err := db.Query(`SELECT kind, provider, model, COUNT(*), SUM(bytes), SUM(hits)
+		FROM result_cache GROUP BY kind, provider, model ORDER BY kind, provider, model`)
+	if err != nil {
+		return s, fmt.Errorf("resultcache: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var m ModelStats
+		if err := rows.Scan(&m.Kind, &m.Provider, &m.Model, &m.Entries, &m.Bytes, &m.Hits); err != nil {
+			return s, fmt.Errorf("resultcache: %w", err)
+		}
+		s.Models = append(s.Models, m)
+		s.Entries += m.Entries
+		s.Bytes += m.Bytes
+	}
+	return s, rows.Err()
+}
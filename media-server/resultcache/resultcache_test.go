@@ -0,0 +1,157 @@
+package resultcache
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stevecastle/shrike/appconfig"
+)
+
+func openForTest(t *testing.T, maxMB int) {
+	t.Helper()
+	cur := appconfig.Get()
+	cfg := cur
+	cfg.ResultCacheMaxMB = maxMB
+	appconfig.Set(cfg)
+	if err := Open(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	hits.Store(0)
+	misses.Store(0)
+	evictions.Store(0)
+	t.Cleanup(func() {
+		Close()
+		appconfig.Set(cur)
+	})
+}
+
+func key(content, model string) Key {
+	return Key{Kind: KindDescribe, Content: content, Provider: "ollama", Model: model, Params: ParamsHash("describe it")}
+}
+
+func TestGetPutStats(t *testing.T) {
+	openForTest(t, 1)
+	if _, ok := Get(key("a", "m1")); ok {
+		t.Fatal("hit on an empty cache")
+	}
+	Put(key("a", "m1"), "a cat")
+	Put(key("a", "m1"), "a cat on a mat") // replaces, not duplicates
+	got, ok := Get(key("a", "m1"))
+	if !ok || got != "a cat on a mat" {
+		t.Fatalf("Get = %q, %v", got, ok)
+	}
+	if _, ok := Get(Key{Kind: KindDescribe, Content: "a", Provider: "ollama", Model: "m1", Params: ParamsHash("other prompt")}); ok {
+		t.Error("different params hit")
+	}
+	s, err := GetStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Enabled || s.Entries != 1 || s.Bytes != int64(len("a cat on a mat")) || s.Hits != 1 || s.Misses != 2 {
+		t.Fatalf("stats = %+v", s)
+	}
+	if len(s.Models) != 1 || s.Models[0].Model != "m1" || s.Models[0].Hits != 1 {
+		t.Fatalf("model stats = %+v", s.Models)
+	}
+}
+
+func TestEvictsLeastRecentlyUsedPastCap(t *testing.T) {
+	openForTest(t, 1)
+	big := strings.Repeat("x", 400*1024)
+	Put(key("old", "m"), big)
+	Put(key("mid", "m"), big)
+	// Stamps tie within one second, so age "mid" to make it the least
+	// recently used.
+	if _, err := db.Exec(`UPDATE result_cache SET last_used_at = last_used_at - 10 WHERE content_hash = 'mid'`); err != nil {
+		t.Fatal(err)
+	}
+	Put(key("new", "m"), big)
+	if _, ok := Get(key("mid", "m")); ok {
+		t.Error("least recently used entry survived eviction")
+	}
+	for _, c := range []string{"old", "new"} {
+		if _, ok := Get(key(c, "m")); !ok {
+			t.Errorf("%s evicted", c)
+		}
+	}
+	s, _ := GetStats()
+	if s.Bytes > s.MaxBytes || s.Evictions != 1 {
+		t.Fatalf("stats after eviction = %+v", s)
+	}
+}
+
+func TestInvalidateModelAndClear(t *testing.T) {
+	openForTest(t, 1)
+	Put(key("a", "m1"), "one")
+	Put(key("b", "m1"), "two")
+	Put(key("a", "m2"), "three")
+	n, err := InvalidateModel("", "m1")
+	if err != nil || n != 2 {
+		t.Fatalf("InvalidateModel = %d, %v", n, err)
+	}
+	if _, ok := Get(key("a", "m2")); !ok {
+		t.Error("other model's entry dropped")
+	}
+	if n, _ := InvalidateModel("runpod", "m2"); n != 0 {
+		t.Errorf("provider filter ignored: deleted %d", n)
+	}
+	if _, err := InvalidateModel("", " "); err == nil {
+		t.Error("blank model accepted")
+	}
+	if n, _ := Clear(); n != 1 {
+		t.Errorf("Clear deleted %d", n)
+	}
+	if s, _ := GetStats(); s.Entries != 0 || s.Bytes != 0 {
+		t.Errorf("stats after clear = %+v", s)
+	}
+}
+
+func TestDisabledCacheIsANoOp(t *testing.T) {
+	openForTest(t, -1)
+	Put(key("a", "m"), "x")
+	if _, ok := Get(key("a", "m")); ok || Enabled() {
+		t.Fatal("negative size cap should disable the cache")
+	}
+	Close()
+	if Enabled() {
+		t.Fatal("closed cache reports enabled")
+	}
+}
+
+func TestContentHashFollowsBytesNotPath(t *testing.T) {
+	dir := t.TempDir()
+	a, b, c := filepath.Join(dir, "a.jpg"), filepath.Join(dir, "sub-b.jpg"), filepath.Join(dir, "c.jpg")
+	for p, body := range map[string]string{a: "same bytes", b: "same bytes", c: "same bytez"} {
+		if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ha, _ := ContentHash(a)
+	hb, _ := ContentHash(b)
+	hc, _ := ContentHash(c)
+	if ha == "" || ha != hb || ha == hc {
+		t.Fatalf("hashes a=%s b=%s c=%s", ha, hb, hc)
+	}
+}
+
+// Files of the same size that share their first 3 MiB (a re-encode with a
+// padded header, a repaired copy) must not share a cached result.
+func TestContentHashCoversWholeFile(t *testing.T) {
+	dir := t.TempDir()
+	body := make([]byte, 4*1024*1024)
+	a, b := filepath.Join(dir, "a.mp4"), filepath.Join(dir, "b.mp4")
+	if err := os.WriteFile(a, body, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	body[len(body)-1] = 1
+	if err := os.WriteFile(b, body, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ha, _ := ContentHash(a)
+	hb, _ := ContentHash(b)
+	if ha == "" || ha == hb {
+		t.Fatalf("files differing after 3 MiB hash alike: %s", ha)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/stevecastle/shrike/resultcache"
+)
+
+// -----------------------------------------------------------------------------
+// Inference result cache API (shared across all platform mains).
+//
+//   GET    /api/result-cache                           — size, hit/miss stats, per-model usage
+//   DELETE /api/result-cache?model=M[&provider=P]      — drop one model's results
+//   DELETE /api/result-cache?confirm=true              — drop everything
+// -----------------------------------------------------------------------------
+
+func resultCacheHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			stats, err := resultcache.GetStats()
+			if err != nil {
+				httpError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, stats)
+		case http.MethodDelete:
+			q := r.URL.Query()
+			model := strings.TrimSpace(q.Get("model"))
+			provider := strings.TrimSpace(q.Get("provider"))
+			var deleted int64
+			var err error
+			switch {
+			case model != "":
+				deleted, err = resultcache.InvalidateModel(provider, model)
+			case provider != "":
+				httpError(w, "provider needs a model; add ?model=", http.StatusBadRequest)
+				return
+			case q.Get("confirm") == "true":
+				deleted, err = resultcache.Clear()
+			default:
+				httpError(w, "add ?model= to invalidate one model's results, or ?confirm=true to clear the cache", http.StatusBadRequest)
+				return
+			}
+			if err != nil {
+				httpError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, map[string]any{"deleted": deleted})
+		default:
+			httpError(w, "use GET or DELETE", http.StatusMethodNotAllowed)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stevecastle/shrike/resultcache"
+)
+
+func TestResultCacheAPI(t *testing.T) {
+	if err := resultcache.Open(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(resultcache.Close)
+	for _, m := range []string{"m1", "m1", "m2"} {
+		resultcache.Put(resultcache.Key{Kind: resultcache.KindDescribe, Content: "c-" + m + "-" + t.Name(), Provider: "ollama", Model: m}, "text")
+	}
+	resultcache.Put(resultcache.Key{Kind: resultcache.KindDescribe, Content: "other", Provider: "ollama", Model: "m1"}, "text")
+
+	h := resultCacheHandler()
+	do := func(method, url string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h(rr, httptest.NewRequest(method, url, nil))
+		return rr
+	}
+
+	rr := do(http.MethodGet, "/api/result-cache")
+	var stats resultcache.Stats
+	if err := json.Unmarshal(rr.Body.Bytes(), &stats); err != nil || rr.Code != http.StatusOK {
+		t.Fatalf("GET = %d %s", rr.Code, rr.Body)
+	}
+	if !stats.Enabled || stats.Entries != 3 {
+		t.Fatalf("stats = %+v", stats)
+	}
+
+	if rr := do(http.MethodDelete, "/api/result-cache"); rr.Code != http.StatusBadRequest {
+		t.Errorf("bare DELETE = %d; want 400", rr.Code)
+	}
+	if rr := do(http.MethodDelete, "/api/result-cache?provider=ollama"); rr.Code != http.StatusBadRequest {
+		t.Errorf("provider-only DELETE = %d; want 400", rr.Code)
+	}
+	rr = do(http.MethodDelete, "/api/result-cache?model=m1&provider=ollama")
+	var out struct {
+		Deleted int64 `json:"deleted"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &out); err != nil || out.Deleted != 2 {
+		t.Fatalf("invalidate m1 = %d %s", rr.Code, rr.Body)
+	}
+	rr = do(http.MethodDelete, "/api/result-cache?confirm=true")
+	if err := json.Unmarshal(rr.Body.Bytes(), &out); err != nil || out.Deleted != 1 {
+		t.Fatalf("clear = %d %s", rr.Code, rr.Body)
+	}
+	if rr := do(http.MethodPost, "/api/result-cache"); rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST = %d; want 405", rr.Code)
+	}
+}
@@ -28,6 +28,8 @@ func RegisterDepsRoutes(mux *http.ServeMux, deps *Dependencies) {
 
 	mux.HandleFunc("GET /api/deps/status", h(handlers.HandleDepsStatus))
 	mux.HandleFunc("GET /api/deps/models/progress", h(handlers.HandleModelProgressSSE))
+	mux.HandleFunc("POST /api/deps/models/import", hw(handlers.HandleModelImport))
+	mux.HandleFunc("GET /api/deps/models/export", hw(handlers.HandleModelExport))
 	mux.HandleFunc("POST /api/deps/models/{id}/download", hw(handlers.HandleModelDownload))
 	mux.HandleFunc("POST /api/deps/models/{id}/cancel", hw(handlers.HandleModelCancel))
 	mux.HandleFunc("POST /api/deps/models/{id}/verify", hw(handlers.HandleModelVerify))
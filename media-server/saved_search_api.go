@@ -0,0 +1,401 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stevecastle/shrike/media"
+	"github.com/stevecastle/shrike/renderer"
+	"github.com/stevecastle/shrike/tasks"
+)
+
+// -----------------------------------------------------------------------------
+// Saved searches API (shared across all platform mains).
+//
+//   GET    /api/saved-searches           — every saved search, with its cached
+//                                          match count when one is known
+//   POST   /api/saved-searches           — create {name, predicates | query,
+//                                          mode, sort}
+//   GET    /api/saved-searches/{id}      — one saved search ({id} or name)
+//   PUT    /api/saved-searches/{id}      — replace its definition
+//   DELETE /api/saved-searches/{id}
+//
+// A saved search is either a predicate list (the browse UI's query chips) or
+// a task search-DSL string. Either way it can be used as a saved:"name"
+// predicate — nested saved searches included — and by any item task through
+// --saved <name>. Match counts ride on the library stats snapshot (recounted
+// with it, and right after every mutation); searches holding similarity
+// predicates are not counted, since their membership is a model call.
+// -----------------------------------------------------------------------------
+
+// savedSearchMaxDepth caps saved:"name" nesting. Cycles are caught by name
+// before this; the cap bounds how much SQL one query can expand into.
+const savedSearchMaxDepth = 8
+
+// errSavedSearch marks user errors in a saved-search expansion (a cycle, a
+// definition that no longer parses), so the query handler answers 400.
+var errSavedSearch = errors.New("saved search")
+
+// RegisterSavedSearchRoutes wires the saved-search API onto mux and hands the
+// task package its --saved resolver.
+func RegisterSavedSearchRoutes(mux *http.ServeMux, deps *Dependencies) {
+	tasks.SetSavedSearchResolver(savedSearchPaths)
+	// Reads are public-readable like the rest of browsing; writes need a
+	// curator or admin account.
+	publicRead := func(h http.HandlerFunc) http.HandlerFunc {
+		return renderer.ApplyMiddlewares(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				h(w, r)
+				return
+			}
+			requireCurator(deps, h)(w, r)
+		}, renderer.RolePublicRead)
+	}
+	mux.HandleFunc("/api/saved-searches", publicRead(savedSearchesHandler(deps)))
+	mux.HandleFunc("/api/saved-searches/{id}", publicRead(savedSearchHandler(deps)))
+}
+
+// savedSearchItem is a saved search as the list endpoint reports it.
+type savedSearchItem struct {
+	media.SavedSearch
+	Count *int `json:"count,omitempty"`
+}
+
+// savedSearchCount is one saved search's match count on the stats snapshot.
+type savedSearchCount struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func savedSearchesHandler(deps *Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			list, err := media.ListSavedSearches(deps.DB)
+			if err != nil {
+				httpError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			counts := map[int64]int{}
+			libStats.mu.Lock()
+			if libStats.snapshot != nil {
+				for _, c := range libStats.snapshot.SavedSearches {
+					counts[c.ID] = c.Count
+				}
+			}
+			libStats.mu.Unlock()
+			out := make([]savedSearchItem, 0, len(list))
+			for _, s := range list {
+				item := savedSearchItem{SavedSearch: s}
+				if n, ok := counts[s.ID]; ok {
+					item.Count = &n
+				}
+				out = append(out, item)
+			}
+			writeJSON(w, out)
+		case http.MethodPost:
+			var req media.SavedSearch
+			if err := readJSON(r, &req); err != nil {
+				httpError(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			if err := validateSavedSearch(req); err != nil {
+				httpError(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			s, err := media.CreateSavedSearch(deps.DB, req)
+			if err != nil {
+				httpError(w, err.Error(), userErrorStatus(err))
+				return
+			}
+			auditLog(deps, r, "search.create", []string{savedSearchTarget(s.ID)}, nil, auditSavedSearch(s))
+			go recountSavedSearches(deps)
+			writeJSON(w, s)
+		default:
+			httpError(w, "use GET or POST", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func savedSearchHandler(deps *Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s, found, err := lookupSavedSearch(deps.DB, r.PathValue("id"))
+		if err != nil {
+			httpError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			httpError(w, "saved search not found", http.StatusNotFound)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, s)
+		case http.MethodPut:
+			var req media.SavedSearch
+			if err := readJSON(r, &req); err != nil {
+				httpError(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			req.ID = s.ID
+			if err := validateSavedSearch(req); err != nil {
+				httpError(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			updated, ok, err := media.UpdateSavedSearch(deps.DB, req)
+			if err != nil {
+				httpError(w, err.Error(), userErrorStatus(err))
+				return
+			}
+			if !ok {
+				httpError(w, "saved search not found", http.StatusNotFound)
+				return
+			}
+			auditLog(deps, r, "search.update", []string{savedSearchTarget(s.ID)}, auditSavedSearch(s), auditSavedSearch(updated))
+			go recountSavedSearches(deps)
+			writeJSON(w, updated)
+		case http.MethodDelete:
+			if _, err := media.DeleteSavedSearch(deps.DB, s.ID); err != nil {
+				httpError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			auditLog(deps, r, "search.delete", []string{savedSearchTarget(s.ID)}, auditSavedSearch(s), nil)
+			go recountSavedSearches(deps)
+			writeJSON(w, map[string]any{"deleted": s.ID})
+		default:
+			httpError(w, "use GET, PUT, or DELETE", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// lookupSavedSearch resolves a path segment that is either a numeric ID or a
+// name.
+func lookupSavedSearch(db *sql.DB, key string) (media.SavedSearch, bool, error) {
+	if id, err := strconv.ParseInt(key, 10, 64); err == nil && id > 0 {
+		if s, ok, err := media.GetSavedSearchByID(db, id); err != nil || ok {
+			return s, ok, err
+		}
+	}
+	return media.GetSavedSearchByName(db, key)
+}
+
+// validateSavedSearch checks what the media package cannot: that predicates
+// decode as Predicates and a DSL query parses. A search may not name itself.
+func validateSavedSearch(s media.SavedSearch) error {
+	if strings.TrimSpace(s.Query) != "" {
+		if _, err := media.NewParser(s.Query).Parse(); err != nil {
+			return fmt.Errorf("invalid query %q: %w", s.Query, err)
+		}
+		return nil
+	}
+	preds, err := decodeSavedPredicates(s.Predicates)
+	if err != nil {
+		return err
+	}
+	for _, p := range preds {
+		if p.Type == "saved" && strings.EqualFold(strings.TrimSpace(p.Value), strings.TrimSpace(s.Name)) {
+			return fmt.Errorf("saved search %q cannot include itself", s.Name)
+		}
+	}
+	return nil
+}
+
+// decodeSavedPredicates decodes a stored predicate list.
+func decodeSavedPredicates(raw json.RawMessage) ([]Predicate, error) {
+	var preds []Predicate
+	if len(raw) == 0 {
+		return preds, nil
+	}
+	if err := json.Unmarshal(raw, &preds); err != nil {
+		return nil, fmt.Errorf("predicates must be a list of query predicates: %w", err)
+	}
+	return preds, nil
+}
+
+// expandSavedPredicates fills SavedSQL/SavedParams for every saved:"name"
+// predicate in preds (in place). visiting is the chain of saved searches
+// already being expanded; meeting one of them again is a cycle. An unknown
+// name is left unexpanded and matches nothing.
+func expandSavedPredicates(ctx context.Context, db *sql.DB, preds []Predicate, visiting []string) error {
+	for i := range preds {
+		if preds[i].Type != "saved" || preds[i].Value == "" {
+			continue
+		}
+		name := strings.TrimSpace(preds[i].Value)
+		for _, v := range visiting {
+			if strings.EqualFold(v, name) {
+				return fmt.Errorf("%w cycle: %s → %s", errSavedSearch, strings.Join(visiting, " → "), name)
+			}
+		}
+		if len(visiting) >= savedSearchMaxDepth {
+			return fmt.Errorf("%w %q: nested more than %d deep", errSavedSearch, name, savedSearchMaxDepth)
+		}
+		s, found, err := media.GetSavedSearchByName(db, name)
+		if err != nil {
+			return err
+		}
+		if !found {
+			continue
+		}
+		querySQL, params, err := savedSearchSQL(ctx, db, s, visiting)
+		if err != nil {
+			return err
+		}
+		preds[i].SavedSQL = querySQL
+		preds[i].SavedParams = params
+	}
+	return nil
+}
+
+// savedSearchSQL returns a query (and its params) selecting a path column
+// for every item s matches. visiting is the expansion chain leading to s.
+// DSL searches drop exists: conditions here — they are checked on disk, not
+// in SQL — so they only filter when the search runs as --saved.
+func savedSearchSQL(ctx context.Context, db *sql.DB, s media.SavedSearch, visiting []string) (string, []any, error) {
+	if s.Query != "" {
+		root, err := media.NewParser(s.Query).Parse()
+		if err != nil {
+			return "", nil, fmt.Errorf("%w %q: %v", errSavedSearch, s.Name, err)
+		}
+		if root == nil {
+			return "SELECT path FROM media", nil, nil
+		}
+		where, args := root.ToSQL()
+		if where == "" {
+			return "SELECT path FROM media", nil, nil
+		}
+		return "SELECT m.path AS path FROM media m WHERE " + where, args, nil
+	}
+	preds, err := decodeSavedPredicates(s.Predicates)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w %q: %v", errSavedSearch, s.Name, err)
+	}
+	chain := append(append([]string{}, visiting...), s.Name)
+	if _, err := resolveQueryPredicates(ctx, db, preds, s.Mode, chain,
+		map[string]float32{}, map[string]tasks.SemanticHit{}); err != nil {
+		return "", nil, err
+	}
+	querySQL, params := BuildMediaQuery(preds, s.Mode)
+	return querySQL, params, nil
+}
+
+// savedSearchPaths is the tasks package's --saved resolver: every path the
+// named search currently matches. DSL searches go through GetPathsByQuery so
+// exists: conditions are honoured exactly as for --query.
+func savedSearchPaths(ctx context.Context, db *sql.DB, name string) ([]string, error) {
+	s, found, err := media.GetSavedSearchByName(db, name)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("saved search %q not found", name)
+	}
+	if s.Query != "" {
+		return media.GetPathsByQuery(db, s.Query)
+	}
+	querySQL, params, err := savedSearchSQL(ctx, db, s, nil)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.QueryContext(ctx, "SELECT DISTINCT path FROM ("+querySQL+") ORDER BY path", params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var paths []string
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		paths = append(paths, p)
+	}
+	return paths, rows.Err()
+}
+
+// savedSearchIsVisual reports whether s (or any saved search it nests) holds
+// a similarity predicate. visiting guards against cycles.
+func savedSearchIsVisual(db *sql.DB, s media.SavedSearch, visiting map[string]bool) bool {
+	if s.Query != "" || visiting[strings.ToLower(s.Name)] {
+		return false
+	}
+	visiting[strings.ToLower(s.Name)] = true
+	preds, _ := decodeSavedPredicates(s.Predicates)
+	for _, p := range preds {
+		if isVisualPredicate(p) {
+			return true
+		}
+		if p.Type == "saved" && p.Value != "" {
+			if inner, found, err := media.GetSavedSearchByName(db, p.Value); err == nil && found &&
+				savedSearchIsVisual(db, inner, visiting) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// countSavedSearches counts every saved search's matches for the stats
+// snapshot. Searches with similarity predicates are skipped (a model call
+// and an index scan per recount), as are ones that fail to expand.
+func countSavedSearches(ctx context.Context, db *sql.DB) ([]savedSearchCount, error) {
+	list, err := media.ListSavedSearches(db)
+	if err != nil {
+		return nil, err
+	}
+	out := []savedSearchCount{}
+	for _, s := range list {
+		if savedSearchIsVisual(db, s, map[string]bool{}) {
+			continue
+		}
+		querySQL, params, err := savedSearchSQL(ctx, db, s, nil)
+		if err != nil {
+			log.Printf("stats: saved search %q: %v", s.Name, err)
+			continue
+		}
+		var n int
+		if err := db.QueryRowContext(ctx, "SELECT COUNT(DISTINCT path) FROM ("+querySQL+")", params...).Scan(&n); err != nil {
+			log.Printf("stats: saved search %q count failed: %v", s.Name, err)
+			continue
+		}
+		out = append(out, savedSearchCount{ID: s.ID, Name: s.Name, Count: n})
+	}
+	return out, nil
+}
+
+// recountSavedSearches refreshes just the saved-search counts on the current
+// stats snapshot — after a create/update/delete, so the list shows the new
+// count without waiting for (or forcing) a full library recount.
+func recountSavedSearches(deps *Dependencies) {
+	libStats.mu.Lock()
+	startGen := libStats.gen
+	libStats.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	counts, err := countSavedSearches(ctx, deps.DB)
+	if err != nil {
+		log.Printf("stats: saved search recount failed: %v", err)
+		return
+	}
+
+	libStats.mu.Lock()
+	defer libStats.mu.Unlock()
+	if libStats.gen != startGen || libStats.snapshot == nil {
+		return // no snapshot yet, or counted across a DB switch; the next full recount covers it
+	}
+	// Copy-on-write: served merges share the snapshot struct by value.
+	next := *libStats.snapshot
+	next.SavedSearches = counts
+	libStats.snapshot = &next
+	libStats.dirty = true
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stevecastle/shrike/media"
+)
+
+// newSavedSearchDB seeds three items: a.jpg (cat, outdoor), b.jpg (cat),
+// c.png (dog).
+func newSavedSearchDB(t *testing.T) *Dependencies {
+	t.Helper()
+	db := newFacesTestDB(t)
+	for _, row := range []struct{ path, tag string }{
+		{"a.jpg", "cat"}, {"a.jpg", "outdoor"}, {"b.jpg", "cat"}, {"c.png", "dog"},
+	} {
+		if _, err := db.Exec(`INSERT OR IGNORE INTO media (path) VALUES (?)`, row.path); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(
+			`INSERT INTO media_tag_by_category (media_path, tag_label, category_label, weight, time_stamp) VALUES (?, ?, 'Subject', 1, 0)`,
+			row.path, row.tag,
+		); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &Dependencies{DB: db}
+}
+
+func saveSearch(t *testing.T, deps *Dependencies, s media.SavedSearch) {
+	t.Helper()
+	if _, err := media.CreateSavedSearch(deps.DB, s); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func runSavedQuery(t *testing.T, deps *Dependencies, body string) (int, []string) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/media/query", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	lokiMediaQueryHandler(deps)(rec, req)
+	if rec.Code != http.StatusOK {
+		return rec.Code, nil
+	}
+	var items []map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &items); err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, it := range items {
+		p, _ := it["path"].(string)
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return rec.Code, paths
+}
+
+func TestSavedPredicateExpandsNestedSearches(t *testing.T) {
+	deps := newSavedSearchDB(t)
+	saveSearch(t, deps, media.SavedSearch{Name: "Cats", Predicates: json.RawMessage(`[{"type":"tag","value":"cat"}]`)})
+	// A DSL search nesting nothing, and a predicate search nesting both kinds.
+	saveSearch(t, deps, media.SavedSearch{Name: "jpegs", Query: "path:%.jpg"})
+	saveSearch(t, deps, media.SavedSearch{Name: "Indoor cats", Predicates: json.RawMessage(
+		`[{"type":"saved","value":"cats"},{"type":"tag","value":"outdoor","exclude":true}]`)})
+
+	if code, paths := runSavedQuery(t, deps, `{"predicates":[{"type":"saved","value":"Indoor cats"}],"mode":"AND"}`); code != http.StatusOK ||
+		len(paths) != 1 || paths[0] != "b.jpg" {
+		t.Errorf("saved:Indoor cats = %d %v, want [b.jpg]", code, paths)
+	}
+	if code, paths := runSavedQuery(t, deps, `{"predicates":[{"type":"tag","value":"dog"},{"type":"saved","value":"jpegs","join":"OR"}],"mode":"AND"}`); code != http.StatusOK ||
+		len(paths) != 3 {
+		t.Errorf("dog OR saved:jpegs = %d %v, want all three", code, paths)
+	}
+	if _, paths := runSavedQuery(t, deps, `{"predicates":[{"type":"saved","value":"cats","exclude":true}],"mode":"AND"}`); len(paths) != 1 || paths[0] != "c.png" {
+		t.Errorf("-saved:cats = %v, want [c.png]", paths)
+	}
+	if _, paths := runSavedQuery(t, deps, `{"predicates":[{"type":"saved","value":"nope"}],"mode":"AND"}`); len(paths) != 0 {
+		t.Errorf("unknown saved search matched %v", paths)
+	}
+}
+
+func TestSavedPredicateCycleIsBadRequest(t *testing.T) {
+	deps := newSavedSearchDB(t)
+	saveSearch(t, deps, media.SavedSearch{Name: "a", Predicates: json.RawMessage(`[{"type":"saved","value":"b"}]`)})
+	saveSearch(t, deps, media.SavedSearch{Name: "b", Predicates: json.RawMessage(`[{"type":"saved","value":"A"}]`)})
+	if code, _ := runSavedQuery(t, deps, `{"predicates":[{"type":"saved","value":"a"}],"mode":"AND"}`); code != http.StatusBadRequest {
+		t.Errorf("cycle status = %d, want 400", code)
+	}
+}
+
+func TestSavedSearchPathsAndCounts(t *testing.T) {
+	deps := newSavedSearchDB(t)
+	saveSearch(t, deps, media.SavedSearch{Name: "cats", Predicates: json.RawMessage(`[{"type":"tag","value":"cat"}]`)})
+	saveSearch(t, deps, media.SavedSearch{Name: "pngs", Query: "path:%.png"})
+	saveSearch(t, deps, media.SavedSearch{Name: "looks like", Predicates: json.RawMessage(`[{"type":"visual","value":"a cat"}]`)})
+
+	paths, err := savedSearchPaths(context.Background(), deps.DB, "Cats")
+	if err != nil || len(paths) != 2 || paths[0] != "a.jpg" || paths[1] != "b.jpg" {
+		t.Errorf("paths = %v, %v", paths, err)
+	}
+	if _, err := savedSearchPaths(context.Background(), deps.DB, "missing"); err == nil {
+		t.Error("missing saved search should error")
+	}
+
+	counts, err := countSavedSearches(context.Background(), deps.DB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]int{}
+	for _, c := range counts {
+		got[c.Name] = c.Count
+	}
+	if len(got) != 2 || got["cats"] != 2 || got["pngs"] != 1 {
+		t.Errorf("counts = %v (similarity searches are not counted)", got)
+	}
+}
+
+func TestSavedSearchHandlersCRUD(t *testing.T) {
+	deps := newSavedSearchDB(t)
+	list := savedSearchesHandler(deps)
+	one := savedSearchHandler(deps)
+
+	rec := httptest.NewRecorder()
+	list(rec, httptest.NewRequest(http.MethodPost, "/api/saved-searches",
+		strings.NewReader(`{"name":"self","predicates":[{"type":"saved","value":"Self"}]}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("self-nesting status = %d, want 400", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	list(rec, httptest.NewRequest(http.MethodPost, "/api/saved-searches", strings.NewReader(`{"name":"none"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("empty definition status = %d, want 400", rec.Code)
+	}
+
+	saveSearch(t, deps, media.SavedSearch{Name: "dogs", Query: "tag:dog"})
+	req := httptest.NewRequest(http.MethodPut, "/api/saved-searches/dogs", strings.NewReader(`{"name":"Dogs","query":"tag:dog","sort":"elo"}`))
+	req.SetPathValue("id", "dogs")
+	rec = httptest.NewRecorder()
+	one(rec, req)
+	var updated media.SavedSearch
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &updated) != nil || updated.Name != "Dogs" || updated.Sort != "elo" {
+		t.Fatalf("update = %d %s", rec.Code, rec.Body)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/saved-searches/x", nil)
+	req.SetPathValue("id", "Dogs")
+	rec = httptest.NewRecorder()
+	one(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("delete = %d %s", rec.Code, rec.Body)
+	}
+	if _, found, _ := media.GetSavedSearchByName(deps.DB, "dogs"); found {
+		t.Error("saved search still present after delete")
+	}
+}
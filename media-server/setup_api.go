@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/stevecastle/shrike/appconfig"
+	"github.com/stevecastle/shrike/auth"
 	"github.com/stevecastle/shrike/deps/models"
 	"github.com/stevecastle/shrike/deps/status"
 	"github.com/stevecastle/shrike/platform"
@@ -79,17 +80,17 @@ func provisionAdminFromEnv(d *Dependencies) {
 	log.Printf("setup: account %q provisioned from LOWKEY_ADMIN_USER", user)
 }
 
-// setupAuthed reports whether the request carries a valid credential
+// setupAuthed reports whether the request carries a valid admin credential
 // (Bearer JWT, lk_ API key, or the auth_token cookie).
 func setupAuthed(d *Dependencies, r *http.Request) bool {
 	if tok := requestAuthToken(r); tok != "" {
-		if _, err := verifyCredential(d, tok); err == nil {
-			return true
+		if claims, err := verifyCredential(d, r, tok); err == nil {
+			return accountRole(d, claims.Username) == auth.RoleAdmin
 		}
 	}
 	if c, err := r.Cookie("auth_token"); err == nil {
-		if _, err := d.Auth.VerifyToken(c.Value); err == nil {
-			return true
+		if claims, err := d.Auth.VerifyToken(c.Value); err == nil {
+			return accountRole(d, claims.Username) == auth.RoleAdmin
 		}
 	}
 	return false
@@ -439,3 +439,62 @@ func TestUserCreateLockdown(t *testing.T) {
 		t.Fatalf("authenticated second account = %d: %s", w.Code, w.Body.String())
 	}
 }
+
+// TestUserManagementRoles: only admins list, create, or edit accounts, and
+// new accounts take the requested role.
+func TestUserManagementRoles(t *testing.T) {
+	d := newSetupTestDeps(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/auth/users", userManagementHandler(d))
+
+	// The first account is an admin whatever it asks for.
+	if w := setupPost(t, mux, "/auth/users", map[string]string{"username": "steve", "password": "pw", "role": "viewer"}, ""); w.Code != http.StatusCreated {
+		t.Fatalf("first account = %d", w.Code)
+	}
+	admin, _ := d.Auth.Login("steve", "pw")
+	if w := setupPost(t, mux, "/auth/users", map[string]any{"username": "kid", "password": "pw", "role": "viewer", "roots": []string{"/photos"}}, admin); w.Code != http.StatusCreated {
+		t.Fatalf("create viewer = %d: %s", w.Code, w.Body.String())
+	}
+	if w := setupPost(t, mux, "/auth/users", map[string]string{"username": "x", "password": "pw", "role": "owner"}, admin); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown role = %d, want 400", w.Code)
+	}
+	if a, _ := d.Auth.UserAccess("steve"); a.Role != auth.RoleAdmin {
+		t.Errorf("first account role = %q", a.Role)
+	}
+
+	viewer, _ := d.Auth.Login("kid", "pw")
+	if w := setupGet(t, mux, "/auth/users", viewer); w.Code != http.StatusForbidden {
+		t.Errorf("viewer list = %d, want 403", w.Code)
+	}
+	if w := setupPost(t, mux, "/auth/users", map[string]string{"username": "pal", "password": "pw"}, viewer); w.Code != http.StatusForbidden {
+		t.Errorf("viewer create = %d, want 403", w.Code)
+	}
+	w := setupGet(t, mux, "/auth/users", admin)
+	var list struct {
+		Users []auth.User `json:"users"`
+	}
+	decodeBody(t, w, &list)
+	if len(list.Users) != 2 || list.Users[0].Username != "kid" || list.Users[0].Role != auth.RoleViewer || len(list.Users[0].Roots) != 1 {
+		t.Errorf("users = %+v", list.Users)
+	}
+
+	put := func(body string, cookie string) int {
+		req := httptest.NewRequest(http.MethodPut, "/auth/users", strings.NewReader(body))
+		req.AddCookie(&http.Cookie{Name: "auth_token", Value: cookie})
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := put(`{"username":"kid","role":"curator","roots":[]}`, admin); code != http.StatusOK {
+		t.Fatalf("promote = %d", code)
+	}
+	if a, _ := d.Auth.UserAccess("kid"); a.Role != auth.RoleCurator || len(a.Roots) != 0 {
+		t.Errorf("kid after promote = %+v", a)
+	}
+	if code := put(`{"username":"steve","role":"viewer"}`, admin); code != http.StatusBadRequest {
+		t.Errorf("demote last admin = %d, want 400", code)
+	}
+	if code := put(`{"username":"ghost","role":"viewer"}`, admin); code != http.StatusNotFound {
+		t.Errorf("unknown user = %d, want 404", code)
+	}
+}
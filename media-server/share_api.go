@@ -0,0 +1,769 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stevecastle/shrike/auth"
+	"github.com/stevecastle/shrike/media"
+	"github.com/stevecastle/shrike/mediaext"
+	"github.com/stevecastle/shrike/renderer"
+)
+
+// -----------------------------------------------------------------------------
+// Share links API (shared across all platform mains).
+//
+//   GET    /api/shares            — every share, newest first
+//   POST   /api/shares            — create {kind, target, title, password,
+//                                    expiresAt, maxViews}; kind is path, tag,
+//                                    query (search DSL), saved, or collection
+//   GET    /api/shares/{id}       — one share
+//   DELETE /api/shares/{id}       — revoke (the row and its log are kept)
+//   GET    /api/shares/{id}/log   — the access log, newest first (?limit=N)
+//   GET    /s/{token}             — the public gallery page (POST submits the
+//                                    share's password)
+//
+// A share token redeems anonymously on /media/file, /media/thumbnail, and
+// /media/hls (GET), for the paths the share currently holds, whether public
+// access is on or not. The token travels as ?share=<token> or in the
+// loki_share cookie the gallery page sets; a password-protected share also
+// needs the proof the gallery puts in that cookie, or the password in an
+// X-Share-Password header. Wrong passwords count against the login
+// throttle, per client address and per share. A view is opening the link —
+// loading the gallery, or a /media/file?share= download — and thumbnails,
+// streaming, and requests the gallery makes on the visitor's behalf don't
+// spend one.
+//
+// Tokens are a random nonce signed with the JWT secret, so a forged token is
+// rejected before any lookup and rotating the secret voids every link.
+// -----------------------------------------------------------------------------
+
+// shareCookie carries a redeemed share for the gallery's media requests.
+const shareCookie = "loki_share"
+
+// shareGalleryMax caps how many items the gallery page lists.
+const shareGalleryMax = 500
+
+// RegisterShareRoutes wires the share API and gallery onto mux. Managing
+// shares is admin-only: a share publishes media to anyone holding the link,
+// just as the public-access switch does.
+func RegisterShareRoutes(mux *http.ServeMux, deps *Dependencies) {
+	mux.HandleFunc("/api/shares", renderer.ApplyMiddlewares(sharesHandler(deps), renderer.RoleAdmin))
+	mux.HandleFunc("/api/shares/{id}", renderer.ApplyMiddlewares(shareHandler(deps), renderer.RoleAdmin))
+	mux.HandleFunc("/api/shares/{id}/log", renderer.ApplyMiddlewares(shareLogHandler(deps), renderer.RoleAdmin))
+	mux.HandleFunc("/s/{token}", renderer.ApplyMiddlewares(shareGalleryHandler(deps), renderer.RolePublic))
+}
+
+// shareItem is a share as the API reports it, with its gallery link.
+type shareItem struct {
+	media.Share
+	URL string `json:"url"`
+}
+
+func newShareItem(s media.Share) shareItem {
+	return shareItem{Share: s, URL: "/s/" + s.Token}
+}
+
+// newShareToken mints a signed share token: nonce "." signature.
+func newShareToken(deps *Dependencies) (string, error) {
+	b := make([]byte, 18)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	nonce := base64.RawURLEncoding.EncodeToString(b)
+	return nonce + "." + deps.Auth.Sign("share:"+nonce), nil
+}
+
+// shareByToken checks token's signature and looks up its share.
+func shareByToken(deps *Dependencies, token string) (media.Share, bool) {
+	nonce, sig, ok := strings.Cut(token, ".")
+	if !ok || deps.Auth == nil || !deps.Auth.VerifySigned("share:"+nonce, sig) {
+		return media.Share{}, false
+	}
+	s, found, err := media.GetShareByToken(deps.DB, token)
+	if err != nil {
+		log.Printf("[share] lookup: %v", err)
+	}
+	return s, found
+}
+
+// shareState is why s no longer redeems ("" while it does).
+func shareState(s media.Share) string {
+	switch {
+	case s.RevokedAt != 0:
+		return "revoked"
+	case s.ExpiresAt != 0 && time.Now().Unix() >= s.ExpiresAt:
+		return "expired"
+	case s.MaxViews > 0 && s.Views >= s.MaxViews:
+		return "used up"
+	}
+	return ""
+}
+
+// sharePasswordProof is what the gallery stores in the cookie once the
+// visitor has given s's password. Changing the password voids it.
+func sharePasswordProof(deps *Dependencies, s media.Share) string {
+	return deps.Auth.Sign("share-password:" + s.Token + ":" + s.PasswordHash)
+}
+
+// Share password guesses are limited per client address and per share, just
+// as sign-ins are per address and per username, but on throttles of their
+// own: a visitor mistyping a share password never locks their address out
+// of /auth/login, nor the reverse.
+var (
+	shareIPThrottle       = auth.NewThrottle(20, 30*time.Second, time.Hour)
+	sharePasswordThrottle = auth.NewThrottle(5, 30*time.Second, time.Hour)
+)
+
+// shareThrottleKey is s's slot in sharePasswordThrottle, which is also its
+// audit target.
+func shareThrottleKey(s media.Share) string {
+	return shareTarget(s.ID)
+}
+
+// sharePasswordWait is how long r's client must wait before guessing at
+// s's password again.
+func sharePasswordWait(r *http.Request, s media.Share) time.Duration {
+	return guessWait(shareIPThrottle, sharePasswordThrottle, r, shareThrottleKey(s))
+}
+
+// sharePasswordFailed records a wrong password for s, logs it, and audits
+// it as auth.login_failed via "share".
+func sharePasswordFailed(deps *Dependencies, r *http.Request, s media.Share) {
+	key := shareThrottleKey(s)
+	ip, lockout := guessFailed(shareIPThrottle, sharePasswordThrottle, r, key)
+	after := map[string]any{"remote": ip, "via": "share", "reason": "invalid share password"}
+	if lockout > 0 {
+		after["lockout_seconds"] = int(lockout / time.Second)
+		log.Printf("[auth] failed share password for %s from %s; locked out for %s", key, ip, lockout)
+	} else {
+		log.Printf("[auth] failed share password for %s from %s", key, ip)
+	}
+	auditLog(deps, r, "auth.login_failed", []string{key}, nil, after)
+}
+
+// sharePasswordOK reports whether r has unlocked s: no password, a valid
+// cookie proof, or the password in X-Share-Password. A header attempt is
+// refused unchecked while r's client or the share is locked out.
+func sharePasswordOK(deps *Dependencies, r *http.Request, s media.Share, proof string) bool {
+	if !s.HasPassword {
+		return true
+	}
+	if proof != "" && deps.Auth.VerifySigned("share-password:"+s.Token+":"+s.PasswordHash, proof) {
+		return true
+	}
+	if pw := r.Header.Get("X-Share-Password"); pw != "" {
+		return checkSharePassword(deps, r, s, pw)
+	}
+	return false
+}
+
+// checkSharePassword checks pw against s, refusing without a check while
+// r's client or the share is locked out, and records the outcome.
+func checkSharePassword(deps *Dependencies, r *http.Request, s media.Share, pw string) bool {
+	if sharePasswordWait(r, s) > 0 {
+		return false
+	}
+	if !auth.CheckSecret(s.PasswordHash, pw) {
+		sharePasswordFailed(deps, r, s)
+		return false
+	}
+	sharePasswordThrottle.Succeed(shareThrottleKey(s))
+	return true
+}
+
+func logShareAccess(deps *Dependencies, r *http.Request, s media.Share, path, outcome string) {
+	remote := ""
+	if ip := remoteIP(r); ip != nil {
+		remote = ip.String()
+	}
+	if err := media.LogShareAccess(deps.DB, media.ShareAccess{
+		ShareID: s.ID, Remote: remote, Route: r.URL.Path, Path: path, Outcome: outcome,
+	}); err != nil {
+		log.Printf("[share] %v", err)
+	}
+}
+
+// shareMemberSQL returns a query selecting a path column for every item s
+// holds.
+func shareMemberSQL(ctx context.Context, deps *Dependencies, s media.Share) (string, []any, error) {
+	switch s.Kind {
+	case media.ShareKindPath:
+		return `SELECT path FROM media WHERE path = ?`, []any{s.Target}, nil
+	case media.ShareKindTag:
+		return `SELECT DISTINCT t.media_path AS path FROM media_tag_by_category t
+			JOIN media m ON m.path = t.media_path WHERE t.tag_label = ?`, []any{s.Target}, nil
+	case media.ShareKindCollection:
+		id, _ := strconv.ParseInt(s.Target, 10, 64)
+		return `SELECT ci.media_path AS path FROM collection_item ci
+			JOIN media m ON m.path = ci.media_path WHERE ci.collection_id = ?`, []any{id}, nil
+	case media.ShareKindQuery:
+		return savedSearchSQL(ctx, deps.DB, media.SavedSearch{Name: "shared query", Query: s.Target}, nil)
+	case media.ShareKindSaved:
+		saved, found, err := lookupSavedSearch(deps.DB, s.Target)
+		if err != nil {
+			return "", nil, err
+		}
+		if !found {
+			return `SELECT path FROM media WHERE 0`, nil, nil
+		}
+		return savedSearchSQL(ctx, deps.DB, saved, nil)
+	}
+	return "", nil, fmt.Errorf("unknown share kind %q", s.Kind)
+}
+
+// shareHasPath reports whether path is currently one of s's items.
+func shareHasPath(ctx context.Context, deps *Dependencies, s media.Share, path string) bool {
+	querySQL, params, err := shareMemberSQL(ctx, deps, s)
+	if err != nil {
+		log.Printf("[share] %d: %v", s.ID, err)
+		return false
+	}
+	var one int
+	err = deps.DB.QueryRowContext(ctx, "SELECT 1 FROM ("+querySQL+") WHERE path = ? LIMIT 1",
+		append(params, path)...).Scan(&one)
+	return err == nil
+}
+
+// sharePaths lists s's items — a collection in its own order, anything else
+// by path — up to limit (0 = all).
+func sharePaths(ctx context.Context, deps *Dependencies, s media.Share, limit int) ([]string, error) {
+	querySQL, params, err := shareMemberSQL(ctx, deps, s)
+	if err != nil {
+		return nil, err
+	}
+	if s.Kind == media.ShareKindCollection {
+		querySQL += " ORDER BY ci.position, ci.media_path"
+	} else {
+		querySQL = "SELECT DISTINCT path FROM (" + querySQL + ") ORDER BY path"
+	}
+	if limit > 0 {
+		querySQL += " LIMIT " + strconv.Itoa(limit)
+	}
+	rows, err := deps.DB.QueryContext(ctx, querySQL, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var paths []string
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		paths = append(paths, p)
+	}
+	return paths, rows.Err()
+}
+
+const (
+	shareHLSCacheTTL = time.Minute
+	shareHLSCacheMax = 64
+)
+
+// shareHLSHashes caches, per share, the HLS cache directory names (see
+// hlsCacheDir) of its items. A player fetches a segment every few seconds;
+// rebuilding the set each time would rerun the share's query and hash every
+// path per segment. Membership can change (a tag or query share), so entries
+// expire after shareHLSCacheTTL.
+var shareHLSHashes = struct {
+	sync.Mutex
+	m map[int64]shareHLSEntry
+}{m: map[int64]shareHLSEntry{}}
+
+type shareHLSEntry struct {
+	hashes  map[string]bool
+	builtAt time.Time
+	// db is the handle the set was built from; a DB hot-swap invalidates it.
+	db *sql.DB
+}
+
+// shareHasHLSHash reports whether one of s's items streams from the HLS
+// cache directory named hash. The lock is held across a rebuild so a
+// player's concurrent segment requests don't each rerun the query.
+func shareHasHLSHash(ctx context.Context, deps *Dependencies, s media.Share, hash string) bool {
+	shareHLSHashes.Lock()
+	defer shareHLSHashes.Unlock()
+	db := deps.DB
+	if e, ok := shareHLSHashes.m[s.ID]; ok && e.db == db && time.Since(e.builtAt) < shareHLSCacheTTL {
+		return e.hashes[hash]
+	}
+	paths, err := sharePaths(ctx, deps, s, 0)
+	if err != nil {
+		log.Printf("[share] %d: %v", s.ID, err)
+		return false
+	}
+	hashes := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		hashes[fmt.Sprintf("%x", sha256.Sum256([]byte(p)))] = true
+	}
+	if len(shareHLSHashes.m) >= shareHLSCacheMax {
+		for id, e := range shareHLSHashes.m {
+			if time.Since(e.builtAt) >= shareHLSCacheTTL || e.db != db {
+				delete(shareHLSHashes.m, id)
+			}
+		}
+		if len(shareHLSHashes.m) >= shareHLSCacheMax {
+			oldest, first := int64(0), true
+			var oldestAt time.Time
+			for id, e := range shareHLSHashes.m {
+				if first || e.builtAt.Before(oldestAt) {
+					oldest, oldestAt, first = id, e.builtAt, false
+				}
+			}
+			delete(shareHLSHashes.m, oldest)
+		}
+	}
+	shareHLSHashes.m[s.ID] = shareHLSEntry{hashes: hashes, builtAt: time.Now(), db: db}
+	return hashes[hash]
+}
+
+// shareKey marks a request admitted by a share link.
+type shareKey struct{}
+
+// requestShare is the share that admitted r, if any.
+func requestShare(r *http.Request) *media.Share {
+	s, _ := r.Context().Value(shareKey{}).(*media.Share)
+	return s
+}
+
+// shareTokenFrom finds the share token on r: ?share= (fromLink) or the
+// gallery cookie, which may carry a password proof after a "~".
+func shareTokenFrom(r *http.Request) (token, proof string, fromLink bool) {
+	if t := r.URL.Query().Get("share"); t != "" {
+		return t, "", true
+	}
+	c, err := r.Cookie(shareCookie)
+	if err != nil {
+		return "", "", false
+	}
+	token, proof, _ = strings.Cut(c.Value, "~")
+	return token, proof, false
+}
+
+// shareMediaPath is the media item a share-redeemable request is for: the
+// path parameter, or "" plus the cache hash for an HLS playlist/segment.
+// ok is false for routes shares don't cover.
+func shareMediaPath(r *http.Request) (path, hlsHash string, ok bool) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return "", "", false
+	}
+	switch r.URL.Path {
+	case "/media/file", "/media/thumbnail":
+		p, err := url.PathUnescape(getRawQueryParam(r.URL.RawQuery, "path"))
+		return strings.TrimSpace(p), "", err == nil && p != ""
+	case "/media/hls":
+		p := r.URL.Query().Get("path")
+		return p, "", p != ""
+	}
+	if rest, found := strings.CutPrefix(r.URL.Path, "/media/hls/"); found {
+		hash, _, _ := strings.Cut(rest, "/")
+		return "", hash, hash != ""
+	}
+	return "", "", false
+}
+
+// shareRequest admits r through a share link when it carries a live token
+// for a media route shares cover and asks for one of the share's items. It
+// spends a view for a /media/file?share= download and logs views and
+// refusals. authMiddleware calls it for RolePublicRead routes.
+func shareRequest(deps *Dependencies, r *http.Request) (*http.Request, bool) {
+	token, proof, fromLink := shareTokenFrom(r)
+	if token == "" || deps.DB == nil {
+		return r, false
+	}
+	path, hlsHash, ok := shareMediaPath(r)
+	if !ok {
+		return r, false
+	}
+	s, found := shareByToken(deps, token)
+	if !found {
+		return r, false
+	}
+	if state := shareState(s); state != "" {
+		logShareAccess(deps, r, s, path, "denied: "+state)
+		return r, false
+	}
+	if !sharePasswordOK(deps, r, s, proof) {
+		logShareAccess(deps, r, s, path, "denied: password")
+		return r, false
+	}
+	member := false
+	if hlsHash != "" {
+		member = shareHasHLSHash(r.Context(), deps, s, hlsHash)
+	} else {
+		member = shareHasPath(r.Context(), deps, s, path)
+	}
+	if !member {
+		logShareAccess(deps, r, s, path, "denied: not shared")
+		return r, false
+	}
+	if fromLink && r.URL.Path == "/media/file" && (r.Header.Get("Range") == "" || strings.HasPrefix(r.Header.Get("Range"), "bytes=0-")) {
+		counted, err := media.CountShareView(deps.DB, s.ID)
+		if err != nil || !counted {
+			logShareAccess(deps, r, s, path, "denied: used up")
+			return r, false
+		}
+		logShareAccess(deps, r, s, path, "view")
+	}
+	return r.WithContext(context.WithValue(r.Context(), shareKey{}, &s)), true
+}
+
+func sharesHandler(deps *Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			list, err := media.ListShares(deps.DB)
+			if err != nil {
+				httpError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			items := make([]shareItem, 0, len(list))
+			for _, s := range list {
+				items = append(items, newShareItem(s))
+			}
+			writeJSON(w, items)
+		case http.MethodPost:
+			var req struct {
+				Kind      string `json:"kind"`
+				Target    string `json:"target"`
+				Title     string `json:"title"`
+				Password  string `json:"password"`
+				ExpiresAt int64  `json:"expiresAt"`
+				MaxViews  int    `json:"maxViews"`
+			}
+			if err := readJSON(r, &req); err != nil {
+				httpError(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			s := media.Share{
+				Kind: strings.ToLower(strings.TrimSpace(req.Kind)), Target: strings.TrimSpace(req.Target),
+				Title: req.Title, ExpiresAt: req.ExpiresAt, MaxViews: req.MaxViews,
+				CreatedBy: requestUsername(deps, r),
+			}
+			if err := resolveShareTarget(deps, &s); err != nil {
+				httpError(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if req.Password != "" {
+				hash, err := auth.HashSecret(req.Password)
+				if err != nil {
+					httpError(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				s.PasswordHash = hash
+			}
+			token, err := newShareToken(deps)
+			if err != nil {
+				httpError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			s.Token = token
+			created, err := media.CreateShare(deps.DB, s)
+			if err != nil {
+				httpError(w, err.Error(), userErrorStatus(err))
+				return
+			}
+			auditLog(deps, r, "share.create", []string{shareTarget(created.ID)}, nil, map[string]any{
+				"kind": created.Kind, "target": created.Target, "expiresAt": created.ExpiresAt,
+				"maxViews": created.MaxViews, "password": created.HasPassword,
+			})
+			writeJSON(w, newShareItem(created))
+		default:
+			httpError(w, "use GET or POST", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// resolveShareTarget checks s's target exists and pins saved searches and
+// collections by ID, so renaming one doesn't break its links. The title
+// defaults to something a visitor can read.
+func resolveShareTarget(deps *Dependencies, s *media.Share) error {
+	title := s.Target
+	switch s.Kind {
+	case media.ShareKindPath:
+		if !mediaRowExists(deps, s.Target) {
+			return fmt.Errorf("path must be a library item (got %q)", s.Target)
+		}
+		title = filepath.Base(s.Target)
+	case media.ShareKindQuery:
+		if _, err := media.NewParser(s.Target).Parse(); err != nil {
+			return fmt.Errorf("invalid query %q: %w", s.Target, err)
+		}
+	case media.ShareKindSaved:
+		saved, found, err := lookupSavedSearch(deps.DB, s.Target)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("saved search %q not found", s.Target)
+		}
+		s.Target, title = strconv.FormatInt(saved.ID, 10), saved.Name
+	case media.ShareKindCollection:
+		c, found, err := lookupCollection(deps.DB, s.Target)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("collection %q not found", s.Target)
+		}
+		s.Target, title = strconv.FormatInt(c.ID, 10), c.Name
+	}
+	if strings.TrimSpace(s.Title) == "" {
+		s.Title = title
+	}
+	return nil
+}
+
+// shareFromPath resolves {id}, answering 400/404/500 itself when it can't.
+func shareFromPath(w http.ResponseWriter, r *http.Request, deps *Dependencies) (media.Share, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		httpError(w, "share id must be a number", http.StatusBadRequest)
+		return media.Share{}, false
+	}
+	s, found, err := media.GetShareByID(deps.DB, id)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusInternalServerError)
+		return s, false
+	}
+	if !found {
+		httpError(w, "share not found", http.StatusNotFound)
+		return s, false
+	}
+	return s, true
+}
+
+func shareHandler(deps *Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s, ok := shareFromPath(w, r, deps)
+		if !ok {
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, newShareItem(s))
+		case http.MethodDelete:
+			if _, err := media.RevokeShare(deps.DB, s.ID); err != nil {
+				httpError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			auditLog(deps, r, "share.revoke", []string{shareTarget(s.ID)}, nil, nil)
+			writeJSON(w, map[string]any{"revoked": s.ID})
+		default:
+			httpError(w, "use GET or DELETE", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func shareLogHandler(deps *Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "use GET", http.StatusMethodNotAllowed)
+			return
+		}
+		s, ok := shareFromPath(w, r, deps)
+		if !ok {
+			return
+		}
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		entries, err := media.ShareAccessLog(deps.DB, s.ID, limit)
+		if err != nil {
+			httpError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if entries == nil {
+			entries = []media.ShareAccess{}
+		}
+		writeJSON(w, entries)
+	}
+}
+
+// shareMediaURL builds a media route URL for path. Spaces are escaped as
+// %20, not "+": the media handlers decode with PathUnescape.
+func shareMediaURL(route, path string) string {
+	return route + "?path=" + strings.ReplaceAll(url.QueryEscape(path), "+", "%20")
+}
+
+// shareGalleryItem is one tile on the gallery page.
+type shareGalleryItem struct {
+	Name, File, Thumb string
+	Video             bool
+}
+
+// shareGalleryHandler implements /s/{token}: GET renders the gallery (or the
+// password form), POST checks the password. Opening the gallery spends a
+// view and hands the visitor the loki_share cookie its media requests use.
+func shareGalleryHandler(deps *Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		page := map[string]any{}
+		render := func(code int) {
+			w.WriteHeader(code)
+			_ = shareGalleryPage.Execute(w, page)
+		}
+
+		s, found := shareByToken(deps, r.PathValue("token"))
+		if !found {
+			page["Message"] = "This link is not valid."
+			render(http.StatusNotFound)
+			return
+		}
+		page["Title"] = s.Title
+		if state := shareState(s); state != "" {
+			logShareAccess(deps, r, s, "", "denied: "+state)
+			page["Message"] = "This link has " + map[string]string{
+				"revoked": "been revoked", "expired": "expired", "used up": "reached its view limit",
+			}[state] + "."
+			render(http.StatusGone)
+			return
+		}
+
+		proof := ""
+		if c, err := r.Cookie(shareCookie); err == nil {
+			if token, p, _ := strings.Cut(c.Value, "~"); token == s.Token {
+				proof = p
+			}
+		}
+		if r.Method == http.MethodPost {
+			if !sameOriginPost(r) {
+				http.Error(w, "Cross-origin request rejected", http.StatusForbidden)
+				return
+			}
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, "Invalid form", http.StatusBadRequest)
+				return
+			}
+			if wait := sharePasswordWait(r, s); wait > 0 {
+				logShareAccess(deps, r, s, "", "denied: locked out")
+				w.Header().Set("Retry-After", strconv.Itoa(int((wait+time.Second-1)/time.Second)))
+				page["NeedPassword"], page["LockedOut"] = true, true
+				render(http.StatusTooManyRequests)
+				return
+			}
+			if !s.HasPassword || !checkSharePassword(deps, r, s, r.PostForm.Get("password")) {
+				logShareAccess(deps, r, s, "", "denied: password")
+				page["NeedPassword"], page["BadPassword"] = true, true
+				render(http.StatusUnauthorized)
+				return
+			}
+			setShareCookie(w, s, s.Token+"~"+sharePasswordProof(deps, s))
+			http.Redirect(w, r, "/s/"+s.Token, http.StatusSeeOther)
+			return
+		} else if r.Method != http.MethodGet {
+			http.Error(w, "Use GET or POST", http.StatusMethodNotAllowed)
+			return
+		}
+		if !sharePasswordOK(deps, r, s, proof) {
+			page["NeedPassword"] = true
+			render(http.StatusOK)
+			return
+		}
+
+		if counted, err := media.CountShareView(deps.DB, s.ID); err != nil || !counted {
+			logShareAccess(deps, r, s, "", "denied: used up")
+			page["Message"] = "This link has reached its view limit."
+			render(http.StatusGone)
+			return
+		}
+		logShareAccess(deps, r, s, "", "view")
+		cookie := s.Token
+		if s.HasPassword {
+			cookie += "~" + sharePasswordProof(deps, s)
+		}
+		setShareCookie(w, s, cookie)
+
+		paths, err := sharePaths(r.Context(), deps, s, shareGalleryMax)
+		if err != nil {
+			log.Printf("[share] %d: %v", s.ID, err)
+		}
+		items := make([]shareGalleryItem, 0, len(paths))
+		for _, p := range paths {
+			items = append(items, shareGalleryItem{
+				Name:  filepath.Base(p),
+				File:  shareMediaURL("/media/file", p),
+				Thumb: shareMediaURL("/media/thumbnail", p),
+				Video: mediaext.IsVideo(p),
+			})
+		}
+		page["Items"] = items
+		render(http.StatusOK)
+	}
+}
+
+// setShareCookie scopes the cookie to the media routes and lets it lapse
+// with the share.
+func setShareCookie(w http.ResponseWriter, s media.Share, value string) {
+	c := &http.Cookie{
+		Name: shareCookie, Value: value, Path: "/media",
+		HttpOnly: true, SameSite: http.SameSiteLaxMode,
+	}
+	if s.ExpiresAt != 0 {
+		c.Expires = time.Unix(s.ExpiresAt, 0)
+	}
+	http.SetCookie(w, c)
+}
+
+var shareGalleryPage = template.Must(template.New("share").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>{{if .Title}}{{.Title}} – {{end}}Shared from Lowkey Media Server</title>
+<style>
+  body { margin:0; background:#101216; color:#d7dae0; font:14px/1.5 "Segoe UI", system-ui, sans-serif; }
+  header { padding:20px 24px 8px; }
+  h1 { font-size:18px; margin:0; }
+  p { color:#9aa0ab; }
+  .grid { display:grid; grid-template-columns:repeat(auto-fill, minmax(200px, 1fr)); gap:10px; padding:16px 24px 32px; }
+  .grid a, .grid video { display:block; width:100%; aspect-ratio:1; background:#1a1d23; border-radius:8px; overflow:hidden; }
+  .grid img { width:100%; height:100%; object-fit:cover; }
+  .card { width:min(380px, calc(100vw - 32px)); margin:15vh auto; background:#1a1d23; border:1px solid #30343e;
+          border-radius:12px; padding:28px; }
+  input, button { width:100%; box-sizing:border-box; padding:10px; border-radius:8px; border:1px solid #30343e;
+                  background:#262a33; color:#d7dae0; font-size:13px; margin-top:10px; }
+  button { background:#00d4aa; border-color:#00d4aa; color:#00281f; font-weight:600; cursor:pointer; }
+  .error { color:#ff6b6b; }
+</style>
+</head>
+<body>
+{{if .Message}}
+  <div class="card"><h1>{{if .Title}}{{.Title}}{{else}}Shared link{{end}}</h1><p>{{.Message}}</p></div>
+{{else if .NeedPassword}}
+  <div class="card">
+    <h1>{{.Title}}</h1>
+    <p>This link is password protected.</p>
+    {{if .LockedOut}}<p class="error">Too many wrong passwords. Try again later.</p>
+    {{else if .BadPassword}}<p class="error">Wrong password.</p>{{end}}
+    <form method="POST">
+      <input type="password" name="password" placeholder="Password" autofocus required>
+      <button type="submit">Open</button>
+    </form>
+  </div>
+{{else}}
+  <header><h1>{{.Title}}</h1><p>{{len .Items}} item{{if ne (len .Items) 1}}s{{end}}</p></header>
+  <div class="grid">
+  {{range .Items}}
+    {{if .Video}}<video src="{{.File}}" poster="{{.Thumb}}" controls preload="none" title="{{.Name}}"></video>
+    {{else}}<a href="{{.File}}" target="_blank" rel="noopener" title="{{.Name}}"><img src="{{.Thumb}}" alt="{{.Name}}" loading="lazy"></a>{{end}}
+  {{end}}
+  </div>
+{{end}}
+</body>
+</html>`))
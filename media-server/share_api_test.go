@@ -0,0 +1,304 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stevecastle/shrike/auth"
+	"github.com/stevecastle/shrike/media"
+	"github.com/stevecastle/shrike/renderer"
+)
+
+// newShareTestDeps is the saved-search library (a.jpg/b.jpg tagged cat,
+// c.png dog) plus one real file on disk for /media/file to serve.
+func newShareTestDeps(t *testing.T) (*Dependencies, string) {
+	t.Helper()
+	deps := newSavedSearchDB(t)
+	deps.Auth = auth.NewAuthService(deps.DB, "test-secret")
+	file := filepath.Join(t.TempDir(), "clip one.jpg")
+	if err := os.WriteFile(file, []byte("jpeg-bytes"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := deps.DB.Exec(`INSERT INTO media (path) VALUES (?)`, file); err != nil {
+		t.Fatal(err)
+	}
+	return deps, file
+}
+
+func createShare(t *testing.T, deps *Dependencies, body string) shareItem {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/shares", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	sharesHandler(deps)(rec, req)
+	var s shareItem
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &s) != nil {
+		t.Fatalf("create %s = %d %s", body, rec.Code, rec.Body)
+	}
+	return s
+}
+
+// getShared sends a GET through the real auth middleware with public access
+// off, as an anonymous JSON client.
+func getShared(deps *Dependencies, h http.HandlerFunc, target string, setup func(*http.Request)) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.Header.Set("Accept", "application/json")
+	if setup != nil {
+		setup(req)
+	}
+	rec := httptest.NewRecorder()
+	authMiddleware(deps, h, renderer.RolePublicRead).ServeHTTP(rec, req)
+	return rec
+}
+
+func TestShareRedeemsOnMediaFile(t *testing.T) {
+	deps, file := newShareTestDeps(t)
+	s := createShare(t, deps, `{"kind":"path","target":`+strconv.Quote(file)+`,"maxViews":1}`)
+	if s.Title != "clip one.jpg" || s.URL != "/s/"+s.Token {
+		t.Errorf("share = %+v", s)
+	}
+	fileURL := shareMediaURL("/media/file", file)
+
+	if rec := getShared(deps, mediaFileHandler(deps), fileURL, nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("no token: status = %d, want 401", rec.Code)
+	}
+	rec := getShared(deps, mediaFileHandler(deps), fileURL+"&share="+s.Token, nil)
+	if rec.Code != http.StatusOK || rec.Body.String() != "jpeg-bytes" {
+		t.Fatalf("shared download = %d %q", rec.Code, rec.Body)
+	}
+	// The one view is spent.
+	if rec := getShared(deps, mediaFileHandler(deps), fileURL+"&share="+s.Token, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("second download: status = %d, want 401", rec.Code)
+	}
+	// Forged tokens never reach the DB lookup.
+	nonce, _, _ := strings.Cut(s.Token, ".")
+	if rec := getShared(deps, mediaFileHandler(deps), fileURL+"&share="+nonce+".forged", nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("forged token: status = %d, want 401", rec.Code)
+	}
+
+	entries, err := media.ShareAccessLog(deps.DB, s.ID, 0)
+	if err != nil || len(entries) != 2 || entries[0].Outcome != "denied: used up" || entries[1].Outcome != "view" || entries[1].Path != file {
+		t.Errorf("access log = %+v, %v", entries, err)
+	}
+}
+
+func TestShareMembershipAndRevocation(t *testing.T) {
+	deps, _ := newShareTestDeps(t)
+	s := createShare(t, deps, `{"kind":"tag","target":"cat"}`)
+	thumb := func(path string) int {
+		// A stub stands in for the thumbnailer: 200 means the share
+		// admitted the request and the handler's read gate agrees.
+		return getShared(deps, func(w http.ResponseWriter, r *http.Request) {
+			if requestShare(r) == nil || !mediaReadAllowed(deps, r, path) {
+				w.WriteHeader(http.StatusTeapot)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}, shareMediaURL("/media/thumbnail", path)+"&share="+s.Token, nil).Code
+	}
+	if got := thumb("a.jpg"); got != http.StatusOK {
+		t.Errorf("tagged item: status = %d, want 200", got)
+	}
+	if got := thumb("c.png"); got != http.StatusUnauthorized {
+		t.Errorf("untagged item: status = %d, want 401", got)
+	}
+	paths, err := sharePaths(t.Context(), deps, s.Share, 0)
+	if err != nil || len(paths) != 2 {
+		t.Errorf("sharePaths = %v, %v", paths, err)
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodDelete, "/api/shares/x", nil)
+	req.SetPathValue("id", strconv.FormatInt(s.ID, 10))
+	shareHandler(deps)(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("revoke = %d %s", rec.Code, rec.Body)
+	}
+	if got := thumb("a.jpg"); got != http.StatusUnauthorized {
+		t.Errorf("revoked share: status = %d, want 401", got)
+	}
+}
+
+func TestShareGalleryPassword(t *testing.T) {
+	deps, file := newShareTestDeps(t)
+	resetLoginThrottles(t)
+	s := createShare(t, deps, `{"kind":"path","target":`+strconv.Quote(file)+`,"password":"hunter2","title":"Holiday"}`)
+	gallery := func(method, body string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/s/"+s.Token, strings.NewReader(body))
+		req.SetPathValue("token", s.Token)
+		if body != "" {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		rec := httptest.NewRecorder()
+		shareGalleryHandler(deps)(rec, req)
+		return rec
+	}
+
+	rec := gallery(http.MethodGet, "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `type="password"`) {
+		t.Fatalf("locked gallery = %d %s", rec.Code, rec.Body)
+	}
+	if rec := gallery(http.MethodPost, "password=wrong"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("wrong password: status = %d, want 401", rec.Code)
+	}
+	rec = gallery(http.MethodPost, "password=hunter2")
+	if rec.Code != http.StatusSeeOther || len(rec.Result().Cookies()) != 1 {
+		t.Fatalf("right password = %d, cookies %v", rec.Code, rec.Result().Cookies())
+	}
+	cookie := rec.Result().Cookies()[0]
+
+	rec = gallery(http.MethodGet, "", cookie)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Holiday") || !strings.Contains(rec.Body.String(), "clip%20one.jpg") {
+		t.Fatalf("unlocked gallery = %d %s", rec.Code, rec.Body)
+	}
+
+	// The gallery's own media requests ride on the cookie and spend no view.
+	fileURL := shareMediaURL("/media/file", file)
+	for i := 0; i < 2; i++ {
+		if rec := getShared(deps, mediaFileHandler(deps), fileURL, func(r *http.Request) { r.AddCookie(cookie) }); rec.Code != http.StatusOK {
+			t.Fatalf("cookie download %d = %d", i, rec.Code)
+		}
+	}
+	// ?share= alone isn't enough for a password-protected share...
+	if rec := getShared(deps, mediaFileHandler(deps), fileURL+"&share="+s.Token, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("no password: status = %d, want 401", rec.Code)
+	}
+	// ...but the password header is.
+	rec = getShared(deps, mediaFileHandler(deps), fileURL+"&share="+s.Token, func(r *http.Request) {
+		r.Header.Set("X-Share-Password", "hunter2")
+	})
+	if rec.Code != http.StatusOK {
+		t.Errorf("password header: status = %d, want 200", rec.Code)
+	}
+	if got, _, _ := media.GetShareByID(deps.DB, s.ID); got.Views != 2 {
+		t.Errorf("views = %d, want 2 (gallery + header download)", got.Views)
+	}
+}
+
+func TestSharePasswordLocksOut(t *testing.T) {
+	deps, file := newShareTestDeps(t)
+	resetLoginThrottles(t)
+	s := createShare(t, deps, `{"kind":"path","target":`+strconv.Quote(file)+`,"password":"hunter2"}`)
+	post := func(password string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/s/"+s.Token, strings.NewReader("password="+password))
+		req.SetPathValue("token", s.Token)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		shareGalleryHandler(deps)(rec, req)
+		return rec
+	}
+
+	for i := 0; i < 6; i++ {
+		if rec := post("guess"); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: status = %d", i+1, rec.Code)
+		}
+	}
+	rec := post("hunter2")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("locked out: status = %d, Retry-After %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	// The header path shares the lockout.
+	rec = getShared(deps, mediaFileHandler(deps), shareMediaURL("/media/file", file)+"&share="+s.Token, func(r *http.Request) {
+		r.Header.Set("X-Share-Password", "hunter2")
+	})
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("header while locked out: status = %d, want 401", rec.Code)
+	}
+}
+
+// TestSharePasswordThrottleIsSeparate: share password guesses and sign-ins
+// from one address count against different throttles.
+func TestSharePasswordThrottleIsSeparate(t *testing.T) {
+	deps, file := newShareTestDeps(t)
+	resetLoginThrottles(t)
+	if err := deps.Auth.Register("steve", "pw"); err != nil {
+		t.Fatal(err)
+	}
+	s := createShare(t, deps, `{"kind":"path","target":`+strconv.Quote(file)+`,"password":"hunter2"}`)
+	got, _, _ := media.GetShareByID(deps.DB, s.ID)
+	req := httptest.NewRequest(http.MethodGet, "/s/"+s.Token, nil)
+
+	for i := 0; i < 30; i++ {
+		sharePasswordFailed(deps, req, got)
+	}
+	if sharePasswordWait(req, got) == 0 {
+		t.Fatal("share guesses never locked out")
+	}
+	if rec := postLogin(deps, `{"username":"steve","password":"pw"}`); rec.Code != http.StatusOK {
+		t.Errorf("login after share guesses = %d %s", rec.Code, rec.Body)
+	}
+
+	resetLoginThrottles(t)
+	for i := 0; i < 30; i++ {
+		loginFailed(deps, req, "login", "steve", "invalid credentials")
+	}
+	if loginWait(req, "steve") == 0 {
+		t.Fatal("sign-in guesses never locked out")
+	}
+	if w := sharePasswordWait(req, got); w != 0 {
+		t.Errorf("share password waits %v after sign-in guesses", w)
+	}
+}
+
+func TestShareHasHLSHash(t *testing.T) {
+	deps, file := newShareTestDeps(t)
+	s := createShare(t, deps, `{"kind":"tag","target":"cat"}`)
+	got, _, _ := media.GetShareByID(deps.DB, s.ID)
+	ctx := context.Background()
+	hash := func(p string) string { return filepath.Base(hlsCacheDir("", p)) }
+
+	if !shareHasHLSHash(ctx, deps, got, hash("a.jpg")) {
+		t.Error("a.jpg is tagged cat but its HLS hash was refused")
+	}
+	if shareHasHLSHash(ctx, deps, got, hash(file)) {
+		t.Error("an item outside the share was admitted")
+	}
+	// The set is cached; a fresh entry picks up membership changes.
+	if _, err := deps.DB.Exec(`INSERT INTO media_tag_by_category (media_path, tag_label, category_label) VALUES (?, 'cat', '')`, file); err != nil {
+		t.Fatal(err)
+	}
+	shareHLSHashes.Lock()
+	delete(shareHLSHashes.m, got.ID)
+	shareHLSHashes.Unlock()
+	if !shareHasHLSHash(ctx, deps, got, hash(file)) {
+		t.Error("newly tagged item refused after the cache entry lapsed")
+	}
+}
+
+func TestSharesHandlerValidation(t *testing.T) {
+	deps, _ := newShareTestDeps(t)
+	for _, body := range []string{
+		`{"kind":"path","target":"/etc/passwd"}`,
+		`{"kind":"album","target":"x"}`,
+		`{"kind":"saved","target":"nope"}`,
+		`{"kind":"collection","target":"nope"}`,
+		`{"kind":"query","target":"(tag:cat"}`,
+		`{"kind":"tag","target":""}`,
+		`{"kind":"tag","target":"cat","maxViews":-1}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/shares", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		sharesHandler(deps)(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400 (%s)", body, rec.Code, rec.Body)
+		}
+	}
+
+	saveSearch(t, deps, media.SavedSearch{Name: "Cats", Query: "tag:cat"})
+	s := createShare(t, deps, `{"kind":"saved","target":"cats"}`)
+	if s.Title != "Cats" || s.Target == "cats" {
+		t.Errorf("saved share should pin the search by ID: %+v", s)
+	}
+	if ok := shareHasPath(t.Context(), deps, s.Share, "b.jpg"); !ok {
+		t.Error("saved share should hold b.jpg")
+	}
+}
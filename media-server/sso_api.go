@@ -0,0 +1,356 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stevecastle/shrike/appconfig"
+	"github.com/stevecastle/shrike/auth"
+	"github.com/stevecastle/shrike/renderer"
+)
+
+// -----------------------------------------------------------------------------
+// Single sign-on
+//
+// Two optional modes next to password login, configured under "sso":
+//
+//   - Trusted header: a reverse proxy that has already authenticated the
+//     user passes their name (and optionally groups) in a header. Requests
+//     from a trusted proxy address carrying it get a session cookie minted
+//     on the spot (trustedHeaderSession, run first in authMiddleware).
+//   - OIDC: /auth/oidc/login sends the browser to the identity provider
+//     (authorization code + PKCE); /auth/oidc/callback verifies the result
+//     and sets the session cookie.
+//
+// Both provision the account on first sign-in, linked to the identity (the
+// proxy's user name, or the OIDC issuer and subject), and set its role from
+// the identity's groups; both end in the same auth_token cookie password
+// login sets, so nothing downstream knows the difference. An existing
+// password account is never taken over by a matching name: an admin links
+// it by setting its ssoSubject (PUT /auth/users), which the refusal logs.
+// -----------------------------------------------------------------------------
+
+// oidcFlowTTL bounds how long a sign-in may sit at the identity provider.
+const oidcFlowTTL = 10 * time.Minute
+
+// RegisterSSORoutes wires the OIDC endpoints and the login page's probe.
+func RegisterSSORoutes(mux *http.ServeMux, deps *Dependencies) {
+	mux.HandleFunc("/auth/sso", renderer.ApplyMiddlewares(ssoInfoHandler(), renderer.RolePublic))
+	mux.HandleFunc("/auth/oidc/login", renderer.ApplyMiddlewares(oidcLoginHandler(deps), renderer.RolePublic))
+	mux.HandleFunc("/auth/oidc/callback", renderer.ApplyMiddlewares(oidcCallbackHandler(deps), renderer.RolePublic))
+}
+
+// setSessionCookie sets the browser session cookie, as password login does.
+func setSessionCookie(w http.ResponseWriter, token string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     "auth_token",
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		Expires:  time.Now().Add(24 * time.Hour),
+	})
+}
+
+// ssoSignIn maps groups to a role, signs in the account linked to subject
+// (provisioning username for a new identity), and returns its session token
+// and name. via names the mode ("proxy", "oidc").
+func ssoSignIn(deps *Dependencies, r *http.Request, via, subject, username string, groups []string) (string, string, error) {
+	cfg := appconfig.Get().SSO
+	role, err := auth.MapRole(groups, cfg.RoleGroups, cfg.DefaultRole)
+	if err != nil {
+		return "", "", err
+	}
+	token, account, created, err := deps.Auth.SSOLogin(subject, username, role)
+	if errors.Is(err, auth.ErrSSONotLinked) {
+		log.Printf("[auth] %s sign-in as %q refused: password account not linked; to link it, set its ssoSubject to %q", via, username, subject)
+	}
+	if err != nil {
+		return "", "", err
+	}
+	if created {
+		log.Printf("[auth] %s sign-in created user %q (%s)", via, account, role)
+		auditLog(deps, r, "user.create", []string{"user:" + account}, nil,
+			map[string]any{"role": role, "via": via, "ssoSubject": subject})
+	}
+	loginSucceeded(account)
+	return token, account, nil
+}
+
+// proxySubject is the identity a trusted proxy vouches for: only a name.
+func proxySubject(username string) string {
+	return "proxy:" + username
+}
+
+// trustedHeaderSession turns a trusted proxy's user header into a session:
+// when r comes from a configured proxy address and names a user its cookie
+// doesn't already belong to, it mints that user's token, sets the cookie on
+// w, and returns r carrying it so the rest of the middleware sees an
+// ordinary signed-in request. Otherwise r is returned unchanged — the
+// header from anywhere else is ignored.
+func trustedHeaderSession(deps *Dependencies, w http.ResponseWriter, r *http.Request) *http.Request {
+	cfg := appconfig.Get().SSO
+	if cfg.TrustedHeader == "" || deps.Auth == nil {
+		return r
+	}
+	_, nets, err := auth.ParseCIDRs(cfg.TrustedProxies)
+	if err != nil {
+		return r
+	}
+	th := auth.TrustedHeader{UserHeader: cfg.TrustedHeader, GroupsHeader: cfg.TrustedGroupsHeader, Proxies: nets}
+	username, groups, ok := th.Identity(r, remoteIP(r))
+	if !ok {
+		return r
+	}
+	if c, err := r.Cookie("auth_token"); err == nil {
+		if claims, err := deps.Auth.VerifyToken(c.Value); err == nil {
+			// The session may be for an account an admin linked under
+			// another name.
+			if claims.Username == username {
+				return r
+			}
+			if subject, _ := deps.Auth.SSOSubject(claims.Username); subject == proxySubject(username) {
+				return r
+			}
+		}
+	}
+	token, _, err := ssoSignIn(deps, r, "proxy", proxySubject(username), username, groups)
+	if err != nil {
+		log.Printf("[auth] proxy sign-in for %q refused: %v", username, err)
+		return r
+	}
+	setSessionCookie(w, token)
+	signed := r.Clone(r.Context())
+	signed.Header.Del("Cookie")
+	for _, c := range r.Cookies() {
+		if c.Name != "auth_token" {
+			signed.AddCookie(c)
+		}
+	}
+	signed.AddCookie(&http.Cookie{Name: "auth_token", Value: token})
+	return signed
+}
+
+// ssoInfoHandler tells the login page which sign-in options to offer.
+func ssoInfoHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := appconfig.Get().SSO
+		writeJSON(w, map[string]bool{"oidc": cfg.OIDCEnabled(), "trustedHeader": cfg.TrustedHeader != ""})
+	}
+}
+
+// ---- OIDC ----
+
+type oidcFlow struct {
+	verifier string
+	nonce    string
+	callback string // redirect_uri sent to the IdP, repeated on exchange
+	next     string // where to land after signing in
+	expires  time.Time
+}
+
+var oidcFlows = struct {
+	sync.Mutex
+	m map[string]*oidcFlow
+}{m: map[string]*oidcFlow{}}
+
+var oidcProvider = struct {
+	sync.Mutex
+	key string
+	p   *auth.OIDCProvider
+}{}
+
+// currentOIDCProvider is the provider for the current config, rebuilt when
+// the config changes; nil while OIDC is off.
+func currentOIDCProvider() *auth.OIDCProvider {
+	cfg := appconfig.Get().SSO
+	if !cfg.OIDCEnabled() {
+		return nil
+	}
+	key := strings.Join([]string{cfg.OIDCIssuer, cfg.OIDCClientID, cfg.OIDCClientSecret,
+		strings.Join(cfg.OIDCScopes, " "), cfg.OIDCUsernameClaim, cfg.OIDCGroupsClaim}, "\x00")
+	oidcProvider.Lock()
+	defer oidcProvider.Unlock()
+	if oidcProvider.p == nil || oidcProvider.key != key {
+		oidcProvider.key = key
+		oidcProvider.p = auth.NewOIDCProvider(auth.OIDCConfig{
+			Issuer: cfg.OIDCIssuer, ClientID: cfg.OIDCClientID, ClientSecret: cfg.OIDCClientSecret,
+			Scopes: cfg.OIDCScopes, UsernameClaim: cfg.OIDCUsernameClaim, GroupsClaim: cfg.OIDCGroupsClaim,
+		}, nil)
+	}
+	return oidcProvider.p
+}
+
+// oidcCallbackURL is the redirect_uri registered with the IdP: configured,
+// or this server's callback as the browser reached it.
+func oidcCallbackURL(r *http.Request) string {
+	if u := strings.TrimSpace(appconfig.Get().SSO.OIDCRedirectURL); u != "" {
+		return u
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + "/auth/oidc/callback"
+}
+
+// localRedirect keeps a post-login destination on this server.
+func localRedirect(target string) string {
+	if !strings.HasPrefix(target, "/") || strings.HasPrefix(target, "//") || strings.HasPrefix(target, "/\\") {
+		return "/"
+	}
+	return target
+}
+
+func randURLToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// oidcLoginHandler implements GET /auth/oidc/login?redirect=/path: start a
+// flow and send the browser to the identity provider.
+func oidcLoginHandler(deps *Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Use GET", http.StatusMethodNotAllowed)
+			return
+		}
+		p := currentOIDCProvider()
+		if p == nil {
+			http.Error(w, "OIDC sign-in is not configured", http.StatusNotFound)
+			return
+		}
+		if wait := loginWait(r, ""); wait > 0 {
+			writeLockedOut(w, wait)
+			return
+		}
+		state, err1 := randURLToken(24)
+		verifier, err2 := randURLToken(32)
+		nonce, err3 := randURLToken(24)
+		if err := errors.Join(err1, err2, err3); err != nil {
+			http.Error(w, "Could not start sign-in", http.StatusInternalServerError)
+			return
+		}
+		sum := sha256.Sum256([]byte(verifier))
+		flow := &oidcFlow{verifier: verifier, nonce: nonce, callback: oidcCallbackURL(r),
+			next: localRedirect(r.URL.Query().Get("redirect")), expires: time.Now().Add(oidcFlowTTL)}
+		target, err := p.AuthURL(r.Context(), flow.callback, state, nonce, base64.RawURLEncoding.EncodeToString(sum[:]))
+		if err != nil {
+			log.Printf("[auth] oidc: %v", err)
+			http.Error(w, "Identity provider unavailable", http.StatusBadGateway)
+			return
+		}
+		now := time.Now()
+		oidcFlows.Lock()
+		for k, f := range oidcFlows.m {
+			if now.After(f.expires) {
+				delete(oidcFlows.m, k)
+			}
+		}
+		oidcFlows.m[state] = flow
+		oidcFlows.Unlock()
+		http.Redirect(w, r, target, http.StatusFound)
+	}
+}
+
+// oidcCallbackHandler implements GET /auth/oidc/callback: redeem the code,
+// verify the ID token, sign the user in, and continue to where they were
+// going.
+func oidcCallbackHandler(deps *Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Use GET", http.StatusMethodNotAllowed)
+			return
+		}
+		p := currentOIDCProvider()
+		if p == nil {
+			http.Error(w, "OIDC sign-in is not configured", http.StatusNotFound)
+			return
+		}
+		if wait := loginWait(r, ""); wait > 0 {
+			writeLockedOut(w, wait)
+			return
+		}
+		q := r.URL.Query()
+		oidcFlows.Lock()
+		flow := oidcFlows.m[q.Get("state")]
+		delete(oidcFlows.m, q.Get("state"))
+		oidcFlows.Unlock()
+		if flow == nil || time.Now().After(flow.expires) {
+			loginFailed(deps, r, "oidc", "", "unknown or expired state")
+			http.Error(w, "Sign-in expired or was not started here — try again", http.StatusBadRequest)
+			return
+		}
+		if e := q.Get("error"); e != "" {
+			http.Error(w, fmt.Sprintf("The identity provider refused sign-in: %s %s", e, q.Get("error_description")), http.StatusUnauthorized)
+			return
+		}
+		id, err := p.Exchange(r.Context(), flow.callback, q.Get("code"), flow.verifier, flow.nonce)
+		if err != nil {
+			loginFailed(deps, r, "oidc", "", err.Error())
+			http.Error(w, "Sign-in could not be verified", http.StatusUnauthorized)
+			return
+		}
+		token, _, err := ssoSignIn(deps, r, "oidc", id.Key(), id.Username, id.Groups)
+		if errors.Is(err, auth.ErrNoSSORole) {
+			loginFailed(deps, r, "oidc", id.Username, "no role for groups")
+			http.Error(w, "Your account has no access to this server", http.StatusForbidden)
+			return
+		} else if err != nil {
+			loginFailed(deps, r, "oidc", id.Username, err.Error())
+			http.Error(w, "Sign-in failed: "+err.Error(), http.StatusForbidden)
+			return
+		}
+		setSessionCookie(w, token)
+		http.Redirect(w, r, flow.next, http.StatusFound)
+	}
+}
+
+// mergeSSOConfig validates a posted SSO section, keeping the stored client
+// secret when the post carries the redaction placeholder.
+func mergeSSOConfig(in, stored appconfig.SSOConfig) (appconfig.SSOConfig, error) {
+	in.TrustedHeader = strings.TrimSpace(in.TrustedHeader)
+	in.TrustedGroupsHeader = strings.TrimSpace(in.TrustedGroupsHeader)
+	proxies, _, err := auth.ParseCIDRs(in.TrustedProxies)
+	if err != nil {
+		return stored, fmt.Errorf("sso.trustedProxies: %w", err)
+	}
+	in.TrustedProxies = proxies
+	if in.TrustedHeader != "" && len(proxies) == 0 {
+		return stored, errors.New("sso.trustedProxies is required with sso.trustedHeader — the header must only be believed from your proxy")
+	}
+	in.OIDCIssuer = strings.TrimSpace(in.OIDCIssuer)
+	if in.OIDCIssuer != "" {
+		u, err := url.Parse(in.OIDCIssuer)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return stored, fmt.Errorf("sso.oidcIssuer must be an http(s) URL (got %q)", in.OIDCIssuer)
+		}
+	}
+	in.OIDCClientID = strings.TrimSpace(in.OIDCClientID)
+	in.OIDCClientSecret = keepStoredIfRedacted(in.OIDCClientSecret, stored.OIDCClientSecret)
+	for group, role := range in.RoleGroups {
+		if _, err := auth.ParseRole(role); err != nil || strings.TrimSpace(role) == "" {
+			return stored, fmt.Errorf("sso.roleGroups[%q]: role must be viewer, curator, or admin", group)
+		}
+	}
+	if in.DefaultRole != "" {
+		role, err := auth.ParseRole(in.DefaultRole)
+		if err != nil {
+			return stored, fmt.Errorf("sso.defaultRole: %w", err)
+		}
+		in.DefaultRole = string(role)
+	}
+	return in, nil
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stevecastle/shrike/appconfig"
+	"github.com/stevecastle/shrike/auth"
+	"github.com/stevecastle/shrike/auth/oidctest"
+	"github.com/stevecastle/shrike/renderer"
+)
+
+func setSSOConfig(t *testing.T, sso appconfig.SSOConfig) {
+	t.Helper()
+	old := appconfig.Get()
+	cfg := old
+	cfg.SSO = sso
+	appconfig.Set(cfg)
+	t.Cleanup(func() { appconfig.Set(old) })
+}
+
+func sessionCookie(rec *httptest.ResponseRecorder) string {
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == "auth_token" {
+			return c.Value
+		}
+	}
+	return ""
+}
+
+func TestTrustedHeaderMintsSession(t *testing.T) {
+	deps := newAPIKeyTestDeps(t)
+	setSSOConfig(t, appconfig.SSOConfig{
+		TrustedHeader: "X-Forwarded-User", TrustedGroupsHeader: "X-Forwarded-Groups",
+		TrustedProxies: []string{"192.0.2.0/24"}, RoleGroups: map[string]string{"editors": "curator"}, DefaultRole: "viewer",
+	})
+	var seen string
+	h := authMiddleware(deps, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = requestUsername(deps, r)
+	}), renderer.RolePublicRead)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/media", nil) // RemoteAddr 192.0.2.1
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Forwarded-User", "alice")
+	req.Header.Set("X-Forwarded-Groups", "staff,editors")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || seen != "alice" {
+		t.Fatalf("proxied request: status %d, user %q", rec.Code, seen)
+	}
+	tok := sessionCookie(rec)
+	if claims, err := deps.Auth.VerifyToken(tok); err != nil || claims.Username != "alice" {
+		t.Fatalf("cookie = %q (%v)", tok, err)
+	}
+	if a, _ := deps.Auth.UserAccess("alice"); a.Role != auth.RoleCurator {
+		t.Errorf("role = %s, want curator", a.Role)
+	}
+
+	// The same header from outside the proxy range is ignored.
+	req = httptest.NewRequest(http.MethodGet, "/api/media", nil)
+	req.RemoteAddr = "203.0.113.9:5555"
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Forwarded-User", "steve")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized || sessionCookie(rec) != "" {
+		t.Errorf("untrusted address: status %d, cookie %q", rec.Code, sessionCookie(rec))
+	}
+}
+
+func TestTrustedHeaderNeedsAdminLinkForPasswordAccount(t *testing.T) {
+	deps := newAPIKeyTestDeps(t) // steve has a password
+	setSSOConfig(t, appconfig.SSOConfig{
+		TrustedHeader: "X-Forwarded-User", TrustedProxies: []string{"192.0.2.0/24"}, DefaultRole: "viewer",
+	})
+	proxied := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/media", nil)
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("X-Forwarded-User", "steve")
+		rec := httptest.NewRecorder()
+		authMiddleware(deps, ok200(), renderer.RolePublicRead).ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := proxied(); rec.Code != http.StatusUnauthorized || sessionCookie(rec) != "" {
+		t.Fatalf("unlinked password account: status %d, cookie %q", rec.Code, sessionCookie(rec))
+	}
+
+	body := strings.NewReader(`{"username":"steve","ssoSubject":"proxy:steve"}`)
+	req := httptest.NewRequest(http.MethodPut, "/auth/users", body)
+	req.AddCookie(&http.Cookie{Name: "auth_token", Value: loginToken(t, deps)})
+	rec := httptest.NewRecorder()
+	userManagementHandler(deps)(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("link = %d %s", rec.Code, rec.Body)
+	}
+
+	rec = proxied()
+	if claims, err := deps.Auth.VerifyToken(sessionCookie(rec)); rec.Code != http.StatusOK || err != nil || claims.Username != "steve" {
+		t.Fatalf("linked account: status %d, session %+v (%v)", rec.Code, claims, err)
+	}
+	// Linking leaves the admin's role alone despite the viewer default.
+	if a, _ := deps.Auth.UserAccess("steve"); a.Role != auth.RoleAdmin {
+		t.Errorf("role = %s, want admin", a.Role)
+	}
+}
+
+func TestOIDCSignInFlow(t *testing.T) {
+	deps := newAPIKeyTestDeps(t)
+	resetLoginThrottles(t)
+	idp := oidctest.New(t, "loki")
+	idp.ClientSecret = "s3cret"
+	setSSOConfig(t, appconfig.SSOConfig{
+		OIDCIssuer: idp.Issuer(), OIDCClientID: "loki", OIDCClientSecret: "s3cret",
+		RoleGroups: map[string]string{"media-admins": "admin"},
+	})
+
+	start := func() *url.URL {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		oidcLoginHandler(deps)(rec, httptest.NewRequest(http.MethodGet, "http://loki.test/auth/oidc/login?redirect=/app/people", nil))
+		if rec.Code != http.StatusFound {
+			t.Fatalf("login = %d %s", rec.Code, rec.Body)
+		}
+		// The stub IdP approves at once and bounces back with a code.
+		client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+		resp, err := client.Get(rec.Header().Get("Location"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		back, _ := url.Parse(resp.Header.Get("Location"))
+		if back.Host != "loki.test" || back.Path != "/auth/oidc/callback" {
+			t.Fatalf("IdP redirected to %s", back)
+		}
+		return back
+	}
+	finish := func(back *url.URL) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		oidcCallbackHandler(deps)(rec, httptest.NewRequest(http.MethodGet, back.String(), nil))
+		return rec
+	}
+
+	idp.SetClaims(map[string]any{"preferred_username": "alice", "groups": []string{"media-admins"}})
+	back := start()
+	rec := finish(back)
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/app/people" {
+		t.Fatalf("callback = %d -> %q (%s)", rec.Code, rec.Header().Get("Location"), rec.Body)
+	}
+	if claims, err := deps.Auth.VerifyToken(sessionCookie(rec)); err != nil || claims.Username != "alice" {
+		t.Fatalf("session = %+v (%v)", claims, err)
+	}
+	if a, _ := deps.Auth.UserAccess("alice"); a.Role != auth.RoleAdmin {
+		t.Errorf("role = %s", a.Role)
+	}
+	// State is single-use.
+	if rec := finish(back); rec.Code != http.StatusBadRequest {
+		t.Errorf("replayed callback = %d", rec.Code)
+	}
+
+	// No mapped group and no default role: refused, no account created.
+	idp.SetClaims(map[string]any{"preferred_username": "mallory", "groups": []string{"guests"}})
+	if rec := finish(start()); rec.Code != http.StatusForbidden || sessionCookie(rec) != "" {
+		t.Errorf("unmapped user = %d", rec.Code)
+	}
+	if _, err := deps.Auth.UserAccess("mallory"); err == nil {
+		t.Error("unmapped user was provisioned")
+	}
+}
+
+func TestMergeSSOConfig(t *testing.T) {
+	stored := appconfig.SSOConfig{OIDCClientSecret: "keep-me"}
+	got, err := mergeSSOConfig(appconfig.SSOConfig{OIDCIssuer: "https://idp.example", OIDCClientSecret: redactedPlaceholder,
+		TrustedHeader: "X-Forwarded-User", TrustedProxies: []string{"10.0.0.1"}, DefaultRole: "Viewer"}, stored)
+	if err != nil || got.OIDCClientSecret != "keep-me" || got.TrustedProxies[0] != "10.0.0.1/32" || got.DefaultRole != "viewer" {
+		t.Errorf("merge = %+v, %v", got, err)
+	}
+	for name, in := range map[string]appconfig.SSOConfig{
+		"header without proxies": {TrustedHeader: "X-Forwarded-User"},
+		"bad issuer":             {OIDCIssuer: "idp.example"},
+		"bad role":               {RoleGroups: map[string]string{"x": "owner"}},
+	} {
+		if _, err := mergeSSOConfig(in, stored); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
@@ -83,6 +83,10 @@ type statsAPIResponse struct {
 	AutotagModel statsModelInfo `json:"autotagModel"`
 	EmbedModel   statsModelInfo `json:"embedModel"`
 	FaceModel    statsModelInfo `json:"faceModel"`
+
+	// SavedSearches is each saved search's match count (saved_search_api.go;
+	// searches with similarity predicates are not counted).
+	SavedSearches []savedSearchCount `json:"savedSearches"`
 }
 
 // addProgress folds one task progress delta into the counters. Kinds are the
@@ -235,7 +239,7 @@ func initStatsRealtime() {
 			if err != nil {
 				continue
 			}
-			stream.Broadcast(stream.Message{Type: "stats", Msg: string(payload)})
+			stream.Broadcast(stream.Message{Type: "stats", Msg: string(payload), Topics: []string{stream.TopicStats}, NoReplay: true})
 		}
 	}()
 }
@@ -363,6 +367,14 @@ func computeLibraryStats(deps *Dependencies) {
 		data.WithFaceScan = 0
 	}
 
+	// Saved-search counts. A failure here costs only these counts, not the
+	// snapshot.
+	data.SavedSearches, err = countSavedSearches(ctx, deps.DB)
+	if err != nil {
+		log.Printf("stats: saved search counts failed: %v", err)
+		data.SavedSearches = []savedSearchCount{}
+	}
+
 	faceModel := tasks.ActiveFaceModel()
 	data.TotalImages = data.TotalMedia - data.TotalVideos - data.TotalAudio
 	data.TotalTranscribable = data.TotalVideos + data.TotalAudio
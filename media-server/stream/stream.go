@@ -5,6 +5,8 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -21,8 +23,23 @@ const (
 	CleanupInterval = 60 * time.Second
 	// Buffer size for hub broadcast queue
 	HubBroadcastBuffer = 2048
+	// Number of recent messages kept for Last-Event-ID replay
+	ReplayBufferSize = 4096
 )
 
+// Topics a connection can subscribe to. Job events also carry
+// JobTopic(id), so a client can follow one job without the rest.
+const (
+	TopicJobs      = "jobs"
+	TopicMedia     = "media"
+	TopicPeople    = "people"
+	TopicScheduler = "scheduler"
+	TopicStats     = "stats"
+)
+
+// JobTopic is the topic of one job's events.
+func JobTopic(id string) string { return "job:" + id }
+
 type clientChan chan Message
 
 // Client represents a connected SSE client
@@ -34,6 +51,20 @@ type Client struct {
 	UserAgent    string
 	Connected    int64 // Unix timestamp when connected
 	MessagesSent int64
+	Topics       map[string]bool // nil receives every message
+}
+
+// accepts reports whether the client subscribed to any of msg's topics.
+func (c *Client) accepts(msg Message) bool {
+	if c.Topics == nil {
+		return true
+	}
+	for _, t := range msg.Topics {
+		if c.Topics[t] {
+			return true
+		}
+	}
+	return false
 }
 
 // ConnectionManager manages SSE client connections with production-ready features
@@ -48,6 +79,14 @@ type ConnectionManager struct {
 	mu                sync.RWMutex
 	shutdown          chan struct{}
 	shutdownOnce      sync.Once
+
+	// Replay ring: the last ReplayBufferSize messages in id order.
+	ringMu    sync.RWMutex
+	lastID    int64
+	ring      [ReplayBufferSize]Message
+	ringStart int
+	ringLen   int
+	evictedID int64 // id of the newest message pushed out of the ring
 }
 
 var manager *ConnectionManager
@@ -65,6 +104,14 @@ func init() {
 type Message struct {
 	Type string `json:"type"`
 	Msg  string `json:"msg"`
+	// ID is assigned by the hub, increasing by one per message.
+	ID int64 `json:"id,omitempty"`
+	// Topics route the message to filtered connections; a message without
+	// topics only reaches unfiltered ones.
+	Topics []string `json:"topics,omitempty"`
+	// NoReplay keeps a high-volume message out of the replay ring, e.g. log
+	// lines, which readers page through the job log API instead.
+	NoReplay bool `json:"-"`
 }
 
 // GetConnectionStats returns current connection statistics
@@ -81,6 +128,10 @@ func GetConnectionStats() map[string]interface{} {
 
 // AddClient registers a new client connection with production safeguards
 func AddClient(c clientChan, remoteAddr, userAgent string) bool {
+	return addClient(c, remoteAddr, userAgent, nil)
+}
+
+func addClient(c clientChan, remoteAddr, userAgent string, topics map[string]bool) bool {
 	// Check connection limit
 	if atomic.LoadInt64(&manager.activeCount) >= MaxConcurrentConnections {
 		atomic.AddInt64(&manager.rejectedConns, 1)
@@ -95,6 +146,7 @@ func AddClient(c clientChan, remoteAddr, userAgent string) bool {
 		RemoteAddr: remoteAddr,
 		UserAgent:  userAgent,
 		Connected:  time.Now().Unix(),
+		Topics:     topics,
 	}
 
 	manager.clients.Store(c, client)
@@ -140,9 +192,13 @@ func (cm *ConnectionManager) runBroadcastLoop() {
 	for {
 		select {
 		case msg := <-cm.broadcast:
+			msg = cm.record(msg)
 			cm.clients.Range(func(key, value any) bool {
 				c := key.(clientChan)
 				client := value.(*Client)
+				if !client.accepts(msg) {
+					return true
+				}
 				select {
 				case c <- msg:
 					atomic.StoreInt64(&client.LastSeen, time.Now().Unix())
@@ -160,6 +216,45 @@ func (cm *ConnectionManager) runBroadcastLoop() {
 	}
 }
 
+// record numbers msg and keeps it for replay. Only the broadcast loop calls
+// it, so ids are assigned in delivery order.
+func (cm *ConnectionManager) record(msg Message) Message {
+	cm.ringMu.Lock()
+	defer cm.ringMu.Unlock()
+	cm.lastID++
+	msg.ID = cm.lastID
+	if msg.NoReplay {
+		return msg
+	}
+	if cm.ringLen < ReplayBufferSize {
+		cm.ring[(cm.ringStart+cm.ringLen)%ReplayBufferSize] = msg
+		cm.ringLen++
+	} else {
+		cm.evictedID = cm.ring[cm.ringStart].ID
+		cm.ring[cm.ringStart] = msg
+		cm.ringStart = (cm.ringStart + 1) % ReplayBufferSize
+	}
+	return msg
+}
+
+// replaySince returns the kept messages after id that client accepts.
+// complete is false when some were already dropped from the ring, or when
+// id is from before a restart, so the caller must resynchronize.
+func (cm *ConnectionManager) replaySince(id int64, client *Client) (msgs []Message, complete bool) {
+	cm.ringMu.RLock()
+	defer cm.ringMu.RUnlock()
+	if id > cm.lastID || id < cm.evictedID {
+		return nil, false
+	}
+	for i := 0; i < cm.ringLen; i++ {
+		msg := cm.ring[(cm.ringStart+i)%ReplayBufferSize]
+		if msg.ID > id && client.accepts(msg) {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs, true
+}
+
 // cleanupRoutine periodically removes stale connections
 func (cm *ConnectionManager) cleanupRoutine() {
 	ticker := time.NewTicker(CleanupInterval)
@@ -216,8 +311,56 @@ func Shutdown() {
 	})
 }
 
+// Subscription selects what a stream connection receives.
+type Subscription struct {
+	// Topics filters the stream; nil receives every message.
+	Topics []string
+	// LastEventID replays the kept messages after this id before going
+	// live. 0 starts live.
+	LastEventID int64
+}
+
+// ParseTopics parses a comma-separated topic list. Empty means no filter
+// (nil).
+func ParseTopics(raw string) ([]string, error) {
+	var topics []string
+	for _, t := range strings.Split(raw, ",") {
+		t = strings.TrimSpace(t)
+		switch {
+		case t == "":
+			continue
+		case t == TopicJobs, t == TopicMedia, t == TopicPeople, t == TopicScheduler, t == TopicStats:
+		case strings.HasPrefix(t, "job:") && len(t) > len("job:"):
+		default:
+			return nil, fmt.Errorf("unknown topic %q", t)
+		}
+		topics = append(topics, t)
+	}
+	return topics, nil
+}
+
+// LastEventID reads the id a reconnecting client resumes from: the
+// Last-Event-ID header EventSource sends, or a lastEventId query parameter
+// for clients that open a fresh connection. 0 when absent.
+func LastEventID(r *http.Request) int64 {
+	v := r.Header.Get("Last-Event-ID")
+	if v == "" {
+		v = r.URL.Query().Get("lastEventId")
+	}
+	id, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+	if err != nil || id < 0 {
+		return 0
+	}
+	return id
+}
+
 // StreamHandler handles the SSE endpoint with production-ready features
 func StreamHandler(w http.ResponseWriter, r *http.Request) {
+	Serve(w, r, Subscription{LastEventID: LastEventID(r)})
+}
+
+// Serve streams the messages sub selects until the client disconnects.
+func Serve(w http.ResponseWriter, r *http.Request, sub Subscription) {
 	// Check if we can accept more connections
 	if atomic.LoadInt64(&manager.activeCount) >= MaxConcurrentConnections {
 		http.Error(w, "Server at capacity, please try again later", http.StatusServiceUnavailable)
@@ -228,8 +371,6 @@ func StreamHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "Cache-Control")
 	w.Header().Del("Content-Encoding")
 
 	// Check if client supports streaming
@@ -239,13 +380,21 @@ func StreamHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var topics map[string]bool
+	if sub.Topics != nil {
+		topics = make(map[string]bool, len(sub.Topics))
+		for _, t := range sub.Topics {
+			topics[t] = true
+		}
+	}
+
 	// Create buffered channel for this client
 	messageChan := make(chan Message, ClientChannelBuffer)
 	remoteAddr := r.RemoteAddr
 	userAgent := r.UserAgent()
 
 	// Try to add the client
-	if !AddClient(messageChan, remoteAddr, userAgent) {
+	if !addClient(messageChan, remoteAddr, userAgent, topics) {
 		http.Error(w, "Server at capacity", http.StatusServiceUnavailable)
 		return
 	}
@@ -264,6 +413,27 @@ func StreamHandler(w http.ResponseWriter, r *http.Request) {
 	if _, err := io.WriteString(w, "data: {\"type\":\"connected\",\"msg\":\"SSE connection established\"}\n\n"); err != nil {
 		return
 	}
+
+	// Replay what a reconnecting client missed. The client is registered
+	// first, so anything broadcast from here on is in its channel too; sent
+	// tracks the newest id written so those aren't delivered twice.
+	var sent int64
+	if sub.LastEventID > 0 {
+		msgs, complete := manager.replaySince(sub.LastEventID, &Client{Topics: topics})
+		if !complete {
+			// Too far behind (or from before a restart): the client must
+			// refetch its state instead of trusting the replay.
+			if _, err := io.WriteString(w, "event: resync\ndata: {\"type\":\"resync\"}\n\n"); err != nil {
+				return
+			}
+		}
+		for _, msg := range msgs {
+			if _, err := io.WriteString(w, formatSSEResponse(msg)); err != nil {
+				return
+			}
+			sent = msg.ID
+		}
+	}
 	flusher.Flush()
 
 	for {
@@ -272,7 +442,13 @@ func StreamHandler(w http.ResponseWriter, r *http.Request) {
 			// Client disconnected
 			return
 
-		case msg := <-messageChan:
+		case msg, ok := <-messageChan:
+			if !ok {
+				return // Removed by cleanup or shutdown
+			}
+			if msg.ID <= sent {
+				continue // Already replayed
+			}
 			// Send message to client
 			if _, err := io.WriteString(w, formatSSEResponse(msg)); err != nil {
 				return // Connection broken
@@ -290,5 +466,8 @@ func StreamHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func formatSSEResponse(msg Message) string {
+	if msg.ID > 0 {
+		return fmt.Sprintf("id: %d\nevent: %s\ndata: %s\n\n", msg.ID, msg.Type, msg.Msg)
+	}
 	return fmt.Sprintf("event: %s\ndata: %s\n\n", msg.Type, msg.Msg)
 }
@@ -1,6 +1,11 @@
 package stream
 
 import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -304,3 +309,137 @@ func TestMultipleClients(t *testing.T) {
 		t.Errorf("Final activeCount = %d; want %d", finalCount, initialCount)
 	}
 }
+
+func TestParseTopics(t *testing.T) {
+	got, err := ParseTopics(" jobs, job:abc ,media,,people,scheduler,stats")
+	if err != nil || strings.Join(got, "|") != "jobs|job:abc|media|people|scheduler|stats" {
+		t.Fatalf("ParseTopics = %v, %v", got, err)
+	}
+	if got, err := ParseTopics(""); got != nil || err != nil {
+		t.Errorf("empty = %v, %v; want no filter", got, err)
+	}
+	for _, bad := range []string{"job:", "jobz", "media,tags"} {
+		if _, err := ParseTopics(bad); err == nil {
+			t.Errorf("ParseTopics(%q) accepted", bad)
+		}
+	}
+}
+
+func TestClientTopicFilter(t *testing.T) {
+	drainBroadcasts()
+	filtered := make(chan Message, ClientChannelBuffer)
+	addClient(filtered, "127.0.0.1:1", "TestAgent", map[string]bool{JobTopic("j1"): true})
+	defer RemoveClient(filtered)
+
+	Broadcast(Message{Type: "update", Msg: "other", Topics: []string{TopicJobs, JobTopic("j2")}})
+	Broadcast(Message{Type: "media-updated", Msg: "m", Topics: []string{TopicMedia}})
+	Broadcast(Message{Type: "untopiced", Msg: "x"})
+	Broadcast(Message{Type: "update", Msg: "mine", Topics: []string{TopicJobs, JobTopic("j1")}})
+
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case msg := <-filtered:
+			if msg.Msg != "mine" {
+				t.Fatalf("filtered client got %+v", msg)
+			}
+			return
+		case <-deadline:
+			t.Fatal("filtered client never got its job's update")
+		}
+	}
+}
+
+func TestFormatSSEResponseWithID(t *testing.T) {
+	got := formatSSEResponse(Message{Type: "update", Msg: "x", ID: 42})
+	if got != "id: 42\nevent: update\ndata: x\n\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+// waitForID blocks until the hub has numbered at least id messages.
+func waitForID(t *testing.T, id int64) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		manager.ringMu.RLock()
+		last := manager.lastID
+		manager.ringMu.RUnlock()
+		if last >= id {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("hub stuck at id %d, want %d", last, id)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func currentID() int64 {
+	manager.ringMu.RLock()
+	defer manager.ringMu.RUnlock()
+	return manager.lastID
+}
+
+// A reconnect with Last-Event-ID gets the matching messages it missed, in
+// order, each with its id.
+func TestServeReplaysAfterLastEventID(t *testing.T) {
+	drainBroadcasts()
+	waitForID(t, 0)
+	Broadcast(Message{Type: "update", Msg: "seen", Topics: []string{TopicJobs}})
+	waitForID(t, currentID()+1)
+	since := currentID()
+	Broadcast(Message{Type: "update", Msg: "missed-1", Topics: []string{TopicJobs}})
+	Broadcast(Message{Type: "media-updated", Msg: "not-mine", Topics: []string{TopicMedia}})
+	Broadcast(Message{Type: "stdout-x", Msg: "log", Topics: []string{TopicJobs}, NoReplay: true})
+	Broadcast(Message{Type: "update", Msg: "missed-2", Topics: []string{TopicJobs}})
+	waitForID(t, since+4)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Serve(w, r, Subscription{Topics: []string{TopicJobs}, LastEventID: LastEventID(r)})
+	}))
+	defer srv.Close()
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("Last-Event-ID", strconv.FormatInt(since, 10))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var data, ids []string
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() && len(data) < 3 {
+		line := sc.Text()
+		if v, ok := strings.CutPrefix(line, "id: "); ok {
+			ids = append(ids, v)
+		}
+		if v, ok := strings.CutPrefix(line, "data: "); ok {
+			data = append(data, v)
+		}
+	}
+	if len(data) != 3 || data[1] != "missed-1" || data[2] != "missed-2" {
+		t.Fatalf("data = %q", data)
+	}
+	if len(ids) != 2 || ids[0] != strconv.FormatInt(since+1, 10) || ids[1] != strconv.FormatInt(since+4, 10) {
+		t.Errorf("ids = %v", ids)
+	}
+}
+
+func TestReplaySinceDetectsGaps(t *testing.T) {
+	if _, complete := manager.replaySince(currentID()+100, &Client{}); complete {
+		t.Error("an id from the future (a previous server run) replayed as complete")
+	}
+	drainBroadcasts()
+	start := currentID()
+	for i := 0; i < ReplayBufferSize+10; i++ {
+		manager.broadcast <- Message{Type: "flood", Topics: []string{TopicStats}}
+	}
+	waitForID(t, start+ReplayBufferSize+10)
+	if _, complete := manager.replaySince(start, &Client{}); complete {
+		t.Error("replay past the ring's start reported complete")
+	}
+	if msgs, complete := manager.replaySince(currentID()-5, &Client{}); !complete || len(msgs) != 5 {
+		t.Errorf("recent replay = %d msgs, complete=%v", len(msgs), complete)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+// stream_api.go — GET /stream, the server-sent event feed. Shared by all
+// three platform mains.
+//
+//	GET /stream?topics=jobs,media     only those topics (default: all the
+//	                                  caller may see)
+//	GET /stream?topics=job:<id>       one job's events
+//
+// The feed needs credentials like any API route. EventSource can't set
+// headers, so a Bearer token or API key may also come as ?access_token=.
+// Job and scheduler topics are admin-only (jobs:run for scoped keys);
+// media, people, and stats are open to every account (media:read).
+//
+// Every message carries an id. A client reconnecting with Last-Event-ID
+// (or ?lastEventId=) first gets what it missed from the hub's replay ring,
+// or a "resync" event when that is gone and it should refetch.
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/stevecastle/shrike/auth"
+	"github.com/stevecastle/shrike/renderer"
+	"github.com/stevecastle/shrike/stream"
+)
+
+// streamTopics are the topics an unfiltered connection may be narrowed to.
+var streamTopics = []string{stream.TopicJobs, stream.TopicScheduler, stream.TopicMedia, stream.TopicPeople, stream.TopicStats}
+
+// streamTopicAccess is the role and API key scope a topic needs.
+func streamTopicAccess(topic string) (renderer.AuthRole, string) {
+	if topic == stream.TopicJobs || topic == stream.TopicScheduler || strings.HasPrefix(topic, "job:") {
+		return renderer.RoleAdmin, auth.ScopeJobsRun
+	}
+	return renderer.RolePublicRead, auth.ScopeMediaRead
+}
+
+// streamRouteScope is the scope the auth middleware asks of a scoped API
+// key for /stream: jobs:run when only job topics are requested, media:read
+// otherwise. streamHandler checks each topic itself.
+func streamRouteScope(r *http.Request) string {
+	topics, err := stream.ParseTopics(r.URL.Query().Get("topics"))
+	if err != nil || len(topics) == 0 {
+		return auth.ScopeMediaRead
+	}
+	for _, t := range topics {
+		if _, scope := streamTopicAccess(t); scope != auth.ScopeJobsRun {
+			return auth.ScopeMediaRead
+		}
+	}
+	return auth.ScopeJobsRun
+}
+
+// streamRoute is /stream with its auth: an ?access_token= becomes the
+// Authorization header before the usual middleware runs.
+func streamRoute(deps *Dependencies) http.HandlerFunc {
+	h := renderer.ApplyMiddlewares(streamHandler(deps), renderer.RolePublicRead)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if tok := r.URL.Query().Get("access_token"); tok != "" && requestAuthToken(r) == "" {
+			r = r.Clone(r.Context())
+			r.Header.Set("Authorization", "Bearer "+tok)
+		}
+		h(w, r)
+	}
+}
+
+func streamHandler(deps *Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Use GET", http.StatusMethodNotAllowed)
+			return
+		}
+		topics, err := stream.ParseTopics(r.URL.Query().Get("topics"))
+		if err != nil {
+			httpError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		// Public access and share links let anonymous visitors past the
+		// middleware on read routes; the feed still wants an account. As in
+		// authMiddleware, the account's role and a key's scopes are
+		// checked separately.
+		username := requestUsername(deps, r)
+		if username == "" {
+			httpError(w, "credentials required", http.StatusUnauthorized)
+			return
+		}
+		access, err := deps.Auth.UserAccess(username)
+		if err != nil {
+			httpError(w, "credentials required", http.StatusUnauthorized)
+			return
+		}
+		allowed := func(topic string) bool {
+			role, scope := streamTopicAccess(topic)
+			return renderer.Permits(access.Role, role) && requestScopeAllows(r, scope)
+		}
+
+		if topics != nil {
+			for _, t := range topics {
+				if !allowed(t) {
+					httpError(w, "not allowed to subscribe to "+t, http.StatusForbidden)
+					return
+				}
+			}
+		} else {
+			// No filter: everything if the caller may see every topic,
+			// otherwise just the topics it may see.
+			var visible []string
+			for _, t := range streamTopics {
+				if allowed(t) {
+					visible = append(visible, t)
+				}
+			}
+			if len(visible) == 0 {
+				httpError(w, "no stream topics are open to this credential", http.StatusForbidden)
+				return
+			}
+			if len(visible) < len(streamTopics) {
+				topics = visible
+			}
+		}
+		stream.Serve(w, r, stream.Subscription{Topics: topics, LastEventID: stream.LastEventID(r)})
+	}
+}
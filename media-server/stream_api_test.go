@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stevecastle/shrike/auth"
+	"github.com/stevecastle/shrike/renderer"
+	"github.com/stevecastle/shrike/stream"
+)
+
+func newStreamTestServer(t *testing.T) (*Dependencies, *httptest.Server) {
+	t.Helper()
+	deps := newAPIKeyTestDeps(t)
+	old := renderer.AuthMiddleware
+	renderer.AuthMiddleware = func(h http.Handler, role renderer.AuthRole) http.Handler { return authMiddleware(deps, h, role) }
+	t.Cleanup(func() { renderer.AuthMiddleware = old })
+	srv := httptest.NewServer(streamRoute(deps))
+	t.Cleanup(srv.Close)
+	return deps, srv
+}
+
+// openStream connects to /stream; the connection closes with the test.
+func openStream(t *testing.T, srv *httptest.Server, query string, header map[string]string) *http.Response {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/stream"+query, nil)
+	req.Header.Set("Accept", "application/json")
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// nextEvents reads event names until want arrives, returning all seen.
+func nextEvents(t *testing.T, resp *http.Response, want string) []string {
+	t.Helper()
+	var seen []string
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sc := bufio.NewScanner(resp.Body)
+		for sc.Scan() {
+			if name, ok := strings.CutPrefix(sc.Text(), "event: "); ok {
+				seen = append(seen, name)
+				if name == want {
+					return
+				}
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		resp.Body.Close()
+		<-done
+		t.Fatalf("no %q event; saw %v", want, seen)
+	}
+	return seen
+}
+
+func TestStreamRequiresCredentials(t *testing.T) {
+	_, srv := newStreamTestServer(t)
+	setPublicAccess(t, true)
+	if resp := openStream(t, srv, "", nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("anonymous with public access on: %d, want 401", resp.StatusCode)
+	}
+}
+
+func TestStreamTopicAccess(t *testing.T) {
+	deps, srv := newStreamTestServer(t)
+	setPublicAccess(t, false)
+	viewer := roleToken(t, deps, "vera", auth.Access{Role: auth.RoleViewer})
+	admin := loginToken(t, deps)
+	rr := doAPIKeys(t, deps, http.MethodPost, "/auth/keys", `{"name":"ci","username":"steve","scopes":["jobs:run"]}`)
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil || created.Key == "" {
+		t.Fatalf("create key: %s", rr.Body.String())
+	}
+
+	for _, c := range []struct {
+		name, query string
+		header      map[string]string
+		want        int
+	}{
+		{"viewer on jobs", "?topics=jobs", map[string]string{"Authorization": "Bearer " + viewer}, http.StatusForbidden},
+		{"viewer on media", "?topics=media,people", map[string]string{"Authorization": "Bearer " + viewer}, http.StatusOK},
+		{"admin via access_token", "?topics=job:abc&access_token=" + admin, nil, http.StatusOK},
+		{"jobs key on a job", "?topics=job:abc", map[string]string{"X-API-Key": created.Key}, http.StatusOK},
+		{"jobs key on media", "?topics=media", map[string]string{"X-API-Key": created.Key}, http.StatusForbidden},
+		{"unknown topic", "?topics=tags", map[string]string{"Authorization": "Bearer " + admin}, http.StatusBadRequest},
+	} {
+		if resp := openStream(t, srv, c.query, c.header); resp.StatusCode != c.want {
+			t.Errorf("%s: %d, want %d", c.name, resp.StatusCode, c.want)
+		}
+	}
+}
+
+// An unfiltered viewer connection is narrowed to the topics a viewer may
+// see, so job events never reach it.
+func TestStreamUnfilteredViewerSkipsJobs(t *testing.T) {
+	deps, srv := newStreamTestServer(t)
+	setPublicAccess(t, false)
+	viewer := roleToken(t, deps, "vera", auth.Access{Role: auth.RoleViewer})
+	resp := openStream(t, srv, "", map[string]string{"Authorization": "Bearer " + viewer})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d", resp.StatusCode)
+	}
+	// Let the connection register before broadcasting.
+	time.Sleep(50 * time.Millisecond)
+	stream.Broadcast(stream.Message{Type: "update", Msg: "{}", Topics: []string{stream.TopicJobs, stream.JobTopic("j")}})
+	stream.Broadcast(stream.Message{Type: "media-updated", Msg: "{}", Topics: []string{stream.TopicMedia}})
+	for _, name := range nextEvents(t, resp, "media-updated") {
+		if name == "update" {
+			t.Error("viewer received a job event")
+		}
+	}
+}
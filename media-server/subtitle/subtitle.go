@@ -0,0 +1,212 @@
+// Package subtitle reads and writes the WebVTT transcripts stored in
+// media.transcript, and renders them as SRT for sidecar export.
+//
+// Speakers are carried as WebVTT voice spans ("<v Speaker 1>Hello"), so a
+// diarized transcript is still a plain VTT that players, the transcript
+// panel, and the search index all read without knowing about diarization.
+package subtitle
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cue is one timed span of transcript text. Speaker is "" when the cue has
+// no voice span.
+type Cue struct {
+	Start   time.Duration
+	End     time.Duration
+	Speaker string
+	Text    string
+}
+
+// voiceRe matches a cue's leading voice span: <v Name> or <v.class Name>.
+var voiceRe = regexp.MustCompile(`^<v(?:\.[^\s>]*)?\s+([^>]*)>`)
+
+// Parse reads a WebVTT or SRT document. Cue identifiers, the WEBVTT header,
+// and NOTE/STYLE/REGION blocks are skipped, as is any block without a
+// parseable timing line. A leading voice span becomes the cue's Speaker and
+// its closing </v> is dropped; other inline tags are left in Text.
+func Parse(s string) []Cue {
+	s = strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\r", "\n")
+	var cues []Cue
+	for _, block := range strings.Split(s, "\n\n") {
+		lines := strings.Split(strings.Trim(block, "\n"), "\n")
+		timing := -1
+		for i, l := range lines {
+			if strings.Contains(l, "-->") {
+				timing = i
+				break
+			}
+		}
+		if timing < 0 {
+			continue
+		}
+		start, end, ok := parseTiming(lines[timing])
+		if !ok {
+			continue
+		}
+		text := strings.TrimSpace(strings.Join(lines[timing+1:], "\n"))
+		if text == "" {
+			continue
+		}
+		c := Cue{Start: start, End: end, Text: text}
+		if m := voiceRe.FindStringSubmatch(text); m != nil {
+			c.Speaker = strings.TrimSpace(m[1])
+			c.Text = strings.TrimSpace(strings.Replace(text[len(m[0]):], "</v>", "", 1))
+		}
+		cues = append(cues, c)
+	}
+	return cues
+}
+
+// parseTiming reads "start --> end [settings]".
+func parseTiming(line string) (start, end time.Duration, ok bool) {
+	parts := strings.SplitN(line, "-->", 2)
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	fields := strings.Fields(parts[1])
+	if len(fields) == 0 {
+		return 0, 0, false
+	}
+	start, ok1 := parseTimestamp(strings.TrimSpace(parts[0]))
+	end, ok2 := parseTimestamp(fields[0])
+	return start, end, ok1 && ok2
+}
+
+// parseTimestamp reads [HH:]MM:SS.mmm, accepting SRT's comma separator.
+func parseTimestamp(ts string) (time.Duration, bool) {
+	ts = strings.Replace(ts, ",", ".", 1)
+	parts := strings.Split(ts, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, false
+	}
+	secs, err := strconv.ParseFloat(parts[len(parts)-1], 64)
+	if err != nil || secs < 0 {
+		return 0, false
+	}
+	total := secs
+	mult := 60.0
+	for i := len(parts) - 2; i >= 0; i-- {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil || n < 0 {
+			return 0, false
+		}
+		total += float64(n) * mult
+		mult *= 60
+	}
+	return time.Duration(total*1000+0.5) * time.Millisecond, true
+}
+
+// FormatVTT renders cues as a WebVTT document, writing each Speaker as a
+// voice span.
+func FormatVTT(cues []Cue) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n")
+	for _, c := range cues {
+		text := c.Text
+		if name := speakerName(c.Speaker); name != "" {
+			text = "<v " + name + ">" + text
+		}
+		fmt.Fprintf(&b, "\n%s --> %s\n%s\n", timestamp(c.Start, "."), timestamp(endOf(c), "."), text)
+	}
+	return b.String()
+}
+
+// FormatSRT renders cues as SubRip. SRT has no speaker markup, so Speaker is
+// dropped; apply WithSpeakerPrefix first to keep it in the text.
+func FormatSRT(cues []Cue) string {
+	var b strings.Builder
+	for i, c := range cues {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n", i+1, timestamp(c.Start, ","), timestamp(endOf(c), ","), c.Text)
+	}
+	return b.String()
+}
+
+// WithSpeakerPrefix returns a copy of cues with each speaker folded into the
+// text as "Name: text", for players and formats that don't render voice
+// spans.
+func WithSpeakerPrefix(cues []Cue) []Cue {
+	out := make([]Cue, len(cues))
+	for i, c := range cues {
+		if c.Speaker != "" {
+			c.Text = c.Speaker + ": " + c.Text
+			c.Speaker = ""
+		}
+		out[i] = c
+	}
+	return out
+}
+
+// Speakers lists the distinct speaker names in order of first appearance.
+func Speakers(cues []Cue) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, c := range cues {
+		if c.Speaker != "" && !seen[c.Speaker] {
+			seen[c.Speaker] = true
+			out = append(out, c.Speaker)
+		}
+	}
+	return out
+}
+
+// RenameSpeaker relabels every cue spoken by from (case-insensitively) as to
+// and returns how many cues changed. Renaming onto an existing name merges
+// the two speakers.
+func RenameSpeaker(cues []Cue, from, to string) int {
+	from = strings.TrimSpace(from)
+	to = speakerName(to)
+	n := 0
+	for i := range cues {
+		if cues[i].Speaker != "" && strings.EqualFold(cues[i].Speaker, from) && cues[i].Speaker != to {
+			cues[i].Speaker = to
+			n++
+		}
+	}
+	return n
+}
+
+// ValidSpeakerName reports whether name survives as a voice-span annotation
+// unchanged: non-empty, one line, and free of the tag delimiters.
+func ValidSpeakerName(name string) bool {
+	return name != "" && speakerName(name) == name
+}
+
+// speakerName makes s safe inside <v ...>: no tag delimiters or line breaks,
+// surrounding space trimmed.
+func speakerName(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch r {
+		case '<', '>', '&':
+			return -1
+		case '\n', '\r', '\t':
+			return ' '
+		}
+		return r
+	}, s)
+	return strings.TrimSpace(s)
+}
+
+func endOf(c Cue) time.Duration {
+	if c.End < c.Start {
+		return c.Start
+	}
+	return c.End
+}
+
+// timestamp formats d as HH:MM:SS<sep>mmm.
+func timestamp(d time.Duration, sep string) string {
+	if d < 0 {
+		d = 0
+	}
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
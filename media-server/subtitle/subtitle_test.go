@@ -0,0 +1,76 @@
+package subtitle
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+const ms = time.Millisecond
+
+func TestParseVTT(t *testing.T) {
+	vtt := "WEBVTT\r\n\r\nNOTE written by hand\r\n\r\n" +
+		"intro\r\n00:00:01.000 --> 00:00:02.500 align:start\r\n<v Speaker 1>Hello there.</v>\r\n\r\n" +
+		"01:02.250 --> 01:03.000\r\n<v.loud Ann>Two\r\nlines\r\n\r\n" +
+		"00:00:05.000 --> 00:00:06.000\r\nNo voice <i>here</i>\r\n\r\n" +
+		"garbage --> also garbage\r\nskipped\r\n"
+	want := []Cue{
+		{Start: 1000 * ms, End: 2500 * ms, Speaker: "Speaker 1", Text: "Hello there."},
+		{Start: 62250 * ms, End: 63000 * ms, Speaker: "Ann", Text: "Two\nlines"},
+		{Start: 5000 * ms, End: 6000 * ms, Text: "No voice <i>here</i>"},
+	}
+	if got := Parse(vtt); !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestParseSRT(t *testing.T) {
+	srt := "1\n00:00:00,500 --> 00:00:01,000\nfirst\n\n2\n01:00:00,000 --> 01:00:01,250\nsecond\n"
+	want := []Cue{
+		{Start: 500 * ms, End: 1000 * ms, Text: "first"},
+		{Start: time.Hour, End: time.Hour + 1250*ms, Text: "second"},
+	}
+	if got := Parse(srt); !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	cues := []Cue{
+		{Start: 0, End: 1250 * ms, Speaker: "Speaker 1", Text: "Hello."},
+		{Start: 1250 * ms, End: 1000 * ms, Text: "End before start."},
+	}
+	vtt := FormatVTT(cues)
+	wantVTT := "WEBVTT\n\n00:00:00.000 --> 00:00:01.250\n<v Speaker 1>Hello.\n\n00:00:01.250 --> 00:00:01.250\nEnd before start.\n"
+	if vtt != wantVTT {
+		t.Errorf("FormatVTT =\n%s\nwant\n%s", vtt, wantVTT)
+	}
+	if back := Parse(vtt); back[0].Speaker != "Speaker 1" || back[0].Text != "Hello." {
+		t.Errorf("round trip = %+v", back)
+	}
+
+	srt := FormatSRT(WithSpeakerPrefix(cues))
+	wantSRT := "1\n00:00:00,000 --> 00:00:01,250\nSpeaker 1: Hello.\n\n2\n00:00:01,250 --> 00:00:01,250\nEnd before start.\n"
+	if srt != wantSRT {
+		t.Errorf("FormatSRT =\n%s\nwant\n%s", srt, wantSRT)
+	}
+	if cues[0].Speaker != "Speaker 1" {
+		t.Error("WithSpeakerPrefix modified its input")
+	}
+}
+
+func TestRenameSpeaker(t *testing.T) {
+	cues := []Cue{{Speaker: "Speaker 2"}, {Speaker: "Speaker 1"}, {}, {Speaker: "speaker 2"}}
+	if got := Speakers(cues); !reflect.DeepEqual(got, []string{"Speaker 2", "Speaker 1", "speaker 2"}) {
+		t.Errorf("Speakers = %v", got)
+	}
+	if n := RenameSpeaker(cues, "SPEAKER 2", " Ann <host> "); n != 2 {
+		t.Errorf("renamed %d cues; want 2", n)
+	}
+	if got := Speakers(cues); !reflect.DeepEqual(got, []string{"Ann host", "Speaker 1"}) {
+		t.Errorf("after rename Speakers = %v", got)
+	}
+	if ValidSpeakerName("a<b") || ValidSpeakerName(" ") || !ValidSpeakerName("Dr. Who") {
+		t.Error("ValidSpeakerName")
+	}
+}
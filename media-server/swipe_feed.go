@@ -61,10 +61,10 @@ func resetSwipeFeedEngine(newDB *sql.DB) {
 }
 
 // maybeHandleSwipeFeed serves /swipe/api requests with mode=feed: the
-// never-ending "For You" feed ranked from the user's swipe favorites (see
-// the feed package for the algorithm). Returns false when the request isn't
-// feed-mode so the caller falls through. Shared by the per-platform
-// swipeAPIHandler copies.
+// never-ending "For You" feed ranked from the requester's likes (see
+// ratingUser, and the feed package for the algorithm). Returns false when
+// the request isn't feed-mode so the caller falls through. Shared by the
+// per-platform swipeAPIHandler copies.
 //
 // The session param (the client's per-load seed) keys the server-side feed
 // sequence so offset/limit pages compose; lane-weight query params
@@ -135,7 +135,7 @@ func handleSwipeFeed(w http.ResponseWriter, r *http.Request, deps *Dependencies,
 		}
 	}
 
-	paths, hasMore, err := engine.Page(r.Context(), session, offset, limit, orientation, override)
+	paths, hasMore, err := engine.Page(r.Context(), ratingUser(deps, r), session, offset, limit, orientation, override)
 	if err != nil {
 		// A canceled context is the client navigating away mid-request —
 		// routine, not an error worth logging loudly.
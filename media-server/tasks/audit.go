@@ -0,0 +1,25 @@
+package tasks
+
+import (
+	"log"
+
+	"github.com/stevecastle/shrike/jobqueue"
+	"github.com/stevecastle/shrike/media"
+)
+
+// auditJob records a library mutation made by job j in the audit log, with
+// the job as actor (its command as the name, its ID as the actor ID). Like
+// the HTTP handlers' auditLog, a failed write is logged and never fails the
+// job.
+func auditJob(q *jobqueue.Queue, j *jobqueue.Job, action string, targets []string, before, after any) {
+	if q == nil || q.Db == nil {
+		return
+	}
+	if err := media.AppendAudit(q.Db, media.AuditEntry{
+		Actor: j.Command, ActorKind: media.AuditActorJob, ActorID: j.ID,
+		Action: action, Targets: targets,
+		Before: media.AuditSummary(before), After: media.AuditSummary(after),
+	}); err != nil {
+		log.Printf("[audit] job %s %s: %v", j.ID, action, err)
+	}
+}
@@ -0,0 +1,453 @@
+package tasks
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/stevecastle/shrike/embedvec"
+	"github.com/stevecastle/shrike/jobqueue"
+	"github.com/stevecastle/shrike/media"
+)
+
+// Library themes: k-means over the whole library's embeddings.
+//
+// The 3D viz shows the embedding space and the feed engine clusters likes,
+// but nothing groups the LIBRARY into browsable pockets. This task runs
+// spherical mini-batch k-means (Sculley, "Web-scale k-means clustering") over
+// one model's image embeddings: centroids are seeded by k-means++ on a
+// reservoir sample, refined by streaming the embedding table in fixed-size
+// batches, and finally every item is assigned to its nearest centroid. Memory
+// is bounded by one batch of vectors plus the k centroids — the library is
+// never loaded whole.
+//
+// Each theme is then named: with --prompts, by the zero-shot prompt whose text
+// vector sits nearest its centroid (needs a multimodal model); otherwise by the
+// tags that most distinguish its members from the rest of the library.
+// Themes are stored in media_cluster / media_cluster_member and queried with
+// the cluster:<id> predicate.
+
+var clusterLibraryOptions = []TaskOption{
+	{Name: "clusters", Label: "Themes", Type: "number",
+		Description: "How many themes to find. 0 picks one from the library size (about √(items/2), 4–200)"},
+	{Name: "iterations", Label: "Passes", Type: "number", Default: 8.0,
+		Description: "Refinement passes over the library. More passes settle the themes further at the cost of time"},
+	{Name: "batch", Label: "Batch Size", Type: "number", Default: 2048.0,
+		Description: "Embeddings read and processed at a time — bounds memory on large libraries"},
+	{Name: "min-size", Label: "Minimum Theme Size", Type: "number", Default: 3.0,
+		Description: "Themes with fewer items are dropped; their items stay unthemed"},
+	{Name: "prompts", Label: "Label Prompts", Type: "string",
+		Description: "Comma-separated zero-shot labels (e.g. \"a beach, a city at night, a cat\"). Each theme takes the nearest prompt; without prompts themes are named by their most distinctive tags. Needs a multimodal model"},
+	{Name: "model", Label: "Embedding Model", Type: "string",
+		Description: "Embedding model whose vectors are clustered. Defaults to the active model"},
+}
+
+const (
+	clusterAutoMin = 4
+	clusterAutoMax = 200
+	// clusterSamplePerTheme sizes the k-means++ seeding sample.
+	clusterSamplePerTheme = 30
+	// themeTagMinShare is the fraction of a theme's members a tag must be on
+	// to name the theme.
+	themeTagMinShare = 0.2
+	themeTagMax      = 3
+)
+
+// themeParams tunes one theme-clustering pass.
+type themeParams struct {
+	K          int // 0 = auto
+	Iterations int
+	Batch      int
+	MinSize    int
+	Seed       int64
+}
+
+// autoThemeCount picks k for a library of n embedded items.
+func autoThemeCount(n int) int {
+	k := int(math.Round(math.Sqrt(float64(n) / 2)))
+	if k < clusterAutoMin {
+		k = clusterAutoMin
+	}
+	if k > clusterAutoMax {
+		k = clusterAutoMax
+	}
+	if k > n {
+		k = n
+	}
+	return k
+}
+
+// nearestCentroid returns the index of the centroid most similar to v (all
+// unit vectors) and that cosine.
+func nearestCentroid(centroids [][]float32, v []float32) (int, float32) {
+	best, bestSim := -1, float32(-3)
+	for i, c := range centroids {
+		if s := dot32(c, v); s > bestSim {
+			best, bestSim = i, s
+		}
+	}
+	return best, bestSim
+}
+
+// kmeansPlusPlus seeds k centroids from sample: the first uniformly, each
+// next one with probability proportional to its cosine distance from the
+// nearest centroid chosen so far. Returns fewer than k when the sample holds
+// fewer distinct points.
+func kmeansPlusPlus(sample [][]float32, k int, rng *rand.Rand) [][]float32 {
+	if len(sample) == 0 || k <= 0 {
+		return nil
+	}
+	centroids := [][]float32{append([]float32(nil), sample[rng.Intn(len(sample))]...)}
+	dist := make([]float64, len(sample))
+	for i, v := range sample {
+		dist[i] = float64(1 - dot32(centroids[0], v))
+	}
+	for len(centroids) < k {
+		var total float64
+		for _, d := range dist {
+			if d > 0 {
+				total += d
+			}
+		}
+		if total <= 1e-9 {
+			break // every remaining point duplicates a centroid
+		}
+		r := rng.Float64() * total
+		pick := len(sample) - 1
+		for i, d := range dist {
+			if d <= 0 {
+				continue
+			}
+			if r -= d; r <= 0 {
+				pick = i
+				break
+			}
+		}
+		c := append([]float32(nil), sample[pick]...)
+		centroids = append(centroids, c)
+		for i, v := range sample {
+			if d := float64(1 - dot32(c, v)); d < dist[i] {
+				dist[i] = d
+			}
+		}
+	}
+	return centroids
+}
+
+// miniBatchKMeans holds the running centroids of a spherical mini-batch
+// k-means pass.
+type miniBatchKMeans struct {
+	centroids [][]float32
+	counts    []float64
+}
+
+// update moves each batch vector's nearest centroid toward it with a
+// per-centroid learning rate of 1/count, so early assignments move a centroid
+// far and later ones only nudge it.
+func (km *miniBatchKMeans) update(batch [][]float32) {
+	assign := make([]int, len(batch))
+	for i, v := range batch {
+		assign[i], _ = nearestCentroid(km.centroids, v)
+	}
+	for i, v := range batch {
+		c := assign[i]
+		km.counts[c]++
+		eta := float32(1 / km.counts[c])
+		cent := km.centroids[c]
+		for d := range cent {
+			cent[d] = (1-eta)*cent[d] + eta*v[d]
+		}
+	}
+}
+
+// renormalize projects the centroids back onto the unit sphere.
+func (km *miniBatchKMeans) renormalize() {
+	for i, c := range km.centroids {
+		km.centroids[i] = embedvec.Normalize(c)
+	}
+}
+
+// clusterEmbeddings runs the full pass over model's stored embeddings and
+// returns the themes (unlabeled) with at least p.MinSize members, largest
+// first. progress (optional) receives done/total vector visits.
+func clusterEmbeddings(ctx context.Context, db *sql.DB, model string, p themeParams, progress func(done, total int)) ([]media.NewLibraryCluster, error) {
+	n, err := media.CountEmbeddings(db, model)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("no embeddings stored for model %q — run the embed task first", model)
+	}
+	k := p.K
+	if k <= 0 {
+		k = autoThemeCount(n)
+	}
+	if k > n {
+		k = n
+	}
+	if p.Iterations <= 0 {
+		p.Iterations = 1
+	}
+	rng := rand.New(rand.NewSource(p.Seed))
+
+	total := n * (p.Iterations + 2)
+	done := 0
+	tick := func(m int) {
+		done += m
+		if progress != nil {
+			progress(done, total)
+		}
+	}
+	stream := func(fn func([]media.StoredEmbedding)) error {
+		return media.ForEachEmbeddingBatch(db, model, p.Batch, func(page []media.StoredEmbedding) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			for i := range page {
+				page[i].Vec = embedvec.Normalize(page[i].Vec)
+			}
+			fn(page)
+			tick(len(page))
+			return nil
+		})
+	}
+
+	// Seeding: reservoir-sample the library, then k-means++ on the sample.
+	sampleSize := k * clusterSamplePerTheme
+	if sampleSize < 1000 {
+		sampleSize = 1000
+	}
+	var sample [][]float32
+	seen := 0
+	if err := stream(func(page []media.StoredEmbedding) {
+		for _, e := range page {
+			seen++
+			if len(sample) < sampleSize {
+				sample = append(sample, e.Vec)
+			} else if j := rng.Intn(seen); j < sampleSize {
+				sample[j] = e.Vec
+			}
+		}
+	}); err != nil {
+		return nil, err
+	}
+	km := &miniBatchKMeans{centroids: kmeansPlusPlus(sample, k, rng)}
+	km.counts = make([]float64, len(km.centroids))
+	sample = nil
+
+	for it := 0; it < p.Iterations; it++ {
+		if err := stream(func(page []media.StoredEmbedding) {
+			vecs := make([][]float32, len(page))
+			for i, e := range page {
+				vecs[i] = e.Vec
+			}
+			km.update(vecs)
+		}); err != nil {
+			return nil, err
+		}
+		km.renormalize()
+	}
+
+	members := make([][]media.ClusterMember, len(km.centroids))
+	if err := stream(func(page []media.StoredEmbedding) {
+		for _, e := range page {
+			c, sim := nearestCentroid(km.centroids, e.Vec)
+			if c >= 0 {
+				members[c] = append(members[c], media.ClusterMember{Path: e.Path, Score: sim})
+			}
+		}
+	}); err != nil {
+		return nil, err
+	}
+
+	var out []media.NewLibraryCluster
+	for c, ms := range members {
+		if len(ms) == 0 || len(ms) < p.MinSize {
+			continue
+		}
+		out = append(out, media.NewLibraryCluster{Centroid: km.centroids[c], Members: ms})
+	}
+	sort.SliceStable(out, func(a, b int) bool { return len(out[a].Members) > len(out[b].Members) })
+	return out, nil
+}
+
+// themeTagLabel names a theme by up to themeTagMax tags that distinguish it:
+// on at least themeTagMinShare of its members and more common there than in
+// the library at large, ranked by share × log-rarity so a tag on half the
+// library (favorites, a source folder's tag) never names a theme.
+func themeTagLabel(counts map[string]int, size int, lib map[string]int, libTotal int) string {
+	if size <= 0 || libTotal <= 0 {
+		return ""
+	}
+	type scored struct {
+		tag   string
+		score float64
+	}
+	var cands []scored
+	for tag, n := range counts {
+		share := float64(n) / float64(size)
+		libN := lib[tag]
+		if share < themeTagMinShare || libN <= 0 {
+			continue
+		}
+		libShare := float64(libN) / float64(libTotal)
+		if share <= libShare {
+			continue
+		}
+		cands = append(cands, scored{tag, share * math.Log(1/libShare+1)})
+	}
+	sort.Slice(cands, func(a, b int) bool {
+		if cands[a].score != cands[b].score {
+			return cands[a].score > cands[b].score
+		}
+		return cands[a].tag < cands[b].tag
+	})
+	if len(cands) > themeTagMax {
+		cands = cands[:themeTagMax]
+	}
+	names := make([]string, len(cands))
+	for i, c := range cands {
+		names[i] = c.tag
+	}
+	return strings.Join(names, ", ")
+}
+
+// splitPrompts parses the --prompts list.
+func splitPrompts(raw string) []string {
+	var out []string
+	for _, p := range strings.Split(raw, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// promptLabels assigns each theme the prompt whose vector is nearest its
+// centroid. vecs is parallel to prompts.
+func promptLabels(clusters []media.NewLibraryCluster, prompts []string, vecs [][]float32) {
+	for i := range clusters {
+		best, bestSim := -1, float32(-3)
+		for j, v := range vecs {
+			if s := embedvec.Cosine(clusters[i].Centroid, v); s > bestSim {
+				best, bestSim = j, s
+			}
+		}
+		if best >= 0 {
+			clusters[i].Label, clusters[i].LabelSource = prompts[best], "prompt"
+		}
+	}
+}
+
+func clusterLibraryTask(j *jobqueue.Job, q *jobqueue.Queue, mu *sync.Mutex) error {
+	ctx := j.Ctx
+	opts := ParseOptions(j, clusterLibraryOptions)
+	num := func(name string) int {
+		v, _ := opts[name].(float64)
+		return int(v)
+	}
+	params := themeParams{
+		K:          num("clusters"),
+		Iterations: num("iterations"),
+		Batch:      num("batch"),
+		MinSize:    num("min-size"),
+		Seed:       1, // deterministic: the same library yields the same themes
+	}
+	model := ActiveEmbedModel()
+	if id, ok := embedModelOverrideFromJob(j); ok {
+		if m, known := EmbedModelByID(id); known {
+			model = m
+		} else {
+			q.PushJobStdout(j.ID, fmt.Sprintf("Unknown --model %q; using active model %q", id, model.ID))
+		}
+	}
+
+	// Zero-shot prompts are encoded up front so a missing text encoder is
+	// reported before minutes of clustering, not after.
+	rawPrompts, _ := opts["prompts"].(string)
+	prompts := splitPrompts(rawPrompts)
+	var promptVecs [][]float32
+	for _, p := range prompts {
+		vec, m, err := TextQueryVector(ctx, p)
+		if err == nil && m.ID != model.ID {
+			err = fmt.Errorf("text encoder belongs to %q, themes are clustered under %q", m.ID, model.ID)
+		}
+		if err != nil {
+			q.PushJobStdout(j.ID, fmt.Sprintf("Zero-shot labels unavailable (%v); naming themes by tags instead", err))
+			promptVecs = nil
+			break
+		}
+		promptVecs = append(promptVecs, vec)
+	}
+
+	q.PushJobStdout(j.ID, fmt.Sprintf("Clustering %s embeddings...", model.ID))
+	lastPct := -10
+	clusters, err := clusterEmbeddings(ctx, q.Db, model.ID, params, func(done, total int) {
+		_ = q.SetJobProgress(j.ID, done, total)
+		if pct := done * 100 / total; pct/10 != lastPct/10 {
+			lastPct = pct
+			q.PushJobStdout(j.ID, fmt.Sprintf("Progress: %d%%", pct))
+		}
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			q.PushJobStdout(j.ID, "Canceled — the previous themes are unchanged")
+			_ = q.CancelJob(j.ID)
+			return ctx.Err()
+		}
+		q.PushJobStdout(j.ID, "Clustering failed: "+err.Error())
+		q.ErrorJob(j.ID)
+		return err
+	}
+
+	if len(promptVecs) > 0 {
+		promptLabels(clusters, prompts, promptVecs)
+	}
+	ids, err := media.ReplaceLibraryClusters(q.Db, model.ID, clusters)
+	if err != nil {
+		q.PushJobStdout(j.ID, "Failed to save themes: "+err.Error())
+		q.ErrorJob(j.ID)
+		return err
+	}
+
+	// Tag labels come from the stored membership (one grouped query rather
+	// than a tag lookup per item).
+	if len(promptVecs) == 0 {
+		counts, err := media.ClusterTagCounts(q.Db)
+		if err == nil {
+			var lib map[string]int
+			var libTotal int
+			if lib, libTotal, err = media.LibraryTagCounts(q.Db); err == nil {
+				for i, id := range ids {
+					if label := themeTagLabel(counts[id], len(clusters[i].Members), lib, libTotal); label != "" {
+						clusters[i].Label, clusters[i].LabelSource = label, "tags"
+						_ = media.SetLibraryClusterLabel(q.Db, id, label, "tags")
+					}
+				}
+			}
+		}
+		if err != nil {
+			q.PushJobStdout(j.ID, "Warning: tag labels failed: "+err.Error())
+		}
+	}
+
+	for i, c := range clusters {
+		if i == 20 {
+			q.PushJobStdout(j.ID, fmt.Sprintf("... and %d more", len(clusters)-i))
+			break
+		}
+		label := c.Label
+		if label == "" {
+			label = "(unlabeled)"
+		}
+		q.PushJobStdout(j.ID, fmt.Sprintf("Theme %d: %s — %d items", ids[i], label, len(c.Members)))
+	}
+	q.PushJobStdout(j.ID, fmt.Sprintf("Found %d themes", len(clusters)))
+	q.CompleteJob(j.ID)
+	return nil
+}
@@ -0,0 +1,122 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stevecastle/shrike/media"
+)
+
+func TestClusterEmbeddingsRecoversSeparatedGroups(t *testing.T) {
+	db := newFaceIndexDB(t)
+	rng := rand.New(rand.NewSource(7))
+	centers := [][]float32{{1, 0, 0, 0}, {0, 1, 0, 0}, {0, 0, 1, 0}}
+	for b, c := range centers {
+		for n := 0; n < 40; n++ {
+			v := make([]float32, len(c))
+			for d := range v {
+				v[d] = c[d] + float32(rng.NormFloat64()*0.05)
+			}
+			if err := media.UpsertEmbedding(db, fmt.Sprintf("blob%d-%02d.jpg", b, n), "m1", v, 0); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// An unrelated model's rows must not leak into the pass.
+	if err := media.UpsertEmbedding(db, "other.jpg", "m2", []float32{0, 0, 0, 1}, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	var lastDone, lastTotal int
+	clusters, err := clusterEmbeddings(context.Background(), db, "m1",
+		themeParams{K: 3, Iterations: 4, Batch: 16, MinSize: 1, Seed: 1},
+		func(done, total int) { lastDone, lastTotal = done, total })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastDone != lastTotal || lastTotal != 120*6 {
+		t.Errorf("progress ended at %d/%d, want %d/%d", lastDone, lastTotal, 120*6, 120*6)
+	}
+	if len(clusters) != 3 {
+		t.Fatalf("clusters = %d, want 3", len(clusters))
+	}
+	for _, c := range clusters {
+		if len(c.Members) != 40 {
+			t.Errorf("cluster size = %d, want 40", len(c.Members))
+		}
+		prefix := c.Members[0].Path[:5]
+		for _, m := range c.Members {
+			if m.Path[:5] != prefix {
+				t.Fatalf("cluster mixes %s and %s", prefix, m.Path)
+			}
+			if m.Score < 0.9 {
+				t.Errorf("%s centroid cosine = %v, want a tight fit", m.Path, m.Score)
+			}
+		}
+	}
+}
+
+func TestClusterEmbeddingsDropsSmallThemesAndCancels(t *testing.T) {
+	db := newFaceIndexDB(t)
+	for n := 0; n < 10; n++ {
+		_ = media.UpsertEmbedding(db, fmt.Sprintf("big-%d.jpg", n), "m1", []float32{1, float32(n) * 0.01}, 0)
+	}
+	_ = media.UpsertEmbedding(db, "loner.jpg", "m1", []float32{-1, 0}, 0)
+
+	clusters, err := clusterEmbeddings(context.Background(), db, "m1",
+		themeParams{K: 2, Iterations: 2, Batch: 4, MinSize: 2, Seed: 1}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clusters) != 1 || len(clusters[0].Members) != 10 {
+		t.Fatalf("clusters = %+v, want only the 10-item theme", clusters)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := clusterEmbeddings(ctx, db, "m1", themeParams{K: 2, Iterations: 1, Batch: 4}, nil); err == nil {
+		t.Error("canceled pass should fail")
+	}
+	if _, err := clusterEmbeddings(context.Background(), db, "none", themeParams{}, nil); err == nil {
+		t.Error("a model with no embeddings should fail")
+	}
+}
+
+func TestAutoThemeCount(t *testing.T) {
+	for _, tc := range []struct{ n, want int }{{2, 2}, {50, 5}, {20000, 100}, {10000000, clusterAutoMax}} {
+		if got := autoThemeCount(tc.n); got != tc.want {
+			t.Errorf("autoThemeCount(%d) = %d, want %d", tc.n, got, tc.want)
+		}
+	}
+}
+
+func TestThemeTagLabelPrefersDistinctiveTags(t *testing.T) {
+	lib := map[string]int{"favorites": 500, "beach": 40, "sunset": 30, "rare": 2}
+	counts := map[string]int{"favorites": 50, "beach": 35, "sunset": 20, "rare": 1}
+	// favorites is on half the library and half the theme — no lift, so it
+	// never names it; rare is under the minimum share.
+	if got := themeTagLabel(counts, 100, lib, 1000); got != "beach, sunset" {
+		t.Errorf("label = %q, want \"beach, sunset\"", got)
+	}
+	if got := themeTagLabel(nil, 10, lib, 1000); got != "" {
+		t.Errorf("untagged theme label = %q, want empty", got)
+	}
+}
+
+func TestPromptLabelsPickNearestPrompt(t *testing.T) {
+	clusters := []media.NewLibraryCluster{
+		{Centroid: []float32{0, 1}},
+		{Centroid: []float32{1, 0.1}},
+	}
+	promptLabels(clusters, []string{"a beach", "a forest"}, [][]float32{{1, 0}, {0, 1}})
+	got := []string{clusters[0].Label, clusters[1].Label}
+	if got[0] != "a forest" || got[1] != "a beach" || clusters[0].LabelSource != "prompt" {
+		t.Errorf("labels = %v (%q)", got, clusters[0].LabelSource)
+	}
+	if p := splitPrompts(" a beach, ,a forest "); !sort.StringsAreSorted(p) || len(p) != 2 {
+		t.Errorf("splitPrompts = %q", p)
+	}
+}
@@ -29,9 +29,10 @@ import (
 // duplicates.
 //
 // Input follows the bulk-task contract: a directory (--target, optionally
-// --recursive), a library search query (--query / --query64 — the only form
-// that can address more media than any one folder), or a newline-separated
-// path list (the palette's discrete selection).
+// --recursive), a library search query (--query / --query64, or a saved
+// search via --saved — the only forms that can address more media than any
+// one folder), or a newline-separated path list (the palette's discrete
+// selection).
 //
 // Each duplicate group is then collapsed through media.MergeInto — the same
 // merge behind the viewer's Merge action — so the kept file gains every tag,
@@ -43,7 +44,7 @@ import (
 
 var dedupeOptions = []TaskOption{
 	{Name: "target", Label: "Target Directory", Type: "string",
-		Description: "Directory to scan for exact duplicate files. Omit to pass a search query (--query/--query64) or a newline-separated path list instead"},
+		Description: "Directory to scan for exact duplicate files. Omit to pass a search query (--query/--query64), a saved search (--saved), or a newline-separated path list instead"},
 	{Name: "recursive", Label: "Recursive", Type: "bool",
 		Description: "Directory mode only: scan subdirectories too. Duplicates are matched across the whole tree"},
 	{Name: "dry-run", Label: "Dry Run", Type: "bool",
@@ -77,13 +78,14 @@ func dedupeTask(j *jobqueue.Job, q *jobqueue.Queue, mu *sync.Mutex) error {
 	// Like the other bulk tasks, a search query addresses media the palette's
 	// directory scan can't — the whole library view, a tag, a filter stack.
 	queryStr, hasQuery := extractQueryFromJob(j)
+	savedName, hasSaved := extractSavedFromJob(j)
 
 	// With --target and --query absent, the bare positional tokens are either
 	// the directory to scan (split-dir/move convention) or an explicit path
 	// list (the palette's discrete selection, one path per line). A single
 	// token that stats as a directory is a directory; anything else is paths.
 	var positional []string
-	if targetDir == "" && !hasQuery {
+	if targetDir == "" && !hasQuery && !hasSaved {
 		for _, tok := range tokens {
 			if !strings.HasPrefix(tok, "-") {
 				positional = append(positional, tok)
@@ -149,6 +151,22 @@ func dedupeTask(j *jobqueue.Job, q *jobqueue.Queue, mu *sync.Mutex) error {
 			return err
 		}
 
+	case hasSaved:
+		paths, err := getMediaPathsBySaved(ctx, q.Db, savedName)
+		if err != nil {
+			q.PushJobStdout(j.ID, fmt.Sprintf("Error resolving saved search %q: %v", savedName, err))
+			q.ErrorJob(j.ID)
+			return err
+		}
+		q.PushJobStdout(j.ID, fmt.Sprintf("Deduplicating %s: %d item(s)", savedQueryLabel(savedName), len(paths)))
+		files = statDedupeCandidates(q, j, paths)
+		stored, err = storedPathsFor(ctx, q.Db, paths)
+		if err != nil {
+			q.PushJobStdout(j.ID, fmt.Sprintf("Error loading library paths: %v", err))
+			q.ErrorJob(j.ID)
+			return err
+		}
+
 	case len(positional) > 0:
 		// The explicit-list shape every per-item task accepts: local paths
 		// absolutized, s3:// identities kept verbatim, non-media dropped.
@@ -298,6 +316,8 @@ func dedupeTask(j *jobqueue.Job, q *jobqueue.Queue, mu *sync.Mutex) error {
 			failed = append(failed, dupes...)
 			continue
 		}
+		auditJob(q, j, "media.merge", append([]string{keeper}, dupes...), nil, res)
+		TextIndexReloadPath(q.Db, keeper)
 		merged++
 		deleted += len(res.Deleted)
 		tagsGained += res.Tags
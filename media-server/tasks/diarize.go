@@ -0,0 +1,249 @@
+package tasks
+
+// diarize.go — speaker diarization for stored transcripts. The audio track is
+// extracted once to raw PCM, each transcript cue is embedded by the embed
+// binary's --speaker worker, and the cue embeddings are clustered into
+// "Speaker 1..N". Speakers are written back as WebVTT voice spans, so the
+// diarized transcript stays a plain VTT in media.transcript.
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/stevecastle/shrike/appconfig"
+	"github.com/stevecastle/shrike/deps"
+	"github.com/stevecastle/shrike/diarize"
+	"github.com/stevecastle/shrike/platform"
+	"github.com/stevecastle/shrike/subtitle"
+)
+
+const (
+	// Cues shorter than this carry too little voice to identify; they take
+	// the speaker of the nearest longer cue instead.
+	diarizeMinCue = 700 * time.Millisecond
+	// Long cues are embedded from their first few seconds — plenty for a
+	// voice print and bounded model cost.
+	diarizeMaxSpan = 10 * time.Second
+)
+
+// diarizeSpeakersOption is shared by the diarize op and transcribe's
+// diarize stage.
+var diarizeSpeakersOption = TaskOption{Name: "speakers", Label: "Speakers", Type: "number",
+	Description: "Number of speakers when known (0 = detect from the diarization threshold)"}
+
+func registerDiarizeItemOp() {
+	RegisterItemOp(ItemOp{
+		ID:      "diarize",
+		Name:    "Speaker Labels (ONNX)",
+		Options: []TaskOption{diarizeSpeakersOption},
+		Applies: extAppliesFn(append(append([]string{}, videoExts...), audioExts...)...),
+		Prepare: prepareDiarizeOp,
+	})
+}
+
+// diarizationModelPath returns the configured speaker-embedding model, or an
+// error naming the setting when none is usable.
+func diarizationModelPath() (string, error) {
+	p := strings.TrimSpace(appconfig.Get().DiarizationModelPath)
+	if p == "" {
+		return "", fmt.Errorf("no speaker-embedding model configured (set diarizationModelPath in settings)")
+	}
+	if _, err := os.Stat(p); err != nil {
+		return "", fmt.Errorf("speaker-embedding model: %w", err)
+	}
+	return p, nil
+}
+
+// speakerArgs assembles the `embed --speaker` worker arguments.
+func speakerArgs(modelPath, ortLib, provider string, threads int) []string {
+	args := []string{"--speaker", "--speaker-model=" + modelPath}
+	if provider != "" {
+		args = append(args, "--provider="+provider)
+	}
+	if threads > 0 {
+		args = append(args, fmt.Sprintf("--threads=%d", threads))
+	}
+	if ortLib != "" {
+		args = append(args, "--ort="+ortLib)
+	}
+	return args
+}
+
+// diarizer labels transcripts using a pool of speaker-embedding workers.
+type diarizer struct {
+	pool      *servePool
+	threshold float64
+	timeout   time.Duration
+}
+
+// newDiarizer starts n speaker workers for a run.
+func newDiarizer(ctx context.Context, n int, background bool) (*diarizer, string, error) {
+	model, err := diarizationModelPath()
+	if err != nil {
+		return nil, "", err
+	}
+	embedBin := deps.BundledOrEmpty("embed")
+	if embedBin == "" {
+		return nil, "", fmt.Errorf("embed binary not installed; install it from Dependencies")
+	}
+	if deps.BundledOrEmpty("ffmpeg") == "" {
+		return nil, "", fmt.Errorf("ffmpeg not found; diarization needs it to extract audio")
+	}
+	_, threads := ResolveEmbedResources()
+	ortLib, provider := resolveONNXRuntime(EmbedProviderFromConfig())
+	pool, err := newServePool(ctx, n, embedBin, speakerArgs(model, ortLib, provider, threads), background)
+	if err != nil {
+		return nil, "", fmt.Errorf("start speaker worker: %w", err)
+	}
+	d := &diarizer{pool: pool, threshold: appconfig.Get().DiarizationThreshold, timeout: OnnxFileTimeout()}
+	if d.threshold <= 0 {
+		d.threshold = diarize.DefaultThreshold
+	}
+	return d, fmt.Sprintf("model %s, threshold %.2f, provider=%s", model, d.threshold, provider), nil
+}
+
+func (d *diarizer) close() { d.pool.close() }
+
+// label attributes each cue of transcript to a speaker and returns the VTT
+// with voice spans plus the number of speakers found. speakers > 0 fixes the
+// count. A cue the model rejects is logged and treated like a short cue.
+func (d *diarizer) label(ctx context.Context, mediaPath, transcript string, speakers int, logf func(string)) (string, int, error) {
+	cues := subtitle.Parse(transcript)
+	if len(cues) == 0 {
+		return "", 0, fmt.Errorf("transcript has no cues")
+	}
+	pcm, err := extractPCM(ctx, mediaPath)
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(pcm)
+
+	w, err := d.pool.acquire(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+	segs := make([]diarize.Segment, len(cues))
+	for i, c := range cues {
+		segs[i] = diarize.Segment{Start: c.Start, End: c.End}
+		if c.End-c.Start < diarizeMinCue {
+			continue
+		}
+		end := c.End
+		if end-c.Start > diarizeMaxSpan {
+			end = c.Start + diarizeMaxSpan
+		}
+		line := fmt.Sprintf("%s\t%d\t%d", pcm, c.Start.Milliseconds(), end.Milliseconds())
+		vec, err, abandoned := runWithTimeout(ctx, d.timeout, func() ([]float32, error) { return w.embed(line) })
+		if abandoned {
+			d.pool.discard(w)
+			if err != nil {
+				return "", 0, err
+			}
+			return "", 0, fmt.Errorf("speaker embedding timed out after %s", d.timeout)
+		}
+		if err != nil {
+			logf(fmt.Sprintf("cue at %s: %v", c.Start, err))
+			continue
+		}
+		segs[i].Vec = vec
+	}
+	d.pool.release(w)
+
+	labels, err := diarize.Assign(segs, d.threshold, speakers)
+	if err != nil {
+		return "", 0, err
+	}
+	found := 0
+	for i := range cues {
+		cues[i].Speaker = diarize.Label(labels[i])
+		if labels[i]+1 > found {
+			found = labels[i] + 1
+		}
+	}
+	return subtitle.FormatVTT(cues), found, nil
+}
+
+// extractPCM writes the media's audio as raw 16 kHz mono s16le to a temp
+// file, the format the speaker worker reads spans from. The caller removes it.
+func extractPCM(ctx context.Context, mediaPath string) (string, error) {
+	ffmpeg := deps.BundledOrEmpty("ffmpeg")
+	if ffmpeg == "" {
+		return "", fmt.Errorf("ffmpeg not found; diarization needs it to extract audio")
+	}
+	f, err := os.CreateTemp("", "lowkey-diarize-*.pcm")
+	if err != nil {
+		return "", err
+	}
+	out := f.Name()
+	f.Close()
+	cmd := exec.CommandContext(ctx, ffmpeg,
+		"-hide_banner", "-loglevel", "error", "-nostdin", "-y",
+		"-i", mediaPath,
+		"-vn", "-ac", "1", "-ar", fmt.Sprint(diarize.SampleRate), "-f", "s16le", out)
+	platform.HideSubprocessWindow(cmd)
+	if b, err := cmd.CombinedOutput(); err != nil {
+		os.Remove(out)
+		return "", fmt.Errorf("ffmpeg audio extraction failed: %w: %s", err, strings.TrimSpace(string(b)))
+	}
+	return out, nil
+}
+
+// speakersOpt reads the "speakers" option (0 = automatic).
+func speakersOpt(opts map[string]any) int {
+	if v, ok := opts["speakers"].(float64); ok && v >= 1 {
+		return int(v)
+	}
+	return 0
+}
+
+// hasSpeakerLabels reports whether a stored transcript already carries
+// voice spans.
+func hasSpeakerLabels(transcript string) bool {
+	for _, c := range subtitle.Parse(transcript) {
+		if c.Speaker != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func prepareDiarizeOp(run *ItemRun) (*ItemProcessor, error) {
+	q, j := run.Queue, run.Job
+	db := q.Db
+	speakers := speakersOpt(run.Opts)
+	d, desc, err := newDiarizer(j.Ctx, run.Workers, run.Background)
+	if err != nil {
+		return nil, err
+	}
+	q.PushJobStdout(j.ID, "Diarization: "+desc)
+
+	return &ItemProcessor{
+		SkipExisting: func(path string) (bool, error) {
+			_, transcript, err := mediaTextColumns(db, path)
+			return err == nil && hasSpeakerLabels(transcript), err
+		},
+		Process: func(ctx context.Context, path, localPath string) (*ItemCommit, error) {
+			_, transcript, err := mediaTextColumns(db, path)
+			if err != nil {
+				return nil, err
+			}
+			if strings.TrimSpace(transcript) == "" {
+				return nil, nil // transcribe first
+			}
+			logf := func(s string) { q.PushJobStdout(j.ID, "[diarize] "+s) }
+			labeled, n, err := d.label(ctx, localPath, transcript, speakers, logf)
+			if err != nil {
+				return nil, err
+			}
+			return &ItemCommit{
+				Commit: func() error { return updateMediaMetadata(db, path, "transcript", labeled) },
+				Detail: fmt.Sprintf("%d speaker(s) labeled", n),
+			}, nil
+		},
+		Close: d.close,
+	}, nil
+}
@@ -18,7 +18,7 @@ func ImageQueryVectorForBytes(ctx context.Context, m EmbedModel, image []byte) (
 	if len(image) == 0 {
 		return nil, fmt.Errorf("empty image")
 	}
-	imageModel, err := deps.ModelPath(m.ID, m.ImageModelFile)
+	imageModel, err := embedModelFilePath(m, m.ImageModelFile)
 	if err != nil {
 		return nil, fmt.Errorf("image model not installed: %w", err)
 	}
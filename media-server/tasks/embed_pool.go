@@ -64,8 +64,7 @@ func ExtractFrameForMedia(ctx context.Context, mediaPath string) (imagePath, tem
 // to delete afterward (empty for non-video). Video frame extraction is bounded
 // by a per-file timeout so a corrupt video can't hang the worker indefinitely.
 func extractFrameForFile(ctx context.Context, mediaPath string, timeout time.Duration) (imagePath, tempFrame string, err error) {
-	switch strings.ToLower(filepath.Ext(mediaPath)) {
-	case ".mp4", ".mov", ".avi", ".mkv", ".webm", ".wmv", ".gif":
+	if isVideoFrameSource(mediaPath) {
 		fctx := ctx
 		if timeout > 0 {
 			var cancel context.CancelFunc
@@ -81,6 +80,16 @@ func extractFrameForFile(ctx context.Context, mediaPath string, timeout time.Dur
 	return mediaPath, "", nil
 }
 
+// isVideoFrameSource reports whether mediaPath is decoded through ffmpeg
+// frame extraction rather than read as an image.
+func isVideoFrameSource(mediaPath string) bool {
+	switch strings.ToLower(filepath.Ext(mediaPath)) {
+	case ".mp4", ".mov", ".avi", ".mkv", ".webm", ".wmv", ".gif":
+		return true
+	}
+	return false
+}
+
 // directMLRuntimeLib returns the path to the optional DirectML onnxruntime.dll
 // if it is installed, else "". The GPU runtime (onnxruntime.dll + DirectML.dll)
 // is downloaded into <DataDir>/runtimes/onnxruntime-directml/.
@@ -96,7 +105,7 @@ func directMLRuntimeLib() string {
 // m's image encoder (extracting a video frame first when needed). Used to embed
 // a "find similar" query item on the fly when it isn't indexed yet.
 func embedFileWithModel(ctx context.Context, path string, m EmbedModel) ([]float32, error) {
-	imageModel, err := deps.ModelPath(m.ID, m.ImageModelFile)
+	imageModel, err := embedModelFilePath(m, m.ImageModelFile)
 	if err != nil || imageModel == "" {
 		return nil, fmt.Errorf("%s image model not installed: %w", m.DisplayName, err)
 	}
@@ -583,14 +583,14 @@ func TextQueryVector(ctx context.Context, text string) ([]float32, EmbedModel, e
 	if !m.Multimodal || m.TextModelFile == "" {
 		return nil, m, fmt.Errorf("model %q does not support text search", m.ID)
 	}
-	textModel, err := deps.ModelPath(m.ID, m.TextModelFile)
+	textModel, err := embedModelFilePath(m, m.TextModelFile)
 	if err != nil {
 		return nil, m, fmt.Errorf("text model not installed: %w", err)
 	}
 	if textModel == "" {
 		return nil, m, fmt.Errorf("text model not installed")
 	}
-	tokenizer, err := deps.ModelPath(m.ID, m.TokenizerFile)
+	tokenizer, err := embedModelFilePath(m, m.TokenizerFile)
 	if err != nil {
 		return nil, m, fmt.Errorf("tokenizer not installed: %w", err)
 	}
@@ -1,12 +1,21 @@
 package tasks
 
-import "github.com/stevecastle/shrike/appconfig"
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/stevecastle/shrike/appconfig"
+	"github.com/stevecastle/shrike/deps"
+)
 
 // EmbedModel describes one visual-embedding model: its identity, output
 // dimensionality, image preprocessing, output pooling, and (for multimodal
 // models) its text encoder. It is the single source of truth that replaces the
 // former package-level EmbedModelID/EmbedDim constants, so a second model
 // (DINOv2) slots in by adding one entry here plus a manifest download entry.
+// Bring-your-own models (appconfig.ByoEmbedModel) resolve to the same struct
+// with BYO set and absolute paths in the *File fields.
 //
 // Vectors are stored keyed by ID in media_embedding, so models coexist
 // non-destructively. Switching the active model (appconfig.EmbeddingModel)
@@ -41,6 +50,22 @@ type EmbedModel struct {
 	TextInput     string
 	TextOutput    string
 	SeqLen        int
+
+	// BYO marks a config-declared model: the *File fields hold absolute paths
+	// instead of paths relative to the deps model dir.
+	BYO bool
+}
+
+// embedModelFilePath resolves one of m's files on disk: BYO models point at
+// their configured file; built-ins go through the deps download flow.
+func embedModelFilePath(m EmbedModel, file string) (string, error) {
+	if m.BYO {
+		if _, err := os.Stat(file); err != nil {
+			return "", fmt.Errorf("BYO embed model %q: file not found at %s", m.ID, file)
+		}
+		return file, nil
+	}
+	return deps.ModelPath(m.ID, file)
 }
 
 // DefaultEmbedModelID is used when the configured model is empty or unknown. It
@@ -90,14 +115,104 @@ var embedModelRegistry = map[string]EmbedModel{
 	},
 }
 
-// EmbedModelByID returns the registry entry and whether it exists.
+// byoToEmbedModel converts a config BYO declaration into an EmbedModel,
+// filling the SigLIP-convention defaults for anything unset.
+func byoToEmbedModel(b appconfig.ByoEmbedModel) EmbedModel {
+	m := EmbedModel{
+		ID:             strings.TrimSpace(b.ID),
+		DisplayName:    b.Name,
+		Dim:            b.Dim,
+		Multimodal:     b.TextModelPath != "" && b.TokenizerPath != "",
+		ImageModelFile: b.ImageModelPath,
+		ImgInput:       b.InputName,
+		ImgOutput:      b.OutputName,
+		Width:          b.Width,
+		Height:         b.Height,
+		Mean:           rgb3(b.Mean, [3]float32{0.5, 0.5, 0.5}),
+		Std:            rgb3(b.Std, [3]float32{0.5, 0.5, 0.5}),
+		CropPct:        float32(b.CropPct),
+		Pooling:        strings.ToLower(strings.TrimSpace(b.Pooling)),
+		TextModelFile:  b.TextModelPath,
+		TokenizerFile:  b.TokenizerPath,
+		TextInput:      b.TextInput,
+		TextOutput:     b.TextOutput,
+		SeqLen:         b.SeqLen,
+		BYO:            true,
+	}
+	if m.DisplayName == "" {
+		m.DisplayName = m.ID + " (bring-your-own)"
+	}
+	if m.ImgInput == "" {
+		m.ImgInput = "pixel_values"
+	}
+	if m.ImgOutput == "" {
+		m.ImgOutput = "pooler_output"
+	}
+	size := b.Size
+	if size <= 0 {
+		size = 224
+	}
+	if m.Width <= 0 {
+		m.Width = size
+	}
+	if m.Height <= 0 {
+		m.Height = size
+	}
+	if m.CropPct <= 0 || m.CropPct >= 1 {
+		m.CropPct = 1.0
+	} else {
+		m.CropMode = "center"
+	}
+	if m.Pooling == "" {
+		m.Pooling = "none"
+	}
+	if m.Multimodal {
+		if m.TextInput == "" {
+			m.TextInput = "input_ids"
+		}
+		if m.TextOutput == "" {
+			m.TextOutput = "pooler_output"
+		}
+		if m.SeqLen <= 0 {
+			m.SeqLen = 64
+		}
+	}
+	return m
+}
+
+// byoEmbedModels returns the config's valid BYO entries that don't shadow a
+// built-in ID.
+func byoEmbedModels() []EmbedModel {
+	var out []EmbedModel
+	for _, b := range appconfig.Get().ByoEmbedModels {
+		if b.Validate() != nil {
+			continue
+		}
+		if _, clash := embedModelRegistry[strings.TrimSpace(b.ID)]; clash {
+			continue // BYO may not shadow a built-in ID
+		}
+		out = append(out, byoToEmbedModel(b))
+	}
+	return out
+}
+
+// EmbedModelByID returns the model for id — built-in first, then the config's
+// BYO entries — and whether it exists.
 func EmbedModelByID(id string) (EmbedModel, bool) {
-	m, ok := embedModelRegistry[id]
-	return m, ok
+	if m, ok := embedModelRegistry[id]; ok {
+		return m, true
+	}
+	for _, m := range byoEmbedModels() {
+		if m.ID == id {
+			return m, true
+		}
+	}
+	return EmbedModel{}, false
 }
 
 // EmbedModelList returns all registered models in a stable, display order
-// (default/multimodal first) for populating the config UI.
+// (default/multimodal first, then valid BYO entries) for populating the
+// config UI.
 func EmbedModelList() []EmbedModel {
 	order := []string{"siglip2-base-patch16-224", "dinov2-base"}
 	out := make([]EmbedModel, 0, len(embedModelRegistry))
@@ -113,14 +228,13 @@ func EmbedModelList() []EmbedModel {
 			out = append(out, m)
 		}
 	}
-	return out
+	return append(out, byoEmbedModels()...)
 }
 
 // ActiveEmbedModel returns the configured active model, falling back to the
 // default when the config is empty or names an unknown model.
 func ActiveEmbedModel() EmbedModel {
-	id := appconfig.Get().EmbeddingModel
-	if m, ok := embedModelRegistry[id]; ok {
+	if m, ok := EmbedModelByID(strings.TrimSpace(appconfig.Get().EmbeddingModel)); ok {
 		return m
 	}
 	return embedModelRegistry[DefaultEmbedModelID]
@@ -150,3 +150,61 @@ func TestSearchByVectorModelGuard(t *testing.T) {
 		t.Errorf("model B search = %+v, want b-hit.jpg from brute-force (index must be skipped)", hitsB)
 	}
 }
+
+func TestByoEmbedModelResolution(t *testing.T) {
+	prev := appconfig.Get()
+	t.Cleanup(func() { appconfig.Set(prev) })
+	cfg := prev
+	cfg.EmbeddingModel = "clip-vit-l-14"
+	cfg.ByoEmbedModels = []appconfig.ByoEmbedModel{
+		{
+			ID:             "clip-vit-l-14",
+			ImageModelPath: "/models/clip/image.onnx",
+			Dim:            768,
+			OutputName:     "image_embeds",
+			Size:           336,
+			Mean:           []float64{0.4815, 0.4578, 0.4082},
+			Std:            []float64{0.2686, 0.2613, 0.2758},
+			CropPct:        0.875,
+			TextModelPath:  "/models/clip/text.onnx",
+			TokenizerPath:  "/models/clip/tokenizer.model",
+		},
+		{ID: "dinov2-base", ImageModelPath: "/x.onnx", Dim: 4}, // shadows built-in
+		{ID: "broken", ImageModelPath: "/x.onnx"},              // no dim
+	}
+	appconfig.Set(cfg)
+
+	m := ActiveEmbedModel()
+	if !m.BYO || m.ID != "clip-vit-l-14" || m.Dim != 768 {
+		t.Fatalf("BYO model not active: %+v", m)
+	}
+	if m.ImgInput != "pixel_values" || m.ImgOutput != "image_embeds" {
+		t.Errorf("tensor names = %q/%q", m.ImgInput, m.ImgOutput)
+	}
+	if m.Width != 336 || m.Height != 336 || m.CropMode != "center" || m.Pooling != "none" {
+		t.Errorf("preprocessing not carried: %+v", m)
+	}
+	if !m.Multimodal || m.TextInput != "input_ids" || m.SeqLen != 64 {
+		t.Errorf("text encoder defaults not filled: %+v", m)
+	}
+	// A multimodal BYO model serves text search itself.
+	if got := TextSearchModel().ID; got != "clip-vit-l-14" {
+		t.Errorf("text search model = %q, want the BYO model", got)
+	}
+	if d, ok := EmbedModelByID("dinov2-base"); !ok || d.BYO || d.Dim != 768 {
+		t.Errorf("built-in dinov2 shadowed: %+v", d)
+	}
+	if _, ok := EmbedModelByID("broken"); ok {
+		t.Error("invalid BYO entry resolved")
+	}
+	ids := map[string]bool{}
+	for _, l := range EmbedModelList() {
+		ids[l.ID] = true
+	}
+	if !ids["clip-vit-l-14"] || ids["broken"] {
+		t.Errorf("EmbedModelList = %v", ids)
+	}
+	if _, err := embedModelFilePath(m, m.ImageModelFile); err == nil {
+		t.Error("missing BYO file should be reported")
+	}
+}
@@ -0,0 +1,300 @@
+package tasks
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stevecastle/shrike/jobqueue"
+	"github.com/stevecastle/shrike/media"
+	"github.com/stevecastle/shrike/mediaext"
+)
+
+// Enrolling people from reference photos.
+//
+// A person normally comes into being when clustering forms an anonymous
+// "Unknown #N" group and someone renames it. When folders of labeled
+// portraits already exist — <root>/<Person Name>/*.jpg — enroll-people skips
+// that step: each photo's face is detected and embedded under the active
+// recognizer, filed under the named person (created when missing), and
+// assigned as 'user'. User-assigned faces are the clustering seeds that
+// anchor a person, so the next pass attaches library faces to the right names
+// from the start.
+//
+// A reference photo is useful only when it is unambiguous. Photos with no
+// detectable face, or with several, are reported and (by default) skipped.
+
+var enrollPeopleOptions = []TaskOption{
+	{Name: "target", Label: "Reference Directory", Type: "string",
+		Description: "Directory holding one subfolder per person (<root>/<Person Name>/*.jpg). A bare directory argument works too"},
+	{Name: "multi-face", Label: "Photos With Several Faces", Type: "enum", Choices: []string{"skip", "largest"}, Default: "skip",
+		Description: "skip reports and ignores the photo; largest enrolls its largest face"},
+	{Name: "overwrite", Label: "Overwrite", Type: "bool",
+		Description: "Re-detect photos that are already enrolled to their person"},
+	{Name: "cluster", Label: "Cluster Afterwards", Type: "bool", Default: true,
+		Description: "Queue a faces-cluster pass when new faces were enrolled, so library faces join the new people"},
+}
+
+// enrollPerson is one person's folder of reference photos.
+type enrollPerson struct {
+	Name   string
+	Photos []string
+}
+
+// listEnrollPeople reads root's person folders: each direct subdirectory is a
+// person named after it, holding the image files directly inside it. Folders
+// without images are left out; order is by name for a stable job log.
+func listEnrollPeople(root string) ([]enrollPerson, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	var out []enrollPerson
+	for _, e := range entries {
+		name := strings.TrimSpace(e.Name())
+		if !e.IsDir() || name == "" || strings.HasPrefix(name, ".") {
+			continue
+		}
+		dir := filepath.Join(root, e.Name())
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		p := enrollPerson{Name: name}
+		for _, f := range files {
+			if !f.IsDir() && mediaext.IsImage(f.Name()) {
+				p.Photos = append(p.Photos, filepath.Join(dir, f.Name()))
+			}
+		}
+		if len(p.Photos) > 0 {
+			sort.Strings(p.Photos)
+			out = append(out, p)
+		}
+	}
+	sort.Slice(out, func(i, k int) bool { return out[i].Name < out[k].Name })
+	return out, nil
+}
+
+// resolveNamedPerson finds the person a display name refers to (as typed, or
+// its "_cluster" form) or creates them. Shared by enrollment (folder names)
+// and XMP import (region names).
+func resolveNamedPerson(db *sql.DB, name string) (int64, bool, error) {
+	p, ok, err := media.GetPersonByDisplayName(db, name)
+	if err != nil {
+		return 0, false, err
+	}
+	if ok {
+		return p.ID, false, nil
+	}
+	id, err := media.CreatePerson(db, name)
+	return id, err == nil, err
+}
+
+// photoEnrolled reports whether photo already carries a face user-assigned
+// to personID under model.
+func photoEnrolled(db *sql.DB, photo, model string, personID int64) (bool, error) {
+	faces, err := media.GetFaces(db, photo, model)
+	if err != nil {
+		return false, err
+	}
+	for _, f := range faces {
+		if f.PersonID == personID && f.AssignedBy == "user" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// largestFace is the index of the face with the biggest box.
+func largestFace(faces []media.NewFace) int {
+	best := 0
+	for i, f := range faces {
+		if f.W*f.H > faces[best].W*faces[best].H {
+			best = i
+		}
+	}
+	return best
+}
+
+// enrollPhotoFace stores photo's detections and assigns faces[seed] to the
+// person as a user label. A reference photo outside the library keeps only
+// its seed face — its other detections would surface as ungrouped strangers
+// with nothing to view them in. A library item keeps every face, exactly as
+// a faces scan would have stored them. Returns the seed face's ID.
+func enrollPhotoFace(db *sql.DB, model FaceModel, personID int64, photo string, faces []media.NewFace, seed int) (int64, error) {
+	var inLibrary int
+	if err := db.QueryRow(`SELECT 1 FROM media WHERE path = ?`, photo).Scan(&inLibrary); err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+	if inLibrary != 1 {
+		faces, seed = faces[seed:seed+1], 0
+	}
+	ids, err := media.ReplaceFaces(db, photo, model.ID, faces, time.Now().Unix())
+	if err != nil {
+		return 0, err
+	}
+	faceIndexReplacePath(model.ID, photo, ids, faces)
+	if err := media.AssignFace(db, ids[seed], personID, "user"); err != nil {
+		return 0, err
+	}
+	return ids[seed], nil
+}
+
+func enrollPeopleTask(j *jobqueue.Job, q *jobqueue.Queue, mu *sync.Mutex) error {
+	ctx := j.Ctx
+
+	tokens := dirTaskTokens(j)
+	opts := ParseOptions(&jobqueue.Job{Arguments: tokens}, enrollPeopleOptions)
+	root, _ := opts["target"].(string)
+	multiFace, _ := opts["multi-face"].(string)
+	overwrite, _ := opts["overwrite"].(bool)
+	cluster, _ := opts["cluster"].(bool)
+	if root == "" {
+		for _, tok := range tokens {
+			if !strings.HasPrefix(tok, "-") {
+				root = tok
+				break
+			}
+		}
+	}
+	if root == "" {
+		q.PushJobStdout(j.ID, "Error: a reference directory is required (<root>/<Person Name>/*.jpg)")
+		q.ErrorJob(j.ID)
+		return fmt.Errorf("reference directory required")
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		q.PushJobStdout(j.ID, fmt.Sprintf("Error resolving directory: %v", err))
+		q.ErrorJob(j.ID)
+		return err
+	}
+	people, err := listEnrollPeople(absRoot)
+	if err != nil {
+		q.PushJobStdout(j.ID, fmt.Sprintf("Error reading %s: %v", absRoot, err))
+		q.ErrorJob(j.ID)
+		return err
+	}
+	total := 0
+	for _, p := range people {
+		total += len(p.Photos)
+	}
+	if total == 0 {
+		q.PushJobStdout(j.ID, fmt.Sprintf("No person folders with images under %s", absRoot))
+		q.CompleteJob(j.ID)
+		return nil
+	}
+
+	model := ActiveFaceModel()
+	q.PushJobStdout(j.ID, fmt.Sprintf("Enrolling %d photo(s) of %d person(s) from %s with %s", total, len(people), absRoot, model.ID))
+	_ = q.SetJobProgress(j.ID, 0, total)
+
+	var enrolled, already, created, failed int
+	var noFace, multi []string
+	done := 0
+	finish := func() {
+		if enrolled > 0 {
+			broadcastPeopleUpdated([]string{model.ID})
+		}
+	}
+	for _, p := range people {
+		personID, isNew, err := resolveNamedPerson(q.Db, p.Name)
+		if err != nil {
+			q.PushJobStdout(j.ID, fmt.Sprintf("Warning: could not create person %q: %v", p.Name, err))
+			failed += len(p.Photos)
+			done += len(p.Photos)
+			continue
+		}
+		if isNew {
+			created++
+		}
+		for _, photo := range p.Photos {
+			select {
+			case <-ctx.Done():
+				q.PushJobStdout(j.ID, "Task was canceled")
+				finish()
+				_ = q.CancelJob(j.ID)
+				return ctx.Err()
+			default:
+			}
+			if q.PauseRequested(j.ID) {
+				q.PushJobStdout(j.ID, fmt.Sprintf("Paused at %d/%d - resume to continue", done, total))
+				finish()
+				return jobqueue.ErrPaused
+			}
+			_ = q.SetJobProgress(j.ID, done, total)
+			done++
+
+			if !overwrite {
+				if ok, err := photoEnrolled(q.Db, photo, model.ID, personID); err == nil && ok {
+					already++
+					continue
+				}
+			}
+			faces, err := detectEnrollFaces(ctx, model, photo)
+			if err != nil {
+				q.PushJobStdout(j.ID, fmt.Sprintf("Warning: face detection failed for %s: %v", photo, err))
+				failed++
+				continue
+			}
+			seed := 0
+			switch {
+			case len(faces) == 0:
+				noFace = append(noFace, photo)
+				continue
+			case len(faces) > 1:
+				multi = append(multi, photo)
+				if multiFace != "largest" {
+					continue
+				}
+				seed = largestFace(faces)
+			}
+			if _, err := enrollPhotoFace(q.Db, model, personID, photo, faces, seed); err != nil {
+				q.PushJobStdout(j.ID, fmt.Sprintf("Warning: could not enroll %s: %v", photo, err))
+				failed++
+				continue
+			}
+			enrolled++
+		}
+	}
+	_ = q.SetJobProgress(j.ID, total, total)
+	finish()
+
+	for _, photo := range noFace {
+		q.PushJobStdout(j.ID, "No face found: "+photo)
+	}
+	for _, photo := range multi {
+		if multiFace == "largest" {
+			q.PushJobStdout(j.ID, "Several faces, enrolled the largest: "+photo)
+		} else {
+			q.PushJobStdout(j.ID, "Several faces, skipped: "+photo)
+		}
+	}
+	q.PushJobStdout(j.ID, fmt.Sprintf(
+		"Enrollment complete: %d face(s) enrolled, %d already enrolled, %d new person(s), %d photo(s) with no face, %d with several, %d failure(s)",
+		enrolled, already, created, len(noFace), len(multi), failed))
+	if cluster {
+		if id, ok := maybeQueueFaceClustering(q, int64(enrolled)); ok {
+			q.PushJobStdout(j.ID, "Queued faces-cluster job "+id)
+		}
+	}
+	q.CompleteJob(j.ID)
+	return nil
+}
+
+// detectEnrollFaces runs the face pipeline on one reference photo.
+func detectEnrollFaces(ctx context.Context, model FaceModel, photo string) ([]media.NewFace, error) {
+	imagePath, tempFrame, err := extractFrameForFile(ctx, photo, 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	if tempFrame != "" {
+		defer os.Remove(tempFrame)
+	}
+	return FacesInImageFile(ctx, model, imagePath)
+}
@@ -0,0 +1,113 @@
+package tasks
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stevecastle/shrike/media"
+	_ "modernc.org/sqlite"
+)
+
+func TestListEnrollPeople(t *testing.T) {
+	root := t.TempDir()
+	for _, f := range []string{
+		"Alice/1.jpg", "Alice/2.PNG", "Alice/notes.txt", "Alice/nested/3.jpg",
+		"Bob Smith/portrait.webp",
+		"Empty/readme.md",
+		".hidden/x.jpg",
+		"loose.jpg",
+	} {
+		p := filepath.Join(root, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	people, err := listEnrollPeople(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(people) != 2 || people[0].Name != "Alice" || people[1].Name != "Bob Smith" {
+		t.Fatalf("people = %+v", people)
+	}
+	want := []string{filepath.Join(root, "Alice", "1.jpg"), filepath.Join(root, "Alice", "2.PNG")}
+	if got := people[0].Photos; len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("Alice photos = %v, want %v (direct images only)", got, want)
+	}
+}
+
+func newEnrollDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	if err := media.InitializeSchema(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestEnrollPhotoFaceSeedsPerson(t *testing.T) {
+	db := newEnrollDB(t)
+	model := ActiveFaceModel()
+	faces := []media.NewFace{
+		{X: 0.1, Y: 0.1, W: 0.1, H: 0.1, Score: 0.9, Vec: []float32{0, 1}},
+		{X: 0.4, Y: 0.2, W: 0.4, H: 0.4, Score: 0.9, Vec: []float32{1, 0}},
+	}
+	if got := largestFace(faces); got != 1 {
+		t.Fatalf("largestFace = %d, want 1", got)
+	}
+
+	alice, isNew, err := resolveNamedPerson(db, "Alice")
+	if err != nil || !isNew {
+		t.Fatalf("resolve new person: %d %v %v", alice, isNew, err)
+	}
+	if again, isNew, _ := resolveNamedPerson(db, "Alice"); again != alice || isNew {
+		t.Fatalf("second resolve = %d (new %v), want existing %d", again, isNew, alice)
+	}
+
+	// Outside the library only the seed face is stored.
+	ref := "/refs/Alice/1.jpg"
+	id, err := enrollPhotoFace(db, model, alice, ref, faces, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := media.GetFaces(db, ref, model.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 1 || stored[0].ID != id || stored[0].PersonID != alice || stored[0].AssignedBy != "user" {
+		t.Fatalf("reference faces = %+v", stored)
+	}
+	if ok, _ := photoEnrolled(db, ref, model.ID, alice); !ok {
+		t.Fatal("photo not reported as enrolled")
+	}
+
+	// A library photo keeps its other detections for clustering.
+	lib := "/library/party.jpg"
+	if _, err := db.Exec(`INSERT INTO media (path) VALUES (?)`, lib); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := enrollPhotoFace(db, model, alice, lib, faces, 1); err != nil {
+		t.Fatal(err)
+	}
+	stored, _ = media.GetFaces(db, lib, model.ID)
+	if len(stored) != 2 {
+		t.Fatalf("library faces = %d, want 2", len(stored))
+	}
+	var tagged int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM media_tag_by_category WHERE media_path = ? AND tag_label = 'Alice'`, lib).Scan(&tagged); err != nil {
+		t.Fatal(err)
+	}
+	if tagged != 1 {
+		t.Fatalf("library photo People rows = %d, want 1", tagged)
+	}
+}
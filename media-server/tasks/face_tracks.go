@@ -0,0 +1,291 @@
+package tasks
+
+// face_tracks.go — multi-frame face scanning for videos. A video is sampled
+// at a fixed interval (or at scene changes), every sample goes through the
+// same faces worker a photo does, and detections on adjacent samples are
+// chained into tracks: a face that keeps its place (box overlap) and its
+// identity (embedding similarity) from one sample to the next is the same
+// face. Each track stores ONE face row — its best detection, so clustering,
+// search, and crops see one clean example per appearance instead of dozens
+// of near-duplicates — plus the span the face was followed over.
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/stevecastle/shrike/deps"
+	"github.com/stevecastle/shrike/embedvec"
+	"github.com/stevecastle/shrike/media"
+	"github.com/stevecastle/shrike/platform"
+)
+
+const (
+	// defaultVideoFaceInterval is the seconds between samples in interval
+	// mode. Two seconds keeps consecutive samples close enough for box
+	// overlap to mean something while a feature film stays in the low
+	// thousands of frames.
+	defaultVideoFaceInterval = 2.0
+	// defaultVideoFaceMaxFrames caps samples per video; longer videos widen
+	// the interval so the samples still span the whole runtime.
+	defaultVideoFaceMaxFrames = 1800
+	// defaultSceneThreshold is ffmpeg's scene score (0..1) above which a
+	// frame counts as a scene change.
+	defaultSceneThreshold = 0.3
+)
+
+// videoSampling says which frames of a video the face scan looks at.
+// Interval <= 0 keeps the historical single midpoint frame.
+type videoSampling struct {
+	Interval       float64
+	Scenes         bool // sample at scene changes (plus the first frame) instead
+	SceneThreshold float64
+	MaxFrames      int
+}
+
+// videoFrame is one extracted sample and its timestamp in seconds.
+type videoFrame struct {
+	Path string
+	TS   float64
+}
+
+var showinfoPTS = regexp.MustCompile(`pts_time:\s*(-?[0-9.]+)`)
+
+// sampleVideoFrames extracts s's samples from videoPath into dir (which the
+// caller removes) in one ffmpeg pass. timeout bounds the pass on top of half
+// the video's runtime — sampling decodes the whole stream, so a fixed
+// per-file timeout would cut long videos short.
+func sampleVideoFrames(ctx context.Context, videoPath, dir string, s videoSampling, timeout time.Duration) ([]videoFrame, error) {
+	duration := probeVideoDuration(ctx, videoPath)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout+time.Duration(duration*float64(time.Second)/2))
+		defer cancel()
+	}
+	maxFrames := s.MaxFrames
+	if maxFrames <= 0 {
+		maxFrames = defaultVideoFaceMaxFrames
+	}
+
+	var filter string
+	interval := s.Interval
+	if s.Scenes {
+		threshold := s.SceneThreshold
+		if threshold <= 0 || threshold >= 1 {
+			threshold = defaultSceneThreshold
+		}
+		filter = fmt.Sprintf(`select='eq(n\,0)+gt(scene\,%g)',showinfo`, threshold)
+	} else {
+		if duration > 0 && duration/interval > float64(maxFrames) {
+			interval = duration / float64(maxFrames)
+		}
+		filter = fmt.Sprintf("fps=1/%g", interval)
+	}
+	pattern := filepath.Join(dir, "frame_%06d.jpg")
+	args := []string{
+		"-i", videoPath,
+		"-an", "-sn",
+		"-vf", filter,
+		"-vsync", "vfr",
+		"-frames:v", strconv.Itoa(maxFrames),
+		"-q:v", "3",
+		"-y", pattern,
+	}
+	cmd := exec.CommandContext(ctx, deps.MustBundled("ffmpeg"), args...)
+	platform.HideSubprocessWindow(cmd)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg frame sampling failed: %w\nOutput: %s", err, tailString(string(output), 2000))
+	}
+
+	var stamps []float64
+	if s.Scenes {
+		for _, m := range showinfoPTS.FindAllStringSubmatch(string(output), -1) {
+			if ts, err := strconv.ParseFloat(m[1], 64); err == nil {
+				stamps = append(stamps, ts)
+			}
+		}
+	}
+	var frames []videoFrame
+	for i := 1; ; i++ {
+		p := fmt.Sprintf(pattern, i)
+		if _, err := os.Stat(p); err != nil {
+			break
+		}
+		ts := float64(i-1) * interval
+		if s.Scenes {
+			if i > len(stamps) {
+				break // a frame ffmpeg didn't report has no known time
+			}
+			ts = stamps[i-1]
+		}
+		frames = append(frames, videoFrame{Path: p, TS: ts})
+	}
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("ffmpeg sampled no frames")
+	}
+	return frames, nil
+}
+
+// ExtractFrameAt extracts the frame ts seconds into videoPath to a temp JPEG
+// the caller deletes — the exact frame a tracked face was detected on, so its
+// stored relative bbox lines up. Exported for the face-crop endpoint.
+func ExtractFrameAt(ctx context.Context, videoPath string, ts float64) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	out := filepath.Join(os.TempDir(), fmt.Sprintf("face_frame_%d.jpg", time.Now().UnixNano()))
+	if err := runFFmpegSingleFrame(ctx, videoPath, out, ts); err != nil {
+		_ = os.Remove(out)
+		return "", err
+	}
+	return out, nil
+}
+
+// tailString keeps the last n bytes of s (ffmpeg's useful error is at the end
+// of a long banner).
+func tailString(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[len(s)-n:]
+}
+
+// frameFaces is one sample's detections.
+type frameFaces struct {
+	TS    float64
+	Faces []media.NewFace
+}
+
+// trackParams tune how detections chain into tracks.
+type trackParams struct {
+	// MinIoU and MinSim together continue a track: the box overlaps the
+	// track's last box and the embedding loosely agrees (blur and profile
+	// views drag similarity well below the identity threshold).
+	MinIoU float64
+	MinSim float32
+	// StrongSim continues a track on identity alone — across a cut or fast
+	// motion where the box jumps.
+	StrongSim float32
+	// MaxGap is how long (seconds) a track may go unseen before it ends.
+	MaxGap float64
+}
+
+// defaultTrackParams derives tracking thresholds from the recognizer's
+// identity threshold and the sampling interval: a face may be missed on one
+// sample (a blink, a turned head) without splitting its track.
+func defaultTrackParams(m FaceModel, interval float64) trackParams {
+	threshold := m.MatchThreshold
+	if threshold <= 0 {
+		threshold = 0.4
+	}
+	if interval <= 0 {
+		interval = defaultVideoFaceInterval
+	}
+	return trackParams{
+		MinIoU:    0.3,
+		MinSim:    threshold / 2,
+		StrongSim: threshold,
+		MaxGap:    2.5 * interval,
+	}
+}
+
+// faceTrack is a track being built.
+type faceTrack struct {
+	start, last float64
+	box         media.NewFace // latest detection (position + embedding)
+	best        media.NewFace
+	bestTS      float64
+}
+
+// faceQuality ranks detections within a track: confidence weighted by area,
+// the same ordering cover crops use (media.PersonFacesByQuality).
+func faceQuality(f media.NewFace) float64 { return f.Score * f.W * f.H }
+
+// boxIoU is the intersection over union of two relative bboxes.
+func boxIoU(a, b media.NewFace) float64 {
+	x0, y0 := max(a.X, b.X), max(a.Y, b.Y)
+	x1, y1 := min(a.X+a.W, b.X+b.W), min(a.Y+a.H, b.Y+b.H)
+	if x1 <= x0 || y1 <= y0 {
+		return 0
+	}
+	inter := (x1 - x0) * (y1 - y0)
+	union := a.W*a.H + b.W*b.H - inter
+	if union <= 0 {
+		return 0
+	}
+	return inter / union
+}
+
+// trackFaces chains per-sample detections (in time order) into tracks and
+// returns one face per track: its best detection, stamped with that
+// detection's time and the track's span. Matching is greedy per sample,
+// strongest link first, so two people crossing paths keep their own tracks.
+func trackFaces(frames []frameFaces, p trackParams) []media.NewFace {
+	var open, done []*faceTrack
+	for _, fr := range frames {
+		// Retire tracks that have gone unseen too long.
+		kept := open[:0]
+		for _, t := range open {
+			if fr.TS-t.last > p.MaxGap {
+				done = append(done, t)
+			} else {
+				kept = append(kept, t)
+			}
+		}
+		open = kept
+
+		type link struct {
+			t, d  int
+			score float64
+		}
+		var links []link
+		for ti, t := range open {
+			for di, d := range fr.Faces {
+				sim := embedvec.Cosine(t.box.Vec, d.Vec)
+				iou := boxIoU(t.box, d)
+				switch {
+				case iou >= p.MinIoU && sim >= p.MinSim:
+					links = append(links, link{ti, di, 1 + iou + float64(sim)})
+				case sim >= p.StrongSim:
+					links = append(links, link{ti, di, float64(sim)})
+				}
+			}
+		}
+		sort.SliceStable(links, func(i, j int) bool { return links[i].score > links[j].score })
+		usedT := make([]bool, len(open))
+		usedD := make([]bool, len(fr.Faces))
+		for _, l := range links {
+			if usedT[l.t] || usedD[l.d] {
+				continue
+			}
+			usedT[l.t], usedD[l.d] = true, true
+			t, d := open[l.t], fr.Faces[l.d]
+			t.last, t.box = fr.TS, d
+			if faceQuality(d) > faceQuality(t.best) {
+				t.best, t.bestTS = d, fr.TS
+			}
+		}
+		for di, d := range fr.Faces {
+			if !usedD[di] {
+				open = append(open, &faceTrack{start: fr.TS, last: fr.TS, box: d, best: d, bestTS: fr.TS})
+			}
+		}
+	}
+	done = append(done, open...)
+	sort.SliceStable(done, func(i, j int) bool { return done[i].start < done[j].start })
+
+	out := make([]media.NewFace, 0, len(done))
+	for _, t := range done {
+		f := t.best
+		f.FrameTS = t.bestTS
+		f.Tracked, f.TrackStart, f.TrackEnd = true, t.start, t.last
+		out = append(out, f)
+	}
+	return out
+}
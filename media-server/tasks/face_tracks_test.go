@@ -0,0 +1,108 @@
+package tasks
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stevecastle/shrike/media"
+)
+
+// det is a detection at (x, y) with a fixed 0.2 box, unit embedding vec, and
+// confidence score.
+func det(x, y float64, vec []float32, score float64) media.NewFace {
+	return media.NewFace{X: x, Y: y, W: 0.2, H: 0.2, Score: score, Vec: vec}
+}
+
+var (
+	faceA = []float32{1, 0}
+	faceB = []float32{0, 1}
+)
+
+func testTrackParams() trackParams {
+	return defaultTrackParams(FaceModel{MatchThreshold: 0.4}, 2)
+}
+
+func TestBoxIoU(t *testing.T) {
+	a := det(0, 0, nil, 1)
+	if got := boxIoU(a, a); math.Abs(got-1) > 1e-9 {
+		t.Errorf("self IoU = %v, want 1", got)
+	}
+	if got := boxIoU(a, det(0.5, 0.5, nil, 1)); got != 0 {
+		t.Errorf("disjoint IoU = %v, want 0", got)
+	}
+	// Half-width shift: overlap 0.1×0.2 over union 0.06.
+	if got := boxIoU(a, det(0.1, 0, nil, 1)); math.Abs(got-1.0/3) > 1e-9 {
+		t.Errorf("shifted IoU = %v, want 1/3", got)
+	}
+}
+
+func TestTrackFacesKeepsBestDetectionPerTrack(t *testing.T) {
+	// Two people side by side for three samples: two tracks, each keeping
+	// its most confident detection and the time it was seen.
+	frames := []frameFaces{
+		{TS: 0, Faces: []media.NewFace{det(0.10, 0.1, faceA, 0.6), det(0.60, 0.1, faceB, 0.9)}},
+		{TS: 2, Faces: []media.NewFace{det(0.12, 0.1, faceA, 0.95), det(0.62, 0.1, faceB, 0.7)}},
+		{TS: 4, Faces: []media.NewFace{det(0.14, 0.1, faceA, 0.8), det(0.64, 0.1, faceB, 0.8)}},
+	}
+	got := trackFaces(frames, testTrackParams())
+	if len(got) != 2 {
+		t.Fatalf("tracks = %d, want 2: %+v", len(got), got)
+	}
+	for _, f := range got {
+		if !f.Tracked || f.TrackStart != 0 || f.TrackEnd != 4 {
+			t.Errorf("span = %v..%v (tracked %v), want 0..4", f.TrackStart, f.TrackEnd, f.Tracked)
+		}
+	}
+	byVec := map[float32]media.NewFace{}
+	for _, f := range got {
+		byVec[f.Vec[0]] = f
+	}
+	if a := byVec[1]; a.Score != 0.95 || a.FrameTS != 2 {
+		t.Errorf("A best = score %v at %v, want 0.95 at 2", a.Score, a.FrameTS)
+	}
+	if b := byVec[0]; b.Score != 0.9 || b.FrameTS != 0 {
+		t.Errorf("B best = score %v at %v, want 0.9 at 0", b.Score, b.FrameTS)
+	}
+}
+
+func TestTrackFacesSplitsOnLongGap(t *testing.T) {
+	p := testTrackParams() // MaxGap 5s
+	frames := []frameFaces{
+		{TS: 0, Faces: []media.NewFace{det(0.1, 0.1, faceA, 0.9)}},
+		{TS: 2, Faces: []media.NewFace{det(0.1, 0.1, faceA, 0.9)}},
+		{TS: 20, Faces: []media.NewFace{det(0.1, 0.1, faceA, 0.9)}},
+	}
+	got := trackFaces(frames, p)
+	if len(got) != 2 {
+		t.Fatalf("tracks = %d, want 2 (gap > MaxGap ends the first)", len(got))
+	}
+	if got[0].TrackStart != 0 || got[0].TrackEnd != 2 || got[1].TrackStart != 20 || got[1].TrackEnd != 20 {
+		t.Errorf("spans = %v..%v, %v..%v", got[0].TrackStart, got[0].TrackEnd, got[1].TrackStart, got[1].TrackEnd)
+	}
+}
+
+func TestTrackFacesFollowsIdentityAcrossBoxJump(t *testing.T) {
+	// A cut moves the face across the frame: no overlap, but the embedding
+	// clears StrongSim so the track continues. A different face in the old
+	// position does not steal it.
+	frames := []frameFaces{
+		{TS: 0, Faces: []media.NewFace{det(0.1, 0.1, faceA, 0.9)}},
+		{TS: 2, Faces: []media.NewFace{det(0.7, 0.6, faceA, 0.9), det(0.1, 0.1, faceB, 0.9)}},
+	}
+	got := trackFaces(frames, testTrackParams())
+	if len(got) != 2 {
+		t.Fatalf("tracks = %d, want 2: %+v", len(got), got)
+	}
+	for _, f := range got {
+		switch f.Vec[0] {
+		case 1:
+			if f.TrackStart != 0 || f.TrackEnd != 2 {
+				t.Errorf("A span = %v..%v, want 0..2", f.TrackStart, f.TrackEnd)
+			}
+		default:
+			if f.TrackStart != 2 {
+				t.Errorf("B start = %v, want 2", f.TrackStart)
+			}
+		}
+	}
+}
@@ -229,7 +229,7 @@ func FaceModelByID(id string) (FaceModel, bool) {
 		return m, true
 	}
 	for _, b := range appconfig.Get().ByoFaceModels {
-		if strings.TrimSpace(b.ID) == id && b.ModelPath != "" && b.Dim > 0 {
+		if strings.TrimSpace(b.ID) == id && b.Validate() == nil {
 			return byoToFaceModel(b), true
 		}
 	}
@@ -263,7 +263,7 @@ func FaceModelList() []FaceModel {
 		}
 	}
 	for _, b := range appconfig.Get().ByoFaceModels {
-		if strings.TrimSpace(b.ID) == "" || b.ModelPath == "" || b.Dim <= 0 {
+		if b.Validate() != nil {
 			continue
 		}
 		if _, clash := builtinFaceModels[strings.TrimSpace(b.ID)]; clash {
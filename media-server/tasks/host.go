@@ -1,12 +1,14 @@
 package tasks
 
 import (
+	"net"
 	"net/url"
 	"strings"
 	"sync"
 
 	"github.com/stevecastle/shrike/appconfig"
 	"github.com/stevecastle/shrike/jobqueue"
+	"github.com/stevecastle/shrike/transcribe"
 )
 
 // HostResolverFn returns the concurrency-bucket name for a job of the
@@ -51,16 +53,25 @@ func ResolveHost(command, input string) string {
 // its work runs on local hardware.
 func opResources(op string) []string {
 	switch op {
-	case "describe":
+	case "describe", "llm-tag", "translate":
 		r := []string{InferenceHost()}
 		if InferenceHostIsLocal() {
 			r = append(r, HostBucketLocalCompute)
 		}
 		return r
 	case "transcribe":
-		// Transcription runs faster-whisper on the local GPU.
-		return []string{HostBucketLocalCompute}
-	case "embed":
+		// Faster-Whisper runs on the local GPU; an HTTP service has its own
+		// bucket and only shares local compute when it runs on this machine.
+		if _, remote := transcribe.ActiveEndpoint(); !remote {
+			return []string{HostBucketLocalCompute}
+		}
+		r := []string{HostBucketTranscribeHTTP}
+		// Speaker labels run locally even when the transcript doesn't.
+		if TranscriptionHostIsLocal() || appconfig.Get().TranscriptionDiarize {
+			r = append(r, HostBucketLocalCompute)
+		}
+		return r
+	case "embed", "textembed", "diarize":
 		return []string{HostBucketEmbed, HostBucketLocalCompute}
 	case "autotag":
 		return []string{HostBucketAutotag, HostBucketLocalCompute}
@@ -71,6 +82,36 @@ func opResources(op string) []string {
 	}
 }
 
+// TranscriptionHost returns the concurrency bucket for standalone transcribe
+// jobs: the HTTP bucket when the active provider is a service, otherwise the
+// inference bucket transcription has always shared with the metadata task.
+func TranscriptionHost() string {
+	if _, remote := transcribe.ActiveEndpoint(); remote {
+		return HostBucketTranscribeHTTP
+	}
+	return InferenceHost()
+}
+
+// TranscriptionHostIsLocal reports whether the active HTTP transcription
+// service listens on this machine (a loopback URL), and so competes with
+// other local model work for the GPU.
+func TranscriptionHostIsLocal() bool {
+	endpoint, remote := transcribe.ActiveEndpoint()
+	if !remote {
+		return false
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
 // legacyTypeToOp mirrors media_metadata.go's mapping for resource resolution
 // of legacy `metadata --type ...` jobs.
 var legacyTypeToOp = map[string]string{
@@ -113,10 +154,15 @@ func flagListValue(flag string, arguments []string, input string) []string {
 func ResolveResources(command string, arguments []string, input string) []string {
 	var ops []string
 	switch command {
-	case "describe", "transcribe", "embed", "autotag", "faces":
+	case "describe", "llm-tag", "transcribe", "embed", "autotag", "faces", "textembed", "diarize":
 		ops = []string{command}
-	case "faces-cluster":
-		// Clustering shares the faces bucket (its Host) and crunches vectors
+	case "translate":
+		ops = []string{command}
+		if translateEngine(arguments, input) == translateEngineWhisper {
+			ops = []string{"transcribe"}
+		}
+	case "faces-cluster", "cluster-library":
+		// Clustering shares its scan's bucket (its Host) and crunches vectors
 		// locally.
 		return []string{HostBucketLocalCompute}
 	case "process":
@@ -174,6 +220,12 @@ func ApplyHostLimits(q *jobqueue.Queue, cfg appconfig.Config) {
 	if n := cfg.InferenceConcurrency.LlamaCpp; n > 0 {
 		q.SetHostLimit(HostBucketLlamaCpp, n)
 	}
+	if n := cfg.InferenceConcurrency.OpenAI; n > 0 {
+		q.SetHostLimit(HostBucketOpenAI, n)
+	}
+	if n := cfg.TranscriptionHTTPConcurrency; n > 0 {
+		q.SetHostLimit(HostBucketTranscribeHTTP, n)
+	}
 	// One embed job at a time; the job parallelizes internally via its worker
 	// pool, so additional concurrent embed jobs would just oversubscribe.
 	q.SetHostLimit(HostBucketEmbed, 1)
@@ -31,6 +31,7 @@ func TestInferenceHostMapping(t *testing.T) {
 		{InferenceProviderRunPod, HostBucketRunPod},
 		{InferenceProviderLMStudio, HostBucketLMStudio},
 		{InferenceProviderLlamaCpp, HostBucketLlamaCpp},
+		{InferenceProviderOpenAI, HostBucketOpenAI},
 		{InferenceProviderOff, "localhost"},
 		{"", "localhost"},
 		{"something-new", "localhost"},
@@ -128,6 +129,8 @@ func TestResolveResources(t *testing.T) {
 			want: []string{HostBucketLocalCompute}},
 		{name: "describe local LLM", command: "describe",
 			want: []string{HostBucketOllama, HostBucketLocalCompute}},
+		{name: "llm-tag local LLM", command: "llm-tag",
+			want: []string{HostBucketOllama, HostBucketLocalCompute}},
 		{name: "process cheap ops", command: "process",
 			arguments: []string{"--ops=hash,dimensions"},
 			absent:    []string{HostBucketLocalCompute, HostBucketEmbed}},
@@ -173,6 +176,31 @@ func TestResolveResources(t *testing.T) {
 	}
 }
 
+// An HTTP transcription provider moves transcribe jobs into their own
+// bucket; it shares local compute only when the service is on loopback.
+func TestTranscriptionHTTPBucket(t *testing.T) {
+	setProvider(t, InferenceProviderOllama)
+	cfg := appconfig.Get()
+	cfg.TranscriptionProvider = "whisper-server"
+	cfg.TranscriptionWhisperServerURL = "http://gpu-box:8080"
+	appconfig.Set(cfg)
+
+	if h := ResolveHost("transcribe", "/a.mp4"); h != HostBucketTranscribeHTTP {
+		t.Errorf("transcribe host = %q; want %q", h, HostBucketTranscribeHTTP)
+	}
+	got := ResolveResources("transcribe", nil, "")
+	if len(got) != 1 || got[0] != HostBucketTranscribeHTTP {
+		t.Errorf("remote transcribe resources = %v; want only %q", got, HostBucketTranscribeHTTP)
+	}
+
+	cfg.TranscriptionWhisperServerURL = "http://127.0.0.1:8080"
+	appconfig.Set(cfg)
+	got = ResolveResources("process", []string{"--ops=transcribe"}, "")
+	if len(got) != 2 || got[0] != HostBucketTranscribeHTTP || got[1] != HostBucketLocalCompute {
+		t.Errorf("loopback transcribe resources = %v; want HTTP bucket plus local-compute", got)
+	}
+}
+
 // TestLocalComputeSerializesHeavyJobs is the scenario from the field: an
 // embed job, an autotag job, and a faces job live in three different buckets
 // and used to run simultaneously, stacking three full-machine worker pools
@@ -6,8 +6,9 @@ package tasks
 // hash, store an embedding, ...). Every op runs through the same runner
 // (runItemOps), which gives all of them one consistent contract:
 //
-//   - Input resolution: a job can carry a search query (--query/--query64) or
-//     an explicit path list; both resolve through resolveJobItems.
+//   - Input resolution: a job can carry a search query (--query/--query64),
+//     a saved search (--saved), or an explicit path list; all resolve
+//     through resolveJobItems.
 //   - Progress: the runner reports done/total via Queue.SetJobProgress as soon
 //     as the input resolves and after every item.
 //   - Overwrite: the shared --overwrite flag means "reprocess items that
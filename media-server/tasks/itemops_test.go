@@ -127,7 +127,7 @@ func countStored(m *sync.Map) int {
 // combine — faces included. A missing entry here means a per-item task
 // silently fell out of the unified system.
 func TestBuiltinOpsAreCombinable(t *testing.T) {
-	want := []string{"describe", "transcribe", "hash", "dimensions", "embed", "autotag", "faces"}
+	want := []string{"describe", "transcribe", "hash", "dimensions", "embed", "autotag", "faces", "llm-tag"}
 	ids := ItemOpIDs()
 	have := make(map[string]bool, len(ids))
 	for _, id := range ids {
@@ -191,6 +191,33 @@ func TestResolveJobItemsCommaPathsSurvive(t *testing.T) {
 	}
 }
 
+func TestResolveJobItemsSavedSearch(t *testing.T) {
+	db := setupItemOpsDB(t)
+	q := jobqueue.NewQueueWithDB(db)
+	t.Cleanup(func() { SetSavedSearchResolver(nil) })
+
+	var asked string
+	SetSavedSearchResolver(func(_ context.Context, _ *sql.DB, name string) ([]string, error) {
+		asked = name
+		return []string{"/lib/a.jpg", "/lib/notes.json"}, nil
+	})
+	id, _ := q.AddJob("", "autotag", []string{"--saved", "best", "cats", "--overwrite"}, "", nil)
+	res, err := resolveJobItems(q.GetJob(id), q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if asked != "best cats" || !res.FromQuery || res.Query != `saved:"best cats"` {
+		t.Errorf("asked %q, res = %+v", asked, res)
+	}
+	if len(res.Paths) != 1 || res.Paths[0] != "/lib/a.jpg" {
+		t.Errorf("paths = %v, want the media file only", res.Paths)
+	}
+	// A --saved job's items depend on the database, like a --query job's.
+	if got := ResolveItems("autotag", nil, "--saved=best"); got != nil {
+		t.Errorf("ResolveItems = %v, want nil", got)
+	}
+}
+
 func TestRunItemOpsProcessesAllItemsAndReportsProgress(t *testing.T) {
 	db := setupItemOpsDB(t)
 	paths := writeTempMedia(t, db, 3)
@@ -0,0 +1,477 @@
+package tasks
+
+// llm_tag.go — the llm-tag item op: structured tagging through the configured
+// vision provider. Where describe stores free prose, llm-tag sends a
+// per-category JSON schema (Config.LLMTagCategories, or the built-in
+// setting/objects/mood/people set), asks the provider for structured output,
+// and writes the labels it gets back as tags in those categories.
+//
+// Providers honour the schema to varying degrees (older Ollama builds and
+// some llama.cpp servers ignore it), so every reply is parsed leniently:
+// code fences and surrounding prose are stripped, a bare string stands in for
+// a one-element list, category keys match case-insensitively, and
+// vocabulary-bound categories keep only labels from their vocabulary.
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/stevecastle/shrike/appconfig"
+	"github.com/stevecastle/shrike/jobqueue"
+	"github.com/stevecastle/shrike/resultcache"
+)
+
+// llmTagDefaultMax is the per-category label cap when MaxLabels is unset.
+const llmTagDefaultMax = 5
+
+// defaultLLMTagCategories is the schema used when none is configured.
+var defaultLLMTagCategories = []appconfig.LLMTagCategory{
+	{Name: "setting", Description: "where the scene takes place",
+		Vocabulary: []string{"indoors", "outdoors", "studio", "home", "office", "street", "city", "nature", "forest", "beach", "mountains", "water", "vehicle", "stage", "night", "day"}},
+	{Name: "objects", Description: "notable objects visible in the image, as short nouns", MaxLabels: 8},
+	{Name: "mood", Description: "the overall mood or tone",
+		Vocabulary: []string{"happy", "calm", "romantic", "playful", "serious", "sad", "tense", "dramatic", "energetic", "mysterious"}, MaxLabels: 2},
+	{Name: "people", Description: "how many people are visible",
+		Vocabulary: []string{"none", "one person", "two people", "group", "crowd"}, MaxLabels: 1},
+}
+
+// resolveLLMTagCategories returns the schema for a run: the per-run JSON
+// override when given, else the configured categories, else the default.
+// Blank names are dropped, names are trimmed, and a repeated name (compared
+// case-insensitively) is an error.
+func resolveLLMTagCategories(override string) ([]appconfig.LLMTagCategory, error) {
+	cats := appconfig.Get().LLMTagCategories
+	if strings.TrimSpace(override) != "" {
+		if err := json.Unmarshal([]byte(override), &cats); err != nil {
+			return nil, fmt.Errorf("invalid schema option: %w", err)
+		}
+	}
+	if len(cats) == 0 {
+		cats = defaultLLMTagCategories
+	}
+	out := make([]appconfig.LLMTagCategory, 0, len(cats))
+	seen := map[string]bool{}
+	for _, c := range cats {
+		c.Name = strings.TrimSpace(c.Name)
+		if c.Name == "" {
+			continue
+		}
+		key := strings.ToLower(c.Name)
+		if seen[key] {
+			return nil, fmt.Errorf("category %q is listed twice", c.Name)
+		}
+		seen[key] = true
+		if c.MaxLabels <= 0 {
+			c.MaxLabels = llmTagDefaultMax
+		}
+		out = append(out, c)
+	}
+	if len(out) == 0 {
+		return nil, errors.New("schema has no named categories")
+	}
+	return out, nil
+}
+
+// buildLLMTagSchema renders cats as the JSON Schema handed to the provider:
+// one required string-array property per category, enum-bound when the
+// category has a vocabulary.
+func buildLLMTagSchema(cats []appconfig.LLMTagCategory) map[string]any {
+	props := make(map[string]any, len(cats))
+	required := make([]string, 0, len(cats))
+	for _, c := range cats {
+		items := map[string]any{"type": "string"}
+		if len(c.Vocabulary) > 0 {
+			items["enum"] = c.Vocabulary
+		}
+		prop := map[string]any{"type": "array", "items": items, "maxItems": c.MaxLabels}
+		if c.Description != "" {
+			prop["description"] = c.Description
+		}
+		props[c.Name] = prop
+		required = append(required, c.Name)
+	}
+	return map[string]any{
+		"type":                 "object",
+		"properties":           props,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// buildLLMTagPrompt spells the schema out in the prompt as well, for
+// providers that ignore structured-output hints. custom, when set, is placed
+// before the generated instructions.
+func buildLLMTagPrompt(cats []appconfig.LLMTagCategory, custom string) string {
+	var b strings.Builder
+	if custom = strings.TrimSpace(custom); custom != "" {
+		b.WriteString(custom)
+		b.WriteString("\n\n")
+	}
+	b.WriteString("Tag this image. Reply with only a JSON object with these keys, each an array of short lowercase labels:\n")
+	for _, c := range cats {
+		fmt.Fprintf(&b, "- %q", c.Name)
+		if c.Description != "" {
+			fmt.Fprintf(&b, ": %s", c.Description)
+		}
+		fmt.Fprintf(&b, " (at most %d", c.MaxLabels)
+		if len(c.Vocabulary) > 0 {
+			fmt.Fprintf(&b, "; choose only from: %s", strings.Join(c.Vocabulary, ", "))
+		}
+		b.WriteString(")\n")
+	}
+	b.WriteString("Use an empty array when nothing fits.")
+	return b.String()
+}
+
+var (
+	llmTagFence         = regexp.MustCompile("(?s)```(?:json)?\\s*(.*?)```")
+	llmTagTrailingComma = regexp.MustCompile(`,\s*([}\]])`)
+)
+
+// extractJSONObject pulls the first balanced {...} out of a model reply,
+// skipping markdown fences and any prose around it, and drops trailing
+// commas. It returns "" when the reply holds no object.
+func extractJSONObject(raw string) string {
+	if m := llmTagFence.FindStringSubmatch(raw); m != nil {
+		raw = m[1]
+	}
+	start := strings.IndexByte(raw, '{')
+	if start < 0 {
+		return ""
+	}
+	depth, inString, escaped := 0, false, false
+	for i := start; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString:
+			if c == '\\' {
+				escaped = true
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return llmTagTrailingComma.ReplaceAllString(raw[start:i+1], "$1")
+			}
+		}
+	}
+	return ""
+}
+
+// llmTagKey folds a category key or label for comparison.
+func llmTagKey(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.NewReplacer("_", " ", "-", " ").Replace(s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// parseLLMTagResponse validates and repairs a model reply against cats,
+// returning the labels per category name. It fails only when the reply holds
+// no JSON object or none of its keys names a category; individual off-schema
+// values are dropped instead.
+func parseLLMTagResponse(raw string, cats []appconfig.LLMTagCategory) (map[string][]string, error) {
+	obj := extractJSONObject(raw)
+	if obj == "" {
+		return nil, fmt.Errorf("no JSON object in reply: %q", truncateForError(raw))
+	}
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(obj), &fields); err != nil {
+		return nil, fmt.Errorf("malformed JSON in reply: %w", err)
+	}
+	byKey := make(map[string]any, len(fields))
+	for k, v := range fields {
+		byKey[llmTagKey(k)] = v
+	}
+
+	out := map[string][]string{}
+	matched := false
+	for _, c := range cats {
+		v, ok := byKey[llmTagKey(c.Name)]
+		if !ok {
+			continue
+		}
+		matched = true
+		var vocab map[string]string
+		if len(c.Vocabulary) > 0 {
+			vocab = make(map[string]string, len(c.Vocabulary))
+			for _, w := range c.Vocabulary {
+				vocab[llmTagKey(w)] = w
+			}
+		}
+		seen := map[string]bool{}
+		for _, label := range llmTagValues(v) {
+			key := llmTagKey(label)
+			if key == "" || seen[key] {
+				continue
+			}
+			if vocab != nil {
+				w, ok := vocab[key]
+				if !ok {
+					continue
+				}
+				label = w
+			} else {
+				label = strings.Join(strings.Fields(label), " ")
+			}
+			seen[key] = true
+			out[c.Name] = append(out[c.Name], label)
+			if len(out[c.Name]) == c.MaxLabels {
+				break
+			}
+		}
+	}
+	if !matched {
+		return nil, fmt.Errorf("reply has none of the schema's categories: %q", truncateForError(obj))
+	}
+	return out, nil
+}
+
+// llmTagValues coerces one category value into labels: arrays keep their
+// string and number entries, and a bare string is split on commas.
+func llmTagValues(v any) []string {
+	switch t := v.(type) {
+	case string:
+		return strings.Split(t, ",")
+	case []any:
+		var out []string
+		for _, e := range t {
+			switch s := e.(type) {
+			case string:
+				out = append(out, s)
+			case float64:
+				out = append(out, fmt.Sprint(s))
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func truncateForError(s string) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	if len(s) > 160 {
+		return s[:160] + "…"
+	}
+	return s
+}
+
+// llmTagLabels maps folded labels to the tags already in the library, so the
+// model's "Beach" lands on an existing "beach" tag — in that tag's category —
+// rather than minting a near-duplicate or re-homing it (tag labels are
+// unique across categories). Touched only from the committer goroutine.
+type llmTagLabels map[string]TagInfo
+
+func loadLLMTagLabels(db *sql.DB) (llmTagLabels, error) {
+	rows, err := db.Query(`SELECT label, COALESCE(category_label, '') FROM tag`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	labels := llmTagLabels{}
+	for rows.Next() {
+		var t TagInfo
+		if err := rows.Scan(&t.Label, &t.Category); err != nil {
+			return nil, err
+		}
+		labels[llmTagKey(t.Label)] = t
+	}
+	return labels, rows.Err()
+}
+
+// resolve returns the tags to write for one item's labels, remembering new
+// ones so later items reuse the same spelling.
+func (l llmTagLabels) resolve(labels map[string][]string, cats []appconfig.LLMTagCategory) []TagInfo {
+	var tags []TagInfo
+	seen := map[string]bool{}
+	for _, c := range cats {
+		for _, label := range labels[c.Name] {
+			key := llmTagKey(label)
+			t, ok := l[key]
+			if !ok || t.Category == "" {
+				t = TagInfo{Label: label, Category: c.Name}
+				l[key] = t
+			}
+			if !seen[t.Label] {
+				seen[t.Label] = true
+				tags = append(tags, t)
+			}
+		}
+	}
+	return tags
+}
+
+// hasLLMTags reports whether a file carries a tag in any schema category —
+// the skip-existing marker for the llm-tag op.
+func hasLLMTags(db *sql.DB, filePath string, cats []appconfig.LLMTagCategory) (bool, error) {
+	args := []any{filePath}
+	marks := make([]string, len(cats))
+	for i, c := range cats {
+		marks[i] = "?"
+		args = append(args, c.Name)
+	}
+	var one int
+	err := db.QueryRow(`SELECT 1 FROM media_tag_by_category WHERE media_path = ? AND category_label IN (`+
+		strings.Join(marks, ",")+`) LIMIT 1`, args...).Scan(&one)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func registerLLMTagItemOp() {
+	RegisterItemOp(ItemOp{
+		ID:   "llm-tag",
+		Name: "Structured Tags (LLM Vision)",
+		Options: []TaskOption{
+			{Name: "schema", Label: "Category Schema", Type: "string",
+				Description: `JSON list of {"name","description","vocabulary":[...],"maxLabels"} overriding the configured categories for this run`},
+			{Name: "prompt", Label: "Extra Instructions", Type: "string", Description: "Prepended to the generated tagging prompt"},
+			{Name: "attempts", Label: "Attempts per File", Type: "number", Default: 3.0,
+				Description: "Tries per file before it is logged as failed; the job carries on either way"},
+		},
+		Applies: extAppliesFn(append(append([]string{}, imageExts...), videoExts...)...),
+		Prepare: prepareLLMTagOp,
+	})
+}
+
+func prepareLLMTagOp(run *ItemRun) (*ItemProcessor, error) {
+	q, jobID := run.Queue, run.Job.ID
+	db := q.Db
+
+	override, _ := run.Opts["schema"].(string)
+	cats, err := resolveLLMTagCategories(override)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range cats {
+		if err := EnsureCategoryExists(db, c.Name, 0); err != nil {
+			return nil, fmt.Errorf("ensure category: %w", err)
+		}
+	}
+	labels, err := loadLLMTagLabels(db)
+	if err != nil {
+		return nil, fmt.Errorf("load tags: %w", err)
+	}
+	custom, _ := run.Opts["prompt"].(string)
+	prompt := buildLLMTagPrompt(cats, custom)
+	schema := buildLLMTagSchema(cats)
+	attempts := 3
+	if v, ok := run.Opts["attempts"].(float64); ok && v >= 1 {
+		attempts = int(v)
+	}
+
+	names := make([]string, len(cats))
+	for i, c := range cats {
+		names[i] = c.Name
+	}
+	q.PushJobStdout(jobID, fmt.Sprintf("LLM tagging into %s via %s", strings.Join(names, ", "), InferenceHost()))
+
+	return &ItemProcessor{
+		SkipExisting: func(path string) (bool, error) { return hasLLMTags(db, path, cats) },
+		Process: func(ctx context.Context, path, localPath string) (*ItemCommit, error) {
+			got, err := llmTagFile(ctx, q, jobID, localPath, prompt, schema, cats, attempts)
+			if err != nil {
+				return nil, err
+			}
+			return &ItemCommit{
+				Commit: func() error {
+					tags := labels.resolve(got, cats)
+					if len(tags) == 0 {
+						return nil
+					}
+					return insertTagsForFile(db, path, tags)
+				},
+				Detail: fmt.Sprintf("%d label(s) tagged", countLLMTagLabels(got)),
+			}, nil
+		},
+	}, nil
+}
+
+// llmTagRetryDelay is the pause before retry n (1-based); a var for tests.
+var llmTagRetryDelay = func(n int) time.Duration { return time.Duration(n) * 2 * time.Second }
+
+// llmTagFile runs one file through the vision provider, retrying transport
+// failures and unusable replies up to attempts times. A disabled provider is
+// not retried.
+func llmTagFile(ctx context.Context, q *jobqueue.Queue, jobID, mediaPath, prompt string, schema map[string]any, cats []appconfig.LLMTagCategory, attempts int) (map[string][]string, error) {
+	// The raw reply is cached; prompt and schema are part of the key, so a
+	// hit parses exactly as it did when it was stored.
+	schemaJSON, _ := json.Marshal(schema)
+	key, cacheable := visionCacheKey(resultcache.KindLLMTag, mediaPath, prompt, string(schemaJSON))
+	if cacheable {
+		if reply, ok := resultcache.Get(key); ok {
+			if got, err := parseLLMTagResponse(reply, cats); err == nil {
+				return got, nil
+			}
+		}
+	}
+	imagePath, cleanup, err := prepareVisionImage(ctx, q, jobID, mediaPath)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	var lastErr error
+	for n := 1; n <= attempts; n++ {
+		if n > 1 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(llmTagRetryDelay(n - 1)):
+			}
+		}
+		reply, got, err := llmTagOnce(ctx, imagePath, prompt, schema, cats)
+		if err == nil {
+			if cacheable {
+				resultcache.Put(key, reply)
+			}
+			return got, nil
+		}
+		if errors.Is(err, ErrInferenceDisabled) || ctx.Err() != nil {
+			return nil, err
+		}
+		lastErr = err
+		if n < attempts && q != nil {
+			q.PushJobStdout(jobID, fmt.Sprintf("[llm-tag] attempt %d/%d failed: %v", n, attempts, err))
+		}
+	}
+	return nil, fmt.Errorf("after %d attempt(s): %w", attempts, lastErr)
+}
+
+func llmTagOnce(ctx context.Context, imagePath, prompt string, schema map[string]any, cats []appconfig.LLMTagCategory) (string, map[string][]string, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, 600*time.Second)
+	defer cancel()
+	reply, err := callVisionLLMJSON(timeoutCtx, imagePath, prompt, schema)
+	if err != nil {
+		return "", nil, err
+	}
+	if looksLikeNoImageResponse(reply) {
+		return "", nil, fmt.Errorf("model returned a no-image response: %q", truncateForError(reply))
+	}
+	got, err := parseLLMTagResponse(reply, cats)
+	return reply, got, err
+}
+
+func countLLMTagLabels(labels map[string][]string) int {
+	n := 0
+	for _, l := range labels {
+		n += len(l)
+	}
+	return n
+}
@@ -0,0 +1,233 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stevecastle/shrike/appconfig"
+	"github.com/stevecastle/shrike/resultcache"
+)
+
+var testLLMTagCats = []appconfig.LLMTagCategory{
+	{Name: "setting", Vocabulary: []string{"indoors", "outdoors", "beach"}, MaxLabels: 2},
+	{Name: "objects", MaxLabels: 3},
+}
+
+func TestBuildLLMTagSchema(t *testing.T) {
+	s := buildLLMTagSchema(testLLMTagCats)
+	props := s["properties"].(map[string]any)
+	setting := props["setting"].(map[string]any)
+	if got := setting["items"].(map[string]any)["enum"]; !reflect.DeepEqual(got, []string{"indoors", "outdoors", "beach"}) {
+		t.Errorf("setting enum = %v", got)
+	}
+	if setting["maxItems"] != 2 {
+		t.Errorf("setting maxItems = %v", setting["maxItems"])
+	}
+	if _, ok := props["objects"].(map[string]any)["items"].(map[string]any)["enum"]; ok {
+		t.Error("free-form category has an enum")
+	}
+	if !reflect.DeepEqual(s["required"], []string{"setting", "objects"}) {
+		t.Errorf("required = %v", s["required"])
+	}
+}
+
+func TestResolveLLMTagCategories(t *testing.T) {
+	cats, err := resolveLLMTagCategories("")
+	if err != nil || len(cats) != len(defaultLLMTagCategories) {
+		t.Fatalf("default schema = %v, %v", cats, err)
+	}
+	cats, err = resolveLLMTagCategories(`[{"name":" colour "},{"name":""}]`)
+	if err != nil || len(cats) != 1 || cats[0].Name != "colour" || cats[0].MaxLabels != llmTagDefaultMax {
+		t.Fatalf("override = %+v, %v", cats, err)
+	}
+	if _, err := resolveLLMTagCategories(`[{"name":"a"},{"name":"A"}]`); err == nil {
+		t.Error("duplicate category accepted")
+	}
+	if _, err := resolveLLMTagCategories(`{`); err == nil {
+		t.Error("malformed override accepted")
+	}
+}
+
+func TestParseLLMTagResponseRepairs(t *testing.T) {
+	cases := []struct {
+		name string
+		raw  string
+		want map[string][]string
+	}{
+		{"clean", `{"setting":["beach"],"objects":["umbrella","towel"]}`,
+			map[string][]string{"setting": {"beach"}, "objects": {"umbrella", "towel"}}},
+		{"fenced with prose and trailing comma", "Sure!\n```json\n{\"Setting\": [\"Outdoors\",], \"objects\": [\"dog\"],}\n```",
+			map[string][]string{"setting": {"outdoors"}, "objects": {"dog"}}},
+		{"string instead of array, off-vocabulary dropped", `{"setting":"indoors, kitchen","objects":"cup,  mug ,cup"}`,
+			map[string][]string{"setting": {"indoors"}, "objects": {"cup", "mug"}}},
+		{"capped and deduped", `{"setting":["beach","BEACH","outdoors","indoors"],"objects":["a","b","c","d"]}`,
+			map[string][]string{"setting": {"beach", "outdoors"}, "objects": {"a", "b", "c"}}},
+		{"missing category", `{"objects":[]}`, map[string][]string{}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := parseLLMTagResponse(c.raw, testLLMTagCats)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("got %v, want %v", got, c.want)
+			}
+		})
+	}
+	for _, raw := range []string{"A sunny beach.", `{"colour":["red"]}`, `{"setting": [}`} {
+		if _, err := parseLLMTagResponse(raw, testLLMTagCats); err == nil {
+			t.Errorf("parseLLMTagResponse(%q) accepted", raw)
+		}
+	}
+}
+
+// Labels resolve onto existing tags case-insensitively and keep that tag's
+// category; new labels land in the schema category and are reused after.
+func TestLLMTagLabelsResolve(t *testing.T) {
+	db := setupTagDB(t)
+	if _, err := db.Exec(`INSERT INTO tag (label, category_label) VALUES ('Beach', 'Location')`); err != nil {
+		t.Fatal(err)
+	}
+	labels, err := loadLLMTagLabels(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := labels.resolve(map[string][]string{"setting": {"beach"}, "objects": {"Red Car"}}, testLLMTagCats)
+	want := []TagInfo{{Label: "Beach", Category: "Location"}, {Label: "Red Car", Category: "objects"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("resolve = %v, want %v", got, want)
+	}
+	got = labels.resolve(map[string][]string{"objects": {"red_car"}}, testLLMTagCats)
+	if len(got) != 1 || got[0].Label != "Red Car" {
+		t.Fatalf("second spelling = %v", got)
+	}
+
+	if err := insertTagsForFile(db, "/a.jpg", want); err != nil {
+		t.Fatal(err)
+	}
+	if has, err := hasLLMTags(db, "/a.jpg", testLLMTagCats); err != nil || !has {
+		t.Errorf("hasLLMTags = %v, %v", has, err)
+	}
+	if has, _ := hasLLMTags(db, "/b.jpg", testLLMTagCats); has {
+		t.Error("untagged file reported as tagged")
+	}
+}
+
+func writeTestPNG(t *testing.T, dir string) string {
+	t.Helper()
+	p := filepath.Join(dir, "a.png")
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, image.NewRGBA(image.Rect(0, 0, 8, 8))); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// useOpenAIProvider points the vision provider at a fake chat-completions
+// server that answers every request with content.
+func useOpenAIProvider(t *testing.T, content func() string) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []any{map[string]any{"message": map[string]any{"content": content()}}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	cur := appconfig.Get()
+	t.Cleanup(func() { appconfig.Set(cur) })
+	cfg := cur
+	cfg.InferenceProvider = InferenceProviderOpenAI
+	cfg.OpenAIBaseURL = srv.URL
+	cfg.OpenAIModel = "test-vision"
+	appconfig.Set(cfg)
+}
+
+// A file whose bytes were already tagged under the same provider, model,
+// prompt, and schema is answered from the result cache.
+func TestLLMTagFileUsesResultCache(t *testing.T) {
+	var calls atomic.Int32
+	useOpenAIProvider(t, func() string {
+		calls.Add(1)
+		return `{"setting":["indoors"],"objects":[]}`
+	})
+	if err := resultcache.Open(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(resultcache.Close)
+
+	schema := buildLLMTagSchema(testLLMTagCats)
+	for _, dir := range []string{t.TempDir(), t.TempDir()} {
+		got, err := llmTagFile(context.Background(), nil, "", writeTestPNG(t, dir), "tag", schema, testLLMTagCats, 1)
+		if err != nil || !reflect.DeepEqual(got["setting"], []string{"indoors"}) {
+			t.Fatalf("llmTagFile = %v, %v", got, err)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("provider called %d times; want 1", calls.Load())
+	}
+	if _, err := llmTagFile(context.Background(), nil, "", writeTestPNG(t, t.TempDir()), "other prompt", schema, testLLMTagCats, 1); err != nil || calls.Load() != 2 {
+		t.Fatalf("changed prompt served from cache (calls = %d, err = %v)", calls.Load(), err)
+	}
+}
+
+// llmTagFile sends the schema as response_format and retries a reply it
+// can't use.
+func TestLLMTagFileRetriesViaOpenAIProvider(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if rf, _ := body["response_format"].(map[string]any); rf["type"] != "json_schema" {
+			t.Errorf("response_format = %v", body["response_format"])
+		}
+		content := "I think it is a beach."
+		if calls.Add(1) > 1 {
+			content = `{"setting":["beach"],"objects":["ball"]}`
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []any{map[string]any{"message": map[string]any{"content": content}}},
+		})
+	}))
+	defer srv.Close()
+
+	cur := appconfig.Get()
+	t.Cleanup(func() { appconfig.Set(cur) })
+	cfg := cur
+	cfg.InferenceProvider = InferenceProviderOpenAI
+	cfg.OpenAIBaseURL = srv.URL
+	cfg.OpenAIModel = "test-vision"
+	appconfig.Set(cfg)
+	oldDelay := llmTagRetryDelay
+	llmTagRetryDelay = func(int) time.Duration { return 0 }
+	t.Cleanup(func() { llmTagRetryDelay = oldDelay })
+
+	imgPath := writeTestPNG(t, t.TempDir())
+
+	got, err := llmTagFile(context.Background(), nil, "", imgPath, "tag", buildLLMTagSchema(testLLMTagCats), testLLMTagCats, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 2 || !reflect.DeepEqual(got["setting"], []string{"beach"}) {
+		t.Fatalf("calls = %d, got %v", calls.Load(), got)
+	}
+
+	calls.Store(-10) // every reply unusable from here
+	if _, err := llmTagFile(context.Background(), nil, "", imgPath, "tag", buildLLMTagSchema(testLLMTagCats), testLLMTagCats, 2); err == nil {
+		t.Error("unusable replies accepted")
+	}
+}
@@ -21,17 +21,19 @@ import (
 
 	"github.com/stevecastle/shrike/appconfig"
 	"github.com/stevecastle/shrike/mediaext"
+	"github.com/stevecastle/shrike/resultcache"
 )
 
 // Inference provider identifiers. Persisted in Config.InferenceProvider and
 // driven from the Inference tab in the config UI. Add a new constant + a
-// matching case in callVisionLLM to wire in a new backend.
+// matching case in callVisionLLMSchema to wire in a new backend.
 const (
 	InferenceProviderOff      = "off"
 	InferenceProviderOllama   = "ollama"
 	InferenceProviderRunPod   = "runpod"
 	InferenceProviderLMStudio = "lmstudio"
 	InferenceProviderLlamaCpp = "llamacpp"
+	InferenceProviderOpenAI   = "openai"
 )
 
 // Host buckets used for jobqueue concurrency on inference work. Each
@@ -45,6 +47,7 @@ const (
 	HostBucketRunPod   = "runpod"
 	HostBucketLMStudio = "lmstudio"
 	HostBucketLlamaCpp = "llamacpp"
+	HostBucketOpenAI   = "openai"
 	// HostBucketEmbed is the local visual-embedding bucket. It is intentionally
 	// separate from the LLM inference buckets so embedding doesn't compete with
 	// autotag/description jobs and isn't capped by the LLM provider's setting.
@@ -67,6 +70,11 @@ const (
 	// GPU at the same time. Limit comes from config LocalComputeConcurrency
 	// (default 1). Remote inference (RunPod) does not hold a slot.
 	HostBucketLocalCompute = "local-compute"
+	// HostBucketTranscribeHTTP is where transcription runs when the active
+	// transcribe provider is an HTTP service (OpenAI-compatible or whisper.cpp
+	// server) rather than the local Faster-Whisper CLI. Limit comes from
+	// config TranscriptionHTTPConcurrency (default 1).
+	HostBucketTranscribeHTTP = "transcribe-http"
 )
 
 // InferenceHost returns the concurrency bucket name for the currently
@@ -86,6 +94,8 @@ func InferenceHost() string {
 		return HostBucketLMStudio
 	case InferenceProviderLlamaCpp:
 		return HostBucketLlamaCpp
+	case InferenceProviderOpenAI:
+		return HostBucketOpenAI
 	default:
 		return "localhost"
 	}
@@ -93,8 +103,9 @@ func InferenceHost() string {
 
 // InferenceHostIsLocal reports whether the configured vision provider runs
 // on this machine (Ollama/LM Studio/llama.cpp) — those jobs consume the
-// shared local-compute slot. RunPod is remote and does not; "off"/unknown
-// jobs fail fast inside callVisionLLM so holding no slot is fine.
+// shared local-compute slot. RunPod and OpenAI-compatible services are
+// remote and do not; "off"/unknown jobs fail fast inside callVisionLLM so
+// holding no slot is fine.
 func InferenceHostIsLocal() bool {
 	switch InferenceHost() {
 	case HostBucketOllama, HostBucketLMStudio, HostBucketLlamaCpp:
@@ -104,6 +115,55 @@ func InferenceHostIsLocal() bool {
 	}
 }
 
+// visionProviderModel names the configured provider and the model its
+// results come from — the identity the result cache keys vision output on.
+// RunPod workers pick their own model, so the endpoint stands in for it;
+// LM Studio / llama.cpp with no model set answer with whatever is loaded,
+// keyed by base URL. ok is false when inference is off or unknown.
+func visionProviderModel() (provider, model string, ok bool) {
+	cfg := appconfig.Get()
+	provider = strings.ToLower(strings.TrimSpace(cfg.InferenceProvider))
+	switch provider {
+	case InferenceProviderOllama:
+		model = cfg.OllamaModel
+	case InferenceProviderRunPod:
+		model = cfg.RunPodEndpoint
+	case InferenceProviderLMStudio:
+		model = firstNonEmpty(cfg.LMStudioModel, cfg.LMStudioBaseURL)
+	case InferenceProviderLlamaCpp:
+		model = firstNonEmpty(cfg.LlamaCppModel, cfg.LlamaCppBaseURL)
+	case InferenceProviderOpenAI:
+		model = cfg.OpenAIModel
+	default:
+		return "", "", false
+	}
+	return provider, strings.TrimSpace(model), true
+}
+
+// visionCacheKey builds the result-cache key for running mediaPath through
+// the configured vision provider with params (prompt, schema, ...). ok is
+// false when the cache is off, inference is off, or the file can't be read.
+func visionCacheKey(kind, mediaPath string, params ...string) (resultcache.Key, bool) {
+	if !resultcache.Enabled() {
+		return resultcache.Key{}, false
+	}
+	provider, model, ok := visionProviderModel()
+	if !ok {
+		return resultcache.Key{}, false
+	}
+	content, err := resultcache.ContentHash(mediaPath)
+	if err != nil {
+		return resultcache.Key{}, false
+	}
+	return resultcache.Key{
+		Kind:     kind,
+		Content:  content,
+		Provider: provider,
+		Model:    model,
+		Params:   resultcache.ParamsHash(params...),
+	}, true
+}
+
 // ErrInferenceDisabled is returned by callVisionLLM when the user has set
 // the inference provider to "off". Callers can match on this to surface a
 // friendlier "configure a provider" message instead of treating it as a
@@ -123,65 +183,95 @@ const visionMaxOutputTokens = 1024
 // (description, autotag). It dispatches based on the configured inference
 // provider. The caller supplies a deadline via ctx.
 func callVisionLLM(ctx context.Context, imagePath, prompt string) (string, error) {
+	return callVisionLLMSchema(ctx, imagePath, prompt, nil)
+}
+
+// callVisionLLMJSON is callVisionLLM for replies that must be JSON matching
+// schema (a JSON Schema object). Each provider is asked for structured
+// output in its own dialect — Ollama's "format", OpenAI-style
+// "response_format" json_schema (LM Studio, llama.cpp, OpenAI-compatible,
+// and passed through to RunPod workers) — but servers that ignore the hint
+// still answer in prose, so callers must validate what comes back.
+func callVisionLLMJSON(ctx context.Context, imagePath, prompt string, schema map[string]any) (string, error) {
+	return callVisionLLMSchema(ctx, imagePath, prompt, schema)
+}
+
+// callTextLLMJSON is callVisionLLMJSON without an image: a text-only prompt
+// (transcript translation) through the same configured provider. Every
+// backend takes the vision payload minus its image part.
+func callTextLLMJSON(ctx context.Context, prompt string, schema map[string]any) (string, error) {
+	return callVisionLLMSchema(ctx, "", prompt, schema)
+}
+
+// callVisionLLMSchema dispatches to the configured provider. An empty
+// imagePath sends the prompt alone.
+func callVisionLLMSchema(ctx context.Context, imagePath, prompt string, schema map[string]any) (string, error) {
 	cfg := appconfig.Get()
 	provider := strings.ToLower(strings.TrimSpace(cfg.InferenceProvider))
 	switch provider {
 	case "", InferenceProviderOff:
 		return "", ErrInferenceDisabled
 	case InferenceProviderOllama:
-		return callOllamaVisionRaw(ctx, imagePath, prompt, cfg.OllamaBaseURL, cfg.OllamaModel)
+		return callOllamaVisionRaw(ctx, imagePath, prompt, cfg.OllamaBaseURL, cfg.OllamaModel, schema)
 	case InferenceProviderRunPod:
 		if strings.TrimSpace(cfg.RunPodEndpoint) == "" || strings.TrimSpace(cfg.RunPodAPIKey) == "" {
 			return "", fmt.Errorf("runpod provider selected but endpoint or api key is empty")
 		}
-		return callRunPodVision(ctx, imagePath, prompt, cfg.RunPodEndpoint, cfg.RunPodAPIKey)
+		return callRunPodVision(ctx, imagePath, prompt, cfg.RunPodEndpoint, cfg.RunPodAPIKey, schema)
 	case InferenceProviderLMStudio:
 		if strings.TrimSpace(cfg.LMStudioBaseURL) == "" {
 			return "", fmt.Errorf("lmstudio provider selected but base URL is empty")
 		}
-		return callOpenAICompatibleVision(ctx, imagePath, prompt, cfg.LMStudioBaseURL, cfg.LMStudioAPIKey, cfg.LMStudioModel)
+		return callOpenAICompatibleVision(ctx, imagePath, prompt, cfg.LMStudioBaseURL, cfg.LMStudioAPIKey, cfg.LMStudioModel, schema)
 	case InferenceProviderLlamaCpp:
 		if strings.TrimSpace(cfg.LlamaCppBaseURL) == "" {
 			return "", fmt.Errorf("llamacpp provider selected but base URL is empty")
 		}
-		return callOpenAICompatibleVision(ctx, imagePath, prompt, cfg.LlamaCppBaseURL, cfg.LlamaCppAPIKey, cfg.LlamaCppModel)
+		return callOpenAICompatibleVision(ctx, imagePath, prompt, cfg.LlamaCppBaseURL, cfg.LlamaCppAPIKey, cfg.LlamaCppModel, schema)
+	case InferenceProviderOpenAI:
+		if strings.TrimSpace(cfg.OpenAIBaseURL) == "" || strings.TrimSpace(cfg.OpenAIModel) == "" {
+			return "", fmt.Errorf("openai provider selected but base URL or model is empty")
+		}
+		return callOpenAICompatibleVision(ctx, imagePath, prompt, cfg.OpenAIBaseURL, cfg.OpenAIAPIKey, cfg.OpenAIModel, schema)
 	default:
 		return "", fmt.Errorf("unknown inference provider %q", cfg.InferenceProvider)
 	}
 }
 
 // callOpenAICompatibleVision posts a chat-completions vision payload to any
-// server that speaks the OpenAI /v1/chat/completions shape — currently
-// LM Studio and the llama.cpp `server` binary, but the same client handles
-// any future OpenAI-compatible local engine. The auth header is only sent
-// when apiKey is non-empty so this works for unauthenticated local servers
-// as well as proxied / remote setups.
+// server that speaks the OpenAI /v1/chat/completions shape — LM Studio, the
+// llama.cpp `server` binary, and the generic "openai" provider. The auth
+// header is only sent when apiKey is non-empty so this works for
+// unauthenticated local servers as well as proxied / remote setups.
 //
 // Distinct from callRunPodVision, which wraps the same payload in RunPod's
 // {input: ...} envelope and supports async /run polling. If we ever pick up
 // more OpenAI-shaped providers (vLLM, TGI, etc.) they reuse this helper.
-func callOpenAICompatibleVision(ctx context.Context, imagePath, prompt, baseURL, apiKey, model string) (string, error) {
-	img, err := loadImageForInference(imagePath)
-	if err != nil {
-		return "", fmt.Errorf("inference: %w", err)
-	}
+func callOpenAICompatibleVision(ctx context.Context, imagePath, prompt, baseURL, apiKey, model string, schema map[string]any) (string, error) {
 	endpoint := strings.TrimRight(baseURL, "/") + "/v1/chat/completions"
-	img.logRequest("openai-compatible", model, endpoint, prompt)
+	parts := []map[string]any{{"type": "text", "text": prompt}}
+	if imagePath != "" {
+		img, err := loadImageForInference(imagePath)
+		if err != nil {
+			return "", fmt.Errorf("inference: %w", err)
+		}
+		img.logRequest("openai-compatible", model, endpoint, prompt)
+		parts = append(parts, map[string]any{"type": "image_url", "image_url": map[string]any{"url": img.dataURI()}})
+	} else {
+		logTextRequest("openai-compatible", model, endpoint, prompt)
+	}
 
 	payload := map[string]any{
 		"model":      model,
 		"stream":     false,
 		"max_tokens": visionMaxOutputTokens,
 		"messages": []map[string]any{
-			{
-				"role": "user",
-				"content": []map[string]any{
-					{"type": "text", "text": prompt},
-					{"type": "image_url", "image_url": map[string]any{"url": img.dataURI()}},
-				},
-			},
+			{"role": "user", "content": parts},
 		},
 	}
+	if schema != nil {
+		payload["response_format"] = openAIResponseFormat(schema)
+	}
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal chat-completions payload: %w", err)
@@ -240,15 +330,30 @@ func callOpenAICompatibleVision(ctx context.Context, imagePath, prompt, baseURL,
 // callOllamaVisionRaw issues an /api/generate request to a local-or-remote
 // Ollama server and returns the model's response field. Equivalent to the
 // in-line plumbing that used to live in metadata_ops.go and autotag_vision.go.
-func callOllamaVisionRaw(ctx context.Context, imagePath, prompt, baseURL, model string) (string, error) {
-	img, err := loadImageForInference(imagePath)
-	if err != nil {
-		return "", fmt.Errorf("ollama: %w", err)
-	}
+func callOllamaVisionRaw(ctx context.Context, imagePath, prompt, baseURL, model string, schema map[string]any) (string, error) {
 	base := strings.TrimRight(baseURL, "/")
-	img.logRequest("ollama", model, base+"/api/generate", prompt)
-	reqJSON := fmt.Sprintf(`{"model":"%s","stream":false,"options":{"num_predict":%d},"prompt":%s,"images":["%s"]}`,
-		model, visionMaxOutputTokens, strconv.Quote(prompt), img.base64())
+	images := ""
+	if imagePath != "" {
+		img, err := loadImageForInference(imagePath)
+		if err != nil {
+			return "", fmt.Errorf("ollama: %w", err)
+		}
+		img.logRequest("ollama", model, base+"/api/generate", prompt)
+		images = fmt.Sprintf(`,"images":["%s"]`, img.base64())
+	} else {
+		logTextRequest("ollama", model, base+"/api/generate", prompt)
+	}
+	// Ollama takes a JSON Schema directly as "format" (0.5+).
+	format := ""
+	if schema != nil {
+		b, err := json.Marshal(schema)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal output schema: %w", err)
+		}
+		format = `,"format":` + string(b)
+	}
+	reqJSON := fmt.Sprintf(`{"model":"%s","stream":false,"options":{"num_predict":%d}%s,"prompt":%s%s}`,
+		model, visionMaxOutputTokens, format, strconv.Quote(prompt), images)
 	req, err := http.NewRequestWithContext(ctx, "POST", base+"/api/generate", strings.NewReader(reqJSON))
 	if err != nil {
 		return "", fmt.Errorf("failed to build request: %w", err)
@@ -286,12 +391,18 @@ var runPodAsyncEndpoint = regexp.MustCompile(`/run(?:\/?$|\?)`)
 // RunPod serverless worker (e.g. SvenBrnn/runpod-worker-ollama) and returns
 // the model's text response. Mirrors the structure tested in
 // thespian/send-image.js.
-func callRunPodVision(ctx context.Context, imagePath, prompt, endpoint, apiKey string) (string, error) {
-	img, err := loadImageForInference(imagePath)
-	if err != nil {
-		return "", fmt.Errorf("runpod: %w", err)
+func callRunPodVision(ctx context.Context, imagePath, prompt, endpoint, apiKey string, schema map[string]any) (string, error) {
+	parts := []map[string]any{{"type": "text", "text": prompt}}
+	if imagePath != "" {
+		img, err := loadImageForInference(imagePath)
+		if err != nil {
+			return "", fmt.Errorf("runpod: %w", err)
+		}
+		img.logRequest("runpod", "", endpoint, prompt)
+		parts = append(parts, map[string]any{"type": "image_url", "image_url": map[string]any{"url": img.dataURI()}})
+	} else {
+		logTextRequest("runpod", "", endpoint, prompt)
 	}
-	img.logRequest("runpod", "", endpoint, prompt)
 
 	payload := map[string]any{
 		"input": map[string]any{
@@ -299,16 +410,13 @@ func callRunPodVision(ctx context.Context, imagePath, prompt, endpoint, apiKey s
 			// by workers that don't support it.
 			"max_tokens": visionMaxOutputTokens,
 			"messages": []map[string]any{
-				{
-					"role": "user",
-					"content": []map[string]any{
-						{"type": "text", "text": prompt},
-						{"type": "image_url", "image_url": map[string]any{"url": img.dataURI()}},
-					},
-				},
+				{"role": "user", "content": parts},
 			},
 		},
 	}
+	if schema != nil {
+		payload["input"].(map[string]any)["response_format"] = openAIResponseFormat(schema)
+	}
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal RunPod payload: %w", err)
@@ -364,6 +472,19 @@ func callRunPodVision(ctx context.Context, imagePath, prompt, endpoint, apiKey s
 	return text, nil
 }
 
+// openAIResponseFormat wraps a JSON Schema in the chat-completions
+// structured-output envelope.
+func openAIResponseFormat(schema map[string]any) map[string]any {
+	return map[string]any{
+		"type": "json_schema",
+		"json_schema": map[string]any{
+			"name":   "response",
+			"strict": true,
+			"schema": schema,
+		},
+	}
+}
+
 type runPodResponse struct {
 	ID     string          `json:"id,omitempty"`
 	Status string          `json:"status,omitempty"`
@@ -560,6 +681,12 @@ func (p *imagePayload) logRequest(provider, model, endpoint, prompt string) {
 		base64.StdEncoding.EncodedLen(len(p.data)), mimeNote, decoded, len(prompt))
 }
 
+// logTextRequest is logRequest for a prompt sent without an image.
+func logTextRequest(provider, model, endpoint, prompt string) {
+	log.Printf("[vision:request] provider=%s model=%q endpoint=%q image=none promptLen=%d",
+		provider, model, endpoint, len(prompt))
+}
+
 // logVisionResponse records what came back so a blind-but-successful run is
 // distinguishable from a healthy one: the raw HTTP body size, the extracted
 // content length, and a short preview (which surfaces "I don't see an image"
@@ -31,6 +31,11 @@ func cleanUpFn(j *jobqueue.Job, q *jobqueue.Queue, mu *sync.Mutex) error {
 		return err
 	}
 
+	if result.MediaItemsRemoved > 0 {
+		auditJob(q, j, "media.cleanup", nil, nil, map[string]any{
+			"items": result.MediaItemsRemoved, "tags": result.TagsRemoved,
+		})
+	}
 	if result.MediaItemsRemoved == 0 {
 		q.PushJobStdout(j.ID, "No orphaned media items found - database is clean!")
 	} else {
@@ -146,11 +146,14 @@ func moveTask(j *jobqueue.Job, q *jobqueue.Queue, mu *sync.Mutex) error {
 		moveCount++
 		q.PushJobStdout(j.ID, fmt.Sprintf("Moved: %s -> %s", srcPath, destPath))
 		q.RegisterOutputFile(j.ID, destPath)
+		moveXMPSidecars(q, j.ID, srcPath, destPath)
 
 		if err := updateMediaPathInDatabase(q.Db, srcPath, destPath); err != nil {
 			q.PushJobStdout(j.ID, fmt.Sprintf("Warning: failed to update database for %s: %v", srcPath, err))
 		} else {
 			updateCount++
+			auditJob(q, j, "media.move", []string{srcPath, destPath}, map[string]string{"path": srcPath},
+				map[string]string{"path": destPath})
 		}
 		if (i+1)%10 == 0 || i == len(validPaths)-1 {
 			q.PushJobStdout(j.ID, fmt.Sprintf("Progress: %d/%d files processed", i+1, len(validPaths)))
@@ -241,3 +244,23 @@ func updateMediaPathInDatabase(db *sql.DB, oldPath, newPath string) error {
 	media.InvalidateRandomSampleCache()
 	return nil
 }
+
+// moveXMPSidecars carries a moved file's XMP sidecars (photo.jpg.xmp and
+// photo.xmp) along with it, so face regions and keywords written for other
+// tools stay attached. A sidecar already at the destination is left alone.
+func moveXMPSidecars(q *jobqueue.Queue, jobID, srcPath, destPath string) {
+	for _, naming := range []string{"full", "stem"} {
+		from := xmpSidecarPath(srcPath, naming)
+		if _, err := os.Stat(from); err != nil {
+			continue
+		}
+		to := xmpSidecarPath(destPath, naming)
+		if _, err := os.Stat(to); err == nil {
+			q.PushJobStdout(jobID, fmt.Sprintf("Warning: sidecar already exists, left in place: %s", from))
+			continue
+		}
+		if err := os.Rename(from, to); err != nil {
+			q.PushJobStdout(jobID, fmt.Sprintf("Warning: failed to move sidecar %s: %v", from, err))
+		}
+	}
+}
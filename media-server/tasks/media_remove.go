@@ -64,6 +64,9 @@ func removeFromDB(j *jobqueue.Job, q *jobqueue.Queue, mu *sync.Mutex) error {
 	// Whatever got committed is durable, so register it either way — a
 	// cancelled run still reports the items it actually removed.
 	q.RegisterOutputFiles(j.ID, removed)
+	if len(removed) > 0 {
+		auditJob(q, j, "media.remove", removed, nil, map[string]any{"items": len(removed), "query": res.Query})
+	}
 
 	if err != nil {
 		// Cancellation (the Pause button) is not a failure: the committed
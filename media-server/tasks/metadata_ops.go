@@ -29,6 +29,7 @@ import (
 	"github.com/stevecastle/shrike/jobqueue"
 	"github.com/stevecastle/shrike/mediaext"
 	"github.com/stevecastle/shrike/platform"
+	"github.com/stevecastle/shrike/resultcache"
 	"github.com/stevecastle/shrike/transcribe"
 )
 
@@ -168,17 +169,63 @@ func probeVideoDuration(ctx context.Context, videoPath string) float64 {
 }
 
 func describeFileWithOllama(ctx context.Context, q *jobqueue.Queue, jobID, mediaPath, model, customPrompt string) (string, error) {
+	// Identical bytes described with the same provider, model, and prompt
+	// reuse the earlier result — no frame extraction, no inference.
+	key, cacheable := visionCacheKey(resultcache.KindDescribe, mediaPath, resolveDescribePrompt(customPrompt))
+	if cacheable {
+		if description, ok := resultcache.Get(key); ok {
+			if q != nil {
+				q.PushJobStdout(jobID, "[describe] reused cached description for "+filepath.Base(mediaPath))
+			}
+			return description, nil
+		}
+	}
+	imagePath, cleanup, err := prepareVisionImage(ctx, q, jobID, mediaPath)
+	if err != nil {
+		return "", err
+	}
+	description, err := callOllamaVision(ctx, imagePath, model, customPrompt)
+	cleanup()
+	if err != nil {
+		return "", fmt.Errorf("ollama call failed: %w", err)
+	}
+	// Guard against a "blind" reply: the backend returned 200 with prose, but
+	// the prose says it never got an image. Treat it as a failure so this text
+	// is not persisted as the file's description — a silent save here is how the
+	// bug hid for so long. No retry (caller decides); the job log shows it.
+	if looksLikeNoImageResponse(description) {
+		preview := strings.ReplaceAll(description, "\n", " ")
+		if len(preview) > 160 {
+			preview = preview[:160] + "…"
+		}
+		return "", fmt.Errorf("model returned a no-image response (image was not ingested by the backend): %q", preview)
+	}
+	if cacheable {
+		resultcache.Put(key, description)
+	}
+	return description, nil
+}
+
+// prepareVisionImage turns mediaPath into the image a vision backend is sent:
+// bytes the model reads directly are used as-is; everything else (animated
+// gif, tiff, heic, avif, any video) gets an ffmpeg frame extracted first, and
+// the result is downscaled when oversized. cleanup removes any temp files and
+// must be called once the backend call returns.
+func prepareVisionImage(ctx context.Context, q *jobqueue.Queue, jobID, mediaPath string) (string, func(), error) {
 	var tempImagePath string
 	var cleanupPaths []string
+	cleanup := func() {
+		for _, p := range cleanupPaths {
+			_ = os.Remove(p)
+		}
+	}
 	source := "image"
-	// Bytes the model reads directly; everything else (animated gif, tiff,
-	// heic, avif, any video) gets an ffmpeg frame extracted first.
 	if mediaext.IsDirectVisionImage(mediaPath) {
 		tempImagePath = mediaPath
 	} else {
 		screenshotPath, err := extractVideoFrame(ctx, mediaPath, "")
 		if err != nil {
-			return "", fmt.Errorf("failed to extract video frame: %w", err)
+			return "", nil, fmt.Errorf("failed to extract video frame: %w", err)
 		}
 		cleanupPaths = append(cleanupPaths, screenshotPath)
 		tempImagePath = screenshotPath
@@ -186,37 +233,14 @@ func describeFileWithOllama(ctx context.Context, q *jobqueue.Queue, jobID, media
 	}
 	resizedPath, err := resizeImageIfNeeded(tempImagePath)
 	if err != nil {
-		for _, p := range cleanupPaths {
-			_ = os.Remove(p)
-		}
-		return "", fmt.Errorf("failed to resize image: %w", err)
+		cleanup()
+		return "", nil, fmt.Errorf("failed to resize image: %w", err)
 	}
 	if resizedPath != tempImagePath {
 		cleanupPaths = append(cleanupPaths, resizedPath)
 	}
 	logImageParseToJob(q, jobID, mediaPath, source, tempImagePath, resizedPath)
-	description, err := callOllamaVision(ctx, resizedPath, model, customPrompt)
-	if err != nil {
-		for _, p := range cleanupPaths {
-			_ = os.Remove(p)
-		}
-		return "", fmt.Errorf("ollama call failed: %w", err)
-	}
-	for _, p := range cleanupPaths {
-		_ = os.Remove(p)
-	}
-	// Guard against a "blind" reply: the backend returned 200 with prose, but
-	// the prose says it never got an image. Treat it as a failure so this text
-	// is not persisted as the file's description — a silent save here is how the
-	// bug hid for so long. No retry (caller decides); the job log shows it.
-	if looksLikeNoImageResponse(description) {
-		preview := strings.ReplaceAll(description, "\n", " ")
-		if len(preview) > 160 {
-			preview = preview[:160] + "…"
-		}
-		return "", fmt.Errorf("model returned a no-image response (image was not ingested by the backend): %q", preview)
-	}
-	return description, nil
+	return resizedPath, cleanup, nil
 }
 
 // logImageParseToJob pushes one line into the per-job stdout (visible in the
@@ -342,8 +366,8 @@ func callOllamaVision(ctx context.Context, imagePath, _ string, customPrompt str
 }
 
 // generateTranscriptWithFasterWhisper transcribes one file through the
-// transcribe facade — the provider (local Faster-Whisper CLI today, possibly
-// an HTTP service later) and its model/language/VAD settings come from config.
+// transcribe facade — the provider (local Faster-Whisper CLI or an HTTP
+// service) and its model/language/VAD settings come from config.
 func generateTranscriptWithFasterWhisper(ctx context.Context, q *jobqueue.Queue, jobID string, filePath string) (string, error) {
 	logFn := func(line string) {
 		if q != nil && jobID != "" {
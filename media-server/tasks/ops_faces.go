@@ -43,6 +43,9 @@ func registerFacesItemOp() {
 		Options: []TaskOption{
 			{Name: "model", Label: "Face Model", Type: "string", Description: "Pin scanning to one face model ID (default: automatic photo/anime routing)"},
 			{Name: "cluster-every", Label: "Cluster Every N Faces", Type: "number", Default: float64(defaultClusterEvery), Description: "Run an incremental people-clustering pass after this many newly scanned faces so People appear while the scan runs (0 = only queue one clustering job at the end)"},
+			{Name: "video-sampling", Label: "Video Sampling", Type: "enum", Choices: []string{"interval", "scenes", "midpoint"}, Default: "interval", Description: "Which video frames to scan: every N seconds, at scene changes, or only the midpoint frame. Faces are tracked across samples and stored once per track with its time range"},
+			{Name: "video-interval", Label: "Video Sample Interval (s)", Type: "number", Default: defaultVideoFaceInterval, Description: "Seconds between sampled frames in interval mode"},
+			{Name: "video-max-frames", Label: "Max Frames Per Video", Type: "number", Default: float64(defaultVideoFaceMaxFrames), Description: "Cap on sampled frames per video; longer videos are sampled more sparsely"},
 		},
 		Concurrency: func() int {
 			workers, _ := ResolveFaceResources()
@@ -58,6 +61,7 @@ type facesOpState struct {
 	q        *jobQueueRef
 	embedBin string
 	timeout  time.Duration
+	sampling videoSampling // zero Interval and Scenes = midpoint frame only
 
 	// Routing. anchors == nil means routing is off (pinned or unavailable)
 	// and pinnedModel is used for everything.
@@ -194,7 +198,7 @@ func broadcastPeopleUpdated(modelIDs []string) {
 	if err != nil {
 		return
 	}
-	stream.Broadcast(stream.Message{Type: "people-updated", Msg: string(payload)})
+	stream.Broadcast(stream.Message{Type: "people-updated", Msg: string(payload), Topics: []string{stream.TopicPeople}})
 }
 
 // jobQueueRef bundles the queue+job identifiers the op needs for logging.
@@ -228,6 +232,22 @@ func prepareFacesOp(run *ItemRun) (*ItemProcessor, error) {
 	if st.clusterEvery > 0 {
 		q.PushJobStdout(j.ID, fmt.Sprintf("Incremental clustering: people update every %d new faces", st.clusterEvery))
 	}
+	st.sampling = videoSampling{Interval: defaultVideoFaceInterval, MaxFrames: defaultVideoFaceMaxFrames}
+	if v, ok := run.Opts["video-interval"].(float64); ok && v > 0 {
+		st.sampling.Interval = v
+	}
+	if v, ok := run.Opts["video-max-frames"].(float64); ok && v > 0 {
+		st.sampling.MaxFrames = int(v)
+	}
+	switch mode, _ := run.Opts["video-sampling"].(string); mode {
+	case "midpoint":
+		st.sampling = videoSampling{}
+	case "scenes":
+		st.sampling.Scenes = true
+		q.PushJobStdout(j.ID, fmt.Sprintf("Videos: sampling at scene changes (max %d frames)", st.sampling.MaxFrames))
+	default:
+		q.PushJobStdout(j.ID, fmt.Sprintf("Videos: sampling every %gs (max %d frames), tracking faces across samples", st.sampling.Interval, st.sampling.MaxFrames))
+	}
 
 	// Resolve routing vs a pinned model. An explicit model option pins
 	// everything (background migration, same contract as embed); otherwise
@@ -373,7 +393,7 @@ func (st *facesOpState) routingPool(ctx context.Context, run *ItemRun) (*servePo
 	if st.routePool != nil || st.routePoolErr != nil {
 		return st.routePool, st.routePoolErr
 	}
-	imageModel, err := deps.ModelPath(st.embedModel.ID, st.embedModel.ImageModelFile)
+	imageModel, err := embedModelFilePath(st.embedModel, st.embedModel.ImageModelFile)
 	if err != nil || imageModel == "" {
 		st.routePoolErr = fmt.Errorf("%s image model not installed", st.embedModel.DisplayName)
 		return nil, st.routePoolErr
@@ -469,8 +489,9 @@ func (st *facesOpState) poolFor(ctx context.Context, run *ItemRun, m FaceModel)
 	return pool, nil
 }
 
-// processOne scans a single item: route → frame → worker → parse, returning
-// the serialized commit (store faces + index + incremental people assignment).
+// processOne scans a single item: route → frame(s) → worker → parse,
+// returning the serialized commit (store faces + index + incremental people
+// assignment). Videos with sampling on scan many frames and store tracks.
 func (st *facesOpState) processOne(ctx context.Context, run *ItemRun, path, localPath string) (*ItemCommit, error) {
 	model := st.modelFor(ctx, run, path, localPath)
 	pool, perr := st.poolFor(ctx, run, model)
@@ -478,43 +499,31 @@ func (st *facesOpState) processOne(ctx context.Context, run *ItemRun, path, loca
 		return nil, perr
 	}
 
-	imagePath, tempFrame, ferr := extractFrameForFile(ctx, localPath, st.timeout)
-	if ferr != nil {
-		return nil, fmt.Errorf("frame extract: %w", ferr)
-	}
-	defer func() {
-		if tempFrame != "" {
-			_ = os.Remove(tempFrame)
-		}
-	}()
-
-	w, aerr := pool.acquire(ctx)
-	if aerr != nil {
-		return nil, aerr
-	}
-	faces, err, abandoned := runWithTimeout(ctx, st.timeout, func() ([]media.NewFace, error) {
-		if werr := w.writeLine(imagePath); werr != nil {
-			return nil, werr
-		}
-		line, ok := w.readLine()
-		if !ok {
-			return nil, fmt.Errorf("faces worker died: %s", w.stderrString())
+	var faces []media.NewFace
+	detail := ""
+	if (st.sampling.Interval > 0 || st.sampling.Scenes) && isVideoFrameSource(localPath) {
+		var frames int
+		var err error
+		faces, frames, err = st.scanVideo(ctx, pool, model, localPath)
+		if err != nil {
+			return nil, err
 		}
-		if msg, found := strings.CutPrefix(line, "ERR "); found {
-			return nil, fmt.Errorf("%s", msg)
+		detail = fmt.Sprintf("%d face track(s) over %d frame(s) [%s]", len(faces), frames, model.ID)
+	} else {
+		imagePath, tempFrame, ferr := extractFrameForFile(ctx, localPath, st.timeout)
+		if ferr != nil {
+			return nil, fmt.Errorf("frame extract: %w", ferr)
 		}
-		return parseFacesLine(line)
-	})
-	if abandoned {
-		pool.discard(w) // request still in flight — the worker is unusable
-		if err != nil {
-			return nil, err // cancelled mid-compute
+		defer func() {
+			if tempFrame != "" {
+				_ = os.Remove(tempFrame)
+			}
+		}()
+		var err error
+		if faces, err = st.detect(ctx, pool, imagePath); err != nil {
+			return nil, err
 		}
-		return nil, fmt.Errorf("timed out after %s", st.timeout)
-	}
-	pool.release(w)
-	if err != nil {
-		return nil, err
+		detail = fmt.Sprintf("%d face(s) [%s]", len(faces), model.ID)
 	}
 
 	db := run.Queue.Db
@@ -541,6 +550,81 @@ func (st *facesOpState) processOne(ctx context.Context, run *ItemRun, path, loca
 			}
 			return nil
 		},
-		Detail: fmt.Sprintf("%d face(s) [%s]", len(faces), model.ID),
+		Detail: detail,
 	}, nil
 }
+
+// detect runs one image through a faces worker.
+func (st *facesOpState) detect(ctx context.Context, pool *servePool, imagePath string) ([]media.NewFace, error) {
+	w, aerr := pool.acquire(ctx)
+	if aerr != nil {
+		return nil, aerr
+	}
+	faces, err, abandoned := runWithTimeout(ctx, st.timeout, func() ([]media.NewFace, error) {
+		if werr := w.writeLine(imagePath); werr != nil {
+			return nil, werr
+		}
+		line, ok := w.readLine()
+		if !ok {
+			return nil, fmt.Errorf("faces worker died: %s", w.stderrString())
+		}
+		if msg, found := strings.CutPrefix(line, "ERR "); found {
+			return nil, fmt.Errorf("%s", msg)
+		}
+		return parseFacesLine(line)
+	})
+	if abandoned {
+		pool.discard(w) // request still in flight — the worker is unusable
+		if err != nil {
+			return nil, err // cancelled mid-compute
+		}
+		return nil, fmt.Errorf("timed out after %s", st.timeout)
+	}
+	pool.release(w)
+	return faces, err
+}
+
+// scanVideo samples a video, detects faces on every sample, and chains them
+// into tracks (see face_tracks.go). A video ffmpeg can't sample falls back to
+// the single midpoint frame, stored untracked like before.
+func (st *facesOpState) scanVideo(ctx context.Context, pool *servePool, model FaceModel, localPath string) ([]media.NewFace, int, error) {
+	dir, err := os.MkdirTemp("", "face_frames_")
+	if err != nil {
+		return nil, 0, err
+	}
+	defer os.RemoveAll(dir)
+
+	frames, serr := sampleVideoFrames(ctx, localPath, dir, st.sampling, st.timeout)
+	if serr != nil {
+		if ctx.Err() != nil {
+			return nil, 0, ctx.Err()
+		}
+		imagePath, tempFrame, ferr := extractFrameForFile(ctx, localPath, st.timeout)
+		if ferr != nil {
+			return nil, 0, fmt.Errorf("frame extract: %w (sampling: %v)", ferr, serr)
+		}
+		defer os.Remove(tempFrame)
+		faces, err := st.detect(ctx, pool, imagePath)
+		return faces, 1, err
+	}
+
+	samples := make([]frameFaces, 0, len(frames))
+	for _, fr := range frames {
+		faces, err := st.detect(ctx, pool, fr.Path)
+		if err != nil {
+			return nil, 0, fmt.Errorf("frame at %.1fs: %w", fr.TS, err)
+		}
+		for i := range faces {
+			faces[i].FrameTS = fr.TS
+		}
+		samples = append(samples, frameFaces{TS: fr.TS, Faces: faces})
+		_ = os.Remove(fr.Path) // free disk as we go on long videos
+	}
+	// Track over the samples' average spacing: scene samples are irregular,
+	// and long videos widen the configured interval.
+	spacing := st.sampling.Interval
+	if n := len(frames); n > 1 {
+		spacing = (frames[n-1].TS - frames[0].TS) / float64(n-1)
+	}
+	return trackFaces(samples, defaultTrackParams(model, spacing)), len(frames), nil
+}
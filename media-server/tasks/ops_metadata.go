@@ -38,8 +38,12 @@ func registerBuiltinItemOps() {
 	})
 
 	RegisterItemOp(ItemOp{
-		ID:      "transcribe",
-		Name:    "Transcript",
+		ID:   "transcribe",
+		Name: "Transcript",
+		Options: []TaskOption{
+			{Name: "diarize", Label: "Label Speakers", Type: "bool", Description: "Label cues Speaker 1..N after transcribing (always on when diarization is enabled in settings)"},
+			diarizeSpeakersOption,
+		},
 		Applies: extAppliesFn(append(append([]string{}, videoExts...), audioExts...)...),
 		Prepare: prepareTranscribeOp,
 	})
@@ -62,6 +66,10 @@ func registerBuiltinItemOps() {
 	registerEmbedItemOp()
 	registerAutotagItemOp()
 	registerFacesItemOp()
+	registerTextEmbedItemOp()
+	registerLLMTagItemOp()
+	registerDiarizeItemOp()
+	registerTranslateItemOp()
 }
 
 func prepareDescribeOp(run *ItemRun) (*ItemProcessor, error) {
@@ -98,6 +106,25 @@ func prepareTranscribeOp(run *ItemRun) (*ItemProcessor, error) {
 	q, jobID := run.Queue, run.Job.ID
 	db := q.Db
 
+	// Diarization is an optional stage: when its model or workers aren't
+	// available the job still produces plain transcripts.
+	var d *diarizer
+	speakers := speakersOpt(run.Opts)
+	if on, _ := run.Opts["diarize"].(bool); on || appconfig.Get().TranscriptionDiarize {
+		var desc string
+		var err error
+		if d, desc, err = newDiarizer(run.Job.Ctx, run.Workers, run.Background); err != nil {
+			q.PushJobStdout(jobID, fmt.Sprintf("Warning: speaker labels disabled: %v", err))
+		} else {
+			q.PushJobStdout(jobID, "Diarization: "+desc)
+		}
+	}
+	closeDiarizer := func() {
+		if d != nil {
+			d.close()
+		}
+	}
+
 	return &ItemProcessor{
 		SkipExisting: func(path string) (bool, error) { return hasExistingMetadata(db, path, "transcript") },
 		Process: func(ctx context.Context, path, localPath string) (*ItemCommit, error) {
@@ -105,6 +132,19 @@ func prepareTranscribeOp(run *ItemRun) (*ItemProcessor, error) {
 			if err != nil {
 				return nil, err
 			}
+			detail := "transcript generated"
+			if d != nil {
+				logf := func(s string) { q.PushJobStdout(jobID, "[diarize] "+s) }
+				if labeled, n, err := d.label(ctx, localPath, transcript, speakers, logf); err != nil {
+					if ctx.Err() != nil {
+						return nil, ctx.Err()
+					}
+					logf(fmt.Sprintf("Warning: keeping unlabeled transcript: %v", err))
+				} else {
+					transcript = labeled
+					detail = fmt.Sprintf("transcript generated, %d speaker(s)", n)
+				}
+			}
 			return &ItemCommit{
 				Commit: func() error {
 					if err := updateMediaMetadata(db, path, "transcript", transcript); err != nil {
@@ -113,9 +153,10 @@ func prepareTranscribeOp(run *ItemRun) (*ItemProcessor, error) {
 					notifyProgress(ProgressTranscript, 1)
 					return nil
 				},
-				Detail: "transcript generated",
+				Detail: detail,
 			}, nil
 		},
+		Close: closeDiarizer,
 	}, nil
 }
 
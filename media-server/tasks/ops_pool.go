@@ -117,7 +117,7 @@ func prepareEmbedOp(run *ItemRun) (*ItemProcessor, error) {
 		}
 	}
 
-	imageModel, _ := deps.ModelPath(model.ID, model.ImageModelFile)
+	imageModel, _ := embedModelFilePath(model, model.ImageModelFile)
 	if imageModel == "" {
 		return nil, fmt.Errorf("%s not installed; install it from Dependencies", model.DisplayName)
 	}
@@ -227,18 +227,19 @@ func prepareAutotagOp(run *ItemRun) (*ItemProcessor, error) {
 
 	// Resolve the active tagging model + its files (deps first, config fallback).
 	tagger := ActiveTaggerModel()
-	modelPath := firstNonEmpty(depModelPathOrEmpty(tagger.ID, tagger.ModelFile), cfg.OnnxTagger.ModelPath)
+	modelPath := firstNonEmpty(taggerModelFilePath(tagger, tagger.ModelFile), cfg.OnnxTagger.ModelPath)
 	if modelPath == "" {
 		return nil, fmt.Errorf("%s not installed; install it from Dependencies", tagger.DisplayName)
 	}
-	labelsPath := firstNonEmpty(depModelPathOrEmpty(tagger.ID, tagger.LabelsFile), cfg.OnnxTagger.LabelsPath)
-	configPath := firstNonEmpty(depModelPathOrEmpty(tagger.ID, tagger.ConfigFile), cfg.OnnxTagger.ConfigPath)
+	labelsPath := firstNonEmpty(taggerModelFilePath(tagger, tagger.LabelsFile), cfg.OnnxTagger.LabelsPath)
+	configPath := firstNonEmpty(taggerModelFilePath(tagger, tagger.ConfigFile), cfg.OnnxTagger.ConfigPath)
 	onnxtagBin := deps.BundledOrEmpty("onnxtag")
 	if onnxtagBin == "" {
 		return nil, fmt.Errorf("onnxtag binary not installed; install it from Dependencies")
 	}
 
 	args := []string{"--serve", "--model=" + modelPath, "--provider=" + provider}
+	args = append(args, byoTaggerArgs(tagger)...)
 	if labelsPath != "" {
 		args = append(args, "--labels="+labelsPath)
 	}
@@ -1,6 +1,8 @@
 package tasks
 
 import (
+	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -85,3 +87,69 @@ func ParseOptions(j *jobqueue.Job, options []TaskOption) map[string]any {
 	}
 	return result
 }
+
+// ValidateArguments checks a job's arguments against the registered task's
+// options: numbers must parse, bools given as --flag=value must be a
+// boolean, enum values must be among the choices, and required options
+// without a default must be present. Flags the task doesn't declare and
+// commands that aren't registered tasks (custom CLI commands) pass as-is.
+// Saved workflows run it after expanding their parameters.
+func ValidateArguments(command string, args []string) error {
+	task, ok := tasks[command]
+	if !ok {
+		return nil
+	}
+	optMap := make(map[string]*TaskOption, len(task.Options))
+	for i := range task.Options {
+		optMap[task.Options[i].Name] = &task.Options[i]
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i < len(args); i++ {
+		if !strings.HasPrefix(args[i], "--") {
+			continue
+		}
+		key, value, hasEquals := strings.Cut(strings.TrimPrefix(args[i], "--"), "=")
+		opt, ok := optMap[key]
+		if !ok {
+			continue
+		}
+		seen[key] = true
+		if opt.Type == "bool" {
+			if hasEquals {
+				switch value {
+				case "true", "1", "yes", "false", "0", "no", "":
+				default:
+					return fmt.Errorf("--%s must be true or false, got %q", key, value)
+				}
+			}
+			continue
+		}
+		if !hasEquals && i+1 < len(args) && !strings.HasPrefix(args[i+1], "--") {
+			i++
+			value = args[i]
+		}
+		switch opt.Type {
+		case "number":
+			if _, err := strconv.ParseFloat(value, 64); err != nil {
+				return fmt.Errorf("--%s must be a number, got %q", key, value)
+			}
+		case "enum":
+			if value != "" && !slices.Contains(opt.Choices, value) {
+				return fmt.Errorf("--%s must be one of %s, got %q", key, strings.Join(opt.Choices, ", "), value)
+			}
+		case "multi-enum":
+			for _, v := range strings.Split(value, ",") {
+				if v = strings.TrimSpace(v); v != "" && !slices.Contains(opt.Choices, v) {
+					return fmt.Errorf("--%s: %q is not one of %s", key, v, strings.Join(opt.Choices, ", "))
+				}
+			}
+		}
+	}
+	for _, opt := range task.Options {
+		if opt.Required && opt.Default == nil && !seen[opt.Name] {
+			return fmt.Errorf("--%s is required", opt.Name)
+		}
+	}
+	return nil
+}
@@ -184,3 +184,34 @@ on two lines`
 		t.Errorf("prompt: got %q, want %q", result["prompt"], value)
 	}
 }
+
+func TestValidateArguments(t *testing.T) {
+	RegisterTask("validate-test", "Validate Test", []TaskOption{
+		{Name: "count", Type: "number"},
+		{Name: "force", Type: "bool"},
+		{Name: "size", Type: "enum", Choices: []string{"s", "m"}},
+		{Name: "kinds", Type: "multi-enum", Choices: []string{"a", "b"}},
+		{Name: "target", Type: "string", Required: true},
+	}, nil)
+	t.Cleanup(func() { delete(tasks, "validate-test") })
+
+	for _, tt := range []struct {
+		args    []string
+		wantErr bool
+	}{
+		{[]string{"--target", "x", "--count", "3", "--force", "--size=m", "--kinds", "a,b"}, false},
+		{[]string{"--target=x", "--force=false", "--unknown", "anything"}, false},
+		{[]string{"--target", "x", "--count", "three"}, true},
+		{[]string{"--target", "x", "--force=maybe"}, true},
+		{[]string{"--target", "x", "--size", "xl"}, true},
+		{[]string{"--target", "x", "--kinds", "a,c"}, true},
+		{[]string{"--count", "1"}, true},
+	} {
+		if err := ValidateArguments("validate-test", tt.args); (err != nil) != tt.wantErr {
+			t.Errorf("%v: err = %v, wantErr %v", tt.args, err, tt.wantErr)
+		}
+	}
+	if err := ValidateArguments("not-a-task", []string{"--count", "x"}); err != nil {
+		t.Errorf("unregistered command: %v", err)
+	}
+}
@@ -222,9 +222,9 @@ type resolvedItems struct {
 
 // resolveJobItems is the single input-resolution path for per-item tasks.
 // Precedence matches the historical behavior of every task: a search query
-// (--query / --query64, in arguments or input) wins; otherwise the input is
-// parsed as a newline-separated path list (flag tokens dropped, paths
-// absolutized, non-media files filtered out).
+// (--query / --query64, in arguments or input) wins, then a saved search
+// (--saved <name>); otherwise the input is parsed as a newline-separated path
+// list (flag tokens dropped, paths absolutized, non-media files filtered out).
 func resolveJobItems(j *jobqueue.Job, q *jobqueue.Queue) (resolvedItems, error) {
 	return resolveJobItemsFiltered(j, q, true)
 }
@@ -245,6 +245,13 @@ func resolveJobItemsFiltered(j *jobqueue.Job, q *jobqueue.Queue, mediaOnly bool)
 		}
 		return resolvedItems{Paths: paths, FromQuery: true, Query: qstr}, nil
 	}
+	if name, ok := extractSavedFromJob(j); ok {
+		paths, err := getMediaPathsBySaved(j.Ctx, q.Db, name)
+		if err != nil {
+			return resolvedItems{}, fmt.Errorf("load media paths for saved search %q: %w", name, err)
+		}
+		return resolvedItems{Paths: paths, FromQuery: true, Query: savedQueryLabel(name)}, nil
+	}
 
 	return resolvedItems{Paths: parseExplicitPaths(j.Input, mediaOnly)}, nil
 }
@@ -291,5 +298,8 @@ func ResolveItems(command string, arguments []string, input string) []string {
 	if _, ok := extractQueryFromJob(probe); ok {
 		return nil
 	}
+	if _, ok := extractSavedFromJob(probe); ok {
+		return nil
+	}
 	return parseExplicitPaths(input, true)
 }
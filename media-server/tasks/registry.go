@@ -1,8 +1,11 @@
 package tasks
 
 import (
+	"maps"
+	"slices"
 	"sync"
 
+	"github.com/stevecastle/shrike/appconfig"
 	"github.com/stevecastle/shrike/jobqueue"
 	"github.com/stevecastle/shrike/media"
 	"github.com/stevecastle/shrike/storage"
@@ -42,6 +45,7 @@ func init() {
 		for _, p := range paths {
 			IndexDelete(p)
 			FaceIndexDeletePath(p)
+			TextIndexDeletePath(p)
 		}
 	})
 
@@ -51,6 +55,16 @@ func init() {
 	// done/total progress, pause/resume with per-item durability.
 	registerBuiltinItemOps()
 
+	// Saved workflows check their expanded arguments against these schemas
+	// before submitting any job.
+	jobqueue.SetArgumentsValidator(ValidateArguments)
+
+	// Config validation reports BYO models that reuse a built-in ID; the
+	// model registries would otherwise skip them without a word.
+	appconfig.RegisterBuiltinModelIDs("byoFaceModels", slices.Collect(maps.Keys(builtinFaceModels)))
+	appconfig.RegisterBuiltinModelIDs("byoEmbedModels", slices.Collect(maps.Keys(embedModelRegistry)))
+	appconfig.RegisterBuiltinModelIDs("byoTaggerModels", slices.Collect(maps.Keys(taggerModelRegistry)))
+
 	// Register built-in tasks
 	RegisterTask("wait", "Wait", nil, waitFn)
 	RegisterTask("remove", "Remove Media", nil, removeFromDB)
@@ -61,10 +75,19 @@ func init() {
 	RegisterTask("transcribe", "Generate Transcripts", itemOpTaskOptions("transcribe"), makeItemOpTaskFn("transcribe"))
 	RegisterTask("hash", "Generate Hashes", itemOpTaskOptions("hash"), makeItemOpTaskFn("hash"))
 	RegisterTask("dimensions", "Generate Dimensions", itemOpTaskOptions("dimensions"), makeItemOpTaskFn("dimensions"))
+	RegisterTask("llm-tag", "Generate Structured Tags (LLM)", itemOpTaskOptions("llm-tag"), makeItemOpTaskFn("llm-tag"))
 	RegisterTask("process", "Process Media (Combined Ops)", processTaskOptions(), processTask)
 	RegisterTask("faces", "Detect Faces (ONNX)", itemOpTaskOptions("faces"), makeItemOpTaskFn("faces"))
+	RegisterTask("textembed", "Text Embedding (ONNX)", itemOpTaskOptions("textembed"), makeItemOpTaskFn("textembed"))
+	RegisterTask("diarize", "Label Transcript Speakers (ONNX)", itemOpTaskOptions("diarize"), makeItemOpTaskFn("diarize"))
+	RegisterTask("translate", "Translate Transcripts", itemOpTaskOptions("translate"), makeItemOpTaskFn("translate"))
 	RegisterTask("faces-cluster", "Cluster Faces into People", nil, facesClusterTask)
+	RegisterTask("cluster-library", "Cluster Library into Themes", clusterLibraryOptions, clusterLibraryTask)
 	RegisterTask("assign-person", "Assign Person to Media", assignPersonOptions, assignPersonTask)
+	RegisterTask("enroll-people", "Enroll People from Reference Photos", enrollPeopleOptions, enrollPeopleTask)
+	RegisterTask("xmp-export", "Write XMP Sidecars", xmpExportOptions, xmpExportTask)
+	RegisterTask("xmp-import", "Import XMP Sidecars", xmpImportOptions, xmpImportTask)
+	RegisterTask("subtitle-export", "Write Subtitle Sidecars", subtitleExportOptions, subtitleExportTask)
 
 	// Legacy alias: maps --type onto the split-out ops above.
 	RegisterTask("metadata", "Generate Metadata (Legacy)", metadataOptions, metadataTask)
@@ -86,15 +109,28 @@ func init() {
 	RegisterHostResolver("metadata", visionHost)
 	// The split-out LLM-vision ops share the inference cap, exactly as their
 	// former metadata-task selves did. Transcription also historically ran
-	// under the metadata task's inference bucket, so it keeps that behavior.
+	// under the metadata task's inference bucket and keeps that behavior,
+	// except that an HTTP transcription provider gets its own bucket.
 	RegisterHostResolver("describe", visionHost)
-	RegisterHostResolver("transcribe", visionHost)
+	RegisterHostResolver("llm-tag", visionHost)
+	RegisterHostResolver("transcribe", func(string) string { return TranscriptionHost() })
+	// Translation is an LLM text job by default; a --engine=whisper run is
+	// resolved to transcription's resources by ResolveResources.
+	RegisterHostResolver("translate", visionHost)
 	// A combined job may include LLM ops, so it conservatively takes the
 	// inference bucket (a hash-only combined run parking there is harmless).
 	RegisterHostResolver("process", visionHost)
 	// Embedding is a local ONNX task with its own concurrency bucket — it must
 	// not share the LLM inference cap (it parallelizes internally instead).
 	RegisterHostResolver("embed", func(string) string { return HostBucketEmbed })
+	// Text embedding runs the same embed binary in sentence mode, so it shares
+	// embed's bucket rather than doubling the ONNX load.
+	RegisterHostResolver("textembed", func(string) string { return HostBucketEmbed })
+	// Diarization runs the embed binary in speaker mode; same reasoning.
+	RegisterHostResolver("diarize", func(string) string { return HostBucketEmbed })
+	// Theme clustering reads every embedding; sharing embed's bucket keeps it
+	// from clustering a table an embed job is still filling.
+	RegisterHostResolver("cluster-library", func(string) string { return HostBucketEmbed })
 	// Face scanning is a local ONNX task with its own concurrency bucket — like
 	// embed/autotag, it parallelizes internally via its worker pool. Clustering
 	// shares the bucket so a scan and a recluster never run concurrently.
@@ -104,6 +140,11 @@ func init() {
 	// pipeline as a face scan), so it shares the faces bucket rather than
 	// racing a scan or recluster.
 	RegisterHostResolver("assign-person", func(string) string { return HostBucketFaces })
+	// Enrollment runs the face pipeline on reference photos and adds clustering
+	// seeds, so it shares the faces bucket too.
+	RegisterHostResolver("enroll-people", func(string) string { return HostBucketFaces })
+	// XMP import scans unscanned items to match their face regions.
+	RegisterHostResolver("xmp-import", func(string) string { return HostBucketFaces })
 	RegisterHostResolver("ingest", urlHostResolver)
 
 	RegisterTask("ffmpeg", "ffmpeg", ffmpegCustomOptions, ffmpegTask)
@@ -40,6 +40,7 @@ func TestGetTasks(t *testing.T) {
 		{"transcribe", "Generate Transcripts"},
 		{"hash", "Generate Hashes"},
 		{"dimensions", "Generate Dimensions"},
+		{"llm-tag", "Generate Structured Tags (LLM)"},
 		{"embed", "Visual Embedding (ONNX)"},
 		{"process", "Process Media (Combined Ops)"},
 	}
@@ -152,7 +152,7 @@ func broadcastMediaUpdated(paths []string) {
 		return
 	}
 	payload, _ := json.Marshal(map[string]any{"paths": paths})
-	stream.Broadcast(stream.Message{Type: "media-updated", Msg: string(payload)})
+	stream.Broadcast(stream.Message{Type: "media-updated", Msg: string(payload), Topics: []string{stream.TopicMedia}})
 }
 
 // broadcastMediaCreated sends an SSE event so clients can add newly
@@ -162,7 +162,7 @@ func broadcastMediaCreated(paths []string) {
 		return
 	}
 	payload, _ := json.Marshal(map[string]any{"paths": paths})
-	stream.Broadcast(stream.Message{Type: "media-created", Msg: string(payload)})
+	stream.Broadcast(stream.Message{Type: "media-created", Msg: string(payload), Topics: []string{stream.TopicMedia}})
 }
 
 // stripLokiTemp removes the .loki-temp/<jobID>/ segment from a path,
@@ -0,0 +1,80 @@
+package tasks
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/stevecastle/shrike/jobqueue"
+)
+
+// Saved searches (--saved <name>) are stored and expanded by package main:
+// their predicate lists can hold similarity predicates, which only the query
+// handler knows how to resolve. The resolver is registered at startup; when
+// unset (tests, lokictl) a --saved job fails rather than running on nothing.
+
+// savedSearchResolver holds a func(ctx, db, name) ([]string, error).
+var savedSearchResolver atomic.Value
+
+// SetSavedSearchResolver registers the function that turns a saved search's
+// name into the media paths it currently matches.
+func SetSavedSearchResolver(fn func(ctx context.Context, db *sql.DB, name string) ([]string, error)) {
+	savedSearchResolver.Store(fn)
+}
+
+// extractSavedFromJob checks args and input for a saved-search name
+// (--saved <name> or --saved=<name>). Like --query, a bare --saved swallows
+// the following non-flag tokens so unquoted multi-word names survive.
+func extractSavedFromJob(j *jobqueue.Job) (string, bool) {
+	scan := func(tokens []string) (string, bool) {
+		for i := 0; i < len(tokens); i++ {
+			lower := strings.ToLower(tokens[i])
+			if strings.HasPrefix(lower, "--saved=") {
+				if name := strings.TrimSpace(tokens[i][len("--saved="):]); name != "" {
+					return name, true
+				}
+			}
+			if lower == "--saved" && i+1 < len(tokens) {
+				end := i + 1
+				for end < len(tokens) && !(strings.HasPrefix(tokens[end], "-") && end > i+1) {
+					end++
+				}
+				if name := strings.TrimSpace(strings.Join(tokens[i+1:end], " ")); name != "" {
+					return name, true
+				}
+			}
+		}
+		return "", false
+	}
+	if name, ok := scan(j.Arguments); ok {
+		return name, true
+	}
+	if input := strings.TrimSpace(j.Input); input != "" {
+		return scan(tokenizeCommandLine(input))
+	}
+	return "", false
+}
+
+// getMediaPathsBySaved resolves a saved search into the media paths it
+// currently matches (non-media rows dropped, as for --query).
+func getMediaPathsBySaved(ctx context.Context, db *sql.DB, name string) ([]string, error) {
+	fn, _ := savedSearchResolver.Load().(func(context.Context, *sql.DB, string) ([]string, error))
+	if fn == nil {
+		return nil, fmt.Errorf("saved searches are not available")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	paths, err := fn(ctx, db, name)
+	if err != nil {
+		return nil, err
+	}
+	return filterMediaPaths(paths), nil
+}
+
+// savedQueryLabel is how a --saved selection reads in job logs.
+func savedQueryLabel(name string) string {
+	return fmt.Sprintf("saved:%q", name)
+}
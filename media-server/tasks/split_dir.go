@@ -332,12 +332,15 @@ func splitDirTask(j *jobqueue.Job, q *jobqueue.Queue, mu *sync.Mutex) error {
 			continue
 		}
 		updated += int(res.Items)
+		auditJob(q, j, "media.move", []string{dbFrom, dbTo}, map[string]string{"path": dbFrom},
+			map[string]any{"path": dbTo, "rows": res.Rows})
 		// Derived in-memory state is keyed by path: without this the vector
-		// index keeps returning the old path and the face index's path→keys
-		// map misses on the next eviction.
+		// index keeps returning the old path and the face and text-chunk
+		// indexes' path→keys maps miss on the next eviction.
 		if res.Items > 0 {
 			IndexRenamePath(q.Db, dbFrom, dbTo)
 			FaceIndexRenamePath(dbFrom, dbTo)
+			TextIndexRenamePath(q.Db, dbFrom, dbTo)
 		}
 		q.RegisterOutputFile(j.ID, destPath)
 
@@ -417,11 +420,12 @@ func listSplitCandidates(dir string) ([]splitFile, error) {
 }
 
 // sidecarExts are the extensions that describe another file rather than being
-// content in their own right: transcripts, subtitles, and the metadata blobs
-// downloaders write beside what they fetched.
+// content in their own right: transcripts, subtitles, the metadata blobs
+// downloaders write beside what they fetched, and XMP sidecars.
 var sidecarExts = map[string]bool{
 	".json": true, ".vtt": true, ".srt": true, ".ass": true, ".ssa": true,
 	".txt": true, ".nfo": true, ".description": true, ".info": true,
+	".xmp": true,
 }
 
 // resolveSidecars points each sidecar at the file it describes, so the two are
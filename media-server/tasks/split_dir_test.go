@@ -310,7 +310,7 @@ func TestSplitDirKeepsSameStemSidecarsWithTheirMedia(t *testing.T) {
 	dir := t.TempDir()
 
 	seedSplitItem(t, db, dir, "lecture.mp4")
-	for _, sidecar := range []string{"lecture.srt", "lecture.vtt", "lecture.json"} {
+	for _, sidecar := range []string{"lecture.srt", "lecture.vtt", "lecture.json", "lecture.xmp"} {
 		if err := os.WriteFile(filepath.Join(dir, sidecar), []byte("x"), 0o644); err != nil {
 			t.Fatal(err)
 		}
@@ -322,7 +322,7 @@ func TestSplitDirKeepsSameStemSidecarsWithTheirMedia(t *testing.T) {
 
 	runSplitDir(t, db, []string{"--target", dir, "--mode", "date", "--granularity", "month"})
 
-	for _, sidecar := range []string{"lecture.srt", "lecture.vtt", "lecture.json"} {
+	for _, sidecar := range []string{"lecture.srt", "lecture.vtt", "lecture.json", "lecture.xmp"} {
 		if _, err := os.Stat(filepath.Join(dir, "2024-05", sidecar)); err != nil {
 			t.Errorf("%s was separated from lecture.mp4: %v", sidecar, err)
 		}
@@ -0,0 +1,200 @@
+package tasks
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/stevecastle/shrike/jobqueue"
+	"github.com/stevecastle/shrike/subtitle"
+)
+
+// Subtitle sidecars: stored transcripts as .srt/.vtt files players pick up.
+//
+// subtitle-export writes each item's transcript next to the file (clip.mp4 →
+// clip.srt) or into a target directory under the same stem. Speakers from
+// diarization are kept as voice spans in VTT; the speakers option also folds
+// them into the text ("Speaker 1: ...") for players that ignore voice spans
+// and for SRT, which has no speaker markup. Existing sidecars — often
+// subtitles a downloader fetched — are kept unless overwrite is set.
+var subtitleExportOptions = []TaskOption{
+	{Name: "format", Label: "Format", Type: "enum", Choices: []string{"srt", "vtt", "both"}, Default: "srt",
+		Description: "Sidecar format to write"},
+	{Name: "speakers", Label: "Speaker Prefixes", Type: "bool",
+		Description: "Prefix each cue with its speaker's name (\"Speaker 1: ...\")"},
+	{Name: "dir", Label: "Target Directory", Type: "string",
+		Description: "Write sidecars here instead of next to each file"},
+	{Name: "overwrite", Label: "Overwrite", Type: "bool",
+		Description: "Replace existing sidecars of the same name"},
+}
+
+// subtitleFormats expands the format option into file extensions.
+func subtitleFormats(format string) []string {
+	switch format {
+	case "vtt":
+		return []string{".vtt"}
+	case "both":
+		return []string{".srt", ".vtt"}
+	default:
+		return []string{".srt"}
+	}
+}
+
+// subtitleSidecarPath is where path's sidecar with extension ext goes: beside
+// the file, or in dir when one is given.
+func subtitleSidecarPath(path, dir, ext string) string {
+	stem := strings.TrimSuffix(path, filepath.Ext(path))
+	if dir != "" {
+		stem = filepath.Join(dir, filepath.Base(stem))
+	}
+	return stem + ext
+}
+
+// renderSubtitles renders a stored transcript in the sidecar format for ext.
+func renderSubtitles(transcript, ext string, speakers bool) (string, bool) {
+	cues := subtitle.Parse(transcript)
+	if len(cues) == 0 {
+		return "", false
+	}
+	if speakers {
+		cues = subtitle.WithSpeakerPrefix(cues)
+	}
+	if ext == ".vtt" {
+		return subtitle.FormatVTT(cues), true
+	}
+	return subtitle.FormatSRT(cues), true
+}
+
+// writeSubtitleSidecar writes data to dest via a temp file in the same
+// directory, so a player never picks up a half-written file.
+func writeSubtitleSidecar(dest string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".subtitle-*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// storedTranscript reads path's transcript ("" when it has none).
+func storedTranscript(db *sql.DB, path string) (string, error) {
+	var t sql.NullString
+	err := db.QueryRow(`SELECT transcript FROM media WHERE path = ?`, path).Scan(&t)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return t.String, err
+}
+
+func subtitleExportTask(j *jobqueue.Job, q *jobqueue.Queue, mu *sync.Mutex) error {
+	ctx := j.Ctx
+	opts := ParseOptions(j, subtitleExportOptions)
+	format, _ := opts["format"].(string)
+	speakers, _ := opts["speakers"].(bool)
+	dir, _ := opts["dir"].(string)
+	dir = strings.TrimSpace(dir)
+	overwrite, _ := opts["overwrite"].(bool)
+
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			q.PushJobStdout(j.ID, fmt.Sprintf("Error creating target directory: %v", err))
+			q.ErrorJob(j.ID)
+			return err
+		}
+	}
+	items, err := resolveJobItems(j, q)
+	if err != nil {
+		q.PushJobStdout(j.ID, fmt.Sprintf("Error resolving input: %v", err))
+		q.ErrorJob(j.ID)
+		return err
+	}
+	paths := items.Paths
+	if len(paths) == 0 {
+		q.PushJobStdout(j.ID, "No items to process")
+		q.CompleteJob(j.ID)
+		return nil
+	}
+	q.PushJobStdout(j.ID, fmt.Sprintf("Writing subtitle sidecars for %d item(s)", len(paths)))
+	_ = q.SetJobProgress(j.ID, 0, len(paths))
+
+	exts := subtitleFormats(format)
+	claimed := map[string]string{} // dest → the item that wrote it this run
+	var written, kept, empty, failed int
+	for i, p := range paths {
+		select {
+		case <-ctx.Done():
+			q.PushJobStdout(j.ID, "Task was canceled")
+			_ = q.CancelJob(j.ID)
+			return ctx.Err()
+		default:
+		}
+		if q.PauseRequested(j.ID) {
+			q.PushJobStdout(j.ID, fmt.Sprintf("Paused at %d/%d - resume to continue", i, len(paths)))
+			return jobqueue.ErrPaused
+		}
+		_ = q.SetJobProgress(j.ID, i, len(paths))
+
+		transcript, err := storedTranscript(q.Db, p)
+		if err != nil {
+			q.PushJobStdout(j.ID, fmt.Sprintf("Warning: could not read transcript for %s: %v", p, err))
+			failed++
+			continue
+		}
+		if strings.TrimSpace(transcript) == "" {
+			empty++
+			continue
+		}
+		if dir == "" && strings.HasPrefix(p, "s3://") {
+			q.PushJobStdout(j.ID, fmt.Sprintf("Skipped %s: remote items need a target directory", p))
+			failed++
+			continue
+		}
+		for _, ext := range exts {
+			dest := subtitleSidecarPath(p, dir, ext)
+			if prev, ok := claimed[dest]; ok {
+				q.PushJobStdout(j.ID, fmt.Sprintf("Skipped %s: %s was already written for %s", p, dest, prev))
+				kept++
+				continue
+			}
+			if _, err := os.Stat(dest); err == nil && !overwrite {
+				q.PushJobStdout(j.ID, fmt.Sprintf("Kept existing %s", dest))
+				kept++
+				continue
+			}
+			data, ok := renderSubtitles(transcript, ext, speakers)
+			if !ok {
+				empty++
+				break
+			}
+			if err := writeSubtitleSidecar(dest, []byte(data)); err != nil {
+				q.PushJobStdout(j.ID, fmt.Sprintf("Warning: could not write %s: %v", dest, err))
+				failed++
+				continue
+			}
+			claimed[dest] = p
+			written++
+			q.RegisterOutputFile(j.ID, dest)
+		}
+	}
+	_ = q.SetJobProgress(j.ID, len(paths), len(paths))
+	q.PushJobStdout(j.ID, fmt.Sprintf(
+		"Subtitle export complete: %d sidecar(s) written, %d kept (use --overwrite), %d item(s) without a transcript, %d failure(s)",
+		written, kept, empty, failed))
+	q.CompleteJob(j.ID)
+	return nil
+}
@@ -0,0 +1,86 @@
+package tasks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stevecastle/shrike/jobqueue"
+)
+
+const diarizedVTT = "WEBVTT\n\n00:00:01.000 --> 00:00:02.500\n<v Speaker 1>Hello there.\n\n" +
+	"00:00:03.000 --> 00:00:04.000\n<v Ann>General Kenobi.\n"
+
+func runSubtitleExport(t *testing.T, q *jobqueue.Queue, args []string, input string) *jobqueue.Job {
+	t.Helper()
+	id, err := q.AddJob("", "subtitle-export", args, input, nil)
+	if err != nil {
+		t.Fatalf("add job: %v", err)
+	}
+	j, err := q.ClaimJob()
+	if err != nil || j == nil {
+		t.Fatalf("claim job: %v (job=%v)", err, j)
+	}
+	if err := subtitleExportTask(j, q, nil); err != nil {
+		t.Fatalf("subtitle-export: %v", err)
+	}
+	if got := q.Jobs[id].State; got != jobqueue.StateCompleted {
+		t.Fatalf("job status = %v, want completed. stdout:\n%s", got, strings.Join(q.Jobs[id].Stdout, "\n"))
+	}
+	return q.Jobs[id]
+}
+
+func readFile(t *testing.T, p string) string {
+	t.Helper()
+	b, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestSubtitleExportWritesSidecars(t *testing.T) {
+	db := newEnrollDB(t)
+	dir := t.TempDir()
+	clip := filepath.Join(dir, "talk.mp4")
+	silent := filepath.Join(dir, "silent.mp4")
+	for _, p := range []string{clip, silent} {
+		if err := os.WriteFile(p, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.Exec(`INSERT INTO media (path, transcript) VALUES (?, ?), (?, NULL)`, clip, diarizedVTT, silent); err != nil {
+		t.Fatal(err)
+	}
+	q := jobqueue.NewQueueWithDB(db)
+	input := clip + "\n" + silent
+
+	// A downloader's subtitle next to the file is kept by default.
+	srt := filepath.Join(dir, "talk.srt")
+	if err := os.WriteFile(srt, []byte("theirs"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runSubtitleExport(t, q, []string{"--format", "both"}, input)
+	if got := readFile(t, srt); got != "theirs" {
+		t.Errorf("existing .srt overwritten: %q", got)
+	}
+	if got := readFile(t, filepath.Join(dir, "talk.vtt")); got != diarizedVTT {
+		t.Errorf(".vtt =\n%s\nwant the stored transcript", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "silent.srt")); !os.IsNotExist(err) {
+		t.Errorf("sidecar written for an item without a transcript (err=%v)", err)
+	}
+
+	runSubtitleExport(t, q, []string{"--format", "srt", "--speakers", "--overwrite"}, input)
+	want := "1\n00:00:01,000 --> 00:00:02,500\nSpeaker 1: Hello there.\n\n2\n00:00:03,000 --> 00:00:04,000\nAnn: General Kenobi.\n"
+	if got := readFile(t, srt); got != want {
+		t.Errorf(".srt =\n%s\nwant\n%s", got, want)
+	}
+
+	out := filepath.Join(t.TempDir(), "subs")
+	runSubtitleExport(t, q, []string{"--format", "vtt", "--dir", out}, input)
+	if got := readFile(t, filepath.Join(out, "talk.vtt")); got != diarizedVTT {
+		t.Errorf("target-dir .vtt =\n%s", got)
+	}
+}
@@ -1,17 +1,37 @@
 package tasks
 
-import "github.com/stevecastle/shrike/appconfig"
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/stevecastle/shrike/appconfig"
+)
 
 // TaggerModel describes one auto-tagging model: its identity and the files it
 // needs (all resolved under the model's dir via deps.ModelPath). This mirrors
 // the embedding model registry (embedmodels.go) so the tagger is switchable —
 // a new model slots in by adding one entry here plus a manifest download entry.
+// Bring-your-own models (appconfig.ByoTaggerModel) set BYO, carry absolute
+// paths in the *File fields, and may override the onnxtag preprocessing.
 type TaggerModel struct {
 	ID          string
 	DisplayName string
 	ModelFile   string // ONNX model, e.g. "model.onnx"
 	LabelsFile  string // label/category CSV, e.g. "selected_tags.csv"
 	ConfigFile  string // preprocessing config JSON, e.g. "config.json"
+
+	// BYO marks a config-declared model. The fields below are forwarded to
+	// onnxtag as flags when set; a ConfigFile still overrides them.
+	BYO        bool
+	InputName  string
+	OutputName string
+	Size       int
+	Mean, Std  []float64
+	Layout     string
+	ColorOrder string
+	PixelRange string
+	PadSquare  bool
 }
 
 // DefaultTaggerModelID is used when the configured model is empty or unknown.
@@ -28,14 +48,63 @@ var taggerModelRegistry = map[string]TaggerModel{
 	},
 }
 
-// TaggerModelByID returns the registry entry and whether it exists.
+// byoToTaggerModel converts a config BYO declaration into a TaggerModel.
+func byoToTaggerModel(b appconfig.ByoTaggerModel) TaggerModel {
+	m := TaggerModel{
+		ID:          strings.TrimSpace(b.ID),
+		DisplayName: b.Name,
+		ModelFile:   b.ModelPath,
+		LabelsFile:  b.LabelsPath,
+		ConfigFile:  b.ConfigPath,
+		BYO:         true,
+		InputName:   b.InputName,
+		OutputName:  b.OutputName,
+		Size:        b.Size,
+		Mean:        b.Mean,
+		Std:         b.Std,
+		Layout:      strings.ToUpper(strings.TrimSpace(b.Layout)),
+		ColorOrder:  strings.ToUpper(strings.TrimSpace(b.ColorOrder)),
+		PixelRange:  strings.TrimSpace(b.PixelRange),
+		PadSquare:   b.PadSquare,
+	}
+	if m.DisplayName == "" {
+		m.DisplayName = m.ID + " (bring-your-own)"
+	}
+	return m
+}
+
+// byoTaggerModels returns the config's valid BYO entries that don't shadow a
+// built-in ID.
+func byoTaggerModels() []TaggerModel {
+	var out []TaggerModel
+	for _, b := range appconfig.Get().ByoTaggerModels {
+		if b.Validate() != nil {
+			continue
+		}
+		if _, clash := taggerModelRegistry[strings.TrimSpace(b.ID)]; clash {
+			continue // BYO may not shadow a built-in ID
+		}
+		out = append(out, byoToTaggerModel(b))
+	}
+	return out
+}
+
+// TaggerModelByID returns the model for id — built-in first, then the
+// config's BYO entries — and whether it exists.
 func TaggerModelByID(id string) (TaggerModel, bool) {
-	m, ok := taggerModelRegistry[id]
-	return m, ok
+	if m, ok := taggerModelRegistry[id]; ok {
+		return m, true
+	}
+	for _, m := range byoTaggerModels() {
+		if m.ID == id {
+			return m, true
+		}
+	}
+	return TaggerModel{}, false
 }
 
 // TaggerModelList returns all registered tagger models in a stable display
-// order (default first) for populating the config UI.
+// order (default first, then valid BYO entries) for populating the config UI.
 func TaggerModelList() []TaggerModel {
 	order := []string{"wd-eva02-large-tagger-v3"}
 	out := make([]TaggerModel, 0, len(taggerModelRegistry))
@@ -51,15 +120,67 @@ func TaggerModelList() []TaggerModel {
 			out = append(out, m)
 		}
 	}
-	return out
+	return append(out, byoTaggerModels()...)
 }
 
 // ActiveTaggerModel returns the configured active tagging model, falling back to
 // the default when the config is empty or names an unknown model.
 func ActiveTaggerModel() TaggerModel {
-	id := appconfig.Get().AutotagModel
-	if m, ok := taggerModelRegistry[id]; ok {
+	if m, ok := TaggerModelByID(strings.TrimSpace(appconfig.Get().AutotagModel)); ok {
 		return m
 	}
 	return taggerModelRegistry[DefaultTaggerModelID]
 }
+
+// taggerModelFilePath returns the on-disk path of one of m's files, or "" when
+// it is missing. BYO files are configured absolute paths; built-ins resolve
+// through the deps download flow.
+func taggerModelFilePath(m TaggerModel, file string) string {
+	if file == "" {
+		return ""
+	}
+	if m.BYO {
+		if _, err := os.Stat(file); err != nil {
+			return ""
+		}
+		return file
+	}
+	return depModelPathOrEmpty(m.ID, file)
+}
+
+// byoTaggerArgs returns the onnxtag preprocessing flags a BYO model declares
+// (none for built-ins, whose config.json carries everything).
+func byoTaggerArgs(m TaggerModel) []string {
+	if !m.BYO {
+		return nil
+	}
+	var args []string
+	if m.InputName != "" {
+		args = append(args, "--input="+m.InputName)
+	}
+	if m.OutputName != "" {
+		args = append(args, "--output="+m.OutputName)
+	}
+	if m.Size > 0 {
+		args = append(args, fmt.Sprintf("--width=%d", m.Size), fmt.Sprintf("--height=%d", m.Size))
+	}
+	if len(m.Mean) == 3 {
+		args = append(args, fmt.Sprintf("--mean=%g,%g,%g", m.Mean[0], m.Mean[1], m.Mean[2]))
+	}
+	if len(m.Std) == 3 {
+		args = append(args, fmt.Sprintf("--std=%g,%g,%g", m.Std[0], m.Std[1], m.Std[2]))
+	}
+	if m.Layout != "" {
+		args = append(args, "--layout="+m.Layout)
+	}
+	if m.ColorOrder != "" {
+		args = append(args, "--color="+m.ColorOrder)
+	}
+	if m.PixelRange != "" {
+		args = append(args, "--pixel-range="+m.PixelRange)
+	}
+	if m.PadSquare {
+		args = append(args, "--pad-square")
+	}
+	return args
+}
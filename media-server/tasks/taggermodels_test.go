@@ -1,6 +1,9 @@
 package tasks
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stevecastle/shrike/appconfig"
@@ -45,3 +48,54 @@ func TestTaggerModelList(t *testing.T) {
 		t.Error("display name should be a human-friendly label, not empty")
 	}
 }
+
+func TestByoTaggerModel(t *testing.T) {
+	prev := appconfig.Get()
+	t.Cleanup(func() { appconfig.Set(prev) })
+	dir := t.TempDir()
+	modelPath := filepath.Join(dir, "model.onnx")
+	labelsPath := filepath.Join(dir, "selected_tags.csv")
+	for _, p := range []string{modelPath, labelsPath} {
+		if err := os.WriteFile(p, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := prev
+	cfg.AutotagModel = "wd-vit-v3"
+	cfg.ByoTaggerModels = []appconfig.ByoTaggerModel{{
+		ID:         "wd-vit-v3",
+		ModelPath:  modelPath,
+		LabelsPath: labelsPath,
+		ConfigPath: filepath.Join(dir, "missing.json"),
+		Size:       448,
+		Layout:     "nhwc",
+		ColorOrder: "bgr",
+		PixelRange: "0_255",
+		PadSquare:  true,
+	}}
+	appconfig.Set(cfg)
+
+	m := ActiveTaggerModel()
+	if !m.BYO || m.ID != "wd-vit-v3" {
+		t.Fatalf("BYO tagger not active: %+v", m)
+	}
+	if got := taggerModelFilePath(m, m.ModelFile); got != modelPath {
+		t.Errorf("model path = %q, want %q", got, modelPath)
+	}
+	if got := taggerModelFilePath(m, m.ConfigFile); got != "" {
+		t.Errorf("missing config should resolve empty, got %q", got)
+	}
+	args := strings.Join(byoTaggerArgs(m), " ")
+	for _, want := range []string{"--width=448", "--height=448", "--layout=NHWC", "--color=BGR", "--pixel-range=0_255", "--pad-square"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("args %q missing %q", args, want)
+		}
+	}
+	if byoTaggerArgs(taggerModelRegistry[DefaultTaggerModelID]) != nil {
+		t.Error("built-in taggers take their preprocessing from config.json")
+	}
+	list := TaggerModelList()
+	if list[0].ID != DefaultTaggerModelID || list[len(list)-1].ID != "wd-vit-v3" {
+		t.Errorf("list order = %+v", list)
+	}
+}
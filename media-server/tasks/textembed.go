@@ -0,0 +1,394 @@
+package tasks
+
+// textembed.go — the semantic text-embedding op. Descriptions and transcripts
+// are cut into windows (transcript windows keep their VTT timestamps), each
+// window is encoded by the configured sentence encoder (appconfig
+// TextEmbedModel) through a pool of `embed --sentence --serve` workers, and
+// the vectors land in media_text_chunk plus the live chunk index
+// (textembed_index.go) that backs the semantic: query predicate.
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/stevecastle/shrike/appconfig"
+	"github.com/stevecastle/shrike/deps"
+	"github.com/stevecastle/shrike/embedvec"
+	"github.com/stevecastle/shrike/media"
+	"github.com/stevecastle/shrike/platform"
+)
+
+// Chunking targets. A window holds whole transcript cues up to
+// textChunkMaxChars (~150 tokens — comfortably inside a 256-token encoder
+// window with the passage prefix), and consecutive transcript windows share
+// one cue so a sentence straddling the boundary is still findable.
+const (
+	textChunkMaxChars = 600
+	// textChunkMinChars merges a short trailing window into its predecessor
+	// rather than indexing a two-word fragment on its own.
+	textChunkMinChars = 80
+)
+
+// TextEmbedModelFromConfig returns the configured sentence encoder with its
+// defaults filled in, or an error naming what to configure.
+func TextEmbedModelFromConfig() (appconfig.TextEmbedModel, error) {
+	m := appconfig.Get().TextEmbedModel
+	if !m.Configured() {
+		return m, fmt.Errorf("no text embedding model configured; set textEmbedModel in the config")
+	}
+	if err := m.Validate(); err != nil {
+		return m, fmt.Errorf("textEmbedModel: %w", err)
+	}
+	m.ID = strings.TrimSpace(m.ID)
+	if m.InputName == "" {
+		m.InputName = "input_ids"
+	}
+	if m.MaskName == "" {
+		m.MaskName = "attention_mask"
+	}
+	if m.OutputName == "" {
+		m.OutputName = "last_hidden_state"
+	}
+	if m.SeqLen == 0 {
+		m.SeqLen = 256
+	}
+	m.Pooling = strings.ToLower(strings.TrimSpace(m.Pooling))
+	if m.Pooling == "" {
+		m.Pooling = "mean"
+	}
+	for _, p := range []string{m.ModelPath, m.TokenizerPath} {
+		if _, err := os.Stat(p); err != nil {
+			return m, fmt.Errorf("text embedding model file missing: %s", p)
+		}
+	}
+	return m, nil
+}
+
+// sentenceArgs returns the embed-binary flags for m in sentence mode (without
+// --serve / --text, which the caller adds).
+func sentenceArgs(m appconfig.TextEmbedModel, ortLib, provider string, threads int) []string {
+	args := []string{
+		"--sentence",
+		"--text-model=" + m.ModelPath,
+		"--tokenizer=" + m.TokenizerPath,
+		fmt.Sprintf("--dim=%d", m.Dim),
+		"--text-input=" + m.InputName,
+		"--mask-input=" + m.MaskName,
+		"--text-output=" + m.OutputName,
+		fmt.Sprintf("--seq-len=%d", m.SeqLen),
+		"--pooling=" + m.Pooling,
+	}
+	if provider != "" {
+		args = append(args, "--provider="+provider)
+	}
+	if threads > 0 {
+		args = append(args, fmt.Sprintf("--threads=%d", threads))
+	}
+	if ortLib != "" {
+		args = append(args, "--ort="+ortLib)
+	}
+	return args
+}
+
+// sentenceLine flattens text onto one line for the serve protocol and
+// prepends the model's prefix.
+func sentenceLine(prefix, text string) string {
+	return prefix + strings.Join(strings.Fields(text), " ")
+}
+
+// SemanticQueryVector encodes a search phrase with the configured sentence
+// encoder (query prefix applied) and returns the vector plus the model ID the
+// chunk vectors must share.
+func SemanticQueryVector(ctx context.Context, text string) ([]float32, string, error) {
+	m, err := TextEmbedModelFromConfig()
+	if err != nil {
+		return nil, "", err
+	}
+	embedBin := deps.BundledOrEmpty("embed")
+	if embedBin == "" {
+		return nil, m.ID, fmt.Errorf("embed binary not installed")
+	}
+	args := append(sentenceArgs(m, deps.BundledOrEmpty("onnxruntime"), "", 0), "--text="+sentenceLine(m.QueryPrefix, text))
+	// Same independent deadline as the image/text query embeds: a hung
+	// encode must not pin a request handler forever.
+	ctx, cancel := context.WithTimeout(ctx, OnnxFileTimeout())
+	defer cancel()
+	cmd := exec.CommandContext(ctx, embedBin, args...)
+	platform.HideSubprocessWindow(cmd)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, m.ID, embedSubprocessError(err)
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(out)))
+	if err != nil {
+		return nil, m.ID, fmt.Errorf("decode base64 vector: %w", err)
+	}
+	vec, err := embedvec.Decode(raw)
+	return vec, m.ID, err
+}
+
+// -----------------------------------------------------------------------------
+// Chunking
+// -----------------------------------------------------------------------------
+
+// vttCue is one timed transcript line.
+type vttCue struct {
+	StartMs, EndMs int64
+	Text           string
+}
+
+// vttTagRe strips inline cue markup (<v Speaker>, <c.color>, <00:01.000>).
+var vttTagRe = regexp.MustCompile(`<[^>]*>`)
+
+// parseVTTTimestamp parses "HH:MM:SS.mmm", "MM:SS.mmm", or the SRT comma form.
+func parseVTTTimestamp(s string) (int64, bool) {
+	s = strings.Replace(strings.TrimSpace(s), ",", ".", 1)
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, false
+	}
+	var mins float64 // hours and minutes folded into minutes
+	for _, p := range parts[:len(parts)-1] {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return 0, false
+		}
+		mins = mins*60 + float64(n)
+	}
+	sec, err := strconv.ParseFloat(parts[len(parts)-1], 64)
+	if err != nil {
+		return 0, false
+	}
+	return int64((mins*60 + sec) * 1000), true
+}
+
+// parseVTTCues extracts the timed cues from a WebVTT (or SRT) transcript.
+// Returns nil when the text holds no timing lines (a plain-text transcript).
+func parseVTTCues(vtt string) []vttCue {
+	var cues []vttCue
+	lines := strings.Split(strings.ReplaceAll(vtt, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		arrow := strings.Index(lines[i], "-->")
+		if arrow < 0 {
+			continue
+		}
+		start, ok1 := parseVTTTimestamp(lines[i][:arrow])
+		endField := strings.Fields(lines[i][arrow+3:])
+		if len(endField) == 0 {
+			continue
+		}
+		end, ok2 := parseVTTTimestamp(endField[0]) // drop cue settings
+		if !ok1 || !ok2 {
+			continue
+		}
+		var text []string
+		for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+			i++
+			if t := strings.TrimSpace(vttTagRe.ReplaceAllString(lines[i], "")); t != "" {
+				text = append(text, t)
+			}
+		}
+		if len(text) > 0 {
+			cues = append(cues, vttCue{StartMs: start, EndMs: end, Text: strings.Join(text, " ")})
+		}
+	}
+	return cues
+}
+
+// chunkCues groups consecutive cues into windows of at most
+// textChunkMaxChars, overlapping by one cue.
+func chunkCues(cues []vttCue) []media.TextChunk {
+	var out []media.TextChunk
+	emit := func(win []vttCue) {
+		texts := make([]string, len(win))
+		for i, c := range win {
+			texts[i] = c.Text
+		}
+		out = append(out, media.TextChunk{
+			Source:  media.TextSourceTranscript,
+			StartMs: win[0].StartMs,
+			EndMs:   win[len(win)-1].EndMs,
+			Text:    strings.Join(texts, " "),
+		})
+	}
+	var win []vttCue
+	size := 0
+	carried := false // win[0] repeats the previous window's last cue
+	for _, c := range cues {
+		if len(win) > 0 && size+len(c.Text)+1 > textChunkMaxChars {
+			emit(win)
+			// Carry the last cue over so boundary sentences appear in both.
+			last := win[len(win)-1]
+			win, size, carried = []vttCue{last}, len(last.Text)+1, true
+			if size+len(c.Text)+1 > textChunkMaxChars {
+				win, size, carried = nil, 0, false
+			}
+		}
+		win = append(win, c)
+		size += len(c.Text) + 1
+	}
+	if len(win) == 0 || (carried && len(win) == 1) {
+		return out
+	}
+	if len(out) > 0 && size < textChunkMinChars {
+		// Fold a short tail into the previous window.
+		prev := &out[len(out)-1]
+		if carried {
+			win = win[1:]
+		}
+		for _, c := range win {
+			prev.Text += " " + c.Text
+			prev.EndMs = c.EndMs
+		}
+		return out
+	}
+	emit(win)
+	return out
+}
+
+// chunkPlainText word-wraps untimed text into windows of at most
+// textChunkMaxChars.
+func chunkPlainText(text, source string) []media.TextChunk {
+	var out []media.TextChunk
+	var b strings.Builder
+	for _, w := range strings.Fields(text) {
+		if b.Len() > 0 && b.Len()+len(w)+1 > textChunkMaxChars {
+			out = append(out, media.TextChunk{Source: source, Text: b.String()})
+			b.Reset()
+		}
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(w)
+	}
+	if b.Len() > 0 {
+		if len(out) > 0 && b.Len() < textChunkMinChars {
+			out[len(out)-1].Text += " " + b.String()
+		} else {
+			out = append(out, media.TextChunk{Source: source, Text: b.String()})
+		}
+	}
+	return out
+}
+
+// buildTextChunks cuts an item's description and transcript into numbered
+// windows. Transcripts without timing lines are chunked as plain text.
+func buildTextChunks(description, transcript string) []media.TextChunk {
+	chunks := chunkPlainText(description, media.TextSourceDescription)
+	if cues := parseVTTCues(transcript); len(cues) > 0 {
+		chunks = append(chunks, chunkCues(cues)...)
+	} else {
+		chunks = append(chunks, chunkPlainText(transcript, media.TextSourceTranscript)...)
+	}
+	for i := range chunks {
+		chunks[i].Seq = i
+	}
+	return chunks
+}
+
+// -----------------------------------------------------------------------------
+// textembed op
+// -----------------------------------------------------------------------------
+
+func registerTextEmbedItemOp() {
+	RegisterItemOp(ItemOp{
+		ID:   "textembed",
+		Name: "Text Embedding (ONNX)",
+		Concurrency: func() int {
+			workers, _ := ResolveEmbedResources()
+			return workers
+		},
+		Prepare: prepareTextEmbedOp,
+	})
+}
+
+// mediaTextColumns reads the description and transcript an item is chunked
+// from. In a combined run this sees the state before the run's own commits,
+// so pair textembed with describe/transcribe as separate (chained) jobs.
+func mediaTextColumns(db *sql.DB, path string) (description, transcript string, err error) {
+	var d, t sql.NullString
+	err = db.QueryRow(`SELECT description, transcript FROM media WHERE path = ?`, path).Scan(&d, &t)
+	if err == sql.ErrNoRows {
+		return "", "", nil
+	}
+	return d.String, t.String, err
+}
+
+func prepareTextEmbedOp(run *ItemRun) (*ItemProcessor, error) {
+	q, j := run.Queue, run.Job
+	db := q.Db
+
+	model, err := TextEmbedModelFromConfig()
+	if err != nil {
+		return nil, err
+	}
+	embedBin := deps.BundledOrEmpty("embed")
+	if embedBin == "" {
+		return nil, fmt.Errorf("embed binary not installed; install it from Dependencies")
+	}
+	_, threads := ResolveEmbedResources()
+	ortLib, provider := resolveONNXRuntime(EmbedProviderFromConfig())
+	q.PushJobStdout(j.ID, fmt.Sprintf("Text embedding model: %s (dim %d), %d worker(s), %d thread(s) each, provider=%s",
+		model.ID, model.Dim, run.Workers, threads, provider))
+
+	args := append([]string{"--serve"}, sentenceArgs(model, ortLib, provider, threads)...)
+	pool, err := newServePool(j.Ctx, run.Workers, embedBin, args, run.Background)
+	if err != nil {
+		return nil, fmt.Errorf("start sentence worker: %w", err)
+	}
+	timeout := OnnxFileTimeout()
+
+	return &ItemProcessor{
+		SkipExisting: func(path string) (bool, error) { return media.HasTextChunks(db, path, model.ID) },
+		Process: func(ctx context.Context, path, _ string) (*ItemCommit, error) {
+			description, transcript, err := mediaTextColumns(db, path)
+			if err != nil {
+				return nil, err
+			}
+			chunks := buildTextChunks(description, transcript)
+			if len(chunks) == 0 {
+				return nil, nil // no description or transcript yet
+			}
+			w, aerr := pool.acquire(ctx)
+			if aerr != nil {
+				return nil, aerr
+			}
+			for i := range chunks {
+				line := sentenceLine(model.PassagePrefix, chunks[i].Text)
+				vec, err, abandoned := runWithTimeout(ctx, timeout, func() ([]float32, error) { return w.embed(line) })
+				if abandoned {
+					pool.discard(w) // request still in flight — the worker is unusable
+					if err != nil {
+						return nil, err // cancelled mid-compute
+					}
+					return nil, fmt.Errorf("timed out after %s", timeout)
+				}
+				if err != nil {
+					pool.release(w)
+					return nil, fmt.Errorf("chunk %d: %w", i, err)
+				}
+				chunks[i].Vec = vec
+			}
+			pool.release(w)
+			return &ItemCommit{
+				Commit: func() error {
+					ids, err := media.ReplaceTextChunks(db, path, model.ID, chunks)
+					if err != nil {
+						return err
+					}
+					textIndexReplacePath(model.ID, path, ids, chunks)
+					return nil
+				},
+				Detail: fmt.Sprintf("%d text chunk(s) embedded (%s)", len(chunks), model.ID),
+			}, nil
+		},
+		Close: pool.close,
+	}, nil
+}
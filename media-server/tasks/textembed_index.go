@@ -0,0 +1,209 @@
+package tasks
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/stevecastle/shrike/embedindex"
+	"github.com/stevecastle/shrike/media"
+)
+
+// -----------------------------------------------------------------------------
+// Package-level TEXT CHUNK index — a third exact-scan index alongside the
+// media-embedding and face indexes, keyed by media_text_chunk row ID because
+// one item holds many transcript windows. Unlike the other two it is built
+// lazily by the first semantic query (most libraries never run one) and
+// rebuilt whenever the configured encoder or the database changes. A side map
+// media_path → chunk keys lets re-embeds, moves, and deletions evict stale
+// windows without a DB round-trip. All state is serialised behind one mutex.
+// -----------------------------------------------------------------------------
+
+// textChunkRef is what a chunk key resolves to at query time.
+type textChunkRef struct {
+	Path           string
+	Source         string
+	StartMs, EndMs int64
+	Text           string
+}
+
+var (
+	textIndexMu    sync.Mutex
+	textIndex      embedindex.VectorIndex
+	textIndexModel string
+	textIndexDB    *sql.DB
+	textChunkRefs  map[string]textChunkRef
+	textPathKeys   map[string][]string
+)
+
+// textChunkKey is the index key for a chunk row ID.
+func textChunkKey(id int64) string { return strconv.FormatInt(id, 10) }
+
+// SemanticHit is one media item matched by a semantic query: its best chunk's
+// score, where that chunk came from, and (for transcripts) where it sits in
+// the media timeline.
+type SemanticHit struct {
+	Path    string  `json:"path"`
+	Score   float32 `json:"score"`
+	Source  string  `json:"source"`
+	StartMs int64   `json:"startMs"`
+	EndMs   int64   `json:"endMs"`
+	Text    string  `json:"text"`
+}
+
+// textIndexAddLocked indexes one chunk. Caller holds textIndexMu.
+func textIndexAddLocked(key string, ref textChunkRef, vec []float32) {
+	textIndex.Add(key, vec) // Add L2-normalizes internally
+	textChunkRefs[key] = ref
+	textPathKeys[ref.Path] = append(textPathKeys[ref.Path], key)
+}
+
+// textIndexDeletePathLocked evicts all of path's chunks. Caller holds
+// textIndexMu.
+func textIndexDeletePathLocked(path string) {
+	for _, key := range textPathKeys[path] {
+		textIndex.Delete(key)
+		delete(textChunkRefs, key)
+	}
+	delete(textPathKeys, path)
+}
+
+// ensureTextIndexLocked (re)builds the index from db when none is installed
+// or it holds another model's or database's chunks. Caller holds textIndexMu.
+func ensureTextIndexLocked(db *sql.DB, model string) error {
+	if textIndex != nil && textIndexModel == model && textIndexDB == db {
+		return nil
+	}
+	all, err := media.LoadTextChunks(db, model, "")
+	if err != nil {
+		return err
+	}
+	// Plain cosine: chunk scores are shown to the user and compared across
+	// queries, so no hub-suppressing centering.
+	textIndex = embedindex.New()
+	textIndexModel = model
+	textIndexDB = db
+	textChunkRefs = make(map[string]textChunkRef, len(all))
+	textPathKeys = map[string][]string{}
+	for _, c := range all {
+		textIndexAddLocked(textChunkKey(c.ID), textChunkRef{
+			Path: c.Path, Source: c.Source, StartMs: c.StartMs, EndMs: c.EndMs, Text: c.Text,
+		}, c.Vec)
+	}
+	return nil
+}
+
+// textIndexReplacePath mirrors ReplaceTextChunks in the live index (ids are
+// parallel to chunks). No-op when the index is not built or holds another
+// model — the next query's lazy build reads the rows from the DB.
+func textIndexReplacePath(model, path string, ids []int64, chunks []media.TextChunk) {
+	textIndexMu.Lock()
+	defer textIndexMu.Unlock()
+	if textIndex == nil || textIndexModel != model {
+		return
+	}
+	textIndexDeletePathLocked(path)
+	for i, id := range ids {
+		c := chunks[i]
+		textIndexAddLocked(textChunkKey(id), textChunkRef{
+			Path: path, Source: c.Source, StartMs: c.StartMs, EndMs: c.EndMs, Text: c.Text,
+		}, c.Vec)
+	}
+}
+
+// TextIndexDeletePath evicts all of path's chunks from the live index (no-op
+// when none is built). Exported for the media-removal hook.
+func TextIndexDeletePath(path string) {
+	textIndexMu.Lock()
+	defer textIndexMu.Unlock()
+	if textIndex != nil {
+		textIndexDeletePathLocked(path)
+	}
+}
+
+// TextIndexReloadPath re-reads path's chunks from db into the live index —
+// after a move (media.MovePath rewrote the rows under the new path) or a
+// merge (chunks copied onto the target). No-op when no index is built.
+func TextIndexReloadPath(db *sql.DB, path string) {
+	textIndexMu.Lock()
+	defer textIndexMu.Unlock()
+	if textIndex == nil || textIndexDB != db {
+		return
+	}
+	textIndexDeletePathLocked(path)
+	chunks, err := media.LoadTextChunks(db, textIndexModel, path)
+	if err != nil {
+		return
+	}
+	for _, c := range chunks {
+		textIndexAddLocked(textChunkKey(c.ID), textChunkRef{
+			Path: c.Path, Source: c.Source, StartMs: c.StartMs, EndMs: c.EndMs, Text: c.Text,
+		}, c.Vec)
+	}
+}
+
+// TextIndexRenamePath re-keys a moved path in the live chunk index.
+func TextIndexRenamePath(db *sql.DB, from, to string) {
+	TextIndexDeletePath(from)
+	TextIndexReloadPath(db, to)
+}
+
+// searchTextChunks ranks media by their best-matching chunk under model,
+// restricted to allow when non-nil, returning at most limit items.
+func searchTextChunks(db *sql.DB, model string, query []float32, limit int, allow PathSet) ([]SemanticHit, error) {
+	textIndexMu.Lock()
+	defer textIndexMu.Unlock()
+	if err := ensureTextIndexLocked(db, model); err != nil {
+		return nil, err
+	}
+	var allowKeys map[string]struct{}
+	if allow != nil {
+		allowKeys = map[string]struct{}{}
+		for p := range allow {
+			for _, k := range textPathKeys[p] {
+				allowKeys[k] = struct{}{}
+			}
+		}
+		if len(allowKeys) == 0 {
+			return nil, nil
+		}
+	}
+	// Items hold several chunks each, so over-fetch chunks before collapsing
+	// to one hit per item (hits arrive best-first, so the first chunk seen
+	// for a path is its best).
+	k := limit * 8
+	if n := textIndex.Len(); k > n {
+		k = n
+	}
+	var out []SemanticHit
+	seen := map[string]bool{}
+	for _, h := range textIndex.SearchFiltered(query, k, allowKeys) {
+		ref, ok := textChunkRefs[h.Path]
+		if !ok || seen[ref.Path] {
+			continue
+		}
+		seen[ref.Path] = true
+		out = append(out, SemanticHit{
+			Path: ref.Path, Score: h.Score, Source: ref.Source,
+			StartMs: ref.StartMs, EndMs: ref.EndMs, Text: ref.Text,
+		})
+		if len(out) == limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+// SearchSemantic encodes text with the configured sentence encoder and
+// returns the top-limit media whose description or transcript best matches
+// it, restricted to allow when non-nil. Transcript hits carry the offset of
+// the matching window so video/audio results can seek straight to it.
+func SearchSemantic(ctx context.Context, db *sql.DB, text string, limit int, allow PathSet) ([]SemanticHit, error) {
+	vec, model, err := SemanticQueryVector(ctx, text)
+	if err != nil {
+		return nil, fmt.Errorf("semantic search: %w", err)
+	}
+	return searchTextChunks(db, model, vec, limit, allow)
+}
@@ -0,0 +1,147 @@
+package tasks
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stevecastle/shrike/media"
+)
+
+func TestParseVTTCues(t *testing.T) {
+	vtt := "WEBVTT\n\n1\n00:00:01.500 --> 00:00:03.000 align:start\n<v Ann>Hello there</v>\nsecond line\n\n" +
+		"01:02:03,250 --> 01:02:04,000\nlater\n\nnot a cue\n"
+	cues := parseVTTCues(vtt)
+	if len(cues) != 2 {
+		t.Fatalf("cues = %d, want 2: %+v", len(cues), cues)
+	}
+	if cues[0].StartMs != 1500 || cues[0].EndMs != 3000 || cues[0].Text != "Hello there second line" {
+		t.Errorf("cue 0 = %+v", cues[0])
+	}
+	if want := int64((3600+2*60+3)*1000 + 250); cues[1].StartMs != want {
+		t.Errorf("cue 1 start = %d, want %d", cues[1].StartMs, want)
+	}
+	if parseVTTCues("just some plain words") != nil {
+		t.Error("plain text should yield no cues")
+	}
+}
+
+func TestChunkCuesOverlapsAndKeepsTimestamps(t *testing.T) {
+	line := strings.Repeat("x", 250)
+	var cues []vttCue
+	for i := 0; i < 5; i++ {
+		cues = append(cues, vttCue{StartMs: int64(i) * 1000, EndMs: int64(i)*1000 + 900, Text: line})
+	}
+	chunks := chunkCues(cues)
+	if len(chunks) != 4 {
+		t.Fatalf("chunks = %d, want 4", len(chunks))
+	}
+	// Each window holds two cues and starts on the previous window's last.
+	for i, c := range chunks {
+		if c.StartMs != int64(i)*1000 || c.EndMs != int64(i+1)*1000+900 {
+			t.Errorf("chunk %d spans %d-%d", i, c.StartMs, c.EndMs)
+		}
+		if c.Source != media.TextSourceTranscript {
+			t.Errorf("chunk %d source = %q", i, c.Source)
+		}
+	}
+}
+
+func TestChunkCuesFoldsShortTail(t *testing.T) {
+	cues := []vttCue{
+		{StartMs: 0, EndMs: 1000, Text: strings.Repeat("a", 400)},
+		{StartMs: 1000, EndMs: 2000, Text: strings.Repeat("b", 300)},
+		{StartMs: 2000, EndMs: 2500, Text: "ok"},
+	}
+	chunks := chunkCues(cues)
+	if len(chunks) != 2 {
+		t.Fatalf("chunks = %d, want 2", len(chunks))
+	}
+	// The 2-char tail lands in the last window instead of its own chunk.
+	if last := chunks[1]; last.EndMs != 2500 || !strings.HasSuffix(last.Text, " ok") {
+		t.Errorf("last chunk = %d-%d %q", last.StartMs, last.EndMs, last.Text[len(last.Text)-5:])
+	}
+}
+
+func TestBuildTextChunksNumbersAllSources(t *testing.T) {
+	chunks := buildTextChunks("a red car", "WEBVTT\n\n00:00:05.000 --> 00:00:06.000\nhonk honk\n")
+	if len(chunks) != 2 {
+		t.Fatalf("chunks = %d, want 2", len(chunks))
+	}
+	if chunks[0].Source != media.TextSourceDescription || chunks[0].Seq != 0 {
+		t.Errorf("chunk 0 = %+v", chunks[0])
+	}
+	if chunks[1].Source != media.TextSourceTranscript || chunks[1].Seq != 1 || chunks[1].StartMs != 5000 {
+		t.Errorf("chunk 1 = %+v", chunks[1])
+	}
+	// An untimed transcript is still indexed, just without offsets.
+	if got := buildTextChunks("", "plain words only"); len(got) != 1 || got[0].Source != media.TextSourceTranscript {
+		t.Errorf("plain transcript chunks = %+v", got)
+	}
+}
+
+// resetTextIndex drops the lazily built chunk index after the test.
+func resetTextIndex(t *testing.T) {
+	t.Helper()
+	t.Cleanup(func() {
+		textIndexMu.Lock()
+		textIndex, textIndexModel, textIndexDB = nil, "", nil
+		textIndexMu.Unlock()
+	})
+}
+
+func TestSearchTextChunksBestChunkPerItem(t *testing.T) {
+	db := newFaceIndexDB(t)
+	resetTextIndex(t)
+
+	if _, err := media.ReplaceTextChunks(db, "a.mp4", "m1", []media.TextChunk{
+		{Seq: 0, Source: media.TextSourceTranscript, StartMs: 0, Text: "intro", Vec: []float32{0, 1, 0}},
+		{Seq: 1, Source: media.TextSourceTranscript, StartMs: 42000, Text: "the match", Vec: []float32{1, 0, 0}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := media.ReplaceTextChunks(db, "b.jpg", "m1", []media.TextChunk{
+		{Seq: 0, Source: media.TextSourceDescription, Text: "close", Vec: []float32{0.8, 0.6, 0}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := media.ReplaceTextChunks(db, "c.jpg", "other", []media.TextChunk{
+		{Seq: 0, Source: media.TextSourceDescription, Text: "other model", Vec: []float32{1, 0, 0}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	hits, err := searchTextChunks(db, "m1", []float32{1, 0, 0}, 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 2 || hits[0].Path != "a.mp4" || hits[1].Path != "b.jpg" {
+		t.Fatalf("hits = %+v", hits)
+	}
+	if hits[0].StartMs != 42000 || hits[0].Text != "the match" {
+		t.Errorf("best chunk = %+v, want the 42s window", hits[0])
+	}
+
+	hits, err = searchTextChunks(db, "m1", []float32{1, 0, 0}, 10, PathSet{"b.jpg": {}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 || hits[0].Path != "b.jpg" {
+		t.Errorf("filtered hits = %+v", hits)
+	}
+
+	// Re-embedding replaces the live entries; deletion evicts them.
+	ids, err := media.ReplaceTextChunks(db, "a.mp4", "m1", []media.TextChunk{
+		{Seq: 0, Source: media.TextSourceTranscript, Text: "now unrelated", Vec: []float32{0, 0, 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	textIndexReplacePath("m1", "a.mp4", ids, []media.TextChunk{
+		{Seq: 0, Source: media.TextSourceTranscript, Text: "now unrelated", Vec: []float32{0, 0, 1}},
+	})
+	TextIndexDeletePath("b.jpg")
+	hits, _ = searchTextChunks(db, "m1", []float32{1, 0, 0}, 1, nil)
+	if len(hits) != 1 || hits[0].Path != "a.mp4" || hits[0].Text != "now unrelated" {
+		t.Errorf("after replace/delete hits = %+v", hits)
+	}
+}
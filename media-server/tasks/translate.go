@@ -0,0 +1,317 @@
+package tasks
+
+// translate.go — transcript translation. The stored transcript's cues are
+// translated into one or more target languages and kept per language in
+// media_transcript; media.transcript keeps the original. Two engines:
+//
+//   - llm: the configured inference provider translates cue text in batches,
+//     so timings and speaker spans carry over untouched.
+//   - whisper: the transcription provider re-runs the audio with whisper's
+//     translate task. Whisper only translates into English.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/stevecastle/shrike/appconfig"
+	"github.com/stevecastle/shrike/media"
+	"github.com/stevecastle/shrike/subtitle"
+	"github.com/stevecastle/shrike/transcribe"
+)
+
+const (
+	translateEngineLLM     = "llm"
+	translateEngineWhisper = "whisper"
+
+	// translateBatch is how many cues go to the model per request: enough
+	// context for coherent phrasing, small enough that a reply missing a
+	// line costs one short retry.
+	translateBatch = 20
+	// translateAttempts bounds retries of a batch whose reply is unusable.
+	translateAttempts = 3
+)
+
+func registerTranslateItemOp() {
+	RegisterItemOp(ItemOp{
+		ID:   "translate",
+		Name: "Transcript Translation",
+		Options: []TaskOption{
+			{Name: "to", Label: "Target Languages", Type: "string", Default: "en",
+				Description: "Comma-separated language codes to translate into (e.g. en,es,pt-br)"},
+			{Name: "engine", Label: "Engine", Type: "enum", Choices: []string{translateEngineLLM, translateEngineWhisper}, Default: translateEngineLLM,
+				Description: "llm translates the stored cues with the inference provider; whisper re-transcribes the audio into English"},
+		},
+		Applies: extAppliesFn(append(append([]string{}, videoExts...), audioExts...)...),
+		Prepare: prepareTranslateOp,
+	})
+}
+
+// translateTargets parses the "to" option into normalized, de-duplicated
+// language tags.
+func translateTargets(raw string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		raw = "en"
+	}
+	var out []string
+	seen := map[string]bool{}
+	for _, part := range strings.Split(raw, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		lang, ok := media.NormalizeLang(part)
+		if !ok {
+			return nil, fmt.Errorf("invalid target language %q", strings.TrimSpace(part))
+		}
+		if !seen[lang] {
+			seen[lang] = true
+			out = append(out, lang)
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no target languages")
+	}
+	return out, nil
+}
+
+func prepareTranslateOp(run *ItemRun) (*ItemProcessor, error) {
+	q, jobID := run.Queue, run.Job.ID
+	db := q.Db
+
+	to, _ := run.Opts["to"].(string)
+	targets, err := translateTargets(to)
+	if err != nil {
+		return nil, err
+	}
+	// Translating into the language the transcript is already in is a
+	// no-op; the original is always served as its own track.
+	if src, ok := media.NormalizeLang(appconfig.Get().TranscriptionLanguage); ok {
+		kept := targets[:0]
+		for _, l := range targets {
+			if l != src {
+				kept = append(kept, l)
+			}
+		}
+		if len(kept) == 0 {
+			return nil, fmt.Errorf("transcripts are already in %s", src)
+		}
+		targets = kept
+	}
+
+	engine, _ := run.Opts["engine"].(string)
+	var desc string
+	switch engine {
+	case "", translateEngineLLM:
+		engine = translateEngineLLM
+		provider, model, ok := visionProviderModel()
+		if !ok {
+			return nil, ErrInferenceDisabled
+		}
+		desc = fmt.Sprintf("via %s (%s)", provider, model)
+	case translateEngineWhisper:
+		if len(targets) != 1 || targets[0] != "en" {
+			return nil, fmt.Errorf("the whisper engine only translates into English (--to=en)")
+		}
+		desc = "via whisper's translate task"
+	default:
+		return nil, fmt.Errorf("unknown translation engine %q", engine)
+	}
+	q.PushJobStdout(jobID, fmt.Sprintf("Translating transcripts into %s %s", strings.Join(targets, ", "), desc))
+
+	return &ItemProcessor{
+		SkipExisting: func(path string) (bool, error) {
+			have, err := media.TranscriptLanguages(db, path)
+			if err != nil {
+				return false, err
+			}
+			return containsAll(have, targets), nil
+		},
+		Process: func(ctx context.Context, path, localPath string) (*ItemCommit, error) {
+			logf := func(s string) { q.PushJobStdout(jobID, "[translate] "+s) }
+			var out []media.TranscriptTranslation
+			if engine == translateEngineWhisper {
+				vtt, model, err := whisperTranslate(ctx, localPath, logf)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, media.TranscriptTranslation{Lang: "en", Transcript: vtt,
+					Source: media.TranslationSourceWhisper, Model: model})
+			} else {
+				_, transcript, err := mediaTextColumns(db, path)
+				if err != nil {
+					return nil, err
+				}
+				if strings.TrimSpace(transcript) == "" {
+					return nil, nil // transcribe first
+				}
+				_, model, _ := visionProviderModel()
+				for _, lang := range targets {
+					vtt, err := llmTranslateTranscript(ctx, transcript, lang, logf)
+					if err != nil {
+						return nil, fmt.Errorf("%s: %w", lang, err)
+					}
+					out = append(out, media.TranscriptTranslation{Lang: lang, Transcript: vtt,
+						Source: media.TranslationSourceLLM, Model: model})
+				}
+			}
+			langs := make([]string, len(out))
+			for i, t := range out {
+				langs[i] = t.Lang
+			}
+			return &ItemCommit{
+				Commit: func() error {
+					for _, t := range out {
+						if err := media.SetTranscriptTranslation(db, path, t); err != nil {
+							return err
+						}
+					}
+					return nil
+				},
+				Detail: "translated into " + strings.Join(langs, ", "),
+			}, nil
+		},
+	}, nil
+}
+
+func containsAll(have, want []string) bool {
+	set := make(map[string]bool, len(have))
+	for _, h := range have {
+		set[h] = true
+	}
+	for _, w := range want {
+		if !set[w] {
+			return false
+		}
+	}
+	return true
+}
+
+// whisperTranslate runs the configured transcription provider with the
+// translate task. Its sidecar goes to a scratch directory so the original
+// transcript's VTT beside the media is left alone.
+func whisperTranslate(ctx context.Context, mediaPath string, logf func(string)) (string, string, error) {
+	provider, req, err := transcribe.FromConfig(mediaPath, logf)
+	if err != nil {
+		return "", "", err
+	}
+	if err := provider.Available(); err != nil {
+		return "", "", err
+	}
+	dir, err := os.MkdirTemp("", "lowkey-translate-*")
+	if err != nil {
+		return "", "", err
+	}
+	defer os.RemoveAll(dir)
+	req.Translate = true
+	req.OutputDir = dir
+	res, err := provider.Transcribe(ctx, req)
+	if err != nil {
+		return "", "", err
+	}
+	return res.Text, req.Model, nil
+}
+
+// translateRetryDelay is the pause before retry n (1-based); a var for tests.
+var translateRetryDelay = func(n int) time.Duration { return time.Duration(n) * 2 * time.Second }
+
+var translateSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"lines": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+	},
+	"required": []string{"lines"},
+}
+
+// llmTranslateTranscript translates a VTT transcript's cue text into lang,
+// keeping every cue's timing and speaker.
+func llmTranslateTranscript(ctx context.Context, transcript, lang string, logf func(string)) (string, error) {
+	cues := subtitle.Parse(transcript)
+	if len(cues) == 0 {
+		return "", fmt.Errorf("transcript has no cues")
+	}
+	for start := 0; start < len(cues); start += translateBatch {
+		end := min(start+translateBatch, len(cues))
+		lines := make([]string, end-start)
+		for i, c := range cues[start:end] {
+			lines[i] = c.Text
+		}
+		got, err := translateLines(ctx, lines, lang, logf)
+		if err != nil {
+			return "", err
+		}
+		for i := range got {
+			cues[start+i].Text = got[i]
+		}
+	}
+	return subtitle.FormatVTT(cues), nil
+}
+
+// translateLines translates one batch, retrying transport failures and
+// replies that don't carry exactly one line per input line.
+func translateLines(ctx context.Context, lines []string, lang string, logf func(string)) ([]string, error) {
+	in, _ := json.Marshal(lines)
+	prompt := fmt.Sprintf(`Translate each subtitle line in this JSON array into %s. `+
+		`Keep the order and return exactly %d lines, one per input line; never merge or split lines. `+
+		`Leave names and inline markup as they are. `+
+		`Respond with JSON only: {"lines": [...]}.
+
+%s`, media.LangName(lang), len(lines), in)
+
+	var lastErr error
+	for n := 1; n <= translateAttempts; n++ {
+		if n > 1 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(translateRetryDelay(n - 1)):
+			}
+		}
+		got, err := translateOnce(ctx, prompt, len(lines))
+		if err == nil {
+			return got, nil
+		}
+		if errors.Is(err, ErrInferenceDisabled) || ctx.Err() != nil {
+			return nil, err
+		}
+		lastErr = err
+		if n < translateAttempts && logf != nil {
+			logf(fmt.Sprintf("attempt %d/%d failed: %v", n, translateAttempts, err))
+		}
+	}
+	return nil, fmt.Errorf("after %d attempt(s): %w", translateAttempts, lastErr)
+}
+
+func translateOnce(ctx context.Context, prompt string, want int) ([]string, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, 600*time.Second)
+	defer cancel()
+	reply, err := callTextLLMJSON(timeoutCtx, prompt, translateSchema)
+	if err != nil {
+		return nil, err
+	}
+	var parsed struct {
+		Lines []string `json:"lines"`
+	}
+	if err := json.Unmarshal([]byte(extractJSONObject(reply)), &parsed); err != nil {
+		return nil, fmt.Errorf("unparseable reply %q: %w", truncateForError(reply), err)
+	}
+	if len(parsed.Lines) != want {
+		return nil, fmt.Errorf("reply has %d line(s), want %d", len(parsed.Lines), want)
+	}
+	for i, l := range parsed.Lines {
+		parsed.Lines[i] = strings.TrimSpace(l)
+	}
+	return parsed.Lines, nil
+}
+
+// translateEngine reads a translate job's --engine flag for resource
+// resolution; anything but whisper is the LLM engine.
+func translateEngine(arguments []string, input string) string {
+	if v := flagListValue("engine", arguments, input); len(v) == 1 && v[0] == translateEngineWhisper {
+		return translateEngineWhisper
+	}
+	return translateEngineLLM
+}
@@ -0,0 +1,84 @@
+package tasks
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stevecastle/shrike/subtitle"
+)
+
+func TestTranslateTargets(t *testing.T) {
+	got, err := translateTargets(" es, pt_BR,ES ,, fr")
+	if err != nil || !reflect.DeepEqual(got, []string{"es", "pt-br", "fr"}) {
+		t.Fatalf("translateTargets = %v, %v", got, err)
+	}
+	if got, _ := translateTargets(""); !reflect.DeepEqual(got, []string{"en"}) {
+		t.Errorf("empty targets = %v, want [en]", got)
+	}
+	if _, err := translateTargets("es,spanish"); err == nil {
+		t.Error("accepted a language name")
+	}
+}
+
+func TestTranslateEngineFlag(t *testing.T) {
+	if e := translateEngine([]string{"--engine=whisper"}, ""); e != translateEngineWhisper {
+		t.Errorf("engine = %q", e)
+	}
+	if e := translateEngine(nil, "a.mp4"); e != translateEngineLLM {
+		t.Errorf("default engine = %q", e)
+	}
+	if r := ResolveResources("translate", []string{"--engine", "whisper"}, ""); reflect.DeepEqual(r, ResolveResources("translate", nil, "")) {
+		t.Errorf("whisper translation resolved to the LLM's resources: %v", r)
+	}
+}
+
+// Cue timings and speakers survive; only the text is replaced. A reply with
+// the wrong number of lines is retried.
+func TestLLMTranslateTranscript(t *testing.T) {
+	old := translateRetryDelay
+	translateRetryDelay = func(int) time.Duration { return 0 }
+	t.Cleanup(func() { translateRetryDelay = old })
+
+	var calls atomic.Int32
+	useOpenAIProvider(t, func() string {
+		if calls.Add(1) == 1 {
+			return `{"lines":["hola"]}`
+		}
+		return "Sure:\n" + `{"lines":[" hola ","adiós"]}`
+	})
+
+	src := "WEBVTT\n\n00:00:01.000 --> 00:00:02.500\n<v Speaker 1>hello\n\n00:00:03.000 --> 00:00:04.000\ngoodbye\n"
+	out, err := llmTranslateTranscript(context.Background(), src, "es", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("provider called %d times; want 2", calls.Load())
+	}
+	cues := subtitle.Parse(out)
+	if len(cues) != 2 || cues[0].Text != "hola" || cues[0].Speaker != "Speaker 1" || cues[1].Text != "adiós" {
+		t.Fatalf("translated cues = %+v", cues)
+	}
+	if cues[0].Start != time.Second || cues[0].End != 2500*time.Millisecond {
+		t.Errorf("timing changed: %+v", cues[0])
+	}
+	if !strings.HasPrefix(out, "WEBVTT") {
+		t.Errorf("not a VTT document:\n%s", out)
+	}
+}
+
+func TestLLMTranslateTranscriptGivesUp(t *testing.T) {
+	old := translateRetryDelay
+	translateRetryDelay = func(int) time.Duration { return 0 }
+	t.Cleanup(func() { translateRetryDelay = old })
+	useOpenAIProvider(t, func() string { return "no json here" })
+
+	_, err := llmTranslateTranscript(context.Background(), "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nhi\n", "fr", nil)
+	if err == nil || !strings.Contains(err.Error(), "after 3 attempt(s)") {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -0,0 +1,277 @@
+package tasks
+
+// xmp.go — the slice of XMP the sidecar tasks read and write: MWG-RS face
+// regions (the Metadata Working Group's region schema, which digiKam,
+// Lightroom, darktable, and most DAMs share) and keywords as dc:subject plus
+// Lightroom's lr:hierarchicalSubject. digiKam's own digiKam:TagsList is read
+// too, since digiKam-only libraries may carry nothing else.
+//
+// Region areas follow MWG: normalized 0..1, x/y the CENTER of the box. Face
+// rows store the top-left corner, so the conversion lives here and nowhere
+// else.
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	nsRDF    = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	nsDC     = "http://purl.org/dc/elements/1.1/"
+	nsXMP    = "http://ns.adobe.com/xap/1.0/"
+	nsLR     = "http://ns.adobe.com/lightroom/1.0/"
+	nsDigiKa = "http://www.digikam.org/ns/1.0/"
+	nsMWGRS  = "http://www.metadataworkinggroup.com/schemas/regions/"
+	nsStArea = "http://ns.adobe.com/xmp/sType/Area#"
+	nsStDim  = "http://ns.adobe.com/xap/1.0/sType/Dimensions#"
+)
+
+// xmpCreatorTool marks sidecars this server wrote, so a re-export may replace
+// them without --overwrite while other tools' sidecars stay untouched.
+const xmpCreatorTool = "lowkey-media-server"
+
+// xmpRegion is one named face region, as a top-left relative bbox.
+type xmpRegion struct {
+	Name       string
+	X, Y, W, H float64
+}
+
+// xmpMeta is what the sidecar tasks exchange with an XMP packet.
+type xmpMeta struct {
+	CreatorTool string
+	// Subjects are the flat dc:subject keywords.
+	Subjects []string
+	// Hierarchical are keyword paths, root first (lr:hierarchicalSubject
+	// "People|Alice", digiKam "People/Alice").
+	Hierarchical [][]string
+	// Regions are the Face-type regions; other region types are ignored.
+	Regions []xmpRegion
+	// Width and Height are the image dimensions the regions were drawn on
+	// (0 when unknown). Normalized areas don't need them; tools expect them.
+	Width, Height int
+}
+
+// encodeXMP renders m as a standalone sidecar packet.
+func encodeXMP(m xmpMeta) []byte {
+	var b bytes.Buffer
+	esc := func(s string) string {
+		var e bytes.Buffer
+		_ = xml.EscapeText(&e, []byte(s))
+		return e.String()
+	}
+	num := func(v float64) string { return strconv.FormatFloat(v, 'f', 6, 64) }
+
+	b.WriteString("<?xpacket begin=\"\ufeff\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	b.WriteString("<x:xmpmeta xmlns:x=\"adobe:ns:meta/\">\n")
+	b.WriteString(" <rdf:RDF xmlns:rdf=\"" + nsRDF + "\">\n")
+	b.WriteString("  <rdf:Description rdf:about=\"\"\n")
+	b.WriteString("    xmlns:xmp=\"" + nsXMP + "\"\n")
+	b.WriteString("    xmlns:dc=\"" + nsDC + "\"\n")
+	b.WriteString("    xmlns:lr=\"" + nsLR + "\"\n")
+	b.WriteString("    xmlns:mwg-rs=\"" + nsMWGRS + "\"\n")
+	b.WriteString("    xmlns:stArea=\"" + nsStArea + "\"\n")
+	b.WriteString("    xmlns:stDim=\"" + nsStDim + "\"\n")
+	b.WriteString("    xmp:CreatorTool=\"" + esc(m.CreatorTool) + "\">\n")
+	if len(m.Subjects) > 0 {
+		b.WriteString("   <dc:subject>\n    <rdf:Bag>\n")
+		for _, s := range m.Subjects {
+			b.WriteString("     <rdf:li>" + esc(s) + "</rdf:li>\n")
+		}
+		b.WriteString("    </rdf:Bag>\n   </dc:subject>\n")
+	}
+	if len(m.Hierarchical) > 0 {
+		b.WriteString("   <lr:hierarchicalSubject>\n    <rdf:Bag>\n")
+		for _, h := range m.Hierarchical {
+			b.WriteString("     <rdf:li>" + esc(strings.Join(h, "|")) + "</rdf:li>\n")
+		}
+		b.WriteString("    </rdf:Bag>\n   </lr:hierarchicalSubject>\n")
+	}
+	if len(m.Regions) > 0 {
+		b.WriteString("   <mwg-rs:Regions rdf:parseType=\"Resource\">\n")
+		if m.Width > 0 && m.Height > 0 {
+			b.WriteString(fmt.Sprintf("    <mwg-rs:AppliedToDimensions stDim:w=\"%d\" stDim:h=\"%d\" stDim:unit=\"pixel\"/>\n", m.Width, m.Height))
+		}
+		b.WriteString("    <mwg-rs:RegionList>\n     <rdf:Bag>\n")
+		for _, r := range m.Regions {
+			b.WriteString("      <rdf:li>\n")
+			b.WriteString("       <rdf:Description mwg-rs:Name=\"" + esc(r.Name) + "\" mwg-rs:Type=\"Face\">\n")
+			b.WriteString("        <mwg-rs:Area stArea:x=\"" + num(r.X+r.W/2) + "\" stArea:y=\"" + num(r.Y+r.H/2) +
+				"\" stArea:w=\"" + num(r.W) + "\" stArea:h=\"" + num(r.H) + "\" stArea:unit=\"normalized\"/>\n")
+			b.WriteString("       </rdf:Description>\n")
+			b.WriteString("      </rdf:li>\n")
+		}
+		b.WriteString("     </rdf:Bag>\n    </mwg-rs:RegionList>\n")
+		b.WriteString("   </mwg-rs:Regions>\n")
+	}
+	b.WriteString("  </rdf:Description>\n </rdf:RDF>\n</x:xmpmeta>\n")
+	b.WriteString("<?xpacket end=\"w\"?>\n")
+	return b.Bytes()
+}
+
+// xmpRegionDraft collects one region's fields; RDF allows each as an
+// attribute or as a child element, so both forms fill the same draft.
+type xmpRegionDraft struct {
+	name, typ, unit string
+	x, y, w, h      float64
+	hasArea         bool
+}
+
+func (d *xmpRegionDraft) set(n xml.Name, v string) {
+	v = strings.TrimSpace(v)
+	switch {
+	case n.Space == nsMWGRS && n.Local == "Name":
+		d.name = v
+	case n.Space == nsMWGRS && n.Local == "Type":
+		d.typ = v
+	case n.Space == nsStArea:
+		f, err := strconv.ParseFloat(v, 64)
+		switch n.Local {
+		case "unit":
+			d.unit = v
+		case "x":
+			d.x, d.hasArea = f, err == nil
+		case "y":
+			d.y = f
+		case "w":
+			d.w = f
+		case "h":
+			d.h = f
+		}
+	}
+}
+
+// parseXMP reads the keywords and named face regions out of an XMP packet.
+// It is lenient by design — sidecars come from many writers — and only a
+// packet that isn't XML at all is an error.
+func parseXMP(data []byte) (xmpMeta, error) {
+	var m xmpMeta
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+
+	var stack []xml.Name
+	var list string // "subject", "hier", "tagslist" while inside one
+	region := -1    // stack depth of the open region's rdf:li, or -1
+	var draft xmpRegionDraft
+	inRegionList := false
+	var text strings.Builder
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return m, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			text.Reset()
+			n := t.Name
+			switch {
+			case n.Space == nsDC && n.Local == "subject":
+				list = "subject"
+			case n.Space == nsLR && n.Local == "hierarchicalSubject":
+				list = "hier"
+			case n.Space == nsDigiKa && n.Local == "TagsList":
+				list = "tagslist"
+			case n.Space == nsMWGRS && n.Local == "RegionList":
+				inRegionList = true
+			case n.Space == nsMWGRS && n.Local == "AppliedToDimensions":
+				for _, a := range t.Attr {
+					if a.Name.Space == nsStDim && (a.Name.Local == "w" || a.Name.Local == "h") {
+						v, _ := strconv.Atoi(strings.TrimSpace(a.Value))
+						if a.Name.Local == "w" {
+							m.Width = v
+						} else {
+							m.Height = v
+						}
+					}
+				}
+			case n.Space == nsRDF && n.Local == "li" && inRegionList && region < 0:
+				region = len(stack)
+				draft = xmpRegionDraft{}
+			}
+			for _, a := range t.Attr {
+				if a.Name.Space == nsXMP && a.Name.Local == "CreatorTool" {
+					m.CreatorTool = a.Value
+				}
+				if region >= 0 {
+					draft.set(a.Name, a.Value)
+				}
+			}
+			stack = append(stack, n)
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			if len(stack) == 0 {
+				continue
+			}
+			n := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			v := strings.TrimSpace(text.String())
+			text.Reset()
+			switch {
+			case n.Space == nsRDF && n.Local == "li" && region == len(stack):
+				if strings.EqualFold(draft.typ, "Face") && draft.name != "" && draft.hasArea &&
+					(draft.unit == "" || strings.EqualFold(draft.unit, "normalized")) && draft.w > 0 && draft.h > 0 {
+					m.Regions = append(m.Regions, xmpRegion{
+						Name: draft.name,
+						X:    draft.x - draft.w/2, Y: draft.y - draft.h/2,
+						W: draft.w, H: draft.h,
+					})
+				}
+				region = -1
+			case n.Space == nsRDF && n.Local == "li" && list != "" && v != "":
+				switch list {
+				case "subject":
+					m.Subjects = append(m.Subjects, v)
+				case "hier":
+					m.Hierarchical = append(m.Hierarchical, splitKeywordPath(v, "|"))
+				case "tagslist":
+					m.Hierarchical = append(m.Hierarchical, splitKeywordPath(v, "/"))
+				}
+			case n.Space == nsDC && n.Local == "subject",
+				n.Space == nsLR && n.Local == "hierarchicalSubject",
+				n.Space == nsDigiKa && n.Local == "TagsList":
+				list = ""
+			case n.Space == nsMWGRS && n.Local == "RegionList":
+				inRegionList = false
+			case n.Space == nsXMP && n.Local == "CreatorTool" && v != "":
+				m.CreatorTool = v
+			case region >= 0 && v != "":
+				draft.set(n, v)
+			}
+		}
+	}
+	return m, nil
+}
+
+// splitKeywordPath splits a hierarchical keyword into its non-empty levels.
+func splitKeywordPath(v, sep string) []string {
+	var out []string
+	for _, p := range strings.Split(v, sep) {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// extractXMPPacket finds an XMP packet embedded in a file's bytes (JPEG APP1,
+// PNG iTXt, TIFF, and most RAW formats store it as plain text). nil when
+// there is none.
+func extractXMPPacket(data []byte) []byte {
+	start := bytes.Index(data, []byte("<x:xmpmeta"))
+	if start < 0 {
+		return nil
+	}
+	end := bytes.Index(data[start:], []byte("</x:xmpmeta>"))
+	if end < 0 {
+		return nil
+	}
+	return data[start : start+end+len("</x:xmpmeta>")]
+}
@@ -0,0 +1,469 @@
+package tasks
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/stevecastle/shrike/jobqueue"
+	"github.com/stevecastle/shrike/media"
+	"github.com/stevecastle/shrike/mediaext"
+)
+
+// XMP sidecars: face identities and tags for other photo tools.
+//
+// Everything the face pipeline learns — boxes, person names, locks — lives in
+// the database, invisible to digiKam or Lightroom. xmp-export writes it next
+// to each file as an .xmp sidecar (MWG face regions for people, tags as
+// keywords); the media file itself is never opened for writing. xmp-import is
+// the reverse trip: keywords become tags, and named face regions become
+// user-locked face assignments on the matching detected face.
+//
+// Sidecars travel with their file: the move task carries them along, and
+// split-dir treats .xmp as a sidecar of the file it names (see sidecarExts).
+
+var xmpExportOptions = []TaskOption{
+	{Name: "naming", Label: "Sidecar Name", Type: "enum", Choices: []string{"full", "stem"}, Default: "full",
+		Description: "full writes photo.jpg.xmp (digiKam, darktable); stem writes photo.xmp (Lightroom)"},
+	{Name: "overwrite", Label: "Overwrite", Type: "bool",
+		Description: "Replace sidecars written by other tools. Sidecars this server wrote are always refreshed"},
+	{Name: "unnamed", Label: "Include Unnamed People", Type: "bool",
+		Description: "Also write regions and keywords for auto-clustered \"Unknown #N\" people"},
+}
+
+var xmpImportOptions = []TaskOption{
+	{Name: "category", Label: "Keyword Category", Type: "string", Default: "Keywords",
+		Description: "Category for flat keywords; hierarchical keywords use their top level as the category"},
+	{Name: "tags", Label: "Import Keywords", Type: "bool", Default: true,
+		Description: "Add the sidecar's keywords as tags"},
+	{Name: "faces", Label: "Import Face Regions", Type: "bool", Default: true,
+		Description: "Lock named face regions onto the matching detected faces (items are scanned on the fly when needed)"},
+}
+
+// xmpRegionMinIoU is how much a sidecar region must overlap a detected face
+// to name it. Other tools draw looser or tighter boxes than our detector, so
+// this is well below what tracking uses for "the same box".
+const xmpRegionMinIoU = 0.3
+
+// xmpSidecarPath is the sidecar name for path under naming "full"
+// (photo.jpg.xmp) or "stem" (photo.xmp).
+func xmpSidecarPath(path, naming string) string {
+	if naming == "stem" {
+		return strings.TrimSuffix(path, filepath.Ext(path)) + ".xmp"
+	}
+	return path + ".xmp"
+}
+
+// xmpSidecarsOf lists the existing sidecars of path, full name first.
+func xmpSidecarsOf(path string) []string {
+	var out []string
+	for _, naming := range []string{"full", "stem"} {
+		p := xmpSidecarPath(path, naming)
+		if _, err := os.Stat(p); err == nil {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// isUnnamedPerson reports whether name is an auto-cluster placeholder.
+func isUnnamedPerson(name string) bool {
+	return strings.HasPrefix(name, "Unknown #")
+}
+
+// buildXMPMeta assembles the sidecar contents for one library item: its
+// tags as keywords (category as the hierarchy root) and, for images, its
+// people's face regions.
+func buildXMPMeta(db *sql.DB, path string, unnamed bool) (xmpMeta, error) {
+	m := xmpMeta{CreatorTool: xmpCreatorTool}
+	tags, err := media.GetTags(db, []string{path})
+	if err != nil {
+		return m, err
+	}
+	seenSubject := map[string]bool{}
+	seenPath := map[string]bool{}
+	for _, t := range tags[path] {
+		label := t.Label
+		if t.Category == media.PeopleCategory {
+			label = strings.TrimSuffix(label, media.PersonClusterSuffix)
+			if !unnamed && isUnnamedPerson(label) {
+				continue
+			}
+		}
+		if !seenSubject[label] {
+			seenSubject[label] = true
+			m.Subjects = append(m.Subjects, label)
+		}
+		if key := t.Category + "|" + label; !seenPath[key] {
+			seenPath[key] = true
+			m.Hierarchical = append(m.Hierarchical, []string{t.Category, label})
+		}
+	}
+	if !mediaext.IsImage(path) {
+		// A video face is one frame of many; MWG regions describe a still.
+		return m, nil
+	}
+	faces, err := media.AssignedFacesForMedia(db, path)
+	if err != nil {
+		return m, err
+	}
+	for _, f := range faces {
+		name := strings.TrimSuffix(f.Name, media.PersonClusterSuffix)
+		if name == "" || (!unnamed && isUnnamedPerson(name)) {
+			continue
+		}
+		m.Regions = append(m.Regions, xmpRegion{Name: name, X: f.X, Y: f.Y, W: f.W, H: f.H})
+	}
+	if len(m.Regions) > 0 {
+		var w, h sql.NullInt64
+		if err := db.QueryRow(`SELECT width, height FROM media WHERE path = ?`, path).Scan(&w, &h); err == nil {
+			m.Width, m.Height = int(w.Int64), int(h.Int64)
+		}
+	}
+	return m, nil
+}
+
+// writeXMPSidecar writes data to dest via a temp file in the same directory,
+// so a crash never leaves a half-written sidecar for another tool to read.
+func writeXMPSidecar(dest string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".xmp-*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// exportXMPSidecar writes path's sidecar. It reports false (and writes
+// nothing) when a sidecar from another tool is in the way and overwrite is
+// off, or when the item has nothing to record.
+func exportXMPSidecar(db *sql.DB, path, naming string, overwrite, unnamed bool) (bool, string, error) {
+	dest := xmpSidecarPath(path, naming)
+	if existing, err := os.ReadFile(dest); err == nil && !overwrite {
+		if prev, perr := parseXMP(existing); perr != nil || prev.CreatorTool != xmpCreatorTool {
+			return false, "kept existing sidecar from another tool", nil
+		}
+	}
+	m, err := buildXMPMeta(db, path, unnamed)
+	if err != nil {
+		return false, "", err
+	}
+	if len(m.Subjects) == 0 && len(m.Regions) == 0 {
+		return false, "nothing to write", nil
+	}
+	if err := writeXMPSidecar(dest, encodeXMP(m)); err != nil {
+		return false, "", err
+	}
+	return true, dest, nil
+}
+
+func xmpExportTask(j *jobqueue.Job, q *jobqueue.Queue, mu *sync.Mutex) error {
+	ctx := j.Ctx
+	opts := ParseOptions(j, xmpExportOptions)
+	naming, _ := opts["naming"].(string)
+	overwrite, _ := opts["overwrite"].(bool)
+	unnamed, _ := opts["unnamed"].(bool)
+
+	items, err := resolveJobItems(j, q)
+	if err != nil {
+		q.PushJobStdout(j.ID, fmt.Sprintf("Error resolving input: %v", err))
+		q.ErrorJob(j.ID)
+		return err
+	}
+	paths := items.Paths
+	if len(paths) == 0 {
+		q.PushJobStdout(j.ID, "No items to process")
+		q.CompleteJob(j.ID)
+		return nil
+	}
+	q.PushJobStdout(j.ID, fmt.Sprintf("Writing XMP sidecars for %d item(s)", len(paths)))
+	_ = q.SetJobProgress(j.ID, 0, len(paths))
+
+	var written, skipped, empty, failed int
+	for i, p := range paths {
+		select {
+		case <-ctx.Done():
+			q.PushJobStdout(j.ID, "Task was canceled")
+			_ = q.CancelJob(j.ID)
+			return ctx.Err()
+		default:
+		}
+		if q.PauseRequested(j.ID) {
+			q.PushJobStdout(j.ID, fmt.Sprintf("Paused at %d/%d - resume to continue", i, len(paths)))
+			return jobqueue.ErrPaused
+		}
+		_ = q.SetJobProgress(j.ID, i, len(paths))
+
+		ok, note, err := exportXMPSidecar(q.Db, p, naming, overwrite, unnamed)
+		switch {
+		case err != nil:
+			q.PushJobStdout(j.ID, fmt.Sprintf("Warning: could not write sidecar for %s: %v", p, err))
+			failed++
+		case ok:
+			written++
+			q.RegisterOutputFile(j.ID, note)
+		case note == "nothing to write":
+			empty++
+		default:
+			q.PushJobStdout(j.ID, fmt.Sprintf("Skipped %s: %s", p, note))
+			skipped++
+		}
+	}
+	_ = q.SetJobProgress(j.ID, len(paths), len(paths))
+	q.PushJobStdout(j.ID, fmt.Sprintf(
+		"XMP export complete: %d sidecar(s) written, %d kept (another tool's; use --overwrite), %d item(s) with nothing to record, %d failure(s)",
+		written, skipped, empty, failed))
+	q.CompleteJob(j.ID)
+	return nil
+}
+
+// readXMPForMedia returns the XMP describing path: its sidecar (full name
+// first), else a packet embedded in an image. found is false when neither
+// exists.
+func readXMPForMedia(path string) (xmpMeta, bool, error) {
+	for _, sc := range xmpSidecarsOf(path) {
+		data, err := os.ReadFile(sc)
+		if err != nil {
+			return xmpMeta{}, false, err
+		}
+		m, err := parseXMP(data)
+		return m, err == nil, err
+	}
+	if !mediaext.IsImage(path) {
+		return xmpMeta{}, false, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return xmpMeta{}, false, err
+	}
+	defer f.Close()
+	// Embedded packets sit in the file's header segments; 8 MiB covers
+	// every layout worth reading without slurping whole RAW files.
+	head, err := io.ReadAll(io.LimitReader(f, 8<<20))
+	if err != nil {
+		return xmpMeta{}, false, err
+	}
+	packet := extractXMPPacket(head)
+	if packet == nil {
+		return xmpMeta{}, false, nil
+	}
+	m, err := parseXMP(packet)
+	return m, err == nil, err
+}
+
+// xmpKeywordTag is a keyword resolved to a tag.
+type xmpKeywordTag struct {
+	Label, Category string
+}
+
+// xmpKeywordTags maps m's keywords to tags. A hierarchical keyword files its
+// leaf under its top level; a flat keyword goes to flatCategory unless a
+// hierarchical one already placed it. People keywords are skipped — people
+// arrive through face regions, where they can be tied to a face.
+func xmpKeywordTags(m xmpMeta, flatCategory string) []xmpKeywordTag {
+	var out []xmpKeywordTag
+	seen := map[xmpKeywordTag]bool{}
+	placed := map[string]bool{}
+	for _, r := range m.Regions {
+		placed[r.Name] = true
+	}
+	add := func(t xmpKeywordTag) {
+		if !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	for _, h := range m.Hierarchical {
+		leaf := h[len(h)-1]
+		if strings.EqualFold(h[0], media.PeopleCategory) {
+			placed[leaf] = true
+			continue
+		}
+		placed[leaf] = true
+		if len(h) == 1 {
+			add(xmpKeywordTag{Label: leaf, Category: flatCategory})
+			continue
+		}
+		add(xmpKeywordTag{Label: leaf, Category: h[0]})
+	}
+	for _, s := range m.Subjects {
+		if !placed[s] {
+			add(xmpKeywordTag{Label: s, Category: flatCategory})
+		}
+	}
+	return out
+}
+
+// matchXMPRegions pairs each region with the stored face it overlaps most
+// (at least xmpRegionMinIoU), strongest overlap first so two regions never
+// claim the same face. Unmatched regions map to -1.
+func matchXMPRegions(regions []xmpRegion, faces []media.Face) []int {
+	type cand struct {
+		r, f int
+		iou  float64
+	}
+	var cands []cand
+	for ri, r := range regions {
+		rb := media.NewFace{X: r.X, Y: r.Y, W: r.W, H: r.H}
+		for fi, f := range faces {
+			if iou := boxIoU(rb, media.NewFace{X: f.X, Y: f.Y, W: f.W, H: f.H}); iou >= xmpRegionMinIoU {
+				cands = append(cands, cand{ri, fi, iou})
+			}
+		}
+	}
+	sort.SliceStable(cands, func(i, k int) bool { return cands[i].iou > cands[k].iou })
+	out := make([]int, len(regions))
+	for i := range out {
+		out[i] = -1
+	}
+	used := make([]bool, len(faces))
+	for _, c := range cands {
+		if out[c.r] >= 0 || used[c.f] {
+			continue
+		}
+		out[c.r], used[c.f] = c.f, true
+	}
+	return out
+}
+
+// xmpImportCounts tallies an import run.
+type xmpImportCounts struct {
+	Tags, Locked, Already, Unmatched, Conflicts int
+}
+
+// applyXMPFaces locks m's named regions onto path's matching faces. A face a
+// user already locked to someone else is left alone and counted as a
+// conflict — the sidecar does not outrank a decision made here.
+func applyXMPFaces(db *sql.DB, path string, m xmpMeta, faces []media.Face, c *xmpImportCounts) error {
+	match := matchXMPRegions(m.Regions, faces)
+	for ri, fi := range match {
+		if fi < 0 {
+			c.Unmatched++
+			continue
+		}
+		f := faces[fi]
+		personID, _, err := resolveNamedPerson(db, m.Regions[ri].Name)
+		if err != nil {
+			return err
+		}
+		switch {
+		case f.PersonID == personID && f.AssignedBy == "user":
+			c.Already++
+			continue
+		case f.PersonID != 0 && f.PersonID != personID && f.AssignedBy == "user":
+			c.Conflicts++
+			continue
+		}
+		if err := media.AssignFace(db, f.ID, personID, "user"); err != nil {
+			return err
+		}
+		c.Locked++
+	}
+	return nil
+}
+
+func xmpImportTask(j *jobqueue.Job, q *jobqueue.Queue, mu *sync.Mutex) error {
+	ctx := j.Ctx
+	opts := ParseOptions(j, xmpImportOptions)
+	category, _ := opts["category"].(string)
+	if strings.TrimSpace(category) == "" {
+		category = "Keywords"
+	}
+	withTags, _ := opts["tags"].(bool)
+	withFaces, _ := opts["faces"].(bool)
+
+	items, err := resolveJobItems(j, q)
+	if err != nil {
+		q.PushJobStdout(j.ID, fmt.Sprintf("Error resolving input: %v", err))
+		q.ErrorJob(j.ID)
+		return err
+	}
+	paths := items.Paths
+	if len(paths) == 0 {
+		q.PushJobStdout(j.ID, "No items to process")
+		q.CompleteJob(j.ID)
+		return nil
+	}
+	q.PushJobStdout(j.ID, fmt.Sprintf("Reading XMP for %d item(s)", len(paths)))
+	_ = q.SetJobProgress(j.ID, 0, len(paths))
+
+	var c xmpImportCounts
+	var withXMP, failed int
+	finish := func() {
+		if c.Locked > 0 {
+			broadcastPeopleUpdated([]string{})
+		}
+	}
+	for i, p := range paths {
+		select {
+		case <-ctx.Done():
+			q.PushJobStdout(j.ID, "Task was canceled")
+			finish()
+			_ = q.CancelJob(j.ID)
+			return ctx.Err()
+		default:
+		}
+		if q.PauseRequested(j.ID) {
+			q.PushJobStdout(j.ID, fmt.Sprintf("Paused at %d/%d - resume to continue", i, len(paths)))
+			finish()
+			return jobqueue.ErrPaused
+		}
+		_ = q.SetJobProgress(j.ID, i, len(paths))
+
+		m, found, err := readXMPForMedia(p)
+		if err != nil {
+			q.PushJobStdout(j.ID, fmt.Sprintf("Warning: could not read XMP for %s: %v", p, err))
+			failed++
+			continue
+		}
+		if !found {
+			continue
+		}
+		withXMP++
+		if withTags {
+			for _, t := range xmpKeywordTags(m, category) {
+				if err := media.AddTag(q.Db, p, t.Label, t.Category); err != nil {
+					q.PushJobStdout(j.ID, fmt.Sprintf("Warning: could not tag %s with %q: %v", p, t.Label, err))
+					continue
+				}
+				c.Tags++
+			}
+		}
+		if withFaces && len(m.Regions) > 0 && mediaext.IsImage(p) {
+			faces, _, err := FacesForPathOrScan(ctx, q.Db, p)
+			if err != nil {
+				q.PushJobStdout(j.ID, fmt.Sprintf("Warning: could not read faces for %s: %v", p, err))
+				failed++
+				continue
+			}
+			if err := applyXMPFaces(q.Db, p, m, faces, &c); err != nil {
+				q.PushJobStdout(j.ID, fmt.Sprintf("Warning: could not assign faces in %s: %v", p, err))
+				failed++
+			}
+		}
+	}
+	_ = q.SetJobProgress(j.ID, len(paths), len(paths))
+	finish()
+	q.PushJobStdout(j.ID, fmt.Sprintf(
+		"XMP import complete: %d item(s) with XMP, %d tag(s) added, %d face(s) locked, %d already locked, %d region(s) without a matching face, %d conflicting with an existing lock, %d failure(s)",
+		withXMP, c.Tags, c.Locked, c.Already, c.Unmatched, c.Conflicts, failed))
+	q.CompleteJob(j.ID)
+	return nil
+}
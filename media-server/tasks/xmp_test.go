@@ -0,0 +1,188 @@
+package tasks
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stevecastle/shrike/media"
+)
+
+func TestXMPRoundTrip(t *testing.T) {
+	in := xmpMeta{
+		CreatorTool:  xmpCreatorTool,
+		Subjects:     []string{"beach", "Ada & Bo"},
+		Hierarchical: [][]string{{"Places", "beach"}, {"People", "Ada & Bo"}},
+		Regions:      []xmpRegion{{Name: "Ada & Bo", X: 0.1, Y: 0.2, W: 0.3, H: 0.4}},
+		Width:        4000, Height: 3000,
+	}
+	out, err := parseXMP(encodeXMP(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.CreatorTool != xmpCreatorTool || out.Width != 4000 || out.Height != 3000 {
+		t.Fatalf("header = %+v", out)
+	}
+	if len(out.Subjects) != 2 || out.Subjects[1] != "Ada & Bo" {
+		t.Fatalf("subjects = %v", out.Subjects)
+	}
+	if len(out.Hierarchical) != 2 || out.Hierarchical[0][0] != "Places" || out.Hierarchical[0][1] != "beach" {
+		t.Fatalf("hierarchical = %v", out.Hierarchical)
+	}
+	if len(out.Regions) != 1 {
+		t.Fatalf("regions = %+v", out.Regions)
+	}
+	r := out.Regions[0]
+	if r.Name != "Ada & Bo" || math.Abs(r.X-0.1) > 1e-6 || math.Abs(r.Y-0.2) > 1e-6 || math.Abs(r.W-0.3) > 1e-6 {
+		t.Fatalf("region = %+v (top-left must survive the center-based MWG area)", r)
+	}
+}
+
+func TestParseXMPElementFormAndDigiKamTags(t *testing.T) {
+	// Properties written as child elements instead of attributes, digiKam's
+	// slash-separated tag list, and a non-face region to ignore.
+	packet := `<x:xmpmeta xmlns:x="adobe:ns:meta/">
+ <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <rdf:Description rdf:about=""
+    xmlns:digiKam="http://www.digikam.org/ns/1.0/"
+    xmlns:mwg-rs="http://www.metadataworkinggroup.com/schemas/regions/"
+    xmlns:stArea="http://ns.adobe.com/xmp/sType/Area#">
+   <digiKam:TagsList><rdf:Seq><rdf:li>Events/Wedding</rdf:li><rdf:li>People/Cy</rdf:li></rdf:Seq></digiKam:TagsList>
+   <mwg-rs:Regions rdf:parseType="Resource">
+    <mwg-rs:RegionList>
+     <rdf:Bag>
+      <rdf:li rdf:parseType="Resource">
+       <mwg-rs:Name>Cy</mwg-rs:Name>
+       <mwg-rs:Type>Face</mwg-rs:Type>
+       <mwg-rs:Area rdf:parseType="Resource">
+        <stArea:x>0.5</stArea:x><stArea:y>0.5</stArea:y><stArea:w>0.2</stArea:w><stArea:h>0.2</stArea:h>
+        <stArea:unit>normalized</stArea:unit>
+       </mwg-rs:Area>
+      </rdf:li>
+      <rdf:li><rdf:Description mwg-rs:Name="Dog" mwg-rs:Type="Pet">
+       <mwg-rs:Area stArea:x="0.2" stArea:y="0.2" stArea:w="0.1" stArea:h="0.1" stArea:unit="normalized"/>
+      </rdf:Description></rdf:li>
+     </rdf:Bag>
+    </mwg-rs:RegionList>
+   </mwg-rs:Regions>
+  </rdf:Description>
+ </rdf:RDF>
+</x:xmpmeta>`
+	embedded := append([]byte("\xff\xd8\xff\xe1 junk http://ns.adobe.com/xap/1.0/\x00"), packet...)
+	embedded = append(embedded, "\xff\xd9"...)
+	m, err := parseXMP(extractXMPPacket(embedded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Regions) != 1 || m.Regions[0].Name != "Cy" || math.Abs(m.Regions[0].X-0.4) > 1e-9 {
+		t.Fatalf("regions = %+v", m.Regions)
+	}
+	tags := xmpKeywordTags(m, "Keywords")
+	if len(tags) != 1 || tags[0] != (xmpKeywordTag{Label: "Wedding", Category: "Events"}) {
+		t.Fatalf("tags = %+v (People keywords come through regions, not tags)", tags)
+	}
+}
+
+func TestXMPKeywordTagsFlatFallback(t *testing.T) {
+	m := xmpMeta{
+		Subjects:     []string{"sunset", "beach", "Ada"},
+		Hierarchical: [][]string{{"Places", "Coast", "beach"}},
+		Regions:      []xmpRegion{{Name: "Ada"}},
+	}
+	got := xmpKeywordTags(m, "Keywords")
+	want := []xmpKeywordTag{{Label: "beach", Category: "Places"}, {Label: "sunset", Category: "Keywords"}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("tags = %+v, want %+v", got, want)
+	}
+}
+
+func TestMatchXMPRegions(t *testing.T) {
+	faces := []media.Face{
+		{ID: 1, X: 0.10, Y: 0.10, W: 0.2, H: 0.2},
+		{ID: 2, X: 0.60, Y: 0.10, W: 0.2, H: 0.2},
+	}
+	regions := []xmpRegion{
+		{Name: "B", X: 0.62, Y: 0.12, W: 0.2, H: 0.2},
+		{Name: "A", X: 0.11, Y: 0.09, W: 0.22, H: 0.2},
+		{Name: "Nobody", X: 0.4, Y: 0.7, W: 0.1, H: 0.1},
+	}
+	got := matchXMPRegions(regions, faces)
+	if got[0] != 1 || got[1] != 0 || got[2] != -1 {
+		t.Fatalf("matches = %v, want [1 0 -1]", got)
+	}
+}
+
+func TestXMPExportImportFaces(t *testing.T) {
+	db := newEnrollDB(t)
+	dir := t.TempDir()
+	photo := filepath.Join(dir, "party.jpg")
+	if err := os.WriteFile(photo, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO media (path, width, height) VALUES (?, 800, 600)`, photo); err != nil {
+		t.Fatal(err)
+	}
+	if err := media.AddTag(db, photo, "beach", "Places"); err != nil {
+		t.Fatal(err)
+	}
+	ids, err := media.ReplaceFaces(db, photo, "m1", []media.NewFace{
+		{X: 0.1, Y: 0.1, W: 0.2, H: 0.2, Score: 0.9, Vec: []float32{1, 0}},
+		{X: 0.6, Y: 0.1, W: 0.2, H: 0.2, Score: 0.9, Vec: []float32{0, 1}},
+	}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ada, _ := media.CreatePerson(db, "Ada")
+	unknown, _ := media.CreatePerson(db, "Unknown #1")
+	_ = media.AssignFace(db, ids[0], ada, "user")
+	_ = media.AssignFace(db, ids[1], unknown, "auto")
+
+	// Another tool's sidecar is kept unless overwrite is on.
+	foreign := xmpSidecarPath(photo, "full")
+	if err := os.WriteFile(foreign, []byte(`<x:xmpmeta xmlns:x="adobe:ns:meta/"/>`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _, err := exportXMPSidecar(db, photo, "full", false, false); err != nil || ok {
+		t.Fatalf("export over foreign sidecar = %v, %v", ok, err)
+	}
+	if ok, dest, err := exportXMPSidecar(db, photo, "full", true, false); err != nil || !ok || dest != foreign {
+		t.Fatalf("overwrite export = %v %q %v", ok, dest, err)
+	}
+	// Our own sidecar is refreshed without overwrite.
+	if ok, _, err := exportXMPSidecar(db, photo, "full", false, false); err != nil || !ok {
+		t.Fatalf("re-export = %v, %v", ok, err)
+	}
+
+	m, found, err := readXMPForMedia(photo)
+	if err != nil || !found {
+		t.Fatalf("read back: %v %v", found, err)
+	}
+	if len(m.Regions) != 1 || m.Regions[0].Name != "Ada" || m.Width != 800 {
+		t.Fatalf("exported regions = %+v (unnamed people are left out)", m)
+	}
+
+	// Import onto a fresh copy of the faces: the region locks face 0 to Ada.
+	fresh, err := media.ReplaceFaces(db, photo, "m1", []media.NewFace{
+		{X: 0.1, Y: 0.1, W: 0.2, H: 0.2, Score: 0.9, Vec: []float32{1, 0}},
+		{X: 0.6, Y: 0.1, W: 0.2, H: 0.2, Score: 0.9, Vec: []float32{0, 1}},
+	}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	faces, _ := media.GetFaces(db, photo, "m1")
+	var c xmpImportCounts
+	if err := applyXMPFaces(db, photo, m, faces, &c); err != nil {
+		t.Fatal(err)
+	}
+	f, _, _ := media.GetFaceByID(db, fresh[0])
+	if c.Locked != 1 || f.PersonID != ada || f.AssignedBy != "user" {
+		t.Fatalf("import = %+v, face = %+v", c, f)
+	}
+	faces, _ = media.GetFaces(db, photo, "m1")
+	c = xmpImportCounts{}
+	_ = applyXMPFaces(db, photo, m, faces, &c)
+	if c.Already != 1 || c.Locked != 0 {
+		t.Fatalf("second import = %+v", c)
+	}
+}
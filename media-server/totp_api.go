@@ -0,0 +1,223 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/stevecastle/shrike/auth"
+	"github.com/stevecastle/shrike/renderer"
+)
+
+// totpIssuer labels the account in authenticator apps.
+const totpIssuer = "Lowkey Media Server"
+
+// RegisterTOTPRoutes wires per-user second-factor management onto mux:
+//
+//	GET  /auth/totp                 → {enabled, pending, recoveryCodes}
+//	POST /auth/totp/enroll          {password} → {secret, uri}
+//	POST /auth/totp/confirm         {code} → {recovery_codes}
+//	POST /auth/totp/disable         {code} | {username} (admin, for another user)
+//	POST /auth/totp/recovery-codes  {code} → {recovery_codes}
+//
+// Each acts on the signed-in user. Enrolling needs the password and the
+// other changes a current code, so a borrowed session can't lock the owner
+// out or strip their second factor; an admin may switch it off for someone
+// who lost their device.
+func RegisterTOTPRoutes(mux *http.ServeMux, deps *Dependencies) {
+	mux.HandleFunc("/auth/totp", renderer.ApplyMiddlewares(totpStatusHandler(deps), renderer.RolePublicRead))
+	mux.HandleFunc("/auth/totp/enroll", renderer.ApplyMiddlewares(totpEnrollHandler(deps), renderer.RolePublicRead))
+	mux.HandleFunc("/auth/totp/confirm", renderer.ApplyMiddlewares(totpConfirmHandler(deps), renderer.RolePublicRead))
+	mux.HandleFunc("/auth/totp/disable", renderer.ApplyMiddlewares(totpDisableHandler(deps), renderer.RolePublicRead))
+	mux.HandleFunc("/auth/totp/recovery-codes", renderer.ApplyMiddlewares(totpRecoveryCodesHandler(deps), renderer.RolePublicRead))
+}
+
+// totpSessionUser is the signed-in user behind r. API keys don't count:
+// second-factor settings belong to the person, not to a script's key.
+func totpSessionUser(deps *Dependencies, r *http.Request) string {
+	if strings.HasPrefix(requestAuthToken(r), auth.APIKeyPrefix) {
+		return ""
+	}
+	return requestUsername(deps, r)
+}
+
+// totpUser resolves the session user for a TOTP endpoint, answering 405/401
+// itself when the request can't proceed.
+func totpUser(deps *Dependencies, w http.ResponseWriter, r *http.Request, method string) string {
+	if r.Method != method {
+		httpError(w, "Use "+method, http.StatusMethodNotAllowed)
+		return ""
+	}
+	username := totpSessionUser(deps, r)
+	if username == "" {
+		httpError(w, "Sign in to manage two-factor authentication", http.StatusUnauthorized)
+	}
+	return username
+}
+
+// totpErrorStatus maps auth's second-factor errors onto HTTP statuses.
+func totpErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, auth.ErrInvalidCode), errors.Is(err, auth.ErrInvalidCreds):
+		return http.StatusUnauthorized
+	case errors.Is(err, auth.ErrTOTPRequired), errors.Is(err, auth.ErrTOTPEnabled), errors.Is(err, auth.ErrTOTPNotPending):
+		return http.StatusBadRequest
+	case errors.Is(err, auth.ErrUserNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// checkTOTPCode verifies a current code for a settings change, throttled
+// and audited like a login.
+func checkTOTPCode(deps *Dependencies, w http.ResponseWriter, r *http.Request, username, code string) bool {
+	if wait := loginWait(r, username); wait > 0 {
+		writeLockedOut(w, wait)
+		return false
+	}
+	if err := deps.Auth.CheckSecondFactor(username, code); err != nil {
+		if errors.Is(err, auth.ErrInvalidCode) {
+			loginFailed(deps, r, "totp", username, "invalid code")
+		}
+		httpError(w, err.Error(), totpErrorStatus(err))
+		return false
+	}
+	return true
+}
+
+func totpStatusHandler(deps *Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username := totpUser(deps, w, r, http.MethodGet)
+		if username == "" {
+			return
+		}
+		st, err := deps.Auth.TOTPState(username)
+		if err != nil {
+			httpError(w, err.Error(), totpErrorStatus(err))
+			return
+		}
+		writeJSON(w, st)
+	}
+}
+
+func totpEnrollHandler(deps *Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username := totpUser(deps, w, r, http.MethodPost)
+		if username == "" {
+			return
+		}
+		var req struct {
+			Password string `json:"password"`
+		}
+		if err := readJSON(r, &req); err != nil {
+			httpError(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if wait := loginWait(r, username); wait > 0 {
+			writeLockedOut(w, wait)
+			return
+		}
+		if err := deps.Auth.CheckPassword(username, req.Password); err != nil {
+			if errors.Is(err, auth.ErrInvalidCreds) {
+				loginFailed(deps, r, "totp", username, "invalid password")
+				httpError(w, "Invalid password", http.StatusUnauthorized)
+				return
+			}
+			httpError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		secret, uri, err := deps.Auth.BeginTOTPEnrollment(username, totpIssuer)
+		if err != nil {
+			httpError(w, err.Error(), totpErrorStatus(err))
+			return
+		}
+		writeJSON(w, map[string]string{"secret": secret, "uri": uri})
+	}
+}
+
+func totpConfirmHandler(deps *Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username := totpUser(deps, w, r, http.MethodPost)
+		if username == "" {
+			return
+		}
+		var req struct {
+			Code string `json:"code"`
+		}
+		if err := readJSON(r, &req); err != nil {
+			httpError(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		codes, err := deps.Auth.ConfirmTOTPEnrollment(username, req.Code)
+		if err != nil {
+			httpError(w, err.Error(), totpErrorStatus(err))
+			return
+		}
+		auditLog(deps, r, "user.totp_enable", []string{"user:" + username}, nil, nil)
+		writeJSON(w, map[string]any{"status": "ok", "recovery_codes": codes})
+	}
+}
+
+func totpDisableHandler(deps *Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username := totpUser(deps, w, r, http.MethodPost)
+		if username == "" {
+			return
+		}
+		var req struct {
+			Code     string `json:"code"`
+			Username string `json:"username"`
+		}
+		if err := readJSON(r, &req); err != nil {
+			httpError(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		target := username
+		if req.Username != "" && req.Username != username {
+			if access, err := deps.Auth.UserAccess(username); err != nil || access.Role != auth.RoleAdmin {
+				httpError(w, "Only an admin can disable another user's two-factor authentication", http.StatusForbidden)
+				return
+			}
+			target = req.Username
+		} else if !checkTOTPCode(deps, w, r, username, req.Code) {
+			return
+		}
+		if err := deps.Auth.DisableTOTP(target); err != nil {
+			httpError(w, err.Error(), totpErrorStatus(err))
+			return
+		}
+		auditLog(deps, r, "user.totp_disable", []string{"user:" + target}, nil, nil)
+		writeJSON(w, map[string]string{"status": "ok"})
+	}
+}
+
+func totpRecoveryCodesHandler(deps *Dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username := totpUser(deps, w, r, http.MethodPost)
+		if username == "" {
+			return
+		}
+		var req struct {
+			Code string `json:"code"`
+		}
+		if err := readJSON(r, &req); err != nil {
+			httpError(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if st, err := deps.Auth.TOTPState(username); err != nil || !st.Enabled {
+			httpError(w, "Two-factor authentication is not enabled", http.StatusBadRequest)
+			return
+		}
+		if !checkTOTPCode(deps, w, r, username, req.Code) {
+			return
+		}
+		codes, err := deps.Auth.RegenerateRecoveryCodes(username)
+		if err != nil {
+			httpError(w, err.Error(), totpErrorStatus(err))
+			return
+		}
+		auditLog(deps, r, "user.totp_recovery_codes", []string{"user:" + username}, nil, nil)
+		writeJSON(w, map[string]any{"status": "ok", "recovery_codes": codes})
+	}
+}
@@ -0,0 +1,61 @@
+package transcribe
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+
+	"github.com/stevecastle/shrike/resultcache"
+)
+
+// cached wraps a Provider with the content-addressed result cache: a file
+// whose bytes were already transcribed with the same provider, model, and
+// decoding options gets the stored transcript back without running the
+// engine. FromConfig hands every caller the wrapped provider.
+type cached struct {
+	Provider
+}
+
+// cacheKey is the result-cache key for req; ok is false when the cache is
+// off or the media can't be read (the provider then reports the real error).
+func (c cached) cacheKey(req Request) (resultcache.Key, bool) {
+	if !resultcache.Enabled() {
+		return resultcache.Key{}, false
+	}
+	content, err := resultcache.ContentHash(req.MediaPath)
+	if err != nil {
+		return resultcache.Key{}, false
+	}
+	params := []string{req.Language, strconv.FormatBool(req.VADFilter),
+		req.InitialPrompt, req.Hotwords, strconv.FormatBool(req.VocalExtract)}
+	if req.Translate {
+		// Appended only when set, so existing transcript keys stay valid.
+		params = append(params, "translate")
+	}
+	return resultcache.Key{
+		Kind:     resultcache.KindTranscribe,
+		Content:  content,
+		Provider: c.ID(),
+		Model:    req.Model,
+		Params:   resultcache.ParamsHash(params...),
+	}, true
+}
+
+// Transcribe answers from the cache when it can and stores fresh results.
+// A cache hit carries no TranscriptPath: no artifact was written this time.
+func (c cached) Transcribe(ctx context.Context, req Request) (Result, error) {
+	key, ok := c.cacheKey(req)
+	if ok {
+		if text, hit := resultcache.Get(key); hit {
+			if req.Log != nil {
+				req.Log("reused cached transcript for " + filepath.Base(req.MediaPath))
+			}
+			return Result{Text: text}, nil
+		}
+	}
+	res, err := c.Provider.Transcribe(ctx, req)
+	if err == nil && ok {
+		resultcache.Put(key, res.Text)
+	}
+	return res, err
+}
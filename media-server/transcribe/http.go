@@ -0,0 +1,326 @@
+package transcribe
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stevecastle/shrike/deps"
+	"github.com/stevecastle/shrike/platform"
+)
+
+// The HTTP providers upload audio, not the media file: ffmpeg extracts a
+// 16 kHz mono PCM track (what every Whisper server decodes to anyway) and
+// splits it into chunks. Ten minutes of that is ~19 MB, under the 25 MB
+// upload cap of OpenAI's endpoint, and short enough that one failed request
+// doesn't cost an hour of audio.
+const (
+	httpChunkSeconds   = 600
+	httpSampleRate     = 16000
+	httpChunkTimeout   = 10 * time.Minute
+	promptCarryRunes   = 200 // previous-chunk tail carried into the next prompt
+	errorBodyMaxLength = 300
+)
+
+// segment is one timed span of transcript text, in seconds.
+type segment struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// audioChunk is one extracted WAV file and where it sits in the source.
+type audioChunk struct {
+	Path     string
+	Offset   float64 // seconds from the start of the media
+	Duration float64
+}
+
+// extractChunks is extractAudioChunks; a var so tests can skip ffmpeg.
+var extractChunks = extractAudioChunks
+
+// extractAudioChunks writes the media's audio track into dir as consecutive
+// WAV chunks of about chunkSeconds each. Offsets come from each chunk's
+// measured length rather than the nominal chunk size, so timestamps stay
+// exact even where the segmenter cut a few samples early or late.
+func extractAudioChunks(ctx context.Context, mediaPath, dir string, chunkSeconds int) ([]audioChunk, error) {
+	ffmpeg := deps.BundledOrEmpty("ffmpeg")
+	if ffmpeg == "" {
+		return nil, fmt.Errorf("ffmpeg not found: HTTP transcription needs it to extract audio")
+	}
+	args := []string{
+		"-hide_banner", "-loglevel", "error", "-nostdin",
+		"-i", mediaPath,
+		"-vn", "-ac", "1", "-ar", fmt.Sprint(httpSampleRate), "-c:a", "pcm_s16le",
+		"-f", "segment", "-segment_time", fmt.Sprint(chunkSeconds), "-reset_timestamps", "1",
+		filepath.Join(dir, "chunk-%04d.wav"),
+	}
+	cmd := exec.CommandContext(ctx, ffmpeg, args...)
+	platform.HideSubprocessWindow(cmd)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("ffmpeg audio extraction failed: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "chunk-*.wav"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	var chunks []audioChunk
+	offset := 0.0
+	for _, p := range paths {
+		d, err := wavDuration(p)
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, audioChunk{Path: p, Offset: offset, Duration: d})
+		offset += d
+	}
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("no audio track in %s", filepath.Base(mediaPath))
+	}
+	return chunks, nil
+}
+
+// wavDuration reads a 16 kHz mono s16 WAV's length from its data chunk. The
+// data size is taken from the file size, not the header, which a streaming
+// writer may leave unfilled.
+func wavDuration(path string) (float64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	var riff [12]byte
+	if _, err := io.ReadFull(f, riff[:]); err != nil || string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return 0, fmt.Errorf("%s: not a WAV file", filepath.Base(path))
+	}
+	pos := int64(12)
+	for {
+		var hdr [8]byte
+		if _, err := io.ReadFull(f, hdr[:]); err != nil {
+			return 0, fmt.Errorf("%s: no data chunk", filepath.Base(path))
+		}
+		pos += 8
+		size := int64(binary.LittleEndian.Uint32(hdr[4:8]))
+		if string(hdr[0:4]) == "data" {
+			return float64(fi.Size()-pos) / (httpSampleRate * 2), nil
+		}
+		pos += size + size%2
+		if _, err := f.Seek(pos, io.SeekStart); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// chunkPoster sends one audio chunk to a service and returns its segments,
+// relative to the start of the chunk.
+type chunkPoster func(ctx context.Context, chunk audioChunk, prompt string, req Request) ([]segment, error)
+
+// transcribeChunked is the shared body of the HTTP providers: extract and
+// chunk the audio, post each chunk in order, shift its segments by the
+// chunk's offset, and stitch everything into one VTT. Each chunk's prompt is
+// the configured vocabulary plus the tail of the previous chunk's text, so
+// decoding carries context across the cut.
+func transcribeChunked(ctx context.Context, name string, req Request, post chunkPoster) (Result, error) {
+	logf := req.Log
+	if logf == nil {
+		logf = func(string) {}
+	}
+	dir, err := os.MkdirTemp("", "lowkey-transcribe-*")
+	if err != nil {
+		return Result{}, fmt.Errorf("%s: %w", name, err)
+	}
+	defer os.RemoveAll(dir)
+
+	logf("extracting audio from " + filepath.Base(req.MediaPath))
+	chunks, err := extractChunks(ctx, req.MediaPath, dir, httpChunkSeconds)
+	if err != nil {
+		return Result{}, fmt.Errorf("%s: %w", name, err)
+	}
+
+	vocab := vocabularyPrompt(req)
+	var all []segment
+	carry := ""
+	for i, c := range chunks {
+		logf(fmt.Sprintf("chunk %d/%d (%s–%s)", i+1, len(chunks), vttTimestamp(c.Offset), vttTimestamp(c.Offset+c.Duration)))
+		chunkCtx, cancel := context.WithTimeout(ctx, httpChunkTimeout)
+		segs, err := post(chunkCtx, c, strings.TrimSpace(vocab+" "+carry), req)
+		cancel()
+		if err != nil {
+			return Result{}, fmt.Errorf("%s: chunk %d/%d: %w", name, i+1, len(chunks), err)
+		}
+		var text []string
+		for _, s := range segs {
+			if s.Text = strings.TrimSpace(s.Text); s.Text == "" {
+				continue
+			}
+			s.Start += c.Offset
+			s.End += c.Offset
+			all = append(all, s)
+			text = append(text, s.Text)
+		}
+		carry = lastRunes(strings.Join(text, " "), promptCarryRunes)
+	}
+	logf(fmt.Sprintf("transcription complete: %d segments", len(all)))
+	return Result{Text: formatVTT(all)}, nil
+}
+
+// vocabularyPrompt folds InitialPrompt and Hotwords into the one prompt
+// field the HTTP APIs offer. Neither API has a separate hotword knob, so the
+// hint words ride along as part of the prompt text.
+func vocabularyPrompt(req Request) string {
+	prompt := strings.TrimSpace(req.InitialPrompt)
+	if h := strings.TrimSpace(req.Hotwords); h != "" {
+		prompt = strings.TrimSpace(prompt + " " + h)
+	}
+	return prompt
+}
+
+func lastRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[len(r)-n:])
+}
+
+// formatVTT renders segments as a WebVTT document.
+func formatVTT(segs []segment) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n")
+	for _, s := range segs {
+		end := s.End
+		if end < s.Start {
+			end = s.Start
+		}
+		fmt.Fprintf(&b, "\n%s --> %s\n%s\n", vttTimestamp(s.Start), vttTimestamp(end), s.Text)
+	}
+	return b.String()
+}
+
+// vttTimestamp formats seconds as HH:MM:SS.mmm.
+func vttTimestamp(sec float64) string {
+	if sec < 0 {
+		sec = 0
+	}
+	ms := int64(sec*1000 + 0.5)
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// verboseJSON is the verbose_json transcription response shared by the
+// OpenAI API, its compatible servers, and whisper.cpp's server.
+type verboseJSON struct {
+	Text     string    `json:"text"`
+	Segments []segment `json:"segments"`
+}
+
+// postChunk uploads chunk as the multipart "file" field with the given form
+// fields and decodes a verbose_json reply. A reply without segments (a
+// server that ignored response_format) becomes one segment spanning the
+// whole chunk.
+func postChunk(ctx context.Context, endpoint, apiKey string, chunk audioChunk, fields map[string]string) ([]segment, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if fields[k] == "" {
+			continue
+		}
+		if err := mw.WriteField(k, fields[k]); err != nil {
+			return nil, err
+		}
+	}
+	fw, err := mw.CreateFormFile("file", filepath.Base(chunk.Path))
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(chunk.Path)
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.Copy(fw, f)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, &body)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", mw.FormDataContentType())
+	if apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg := strings.TrimSpace(string(raw))
+		if r := []rune(msg); len(r) > errorBodyMaxLength {
+			msg = string(r[:errorBodyMaxLength]) + "…"
+		}
+		return nil, fmt.Errorf("%s returned %s: %s", endpoint, resp.Status, msg)
+	}
+	var out verboseJSON
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("decode %s response: %w", endpoint, err)
+	}
+	if len(out.Segments) == 0 && strings.TrimSpace(out.Text) != "" {
+		return []segment{{Start: 0, End: chunk.Duration, Text: out.Text}}, nil
+	}
+	return out.Segments, nil
+}
+
+// ignoredOptions logs the request options an HTTP provider can't pass on, so
+// a setting that silently does nothing is at least visible in the job log.
+func ignoredOptions(name string, req Request, logf func(string)) {
+	if logf == nil {
+		return
+	}
+	if req.VADFilter {
+		logf(name + ": voice activity detection is not supported over HTTP; ignored")
+	}
+	if req.VocalExtract {
+		logf(name + ": vocal isolation is not supported over HTTP; ignored")
+	}
+}
+
+// httpAvailable is the shared Available check: a base URL and ffmpeg.
+func httpAvailable(name, baseURL, setting string) error {
+	if strings.TrimSpace(baseURL) == "" {
+		return fmt.Errorf("%s: no server URL configured (set %s in settings)", name, setting)
+	}
+	if deps.BundledOrEmpty("ffmpeg") == "" {
+		return fmt.Errorf("%s: ffmpeg not found; it is needed to extract audio", name)
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package transcribe
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stevecastle/shrike/appconfig"
+)
+
+// writeWAV writes seconds of 16 kHz mono s16 silence, with a LIST chunk
+// ahead of the data chunk the way ffmpeg writes metadata.
+func writeWAV(t *testing.T, path string, seconds float64) {
+	t.Helper()
+	data := make([]byte, int(seconds*httpSampleRate)*2)
+	list := []byte("INFOISFT\x04\x00\x00\x00test")
+	var b []byte
+	le := binary.LittleEndian
+	b = append(b, "RIFF"...)
+	b = le.AppendUint32(b, uint32(4+8+16+8+len(list)+8+len(data)))
+	b = append(b, "WAVEfmt "...)
+	b = le.AppendUint32(b, 16)
+	b = le.AppendUint16(b, 1) // PCM
+	b = le.AppendUint16(b, 1) // mono
+	b = le.AppendUint32(b, httpSampleRate)
+	b = le.AppendUint32(b, httpSampleRate*2)
+	b = le.AppendUint16(b, 2)
+	b = le.AppendUint16(b, 16)
+	b = append(b, "LIST"...)
+	b = le.AppendUint32(b, uint32(len(list)))
+	b = append(b, list...)
+	b = append(b, "data"...)
+	b = le.AppendUint32(b, uint32(len(data)))
+	b = append(b, data...)
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWAVDuration(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "a.wav")
+	writeWAV(t, p, 2.5)
+	d, err := wavDuration(p)
+	if err != nil || d != 2.5 {
+		t.Fatalf("wavDuration = %v, %v; want 2.5", d, err)
+	}
+	if err := os.WriteFile(p, []byte("not audio"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wavDuration(p); err == nil {
+		t.Error("non-WAV accepted")
+	}
+}
+
+// fakeChunks replaces ffmpeg extraction with WAV chunks of the given lengths.
+func fakeChunks(t *testing.T, lengths ...float64) {
+	t.Helper()
+	orig := extractChunks
+	t.Cleanup(func() { extractChunks = orig })
+	extractChunks = func(_ context.Context, _, dir string, _ int) ([]audioChunk, error) {
+		var out []audioChunk
+		offset := 0.0
+		for i, l := range lengths {
+			p := filepath.Join(dir, fmt.Sprintf("chunk-%04d.wav", i))
+			writeWAV(t, p, l)
+			out = append(out, audioChunk{Path: p, Offset: offset, Duration: l})
+			offset += l
+		}
+		return out, nil
+	}
+}
+
+// chunkRequest is what the stub server saw for one upload.
+type chunkRequest struct {
+	path, auth string
+	fields     map[string]string
+	fileSize   int
+}
+
+func stubServer(t *testing.T, reply func(n int) (int, string)) (*httptest.Server, func() []chunkRequest) {
+	t.Helper()
+	var mu sync.Mutex
+	var seen []chunkRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("multipart: %v", err)
+		}
+		cr := chunkRequest{path: r.URL.Path, auth: r.Header.Get("Authorization"), fields: map[string]string{}}
+		for k, v := range r.MultipartForm.Value {
+			cr.fields[k] = v[0]
+		}
+		if fh := r.MultipartForm.File["file"]; len(fh) == 1 {
+			cr.fileSize = int(fh[0].Size)
+		}
+		mu.Lock()
+		seen = append(seen, cr)
+		n := len(seen)
+		mu.Unlock()
+		status, body := reply(n)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []chunkRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]chunkRequest(nil), seen...)
+	}
+}
+
+func segmentsJSON(segs ...segment) string {
+	b, _ := json.Marshal(verboseJSON{Segments: segs})
+	return string(b)
+}
+
+// Chunks are posted in order and their segments shifted by each chunk's
+// offset into one VTT; vocabulary and the previous chunk's text ride in the
+// prompt.
+func TestOpenAIHTTPStitchesChunks(t *testing.T) {
+	fakeChunks(t, 2, 1.5)
+	srv, seen := stubServer(t, func(n int) (int, string) {
+		if n == 1 {
+			return http.StatusOK, segmentsJSON(segment{0, 1.25, " Hello there. "}, segment{1.25, 2, "General Kenobi."})
+		}
+		return http.StatusOK, segmentsJSON(segment{0.5, 1.5, "You are a bold one."})
+	})
+	withConfig(t, appconfig.Config{
+		TranscriptionProvider:      "openai-http",
+		TranscriptionOpenAIBaseURL: srv.URL + "/",
+		TranscriptionOpenAIAPIKey:  "sk-test",
+	})
+
+	res, err := (&openAIHTTP{}).transcribe(context.Background(), Request{
+		MediaPath:     "clip.mp4",
+		Model:         "whisper-1",
+		Language:      "en",
+		InitialPrompt: "Names: Kenobi.",
+		Hotwords:      "Grievous",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "WEBVTT\n\n" +
+		"00:00:00.000 --> 00:00:01.250\nHello there.\n\n" +
+		"00:00:01.250 --> 00:00:02.000\nGeneral Kenobi.\n\n" +
+		"00:00:02.500 --> 00:00:03.500\nYou are a bold one.\n"
+	if res.Text != want {
+		t.Errorf("VTT =\n%s\nwant\n%s", res.Text, want)
+	}
+
+	reqs := seen()
+	if len(reqs) != 2 {
+		t.Fatalf("%d requests; want 2", len(reqs))
+	}
+	first := reqs[0]
+	if first.path != "/v1/audio/transcriptions" || first.auth != "Bearer sk-test" || first.fileSize == 0 {
+		t.Errorf("first request = %+v", first)
+	}
+	if first.fields["model"] != "whisper-1" || first.fields["language"] != "en" || first.fields["response_format"] != "verbose_json" {
+		t.Errorf("first fields = %v", first.fields)
+	}
+	if first.fields["prompt"] != "Names: Kenobi. Grievous" {
+		t.Errorf("first prompt = %q", first.fields["prompt"])
+	}
+	if p := reqs[1].fields["prompt"]; !strings.HasPrefix(p, "Names: Kenobi. Grievous") || !strings.HasSuffix(p, "Hello there. General Kenobi.") {
+		t.Errorf("second prompt = %q; want vocabulary plus previous chunk text", p)
+	}
+}
+
+// whisper.cpp's server gets "auto" for an empty language and no auth; a
+// text-only reply spans its chunk, and a failed chunk fails the transcript.
+func TestWhisperServerTranscribe(t *testing.T) {
+	fakeChunks(t, 3, 2)
+	srv, seen := stubServer(t, func(n int) (int, string) {
+		return http.StatusOK, fmt.Sprintf(`{"text":"part %d"}`, n)
+	})
+	withConfig(t, appconfig.Config{TranscriptionProvider: "whisper-server", TranscriptionWhisperServerURL: srv.URL})
+
+	res, err := (&whisperServer{}).transcribe(context.Background(), Request{MediaPath: "clip.mp4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "WEBVTT\n\n00:00:00.000 --> 00:00:03.000\npart 1\n\n00:00:03.000 --> 00:00:05.000\npart 2\n"
+	if res.Text != want {
+		t.Errorf("VTT =\n%s\nwant\n%s", res.Text, want)
+	}
+	if r := seen()[0]; r.path != "/inference" || r.auth != "" || r.fields["language"] != "auto" {
+		t.Errorf("request = %+v", r)
+	}
+
+	srv2, _ := stubServer(t, func(n int) (int, string) {
+		if n == 2 {
+			return http.StatusInternalServerError, "model crashed"
+		}
+		return http.StatusOK, `{"text":"ok"}`
+	})
+	withConfig(t, appconfig.Config{TranscriptionProvider: "whisper-server", TranscriptionWhisperServerURL: srv2.URL})
+	_, err = (&whisperServer{}).transcribe(context.Background(), Request{MediaPath: "clip.mp4"})
+	if err == nil || !strings.Contains(err.Error(), "chunk 2/2") || !strings.Contains(err.Error(), "model crashed") {
+		t.Errorf("err = %v; want chunk 2 failure with the server's message", err)
+	}
+}
+
+// Translate switches OpenAI-compatible servers to /v1/audio/translations
+// (which takes no language) and sets whisper.cpp's translate flag.
+func TestHTTPTranslate(t *testing.T) {
+	fakeChunks(t, 2)
+	srv, seen := stubServer(t, func(int) (int, string) { return http.StatusOK, `{"text":"Hello"}` })
+	withConfig(t, appconfig.Config{TranscriptionProvider: "openai-http", TranscriptionOpenAIBaseURL: srv.URL})
+	if _, err := (&openAIHTTP{}).transcribe(context.Background(), Request{MediaPath: "clip.mp4", Language: "es", Translate: true}); err != nil {
+		t.Fatal(err)
+	}
+	if r := seen()[0]; r.path != "/v1/audio/translations" || r.fields["language"] != "" || r.fields["model"] == "" {
+		t.Errorf("openai translate request = %+v", r)
+	}
+
+	srv2, seen2 := stubServer(t, func(int) (int, string) { return http.StatusOK, `{"text":"Hello"}` })
+	withConfig(t, appconfig.Config{TranscriptionProvider: "whisper-server", TranscriptionWhisperServerURL: srv2.URL})
+	if _, err := (&whisperServer{}).transcribe(context.Background(), Request{MediaPath: "clip.mp4", Translate: true}); err != nil {
+		t.Fatal(err)
+	}
+	if r := seen2()[0]; r.path != "/inference" || r.fields["translate"] != "true" {
+		t.Errorf("whisper-server translate request = %+v", r)
+	}
+}
+
+func TestActiveEndpoint(t *testing.T) {
+	withConfig(t, appconfig.Config{TranscriptionProvider: "whisper-cli"})
+	if _, ok := ActiveEndpoint(); ok {
+		t.Error("whisper-cli reported as remote")
+	}
+	withConfig(t, appconfig.Config{TranscriptionProvider: "whisper-server", TranscriptionWhisperServerURL: "http://gpu-box:8080/"})
+	if e, ok := ActiveEndpoint(); !ok || e != "http://gpu-box:8080/inference" {
+		t.Errorf("ActiveEndpoint = %q, %v", e, ok)
+	}
+}
+
+// Switching provider leaves the old provider's model in config; the new
+// provider falls back to its default instead of sending a name it doesn't
+// know, while a hand-entered model passes through.
+func TestFromConfigDropsOtherProvidersModel(t *testing.T) {
+	withConfig(t, appconfig.Config{TranscriptionProvider: "openai-http", TranscriptionModel: "large-v2"})
+	if _, req, _ := FromConfig("x.mp4", nil); req.Model != (&openAIHTTP{}).DefaultModel() {
+		t.Errorf("leftover model = %q; want provider default", req.Model)
+	}
+	withConfig(t, appconfig.Config{TranscriptionProvider: "openai-http", TranscriptionModel: "my-org/whisper-finetune"})
+	if _, req, _ := FromConfig("x.mp4", nil); req.Model != "my-org/whisper-finetune" {
+		t.Errorf("custom model = %q", req.Model)
+	}
+	if m := (&openAIHTTP{}).Models(); m[0].ID != "my-org/whisper-finetune" {
+		t.Errorf("custom model missing from dropdown: %v", m)
+	}
+}
@@ -0,0 +1,88 @@
+package transcribe
+
+import (
+	"context"
+	"strings"
+
+	"github.com/stevecastle/shrike/appconfig"
+)
+
+func init() { Register(&openAIHTTP{}) }
+
+// openAIHTTP posts audio to an OpenAI-compatible /v1/audio/transcriptions
+// endpoint: OpenAI itself, or a self-hosted server speaking the same API
+// (speaches, LocalAI, ...). The model name is passed through untouched, so
+// any model the server knows works even if it isn't in Models().
+type openAIHTTP struct{}
+
+func (o *openAIHTTP) ID() string           { return "openai-http" }
+func (o *openAIHTTP) DisplayName() string  { return "OpenAI-compatible HTTP" }
+func (o *openAIHTTP) DefaultModel() string { return "Systran/faster-whisper-large-v3" }
+
+func (o *openAIHTTP) Models() []ModelChoice {
+	models := []ModelChoice{
+		{ID: "Systran/faster-whisper-large-v3", DisplayName: "faster-whisper large-v3 (speaches)"},
+		{ID: "deepdml/faster-whisper-large-v3-turbo-ct2", DisplayName: "faster-whisper large-v3 turbo (speaches)"},
+		{ID: "Systran/faster-distil-whisper-large-v3", DisplayName: "faster-distil-whisper large-v3 (speaches)"},
+		{ID: "whisper-1", DisplayName: "whisper-1 (OpenAI)"},
+	}
+	// Keep a hand-entered model selectable in the dropdown.
+	if m := strings.TrimSpace(appconfig.Get().TranscriptionModel); m != "" && !hasModel(models, m) && !listedByOthers(o.ID(), m) {
+		models = append([]ModelChoice{{ID: m, DisplayName: m}}, models...)
+	}
+	return models
+}
+
+// Endpoint is the transcription URL requests go to.
+func (o *openAIHTTP) Endpoint() string {
+	base := strings.TrimRight(strings.TrimSpace(appconfig.Get().TranscriptionOpenAIBaseURL), "/")
+	if base == "" {
+		return ""
+	}
+	return base + "/v1/audio/transcriptions"
+}
+
+func (o *openAIHTTP) Available() error {
+	return httpAvailable(o.ID(), o.Endpoint(), "transcriptionOpenaiBaseUrl")
+}
+
+func (o *openAIHTTP) Transcribe(ctx context.Context, req Request) (Result, error) {
+	if err := o.Available(); err != nil {
+		return Result{}, err
+	}
+	return o.transcribe(ctx, req)
+}
+
+func (o *openAIHTTP) transcribe(ctx context.Context, req Request) (Result, error) {
+	endpoint := o.Endpoint()
+	if req.Log != nil {
+		req.Log("posting to " + endpoint)
+	}
+	ignoredOptions(o.ID(), req, req.Log)
+	apiKey := strings.TrimSpace(appconfig.Get().TranscriptionOpenAIAPIKey)
+	model := req.Model
+	if model == "" {
+		model = o.DefaultModel()
+	}
+	if req.Translate {
+		// Translation has its own endpoint, English output only, and takes
+		// neither a language nor timestamp granularities.
+		endpoint = strings.TrimSuffix(endpoint, "/transcriptions") + "/translations"
+		return transcribeChunked(ctx, o.ID(), req, func(ctx context.Context, c audioChunk, prompt string, req Request) ([]segment, error) {
+			return postChunk(ctx, endpoint, apiKey, c, map[string]string{
+				"model":           model,
+				"response_format": "verbose_json",
+				"prompt":          prompt,
+			})
+		})
+	}
+	return transcribeChunked(ctx, o.ID(), req, func(ctx context.Context, c audioChunk, prompt string, req Request) ([]segment, error) {
+		return postChunk(ctx, endpoint, apiKey, c, map[string]string{
+			"model":                     model,
+			"response_format":           "verbose_json",
+			"timestamp_granularities[]": "segment",
+			"language":                  req.Language,
+			"prompt":                    prompt,
+		})
+	})
+}
@@ -1,8 +1,9 @@
 // Package transcribe hides transcription implementation details behind a
 // provider interface. The rest of the server talks to this facade only;
-// whether speech-to-text runs through a local CLI (Faster-Whisper today), an
-// HTTP service, or some future engine is a provider concern. New providers
-// register themselves in an init() the same way tasks do.
+// whether speech-to-text runs through a local CLI (Faster-Whisper), an HTTP
+// service (OpenAI-compatible or whisper.cpp's server), or some future engine
+// is a provider concern. New providers register themselves in an init() the
+// same way tasks do.
 package transcribe
 
 import (
@@ -34,6 +35,12 @@ type Request struct {
 	// VocalExtract isolates vocals from background music/noise before
 	// transcribing. Provider support varies.
 	VocalExtract bool
+	// Translate asks for an English translation of the speech (whisper's
+	// translate task) instead of a transcript in the spoken language.
+	Translate bool
+	// OutputDir is where a provider that writes a .vtt artifact puts it
+	// ("" = beside the media file, replacing any transcript sidecar there).
+	OutputDir string
 	// Log receives human-readable progress lines. May be nil.
 	Log func(string)
 }
@@ -117,7 +124,8 @@ func Active() (Provider, error) {
 }
 
 // FromConfig builds a Request for mediaPath from the persisted transcription
-// settings and resolves the active provider.
+// settings and resolves the active provider, wrapped so identical media is
+// answered from the result cache.
 func FromConfig(mediaPath string, logFn func(string)) (Provider, Request, error) {
 	p, err := Active()
 	if err != nil {
@@ -125,10 +133,10 @@ func FromConfig(mediaPath string, logFn func(string)) (Provider, Request, error)
 	}
 	cfg := appconfig.Get()
 	model := strings.TrimSpace(cfg.TranscriptionModel)
-	if model == "" {
+	if model == "" || knownElsewhere(p, model) {
 		model = p.DefaultModel()
 	}
-	return p, Request{
+	return cached{p}, Request{
 		MediaPath:     mediaPath,
 		Model:         model,
 		Language:      strings.TrimSpace(cfg.TranscriptionLanguage),
@@ -139,3 +147,49 @@ func FromConfig(mediaPath string, logFn func(string)) (Provider, Request, error)
 		Log:           logFn,
 	}, nil
 }
+
+// knownElsewhere reports whether model is one of another provider's choices
+// but not p's — a leftover from before the provider was switched, which p
+// would reject. Names no provider lists are taken as deliberate.
+func knownElsewhere(p Provider, model string) bool {
+	return !hasModel(p.Models(), model) && listedByOthers(p.ID(), model)
+}
+
+// listedByOthers reports whether any provider but id lists model.
+func listedByOthers(id, model string) bool {
+	for other, p := range registry {
+		if other != id && hasModel(p.Models(), model) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasModel(models []ModelChoice, id string) bool {
+	for _, m := range models {
+		if m.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// remote is implemented by providers that send audio to an HTTP service.
+type remote interface {
+	Endpoint() string
+}
+
+// ActiveEndpoint returns the URL the active provider transcribes through;
+// ok is false for providers that run locally. The job queue uses it to give
+// HTTP transcription its own concurrency bucket.
+func ActiveEndpoint() (endpoint string, ok bool) {
+	p, err := Active()
+	if err != nil {
+		return "", false
+	}
+	r, ok := p.(remote)
+	if !ok {
+		return "", false
+	}
+	return r.Endpoint(), true
+}
@@ -1,11 +1,15 @@
 package transcribe
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"runtime"
 	"slices"
 	"testing"
 
 	"github.com/stevecastle/shrike/appconfig"
+	"github.com/stevecastle/shrike/resultcache"
 )
 
 func withConfig(t *testing.T, c appconfig.Config) {
@@ -135,4 +139,61 @@ func TestWhisperBuildArgs(t *testing.T) {
 	if !slices.Equal(args, want) {
 		t.Errorf("args = %v\nwant  %v", args, want)
 	}
+
+	// Translation writes elsewhere so the spoken-language sidecar survives.
+	args = w.buildArgs(Request{MediaPath: "d.mp4", Model: "medium", Translate: true, OutputDir: "/tmp/x"})
+	want = []string{"--beep_off", "--output_format=vtt", "--output_dir=/tmp/x", "--model", "medium", "--task", "translate", "d.mp4"}
+	if !slices.Equal(args, want) {
+		t.Errorf("args = %v\nwant  %v", args, want)
+	}
+}
+
+type countingProvider struct {
+	Provider
+	calls int
+}
+
+func (p *countingProvider) Transcribe(_ context.Context, req Request) (Result, error) {
+	p.calls++
+	return Result{Text: "WEBVTT\n\n" + req.Language, TranscriptPath: req.MediaPath + ".vtt"}, nil
+}
+
+// Identical bytes at another path are answered from the result cache; a
+// different decoding option is a different key.
+func TestCachedProviderReusesTranscripts(t *testing.T) {
+	if err := resultcache.Open(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(resultcache.Close)
+	whisper, _ := Lookup("whisper-cli")
+	inner := &countingProvider{Provider: whisper}
+	p := cached{inner}
+
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.mp3"), filepath.Join(dir, "moved", "a.mp3")
+	if err := os.MkdirAll(filepath.Dir(b), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{a, b} {
+		if err := os.WriteFile(f, []byte("same audio"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req := Request{MediaPath: a, Model: "small", Language: "en"}
+	if _, err := p.Transcribe(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	req.MediaPath = b
+	res, err := p.Transcribe(context.Background(), req)
+	if err != nil || inner.calls != 1 || res.Text != "WEBVTT\n\nen" || res.TranscriptPath != "" {
+		t.Fatalf("cached result = %+v, %v after %d call(s)", res, err, inner.calls)
+	}
+	req.Language = "de"
+	if _, err := p.Transcribe(context.Background(), req); err != nil || inner.calls != 2 {
+		t.Fatalf("new language served from cache (calls = %d)", inner.calls)
+	}
+	req.Translate = true
+	if _, err := p.Transcribe(context.Background(), req); err != nil || inner.calls != 3 {
+		t.Fatalf("translation served the cached transcript (calls = %d)", inner.calls)
+	}
 }
@@ -104,12 +104,19 @@ func (w *whisperCLI) buildArgs(req Request) []string {
 	if model == "" {
 		model = w.DefaultModel()
 	}
+	outDir := "source"
+	if req.OutputDir != "" {
+		outDir = req.OutputDir
+	}
 	args := []string{
 		"--beep_off",
 		"--output_format=vtt",
-		"--output_dir=source",
+		"--output_dir=" + outDir,
 		"--model", model,
 	}
+	if req.Translate {
+		args = append(args, "--task", "translate")
+	}
 	// --vad_filter trims non-speech, which dramatically reduces
 	// hallucinations during silent stretches in long clips.
 	if req.VADFilter {
@@ -186,6 +193,9 @@ func (w *whisperCLI) Transcribe(ctx context.Context, req Request) (Result, error
 	waitErr := cmd.Wait()
 
 	vttPath := req.MediaPath[:len(req.MediaPath)-len(filepath.Ext(req.MediaPath))] + ".vtt"
+	if req.OutputDir != "" {
+		vttPath = filepath.Join(req.OutputDir, filepath.Base(vttPath))
+	}
 
 	// Trust the artifact, not the exit code. The standalone binary is a
 	// PyInstaller bundle that on Windows sometimes returns 0xc0000409
@@ -0,0 +1,70 @@
+package transcribe
+
+import (
+	"context"
+	"strings"
+
+	"github.com/stevecastle/shrike/appconfig"
+)
+
+func init() { Register(&whisperServer{}) }
+
+// whisperServer posts audio to whisper.cpp's `server` example (/inference).
+// The server transcribes with whatever model it was started with, so there
+// is nothing to choose here; restart the server with -m to switch models
+// (and invalidate the result cache for model "server" afterwards).
+type whisperServer struct{}
+
+func (w *whisperServer) ID() string           { return "whisper-server" }
+func (w *whisperServer) DisplayName() string  { return "whisper.cpp server (HTTP)" }
+func (w *whisperServer) DefaultModel() string { return "server" }
+
+func (w *whisperServer) Models() []ModelChoice {
+	return []ModelChoice{{ID: "server", DisplayName: "Model loaded by the server"}}
+}
+
+// Endpoint is the inference URL requests go to.
+func (w *whisperServer) Endpoint() string {
+	base := strings.TrimRight(strings.TrimSpace(appconfig.Get().TranscriptionWhisperServerURL), "/")
+	if base == "" {
+		return ""
+	}
+	return base + "/inference"
+}
+
+func (w *whisperServer) Available() error {
+	return httpAvailable(w.ID(), w.Endpoint(), "transcriptionWhisperServerUrl")
+}
+
+func (w *whisperServer) Transcribe(ctx context.Context, req Request) (Result, error) {
+	if err := w.Available(); err != nil {
+		return Result{}, err
+	}
+	return w.transcribe(ctx, req)
+}
+
+func (w *whisperServer) transcribe(ctx context.Context, req Request) (Result, error) {
+	endpoint := w.Endpoint()
+	if req.Log != nil {
+		req.Log("posting to " + endpoint)
+	}
+	ignoredOptions(w.ID(), req, req.Log)
+	// The server's own default language is English; "auto" asks it to
+	// detect, matching the other providers' empty-means-detect.
+	language := req.Language
+	if language == "" {
+		language = "auto"
+	}
+	return transcribeChunked(ctx, w.ID(), req, func(ctx context.Context, c audioChunk, prompt string, req Request) ([]segment, error) {
+		fields := map[string]string{
+			"response_format": "verbose_json",
+			"temperature":     "0.0",
+			"language":        language,
+			"prompt":          prompt,
+		}
+		if req.Translate {
+			fields["translate"] = "true" // English output
+		}
+		return postChunk(ctx, endpoint, "", c, fields)
+	})
+}
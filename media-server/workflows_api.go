@@ -3,9 +3,15 @@ package main
 // Saved-workflow CRUD + run handlers. This file is intentionally free of
 // build tags so every platform main can register the /workflows routes;
 // the ad-hoc POST /workflow handler stays in the per-platform mains.
+//
+// A saved workflow may declare typed parameters ("params"), referenced as
+// ${name} in its tasks' arguments and input. POST /workflows/{id}/run takes
+// their values as {"params": {"name": value}}; bad declarations and values
+// answer 400.
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/stevecastle/shrike/jobqueue"
@@ -52,15 +58,24 @@ func workflowDetailHandler(deps *Dependencies) http.HandlerFunc {
 
 		case http.MethodPut:
 			var req struct {
-				Name string                  `json:"name"`
-				DAG  []jobqueue.WorkflowTask `json:"dag"`
+				Name   string                    `json:"name"`
+				DAG    []jobqueue.WorkflowTask   `json:"dag"`
+				Params *[]jobqueue.WorkflowParam `json:"params"`
 			}
 			if err := readJSONBody(r, &req); err != nil {
 				http.Error(w, "bad json", http.StatusBadRequest)
 				return
 			}
-			if err := deps.Queue.UpdateWorkflow(id, req.Name, req.DAG); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+			// Omitted params keep the current ones, so clients that predate
+			// parameters don't wipe them on save.
+			var params []jobqueue.WorkflowParam
+			if req.Params != nil {
+				params = *req.Params
+			} else if cur, err := deps.Queue.GetWorkflow(id); err == nil {
+				params = cur.Params
+			}
+			if err := deps.Queue.UpdateWorkflow(id, req.Name, req.DAG, params); err != nil {
+				http.Error(w, err.Error(), workflowErrorStatus(err))
 				return
 			}
 			wf, err := deps.Queue.GetWorkflow(id)
@@ -92,17 +107,18 @@ func workflowCreateHandler(deps *Dependencies) http.HandlerFunc {
 		}
 
 		var req struct {
-			Name string                  `json:"name"`
-			DAG  []jobqueue.WorkflowTask `json:"dag"`
+			Name   string                   `json:"name"`
+			DAG    []jobqueue.WorkflowTask  `json:"dag"`
+			Params []jobqueue.WorkflowParam `json:"params"`
 		}
 		if err := readJSONBody(r, &req); err != nil {
 			http.Error(w, "bad json", http.StatusBadRequest)
 			return
 		}
 
-		wf, err := deps.Queue.CreateWorkflow(req.Name, req.DAG)
+		wf, err := deps.Queue.CreateWorkflow(req.Name, req.DAG, req.Params)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			http.Error(w, err.Error(), workflowErrorStatus(err))
 			return
 		}
 
@@ -126,16 +142,17 @@ func workflowRunHandler(deps *Dependencies) http.HandlerFunc {
 		}
 
 		var req struct {
-			Input string `json:"input"`
+			Input  string         `json:"input"`
+			Params map[string]any `json:"params"`
 		}
 		if err := readJSONBody(r, &req); err != nil {
 			http.Error(w, "bad json", http.StatusBadRequest)
 			return
 		}
 
-		ids, err := deps.Queue.RunWorkflow(id, req.Input)
+		ids, err := deps.Queue.RunWorkflow(id, req.Input, req.Params)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			http.Error(w, err.Error(), workflowErrorStatus(err))
 			return
 		}
 
@@ -144,3 +161,11 @@ func workflowRunHandler(deps *Dependencies) http.HandlerFunc {
 		_ = json.NewEncoder(w).Encode(map[string]interface{}{"ids": ids})
 	}
 }
+
+// workflowErrorStatus is 400 for parameter problems, 500 otherwise.
+func workflowErrorStatus(err error) int {
+	if errors.Is(err, jobqueue.ErrInvalidParams) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
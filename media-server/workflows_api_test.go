@@ -95,7 +95,7 @@ func TestWorkflowsAPI_CreateListRunDelete(t *testing.T) {
 func TestWorkflowsAPI_DetailGetAndUpdate(t *testing.T) {
 	deps := newWorkflowTestDeps(t)
 
-	wf, err := deps.Queue.CreateWorkflow("orig", []jobqueue.WorkflowTask{{ID: "a", Command: "wait"}})
+	wf, err := deps.Queue.CreateWorkflow("orig", []jobqueue.WorkflowTask{{ID: "a", Command: "wait"}}, nil)
 	if err != nil {
 		t.Fatalf("CreateWorkflow: %v", err)
 	}
@@ -128,3 +128,61 @@ func TestWorkflowsAPI_DetailGetAndUpdate(t *testing.T) {
 		t.Fatalf("update name = %q, want renamed", updated.Name)
 	}
 }
+
+func TestWorkflowsAPI_RunWithParams(t *testing.T) {
+	deps := newWorkflowTestDeps(t)
+
+	create := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/workflows/create", strings.NewReader(body))
+		rr := httptest.NewRecorder()
+		workflowCreateHandler(deps).ServeHTTP(rr, req)
+		return rr
+	}
+	if rr := create(`{"name":"bad","dag":[{"id":"a","command":"wait","input":"${seconds}"}]}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("undeclared reference: status = %d, want 400", rr.Code)
+	}
+
+	// cols is a plain string parameter; the thumbsheet task's number schema
+	// is what rejects a non-numeric value at run time.
+	rr := create(`{"name":"sheet","params":[{"name":"cols","type":"string","default":"4"}],
+		"dag":[{"id":"a","command":"ffmpeg-thumbsheet","arguments":["--columns","${cols}"]}]}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create status = %d; body = %s", rr.Code, rr.Body.String())
+	}
+	var created jobqueue.SavedWorkflow
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/workflows/"+created.ID+"/run", strings.NewReader(body))
+		req.SetPathValue("id", created.ID)
+		rr := httptest.NewRecorder()
+		workflowRunHandler(deps).ServeHTTP(rr, req)
+		return rr
+	}
+	if rr := run(`{"params":{"cols":"many"}}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("non-numeric columns: status = %d, want 400; body = %s", rr.Code, rr.Body.String())
+	}
+	rr = run(`{"params":{"cols":6}}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("run status = %d; body = %s", rr.Code, rr.Body.String())
+	}
+	var out struct {
+		IDs []string `json:"ids"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &out)
+	if job := deps.Queue.GetJob(out.IDs[0]); strings.Join(job.Arguments, " ") != "--columns 6" {
+		t.Errorf("arguments = %v", job.Arguments)
+	}
+
+	// A PUT without params keeps the declared ones.
+	req := httptest.NewRequest(http.MethodPut, "/workflows/"+created.ID, strings.NewReader(
+		`{"name":"sheet","dag":[{"id":"a","command":"ffmpeg-thumbsheet","arguments":["--rows","${cols}"]}]}`))
+	req.SetPathValue("id", created.ID)
+	rr = httptest.NewRecorder()
+	workflowDetailHandler(deps).ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"cols"`) {
+		t.Errorf("update without params: %d %s", rr.Code, rr.Body.String())
+	}
+}
@@ -6,6 +6,18 @@ describe('deriveCanWrite', () => {
     expect(deriveCanWrite({ loggedIn: true, publicAccess: false })).toBe(true);
   });
 
+  it('viewer accounts are view-only; curators and admins can write', () => {
+    expect(
+      deriveCanWrite({ loggedIn: true, publicAccess: false, role: 'viewer' })
+    ).toBe(false);
+    expect(
+      deriveCanWrite({ loggedIn: true, publicAccess: true, role: 'curator' })
+    ).toBe(true);
+    expect(
+      deriveCanWrite({ loggedIn: true, publicAccess: false, role: 'admin' })
+    ).toBe(true);
+  });
+
   it('anonymous visitors on a public-access server are view-only', () => {
     expect(deriveCanWrite({ loggedIn: false, publicAccess: true })).toBe(false);
   });
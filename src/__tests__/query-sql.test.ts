@@ -65,6 +65,102 @@ describe('buildMediaQuery', () => {
     expect(bogus.sql).toContain('1=0');
   });
 
+  it('drives person: from the People tag rows so video hits keep their timestamps', () => {
+    const { sql, params } = buildMediaQuery(
+      [{ type: 'person', value: 'Ada', exclude: false }],
+      'AND'
+    );
+    expect(sql).toContain('FROM media_tag_by_category mtcw');
+    expect(sql).toContain('mtcw.time_stamp AS time_stamp');
+    expect(sql).toContain('WHERE mtcw.category_label = ? AND mtcw.tag_label IN (?, ?)');
+    expect(params).toEqual(['People', 'Ada', 'Ada_cluster']);
+
+    // Mixed operators fall back to a media scan that still LEFT JOINs the
+    // person's rows.
+    const mixed = buildMediaQuery(
+      [
+        { type: 'path', value: 'clips', exclude: false },
+        { type: 'person', value: 'Ada', exclude: false, join: 'OR' },
+        { type: 'tag', value: 'beach', exclude: false, join: 'AND' },
+      ],
+      'AND'
+    );
+    expect(mixed.sql).toContain(
+      'LEFT JOIN media_tag_by_category mtcw ON mtcw.media_path = media.path AND mtcw.category_label = ? AND mtcw.tag_label IN (?, ?)'
+    );
+    expect(mixed.params.slice(0, 3)).toEqual(['People', 'Ada', 'Ada_cluster']);
+
+    const excluded = buildMediaQuery(
+      [{ type: 'person', value: 'Ada', exclude: true }],
+      'AND'
+    );
+    expect(excluded.sql).toContain('(NOT EXISTS (SELECT 1 FROM media_tag_by_category mtc');
+  });
+
+  it('reads with: and together: from the co-occurrence pairs', () => {
+    const withQ = buildMediaQuery(
+      [{ type: 'with', value: 'Ada', exclude: false }],
+      'AND'
+    );
+    expect(withQ.sql).toContain('FROM person_cooccur pc');
+    expect(withQ.sql).toContain("p2.name NOT LIKE 'Unknown #%'");
+    expect(withQ.params).toEqual(['Ada', 'Ada_cluster']);
+
+    // together:"Ada","Bo","Cy" reaches the builder as Ada,Bo,Cy — one pair
+    // check per companion.
+    const together = buildMediaQuery(
+      [{ type: 'together', value: 'Ada,Bo,Cy', exclude: false }],
+      'AND'
+    );
+    expect(together.sql.split('FROM person_cooccur pc').length - 1).toBe(2);
+    expect(together.params).toEqual([
+      'Ada', 'Ada_cluster', 'Bo', 'Bo_cluster',
+      'Ada', 'Ada_cluster', 'Cy', 'Cy_cluster',
+    ]);
+
+    const single = buildMediaQuery(
+      [{ type: 'together', value: 'Ada', exclude: false }],
+      'AND'
+    );
+    expect(single.sql).toContain('1=0');
+  });
+
+  it('compiles speaker: to a transcript voice-span match', () => {
+    const include = buildMediaQuery(
+      [{ type: 'speaker', value: 'Speaker *', exclude: false }],
+      'AND'
+    );
+    expect(include.sql).toContain('(media.transcript LIKE ?)');
+    expect(include.params).toEqual(['%<v Speaker %>%']);
+
+    // Excluding a speaker keeps items with no transcript at all.
+    const excluded = buildMediaQuery(
+      [{ type: 'speaker', value: 'Ann', exclude: true }],
+      'AND'
+    );
+    expect(excluded.sql).toContain("(COALESCE(media.transcript, '') NOT LIKE ?)");
+  });
+
+  it('compiles translation: to a per-language transcript lookup', () => {
+    const include = buildMediaQuery(
+      [{ type: 'translation', value: 'pt_BR:ol*', exclude: false }],
+      'AND'
+    );
+    expect(include.sql).toContain(
+      '(EXISTS (SELECT 1 FROM media_transcript mt WHERE mt.media_path = media.path AND mt.lang = ? AND mt.transcript LIKE ?))'
+    );
+    expect(include.params).toEqual(['pt-br', '%ol%%']);
+
+    const excluded = buildMediaQuery(
+      [{ type: 'translation', value: 'es', exclude: true }],
+      'AND'
+    );
+    expect(excluded.sql).toContain(
+      '(NOT EXISTS (SELECT 1 FROM media_transcript mt WHERE mt.media_path = media.path AND mt.lang = ?))'
+    );
+    expect(excluded.params).toEqual(['es']);
+  });
+
   it('compiles orientation:landscape/portrait/square from width vs height', () => {
     const landscape = buildMediaQuery(
       [{ type: 'orientation', value: 'landscape', exclude: false }],
@@ -48,6 +48,7 @@ jest.mock('../renderer/platform', () => ({
 
 jest.mock('../renderer/stream-bus', () => ({
   __esModule: true,
+  setStreamAuthToken: jest.fn(),
   subscribeStream: (cb: any) => {
     streamHandler = cb;
     return () => {
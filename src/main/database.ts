@@ -311,6 +311,12 @@ export async function initDB(db: Database) {
       e
     );
   }
+  // username marks votes the media server logged against one account's own
+  // ratings; the viewer's library-wide ranking only counts NULL rows.
+  const battleInfo = await db.all(`PRAGMA table_info(battle)`);
+  if (!battleInfo.some((c: any) => c.name === 'username')) {
+    await db.run(`ALTER TABLE battle ADD COLUMN username TEXT`);
+  }
 
   // Migrate existing media table if needed
   const mediaTable = await db.get(
@@ -513,7 +513,8 @@ const recordBattle =
           'recordBattle:elo'
         );
         const count = await db.get(
-          `SELECT COUNT(*) AS n FROM battle WHERE winner_path = ? OR loser_path = ?`,
+          `SELECT COUNT(*) AS n FROM battle
+           WHERE (winner_path = ? OR loser_path = ?) AND username IS NULL`,
           [path, path],
           'recordBattle:matches'
         );
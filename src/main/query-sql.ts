@@ -26,6 +26,61 @@ const DRIVEN_TAG_COLUMNS =
   'mtcw.time_stamp AS time_stamp, mtcw.created_at AS created_at, ' +
   'media.battles AS battles';
 
+// A person's tag lives in the People category under their name, or
+// "<name>_cluster" when a curated tag owns the plain name.
+function personTagParams(name: string): string[] {
+  const n = name.trim();
+  return ['People', n, `${n}_cluster`];
+}
+
+// The pairs one item shares, read from person_cooccur: p1 is the named
+// person, p2 the other face on the same item. Mirror of media/cooccur.go.
+const COOCCUR_PAIR =
+  'EXISTS (SELECT 1 FROM person_cooccur pc ' +
+  'JOIN person p1 ON p1.id IN (pc.person_a, pc.person_b) ' +
+  'JOIN person p2 ON p2.id IN (pc.person_a, pc.person_b) AND p2.id <> p1.id ' +
+  'WHERE pc.media_path = media.path AND p1.name IN (?, ?) AND ';
+
+// with:"Ada" — Ada alongside at least one other NAMED person (unnamed
+// "Unknown #N" auto-clusters don't count).
+function withClause(name: string, params: string[]): string {
+  params.push(...personTagParams(name).slice(1));
+  return `${COOCCUR_PAIR}p2.name NOT LIKE 'Unknown #%')`;
+}
+
+// together:"Ada","Bo" — the tokenizer has already stripped the quotes, so the
+// value is "Ada,Bo": every named person appears on the item. Fewer than two
+// names is no relationship and matches nothing.
+function togetherClause(value: string, params: string[]): string {
+  const names = value
+    .split(',')
+    .map((n) => n.replace(/^"|"$/g, '').trim())
+    .filter(Boolean);
+  if (names.length < 2) return '1=0';
+  const conds = names.slice(1).map((other) => {
+    params.push(...personTagParams(names[0]).slice(1));
+    params.push(...personTagParams(other).slice(1));
+    return `${COOCCUR_PAIR}p2.name IN (?, ?))`;
+  });
+  return `(${conds.join(' AND ')})`;
+}
+
+// Whether p's tag rows can drive a query, surfacing their weight and
+// timestamp: an include tag, or an include person (one row per appearance).
+function drivesTagRows(p: Predicate): boolean {
+  return (p.type === 'tag' || p.type === 'person') && !p.exclude;
+}
+
+// The mtcw condition selecting a driving predicate's tag rows.
+function tagRowsOn(p: Predicate, params: string[]): string {
+  if (p.type === 'person') {
+    params.push(...personTagParams(p.value));
+    return 'mtcw.category_label = ? AND mtcw.tag_label IN (?, ?)';
+  }
+  params.push(p.value);
+  return 'mtcw.tag_label = ?';
+}
+
 function clauseFor(p: Predicate, params: string[]): string {
   const like = `%${p.value}%`;
   switch (p.type) {
@@ -39,6 +94,50 @@ function clauseFor(p: Predicate, params: string[]): string {
       return p.exclude
         ? '(NOT EXISTS (SELECT 1 FROM media_tag_by_category mtc WHERE mtc.media_path = media.path AND mtc.category_label = ?))'
         : '(EXISTS (SELECT 1 FROM media_tag_by_category mtc WHERE mtc.media_path = media.path AND mtc.category_label = ?))';
+    case 'person':
+      // person:"name" — media a person's faces were assigned in, through
+      // their People tag rows (plain or _cluster-suffixed name). Mirror of
+      // media_query.go.
+      params.push(...personTagParams(p.value));
+      return p.exclude
+        ? '(NOT EXISTS (SELECT 1 FROM media_tag_by_category mtc WHERE mtc.media_path = media.path AND mtc.category_label = ? AND mtc.tag_label IN (?, ?)))'
+        : '(EXISTS (SELECT 1 FROM media_tag_by_category mtc WHERE mtc.media_path = media.path AND mtc.category_label = ? AND mtc.tag_label IN (?, ?)))';
+    case 'with':
+    case 'together': {
+      // Mirror of media_query.go.
+      const cond =
+        p.type === 'with'
+          ? withClause(p.value, params)
+          : togetherClause(p.value, params);
+      return p.exclude ? `(NOT ${cond})` : `(${cond})`;
+    }
+    case 'speaker':
+      // speaker:"Ann" — a cue in the diarized transcript voiced by Ann
+      // (<v Ann>); '*' is a wildcard. An exclude keeps untranscribed items.
+      // Mirror of media_query.go.
+      params.push(`%<v ${p.value.replace(/\*/g, '%')}>%`);
+      return p.exclude
+        ? "(COALESCE(media.transcript, '') NOT LIKE ?)"
+        : '(media.transcript LIKE ?)';
+    case 'translation': {
+      // translation:"es" — a Spanish transcript exists; "es:hola" — and it
+      // contains hola ('*' is a wildcard). Mirror of media_query.go.
+      const sep = p.value.indexOf(':');
+      const lang = (sep < 0 ? p.value : p.value.slice(0, sep))
+        .trim()
+        .toLowerCase()
+        .replace(/_/g, '-');
+      const text = sep < 0 ? '' : p.value.slice(sep + 1).trim();
+      params.push(lang);
+      let cond =
+        'EXISTS (SELECT 1 FROM media_transcript mt WHERE mt.media_path = media.path AND mt.lang = ?';
+      if (text) {
+        params.push(`%${text.replace(/\*/g, '%')}%`);
+        cond += ' AND mt.transcript LIKE ?';
+      }
+      cond += ')';
+      return p.exclude ? `(NOT ${cond})` : `(${cond})`;
+    }
     case 'path':
       params.push(like);
       return p.exclude ? '(media.path NOT LIKE ?)' : '(media.path LIKE ?)';
@@ -48,6 +147,27 @@ function clauseFor(p: Predicate, params: string[]): string {
     case 'hash':
       params.push(like);
       return p.exclude ? '(media.hash NOT LIKE ?)' : '(media.hash LIKE ?)';
+    case 'cluster': {
+      // cluster:<id> — members of one library theme (the media server's
+      // cluster-library task writes media_cluster_member into the shared
+      // library DB; before the first run the predicate is never offered).
+      // A non-numeric id matches nothing. Mirror of media_query.go.
+      const id = p.value.trim();
+      if (!/^\d+$/.test(id)) return p.exclude ? '(1=1)' : '(1=0)';
+      params.push(id);
+      return p.exclude
+        ? '(NOT EXISTS (SELECT 1 FROM media_cluster_member mcm WHERE mcm.media_path = media.path AND mcm.cluster_id = ?))'
+        : '(EXISTS (SELECT 1 FROM media_cluster_member mcm WHERE mcm.media_path = media.path AND mcm.cluster_id = ?))';
+    }
+    case 'collection':
+      // collection:"name" — members of a hand-ordered collection (the media
+      // server owns the collection tables in the shared library DB). Names
+      // match case-insensitively via the column's NOCASE collation. Mirror
+      // of media_query.go.
+      params.push(p.value.trim());
+      return p.exclude
+        ? '(NOT EXISTS (SELECT 1 FROM collection_item ci JOIN collection c ON c.id = ci.collection_id WHERE ci.media_path = media.path AND c.name = ?))'
+        : '(EXISTS (SELECT 1 FROM collection_item ci JOIN collection c ON c.id = ci.collection_id WHERE ci.media_path = media.path AND c.name = ?))';
     case 'faces': {
       // faces:ungrouped — media whose detected faces are ALL still unassigned
       // (the People panel's Ungrouped card). One grouped face disqualifies
@@ -82,10 +202,13 @@ function clauseFor(p: Predicate, params: string[]): string {
     case 'visual':
     case 'clip':
     case 'face':
+    case 'semantic':
+    case 'saved':
       // Similarity search requires the embedding backend, which only exists in
-      // the media-server (web mode). In Electron's local-SQLite path we cannot
-      // resolve it, so treat it as no constraint and warn. The server path
-      // (/api/media/query) handles these properly.
+      // the media-server (web mode), and saved searches are expanded there
+      // too. In Electron's local-SQLite path we cannot resolve them, so treat
+      // them as no constraint and warn. The server path (/api/media/query)
+      // handles these properly.
       console.warn(
         `Visual search ('${p.type}:') is only available in server/web mode; ignoring this predicate in local mode.`
       );
@@ -106,23 +229,22 @@ function andJoin(preds: Predicate[], params: string[]): string {
 // LEFT-TO-RIGHT using the given connectors (connectors[i] joins valid[i+1] to
 // the running expression), parenthesized so evaluation is left-associative and
 // matches how the chips read. Surfaces tag columns via a LEFT JOIN on the first
-// include-tag (if any). Used for mixed AND/OR and exclude/non-tag-only queries.
+// include tag or person (if any). Used for mixed AND/OR and exclude/non-tag-only
+// queries.
 function mediaScan(
   valid: Predicate[],
-  connectors: ('AND' | 'OR')[],
-  isIncludeTag: (p: Predicate) => boolean
+  connectors: ('AND' | 'OR')[]
 ): { sql: string; params: string[] } {
-  const primaryTag = valid.find(isIncludeTag)?.value;
+  const primary = valid.find(drivesTagRows);
   const params: string[] = [];
   let select: string;
-  if (primaryTag) {
+  if (primary) {
     select =
       `SELECT ${BASE_COLUMNS}, mtcw.weight AS weight, mtcw.tag_label AS tag_label, ` +
       `mtcw.time_stamp AS time_stamp, mtcw.created_at AS created_at, ` +
       `media.battles AS battles ` +
       `FROM media LEFT JOIN media_tag_by_category mtcw ` +
-      `ON mtcw.media_path = media.path AND mtcw.tag_label = ?`;
-    params.push(primaryTag); // JOIN param comes first in the SQL
+      `ON mtcw.media_path = media.path AND ${tagRowsOn(primary, params)}`; // JOIN params come first in the SQL
   } else {
     select = `SELECT ${BASE_COLUMNS}, ${NULL_TAG_COLS} FROM media`;
   }
@@ -187,11 +309,12 @@ export function buildMediaQuery(
   }
 
   // ---- Intersection (single predicate or all-AND): drive from an indexed
-  //      tag/category, with the rest as AND conjuncts ----
+  //      tag/person/category, with the rest as AND conjuncts ----
   if (allAnd) {
-    const driveTag = valid.find(isIncludeTag);
+    const driveTag = valid.find(drivesTagRows);
     if (driveTag) {
-      const params: string[] = [driveTag.value]; // driving join param first
+      const params: string[] = [];
+      const on = tagRowsOn(driveTag, params); // driving params first
       const rest = valid.filter((p) => p !== driveTag);
       const restWhere = andJoin(rest, params);
       const extra = restWhere ? ` AND ${restWhere}` : '';
@@ -199,7 +322,7 @@ export function buildMediaQuery(
         sql:
           `SELECT ${DRIVEN_TAG_COLUMNS} FROM media_tag_by_category mtcw ` +
           `LEFT JOIN media ON media.path = mtcw.media_path ` +
-          `WHERE mtcw.tag_label = ?${extra}`,
+          `WHERE ${on}${extra}`,
         params,
       };
     }
@@ -219,9 +342,9 @@ export function buildMediaQuery(
       };
     }
     // No drivable tag/category (paths/hash/excludes only) → AND media scan.
-    return mediaScan(valid, connectors, isIncludeTag);
+    return mediaScan(valid, connectors);
   }
 
   // ---- Mixed AND/OR operators → correct left-to-right media scan ----
-  return mediaScan(valid, connectors, isIncludeTag);
+  return mediaScan(valid, connectors);
 }
@@ -4,9 +4,14 @@ import { isElectron } from './platform';
 // whether the server is running in Allow Public Access mode. Electron is
 // always full-featured, so this module is effectively a no-op there.
 
+export type AccountRole = 'viewer' | 'curator' | 'admin';
+
 export interface AccessInfo {
   loggedIn: boolean;
   publicAccess: boolean;
+  // The signed-in account's role ('' when anonymous). Viewers get the
+  // same view-only UI as anonymous public-access visitors.
+  role: AccountRole | '';
   canWrite: boolean;
   // Server-configured folder a fresh session opens instead of the picker
   // (signed-in web sessions only; '' = no default).
@@ -19,13 +24,20 @@ export interface AccessInfo {
 export function deriveCanWrite(a: {
   loggedIn: boolean;
   publicAccess: boolean;
+  role?: AccountRole | '';
 }): boolean {
+  if (a.loggedIn && a.role === 'viewer') return false;
   return a.loggedIn || !a.publicAccess;
 }
 
+function parseRole(v: unknown): AccountRole | '' {
+  return v === 'viewer' || v === 'curator' || v === 'admin' ? v : '';
+}
+
 let cached: AccessInfo = {
   loggedIn: true,
   publicAccess: false,
+  role: '',
   canWrite: true,
   defaultStartPath: '',
 };
@@ -40,12 +52,15 @@ export async function initAccess(): Promise<AccessInfo> {
       headers: { Accept: 'application/json' },
     });
     const data = await res.json();
+    const role = parseRole(data.role);
     cached = {
       loggedIn: !!data.loggedIn,
       publicAccess: !!data.publicAccess,
+      role,
       canWrite: deriveCanWrite({
         loggedIn: !!data.loggedIn,
         publicAccess: !!data.publicAccess,
+        role,
       }),
       defaultStartPath:
         typeof data.defaultStartPath === 'string' ? data.defaultStartPath : '',
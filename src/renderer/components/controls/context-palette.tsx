@@ -91,6 +91,7 @@ type MetadataType = {
 const METADATA_TYPES: MetadataType[] = [
   { label: 'Tags', ops: ['autotag'], skipTaggedInFolder: true },
   { label: 'Descriptions', ops: ['describe'] },
+  { label: 'AI tags', ops: ['llm-tag'] },
   { label: 'Transcripts', ops: ['transcribe'] },
   { label: 'File info', ops: ['hash', 'dimensions'] },
   { label: 'Embeddings', ops: ['embed'] },
@@ -14,6 +14,9 @@ export default function LoginWidget() {
 
   const [username, setUsername] = useState('');
   const [password, setPassword] = useState('');
+  // Shown once the server says this account has a second factor.
+  const [code, setCode] = useState('');
+  const [needsCode, setNeedsCode] = useState(false);
   const [isSubmitting, setIsSubmitting] = useState(false);
   const [error, setError] = useState<string | null>(null);
 
@@ -51,11 +54,18 @@ export default function LoginWidget() {
         headers: {
           'Content-Type': 'application/json',
         },
-        body: JSON.stringify({ username, password }),
+        body: JSON.stringify({ username, password, code: code.trim() }),
       });
 
       if (!response.ok) {
         const data = await response.json().catch(() => ({}));
+        if (data.error === 'totp_required') {
+          setNeedsCode(true);
+          return;
+        }
+        if (response.status === 429) {
+          throw new Error('Too many attempts, try again later');
+        }
         throw new Error(data.error || 'Login failed');
       }
 
@@ -73,6 +83,8 @@ export default function LoginWidget() {
       libraryService.send({ type: 'SET_CAN_WRITE', canWrite: true });
       setPassword('');
       setUsername('');
+      setCode('');
+      setNeedsCode(false);
     } catch (err: unknown) {
       const message = err instanceof Error ? err.message : 'Login failed';
       setError(message);
@@ -138,6 +150,20 @@ export default function LoginWidget() {
             disabled={isSubmitting}
           />
         </div>
+        {needsCode && (
+          <div className="input-group">
+            <input
+              type="text"
+              placeholder="Authentication code"
+              inputMode="numeric"
+              autoComplete="one-time-code"
+              value={code}
+              onChange={(e) => setCode(e.target.value)}
+              disabled={isSubmitting}
+              autoFocus
+            />
+          </div>
+        )}
         {error && <div className="error-message">{error}</div>}
         <button type="submit" disabled={isSubmitting || !username || !password}>
           {isSubmitting ? '...' : 'Login'}
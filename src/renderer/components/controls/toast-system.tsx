@@ -3,7 +3,7 @@ import { useSelector } from '@xstate/react';
 import { useQueryClient } from '@tanstack/react-query';
 import { GlobalStateContext } from '../../state';
 import { send, mediaServerBase, isElectron, on } from '../../platform';
-import { subscribeStream } from '../../stream-bus';
+import { setStreamAuthToken, subscribeStream } from '../../stream-bus';
 import './toast-system.css';
 
 type JobState =
@@ -86,10 +86,24 @@ const getJobTitle = (job: JobRunnerJob): string => {
         : 'Grouping Faces into People';
     case 'assign-person':
       return 'Assigning Person';
+    case 'enroll-people':
+      return 'Enrolling People';
+    case 'xmp-export':
+      return 'Writing XMP Sidecars';
+    case 'xmp-import':
+      return 'Importing XMP Metadata';
     case 'describe':
       return 'Generating Descriptions';
+    case 'llm-tag':
+      return 'Tagging with AI';
     case 'transcribe':
       return 'Transcribing Audio';
+    case 'diarize':
+      return 'Labeling Speakers';
+    case 'subtitle-export':
+      return 'Writing Subtitle Sidecars';
+    case 'translate':
+      return 'Translating Transcripts';
     case 'hash':
       return 'Hashing Files';
     case 'dimensions':
@@ -132,10 +146,24 @@ const getJobSubtitle = (job: JobRunnerJob): string | null => {
         : 'Matching new faces to your people; nothing already grouped is moved.';
     case 'assign-person':
       return 'Assigning this person’s face across the selected items.';
+    case 'enroll-people':
+      return 'Learning faces from your reference photos so clustering starts with their names.';
+    case 'xmp-export':
+      return 'Saving people and tags beside each file for other photo tools.';
+    case 'xmp-import':
+      return 'Reading keywords and named faces written by other photo tools.';
     case 'describe':
       return 'Writing AI descriptions of your media.';
+    case 'llm-tag':
+      return 'Sorting setting, objects, and mood into tag categories.';
     case 'transcribe':
       return 'Transcribing speech into searchable text.';
+    case 'diarize':
+      return 'Working out who is speaking in each transcript line.';
+    case 'subtitle-export':
+      return 'Saving transcripts as subtitle files beside each video.';
+    case 'translate':
+      return 'Translating transcripts into other languages.';
     case 'hash':
       return 'Computing content hashes to find duplicates.';
     case 'dimensions':
@@ -385,6 +413,10 @@ export function ToastSystem() {
     libraryService,
     (state) => state.context.authToken
   );
+  // The shared /stream connection authenticates with the session token.
+  useEffect(() => {
+    setStreamAuthToken(authToken);
+  }, [authToken]);
   const [jobs, setJobs] = useState<Map<string, JobRunnerJob>>(new Map());
 
   // Jobs whose toast the user dismissed with the × button. Clearing a toast is
@@ -490,7 +522,11 @@ export function ToastSystem() {
           queryClient.invalidateQueries(['tags-by-path']);
         }
 
-        if (job.command === 'autotag' || job.command === 'process') {
+        if (
+          job.command === 'autotag' ||
+          job.command === 'llm-tag' ||
+          job.command === 'process'
+        ) {
           queryClient.invalidateQueries(['tags-by-path']);
           queryClient.invalidateQueries({ queryKey: ['taxonomy'] });
         }
@@ -657,6 +693,11 @@ export function ToastSystem() {
         case 'media-created':
           onMediaCreated(event);
           break;
+        case 'resync':
+          // The server could not replay everything missed while
+          // disconnected; refetch rather than trust the cache.
+          queryClient.invalidateQueries();
+          break;
         default:
           break;
       }
@@ -32,6 +32,11 @@ export const TYPE_GLYPH: Record<Predicate['type'], string> = {
   face: 'face:', // never shown — face chips render a thumbnail instead
   faces: 'faces:',
   orientation: 'orientation:',
+  person: 'person:',
+  with: 'with:',
+  together: 'together:',
+  speaker: 'speaker:',
+  translation: 'translation:',
 };
 
 // Navigation keys for the rows this section contributes to a host's shared
@@ -531,13 +531,16 @@ if (isElectron) {
   loadMediaByQuery = async (predicates, mode = 'AND', authToken) => {
     // Match the server's guard (loki_api.go): only a visual predicate with a
     // non-empty value is resolved via the embedding backend, so only route
-    // (and require auth) when there's actually a visual query to run.
+    // (and require auth) when there's actually a visual query to run. Saved
+    // searches are stored (and expanded) server-side, so they route too.
     const hasVisual = predicates.some(
       (p) =>
         (p.type === 'similar' ||
           p.type === 'visual' ||
           p.type === 'clip' ||
-          p.type === 'face') &&
+          p.type === 'face' ||
+          p.type === 'semantic' ||
+          p.type === 'saved') &&
         p.value !== ''
     );
     if (!hasVisual) {
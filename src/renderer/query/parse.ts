@@ -34,7 +34,16 @@ const PREFIXES: Array<{ prefix: string; type: PredicateType }> = [
   // adjacent so nobody reorders them apart.
   { prefix: 'faces:', type: 'faces' },
   { prefix: 'face:', type: 'face' },
+  { prefix: 'semantic:', type: 'semantic' },
+  { prefix: 'cluster:', type: 'cluster' },
+  { prefix: 'collection:', type: 'collection' },
+  { prefix: 'saved:', type: 'saved' },
   { prefix: 'orientation:', type: 'orientation' },
+  { prefix: 'person:', type: 'person' },
+  { prefix: 'with:', type: 'with' },
+  { prefix: 'together:', type: 'together' },
+  { prefix: 'speaker:', type: 'speaker' },
+  { prefix: 'translation:', type: 'translation' },
 ];
 
 // Strip surrounding quotes that survived tokenization of a prefixed value
@@ -86,7 +95,16 @@ const TYPE_PREFIX: Record<PredicateType, string> = {
   clip: 'clip:',
   face: 'face:',
   faces: 'faces:',
+  semantic: 'semantic:',
+  cluster: 'cluster:',
+  collection: 'collection:',
+  saved: 'saved:',
   orientation: 'orientation:',
+  person: 'person:',
+  with: 'with:',
+  together: 'together:',
+  speaker: 'speaker:',
+  translation: 'translation:',
 };
 
 export function serializePredicate(p: Predicate): string {
@@ -14,7 +14,16 @@ export type PredicateType =
   | 'clip'
   | 'face'
   | 'faces'
-  | 'orientation';
+  | 'semantic'
+  | 'cluster'
+  | 'collection'
+  | 'saved'
+  | 'orientation'
+  | 'person'
+  | 'with'
+  | 'together'
+  | 'speaker'
+  | 'translation';
 
 // One extra component of a composite similarity query, merged with the
 // predicate's base value into a single query vector server-side:
@@ -43,9 +52,30 @@ export interface Predicate {
   // 'faces' = face-presence filter; value 'ungrouped' = media holding at
   //   least one detected face not assigned to any person yet (the People
   //   panel's Ungrouped pool).
+  // 'semantic' = free text matched by MEANING against description and
+  //   transcript chunks (local sentence encoder); transcript hits carry the
+  //   matching window's start offset as item.semanticOffset.
+  // 'cluster' = library theme membership; value is a theme id from
+  //   GET /api/clusters (ids change when the themes are recomputed).
+  // 'collection' = membership of a hand-ordered collection, by name
+  //   (case-insensitive; see /api/collections).
+  // 'saved' = membership of a server-side saved search, by name (see
+  //   /api/saved-searches); saved searches may nest other saved searches.
   // 'orientation' = dimension filter on media.width vs media.height; value
   //   'landscape' | 'portrait' | 'square'. Items without known dimensions
   //   never match an include and are kept by an exclude.
+  // 'person' = media a named person appears in, by display name (their
+  //   People tag rows). Results carry one row per appearance; video hits
+  //   from frame-by-frame face scans carry the appearance's time_stamp.
+  // 'with' = media a named person shares with at least one other NAMED
+  //   person, by display name.
+  // 'together' = media showing ALL the listed people; value is the names
+  //   comma-separated ("Ada,Bo" — typed as together:"Ada","Bo").
+  // 'speaker' = media whose diarized transcript has a cue spoken by the
+  //   named speaker ("Speaker 2", or the name it was renamed to); '*' is a
+  //   wildcard. Matches the transcript's WebVTT voice spans.
+  // 'translation' = media with a transcript translated into a language
+  //   ("es"), optionally containing text ("es:hola"; '*' is a wildcard).
   value: string;
   // Per-predicate include (false) / exclude (true).
   exclude: boolean;
@@ -206,15 +206,17 @@ type LibraryState = {
 
 // Predicate types resolved by the embedding backend and returned RANKED with
 // per-item scores (loki_api sortItemsByScore). 'face' ranks by face-embedding
-// cosine exactly like similar/clip/visual rank by SigLIP2 — all of them flip
-// the sort to 'similarity' so the ranking (and the % score badge) shows.
+// cosine exactly like similar/clip/visual rank by SigLIP2, and 'semantic' by
+// best-chunk text similarity — all of them flip the sort to 'similarity' so
+// the ranking (and the % score badge) shows.
 const queryHasVisual = (predicates: Predicate[] = []): boolean =>
   predicates.some(
     (p) =>
       p.type === 'similar' ||
       p.type === 'visual' ||
       p.type === 'clip' ||
-      p.type === 'face'
+      p.type === 'face' ||
+      p.type === 'semantic'
   );
 
 const applySimilaritySort = assign<LibraryState, AnyEventObject>({
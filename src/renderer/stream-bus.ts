@@ -11,6 +11,12 @@
 // subscribers. It also centralizes the zombie watchdog: the server pings every
 // 30s (stream.KeepAliveInterval), so >90s of silence or a CLOSED readyState
 // means the connection is dead and gets rebuilt.
+//
+// /stream needs credentials. EventSource can't set headers, so the bus passes
+// the session token as ?access_token= (a browser on the server's own origin
+// also has the session cookie). Every event carries an id; rebuilds pass the
+// last one as ?lastEventId= so the server replays what was missed, or sends
+// 'resync' when it no longer has it and subscribers should refetch.
 
 import { mediaServerBase } from './platform';
 
@@ -29,12 +35,15 @@ const EVENT_TYPES = [
   'media-created',
   'people-updated',
   'stats',
+  'resync',
 ];
 
 let es: EventSource | null = null;
 let lastActivityAt = Date.now();
 let connected = false;
 let watchdog: number | null = null;
+let authToken: string | null = null;
+let lastEventId = '';
 const listeners = new Set<StreamListener>();
 const statusListeners = new Set<StatusListener>();
 
@@ -53,13 +62,20 @@ function notifyStatus(next: boolean) {
 function open() {
   if (es) return;
   try {
-    es = new EventSource(`${mediaServerBase}/stream`);
+    const params = new URLSearchParams();
+    if (authToken) params.set('access_token', authToken);
+    if (lastEventId) params.set('lastEventId', lastEventId);
+    const query = params.toString();
+    const url = `${mediaServerBase}/stream${query ? `?${query}` : ''}`;
+    es = new EventSource(url);
   } catch {
     es = null;
     return;
   }
   const dispatch = (type: string) => (event: Event) => {
     lastActivityAt = Date.now();
+    const { lastEventId: id } = event as MessageEvent;
+    if (id) lastEventId = id;
     listeners.forEach((cb) => {
       try {
         cb(type, event as MessageEvent);
@@ -121,6 +137,17 @@ function ensureWatchdog() {
   }, 15000);
 }
 
+// Set the token /stream is opened with. A change reconnects (resuming from the
+// last event id) so the feed follows logins and logouts.
+export function setStreamAuthToken(token: string | null) {
+  if (token === authToken) return;
+  authToken = token;
+  if (!es) return;
+  close();
+  lastActivityAt = Date.now();
+  open();
+}
+
 // Subscribe to named /stream events. Returns an unsubscribe function.
 export function subscribeStream(cb: StreamListener): () => void {
   listeners.add(cb);